@@ -13,6 +13,8 @@ import (
 
 	"github.com/spf13/cobra"
 	"github.com/yourusername/trivia-api/bottest/pkg/bot"
+	"github.com/yourusername/trivia-api/bottest/pkg/loadtest"
+	"github.com/yourusername/trivia-api/bottest/pkg/verify"
 )
 
 var (
@@ -36,6 +38,20 @@ var (
 	startUserID uint = 1000
 	// Создать новую викторину
 	createQuiz bool
+	// Максимальное число попыток переподключения бота (0 - без переподключения, -1 - без ограничений)
+	maxReconnects int = 5
+
+	// Параметры нагрузочного теста answer-storm
+	stormConnections  int = 10000
+	stormRamp         int = 500
+	stormTokensFile   string
+	stormRespTimeout  time.Duration = 30 * time.Second
+	stormQuestionWait time.Duration = 10 * time.Minute
+
+	// Параметры проверки итогов verify
+	verifyFixture     string
+	verifyTimeout     time.Duration = 30 * time.Minute
+	verifyResultsWait time.Duration = 30 * time.Second
 )
 
 func main() {
@@ -64,12 +80,45 @@ func main() {
 	runCmd.Flags().IntVar(&correctAnswerRate, "correct-rate", correctAnswerRate, "Процент правильных ответов (0-100)")
 	runCmd.Flags().UintVar(&startUserID, "start-uid", startUserID, "Начальный ID пользователя")
 	runCmd.Flags().BoolVar(&createQuiz, "create", false, "Создать новую викторину")
+	runCmd.Flags().IntVar(&maxReconnects, "max-reconnects", maxReconnects, "Максимум попыток переподключения после обрыва WebSocket (0 - отключено, -1 - без ограничений)")
 
 	// Проверка обязательных параметров
 	runCmd.MarkFlagRequired("token")
 
+	// Команда нагрузочного теста приема ответов
+	stormCmd := &cobra.Command{
+		Use:   "answer-storm",
+		Short: "Отправить ответы со множества подключений одновременно",
+		Long:  "Открывает заданное число WebSocket-подключений и при получении первого вопроса отправляет все ответы разом, проверяя очередь ответов сервера.",
+		Run:   runAnswerStorm,
+	}
+	stormCmd.Flags().StringVar(&baseURL, "url", baseURL, "Базовый URL API (например, http://localhost:8080)")
+	stormCmd.Flags().StringVar(&token, "token", "", "JWT токен для авторизации (используется, если не указан --tokens-file)")
+	stormCmd.Flags().StringVar(&stormTokensFile, "tokens-file", "", "Файл с токенами, по одному на строку")
+	stormCmd.Flags().UintVar(&quizID, "quiz", 0, "ID викторины")
+	stormCmd.Flags().IntVar(&stormConnections, "connections", stormConnections, "Количество одновременных подключений (ответов)")
+	stormCmd.Flags().IntVar(&stormRamp, "ramp", stormRamp, "Сколько подключений открывать в секунду")
+	stormCmd.Flags().DurationVar(&stormRespTimeout, "response-timeout", stormRespTimeout, "Сколько ждать ответов сервера после отправки")
+	stormCmd.Flags().DurationVar(&stormQuestionWait, "question-timeout", stormQuestionWait, "Сколько ждать первого вопроса")
+	stormCmd.MarkFlagRequired("quiz")
+
+	// Команда проверки корректности подсчета итогов
+	verifyCmd := &cobra.Command{
+		Use:   "verify",
+		Short: "Проиграть викторину по сценарию и сверить итоги с ожидаемыми",
+		Long:  "Подключает по одному боту на каждого игрока из сценария, отвечает заранее заданными ответами и сравнивает quiz:final_results и my-result с ожидаемыми очками, местами и выбыванием. При расхождении завершается с ненулевым кодом.",
+		Run:   runVerify,
+	}
+	verifyCmd.Flags().StringVar(&baseURL, "url", baseURL, "Базовый URL API (например, http://localhost:8080)")
+	verifyCmd.Flags().StringVar(&verifyFixture, "fixture", "", "JSON-файл сценария с ожидаемыми итогами")
+	verifyCmd.Flags().DurationVar(&verifyTimeout, "timeout", verifyTimeout, "Сколько ждать окончания викторины")
+	verifyCmd.Flags().DurationVar(&verifyResultsWait, "results-wait", verifyResultsWait, "Сколько ждать quiz:final_results после quiz:end")
+	verifyCmd.MarkFlagRequired("fixture")
+
 	// Добавляем подкоманды к корневой команде
 	rootCmd.AddCommand(runCmd)
+	rootCmd.AddCommand(stormCmd)
+	rootCmd.AddCommand(verifyCmd)
 
 	// Запускаем
 	if err := rootCmd.Execute(); err != nil {
@@ -113,6 +162,7 @@ func runBots(cmd *cobra.Command, args []string) {
 	log.Printf("🤖 Ботов: %d", botCount)
 	log.Printf("⚙️ Стратегия: %s", answerStrategy)
 	log.Printf("⏱️ Задержка: %d-%d мс", minDelayMs, maxDelayMs)
+	log.Printf("🔄 Попыток переподключения: %d", maxReconnects)
 
 	// Если нужно создать викторину, делаем это
 	var createdQuizID uint
@@ -138,6 +188,7 @@ func runBots(cmd *cobra.Command, args []string) {
 			MinDelay:          time.Duration(minDelayMs) * time.Millisecond,
 			MaxDelay:          time.Duration(maxDelayMs) * time.Millisecond,
 			CorrectAnswerRate: correctAnswerRate,
+			MaxReconnects:     maxReconnects,
 		}
 
 		// Каждому боту назначаем свой userID
@@ -193,6 +244,83 @@ func runBots(cmd *cobra.Command, args []string) {
 	wg.Wait()
 }
 
+// runAnswerStorm запускает нагрузочный тест приема ответов
+func runAnswerStorm(cmd *cobra.Command, args []string) {
+	tokens, err := loadTokens()
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+
+	log.Printf("🌩️ Запуск answer-storm: %d подключений, %d токенов, викторина #%d", stormConnections, len(tokens), quizID)
+
+	report, err := loadtest.RunAnswerStorm(loadtest.StormConfig{
+		BaseURL:         baseURL,
+		Tokens:          tokens,
+		QuizID:          quizID,
+		Connections:     stormConnections,
+		RampPerSecond:   stormRamp,
+		ResponseTimeout: stormRespTimeout,
+		QuestionTimeout: stormQuestionWait,
+	})
+	if report != nil {
+		report.Print()
+	}
+	if err != nil {
+		log.Fatalf("❌ Ошибка нагрузочного теста: %v", err)
+	}
+}
+
+// runVerify проигрывает сценарий и завершает процесс с кодом 1 при расхождении итогов
+func runVerify(cmd *cobra.Command, args []string) {
+	fixture, err := verify.LoadFixture(verifyFixture)
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+
+	log.Printf("🔍 Проверка итогов викторины #%d: %d игроков", fixture.QuizID, len(fixture.Personas))
+
+	report, err := verify.Run(verify.Config{
+		BaseURL:     baseURL,
+		Fixture:     fixture,
+		Timeout:     verifyTimeout,
+		ResultsWait: verifyResultsWait,
+	})
+	if err != nil {
+		log.Fatalf("❌ Ошибка проверки: %v", err)
+	}
+
+	report.Print()
+	if !report.OK() {
+		os.Exit(1)
+	}
+}
+
+// loadTokens возвращает токены из файла или единственный токен из --token
+func loadTokens() ([]string, error) {
+	if stormTokensFile == "" {
+		if token == "" {
+			return nil, fmt.Errorf("требуется --token или --tokens-file")
+		}
+		return []string{token}, nil
+	}
+
+	data, err := os.ReadFile(stormTokensFile)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения файла токенов: %w", err)
+	}
+
+	var tokens []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			tokens = append(tokens, line)
+		}
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("файл токенов пуст")
+	}
+	return tokens, nil
+}
+
 // createNewQuiz создает новую викторину и возвращает ее ID
 func createNewQuiz() (uint, error) {
 	// Создаем конфигурацию для бота-создателя
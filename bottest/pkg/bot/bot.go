@@ -34,6 +34,10 @@ type BotStats struct {
 	ServerTimestamps []int64
 	ClientTimestamps []int64
 	AnswerResults    []bool
+	// Успешные переподключения WebSocket
+	Reconnects int
+	// Исчерпание попыток переподключения (бот перестал участвовать)
+	ReconnectFailures int
 }
 
 // BotConfig содержит настройки бота
@@ -46,6 +50,8 @@ type BotConfig struct {
 	MaxDelay time.Duration
 	// Процент правильных ответов (0-100), если стратегия "correct" или "incorrect"
 	CorrectAnswerRate int
+	// Максимальное число попыток переподключения после обрыва соединения (0 - не переподключаться)
+	MaxReconnects int
 }
 
 // NewBot создает нового бота
@@ -68,13 +74,26 @@ func NewBot(baseURL, token string, userID uint, botID int, config *BotConfig) *B
 		AnswerResults:    make([]bool, 0),
 	}
 
-	return &Bot{
+	b := &Bot{
 		Name:   name,
 		Client: quizClient,
 		BotID:  botID,
 		Stats:  stats,
 		Config: config,
 	}
+
+	quizClient.MaxReconnects = config.MaxReconnects
+	quizClient.OnReconnect = func(attempt int) {
+		b.Stats.Reconnects++
+		log.Printf("[%s] 🔄 Переподключение к викторине #%d выполнено (попыток: %d, всего переподключений: %d)",
+			b.Name, b.QuizID, attempt, b.Stats.Reconnects)
+	}
+	quizClient.OnReconnectFailed = func(err error) {
+		b.Stats.ReconnectFailures++
+		log.Printf("[%s] ❌ Бот перестал участвовать в викторине #%d: %v", b.Name, b.QuizID, err)
+	}
+
+	return b
 }
 
 // CreateAndJoinQuiz создает викторину и присоединяется к ней
@@ -144,6 +163,8 @@ func (b *Bot) handleMessage(messageType string, data map[string]interface{}) {
 		log.Printf("[%s] Напоминание о выбывании получено", b.Name)
 	case "quiz:leaderboard":
 		b.handleLeaderboard(data)
+	case "quiz:leaderboard_delta":
+		log.Printf("[%s] Получены изменения таблицы лидеров, версия %v", b.Name, data["version"])
 	case "quiz:end":
 		b.handleQuizEnd(data)
 	case "server:heartbeat":
@@ -239,6 +260,16 @@ func (b *Bot) handleQuizQuestion(data map[string]interface{}) {
 		return
 	}
 
+	// Срок ответа, вычисленный сервером с учетом времени на чтение вопроса.
+	// Старый сервер его не присылает, тогда срок считается от получения вопроса.
+	var endsAtMs, answersOpenAtMs int64
+	if endsAt, ok := data["ends_at_ms"].(float64); ok {
+		endsAtMs = int64(endsAt)
+	}
+	if openAt, ok := data["answers_open_at"].(float64); ok {
+		answersOpenAtMs = int64(openAt)
+	}
+
 	// Текущее время клиента
 	clientReceivedAt := time.Now().UnixNano() / int64(time.Millisecond)
 
@@ -263,7 +294,7 @@ func (b *Bot) handleQuizQuestion(data map[string]interface{}) {
 
 	// Если бот не выбыл, отправляем ответ
 	if !b.IsEliminated {
-		go b.sendRandomAnswerWithStrategy(uint(questionID), options, int64(serverTimestamp), timeLimit)
+		go b.sendRandomAnswerWithStrategy(uint(questionID), options, int64(serverTimestamp), timeLimit, answersOpenAtMs, endsAtMs, timeOffset)
 	} else {
 		log.Printf("[%s] Бот выбыл, не отправляет ответ на вопрос #%d", b.Name, uint(questionID))
 	}
@@ -393,10 +424,18 @@ func (b *Bot) handleQuizEnd(data map[string]interface{}) {
 	log.Printf("[%s] Правильных ответов: %d", b.Name, b.Stats.CorrectAnswers)
 	log.Printf("[%s] Неправильных ответов: %d", b.Name, b.Stats.IncorrectAnswers)
 	log.Printf("[%s] Всего очков: %d", b.Name, b.Stats.TotalPoints)
+	log.Printf("[%s] Переподключений: %d", b.Name, b.Stats.Reconnects)
 }
 
 // sendRandomAnswerWithStrategy отправляет ответ по выбранной стратегии
-func (b *Bot) sendRandomAnswerWithStrategy(questionID uint, options []map[string]interface{}, serverTimestamp int64, timeLimit float64) {
+// answersOpenAtMs и endsAtMs - время сервера (0, если сервер их не прислал),
+// timeOffsetMs - разница между часами бота и сервера.
+func (b *Bot) sendRandomAnswerWithStrategy(questionID uint, options []map[string]interface{}, serverTimestamp int64, timeLimit float64, answersOpenAtMs, endsAtMs, timeOffsetMs int64) {
+	// Во время чтения вопроса ответы не принимаются: ждем открытия приема ответов
+	if answersOpenAtMs > 0 {
+		time.Sleep(time.Until(time.UnixMilli(answersOpenAtMs + timeOffsetMs)))
+	}
+
 	var delay time.Duration
 	var selectedOption int
 
@@ -424,6 +463,10 @@ func (b *Bot) sendRandomAnswerWithStrategy(questionID uint, options []map[string
 	// Проверяем, не слишком ли большая задержка (критический порог - 10 секунд)
 	maxAllowedDelay := time.Duration((timeLimit*1000)-500) * time.Millisecond // -500мс запас
 	criticalThreshold := time.Duration(10000) * time.Millisecond              // 10 секунд - порог выбывания
+	if endsAtMs > 0 {
+		// Срок от сервера не зависит от задержки доставки вопроса
+		maxAllowedDelay = time.Until(time.UnixMilli(endsAtMs+timeOffsetMs)) - 500*time.Millisecond
+	}
 
 	if delay > maxAllowedDelay {
 		log.Printf("[%s] ⚠️ Внимание: задержка %v превышает лимит времени вопроса. Корректируем до %v",
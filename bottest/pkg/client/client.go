@@ -8,18 +8,38 @@ import (
 	"math/rand"
 	"net/http"
 	"net/url"
+	"sync"
 	"time"
 
 	"github.com/gorilla/websocket"
 )
 
+// Параметры экспоненциальной задержки между попытками переподключения
+const (
+	reconnectInitialBackoff = 500 * time.Millisecond
+	reconnectMaxBackoff     = 30 * time.Second
+)
+
 // QuizClient представляет клиента для работы с API викторины
 type QuizClient struct {
 	BaseURL     string
 	AccessToken string
 	UserID      uint
-	conn        *websocket.Conn
-	stopChan    chan struct{}
+	// Максимальное число попыток переподключения подряд после обрыва WebSocket.
+	// 0 отключает переподключение, отрицательное значение - без ограничений.
+	MaxReconnects int
+	// OnReconnect вызывается после каждой успешной попытки переподключения
+	OnReconnect func(attempt int)
+	// OnReconnectFailed вызывается, когда все попытки переподключения исчерпаны
+	OnReconnectFailed func(err error)
+
+	connMu    sync.Mutex
+	conn      *websocket.Conn
+	stopChan  chan struct{}
+	closeOnce sync.Once
+
+	quizID    uint
+	onMessage func(messageType string, data map[string]interface{})
 }
 
 // NewQuizClient создает нового клиента для работы с API викторины
@@ -32,6 +52,12 @@ func NewQuizClient(baseURL string, accessToken string, userID uint) *QuizClient
 	}
 }
 
+// apiError представляет ответ API об ошибке
+type apiError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
 // CreateQuizRequest представляет запрос на создание викторины
 type CreateQuizRequest struct {
 	Title         string    `json:"title"`
@@ -78,11 +104,11 @@ func (c *QuizClient) CreateQuiz(title, description string, scheduledTime time.Ti
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusCreated {
-		var errResp map[string]string
+		var errResp apiError
 		if err := json.NewDecoder(resp.Body).Decode(&errResp); err != nil {
 			return nil, fmt.Errorf("неожиданный статус-код: %d", resp.StatusCode)
 		}
-		return nil, fmt.Errorf("ошибка API: %s", errResp["error"])
+		return nil, fmt.Errorf("ошибка API (%s): %s", errResp.Code, errResp.Message)
 	}
 
 	var quiz Quiz
@@ -134,11 +160,11 @@ func (c *QuizClient) AddQuestions(quizID uint, questions []Question) error {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		var errResp map[string]string
+		var errResp apiError
 		if err := json.NewDecoder(resp.Body).Decode(&errResp); err != nil {
 			return fmt.Errorf("неожиданный статус-код: %d", resp.StatusCode)
 		}
-		return fmt.Errorf("ошибка API: %s", errResp["error"])
+		return fmt.Errorf("ошибка API (%s): %s", errResp.Code, errResp.Message)
 	}
 
 	return nil
@@ -176,29 +202,140 @@ func (c *QuizClient) ScheduleQuiz(quizID uint, scheduledTime time.Time) error {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		var errResp map[string]string
+		var errResp apiError
 		if err := json.NewDecoder(resp.Body).Decode(&errResp); err != nil {
 			return fmt.Errorf("неожиданный статус-код: %d", resp.StatusCode)
 		}
-		return fmt.Errorf("ошибка API: %s", errResp["error"])
+		return fmt.Errorf("ошибка API (%s): %s", errResp.Code, errResp.Message)
 	}
 
 	return nil
 }
 
-// ConnectToQuiz подключается к викторине через WebSocket
+// QuizResult представляет результат пользователя в викторине
+type QuizResult struct {
+	UserID         uint   `json:"user_id"`
+	QuizID         uint   `json:"quiz_id"`
+	Username       string `json:"username"`
+	Score          int    `json:"score"`
+	CorrectAnswers int    `json:"correct_answers"`
+	TotalQuestions int    `json:"total_questions"`
+	Rank           int    `json:"rank"`
+	IsWinner       bool   `json:"is_winner"`
+	IsEliminated   bool   `json:"is_eliminated"`
+	PrizeFund      int    `json:"prize_fund"`
+}
+
+// GetMyResult возвращает результат текущего пользователя в викторине
+func (c *QuizClient) GetMyResult(quizID uint) (*QuizResult, error) {
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/api/quizzes/%d/my-result", c.BaseURL, quizID), nil)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка создания запроса: %w", err)
+	}
+
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.AccessToken))
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка выполнения запроса: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var errResp apiError
+		if err := json.NewDecoder(resp.Body).Decode(&errResp); err != nil {
+			return nil, fmt.Errorf("неожиданный статус-код: %d", resp.StatusCode)
+		}
+		return nil, fmt.Errorf("ошибка API (%s): %s", errResp.Code, errResp.Message)
+	}
+
+	var result QuizResult
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("ошибка декодирования ответа: %w", err)
+	}
+
+	return &result, nil
+}
+
+// WsTicketResponse представляет ответ API с тикетом для WebSocket
+type WsTicketResponse struct {
+	Success bool `json:"success"`
+	Data    struct {
+		Ticket string `json:"ticket"`
+	} `json:"data"`
+}
+
+// FetchWSTicket получает короткоживущий тикет для подключения к WebSocket
+func (c *QuizClient) FetchWSTicket() (string, error) {
+	req, err := http.NewRequest("POST", fmt.Sprintf("%s/api/auth/ws-ticket", c.BaseURL), nil)
+	if err != nil {
+		return "", fmt.Errorf("ошибка создания запроса: %w", err)
+	}
+
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.AccessToken))
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("ошибка выполнения запроса: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("неожиданный статус-код: %d", resp.StatusCode)
+	}
+
+	var ticketResp WsTicketResponse
+	if err := json.NewDecoder(resp.Body).Decode(&ticketResp); err != nil {
+		return "", fmt.Errorf("ошибка декодирования ответа: %w", err)
+	}
+	if ticketResp.Data.Ticket == "" {
+		return "", fmt.Errorf("сервер вернул пустой тикет")
+	}
+
+	return ticketResp.Data.Ticket, nil
+}
+
+// ConnectToQuiz подключается к викторине через WebSocket.
+// При обрыве соединения клиент переподключается согласно MaxReconnects.
 func (c *QuizClient) ConnectToQuiz(quizID uint, onMessage func(messageType string, data map[string]interface{})) error {
+	c.quizID = quizID
+	c.onMessage = onMessage
+
+	if err := c.dial(); err != nil {
+		return err
+	}
+
+	// Запускаем горутину для чтения сообщений
+	go c.readMessages()
+
+	return nil
+}
+
+// dial устанавливает WebSocket-соединение и отправляет сообщение о готовности.
+// Тикет запрашивается заново при каждом подключении, так как он короткоживущий.
+func (c *QuizClient) dial() error {
+	query := ""
+	ticket, err := c.FetchWSTicket()
+	if err != nil {
+		// Старые версии сервера принимали access-токен напрямую
+		log.Printf("[BotClient] Не удалось получить WS-тикет (%v), используем access-токен", err)
+		query = fmt.Sprintf("token=%s", c.AccessToken)
+	} else {
+		query = fmt.Sprintf("ticket=%s", ticket)
+	}
+
 	u := url.URL{
 		Scheme:   "ws",
 		Host:     c.BaseURL[7:], // Удаляем "http://" из начала
 		Path:     "/ws",
-		RawQuery: fmt.Sprintf("token=%s", c.AccessToken),
+		RawQuery: query,
 	}
 
 	log.Printf("[BotClient] Подключение к WebSocket: %s", u.String())
 
-	var err error
-	c.conn, _, err = websocket.DefaultDialer.Dial(u.String(), nil)
+	conn, _, err := websocket.DefaultDialer.Dial(u.String(), nil)
 	if err != nil {
 		return fmt.Errorf("ошибка при подключении к WebSocket: %w", err)
 	}
@@ -209,54 +346,131 @@ func (c *QuizClient) ConnectToQuiz(quizID uint, onMessage func(messageType strin
 	readyMessage := map[string]interface{}{
 		"type": "user:ready",
 		"data": map[string]interface{}{
-			"quiz_id": quizID,
+			"quiz_id": c.quizID,
 		},
 	}
 
-	if err := c.conn.WriteJSON(readyMessage); err != nil {
+	if err := conn.WriteJSON(readyMessage); err != nil {
+		conn.Close()
 		return fmt.Errorf("ошибка при отправке сообщения готовности: %w", err)
 	}
 
-	log.Printf("[BotClient] Отправлено сообщение готовности для викторины #%d", quizID)
-
-	// Запускаем горутину для чтения сообщений
-	go c.readMessages(onMessage)
+	c.connMu.Lock()
+	c.conn = conn
+	c.connMu.Unlock()
 
+	log.Printf("[BotClient] Отправлено сообщение готовности для викторины #%d", c.quizID)
 	return nil
 }
 
-// readMessages читает сообщения из WebSocket
-func (c *QuizClient) readMessages(onMessage func(messageType string, data map[string]interface{})) {
-	defer c.conn.Close()
-
+// readMessages читает сообщения из WebSocket и переподключается при обрыве
+func (c *QuizClient) readMessages() {
 	for {
-		select {
-		case <-c.stopChan:
+		c.connMu.Lock()
+		conn := c.conn
+		c.connMu.Unlock()
+
+		err := c.readLoop(conn)
+		conn.Close()
+
+		if c.isStopped() {
 			log.Printf("[BotClient] Завершение чтения сообщений")
 			return
-		default:
-			// Читаем сообщение
-			_, message, err := c.conn.ReadMessage()
-			if err != nil {
-				log.Printf("[BotClient] Ошибка при чтении сообщения: %v", err)
-				return
-			}
+		}
 
-			// Разбираем JSON
-			var event struct {
-				Type string                 `json:"type"`
-				Data map[string]interface{} `json:"data"`
+		log.Printf("[BotClient] Соединение потеряно: %v", err)
+		if err := c.reconnect(); err != nil {
+			log.Printf("[BotClient] Переподключение не удалось: %v", err)
+			if c.OnReconnectFailed != nil {
+				c.OnReconnectFailed(err)
 			}
+			return
+		}
+	}
+}
+
+// readLoop читает сообщения из соединения до первой ошибки
+func (c *QuizClient) readLoop(conn *websocket.Conn) error {
+	for {
+		// Читаем сообщение
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			return err
+		}
 
-			if err := json.Unmarshal(message, &event); err != nil {
-				log.Printf("[BotClient] Ошибка при разборе JSON: %v", err)
-				continue
+		// Разбираем JSON
+		var event struct {
+			Type string                 `json:"type"`
+			Data map[string]interface{} `json:"data"`
+		}
+
+		if err := json.Unmarshal(message, &event); err != nil {
+			log.Printf("[BotClient] Ошибка при разборе JSON: %v", err)
+			continue
+		}
+
+		// Вызываем обработчик
+		c.onMessage(event.Type, event.Data)
+	}
+}
+
+// reconnect пытается восстановить соединение с экспоненциальной задержкой
+func (c *QuizClient) reconnect() error {
+	if c.MaxReconnects == 0 {
+		return fmt.Errorf("переподключение отключено")
+	}
+
+	backoff := reconnectInitialBackoff
+	var lastErr error
+
+	for attempt := 1; c.MaxReconnects < 0 || attempt <= c.MaxReconnects; attempt++ {
+		// Добавляем случайный разброс, чтобы боты не переподключались одновременно
+		wait := backoff + time.Duration(rand.Int63n(int64(backoff/2)+1))
+		log.Printf("[BotClient] Попытка переподключения %d через %v", attempt, wait)
+
+		select {
+		case <-c.stopChan:
+			return fmt.Errorf("клиент закрыт")
+		case <-time.After(wait):
+		}
+
+		if lastErr = c.dial(); lastErr == nil {
+			log.Printf("[BotClient] Соединение восстановлено после %d попыток", attempt)
+			if c.OnReconnect != nil {
+				c.OnReconnect(attempt)
 			}
+			return nil
+		}
+		log.Printf("[BotClient] Попытка переподключения %d не удалась: %v", attempt, lastErr)
 
-			// Вызываем обработчик
-			onMessage(event.Type, event.Data)
+		backoff *= 2
+		if backoff > reconnectMaxBackoff {
+			backoff = reconnectMaxBackoff
 		}
 	}
+
+	return fmt.Errorf("исчерпаны попытки переподключения (%d): %w", c.MaxReconnects, lastErr)
+}
+
+// isStopped сообщает, был ли клиент закрыт
+func (c *QuizClient) isStopped() bool {
+	select {
+	case <-c.stopChan:
+		return true
+	default:
+		return false
+	}
+}
+
+// writeJSON отправляет сообщение в текущее соединение
+func (c *QuizClient) writeJSON(v interface{}) error {
+	c.connMu.Lock()
+	defer c.connMu.Unlock()
+
+	if c.conn == nil {
+		return fmt.Errorf("нет активного соединения")
+	}
+	return c.conn.WriteJSON(v)
 }
 
 // SendAnswer отправляет ответ на вопрос
@@ -270,7 +484,7 @@ func (c *QuizClient) SendAnswer(questionID uint, selectedOption int) error {
 		},
 	}
 
-	if err := c.conn.WriteJSON(answerMessage); err != nil {
+	if err := c.writeJSON(answerMessage); err != nil {
 		return fmt.Errorf("ошибка при отправке ответа: %w", err)
 	}
 
@@ -297,7 +511,7 @@ func (c *QuizClient) SendAnswerWithServerTime(questionID uint, selectedOption in
 		},
 	}
 
-	if err := c.conn.WriteJSON(answerMessage); err != nil {
+	if err := c.writeJSON(answerMessage); err != nil {
 		return fmt.Errorf("ошибка при отправке ответа: %w", err)
 	}
 
@@ -320,7 +534,12 @@ func (c *QuizClient) SendRandomAnswer(questionID uint, options []map[string]inte
 
 // Close закрывает соединение с сервером
 func (c *QuizClient) Close() {
-	close(c.stopChan)
+	c.closeOnce.Do(func() {
+		close(c.stopChan)
+	})
+
+	c.connMu.Lock()
+	defer c.connMu.Unlock()
 	if c.conn != nil {
 		c.conn.Close()
 	}
@@ -0,0 +1,214 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// testServer выдает тикеты и принимает WebSocket-подключения,
+// запоминая тикеты подключившихся клиентов
+type testServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	issued   int
+	tickets  []string
+	conns    chan *websocket.Conn
+	rejectWS bool
+}
+
+func newTestServer(t *testing.T) *testServer {
+	s := &testServer{conns: make(chan *websocket.Conn, 4)}
+	upgrader := websocket.Upgrader{}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/auth/ws-ticket", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.issued++
+		ticket := fmt.Sprintf("ticket-%d", s.issued)
+		s.mu.Unlock()
+		fmt.Fprintf(w, `{"success":true,"data":{"ticket":%q}}`, ticket)
+	})
+	mux.HandleFunc("/ws", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		reject := s.rejectWS
+		s.tickets = append(s.tickets, r.URL.Query().Get("ticket"))
+		s.mu.Unlock()
+		if reject {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("ошибка upgrade: %v", err)
+			return
+		}
+		// Клиент сразу сообщает о готовности
+		var ready map[string]interface{}
+		if err := conn.ReadJSON(&ready); err != nil || ready["type"] != "user:ready" {
+			t.Errorf("ожидалось user:ready, получено %v (%v)", ready, err)
+		}
+		s.conns <- conn
+	})
+	s.Server = httptest.NewServer(mux)
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *testServer) acceptedConn(t *testing.T) *websocket.Conn {
+	t.Helper()
+	select {
+	case conn := <-s.conns:
+		return conn
+	case <-time.After(5 * time.Second):
+		t.Fatal("клиент не подключился")
+		return nil
+	}
+}
+
+func (s *testServer) setRejectWS(reject bool) {
+	s.mu.Lock()
+	s.rejectWS = reject
+	s.mu.Unlock()
+}
+
+func (s *testServer) dialedTickets() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.tickets...)
+}
+
+func TestQuizClient_ReconnectsWithFreshTicket(t *testing.T) {
+	server := newTestServer(t)
+	c := NewQuizClient(server.URL, "token", 1)
+	c.MaxReconnects = 3
+	reconnected := make(chan int, 1)
+	c.OnReconnect = func(attempt int) { reconnected <- attempt }
+	messages := make(chan string, 4)
+	if err := c.ConnectToQuiz(5, func(messageType string, data map[string]interface{}) {
+		messages <- messageType
+	}); err != nil {
+		t.Fatalf("ошибка подключения: %v", err)
+	}
+	defer c.Close()
+
+	// Сервер обрывает первое соединение
+	server.acceptedConn(t).Close()
+	conn := server.acceptedConn(t)
+	defer conn.Close()
+
+	select {
+	case attempt := <-reconnected:
+		if attempt != 1 {
+			t.Errorf("ожидалась первая попытка, получено %d", attempt)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("OnReconnect не вызван")
+	}
+	if got := strings.Join(server.dialedTickets(), ","); got != "ticket-1,ticket-2" {
+		t.Errorf("каждое подключение должно использовать новый тикет, получено %s", got)
+	}
+
+	// Новое соединение используется для чтения и отправки
+	if err := conn.WriteJSON(map[string]interface{}{"type": "quiz:start", "data": map[string]interface{}{}}); err != nil {
+		t.Fatalf("ошибка отправки: %v", err)
+	}
+	select {
+	case messageType := <-messages:
+		if messageType != "quiz:start" {
+			t.Errorf("ожидалось quiz:start, получено %s", messageType)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("сообщение после переподключения не получено")
+	}
+	if err := c.SendAnswer(10, 2); err != nil {
+		t.Fatalf("ошибка отправки ответа: %v", err)
+	}
+	var answer map[string]interface{}
+	if err := conn.ReadJSON(&answer); err != nil || answer["type"] != "user:answer" {
+		t.Errorf("ожидался user:answer, получено %v (%v)", answer, err)
+	}
+}
+
+func TestQuizClient_ReconnectAttemptsExhausted(t *testing.T) {
+	server := newTestServer(t)
+	c := NewQuizClient(server.URL, "token", 1)
+	c.MaxReconnects = 1
+	failed := make(chan error, 1)
+	c.OnReconnectFailed = func(err error) { failed <- err }
+	if err := c.ConnectToQuiz(5, func(string, map[string]interface{}) {}); err != nil {
+		t.Fatalf("ошибка подключения: %v", err)
+	}
+	defer c.Close()
+
+	server.setRejectWS(true)
+	server.acceptedConn(t).Close()
+
+	select {
+	case err := <-failed:
+		if !strings.Contains(err.Error(), "исчерпаны попытки переподключения (1)") {
+			t.Errorf("неожиданная ошибка: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("OnReconnectFailed не вызван")
+	}
+	if got := len(server.dialedTickets()); got != 2 {
+		t.Errorf("ожидалось 2 подключения, получено %d", got)
+	}
+}
+
+func TestQuizClient_ReconnectDisabled(t *testing.T) {
+	server := newTestServer(t)
+	c := NewQuizClient(server.URL, "token", 1)
+	failed := make(chan error, 1)
+	c.OnReconnectFailed = func(err error) { failed <- err }
+	if err := c.ConnectToQuiz(5, func(string, map[string]interface{}) {}); err != nil {
+		t.Fatalf("ошибка подключения: %v", err)
+	}
+	defer c.Close()
+
+	server.acceptedConn(t).Close()
+	select {
+	case err := <-failed:
+		if !strings.Contains(err.Error(), "переподключение отключено") {
+			t.Errorf("неожиданная ошибка: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("OnReconnectFailed не вызван")
+	}
+}
+
+func TestQuizClient_CloseStopsReconnect(t *testing.T) {
+	server := newTestServer(t)
+	c := NewQuizClient(server.URL, "token", 1)
+	c.MaxReconnects = -1
+	failed := make(chan error, 1)
+	c.OnReconnectFailed = func(err error) { failed <- err }
+	if err := c.ConnectToQuiz(5, func(string, map[string]interface{}) {}); err != nil {
+		t.Fatalf("ошибка подключения: %v", err)
+	}
+
+	// Закрытие клиента во время ожидания переподключения прерывает попытки
+	server.setRejectWS(true)
+	server.acceptedConn(t).Close()
+	time.Sleep(50 * time.Millisecond)
+	c.Close()
+
+	select {
+	case err := <-failed:
+		if !strings.Contains(err.Error(), "клиент закрыт") {
+			t.Errorf("неожиданная ошибка: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("переподключение не остановлено")
+	}
+	if got := len(server.dialedTickets()); got != 1 {
+		t.Errorf("после закрытия клиент не должен подключаться, подключений: %d", got)
+	}
+}
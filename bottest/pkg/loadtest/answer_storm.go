@@ -0,0 +1,213 @@
+package loadtest
+
+import (
+	"fmt"
+	"log"
+	"math/rand"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/yourusername/trivia-api/bottest/pkg/client"
+)
+
+// StormConfig содержит настройки нагрузочного теста приема ответов
+type StormConfig struct {
+	BaseURL string
+	// Токены для подключений. Если токенов меньше, чем подключений,
+	// они используются по кругу (ответы одного пользователя сервер считает дубликатами).
+	Tokens []string
+	QuizID uint
+	// Количество одновременных подключений (и, соответственно, ответов)
+	Connections int
+	// Сколько подключений открывать в секунду
+	RampPerSecond int
+	// Сколько ждать ответов сервера после отправки
+	ResponseTimeout time.Duration
+	// Сколько ждать первого вопроса
+	QuestionTimeout time.Duration
+}
+
+// StormReport содержит итоги нагрузочного теста
+type StormReport struct {
+	Connected     int64
+	ConnectErrors int64
+	AnswersSent   int64
+	SendErrors    int64
+	Results       int64 // quiz:answer_result
+	Queued        int64 // quiz:answer_received (сброс нагрузки на сервере)
+	ServerErrors  int64 // server:error
+	NoResponse    int64
+	Latencies     []time.Duration
+}
+
+// stormConn хранит состояние одного подключения
+type stormConn struct {
+	client   *client.QuizClient
+	sentAt   atomic.Int64 // UnixNano отправки ответа
+	answered atomic.Bool  // Получен первый ответ сервера
+}
+
+// RunAnswerStorm открывает заданное число подключений к викторине и, как только
+// приходит первый вопрос, все подключения одновременно отправляют ответ.
+// Позволяет проверить поведение очереди ответов сервера под пиковой нагрузкой.
+func RunAnswerStorm(cfg StormConfig) (*StormReport, error) {
+	if len(cfg.Tokens) == 0 {
+		return nil, fmt.Errorf("требуется хотя бы один токен")
+	}
+	if cfg.Connections <= 0 {
+		return nil, fmt.Errorf("количество подключений должно быть положительным")
+	}
+	if cfg.RampPerSecond <= 0 {
+		cfg.RampPerSecond = cfg.Connections
+	}
+
+	report := &StormReport{}
+	conns := make([]*stormConn, cfg.Connections)
+
+	var latMu sync.Mutex
+	var questionOnce sync.Once
+	questionCh := make(chan struct{})
+
+	var (
+		questionID float64
+		optionsLen int
+	)
+
+	onResponse := func(sc *stormConn, counter *int64) {
+		atomic.AddInt64(counter, 1)
+		if sc.answered.CompareAndSwap(false, true) {
+			if sent := sc.sentAt.Load(); sent > 0 {
+				latMu.Lock()
+				report.Latencies = append(report.Latencies, time.Since(time.Unix(0, sent)))
+				latMu.Unlock()
+			}
+		}
+	}
+
+	// Открываем подключения с заданной скоростью
+	interval := time.Second / time.Duration(cfg.RampPerSecond)
+	for i := 0; i < cfg.Connections; i++ {
+		sc := &stormConn{
+			client: client.NewQuizClient(cfg.BaseURL, cfg.Tokens[i%len(cfg.Tokens)], uint(i+1)),
+		}
+		conns[i] = sc
+
+		err := sc.client.ConnectToQuiz(cfg.QuizID, func(messageType string, data map[string]interface{}) {
+			switch messageType {
+			case "quiz:question":
+				questionOnce.Do(func() {
+					questionID, _ = data["question_id"].(float64)
+					if opts, ok := data["options"].([]interface{}); ok {
+						optionsLen = len(opts)
+					}
+					close(questionCh)
+				})
+			case "quiz:answer_result":
+				onResponse(sc, &report.Results)
+			case "quiz:answer_received":
+				onResponse(sc, &report.Queued)
+			case "server:error":
+				onResponse(sc, &report.ServerErrors)
+			}
+		})
+		if err != nil {
+			atomic.AddInt64(&report.ConnectErrors, 1)
+			conns[i] = nil
+			continue
+		}
+		atomic.AddInt64(&report.Connected, 1)
+
+		if (i+1)%1000 == 0 {
+			log.Printf("[AnswerStorm] Открыто подключений: %d/%d", i+1, cfg.Connections)
+		}
+		time.Sleep(interval)
+	}
+
+	defer func() {
+		for _, sc := range conns {
+			if sc != nil {
+				sc.client.Close()
+			}
+		}
+	}()
+
+	log.Printf("[AnswerStorm] Подключено: %d, ошибок: %d. Ожидание вопроса...", report.Connected, report.ConnectErrors)
+
+	select {
+	case <-questionCh:
+	case <-time.After(cfg.QuestionTimeout):
+		return report, fmt.Errorf("вопрос не получен за %v", cfg.QuestionTimeout)
+	}
+
+	if optionsLen == 0 {
+		optionsLen = 1
+	}
+	log.Printf("[AnswerStorm] Получен вопрос #%d, отправка %d ответов одновременно", uint(questionID), report.Connected)
+
+	// Отправляем все ответы одновременно
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for _, sc := range conns {
+		if sc == nil {
+			continue
+		}
+		wg.Add(1)
+		go func(sc *stormConn) {
+			defer wg.Done()
+			<-start
+			sc.sentAt.Store(time.Now().UnixNano())
+			if err := sc.client.SendAnswer(uint(questionID), rand.Intn(optionsLen)+1); err != nil {
+				atomic.AddInt64(&report.SendErrors, 1)
+				return
+			}
+			atomic.AddInt64(&report.AnswersSent, 1)
+		}(sc)
+	}
+	close(start)
+	wg.Wait()
+
+	// Ждем ответов сервера
+	deadline := time.Now().Add(cfg.ResponseTimeout)
+	for time.Now().Before(deadline) {
+		responded := atomic.LoadInt64(&report.Results) + atomic.LoadInt64(&report.Queued) + atomic.LoadInt64(&report.ServerErrors)
+		if responded >= report.AnswersSent {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	for _, sc := range conns {
+		if sc != nil && sc.sentAt.Load() > 0 && !sc.answered.Load() {
+			report.NoResponse++
+		}
+	}
+
+	return report, nil
+}
+
+// Percentile возвращает перцентиль задержки первого ответа сервера
+func (r *StormReport) Percentile(p float64) time.Duration {
+	if len(r.Latencies) == 0 {
+		return 0
+	}
+	sorted := make([]time.Duration, len(r.Latencies))
+	copy(sorted, r.Latencies)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	idx := int(float64(len(sorted)-1) * p)
+	return sorted[idx]
+}
+
+// Print выводит итоги теста в лог
+func (r *StormReport) Print() {
+	log.Printf("📊 Итоги answer-storm:")
+	log.Printf("   Подключено: %d (ошибок подключения: %d)", r.Connected, r.ConnectErrors)
+	log.Printf("   Отправлено ответов: %d (ошибок отправки: %d)", r.AnswersSent, r.SendErrors)
+	log.Printf("   quiz:answer_result: %d", r.Results)
+	log.Printf("   quiz:answer_received (отложено сервером): %d", r.Queued)
+	log.Printf("   server:error: %d", r.ServerErrors)
+	log.Printf("   Без ответа: %d", r.NoResponse)
+	log.Printf("   Задержка p50=%v p95=%v p99=%v", r.Percentile(0.50), r.Percentile(0.95), r.Percentile(0.99))
+}
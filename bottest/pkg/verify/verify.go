@@ -0,0 +1,296 @@
+package verify
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/yourusername/trivia-api/bottest/pkg/client"
+)
+
+// Fixture описывает сценарий проверки: викторину, сценарии игроков и ожидаемые итоги
+type Fixture struct {
+	QuizID   uint      `json:"quiz_id"`
+	Personas []Persona `json:"personas"`
+}
+
+// Persona - игрок с заранее заданными ответами
+type Persona struct {
+	Name    string           `json:"name"`
+	Token   string           `json:"token"`
+	Answers []ScriptedAnswer `json:"answers"`
+	// Expected содержит только проверяемые поля; незаданные поля не сравниваются
+	Expected Expectation `json:"expected"`
+}
+
+// ScriptedAnswer задает ответ на вопрос по его номеру в викторине (с 1).
+// Вопросы без ответа в сценарии пропускаются.
+type ScriptedAnswer struct {
+	Question int `json:"question"`
+	Option   int `json:"option"`
+	DelayMs  int `json:"delay_ms"`
+}
+
+// Expectation - ожидаемый итог игрока
+type Expectation struct {
+	Score          *int  `json:"score,omitempty"`
+	CorrectAnswers *int  `json:"correct_answers,omitempty"`
+	Rank           *int  `json:"rank,omitempty"`
+	IsEliminated   *bool `json:"is_eliminated,omitempty"`
+	IsWinner       *bool `json:"is_winner,omitempty"`
+}
+
+// Mismatch - расхождение итогов сервера с ожидаемыми
+type Mismatch struct {
+	Persona  string
+	Source   string // "my-result" или "quiz:final_results"
+	Field    string
+	Expected interface{}
+	Actual   interface{}
+}
+
+// Report содержит результаты проверки
+type Report struct {
+	Personas   int
+	Mismatches []Mismatch
+}
+
+// Config содержит настройки проверки
+type Config struct {
+	BaseURL string
+	Fixture *Fixture
+	// Сколько ждать окончания викторины и итоговых результатов
+	Timeout time.Duration
+	// Сколько ждать quiz:final_results после quiz:end
+	ResultsWait time.Duration
+}
+
+// LoadFixture читает и проверяет файл сценария
+func LoadFixture(path string) (*Fixture, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения сценария: %w", err)
+	}
+
+	var fixture Fixture
+	if err := json.Unmarshal(data, &fixture); err != nil {
+		return nil, fmt.Errorf("ошибка разбора сценария: %w", err)
+	}
+	if fixture.QuizID == 0 {
+		return nil, fmt.Errorf("в сценарии не указан quiz_id")
+	}
+	if len(fixture.Personas) == 0 {
+		return nil, fmt.Errorf("в сценарии нет игроков")
+	}
+	for i, p := range fixture.Personas {
+		if p.Name == "" {
+			fixture.Personas[i].Name = fmt.Sprintf("persona-%d", i+1)
+		}
+		if p.Token == "" {
+			return nil, fmt.Errorf("у игрока %s не указан token", fixture.Personas[i].Name)
+		}
+	}
+
+	return &fixture, nil
+}
+
+// player хранит состояние подключения одного игрока
+type player struct {
+	persona *Persona
+	client  *client.QuizClient
+	ended   chan struct{}
+	endOnce sync.Once
+}
+
+// Run проигрывает сценарий и сравнивает итоги сервера с ожидаемыми.
+// Ошибка возвращается, только если проверку не удалось провести.
+func Run(cfg Config) (*Report, error) {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 30 * time.Minute
+	}
+	if cfg.ResultsWait <= 0 {
+		cfg.ResultsWait = 30 * time.Second
+	}
+
+	fixture := cfg.Fixture
+	var (
+		finalMu      sync.Mutex
+		finalResults map[string]interface{}
+	)
+	finalCh := make(chan struct{})
+	var finalOnce sync.Once
+
+	players := make([]*player, len(fixture.Personas))
+	defer func() {
+		for _, p := range players {
+			if p != nil {
+				p.client.Close()
+			}
+		}
+	}()
+
+	for i := range fixture.Personas {
+		p := &player{
+			persona: &fixture.Personas[i],
+			client:  client.NewQuizClient(cfg.BaseURL, fixture.Personas[i].Token, uint(i+1)),
+			ended:   make(chan struct{}),
+		}
+		players[i] = p
+
+		err := p.client.ConnectToQuiz(fixture.QuizID, func(messageType string, data map[string]interface{}) {
+			// Повтор викторины администратором не влияет на результаты
+			if replay, _ := data["replay"].(bool); replay {
+				return
+			}
+			switch messageType {
+			case "quiz:question":
+				p.answer(data)
+			case "quiz:end":
+				p.endOnce.Do(func() { close(p.ended) })
+			case "quiz:final_results":
+				finalOnce.Do(func() {
+					finalMu.Lock()
+					finalResults = data
+					finalMu.Unlock()
+					close(finalCh)
+				})
+			}
+		})
+		if err != nil {
+			return nil, fmt.Errorf("игрок %s не подключился: %w", p.persona.Name, err)
+		}
+	}
+
+	log.Printf("[Verify] Подключено игроков: %d. Ожидание викторины #%d...", len(players), fixture.QuizID)
+
+	deadline := time.After(cfg.Timeout)
+	for _, p := range players {
+		select {
+		case <-p.ended:
+		case <-deadline:
+			return nil, fmt.Errorf("викторина не завершилась за %v", cfg.Timeout)
+		}
+	}
+
+	report := &Report{Personas: len(players)}
+
+	select {
+	case <-finalCh:
+	case <-time.After(cfg.ResultsWait):
+		log.Printf("[Verify] Событие quiz:final_results не получено за %v", cfg.ResultsWait)
+	}
+	finalMu.Lock()
+	standings := standingsByUser(finalResults)
+	finalMu.Unlock()
+
+	for _, p := range players {
+		result, err := p.client.GetMyResult(fixture.QuizID)
+		if err != nil {
+			report.add(p.persona.Name, "my-result", "result", "получен", err.Error())
+			continue
+		}
+		report.compare(p.persona, "my-result", result)
+
+		if finalResults == nil {
+			report.add(p.persona.Name, "quiz:final_results", "event", "получено", "не получено")
+			continue
+		}
+		standing, ok := standings[result.UserID]
+		if !ok {
+			report.add(p.persona.Name, "quiz:final_results", "standing", "присутствует", "отсутствует")
+			continue
+		}
+		report.compare(p.persona, "quiz:final_results", standing)
+	}
+
+	return report, nil
+}
+
+// answer отправляет ответ по сценарию, если он задан для этого вопроса
+func (p *player) answer(data map[string]interface{}) {
+	number, _ := data["number"].(float64)
+	questionID, _ := data["question_id"].(float64)
+	for _, a := range p.persona.Answers {
+		if a.Question != int(number) {
+			continue
+		}
+		go func(a ScriptedAnswer) {
+			time.Sleep(time.Duration(a.DelayMs) * time.Millisecond)
+			if err := p.client.SendAnswer(uint(questionID), a.Option); err != nil {
+				log.Printf("[Verify] %s: ошибка отправки ответа на вопрос %d: %v", p.persona.Name, a.Question, err)
+			}
+		}(a)
+		return
+	}
+}
+
+// standingsByUser раскладывает итоговую таблицу по ID пользователя
+func standingsByUser(finalResults map[string]interface{}) map[uint]*client.QuizResult {
+	standings := make(map[uint]*client.QuizResult)
+	raw, ok := finalResults["standings"]
+	if !ok {
+		return standings
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return standings
+	}
+	var list []client.QuizResult
+	if err := json.Unmarshal(data, &list); err != nil {
+		return standings
+	}
+	for i := range list {
+		standings[list[i].UserID] = &list[i]
+	}
+	return standings
+}
+
+// compare сверяет заданные в сценарии поля с фактическим результатом
+func (r *Report) compare(persona *Persona, source string, actual *client.QuizResult) {
+	exp := persona.Expected
+	if exp.Score != nil && *exp.Score != actual.Score {
+		r.add(persona.Name, source, "score", *exp.Score, actual.Score)
+	}
+	if exp.CorrectAnswers != nil && *exp.CorrectAnswers != actual.CorrectAnswers {
+		r.add(persona.Name, source, "correct_answers", *exp.CorrectAnswers, actual.CorrectAnswers)
+	}
+	if exp.Rank != nil && *exp.Rank != actual.Rank {
+		r.add(persona.Name, source, "rank", *exp.Rank, actual.Rank)
+	}
+	if exp.IsEliminated != nil && *exp.IsEliminated != actual.IsEliminated {
+		r.add(persona.Name, source, "is_eliminated", *exp.IsEliminated, actual.IsEliminated)
+	}
+	if exp.IsWinner != nil && *exp.IsWinner != actual.IsWinner {
+		r.add(persona.Name, source, "is_winner", *exp.IsWinner, actual.IsWinner)
+	}
+}
+
+func (r *Report) add(persona, source, field string, expected, actual interface{}) {
+	r.Mismatches = append(r.Mismatches, Mismatch{
+		Persona:  persona,
+		Source:   source,
+		Field:    field,
+		Expected: expected,
+		Actual:   actual,
+	})
+}
+
+// OK сообщает, совпали ли итоги сервера с ожидаемыми
+func (r *Report) OK() bool {
+	return len(r.Mismatches) == 0
+}
+
+// Print выводит итоги проверки в лог
+func (r *Report) Print() {
+	if r.OK() {
+		log.Printf("✅ Итоги совпали с ожидаемыми для всех игроков (%d)", r.Personas)
+		return
+	}
+	log.Printf("❌ Найдено расхождений: %d", len(r.Mismatches))
+	for _, m := range r.Mismatches {
+		log.Printf("   %s [%s] %s: ожидалось %v, получено %v", m.Persona, m.Source, m.Field, m.Expected, m.Actual)
+	}
+}
@@ -0,0 +1,200 @@
+package verify
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func writeFixture(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "fixture.json")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("ошибка записи сценария: %v", err)
+	}
+	return path
+}
+
+func TestLoadFixture(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{"некорректный JSON", `{`, "ошибка разбора сценария"},
+		{"без викторины", `{"personas":[{"token":"a"}]}`, "не указан quiz_id"},
+		{"без игроков", `{"quiz_id":1}`, "нет игроков"},
+		{"без токена", `{"quiz_id":1,"personas":[{"name":"alice"}]}`, "у игрока alice не указан token"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadFixture(writeFixture(t, tt.content))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ожидалась ошибка %q, получено %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	fixture, err := LoadFixture(writeFixture(t, `{"quiz_id":3,"personas":[{"name":"alice","token":"a"},{"token":"b"}]}`))
+	if err != nil {
+		t.Fatalf("ошибка загрузки сценария: %v", err)
+	}
+	if fixture.Personas[0].Name != "alice" || fixture.Personas[1].Name != "persona-2" {
+		t.Errorf("неожиданные имена игроков: %s, %s", fixture.Personas[0].Name, fixture.Personas[1].Name)
+	}
+
+	if _, err := LoadFixture(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("ожидалась ошибка чтения отсутствующего файла")
+	}
+}
+
+// quizServer проводит викторину из одного вопроса для каждого подключения
+// и отдает заданные итоги по токену игрока
+type quizServer struct {
+	mu      sync.Mutex
+	answers map[string]int // Токен -> выбранный вариант
+	results map[string]map[string]interface{}
+}
+
+func (s *quizServer) handler(t *testing.T) http.Handler {
+	upgrader := websocket.Upgrader{}
+	standings := make([]map[string]interface{}, 0, len(s.results))
+	for _, result := range s.results {
+		standings = append(standings, result)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/auth/ws-ticket", func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		fmt.Fprintf(w, `{"success":true,"data":{"ticket":%q}}`, token)
+	})
+	mux.HandleFunc("/api/quizzes/7/my-result", func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		json.NewEncoder(w).Encode(s.results[token])
+	})
+	mux.HandleFunc("/ws", func(w http.ResponseWriter, r *http.Request) {
+		token := r.URL.Query().Get("ticket")
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("ошибка upgrade: %v", err)
+			return
+		}
+		defer conn.Close()
+
+		var msg struct {
+			Type string                 `json:"type"`
+			Data map[string]interface{} `json:"data"`
+		}
+		if err := conn.ReadJSON(&msg); err != nil || msg.Type != "user:ready" {
+			t.Errorf("ожидалось user:ready, получено %s (%v)", msg.Type, err)
+			return
+		}
+
+		send := func(eventType string, data map[string]interface{}) {
+			conn.WriteJSON(map[string]interface{}{"type": eventType, "data": data})
+		}
+		// Повтор викторины игнорируется
+		send("quiz:question", map[string]interface{}{"number": 1, "question_id": 99, "replay": true})
+		send("quiz:question", map[string]interface{}{"number": 1, "question_id": 11})
+
+		conn.SetReadDeadline(time.Now().Add(200 * time.Millisecond))
+		if err := conn.ReadJSON(&msg); err == nil && msg.Type == "user:answer" {
+			if questionID, _ := msg.Data["question_id"].(float64); questionID != 11 {
+				t.Errorf("ответ на неожиданный вопрос %v", questionID)
+			}
+			option, _ := msg.Data["selected_option"].(float64)
+			s.mu.Lock()
+			s.answers[token] = int(option)
+			s.mu.Unlock()
+		}
+
+		send("quiz:end", map[string]interface{}{})
+		send("quiz:final_results", map[string]interface{}{"standings": standings})
+		// Держим соединение, пока клиент его не закроет (после таймаута чтения - сразу)
+		conn.ReadMessage()
+	})
+	return mux
+}
+
+func intPtr(v int) *int    { return &v }
+func boolPtr(v bool) *bool { return &v }
+
+func TestRun_ComparesResults(t *testing.T) {
+	qs := &quizServer{
+		answers: make(map[string]int),
+		results: map[string]map[string]interface{}{
+			"a": {"user_id": 1, "score": 10, "correct_answers": 1, "rank": 1, "is_winner": true},
+			"b": {"user_id": 2, "score": 0, "correct_answers": 0, "rank": 2},
+		},
+	}
+	server := httptest.NewServer(qs.handler(t))
+	defer server.Close()
+
+	fixture := &Fixture{
+		QuizID: 7,
+		Personas: []Persona{
+			{
+				Name:     "alice",
+				Token:    "a",
+				Answers:  []ScriptedAnswer{{Question: 1, Option: 2}},
+				Expected: Expectation{Score: intPtr(10), Rank: intPtr(1), IsWinner: boolPtr(true)},
+			},
+			{
+				// Игрок без ответов: ожидаемый счет расходится с фактическим
+				Name:     "bob",
+				Token:    "b",
+				Expected: Expectation{Score: intPtr(5), IsEliminated: boolPtr(false)},
+			},
+		},
+	}
+
+	report, err := Run(Config{BaseURL: server.URL, Fixture: fixture, Timeout: 5 * time.Second, ResultsWait: time.Second})
+	if err != nil {
+		t.Fatalf("ошибка проверки: %v", err)
+	}
+
+	qs.mu.Lock()
+	answers := qs.answers
+	qs.mu.Unlock()
+	if len(answers) != 1 || answers["a"] != 2 {
+		t.Errorf("ответы должны отправляться только по сценарию, получено %v", answers)
+	}
+
+	if report.OK() || report.Personas != 2 {
+		t.Fatalf("ожидались расхождения для 2 игроков, получено %+v", report)
+	}
+	want := []Mismatch{
+		{Persona: "bob", Source: "my-result", Field: "score", Expected: 5, Actual: 0},
+		{Persona: "bob", Source: "quiz:final_results", Field: "score", Expected: 5, Actual: 0},
+	}
+	if fmt.Sprint(report.Mismatches) != fmt.Sprint(want) {
+		t.Errorf("ожидались расхождения %v, получено %v", want, report.Mismatches)
+	}
+}
+
+func TestStandingsByUser(t *testing.T) {
+	standings := standingsByUser(map[string]interface{}{
+		"standings": []interface{}{
+			map[string]interface{}{"user_id": float64(3), "score": float64(20)},
+		},
+	})
+	if len(standings) != 1 || standings[3].Score != 20 {
+		t.Errorf("неожиданная таблица: %v", standings)
+	}
+
+	if got := standingsByUser(nil); len(got) != 0 {
+		t.Errorf("без итогов таблица должна быть пустой, получено %v", got)
+	}
+	if got := standingsByUser(map[string]interface{}{"standings": "broken"}); len(got) != 0 {
+		t.Errorf("некорректная таблица должна игнорироваться, получено %v", got)
+	}
+}
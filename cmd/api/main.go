@@ -12,6 +12,8 @@ import (
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/yourusername/trivia-api/internal/config"
+	"github.com/yourusername/trivia-api/internal/domain/entity"
+	"github.com/yourusername/trivia-api/internal/domain/repository"
 	"github.com/yourusername/trivia-api/internal/handler"
 	"github.com/yourusername/trivia-api/internal/middleware"
 	pgRepo "github.com/yourusername/trivia-api/internal/repository/postgres"
@@ -21,6 +23,8 @@ import (
 	"github.com/yourusername/trivia-api/pkg/auth"
 	"github.com/yourusername/trivia-api/pkg/auth/manager"
 	"github.com/yourusername/trivia-api/pkg/database"
+	"github.com/yourusername/trivia-api/pkg/geoip"
+	"github.com/yourusername/trivia-api/pkg/moderation"
 )
 
 func main() {
@@ -37,7 +41,7 @@ func main() {
 	}
 
 	// Инициализируем подключение к PostgreSQL
-	db, err := database.NewPostgresDB(cfg.Database.PostgresConnectionString())
+	db, err := database.NewPostgresDB(cfg.Database)
 	if err != nil {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
@@ -65,7 +69,14 @@ func main() {
 	quizRepo := pgRepo.NewQuizRepo(db)
 	questionRepo := pgRepo.NewQuestionRepo(db)
 	resultRepo := pgRepo.NewResultRepo(db)
-	cacheRepo := redisRepo.NewCacheRepo(redisClient)
+	tournamentRepo := pgRepo.NewTournamentRepo(db)
+	var cacheRepo repository.CacheRepository = redisRepo.NewCacheRepo(redisClient)
+	// Локальный резерв критичных ключей на время кратковременной недоступности Redis
+	var cacheFallback *redisRepo.FallbackCacheRepo
+	if cfg.Redis.FallbackCacheSize > 0 {
+		cacheFallback = redisRepo.NewFallbackCacheRepo(cacheRepo, cfg.Redis.FallbackCacheSize, cfg.Redis.FallbackKeyPatterns)
+		cacheRepo = cacheFallback
+	}
 
 	// Инициализируем репозиторий для инвалидированных токенов
 	invalidTokenRepo := pgRepo.NewInvalidTokenRepo(db)
@@ -73,18 +84,48 @@ func main() {
 	// Инициализируем репозиторий для refresh-токенов
 	refreshTokenRepo := pgRepo.NewRefreshTokenRepo(db)
 
+	// Инициализируем репозиторий истории метрик WebSocket
+	wsMetricsRepo := pgRepo.NewWSMetricsRepo(db)
+	wsAlertRepo := pgRepo.NewWSAlertRepo(db)
+
 	// Создаем JWT сервис с поддержкой персистентного хранения инвалидированных токенов
 	jwtService := auth.NewJWTService(cfg.JWT.Secret, cfg.JWT.ExpirationHrs, invalidTokenRepo, cfg.JWT.WSTicketExpirySec, cfg.JWT.CleanupInterval)
+	if cfg.JWT.Algorithm == auth.AlgorithmRS256 || cfg.JWT.Algorithm == auth.AlgorithmES256 {
+		privateKey, err := os.ReadFile(cfg.JWT.PrivateKeyFile)
+		if err != nil {
+			log.Fatalf("Failed to read JWT private key: %v", err)
+		}
+		if err := jwtService.EnableAsymmetricSigning(cfg.JWT.Algorithm, privateKey); err != nil {
+			log.Fatalf("Failed to configure JWT signing: %v", err)
+		}
+	}
+	if err := jwtService.SetInvalidationFailMode(cfg.Auth.InvalidationFailMode); err != nil {
+		log.Fatalf("Failed to configure token invalidation check: %v", err)
+	}
+	// Использованные WS-тикеты храним в Redis, чтобы тикет был одноразовым во всем кластере
+	jwtService.SetWSTicketStore(cacheRepo)
 
 	// Создаем TokenManager
 	tokenManager := manager.NewTokenManager(jwtService, refreshTokenRepo, userRepo)
 	tokenManager.SetAccessTokenExpiry(time.Duration(cfg.JWT.ExpirationHrs) * time.Hour)          // Используем значение из конфига
 	tokenManager.SetRefreshTokenExpiry(time.Duration(cfg.Auth.RefreshTokenLifetime) * time.Hour) // Используем значение из конфига
 	tokenManager.SetMaxRefreshTokensPerUser(cfg.Auth.SessionLimit)                               // Используем значение из конфига
+	tokenManager.SetSessionLimitPolicy(cfg.Auth.SessionLimitPolicy)                              // Вытеснение старых сессий или отказ во входе
+	tokenManager.SetMaxCSRFTokensPerUser(cfg.Auth.CSRFTokensPerUser)                             // Лимит CSRF токенов пользователя в памяти
+	tokenManager.SetMaxSessionLifetime(time.Duration(cfg.Auth.MaxSessionLifetime) * time.Hour)   // Абсолютный срок сессии (0 - без ограничения)
 	tokenManager.SetProductionMode(gin.Mode() == gin.ReleaseMode)                                // Устанавливаем режим для Secure кук
 
+	// Геолокация сессий и подключений по IP. По умолчанию выключена; для включения
+	// подставьте реализацию geoip.Provider (например, на базе MaxMind GeoLite2).
+	var geoProvider geoip.Provider = geoip.NoopProvider{}
+	tokenManager.SetGeoProvider(geoProvider)
+
 	// Передаем TokenManager в AuthService
 	authService := service.NewAuthService(userRepo, jwtService, tokenManager, refreshTokenRepo, invalidTokenRepo)
+	if cfg.Moderation.NameFilterEnabled {
+		authService.SetNameFilter(moderation.NewNameFilter(cfg.Moderation.Blocklist, cfg.Moderation.Allowlist))
+		log.Printf("Фильтр имен пользователей включен (%d запрещенных слов)", len(cfg.Moderation.Blocklist))
+	}
 
 	// Создаем контекст с отменой для корректного завершения работы горутин
 	ctx, cancel := context.WithCancel(context.Background())
@@ -113,31 +154,46 @@ func main() {
 		}
 	}()
 
+	// Очистка старых викторин и результатов
+	retentionService := service.NewRetentionService(quizRepo, resultRepo, service.RetentionOptions{
+		RetentionDays: cfg.Retention.RetentionDays,
+		Interval:      time.Duration(cfg.Retention.IntervalHours) * time.Hour,
+		DryRun:        cfg.Retention.DryRun,
+		BatchSize:     cfg.Retention.BatchSize,
+		MaxQuizzes:    cfg.Retention.MaxQuizzesPerRun,
+	})
+	if cfg.Retention.Enabled {
+		retentionService.Start(ctx)
+	}
+
 	// --- Инициализация WebSocket --- //
 	var wsHub ws.HubInterface
 	var pubSubProvider ws.PubSubProvider = &ws.NoOpPubSub{} // Провайдер по умолчанию
 
-	// Создаем PubSubProvider только если кластеризация включена
+	// Создаем PubSubProvider только если кластеризация включена.
+	// Если Redis недоступен, провайдер работает в режиме деградации и переподключается в фоне.
+	var reconnectingPubSub *ws.ReconnectingPubSub
 	if cfg.WebSocket.Cluster.Enabled {
 		log.Println("Инициализация Redis PubSub для кластеризации WebSocket...")
-		// Создаем КЛИЕНТ Redis PubSub с использованием той же универсальной функции
-		redisPubSubClient, err := database.NewUniversalRedisClient(cfg.Redis)
-		if err != nil {
-			log.Printf("Ошибка при инициализации Redis клиента для PubSub: %v. Кластеризация WS будет неактивна.", err)
-			// Используем NoOpPubSub если не удалось подключиться к Redis
-			pubSubProvider = &ws.NoOpPubSub{}
-		} else {
-			// Создаем провайдер Redis PubSub, передавая ему созданный клиент
+		reconnectingPubSub = ws.NewReconnectingPubSub(func() (ws.PubSubProvider, error) {
+			// Создаем КЛИЕНТ Redis PubSub с использованием той же универсальной функции
+			redisPubSubClient, err := database.NewUniversalRedisClient(cfg.Redis)
+			if err != nil {
+				return nil, err
+			}
 			redisProvider, err := ws.NewRedisPubSub(redisPubSubClient)
 			if err != nil {
-				log.Printf("Ошибка при создании Redis PubSub провайдера: %v. Кластеризация WS будет неактивна.", err)
 				redisPubSubClient.Close() // Закрываем созданный клиент, так как он не будет использоваться
-				pubSubProvider = &ws.NoOpPubSub{}
-			} else {
-				log.Println("Redis PubSub провайдер успешно инициализирован")
-				pubSubProvider = redisProvider
+				return nil, err
 			}
+			return redisProvider, nil
+		})
+		if reconnectingPubSub.Connected() {
+			log.Println("Redis PubSub провайдер успешно инициализирован")
+		} else {
+			log.Println("ВНИМАНИЕ: Redis PubSub недоступен, кластеризация WS деградировала до восстановления соединения")
 		}
+		pubSubProvider = reconnectingPubSub
 	}
 
 	if cfg.WebSocket.Sharding.Enabled {
@@ -146,6 +202,25 @@ func main() {
 		shardedHub := ws.NewShardedHub(cfg.WebSocket, pubSubProvider)
 		go shardedHub.Run() // Запускаем обработчик шардов
 		wsHub = shardedHub
+
+		// Периодически сохраняем снимки метрик для анализа после событий
+		if historyCfg := cfg.WebSocket.MetricsHistory; historyCfg.Enabled {
+			shardedHub.StartMetricsHistory(
+				wsMetricsRepo,
+				time.Duration(historyCfg.Interval)*time.Second,
+				time.Duration(historyCfg.RetentionHours)*time.Hour,
+			)
+		}
+
+		// История алертов в БД переживает перезапуск и общая для экземпляров
+		if alertHistory := cfg.WebSocket.Alerts.History; alertHistory.Persist {
+			shardedHub.SetAlertStore(ws.NewPersistentAlertStore(
+				wsAlertRepo,
+				shardedHub.GetInstanceID(),
+				alertHistory.Size,
+				time.Duration(alertHistory.MaxAgeHours)*time.Hour,
+			))
+		}
 	} else {
 		log.Println("WebSocket: используется один хаб")
 		// Для простого Hub не требуется сложная конфигурация или PubSub
@@ -155,26 +230,158 @@ func main() {
 	}
 
 	wsManager := ws.NewManager(wsHub)
+	if cfg.WebSocket.Acks.Enabled {
+		wsManager.EnableAcks(cfg.WebSocket.Acks.MaxRedeliveries)
+	}
+	if reconnectingPubSub != nil {
+		reconnectingPubSub.SetAlertFunc(wsManager.SendAlert)
+		reconnectingPubSub.Start()
+	}
 
 	// Инициализируем сервисы
 	quizService := service.NewQuizService(quizRepo, questionRepo, cacheRepo)
+	if cfg.Questions.MinOptions > 0 || cfg.Questions.MaxOptions > 0 {
+		minOptions, maxOptions := quizService.OptionLimits()
+		if cfg.Questions.MinOptions > 0 {
+			minOptions = cfg.Questions.MinOptions
+		}
+		if cfg.Questions.MaxOptions > 0 {
+			maxOptions = cfg.Questions.MaxOptions
+		}
+		quizService.SetOptionLimits(minOptions, maxOptions)
+	}
+	if days := cfg.QuizManager.MaxScheduleLeadDays; days > 0 {
+		quizService.SetMaxScheduleLead(time.Duration(days) * 24 * time.Hour)
+	}
 	resultService := service.NewResultService(resultRepo, userRepo, quizRepo, questionRepo, cacheRepo, db, wsManager)
+	resultService.SetTournamentRepo(tournamentRepo)
+	tournamentService := service.NewTournamentService(tournamentRepo, quizRepo)
+	if webhookCfg := cfg.Webhooks.FinalResults; webhookCfg.URL != "" {
+		resultService.SetFinalResultsWebhook(service.NewFinalResultsWebhook(service.FinalResultsWebhookOptions{
+			URL:         webhookCfg.URL,
+			Secret:      webhookCfg.Secret,
+			MaxAttempts: webhookCfg.MaxAttempts,
+			Timeout:     time.Duration(webhookCfg.TimeoutSec) * time.Second,
+		}))
+		log.Printf("Вебхук итоговых результатов включен: %s", webhookCfg.URL)
+	}
 	quizManager := service.NewQuizManager(quizRepo, questionRepo, resultRepo, resultService, cacheRepo, wsManager, db)
+	if cfg.QuizManager.MaxDurationSlackFactor > 0 {
+		quizManager.SetMaxDurationSlackFactor(cfg.QuizManager.MaxDurationSlackFactor)
+	}
+	if cfg.QuizManager.DisconnectGraceMs > 0 {
+		quizManager.SetDisconnectGrace(time.Duration(cfg.QuizManager.DisconnectGraceMs) * time.Millisecond)
+	}
+	if cfg.QuizManager.ReconnectGraceSec > 0 {
+		quizManager.SetReconnectGrace(time.Duration(cfg.QuizManager.ReconnectGraceSec)*time.Second, cfg.QuizManager.ReconnectReprieves)
+	}
+	if cfg.QuizManager.MaxConcurrentQuizzes > 0 {
+		quizManager.SetMaxConcurrentQuizzes(cfg.QuizManager.MaxConcurrentQuizzes)
+	}
+	if cfg.QuizManager.AnswerBatchSize > 0 || cfg.QuizManager.AnswerFlushIntervalMs > 0 {
+		quizManager.SetAnswerBatching(cfg.QuizManager.AnswerBatchSize, time.Duration(cfg.QuizManager.AnswerFlushIntervalMs)*time.Millisecond)
+	}
+	if cfg.QuizManager.MinPlayersGraceSec > 0 {
+		quizManager.SetMinPlayersGrace(time.Duration(cfg.QuizManager.MinPlayersGraceSec) * time.Second)
+	}
+	quizManager.SetLeaderboardDeltas(cfg.QuizManager.LeaderboardDeltas)
+	quizManager.SetRequireJoin(cfg.QuizManager.RequireJoin)
+
+	// Режим обслуживания (общий для всех экземпляров через Redis)
+	maintenanceService := service.NewMaintenanceService(cacheRepo)
+
+	// Настройки уведомлений пользователей: необязательные системные события по WebSocket
+	notificationPrefsService := service.NewNotificationPrefsService(cacheRepo)
+	wsManager.SetNotificationFilter(notificationPrefsService)
+
+	// Следующие викторины повторяющихся серий
+	if cfg.Recurrence.Enabled {
+		recurrenceService := service.NewRecurrenceService(quizRepo, quizService, quizManager, cacheRepo,
+			time.Duration(cfg.Recurrence.CheckIntervalSec)*time.Second)
+		recurrenceService.SetMaintenance(maintenanceService)
+		recurrenceService.Start(ctx)
+	}
 
 	// Инициализируем обработчики
 	authHandler := handler.NewAuthHandler(authService, tokenManager, wsHub)
 	quizHandler := handler.NewQuizHandler(quizService, resultService, quizManager)
+	tournamentHandler := handler.NewTournamentHandler(tournamentService, resultService)
+	timeHandler := handler.NewTimeHandler()
+	jwksHandler := handler.NewJWKSHandler(jwtService)
 	wsHandler := handler.NewWSHandler(wsHub, wsManager, quizManager, jwtService)
+	metricsHandler := handler.NewMetricsHandler(wsMetricsRepo)
+	if sqlDB, err := database.GetSQLDB(db); err == nil {
+		metricsHandler.SetDBPool(sqlDB)
+	} else {
+		log.Printf("Диагностика пула БД недоступна: %v", err)
+	}
+	if cacheFallback != nil {
+		metricsHandler.SetCacheFallback(cacheFallback)
+	}
+	metricsHandler.SetWSAcks(wsManager)
+	metricsHandler.SetWSConnections(wsManager)
+	metricsHandler.SetAlerts(wsManager)
+	metricsHandler.SetTokenInvalidation(jwtService)
+	metricsHandler.SetCSRFTokens(tokenManager)
+	retentionHandler := handler.NewRetentionHandler(retentionService)
+	maintenanceHandler := handler.NewMaintenanceHandler(maintenanceService, quizManager, wsHub)
+	notificationPrefsHandler := handler.NewNotificationPrefsHandler(notificationPrefsService)
+	statsHandler := handler.NewStatsHandler(service.NewStatsService(userRepo, quizRepo, resultRepo, wsHub))
+
+	// Источники, которым разрешены CORS-запросы и подключение к WebSocket
+	allowedOrigins := cfg.Server.AllowedOrigins
+	if len(allowedOrigins) == 0 {
+		allowedOrigins = []string{"http://localhost:5173", "http://localhost:8000", "http://localhost:3000"}
+	}
+	wsHandler.SetAllowedOrigins(allowedOrigins)
+	if cfg.WebSocket.AllowAllOrigins {
+		wsHandler.AllowAllOrigins()
+	}
+	if cfg.Auth.RefreshExpiryWarningHours > 0 {
+		wsHandler.EnableRefreshExpiryWarnings(tokenManager, time.Duration(cfg.Auth.RefreshExpiryWarningHours)*time.Hour)
+	}
+	if cfg.Auth.WSCookieAuth {
+		wsHandler.EnableAccessTokenCookieAuth(allowedOrigins)
+	}
+	if cfg.WebSocket.BinaryProtocol {
+		wsHandler.EnableBinaryProtocol()
+	}
+	wsHandler.SetMaintenance(maintenanceService)
+	wsHandler.SetGeoProvider(geoProvider)
+	wsHandler.SetDebugPingAdminOnly(cfg.WebSocket.DebugPingAdminOnly)
+	wsHandler.SetClientBufferSize(cfg.WebSocket.Buffers.ClientSendBuffer)
+	wsHandler.SetWritePolicy(
+		time.Duration(cfg.WebSocket.Write.TimeoutMs)*time.Millisecond,
+		time.Duration(cfg.WebSocket.Write.SlowThresholdMs)*time.Millisecond,
+		cfg.WebSocket.Write.MaxSlowWrites,
+	)
+	// В кластере соединения с одного IP считаются по всем экземплярам в Redis
+	var ipConnections ws.IPConnectionCounter = ws.NewLocalIPConnectionCounter()
+	if cfg.WebSocket.Cluster.Enabled {
+		ipConnections = ws.NewRedisIPConnectionCounter(redisClient)
+	}
+	if err := wsHandler.SetIPConnectionLimit(ipConnections, cfg.WebSocket.Limits.MaxConnectionsPerIP, cfg.WebSocket.Limits.IPAllowList); err != nil {
+		log.Fatalf("Некорректный список websocket.limits.ipAllowList: %v", err)
+	}
+	metricsHandler.SetWSIPConnections(wsHandler)
 
 	// Инициализируем middleware
 	authMiddleware := middleware.NewAuthMiddlewareWithManager(jwtService, tokenManager)
+	// Создание и запуск викторин недоступны в режиме обслуживания
+	rejectDuringMaintenance := middleware.RejectDuringMaintenance(maintenanceService)
 
 	// Инициализируем роутер Gin
 	router := gin.Default()
 
+	// IP клиента (сессии, лимиты запросов, геолокация) берется из X-Forwarded-For
+	// только за доверенными прокси, иначе - из адреса соединения
+	if err := router.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		log.Fatalf("Некорректный список доверенных прокси server.trustedProxies: %v", err)
+	}
+
 	// Настройка CORS
 	router.Use(cors.New(cors.Config{
-		AllowOrigins:     []string{"http://localhost:5173", "http://localhost:8000", "http://localhost:3000"},
+		AllowOrigins:     allowedOrigins,
 		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
 		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "X-CSRF-Token"},
 		ExposeHeaders:    []string{"Content-Length"},
@@ -188,6 +395,15 @@ func main() {
 	// Настраиваем маршруты API
 	api := router.Group("/api")
 	{
+		// Время сервера для синхронизации часов клиентов (без аутентификации)
+		api.GET("/time", timeHandler.GetServerTime)
+
+		// Состояние сервера, включая режим обслуживания (без аутентификации)
+		api.GET("/health", maintenanceHandler.Health)
+
+		// Общая статистика платформы (без аутентификации, кэшируется)
+		api.GET("/stats", statsHandler.GetPublicStats)
+
 		// Аутентификация
 		auth := api.Group("/auth")
 		{
@@ -205,8 +421,9 @@ func main() {
 				authedAuth.POST("/logout-all", authHandler.LogoutAllDevices)
 				authedAuth.GET("/sessions", authHandler.GetActiveSessions)
 				authedAuth.POST("/revoke-session", authHandler.RevokeSession)
+				authedAuth.POST("/revoke-device", authHandler.RevokeDevice)
 				authedAuth.POST("/change-password", authHandler.ChangePassword)
-				authedAuth.POST("/ws-ticket", authHandler.GenerateWsTicket)
+				authedAuth.POST("/ws-ticket", middleware.RateLimitPerUser(30, time.Minute), authHandler.GenerateWsTicket)
 			}
 
 			// Маршрут для сброса инвалидаций токенов (только для администраторов)
@@ -216,6 +433,11 @@ func main() {
 				adminAuth.POST("/reset-auth", authHandler.ResetAuth)
 				adminAuth.POST("/debug-token", authHandler.DebugToken)
 				adminAuth.POST("/reset-password", authHandler.AdminResetPassword)
+				adminAuth.POST("/revoke-user-sessions", authHandler.AdminRevokeUserSessions)
+				adminAuth.POST("/users/import", authHandler.AdminImportUsers)
+				adminAuth.PUT("/users/role", authHandler.AdminSetUserRole)
+				adminAuth.PUT("/users/email-verified", authHandler.AdminSetEmailVerified)
+				adminAuth.POST("/ws-test", middleware.RateLimitPerUser(10, time.Minute), authHandler.AdminTestWebSocketDelivery)
 			}
 		}
 
@@ -225,13 +447,16 @@ func main() {
 		{
 			users.GET("/me", authHandler.GetMe)
 			users.PUT("/me", authHandler.UpdateProfile)
+			users.GET("/me/active-quiz", quizHandler.GetMyActiveQuiz)
+			users.GET("/me/notification-prefs", notificationPrefsHandler.GetNotificationPrefs)
+			users.PUT("/me/notification-prefs", notificationPrefsHandler.UpdateNotificationPrefs)
 		}
 
 		// Викторины
 		quizzes := api.Group("/quizzes")
 		{
 			quizzes.GET("", quizHandler.ListQuizzes)
-			quizzes.GET("/active", quizHandler.GetActiveQuiz)
+			quizzes.GET("/active", quizHandler.GetActiveQuizzes)
 			quizzes.GET("/scheduled", quizHandler.GetScheduledQuizzes)
 
 			// Группа маршрутов, требующих quizID
@@ -247,6 +472,10 @@ func main() {
 				authedQuizzes.Use(authMiddleware.RequireAuth())
 				{
 					authedQuizzes.GET("/my-result", quizHandler.GetUserQuizResult)
+					authedQuizzes.GET("/my-answers", quizHandler.GetUserQuizAnswers)
+					authedQuizzes.GET("/sync", quizHandler.GetQuizSync)
+					authedQuizzes.POST("/join", quizHandler.JoinQuiz)
+					authedQuizzes.POST("/leave", quizHandler.LeaveQuiz)
 				}
 
 				// Маршруты для администраторов
@@ -254,8 +483,23 @@ func main() {
 				adminQuizzes.Use(authMiddleware.RequireAuth(), authMiddleware.AdminOnly())
 				{
 					adminQuizzes.POST("/questions", quizHandler.AddQuestions)
-					adminQuizzes.PUT("/schedule", quizHandler.ScheduleQuiz)
-					adminQuizzes.PUT("/cancel", quizHandler.CancelQuiz)
+					adminQuizzes.POST("/clone", rejectDuringMaintenance, quizHandler.CloneQuiz)
+					adminQuizzes.GET("/export", quizHandler.ExportQuiz)
+					adminQuizzes.POST("/replay", rejectDuringMaintenance, quizHandler.ReplayQuiz)
+					adminQuizzes.POST("/recompute-results", quizHandler.RecomputeQuizResults)
+					adminQuizzes.PUT("/recurrence", quizHandler.SetRecurrence)
+				}
+
+				// Проведение викторин: модераторы и администраторы
+				moderatorQuizzes := quizWithID.Group("") // Наследует middleware
+				moderatorQuizzes.Use(authMiddleware.RequireAuth(), authMiddleware.RequireRole(entity.RoleModerator))
+				{
+					moderatorQuizzes.PUT("/schedule", rejectDuringMaintenance, quizHandler.ScheduleQuiz)
+					moderatorQuizzes.PUT("/cancel", quizHandler.CancelQuiz)
+					moderatorQuizzes.POST("/kick", quizHandler.KickPlayer)
+					moderatorQuizzes.POST("/invite-code", quizHandler.RotateInviteCode)
+					moderatorQuizzes.GET("/readiness", quizHandler.GetQuizReadiness)
+					moderatorQuizzes.GET("/participation", quizHandler.GetQuizParticipation)
 				}
 			}
 
@@ -263,14 +507,63 @@ func main() {
 			adminCreateQuiz := quizzes.Group("")
 			adminCreateQuiz.Use(authMiddleware.RequireAuth(), authMiddleware.AdminOnly())
 			{
-				adminCreateQuiz.POST("", quizHandler.CreateQuiz)
+				adminCreateQuiz.POST("", rejectDuringMaintenance, quizHandler.CreateQuiz)
+				adminCreateQuiz.POST("/import", rejectDuringMaintenance, quizHandler.ImportQuiz)
 			}
 		}
+
+		// Турниры: несколько викторин с общим зачетом
+		tournaments := api.Group("/tournaments")
+		{
+			adminCreateTournament := tournaments.Group("")
+			adminCreateTournament.Use(authMiddleware.RequireAuth(), authMiddleware.AdminOnly())
+			{
+				adminCreateTournament.POST("", tournamentHandler.CreateTournament)
+			}
+
+			tournamentWithID := tournaments.Group("/:id")
+			tournamentWithID.Use(middleware.ExtractUintParam("id", "tournamentID"))
+			{
+				tournamentWithID.GET("", tournamentHandler.GetTournament)
+				tournamentWithID.GET("/standings", tournamentHandler.GetStandings)
+
+				adminTournaments := tournamentWithID.Group("") // Наследует middleware
+				adminTournaments.Use(authMiddleware.RequireAuth(), authMiddleware.AdminOnly())
+				{
+					adminTournaments.POST("/quizzes", tournamentHandler.AddQuiz)
+				}
+			}
+		}
+
+		// Административные маршруты мониторинга
+		admin := api.Group("/admin")
+		admin.Use(authMiddleware.RequireAuth(), authMiddleware.AdminOnly())
+		{
+			admin.GET("/metrics/answer-queue", quizHandler.GetAnswerQueueMetrics)
+			admin.GET("/metrics/quizzes", quizHandler.GetConcurrencyMetrics)
+			admin.PUT("/quizzes/concurrency-limit", quizHandler.SetConcurrencyLimit)
+			admin.GET("/metrics/ws-history", metricsHandler.GetWSMetricsHistory)
+			admin.GET("/metrics/db-pool", metricsHandler.GetDBPoolStats)
+			admin.GET("/metrics/cache-fallback", metricsHandler.GetCacheFallbackStats)
+			admin.GET("/metrics/ws-acks", metricsHandler.GetWSAckStats)
+			admin.GET("/metrics/token-invalidation", metricsHandler.GetTokenInvalidationStats)
+			admin.GET("/metrics/csrf-tokens", metricsHandler.GetCSRFTokenStats)
+			admin.GET("/metrics/ws-ip-connections", metricsHandler.GetWSIPConnections)
+			admin.GET("/metrics/ws-connections", metricsHandler.GetWSConnections)
+			admin.GET("/alerts", metricsHandler.GetAlerts)
+			admin.POST("/retention/run", retentionHandler.RunCleanup)
+			admin.GET("/maintenance", maintenanceHandler.GetMaintenance)
+			admin.GET("/stats", statsHandler.GetAdminStats)
+			admin.PUT("/maintenance", maintenanceHandler.SetMaintenance)
+		}
 	}
 
 	// WebSocket маршрут
 	router.GET("/ws", wsHandler.HandleConnection)
 
+	// Открытые ключи проверки JWT (RS256/ES256)
+	router.GET("/.well-known/jwks.json", jwksHandler.GetJWKS)
+
 	// Запланированные викторины
 	// После перезапуска сервера нужно заново запланировать активные викторины
 	go func() {
@@ -312,6 +605,9 @@ func main() {
 	// Отправляем сигнал завершения для всех горутин
 	cancel()
 
+	// Останавливаем викторины и записываем накопленные в буфере ответы
+	quizManager.Shutdown()
+
 	// Закрываем PubSubProvider, если он был создан
 	if pubSubProvider != nil {
 		if err := pubSubProvider.Close(); err != nil {
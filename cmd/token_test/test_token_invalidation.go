@@ -29,7 +29,7 @@ func main() {
 	}
 
 	// Инициализируем подключение к PostgreSQL
-	db, err := database.NewPostgresDB(cfg.Database.PostgresConnectionString())
+	db, err := database.NewPostgresDB(cfg.Database)
 	if err != nil {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
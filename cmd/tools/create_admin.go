@@ -19,7 +19,7 @@ func main() {
 	}
 
 	// Подключаемся к базе данных
-	db, err := database.NewPostgresDB(cfg.Database.PostgresConnectionString())
+	db, err := database.NewPostgresDB(cfg.Database)
 	if err != nil {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
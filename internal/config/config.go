@@ -2,7 +2,6 @@ package config
 
 import (
 	"fmt"
-	"log"
 	"time"
 
 	"github.com/spf13/viper"
@@ -16,6 +15,18 @@ type Config struct {
 	JWT       JWTConfig
 	Auth      AuthConfig
 	WebSocket WebSocketConfig
+	// QuizManager: настройки проведения викторин
+	QuizManager QuizManagerConfig
+	// Questions: ограничения на вопросы викторин
+	Questions QuestionsConfig
+	// Moderation: фильтрация недопустимых имен пользователей
+	Moderation ModerationConfig
+	// Retention: очистка старых викторин и результатов
+	Retention RetentionConfig
+	// Recurrence: создание следующих викторин повторяющихся серий
+	Recurrence RecurrenceConfig
+	// Webhooks: уведомления внешних систем
+	Webhooks WebhooksConfig
 }
 
 // ServerConfig содержит настройки HTTP сервера
@@ -23,6 +34,12 @@ type ServerConfig struct {
 	Port         string
 	ReadTimeout  int
 	WriteTimeout int
+	// AllowedOrigins: источники, которым разрешены CORS-запросы и подключение к /ws
+	AllowedOrigins []string
+	// TrustedProxies: адреса и подсети (CIDR) прокси и балансировщиков, которым
+	// разрешено передавать IP клиента в X-Forwarded-For / X-Real-IP. Пустой
+	// список - заголовкам не доверяют, IP клиента берется из адреса соединения.
+	TrustedProxies []string
 }
 
 // DatabaseConfig содержит настройки подключения к PostgreSQL
@@ -33,6 +50,10 @@ type DatabaseConfig struct {
 	Password string
 	DBName   string
 	SSLMode  string
+	// Пул соединений. 0 - значение по умолчанию (25 / 10 / 60 минут).
+	MaxOpenConns       int // Максимум открытых соединений
+	MaxIdleConns       int // Максимум простаивающих соединений, не больше MaxOpenConns
+	ConnMaxLifetimeMin int // Время жизни соединения в минутах
 }
 
 // RedisConfig содержит унифицированные настройки подключения к Redis
@@ -63,6 +84,14 @@ type RedisConfig struct {
 
 	// MaxRetryBackoff: Максимальный интервал между попытками (в миллисекундах). По умолчанию 512ms.
 	MaxRetryBackoff int `mapstructure:"max_retry_backoff"`
+
+	// FallbackCacheSize: емкость локального LRU-кэша, в котором дублируются критичные
+	// ключи на случай кратковременной недоступности Redis (0 - резерв выключен).
+	FallbackCacheSize int `mapstructure:"fallback_cache_size"`
+
+	// FallbackKeyPatterns: шаблоны критичных ключей (path.Match). По умолчанию -
+	// время начала вопросов "quiz:*:question:*:start_time".
+	FallbackKeyPatterns []string `mapstructure:"fallback_key_patterns"`
 }
 
 // JWTConfig содержит настройки JWT
@@ -71,12 +100,34 @@ type JWTConfig struct {
 	ExpirationHrs     int
 	WSTicketExpirySec int           `mapstructure:"wsTicketExpirySec"` // Время жизни тикета для WebSocket в секундах
 	CleanupInterval   time.Duration `mapstructure:"cleanup_interval"`  // Интервал очистки кеша
+	// Алгоритм подписи: HS256 (по умолчанию, общий секрет), RS256 или ES256 (закрытый ключ из PrivateKeyFile)
+	Algorithm string
+	// PEM-файл закрытого ключа RSA или EC P-256 для RS256/ES256
+	PrivateKeyFile string `mapstructure:"privateKeyFile"`
 }
 
 // AuthConfig содержит настройки аутентификации
 type AuthConfig struct {
-	SessionLimit         int
+	SessionLimit int
+	// SessionLimitPolicy - что делать при входе сверх SessionLimit: evict_oldest
+	// (по умолчанию) завершает самые старые сессии, reject_new отклоняет вход
+	SessionLimitPolicy   string
 	RefreshTokenLifetime int
+	// MaxSessionLifetime - абсолютный срок сессии в часах от входа; обновление
+	// токенов не продлевает ее дальше (0 - без ограничения)
+	MaxSessionLifetime int
+	// RefreshExpiryWarningHours - за сколько часов до истечения refresh-токена
+	// отправлять по WebSocket REFRESH_TOKEN_EXPIRE_SOON (0 - не отправлять)
+	RefreshExpiryWarningHours int
+	// WSCookieAuth разрешает подключение к WebSocket по куке access_token без тикета
+	WSCookieAuth bool
+	// InvalidationFailMode - что делать с токеном, если хранилище инвалидированных
+	// токенов недоступно: fail_open (по умолчанию) принимает токен по проверке в
+	// памяти экземпляра, fail_closed отклоняет его
+	InvalidationFailMode string
+	// CSRFTokensPerUser - сколько CSRF токенов пользователя хранится в памяти;
+	// при выдаче нового токена сверх лимита самые старые перестают действовать
+	CSRFTokensPerUser int `mapstructure:"csrfTokensPerUser"`
 }
 
 // WebSocketConfig содержит настройки WebSocket-подсистемы
@@ -85,8 +136,33 @@ type WebSocketConfig struct {
 	Buffers  BuffersConfig
 	Priority PriorityConfig
 	Ping     PingConfig
+	Write    WriteConfig
 	Cluster  ClusterConfig
 	Limits   LimitsConfig
+	Alerts   AlertsConfig
+	// MetricsHistory: периодическое сохранение снимков метрик для анализа после событий
+	MetricsHistory MetricsHistoryConfig
+	// Acks: подтверждение клиентом критических событий и их повтор при переподключении
+	Acks AcksConfig
+	// BinaryProtocol: разрешить клиентам бинарный формат quiz:question, quiz:timer и user:answer
+	BinaryProtocol bool
+	// DebugPingAdminOnly: команда debug:ping доступна только администраторам
+	DebugPingAdminOnly bool
+	// AllowAllOrigins: не проверять Origin при подключении к /ws. Только для разработки!
+	AllowAllOrigins bool
+	// DuplicateConnections: поведение при втором соединении пользователя (вторая вкладка)
+	DuplicateConnections DuplicateConnectionsConfig
+}
+
+// DuplicateConnectionsConfig содержит настройки повторного соединения пользователя
+type DuplicateConnectionsConfig struct {
+	// Policy: "replace" (по умолчанию) - новое соединение заменяет старое,
+	// "reject_new" - новое соединение закрывается, "prompt" - старое соединение
+	// получает connection:takeover_requested и решает, отдать ли место новому
+	Policy string
+	// TakeoverTimeoutMs: сколько при "prompt" ждать ответа старого соединения,
+	// после чего новое заменяет его. 0 - по умолчанию (15000).
+	TakeoverTimeoutMs int
 }
 
 // ShardingConfig содержит настройки шардирования
@@ -118,6 +194,23 @@ type PingConfig struct {
 	Timeout  int
 }
 
+// WriteConfig содержит политику записи сообщений клиентам
+type WriteConfig struct {
+	// TimeoutMs: тайм-аут записи одного сообщения. По истечении соединение обрывается. 0 - 10 секунд.
+	TimeoutMs int
+	// SlowThresholdMs: запись дольше этого времени считается медленной. 0 - не отслеживать.
+	SlowThresholdMs int
+	// MaxSlowWrites: после стольких медленных записей подряд клиент отключается. 0 - 3.
+	MaxSlowWrites int
+}
+
+// AcksConfig содержит настройки подтверждения критических событий
+type AcksConfig struct {
+	Enabled bool
+	// MaxRedeliveries: сколько раз повторять неподтвержденное событие при переподключениях. 0 - 3.
+	MaxRedeliveries int
+}
+
 // ClusterConfig содержит настройки кластеризации
 type ClusterConfig struct {
 	Enabled          bool
@@ -135,6 +228,121 @@ type LimitsConfig struct {
 	PongWait            int
 	MaxConnectionsPerIP int
 	CleanupInterval     int
+	// IPAllowList: IP-адреса и подсети (CIDR), на которые не действует
+	// MaxConnectionsPerIP, например боты нагрузочных тестов
+	IPAllowList []string
+	// MaxConcurrentBroadcasts: одновременных рассылок по шардам; остальные ждут в очереди
+	MaxConcurrentBroadcasts int
+	// IdleTimeout: через сколько секунд без сообщений и pong отключать клиента
+	// (0 - PongWait)
+	IdleTimeout int
+	// IdleWarningGrace: за сколько секунд до отключения по неактивности отправлять
+	// connection:idle_warning (0 - без предупреждения)
+	IdleWarningGrace int
+}
+
+// AlertsConfig содержит настройки очереди алертов WebSocket
+type AlertsConfig struct {
+	Buffer int // Размер буфера алертов
+	// OverflowPolicy: поведение при переполнении буфера -
+	// "drop_new" (по умолчанию), "drop_oldest" или "block"
+	OverflowPolicy string
+	BlockTimeoutMs int // Время ожидания места в буфере для политики "block"
+	// History: история алертов для GET /api/admin/alerts
+	History AlertHistoryConfig
+}
+
+// AlertHistoryConfig содержит ограничения истории алертов WebSocket
+type AlertHistoryConfig struct {
+	Size        int  // Сколько последних алертов хранить в памяти (0 - по умолчанию, 1000)
+	MaxAgeHours int  // Сколько часов хранить алерты (0 - по умолчанию, 24)
+	Persist     bool // Сохранять алерты в БД (таблица ws_alerts)
+}
+
+// QuizManagerConfig содержит настройки менеджера викторин
+type QuizManagerConfig struct {
+	// MaxDurationSlackFactor: множитель запаса к расчетной длительности викторины,
+	// после которой она завершается принудительно. По умолчанию 2.0.
+	MaxDurationSlackFactor float64
+	// ReconnectGraceSec: окно после отключения игрока во время вопроса, в течение
+	// которого опоздавший ответ не приводит к выбыванию. 0 - выключено.
+	ReconnectGraceSec int
+	// ReconnectReprieves: сколько раз за викторину игроку прощается такое опоздание
+	ReconnectReprieves int
+	// DisconnectGraceMs: сколько после отключения игрок еще ожидается на вопросе
+	// викторины с auto_advance, чтобы успеть переподключиться и ответить.
+	// 0 - по умолчанию (5000).
+	DisconnectGraceMs int
+	// MaxConcurrentQuizzes: максимальное число одновременно проводимых викторин.
+	// Запуск сверх лимита отклоняется. 0 - без ограничения.
+	MaxConcurrentQuizzes int
+	// MaxScheduleLeadDays: насколько далеко вперед можно запланировать викторину,
+	// в днях. 0 - без ограничения.
+	MaxScheduleLeadDays int
+	// AnswerBatchSize: размер пакета записи ответов в БД. 1 - каждый ответ
+	// записывается сразу, 0 - по умолчанию (500).
+	AnswerBatchSize int
+	// AnswerFlushIntervalMs: интервал записи накопленных ответов, мс. 0 - по умолчанию (250).
+	AnswerFlushIntervalMs int
+	// MinPlayersGraceSec: сколько ждать игроков викторины с min_players и политикой
+	// wait после назначенного времени, прежде чем отменить ее. 0 - по умолчанию (120).
+	MinPlayersGraceSec int
+	// LeaderboardDeltas: после первой полной таблицы лидеров рассылать только ее
+	// изменения (quiz:leaderboard_delta). false - каждый раз полная таблица.
+	LeaderboardDeltas bool
+	// RequireJoin: перед user:ready нужно присоединиться через POST /api/quizzes/:id/join.
+	// false - user:ready занимает место в викторине сам.
+	RequireJoin bool
+}
+
+// QuestionsConfig содержит ограничения на вопросы викторин. 0 - значение по умолчанию (2-6).
+type QuestionsConfig struct {
+	MinOptions int // Минимальное число вариантов ответа
+	MaxOptions int // Максимальное число вариантов ответа
+}
+
+// ModerationConfig содержит настройки фильтра имен пользователей
+type ModerationConfig struct {
+	NameFilterEnabled bool     // Включить проверку имен при регистрации и смене профиля
+	Blocklist         []string // Запрещенные слова (сравниваются после нормализации leetspeak)
+	Allowlist         []string // Разрешенные слова, содержащие запрещенные подстроки
+}
+
+// RetentionConfig содержит настройки очистки старых викторин
+type RetentionConfig struct {
+	Enabled          bool // Фоновая очистка; ручной запуск доступен всегда
+	RetentionDays    int  // Удалять викторины, проведенные раньше указанного числа дней
+	IntervalHours    int  // Интервал фоновой очистки в часах
+	DryRun           bool // Только логировать, что было бы удалено
+	BatchSize        int  // Размер пакета при удалении ответов и результатов
+	MaxQuizzesPerRun int  // Максимум викторин за один запуск
+}
+
+// RecurrenceConfig содержит настройки повторяющихся викторин
+type RecurrenceConfig struct {
+	Enabled          bool // Создавать следующие викторины серий; правило повторения можно задавать всегда
+	CheckIntervalSec int  // Интервал проверки завершенных повторяющихся викторин в секундах (0 - 60)
+}
+
+// WebhooksConfig содержит настройки исходящих вебхуков
+type WebhooksConfig struct {
+	// FinalResults: итоговая таблица викторины после расчета рангов и призов
+	FinalResults WebhookConfig
+}
+
+// WebhookConfig содержит настройки одного вебхука. Пустой URL - вебхук выключен.
+type WebhookConfig struct {
+	URL         string
+	Secret      string // Ключ подписи HMAC-SHA256 тела запроса
+	MaxAttempts int    // Число попыток доставки
+	TimeoutSec  int    // Таймаут одного запроса в секундах
+}
+
+// MetricsHistoryConfig содержит настройки сохранения истории метрик WebSocket
+type MetricsHistoryConfig struct {
+	Enabled        bool
+	Interval       int // Интервал сохранения снимков в секундах
+	RetentionHours int // Сколько часов хранить снимки (0 - без очистки)
 }
 
 // PostgresConnectionString формирует строку подключения к PostgreSQL
@@ -160,13 +368,9 @@ func Load(configPath string) (*Config, error) {
 		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
 	}
 
-	// Проверка обязательных параметров
-	if cfg.JWT.Secret == "" {
-		log.Fatal("JWT secret is required")
-	}
-
-	if cfg.Database.Host == "" || cfg.Database.DBName == "" {
-		log.Fatal("Database configuration is incomplete")
+	// Проверка обязательных параметров и диапазонов: ошибки выводятся списком при запуске
+	if err := cfg.Validate(); err != nil {
+		return nil, err
 	}
 
 	return &cfg, nil
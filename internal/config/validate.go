@@ -0,0 +1,355 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/yourusername/trivia-api/internal/domain/entity"
+)
+
+// Ограничения, которые проверяются при запуске
+const (
+	minJWTSecretLength = 32    // Короткий секрет HMAC легко подобрать
+	maxSessionLimit    = 1000  // Больше сессий на пользователя - почти наверняка ошибка в конфиге
+	minQuestionOptions = 2     // Вопрос с одним вариантом не имеет смысла
+	postgresMaxParams  = 65535 // Предел параметров одного запроса в протоколе Postgres
+)
+
+// maxAnswerBatchSize - наибольший пакет ответов, который помещается в один INSERT:
+// каждый ответ занимает по параметру на столбец user_answers, кроме id
+var maxAnswerBatchSize = postgresMaxParams / (reflect.TypeOf(entity.UserAnswer{}).NumField() - 1)
+
+// ValidationError содержит все проблемы конфигурации, найденные за одну проверку,
+// чтобы их можно было исправить за один раз
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid configuration (%d problems):\n  - %s", len(e.Problems), strings.Join(e.Problems, "\n  - "))
+}
+
+func (e *ValidationError) add(field, format string, args ...interface{}) {
+	e.Problems = append(e.Problems, field+": "+fmt.Sprintf(format, args...))
+}
+
+// Validate проверяет обязательные параметры и диапазоны значений.
+// Возвращает *ValidationError со списком всех найденных проблем или nil.
+// Нулевые значения, для которых в коде есть значения по умолчанию
+// (время жизни WS тикета, интервалы очистки), считаются допустимыми.
+func (c *Config) Validate() error {
+	errs := &ValidationError{}
+
+	if c.Server.Port != "" {
+		if port, err := strconv.Atoi(c.Server.Port); err != nil || port <= 0 || port > 65535 {
+			errs.add("server.port", "must be a port number, got %q", c.Server.Port)
+		}
+	}
+
+	for i, proxy := range c.Server.TrustedProxies {
+		if err := validateTrustedProxy(proxy); err != nil {
+			errs.add(fmt.Sprintf("server.trustedProxies[%d]", i), "%v", err)
+		}
+	}
+
+	if c.Database.Host == "" {
+		errs.add("database.host", "is required")
+	}
+	if c.Database.DBName == "" {
+		errs.add("database.dbname", "is required")
+	}
+	if c.Database.MaxOpenConns < 0 {
+		errs.add("database.maxOpenConns", "must not be negative, got %d", c.Database.MaxOpenConns)
+	}
+	if c.Database.MaxIdleConns < 0 {
+		errs.add("database.maxIdleConns", "must not be negative, got %d", c.Database.MaxIdleConns)
+	} else if c.Database.MaxOpenConns > 0 && c.Database.MaxIdleConns > c.Database.MaxOpenConns {
+		errs.add("database.maxIdleConns", "must not exceed maxOpenConns (%d), got %d", c.Database.MaxOpenConns, c.Database.MaxIdleConns)
+	}
+	if c.Database.ConnMaxLifetimeMin < 0 {
+		errs.add("database.connMaxLifetimeMin", "must not be negative, got %d", c.Database.ConnMaxLifetimeMin)
+	}
+
+	// JWT
+	switch {
+	case c.JWT.Secret == "":
+		errs.add("jwt.secret", "is required")
+	case len(c.JWT.Secret) < minJWTSecretLength:
+		errs.add("jwt.secret", "must be at least %d characters long, got %d", minJWTSecretLength, len(c.JWT.Secret))
+	}
+	if c.JWT.ExpirationHrs <= 0 {
+		errs.add("jwt.expirationHrs", "must be positive, got %d", c.JWT.ExpirationHrs)
+	}
+	if c.JWT.WSTicketExpirySec < 0 {
+		errs.add("jwt.wsTicketExpirySec", "must not be negative, got %d", c.JWT.WSTicketExpirySec)
+	}
+	if c.JWT.CleanupInterval < 0 {
+		errs.add("jwt.cleanup_interval", "must not be negative, got %v", c.JWT.CleanupInterval)
+	}
+	switch c.JWT.Algorithm {
+	case "", "HS256":
+	case "RS256", "ES256":
+		if c.JWT.PrivateKeyFile == "" {
+			errs.add("jwt.privateKeyFile", "is required for %s", c.JWT.Algorithm)
+		}
+	default:
+		errs.add("jwt.algorithm", "must be one of HS256, RS256, ES256, got %q", c.JWT.Algorithm)
+	}
+
+	// Сессии
+	if c.Auth.RefreshTokenLifetime <= 0 {
+		errs.add("auth.refreshTokenLifetime", "must be positive, got %d", c.Auth.RefreshTokenLifetime)
+	}
+	if c.Auth.SessionLimit <= 0 || c.Auth.SessionLimit > maxSessionLimit {
+		errs.add("auth.sessionLimit", "must be between 1 and %d, got %d", maxSessionLimit, c.Auth.SessionLimit)
+	}
+	switch c.Auth.SessionLimitPolicy {
+	case "", "evict_oldest", "reject_new":
+	default:
+		errs.add("auth.sessionLimitPolicy", "must be one of evict_oldest, reject_new, got %q", c.Auth.SessionLimitPolicy)
+	}
+	switch c.Auth.InvalidationFailMode {
+	case "", "fail_open", "fail_closed":
+	default:
+		errs.add("auth.invalidationFailMode", "must be one of fail_open, fail_closed, got %q", c.Auth.InvalidationFailMode)
+	}
+	if c.Auth.CSRFTokensPerUser < 0 {
+		errs.add("auth.csrfTokensPerUser", "must not be negative, got %d", c.Auth.CSRFTokensPerUser)
+	}
+	if c.Auth.MaxSessionLifetime < 0 {
+		errs.add("auth.maxSessionLifetime", "must not be negative, got %d", c.Auth.MaxSessionLifetime)
+	}
+	if c.Auth.RefreshExpiryWarningHours < 0 {
+		errs.add("auth.refreshExpiryWarningHours", "must not be negative, got %d", c.Auth.RefreshExpiryWarningHours)
+	}
+
+	if c.WebSocket.Buffers.ClientSendBuffer < 0 {
+		errs.add("websocket.buffers.clientSendBuffer", "must not be negative, got %d", c.WebSocket.Buffers.ClientSendBuffer)
+	}
+	if c.WebSocket.Write.TimeoutMs < 0 {
+		errs.add("websocket.write.timeoutMs", "must not be negative, got %d", c.WebSocket.Write.TimeoutMs)
+	}
+	if c.WebSocket.Write.SlowThresholdMs < 0 {
+		errs.add("websocket.write.slowThresholdMs", "must not be negative, got %d", c.WebSocket.Write.SlowThresholdMs)
+	}
+	if write := c.WebSocket.Write; write.SlowThresholdMs > 0 && write.TimeoutMs > 0 && write.SlowThresholdMs >= write.TimeoutMs {
+		errs.add("websocket.write.slowThresholdMs", "must be less than websocket.write.timeoutMs (%d), got %d", write.TimeoutMs, write.SlowThresholdMs)
+	}
+	if c.WebSocket.Write.MaxSlowWrites < 0 {
+		errs.add("websocket.write.maxSlowWrites", "must not be negative, got %d", c.WebSocket.Write.MaxSlowWrites)
+	}
+	switch c.WebSocket.DuplicateConnections.Policy {
+	case "", "replace", "reject_new", "prompt":
+	default:
+		errs.add("websocket.duplicateConnections.policy", "must be one of replace, reject_new, prompt, got %q", c.WebSocket.DuplicateConnections.Policy)
+	}
+	if c.WebSocket.DuplicateConnections.TakeoverTimeoutMs < 0 {
+		errs.add("websocket.duplicateConnections.takeoverTimeoutMs", "must not be negative, got %d", c.WebSocket.DuplicateConnections.TakeoverTimeoutMs)
+	}
+	if c.WebSocket.Alerts.History.Size < 0 {
+		errs.add("websocket.alerts.history.size", "must not be negative, got %d", c.WebSocket.Alerts.History.Size)
+	}
+	if c.WebSocket.Alerts.History.MaxAgeHours < 0 {
+		errs.add("websocket.alerts.history.maxAgeHours", "must not be negative, got %d", c.WebSocket.Alerts.History.MaxAgeHours)
+	}
+	if c.WebSocket.Limits.MaxConcurrentBroadcasts < 0 {
+		errs.add("websocket.limits.maxConcurrentBroadcasts", "must not be negative, got %d", c.WebSocket.Limits.MaxConcurrentBroadcasts)
+	}
+	if c.WebSocket.Limits.MaxConnectionsPerIP < 0 {
+		errs.add("websocket.limits.maxConnectionsPerIP", "must not be negative, got %d", c.WebSocket.Limits.MaxConnectionsPerIP)
+	}
+	if c.WebSocket.Limits.IdleTimeout < 0 {
+		errs.add("websocket.limits.idleTimeout", "must not be negative, got %d", c.WebSocket.Limits.IdleTimeout)
+	}
+	if c.WebSocket.Limits.IdleWarningGrace < 0 {
+		errs.add("websocket.limits.idleWarningGrace", "must not be negative, got %d", c.WebSocket.Limits.IdleWarningGrace)
+	}
+	if limits := c.WebSocket.Limits; limits.IdleWarningGrace > 0 {
+		idleTimeout := limits.IdleTimeout
+		if idleTimeout == 0 {
+			idleTimeout = limits.PongWait
+		}
+		if idleTimeout > 0 && limits.IdleWarningGrace >= idleTimeout {
+			errs.add("websocket.limits.idleWarningGrace", "must be less than the idle timeout (%d), got %d", idleTimeout, limits.IdleWarningGrace)
+		}
+	}
+	for i, entry := range c.WebSocket.Limits.IPAllowList {
+		if err := validateIPAllowListEntry(entry); err != nil {
+			errs.add(fmt.Sprintf("websocket.limits.ipAllowList[%d]", i), "%v", err)
+		}
+	}
+
+	if c.QuizManager.ReconnectGraceSec < 0 {
+		errs.add("quizManager.reconnectGraceSec", "must not be negative, got %d", c.QuizManager.ReconnectGraceSec)
+	}
+	if c.QuizManager.ReconnectReprieves < 0 {
+		errs.add("quizManager.reconnectReprieves", "must not be negative, got %d", c.QuizManager.ReconnectReprieves)
+	}
+	if c.QuizManager.DisconnectGraceMs < 0 {
+		errs.add("quizManager.disconnectGraceMs", "must not be negative, got %d", c.QuizManager.DisconnectGraceMs)
+	}
+	if c.QuizManager.MaxConcurrentQuizzes < 0 {
+		errs.add("quizManager.maxConcurrentQuizzes", "must not be negative, got %d", c.QuizManager.MaxConcurrentQuizzes)
+	}
+	if c.QuizManager.AnswerBatchSize < 0 {
+		errs.add("quizManager.answerBatchSize", "must not be negative, got %d", c.QuizManager.AnswerBatchSize)
+	} else if c.QuizManager.AnswerBatchSize > maxAnswerBatchSize {
+		errs.add("quizManager.answerBatchSize", "must not exceed %d (Postgres parameter limit), got %d", maxAnswerBatchSize, c.QuizManager.AnswerBatchSize)
+	}
+	if c.QuizManager.AnswerFlushIntervalMs < 0 {
+		errs.add("quizManager.answerFlushIntervalMs", "must not be negative, got %d", c.QuizManager.AnswerFlushIntervalMs)
+	}
+	if c.QuizManager.MinPlayersGraceSec < 0 {
+		errs.add("quizManager.minPlayersGraceSec", "must not be negative, got %d", c.QuizManager.MinPlayersGraceSec)
+	}
+	if c.QuizManager.MaxScheduleLeadDays < 0 {
+		errs.add("quizManager.maxScheduleLeadDays", "must not be negative, got %d", c.QuizManager.MaxScheduleLeadDays)
+	}
+
+	if c.Questions.MinOptions != 0 && c.Questions.MinOptions < minQuestionOptions {
+		errs.add("questions.minOptions", "must be at least %d, got %d", minQuestionOptions, c.Questions.MinOptions)
+	}
+	if minOptions := c.Questions.MinOptions; c.Questions.MaxOptions != 0 {
+		if minOptions == 0 {
+			minOptions = minQuestionOptions
+		}
+		if c.Questions.MaxOptions < minOptions {
+			errs.add("questions.maxOptions", "must not be less than minOptions (%d), got %d", minOptions, c.Questions.MaxOptions)
+		}
+	}
+
+	if c.Recurrence.CheckIntervalSec < 0 {
+		errs.add("recurrence.checkIntervalSec", "must not be negative, got %d", c.Recurrence.CheckIntervalSec)
+	}
+
+	c.Webhooks.FinalResults.validate("webhooks.finalResults", errs)
+	c.Redis.validate(errs)
+
+	if len(errs.Problems) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// validate проверяет режим Redis и адреса так же, как их выбирает database.NewUniversalRedisClient
+func (r *RedisConfig) validate(errs *ValidationError) {
+	mode := r.Mode
+	if mode == "" {
+		mode = "single"
+	}
+
+	switch mode {
+	case "single":
+		if len(r.Addrs) == 0 && r.Addr == "" {
+			errs.add("redis.addrs", "addrs or addr is required")
+		}
+	case "sentinel":
+		if len(r.Addrs) == 0 {
+			errs.add("redis.addrs", "is required in sentinel mode")
+		}
+		if r.MasterName == "" {
+			errs.add("redis.master_name", "is required in sentinel mode")
+		}
+	case "cluster":
+		if len(r.Addrs) == 0 {
+			errs.add("redis.addrs", "is required in cluster mode")
+		}
+	default:
+		errs.add("redis.mode", "must be one of single, sentinel, cluster, got %q", r.Mode)
+	}
+
+	for i, addr := range r.Addrs {
+		if err := validateHostPort(addr); err != nil {
+			errs.add(fmt.Sprintf("redis.addrs[%d]", i), "%v", err)
+		}
+	}
+	if r.Addr != "" {
+		if err := validateHostPort(r.Addr); err != nil {
+			errs.add("redis.addr", "%v", err)
+		}
+	}
+	if r.DB < 0 {
+		errs.add("redis.db", "must not be negative, got %d", r.DB)
+	}
+	if r.FallbackCacheSize < 0 {
+		errs.add("redis.fallback_cache_size", "must not be negative, got %d", r.FallbackCacheSize)
+	}
+	for i, pattern := range r.FallbackKeyPatterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			errs.add(fmt.Sprintf("redis.fallback_key_patterns[%d]", i), "invalid pattern %q: %v", pattern, err)
+		}
+	}
+}
+
+// validate проверяет адрес и параметры доставки вебхука, если он включен
+func (w *WebhookConfig) validate(field string, errs *ValidationError) {
+	if w.URL == "" {
+		return
+	}
+	if u, err := url.Parse(w.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		errs.add(field+".url", "must be an absolute http(s) URL, got %q", w.URL)
+	}
+	if w.MaxAttempts < 0 {
+		errs.add(field+".maxAttempts", "must not be negative, got %d", w.MaxAttempts)
+	}
+	if w.TimeoutSec < 0 {
+		errs.add(field+".timeoutSec", "must not be negative, got %d", w.TimeoutSec)
+	}
+}
+
+// validateTrustedProxy проверяет адрес или подсеть доверенного прокси. Подсеть,
+// включающая все адреса, запрещена: любой клиент смог бы подставить чужой IP.
+func validateTrustedProxy(proxy string) error {
+	if !strings.Contains(proxy, "/") {
+		if net.ParseIP(proxy) == nil {
+			return fmt.Errorf("invalid IP address %q", proxy)
+		}
+		return nil
+	}
+	_, network, err := net.ParseCIDR(proxy)
+	if err != nil {
+		return fmt.Errorf("invalid CIDR %q", proxy)
+	}
+	if ones, _ := network.Mask.Size(); ones == 0 {
+		return fmt.Errorf("%q trusts every address, any client could spoof its IP", proxy)
+	}
+	return nil
+}
+
+// validateIPAllowListEntry проверяет IP-адрес или подсеть, освобожденную от лимита соединений
+func validateIPAllowListEntry(entry string) error {
+	if !strings.Contains(entry, "/") {
+		if net.ParseIP(entry) == nil {
+			return fmt.Errorf("invalid IP address %q", entry)
+		}
+		return nil
+	}
+	_, network, err := net.ParseCIDR(entry)
+	if err != nil {
+		return fmt.Errorf("invalid CIDR %q", entry)
+	}
+	if ones, _ := network.Mask.Size(); ones == 0 {
+		return fmt.Errorf("%q exempts every address, use maxConnectionsPerIP: 0 to disable the limit", entry)
+	}
+	return nil
+}
+
+// validateHostPort проверяет адрес вида "хост:порт"
+func validateHostPort(addr string) error {
+	host, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("invalid address %q: %v", addr, err)
+	}
+	if host == "" {
+		return fmt.Errorf("invalid address %q: host is empty", addr)
+	}
+	if port, err := strconv.Atoi(portStr); err != nil || port <= 0 || port > 65535 {
+		return fmt.Errorf("invalid address %q: bad port", addr)
+	}
+	return nil
+}
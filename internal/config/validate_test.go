@@ -0,0 +1,119 @@
+package config
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func validConfig() *Config {
+	return &Config{
+		Server:   ServerConfig{Port: "8080"},
+		Database: DatabaseConfig{Host: "localhost", DBName: "trivia_db"},
+		Redis:    RedisConfig{Mode: "single", Addr: "localhost:6379"},
+		JWT:      JWTConfig{Secret: "0123456789abcdef0123456789abcdef", ExpirationHrs: 24},
+		Auth:     AuthConfig{SessionLimit: 10, RefreshTokenLifetime: 720},
+	}
+}
+
+func TestValidate_ValidConfig(t *testing.T) {
+	assert.NoError(t, validConfig().Validate())
+
+	cfg := validConfig()
+	cfg.Server.TrustedProxies = []string{"10.0.0.0/8", "192.168.1.10", "fd00::/8"}
+	assert.NoError(t, cfg.Validate())
+}
+
+func TestValidate_ShippedConfigs(t *testing.T) {
+	for _, path := range []string{"config.yaml", "../../config/config.yaml"} {
+		_, err := Load(path)
+		assert.NoError(t, err, path)
+	}
+}
+
+func TestValidate_InvalidConfigs(t *testing.T) {
+	cases := []struct {
+		name   string
+		modify func(c *Config)
+		field  string
+	}{
+		{"пустой секрет", func(c *Config) { c.JWT.Secret = "" }, "jwt.secret"},
+		{"короткий секрет", func(c *Config) { c.JWT.Secret = "secret" }, "jwt.secret"},
+		{"неизвестный алгоритм JWT", func(c *Config) { c.JWT.Algorithm = "none" }, "jwt.algorithm"},
+		{"RS256 без закрытого ключа", func(c *Config) { c.JWT.Algorithm = "RS256" }, "jwt.privateKeyFile"},
+		{"нулевое время жизни токена", func(c *Config) { c.JWT.ExpirationHrs = 0 }, "jwt.expirationHrs"},
+		{"нулевое время жизни refresh-токена", func(c *Config) { c.Auth.RefreshTokenLifetime = 0 }, "auth.refreshTokenLifetime"},
+		{"нет лимита сессий", func(c *Config) { c.Auth.SessionLimit = 0 }, "auth.sessionLimit"},
+		{"неизвестная политика лимита сессий", func(c *Config) { c.Auth.SessionLimitPolicy = "reject_all" }, "auth.sessionLimitPolicy"},
+		{"неизвестный режим проверки инвалидаций", func(c *Config) { c.Auth.InvalidationFailMode = "ignore" }, "auth.invalidationFailMode"},
+		{"адрес Redis без порта", func(c *Config) { c.Redis.Addr = "localhost" }, "redis.addr"},
+		{"sentinel без master_name", func(c *Config) {
+			c.Redis = RedisConfig{Mode: "sentinel", Addrs: []string{"host1:26379"}}
+		}, "redis.master_name"},
+		{"неизвестный режим Redis", func(c *Config) { c.Redis.Mode = "replica" }, "redis.mode"},
+		{"отрицательная емкость резерва Redis", func(c *Config) { c.Redis.FallbackCacheSize = -1 }, "redis.fallback_cache_size"},
+		{"некорректный шаблон резерва Redis", func(c *Config) { c.Redis.FallbackKeyPatterns = []string{"quiz:[1"} }, "redis.fallback_key_patterns[0]"},
+		{"некорректная подсеть прокси", func(c *Config) { c.Server.TrustedProxies = []string{"10.0.0.0/8", "10.0.0.0/33"} }, "server.trustedProxies[1]"},
+		{"некорректный адрес прокси", func(c *Config) { c.Server.TrustedProxies = []string{"proxy.local"} }, "server.trustedProxies[0]"},
+		{"доверие всем адресам", func(c *Config) { c.Server.TrustedProxies = []string{"0.0.0.0/0"} }, "server.trustedProxies[0]"},
+		{"нет базы данных", func(c *Config) { c.Database.DBName = "" }, "database.dbname"},
+		{"отрицательный буфер клиента", func(c *Config) { c.WebSocket.Buffers.ClientSendBuffer = -1 }, "websocket.buffers.clientSendBuffer"},
+		{"отрицательный тайм-аут записи", func(c *Config) { c.WebSocket.Write.TimeoutMs = -1 }, "websocket.write.timeoutMs"},
+		{"порог медленной записи не меньше тайм-аута", func(c *Config) {
+			c.WebSocket.Write = WriteConfig{TimeoutMs: 1000, SlowThresholdMs: 1000}
+		}, "websocket.write.slowThresholdMs"},
+		{"неизвестная политика повторного соединения", func(c *Config) { c.WebSocket.DuplicateConnections.Policy = "kick_both" }, "websocket.duplicateConnections.policy"},
+		{"отрицательный размер истории алертов", func(c *Config) { c.WebSocket.Alerts.History.Size = -1 }, "websocket.alerts.history.size"},
+		{"отрицательный лимит рассылок", func(c *Config) { c.WebSocket.Limits.MaxConcurrentBroadcasts = -1 }, "websocket.limits.maxConcurrentBroadcasts"},
+		{"предупреждение не раньше таймаута неактивности", func(c *Config) {
+			c.WebSocket.Limits.IdleTimeout, c.WebSocket.Limits.IdleWarningGrace = 30, 30
+		}, "websocket.limits.idleWarningGrace"},
+		{"отрицательный лимит соединений с IP", func(c *Config) { c.WebSocket.Limits.MaxConnectionsPerIP = -1 }, "websocket.limits.maxConnectionsPerIP"},
+		{"некорректное исключение из лимита", func(c *Config) { c.WebSocket.Limits.IPAllowList = []string{"10.1.2.3", "bots"} }, "websocket.limits.ipAllowList[1]"},
+		{"исключение всех адресов", func(c *Config) { c.WebSocket.Limits.IPAllowList = []string{"::/0"} }, "websocket.limits.ipAllowList[0]"},
+		{"отрицательный пул соединений", func(c *Config) { c.Database.MaxOpenConns = -1 }, "database.maxOpenConns"},
+		{"простаивающих соединений больше открытых", func(c *Config) {
+			c.Database.MaxOpenConns, c.Database.MaxIdleConns = 10, 20
+		}, "database.maxIdleConns"},
+		{"отрицательный лимит викторин", func(c *Config) { c.QuizManager.MaxConcurrentQuizzes = -1 }, "quizManager.maxConcurrentQuizzes"},
+		{"отрицательный горизонт планирования", func(c *Config) { c.QuizManager.MaxScheduleLeadDays = -1 }, "quizManager.maxScheduleLeadDays"},
+		{"отрицательный размер пакета записи ответов", func(c *Config) { c.QuizManager.AnswerBatchSize = -1 }, "quizManager.answerBatchSize"},
+		{"пакет ответов больше лимита параметров", func(c *Config) { c.QuizManager.AnswerBatchSize = 10000 }, "quizManager.answerBatchSize"},
+		{"отрицательное ожидание переподключения", func(c *Config) { c.QuizManager.DisconnectGraceMs = -1 }, "quizManager.disconnectGraceMs"},
+		{"отрицательное ожидание игроков", func(c *Config) { c.QuizManager.MinPlayersGraceSec = -1 }, "quizManager.minPlayersGraceSec"},
+		{"один вариант ответа", func(c *Config) { c.Questions.MinOptions = 1 }, "questions.minOptions"},
+		{"максимум вариантов меньше минимума", func(c *Config) { c.Questions.MaxOptions = 1 }, "questions.maxOptions"},
+		{"отрицательный лимит CSRF токенов", func(c *Config) { c.Auth.CSRFTokensPerUser = -1 }, "auth.csrfTokensPerUser"},
+		{"отрицательный срок сессии", func(c *Config) { c.Auth.MaxSessionLifetime = -1 }, "auth.maxSessionLifetime"},
+		{"отрицательное упреждение предупреждения о refresh-токене", func(c *Config) { c.Auth.RefreshExpiryWarningHours = -1 }, "auth.refreshExpiryWarningHours"},
+		{"отрицательный интервал проверки повторений", func(c *Config) { c.Recurrence.CheckIntervalSec = -1 }, "recurrence.checkIntervalSec"},
+		{"относительный адрес вебхука", func(c *Config) { c.Webhooks.FinalResults.URL = "/hooks/results" }, "webhooks.finalResults.url"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := validConfig()
+			tc.modify(cfg)
+
+			var verr *ValidationError
+			require.True(t, errors.As(cfg.Validate(), &verr))
+			require.Len(t, verr.Problems, 1)
+			assert.Contains(t, verr.Problems[0], tc.field)
+		})
+	}
+}
+
+func TestValidate_ReportsAllProblems(t *testing.T) {
+	cfg := validConfig()
+	cfg.JWT.Secret = ""
+	cfg.JWT.ExpirationHrs = -1
+	cfg.Redis.Addrs = []string{"redis:6379", "redis:port"}
+
+	err := cfg.Validate()
+	var verr *ValidationError
+	require.True(t, errors.As(err, &verr))
+	assert.Len(t, verr.Problems, 3)
+	assert.Contains(t, err.Error(), "redis.addrs[1]")
+}
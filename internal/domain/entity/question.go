@@ -31,17 +31,51 @@ func (o StringArray) Value() (driver.Value, error) {
 	return json.Marshal(o)
 }
 
+// Типы вопросов
+const (
+	QuestionTypeSingleChoice = "single_choice" // Выбор одного варианта
+	QuestionTypeOrdering     = "ordering"      // Расстановка вариантов в правильном порядке
+)
+
+// Сложность вопроса (см. Quiz.DifficultyScaling)
+const (
+	DifficultyEasy   = "easy"
+	DifficultyMedium = "medium"
+	DifficultyHard   = "hard"
+)
+
+// IsValidDifficulty проверяет, что сложность вопроса известна (пустая - medium)
+func IsValidDifficulty(difficulty string) bool {
+	switch difficulty {
+	case "", DifficultyEasy, DifficultyMedium, DifficultyHard:
+		return true
+	}
+	return false
+}
+
 // Question представляет вопрос в викторине
 type Question struct {
 	ID            uint        `gorm:"primaryKey" json:"id"`
 	QuizID        uint        `gorm:"not null" json:"quiz_id"`
+	Type          string      `gorm:"size:20;not null;default:'single_choice'" json:"type"`
 	Text          string      `gorm:"size:500;not null" json:"text"`
 	Options       StringArray `gorm:"type:jsonb;not null" json:"options"`
 	CorrectOption int         `gorm:"not null" json:"-"` // Скрыто от клиента
-	TimeLimitSec  int         `gorm:"not null" json:"time_limit_sec"`
-	PointValue    int         `gorm:"not null" json:"point_value"`
-	CreatedAt     time.Time   `json:"created_at"`
-	UpdatedAt     time.Time   `json:"updated_at"`
+	// Для вопросов ordering: номера вариантов (с 1) в правильном порядке
+	CorrectOrder IntArray `gorm:"type:jsonb" json:"-"`
+	// Для вопросов ordering: способ начисления очков (см. OrderingScoring*)
+	ScoringMethod string    `gorm:"size:20" json:"scoring_method,omitempty"`
+	TimeLimitSec  int       `gorm:"not null" json:"time_limit_sec"`
+	PointValue    int       `gorm:"not null" json:"point_value"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+	// Сложность: easy, medium (по умолчанию) или hard (см. Quiz.DifficultyScaling)
+	Difficulty string `gorm:"size:10;not null;default:'medium'" json:"difficulty"`
+}
+
+// IsOrdering проверяет, является ли вопрос вопросом на упорядочивание
+func (q *Question) IsOrdering() bool {
+	return q.Type == QuestionTypeOrdering
 }
 
 // IsCorrect проверяет, является ли выбранный вариант правильным
@@ -0,0 +1,127 @@
+package entity
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+)
+
+// Способы начисления очков за вопросы ordering
+const (
+	// OrderingScoringExact - полные очки только за полностью верный порядок
+	OrderingScoringExact = "exact"
+	// OrderingScoringKendallTau - доля пар вариантов, расположенных в верном относительном порядке
+	OrderingScoringKendallTau = "kendall_tau"
+	// OrderingScoringAdjacent - доля верных соседних пар
+	OrderingScoringAdjacent = "adjacent"
+)
+
+// IntArray - пользовательский тип для хранения списка чисел в JSONB
+type IntArray []int
+
+// Scan реализует интерфейс sql.Scanner для IntArray
+func (a *IntArray) Scan(value interface{}) error {
+	if value == nil {
+		*a = nil
+		return nil
+	}
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New("failed to unmarshal JSONB value")
+	}
+	return json.Unmarshal(bytes, a)
+}
+
+// Value реализует интерфейс driver.Valuer для IntArray
+func (a IntArray) Value() (driver.Value, error) {
+	if a == nil {
+		return nil, nil
+	}
+	return json.Marshal(a)
+}
+
+// IsValidOrderingScoring проверяет, поддерживается ли способ начисления очков
+func IsValidOrderingScoring(method string) bool {
+	switch method {
+	case "", OrderingScoringExact, OrderingScoringKendallTau, OrderingScoringAdjacent:
+		return true
+	}
+	return false
+}
+
+// OrderingCredit возвращает долю правильности порядка от 0 до 1 согласно ScoringMethod.
+// Порядок, не являющийся перестановкой CorrectOrder, оценивается в 0.
+func (q *Question) OrderingCredit(order []int) float64 {
+	n := len(q.CorrectOrder)
+	if n == 0 || len(order) != n {
+		return 0
+	}
+
+	// Позиция каждого варианта в правильном порядке
+	position := make(map[int]int, n)
+	for i, option := range q.CorrectOrder {
+		position[option] = i
+	}
+
+	// Ответ должен содержать каждый вариант ровно один раз
+	seen := make(map[int]bool, n)
+	for _, option := range order {
+		if _, ok := position[option]; !ok || seen[option] {
+			return 0
+		}
+		seen[option] = true
+	}
+
+	exact := true
+	for i := range order {
+		if order[i] != q.CorrectOrder[i] {
+			exact = false
+			break
+		}
+	}
+	if exact {
+		return 1
+	}
+	if n == 1 {
+		return 0
+	}
+
+	switch q.ScoringMethod {
+	case OrderingScoringKendallTau:
+		// Доля согласованных пар: 1 - (число инверсий / общее число пар)
+		concordant := 0
+		for i := 0; i < n; i++ {
+			for j := i + 1; j < n; j++ {
+				if position[order[i]] < position[order[j]] {
+					concordant++
+				}
+			}
+		}
+		return float64(concordant) / float64(n*(n-1)/2)
+
+	case OrderingScoringAdjacent:
+		// Доля соседних пар ответа, которые стоят рядом и в том же порядке в правильном ответе
+		correct := 0
+		for i := 0; i+1 < n; i++ {
+			if position[order[i+1]] == position[order[i]]+1 {
+				correct++
+			}
+		}
+		return float64(correct) / float64(n-1)
+
+	default: // OrderingScoringExact
+		return 0
+	}
+}
+
+// CalculateOrderingPoints вычисляет очки за ответ на вопрос ordering:
+// очки за скорость ответа умножаются на долю правильности порядка
+func (q *Question) CalculateOrderingPoints(credit float64, responseTimeMs int64) int {
+	if credit <= 0 {
+		return 0
+	}
+	if credit > 1 {
+		credit = 1
+	}
+	return int(float64(q.CalculatePoints(true, responseTimeMs)) * credit)
+}
@@ -0,0 +1,54 @@
+package entity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func orderingQuestion(method string) *Question {
+	return &Question{
+		Type:          QuestionTypeOrdering,
+		Options:       StringArray{"a", "b", "c", "d"},
+		CorrectOrder:  IntArray{1, 2, 3, 4},
+		ScoringMethod: method,
+		TimeLimitSec:  10,
+		PointValue:    10,
+	}
+}
+
+func TestOrderingCredit_ExactMatch(t *testing.T) {
+	for _, method := range []string{OrderingScoringExact, OrderingScoringKendallTau, OrderingScoringAdjacent} {
+		assert.Equal(t, 1.0, orderingQuestion(method).OrderingCredit([]int{1, 2, 3, 4}), method)
+	}
+}
+
+func TestOrderingCredit_PartialCredit(t *testing.T) {
+	// Одна перестановка соседних вариантов: 5 из 6 пар согласованы
+	assert.InDelta(t, 5.0/6.0, orderingQuestion(OrderingScoringKendallTau).OrderingCredit([]int{2, 1, 3, 4}), 1e-9)
+	// Полностью обратный порядок
+	assert.Zero(t, orderingQuestion(OrderingScoringKendallTau).OrderingCredit([]int{4, 3, 2, 1}))
+
+	// Верна только соседняя пара 3-4
+	assert.InDelta(t, 1.0/3.0, orderingQuestion(OrderingScoringAdjacent).OrderingCredit([]int{2, 1, 3, 4}), 1e-9)
+
+	assert.Zero(t, orderingQuestion(OrderingScoringExact).OrderingCredit([]int{2, 1, 3, 4}))
+}
+
+func TestOrderingCredit_InvalidOrder(t *testing.T) {
+	q := orderingQuestion(OrderingScoringKendallTau)
+
+	assert.Zero(t, q.OrderingCredit(nil))
+	assert.Zero(t, q.OrderingCredit([]int{1, 2, 3}), "неполный порядок")
+	assert.Zero(t, q.OrderingCredit([]int{1, 1, 2, 3}), "повторяющийся вариант")
+	assert.Zero(t, q.OrderingCredit([]int{1, 2, 3, 5}), "несуществующий вариант")
+}
+
+func TestCalculateOrderingPoints(t *testing.T) {
+	q := orderingQuestion(OrderingScoringKendallTau)
+	full := q.CalculatePoints(true, 1000)
+
+	assert.Equal(t, full, q.CalculateOrderingPoints(1, 1000))
+	assert.Equal(t, full/2, q.CalculateOrderingPoints(0.5, 1000))
+	assert.Zero(t, q.CalculateOrderingPoints(0, 1000))
+}
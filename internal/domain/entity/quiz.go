@@ -1,33 +1,311 @@
 package entity
 
 import (
+	"crypto/subtle"
+	"math"
+	"strings"
 	"time"
 )
 
+// Правила присоединения к викторине
+const (
+	// JoinPolicyBeforeStartOnly - присоединиться можно только до первого вопроса
+	JoinPolicyBeforeStartOnly = "before_start_only"
+	// JoinPolicyAnytime - можно присоединиться во время проведения и играть с текущего вопроса
+	JoinPolicyAnytime = "anytime"
+)
+
+// Темп прохождения викторины
+const (
+	// PacingSynchronized - все игроки одновременно получают вопросы с общим таймером
+	PacingSynchronized = "synchronized"
+	// PacingSelfPaced - каждый игрок проходит вопросы в своем темпе в пределах
+	// общего лимита времени викторины
+	PacingSelfPaced = "self_paced"
+)
+
+// Поведение при нехватке игроков к началу викторины (Quiz.MinPlayers)
+const (
+	// MinPlayersPolicyWait - запуск откладывается, пока игроков не станет достаточно,
+	// но не дольше времени ожидания; затем викторина отменяется
+	MinPlayersPolicyWait = "wait"
+	// MinPlayersPolicyCancel - викторина отменяется сразу
+	MinPlayersPolicyCancel = "cancel"
+)
+
+// Видимость викторины
+const (
+	// VisibilityPublic - викторина есть в списках, присоединиться может любой
+	VisibilityPublic = "public"
+	// VisibilityUnlisted - викторины нет в списках, присоединиться можно по ссылке (ID)
+	VisibilityUnlisted = "unlisted"
+	// VisibilityPrivate - викторины нет в списках, для присоединения нужен код приглашения
+	VisibilityPrivate = "private"
+)
+
+// DifficultyMultipliers - множители стоимости вопросов по сложности
+// (Quiz.DifficultyScaling). Нулевой множитель заменяется значением по умолчанию.
+type DifficultyMultipliers struct {
+	Easy   float64 `gorm:"not null;default:0.5" json:"easy"`
+	Medium float64 `gorm:"not null;default:1" json:"medium"`
+	Hard   float64 `gorm:"not null;default:2" json:"hard"`
+}
+
+// DefaultDifficultyMultipliers - множители сложности по умолчанию
+var DefaultDifficultyMultipliers = DifficultyMultipliers{Easy: 0.5, Medium: 1, Hard: 2}
+
+// For возвращает множитель для сложности вопроса (пустая - medium)
+func (m DifficultyMultipliers) For(difficulty string) float64 {
+	multiplier, fallback := m.Medium, DefaultDifficultyMultipliers.Medium
+	switch difficulty {
+	case DifficultyEasy:
+		multiplier, fallback = m.Easy, DefaultDifficultyMultipliers.Easy
+	case DifficultyHard:
+		multiplier, fallback = m.Hard, DefaultDifficultyMultipliers.Hard
+	}
+	if multiplier <= 0 {
+		return fallback
+	}
+	return multiplier
+}
+
 // Quiz представляет викторину
 type Quiz struct {
 	ID            uint       `gorm:"primaryKey" json:"id"`
 	Title         string     `gorm:"size:100;not null" json:"title"`
 	Description   string     `gorm:"size:500" json:"description"`
 	ScheduledTime time.Time  `gorm:"not null" json:"scheduled_time"`
-	Status        string     `gorm:"size:20;not null" json:"status"` // scheduled, in_progress, completed
+	Status        QuizStatus `gorm:"size:20;not null" json:"status"`
 	QuestionCount int        `json:"question_count"`
-	Questions     []Question `gorm:"foreignKey:QuizID" json:"questions,omitempty"`
-	CreatedAt     time.Time  `json:"created_at"`
-	UpdatedAt     time.Time  `json:"updated_at"`
+	// Результаты ответов сообщаются игрокам только после закрытия вопроса
+	DelayedResults bool `gorm:"not null;default:false" json:"delayed_results"`
+	// Сразу после ответа игрок получает только нейтральное подтверждение приема,
+	// правильность и выбывание сообщаются после раскрытия ответа (формат на выбывание)
+	SuppressAnswerFeedback bool `gorm:"not null;default:false" json:"suppress_answer_feedback"`
+	// Игрок сразу узнает только свой результат, правильный ответ всем участникам
+	// одновременно показывается в quiz:answer_reveal
+	HideCorrectAnswer bool `gorm:"not null;default:false" json:"hide_correct_answer"`
+	// Единая стоимость всех вопросов викторины (0 - используется PointValue вопроса)
+	UniformPointValue int `gorm:"not null;default:0" json:"uniform_point_value"`
+	// Множитель очков, применяемый ко всем вопросам после UniformPointValue
+	PointsMultiplier float64 `gorm:"not null;default:1" json:"points_multiplier"`
+	// Штраф за неверный ответ, вычитаемый из итогового счета (0 - без штрафа)
+	WrongAnswerPenalty int `gorm:"not null;default:0" json:"wrong_answer_penalty"`
+	// Нижняя граница, ниже которой штрафы не опускают итоговый счет
+	ScoreFloor int `gorm:"not null;default:0" json:"score_floor"`
+	// Бонус первому правильно ответившему на вопрос (0 - без бонуса)
+	FastestFingerBonus int `gorm:"not null;default:0" json:"fastest_finger_bonus"`
+	// Стоимость вопроса умножается на множитель его сложности (Question.Difficulty)
+	DifficultyScaling     bool                  `gorm:"not null;default:false" json:"difficulty_scaling"`
+	DifficultyMultipliers DifficultyMultipliers `gorm:"embedded;embeddedPrefix:difficulty_" json:"difficulty_multipliers"`
+	// Правило присоединения: before_start_only (по умолчанию) или anytime
+	JoinPolicy string `gorm:"size:20;not null;default:'before_start_only'" json:"join_policy"`
+	// Условия участия (например, для призовых викторин): подтвержденный email,
+	// минимальное число сыгранных игр и внешняя проверка
+	RequireVerifiedEmail     bool `gorm:"not null;default:false" json:"require_verified_email"`
+	MinGamesPlayed           int  `gorm:"not null;default:0" json:"min_games_played"`
+	ExternalEligibilityCheck bool `gorm:"not null;default:false" json:"external_eligibility_check"`
+	// Темп прохождения: synchronized (по умолчанию) или self_paced
+	PacingMode string `gorm:"size:20;not null;default:'synchronized'" json:"pacing_mode"`
+	// Общий лимит времени самостоятельного прохождения в секундах с начала викторины
+	SelfPacedTimeLimitSec int `gorm:"not null;default:0" json:"self_paced_time_limit_sec"`
+	// Вопрос закрывается досрочно, когда ответили все активные (подключенные и
+	// не выбывшие) игроки (только для synchronized)
+	AutoAdvance bool `gorm:"not null;default:false" json:"auto_advance"`
+	// Время на ответ отсчитывается для каждого игрока от подтверждения получения
+	// вопроса (quiz:question_received), а не от рассылки (только для synchronized)
+	AnswerWindowFromAck bool `gorm:"not null;default:false" json:"answer_window_from_ack"`
+	// Время на чтение вопроса в секундах: quiz:question показывается, но ответы
+	// принимаются только после quiz:answer_window_open (0 - сразу, только для synchronized)
+	QuestionReadTimeSec int `gorm:"not null;default:0" json:"question_read_time_sec"`
+	// Минимум игроков в комнате викторины к началу (0 - без ограничения) и что
+	// делать, если их меньше: wait (по умолчанию) или cancel
+	MinPlayers       int    `gorm:"not null;default:0" json:"min_players"`
+	MinPlayersPolicy string `gorm:"size:20;not null;default:'wait'" json:"min_players_policy"`
+	// Максимум игроков, занявших место в викторине (0 - без ограничения)
+	MaxPlayers int `gorm:"not null;default:0" json:"max_players"`
+	// Число жизней в формате на выбывание: игрок выбывает после Lives неверных
+	// или опоздавших ответов (0 и 1 - выбывание с первой ошибки)
+	Lives int `gorm:"not null;default:1" json:"lives"`
+	// Интервал повторения в минутах (0 - викторина не повторяется). После завершения
+	// повторяющейся викторины планируется ее копия на следующий момент серии.
+	RecurrenceIntervalMin int `gorm:"not null;default:0" json:"recurrence_interval_min"`
+	// Повторение приостановлено: следующая викторина серии не создается
+	RecurrencePaused bool `gorm:"not null;default:false" json:"recurrence_paused"`
+	// Викторина серии, копией которой создана эта (nil - первая в серии)
+	RecurrenceSourceID *uint      `gorm:"index" json:"recurrence_source_id,omitempty"`
+	Questions          []Question `gorm:"foreignKey:QuizID" json:"questions,omitempty"`
+	CreatedAt          time.Time  `json:"created_at"`
+	UpdatedAt          time.Time  `json:"updated_at"`
+	// Видимость: public (по умолчанию), unlisted или private
+	Visibility string `gorm:"size:20;not null;default:'public'" json:"visibility"`
+	// Код приглашения в приватную викторину. Не отдается в ответах API:
+	// администратор получает его при создании и перевыпуске кода.
+	InviteCode string `gorm:"size:32" json:"-"`
+}
+
+// DefersAnswerResults сообщает, откладываются ли результаты ответов до закрытия вопроса
+func (q *Quiz) DefersAnswerResults() bool {
+	return q.DelayedResults || q.SuppressAnswerFeedback
+}
+
+// AllowsLateJoin сообщает, можно ли присоединиться к уже идущей викторине
+func (q *Quiz) AllowsLateJoin() bool {
+	return q.JoinPolicy == JoinPolicyAnytime
+}
+
+// IsSelfPaced сообщает, проходят ли игроки вопросы каждый в своем темпе
+func (q *Quiz) IsSelfPaced() bool {
+	return q.PacingMode == PacingSelfPaced
+}
+
+// SelfPacedTimeLimit возвращает общий лимит времени самостоятельного прохождения
+func (q *Quiz) SelfPacedTimeLimit() time.Duration {
+	return time.Duration(q.SelfPacedTimeLimitSec) * time.Second
+}
+
+// EliminationLives возвращает число жизней игрока в викторине (не меньше одной)
+func (q *Quiz) EliminationLives() int {
+	if q.Lives < 1 {
+		return 1
+	}
+	return q.Lives
+}
+
+// WaitsForMinPlayers сообщает, откладывается ли запуск при нехватке игроков
+func (q *Quiz) WaitsForMinPlayers() bool {
+	return q.MinPlayersPolicy != MinPlayersPolicyCancel
+}
+
+// QuestionByID возвращает вопрос викторины по ID или nil
+func (q *Quiz) QuestionByID(questionID uint) *Question {
+	for i := range q.Questions {
+		if q.Questions[i].ID == questionID {
+			return &q.Questions[i]
+		}
+	}
+	return nil
+}
+
+// HasEntryRequirements сообщает, ограничено ли участие в викторине условиями
+func (q *Quiz) HasEntryRequirements() bool {
+	return q.RequireVerifiedEmail || q.MinGamesPlayed > 0 || q.ExternalEligibilityCheck
+}
+
+// IsListed сообщает, показывается ли викторина в общих списках
+func (q *Quiz) IsListed() bool {
+	return q.Visibility == "" || q.Visibility == VisibilityPublic
+}
+
+// RequiresInviteCode сообщает, нужен ли для присоединения код приглашения
+func (q *Quiz) RequiresInviteCode() bool {
+	return q.Visibility == VisibilityPrivate
+}
+
+// CheckInviteCode проверяет код приглашения без учета регистра.
+// Для викторин, не требующих кода, всегда возвращает true.
+func (q *Quiz) CheckInviteCode(code string) bool {
+	if !q.RequiresInviteCode() {
+		return true
+	}
+	if q.InviteCode == "" || code == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(strings.ToUpper(strings.TrimSpace(code))), []byte(q.InviteCode)) == 1
+}
+
+// IsRecurring сообщает, задано ли для викторины повторение
+func (q *Quiz) IsRecurring() bool {
+	return q.RecurrenceIntervalMin > 0
+}
+
+// RecurrenceInterval возвращает интервал повторения викторины
+func (q *Quiz) RecurrenceInterval() time.Duration {
+	return time.Duration(q.RecurrenceIntervalMin) * time.Minute
+}
+
+// NextOccurrence возвращает ближайший момент после now, отстоящий от времени
+// проведения на целое число интервалов повторения. Пропущенные (например, пока
+// сервер не работал) моменты не навёрстываются. Для неповторяющейся викторины
+// возвращает нулевое время.
+func (q *Quiz) NextOccurrence(now time.Time) time.Time {
+	if !q.IsRecurring() {
+		return time.Time{}
+	}
+	return NextOccurrenceAfter(q.ScheduledTime, now, q.RecurrenceInterval())
+}
+
+// NextOccurrenceAfter возвращает ближайший момент после now, отстоящий от from
+// на целое (не меньше одного) число интервалов
+func NextOccurrenceAfter(from, now time.Time, interval time.Duration) time.Time {
+	if from.After(now) {
+		return from.Add(interval)
+	}
+	steps := now.Sub(from)/interval + 1
+	return from.Add(steps * interval)
+}
+
+// QuestionsAvailableFrom возвращает число вопросов, доступных игроку,
+// присоединившемуся на вопросе joinedAt (нумерация с 1; 0 - с самого начала)
+func (q *Quiz) QuestionsAvailableFrom(joinedAt int) int {
+	total := len(q.Questions)
+	if joinedAt <= 1 {
+		return total
+	}
+	if joinedAt > total {
+		return 0
+	}
+	return total - joinedAt + 1
+}
+
+// EffectivePointValue возвращает стоимость вопроса с учетом настроек викторины:
+// UniformPointValue заменяет PointValue вопроса, затем применяются множитель
+// сложности (при DifficultyScaling) и PointsMultiplier. Множители положительны,
+// поэтому ненулевая стоимость не округляется до нуля: наименьшая стоимость - 1.
+func (q *Quiz) EffectivePointValue(question *Question) int {
+	points := question.PointValue
+	if q.UniformPointValue > 0 {
+		points = q.UniformPointValue
+	}
+	factor := 1.0
+	if q.PointsMultiplier > 0 {
+		factor = q.PointsMultiplier
+	}
+	if q.DifficultyScaling {
+		factor *= q.DifficultyMultipliers.For(question.Difficulty)
+	}
+	if factor != 1 && points > 0 {
+		points = max(int(math.Round(float64(points)*factor)), 1)
+	}
+	return points
+}
+
+// ApplyPenalties вычитает штрафы из набранных очков, не опуская счет ниже ScoreFloor.
+// Штрафы только уменьшают счет: если очков и так меньше ScoreFloor, они не меняются.
+func (q *Quiz) ApplyPenalties(earned, penalty int) int {
+	total := earned - penalty
+	if floor := min(earned, q.ScoreFloor); total < floor {
+		return floor
+	}
+	return total
 }
 
 // IsActive проверяет, активна ли викторина
 func (q *Quiz) IsActive() bool {
-	return q.Status == "in_progress"
+	return q.Status == QuizStatusInProgress
 }
 
 // IsScheduled проверяет, запланирована ли викторина
 func (q *Quiz) IsScheduled() bool {
-	return q.Status == "scheduled"
+	return q.Status == QuizStatusScheduled
 }
 
 // IsCompleted проверяет, завершена ли викторина
 func (q *Quiz) IsCompleted() bool {
-	return q.Status == "completed"
+	return q.Status == QuizStatusCompleted
+}
+
+// IsCancelled проверяет, отменена ли викторина
+func (q *Quiz) IsCancelled() bool {
+	return q.Status == QuizStatusCancelled
 }
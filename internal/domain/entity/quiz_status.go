@@ -0,0 +1,74 @@
+package entity
+
+import (
+	"errors"
+	"fmt"
+)
+
+// QuizStatus - статус викторины
+type QuizStatus string
+
+// Статусы викторины
+const (
+	// QuizStatusScheduled - викторина создана и ждет запуска
+	QuizStatusScheduled QuizStatus = "scheduled"
+	// QuizStatusInProgress - викторина проводится
+	QuizStatusInProgress QuizStatus = "in_progress"
+	// QuizStatusCompleted - викторина завершена, результаты подсчитаны
+	QuizStatusCompleted QuizStatus = "completed"
+	// QuizStatusCancelled - викторина отменена до или во время запуска
+	QuizStatusCancelled QuizStatus = "cancelled"
+)
+
+// ErrInvalidStatusTransition возвращается при недопустимой смене статуса викторины
+var ErrInvalidStatusTransition = errors.New("invalid quiz status transition")
+
+// quizStatusTransitions - допустимые переходы между статусами викторины
+var quizStatusTransitions = map[QuizStatus][]QuizStatus{
+	// Перенос времени запланированной викторины сохраняет статус
+	QuizStatusScheduled: {QuizStatusScheduled, QuizStatusInProgress, QuizStatusCancelled},
+	// Отмена идущей викторины - запуск отклонен лимитом одновременных викторин
+	QuizStatusInProgress: {QuizStatusCompleted, QuizStatusCancelled},
+	// Завершенную викторину можно запланировать повторно
+	QuizStatusCompleted: {QuizStatusScheduled},
+	QuizStatusCancelled: nil,
+}
+
+// QuizStatuses возвращает все статусы викторины
+func QuizStatuses() []QuizStatus {
+	return []QuizStatus{QuizStatusScheduled, QuizStatusInProgress, QuizStatusCompleted, QuizStatusCancelled}
+}
+
+// IsValid сообщает, является ли статус известным
+func (s QuizStatus) IsValid() bool {
+	_, ok := quizStatusTransitions[s]
+	return ok
+}
+
+// CanTransitionTo сообщает, допустим ли переход из статуса s в next
+func (s QuizStatus) CanTransitionTo(next QuizStatus) bool {
+	for _, allowed := range quizStatusTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateStatusTransition проверяет переход между статусами викторины
+func ValidateStatusTransition(from, to QuizStatus) error {
+	if !from.CanTransitionTo(to) {
+		return fmt.Errorf("%w: %s -> %s", ErrInvalidStatusTransition, from, to)
+	}
+	return nil
+}
+
+// TransitionTo меняет статус викторины, если переход допустим. Статус
+// сохраняется в БД отдельно.
+func (q *Quiz) TransitionTo(next QuizStatus) error {
+	if err := ValidateStatusTransition(q.Status, next); err != nil {
+		return fmt.Errorf("quiz %d: %w", q.ID, err)
+	}
+	q.Status = next
+	return nil
+}
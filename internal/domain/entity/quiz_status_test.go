@@ -0,0 +1,58 @@
+package entity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuizStatus_Transitions(t *testing.T) {
+	legal := map[QuizStatus][]QuizStatus{
+		QuizStatusScheduled:  {QuizStatusScheduled, QuizStatusInProgress, QuizStatusCancelled},
+		QuizStatusInProgress: {QuizStatusCompleted, QuizStatusCancelled},
+		QuizStatusCompleted:  {QuizStatusScheduled},
+		QuizStatusCancelled:  {},
+	}
+
+	for _, from := range QuizStatuses() {
+		for _, to := range QuizStatuses() {
+			want := false
+			for _, allowed := range legal[from] {
+				if allowed == to {
+					want = true
+				}
+			}
+			assert.Equal(t, want, from.CanTransitionTo(to), "%s -> %s", from, to)
+
+			err := ValidateStatusTransition(from, to)
+			if want {
+				assert.NoError(t, err, "%s -> %s", from, to)
+			} else {
+				assert.ErrorIs(t, err, ErrInvalidStatusTransition, "%s -> %s", from, to)
+			}
+		}
+	}
+}
+
+func TestQuizStatus_Unknown(t *testing.T) {
+	unknown := QuizStatus("draft")
+
+	assert.False(t, unknown.IsValid())
+	for _, status := range QuizStatuses() {
+		assert.True(t, status.IsValid(), status)
+		assert.False(t, unknown.CanTransitionTo(status))
+		assert.False(t, status.CanTransitionTo(unknown))
+	}
+}
+
+func TestQuiz_TransitionTo(t *testing.T) {
+	quiz := &Quiz{ID: 7, Status: QuizStatusCompleted}
+	require.NoError(t, quiz.TransitionTo(QuizStatusScheduled))
+	assert.Equal(t, QuizStatusScheduled, quiz.Status)
+
+	quiz.Status = QuizStatusCancelled
+	err := quiz.TransitionTo(QuizStatusScheduled)
+	assert.ErrorIs(t, err, ErrInvalidStatusTransition)
+	assert.Equal(t, QuizStatusCancelled, quiz.Status, "статус не меняется при недопустимом переходе")
+}
@@ -0,0 +1,103 @@
+package entity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuiz_EffectivePointValue(t *testing.T) {
+	question := &Question{PointValue: 30}
+
+	cases := []struct {
+		name string
+		quiz Quiz
+		want int
+	}{
+		{"без переопределений", Quiz{}, 30},
+		{"множитель по умолчанию", Quiz{PointsMultiplier: 1}, 30},
+		{"единая стоимость важнее стоимости вопроса", Quiz{UniformPointValue: 10}, 10},
+		{"множитель", Quiz{PointsMultiplier: 1.5}, 45},
+		{"единая стоимость и множитель", Quiz{UniformPointValue: 10, PointsMultiplier: 2.5}, 25},
+		{"сложность без режима не учитывается", Quiz{DifficultyMultipliers: DifficultyMultipliers{Medium: 3}}, 30},
+		{"сложность medium по умолчанию", Quiz{DifficultyScaling: true}, 30},
+		{"сложность и множитель", Quiz{DifficultyScaling: true, DifficultyMultipliers: DifficultyMultipliers{Medium: 1.5}, PointsMultiplier: 2}, 90},
+		{"малый множитель не обнуляет стоимость", Quiz{UniformPointValue: 1, PointsMultiplier: 0.4}, 1},
+		{"сложность и малый множитель не обнуляют стоимость", Quiz{DifficultyScaling: true, DifficultyMultipliers: DifficultyMultipliers{Medium: 0.1}, PointsMultiplier: 0.1}, 1},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, tc.quiz.EffectivePointValue(question))
+		})
+	}
+}
+
+// TestQuiz_EffectivePointValue_Difficulty: при одинаковой базовой стоимости
+// сложный вопрос стоит больше простого
+func TestQuiz_EffectivePointValue_Difficulty(t *testing.T) {
+	quiz := Quiz{DifficultyScaling: true}
+	easy := &Question{PointValue: 20, Difficulty: DifficultyEasy}
+	hard := &Question{PointValue: 20, Difficulty: DifficultyHard}
+
+	assert.Equal(t, 10, quiz.EffectivePointValue(easy))
+	assert.Equal(t, 40, quiz.EffectivePointValue(hard))
+
+	quiz.DifficultyMultipliers = DifficultyMultipliers{Easy: 1, Medium: 1.2, Hard: 1.5}
+	assert.Equal(t, 20, quiz.EffectivePointValue(easy))
+	assert.Equal(t, 30, quiz.EffectivePointValue(hard))
+	assert.Greater(t, quiz.EffectivePointValue(hard), quiz.EffectivePointValue(easy))
+}
+
+func TestQuiz_ApplyPenalties(t *testing.T) {
+	cases := []struct {
+		name    string
+		quiz    Quiz
+		earned  int
+		penalty int
+		want    int
+	}{
+		{"без штрафов", Quiz{}, 40, 0, 40},
+		{"штраф меньше очков", Quiz{}, 40, 15, 25},
+		{"счет не уходит ниже нуля", Quiz{}, 10, 30, 0},
+		{"нижняя граница", Quiz{ScoreFloor: 20}, 40, 30, 20},
+		{"штраф не поднимает счет до границы", Quiz{ScoreFloor: 20}, 10, 5, 10},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, tc.quiz.ApplyPenalties(tc.earned, tc.penalty))
+		})
+	}
+}
+
+func TestQuiz_QuestionsAvailableFrom(t *testing.T) {
+	quiz := &Quiz{Questions: make([]Question, 5)}
+	assert.Equal(t, 5, quiz.QuestionsAvailableFrom(0))
+	assert.Equal(t, 5, quiz.QuestionsAvailableFrom(1))
+	assert.Equal(t, 1, quiz.QuestionsAvailableFrom(5))
+	assert.Equal(t, 0, quiz.QuestionsAvailableFrom(6))
+}
+
+func TestQuiz_NextOccurrence(t *testing.T) {
+	start := time.Date(2026, 3, 6, 19, 0, 0, 0, time.UTC)
+	daily := Quiz{ScheduledTime: start, RecurrenceIntervalMin: 24 * 60}
+
+	cases := []struct {
+		name string
+		now  time.Time
+		want time.Time
+	}{
+		{"викторина еще впереди - следующий интервал после нее", start.Add(-time.Hour), start.Add(24 * time.Hour)},
+		{"ровно в момент проведения", start, start.Add(24 * time.Hour)},
+		{"сразу после завершения", start.Add(30 * time.Minute), start.Add(24 * time.Hour)},
+		{"пропущенные моменты не навёрстываются", start.Add(72*time.Hour + time.Minute), start.Add(96 * time.Hour)},
+		{"граница интервала", start.Add(48 * time.Hour), start.Add(72 * time.Hour)},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, daily.NextOccurrence(tc.now))
+		})
+	}
+
+	assert.True(t, (&Quiz{ScheduledTime: start}).NextOccurrence(start).IsZero(), "неповторяющаяся викторина")
+}
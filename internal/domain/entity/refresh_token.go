@@ -17,6 +17,12 @@ type RefreshToken struct {
 	IsExpired bool       `json:"is_expired"`
 	RevokedAt *time.Time `json:"revoked_at,omitempty"`
 	Reason    string     `json:"reason,omitempty"`
+	// Абсолютный срок сессии: токены, выданные при обновлении, наследуют его
+	// и не продлевают сессию дальше (nil - без ограничения)
+	SessionExpiresAt *time.Time `json:"session_expires_at,omitempty"`
+	// Местоположение по IP-адресу (пусто, если не определено)
+	Country string `gorm:"size:2" json:"country,omitempty"`
+	City    string `gorm:"size:100" json:"city,omitempty"`
 }
 
 // NewRefreshToken создает новый refresh токен
@@ -50,6 +56,17 @@ func (rt *RefreshToken) SessionInfo() map[string]interface{} {
 		"is_expired": rt.IsExpired,
 	}
 
+	if rt.SessionExpiresAt != nil {
+		info["session_expires_at"] = rt.SessionExpiresAt
+	}
+
+	if rt.Country != "" {
+		info["country"] = rt.Country
+	}
+	if rt.City != "" {
+		info["city"] = rt.City
+	}
+
 	if rt.RevokedAt != nil {
 		info["revoked_at"] = rt.RevokedAt
 	}
@@ -1,6 +1,7 @@
 package entity
 
 import (
+	"sort"
 	"time"
 )
 
@@ -20,4 +21,58 @@ type Result struct {
 	IsEliminated   bool      `json:"is_eliminated"` // Добавленное поле: выбыл ли пользователь во время игры
 	CompletedAt    time.Time `json:"completed_at"`
 	CreatedAt      time.Time `json:"created_at"`
+	// Страна, из которой игрок подключился к викторине (если определена)
+	Country string `gorm:"size:2" json:"country,omitempty"`
+}
+
+// Accuracy возвращает долю правильных ответов среди доступных игроку вопросов
+func (r *Result) Accuracy() float64 {
+	if r.TotalQuestions <= 0 {
+		return 0
+	}
+	return float64(r.CorrectAnswers) / float64(r.TotalQuestions)
+}
+
+// RankResults упорядочивает результаты, проставляет ранги и отмечает победителей.
+// Возвращает число победителей; призовой фонд распределяет вызывающий.
+//
+// Если byAccuracy = false, все играли одинаковое число вопросов (totalQuestions):
+// ранг определяется очками, победитель - ответивший правильно на все вопросы.
+// Если byAccuracy = true (викторина с присоединением во время проведения), у игроков
+// разное число доступных вопросов (Result.TotalQuestions): сначала сравнивается доля
+// правильных ответов, затем очки. Победителем и в этом случае может стать только
+// ответивший правильно на все totalQuestions вопросов: поздно присоединившийся
+// игрок получает место по точности, но не долю призового фонда.
+func RankResults(results []Result, totalQuestions int, byAccuracy bool) int {
+	sort.SliceStable(results, func(i, j int) bool {
+		if byAccuracy {
+			if ai, aj := results[i].Accuracy(), results[j].Accuracy(); ai != aj {
+				return ai > aj
+			}
+		}
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].CorrectAnswers > results[j].CorrectAnswers
+	})
+
+	winners := 0
+	for i := range results {
+		r := &results[i]
+
+		// Одинаковые показатели - одинаковый ранг, следующие места пропускаются
+		if i == 0 {
+			r.Rank = 1
+		} else if prev := &results[i-1]; r.Score == prev.Score && (!byAccuracy || r.Accuracy() == prev.Accuracy()) {
+			r.Rank = prev.Rank
+		} else {
+			r.Rank = i + 1
+		}
+
+		r.IsWinner = totalQuestions > 0 && r.CorrectAnswers == totalQuestions && !r.IsEliminated
+		if r.IsWinner {
+			winners++
+		}
+	}
+	return winners
 }
@@ -0,0 +1,76 @@
+package entity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRankResults_ByScore(t *testing.T) {
+	results := []Result{
+		{UserID: 1, Score: 30, CorrectAnswers: 3, TotalQuestions: 5},
+		{UserID: 2, Score: 50, CorrectAnswers: 5, TotalQuestions: 5},
+		{UserID: 3, Score: 30, CorrectAnswers: 3, TotalQuestions: 5},
+		{UserID: 4, Score: 50, CorrectAnswers: 5, TotalQuestions: 5, IsEliminated: true},
+	}
+
+	winners := RankResults(results, 5, false)
+
+	assert.Equal(t, 1, winners)
+	ranks := map[uint]int{}
+	for _, r := range results {
+		ranks[r.UserID] = r.Rank
+		assert.Equal(t, r.UserID == 2, r.IsWinner, "user %d", r.UserID)
+	}
+	assert.Equal(t, map[uint]int{2: 1, 4: 1, 1: 3, 3: 3}, ranks)
+}
+
+// TestRankResults_LateJoinerAtQuestionThree: игрок присоединился на 3-м вопросе из 5
+// и ответил правильно на все три доступных ему вопроса
+func TestRankResults_LateJoinerAtQuestionThree(t *testing.T) {
+	quiz := &Quiz{JoinPolicy: JoinPolicyAnytime, Questions: make([]Question, 5)}
+	lateTotal := quiz.QuestionsAvailableFrom(3)
+	assert.Equal(t, 3, lateTotal)
+
+	results := []Result{
+		{UserID: 1, Score: 40, CorrectAnswers: 4, TotalQuestions: 5}, // ошибся на последнем вопросе
+		{UserID: 2, Score: 30, CorrectAnswers: 3, TotalQuestions: lateTotal},
+		{UserID: 3, Score: 50, CorrectAnswers: 5, TotalQuestions: 5},
+		{UserID: 4, Score: 20, CorrectAnswers: 2, TotalQuestions: lateTotal},
+	}
+
+	winners := RankResults(results, len(quiz.Questions), quiz.AllowsLateJoin())
+
+	// Доля правильных ответов важнее очков: поздний игрок со 100% выше игрока с 80%
+	order := make([]uint, len(results))
+	for i, r := range results {
+		order[i] = r.UserID
+	}
+	assert.Equal(t, []uint{3, 2, 1, 4}, order)
+	assert.Equal(t, []int{1, 2, 3, 4}, []int{results[0].Rank, results[1].Rank, results[2].Rank, results[3].Rank})
+
+	// Поздний игрок не претендует на приз: победитель должен ответить на все вопросы
+	assert.Equal(t, 1, winners)
+	assert.True(t, results[0].IsWinner)
+	assert.False(t, results[1].IsWinner)
+	assert.False(t, results[2].IsWinner)
+	assert.False(t, results[3].IsWinner)
+}
+
+// TestRankResults_LateJoinerOnLastQuestion: игрок, ответивший только на последний
+// вопрос, не должен забирать долю призового фонда у прошедших всю викторину
+func TestRankResults_LateJoinerOnLastQuestion(t *testing.T) {
+	quiz := &Quiz{JoinPolicy: JoinPolicyAnytime, Questions: make([]Question, 5)}
+
+	results := []Result{
+		{UserID: 1, Score: 50, CorrectAnswers: 5, TotalQuestions: 5},
+		{UserID: 2, Score: 10, CorrectAnswers: 1, TotalQuestions: quiz.QuestionsAvailableFrom(5)},
+	}
+
+	winners := RankResults(results, len(quiz.Questions), quiz.AllowsLateJoin())
+
+	assert.Equal(t, 1, winners)
+	for _, r := range results {
+		assert.Equal(t, r.UserID == 1, r.IsWinner, "user %d", r.UserID)
+	}
+}
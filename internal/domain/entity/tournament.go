@@ -0,0 +1,41 @@
+package entity
+
+import "time"
+
+// Правила подсчета очков турнира
+const (
+	// TournamentScoringSum - очки игрока складываются по всем викторинам турнира
+	TournamentScoringSum = "sum"
+	// TournamentScoringBestN - складываются только BestN лучших результатов игрока
+	TournamentScoringBestN = "best_n"
+)
+
+// Tournament объединяет несколько викторин (например, многодневный турнир)
+// с общим зачетом: очки игрока накапливаются по всем викторинам турнира
+type Tournament struct {
+	ID          uint   `gorm:"primaryKey" json:"id"`
+	Title       string `gorm:"size:100;not null" json:"title"`
+	Description string `gorm:"size:500" json:"description,omitempty"`
+	// Правило подсчета: sum (по умолчанию) или best_n
+	ScoringMode string `gorm:"size:20;not null;default:'sum'" json:"scoring_mode"`
+	// Сколько лучших результатов игрока учитывается при best_n
+	BestN     int       `gorm:"not null;default:0" json:"best_n,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// CountedResults возвращает, сколько лучших результатов игрока учитывается
+// в зачете (0 - все результаты)
+func (t *Tournament) CountedResults() int {
+	if t.ScoringMode == TournamentScoringBestN {
+		return t.BestN
+	}
+	return 0
+}
+
+// TournamentQuiz - викторина, входящая в турнир
+type TournamentQuiz struct {
+	TournamentID uint      `gorm:"primaryKey" json:"tournament_id"`
+	QuizID       uint      `gorm:"primaryKey" json:"quiz_id"`
+	CreatedAt    time.Time `json:"created_at"`
+}
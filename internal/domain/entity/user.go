@@ -21,6 +21,37 @@ type User struct {
 	HighestScore   int       `json:"highest_score"`
 	CreatedAt      time.Time `json:"created_at"`
 	UpdatedAt      time.Time `json:"updated_at"`
+	// Роль: player (по умолчанию), moderator или admin
+	Role string `gorm:"size:20;not null;default:player" json:"role"`
+	// Email подтвержден (проставляется при подтверждении адреса)
+	EmailVerified bool `gorm:"not null;default:false" json:"email_verified"`
+}
+
+// Роли пользователей. Старшая роль включает права младших.
+const (
+	RolePlayer = "player"
+	// RoleModerator управляет проведением викторин, но не пользователями и ключами
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
+// roleLevels - старшинство ролей
+var roleLevels = map[string]int{
+	RolePlayer:    0,
+	RoleModerator: 1,
+	RoleAdmin:     2,
+}
+
+// IsValidRole проверяет, что роль известна
+func IsValidRole(role string) bool {
+	_, ok := roleLevels[role]
+	return ok
+}
+
+// RoleAtLeast сообщает, что роль role не ниже required. Пустая или неизвестная
+// роль (например, в токенах, выданных до появления ролей) считается ролью игрока.
+func RoleAtLeast(role, required string) bool {
+	return roleLevels[role] >= roleLevels[required]
 }
 
 // BeforeSave хеширует пароль перед сохранением, только если он не является bcrypt-хешем
@@ -11,9 +11,12 @@ type UserAnswer struct {
 	QuizID            uint      `gorm:"not null" json:"quiz_id"`
 	QuestionID        uint      `gorm:"not null" json:"question_id"`
 	SelectedOption    int       `json:"selected_option"`
+	SelectedOrder     IntArray  `gorm:"type:jsonb" json:"selected_order,omitempty"` // Ответ на вопрос ordering
 	IsCorrect         bool      `json:"is_correct"`
 	ResponseTimeMs    int64     `json:"response_time_ms"`
 	Score             int       `json:"score"`
+	Penalty           int       `gorm:"not null;default:0" json:"penalty,omitempty"`  // Штраф за неверный ответ
+	FastestFinger     bool      `gorm:"not null;default:false" json:"fastest_finger"` // Первый правильный ответ на вопрос
 	IsEliminated      bool      `json:"is_eliminated"`
 	EliminationReason string    `json:"elimination_reason,omitempty"` // Причина выбывания
 	CreatedAt         time.Time `json:"created_at"`
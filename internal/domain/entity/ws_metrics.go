@@ -0,0 +1,68 @@
+package entity
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// JSONMap - пользовательский тип для хранения произвольного JSON-объекта в JSONB
+type JSONMap map[string]interface{}
+
+// Scan реализует интерфейс sql.Scanner для JSONMap
+func (m *JSONMap) Scan(value interface{}) error {
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New("failed to unmarshal JSONB value")
+	}
+
+	return json.Unmarshal(bytes, m)
+}
+
+// Value реализует интерфейс driver.Valuer для JSONMap
+func (m JSONMap) Value() (driver.Value, error) {
+	if m == nil {
+		return nil, nil
+	}
+	return json.Marshal(m)
+}
+
+// WSMetricsSnapshot представляет снимок метрик WebSocket-хаба в момент времени.
+// Снимки сохраняются периодически и используются для анализа после событий.
+type WSMetricsSnapshot struct {
+	ID                uint      `gorm:"primaryKey" json:"id"`
+	InstanceID        string    `gorm:"size:64;index" json:"instance_id"`
+	CollectedAt       time.Time `gorm:"not null;index" json:"collected_at"`
+	ActiveConnections int64     `json:"active_connections"`
+	TotalConnections  int64     `json:"total_connections"`
+	MessagesSent      int64     `json:"messages_sent"`
+	MessagesReceived  int64     `json:"messages_received"`
+	MessagesDropped   int64     `json:"messages_dropped"`
+	ConnectionErrors  int64     `json:"connection_errors"`
+	AlertsTotal       int64     `json:"alerts_total"`
+	HotShards         int       `json:"hot_shards"`
+	MaxShardLoad      float64   `json:"max_shard_load"`
+	ShardLoads        JSONMap   `gorm:"type:jsonb" json:"shard_loads"` // shard_id -> метрики шарда
+}
+
+// TableName задает имя таблицы для GORM
+func (WSMetricsSnapshot) TableName() string {
+	return "ws_metrics"
+}
+
+// WSAlert - алерт WebSocket-хаба, сохраненный для разбора инцидентов
+type WSAlert struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	InstanceID string    `gorm:"size:64;index" json:"instance_id"`
+	Type       string    `gorm:"size:64;not null;index" json:"type"`
+	Severity   string    `gorm:"size:16;not null" json:"severity"`
+	Message    string    `gorm:"type:text" json:"message"`
+	Metadata   JSONMap   `gorm:"type:jsonb" json:"metadata"`
+	CreatedAt  time.Time `gorm:"not null;index" json:"created_at"`
+}
+
+// TableName задает имя таблицы для GORM
+func (WSAlert) TableName() string {
+	return "ws_alerts"
+}
@@ -9,6 +9,8 @@ type CacheRepository interface {
 	Set(key string, value interface{}, expiration time.Duration) error
 	Get(key string) (string, error)
 	Delete(key string) error
+	// DeleteIfExists удаляет ключ и сообщает, был ли он удален этим вызовом
+	DeleteIfExists(key string) (bool, error)
 	Increment(key string) (int64, error)
 	SetJSON(key string, value interface{}, expiration time.Duration) error
 	GetJSON(key string, dest interface{}) error
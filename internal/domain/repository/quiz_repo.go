@@ -1,6 +1,8 @@
 package repository
 
 import (
+	"time"
+
 	"github.com/yourusername/trivia-api/internal/domain/entity"
 )
 
@@ -8,11 +10,29 @@ import (
 type QuizRepository interface {
 	Create(quiz *entity.Quiz) error
 	GetByID(id uint) (*entity.Quiz, error)
-	GetActive() (*entity.Quiz, error)
+	// ListActive возвращает проводимые сейчас викторины в порядке возрастания ID
+	ListActive() ([]entity.Quiz, error)
 	GetScheduled() ([]entity.Quiz, error)
 	GetWithQuestions(id uint) (*entity.Quiz, error)
-	UpdateStatus(quizID uint, status string) error
+	// UpdateStatus меняет статус викторины с from на to. Если статус в БД уже
+	// не from, возвращает ошибку с entity.ErrInvalidStatusTransition.
+	UpdateStatus(quizID uint, from, to entity.QuizStatus) error
+	// UpdateScheduledTime меняет время проведения викторины, пока она в статусе
+	// status. Иначе возвращает ошибку с entity.ErrInvalidStatusTransition.
+	UpdateScheduledTime(quizID uint, status entity.QuizStatus, scheduledTime time.Time) error
 	Update(quiz *entity.Quiz) error
 	List(limit, offset int) ([]entity.Quiz, error)
+	// ListPublic возвращает публичные викторины с пагинацией в порядке убывания ID
+	ListPublic(limit, offset int) ([]entity.Quiz, error)
 	Delete(id uint) error
+	// CreateWithQuestions создает викторину и ее вопросы в одной транзакции
+	CreateWithQuestions(quiz *entity.Quiz, questions []entity.Question) error
+	// ListFinishedBefore возвращает завершенные и отмененные викторины,
+	// запланированные раньше cutoff, в порядке возрастания ID, кроме викторин турниров
+	ListFinishedBefore(cutoff time.Time, limit int) ([]entity.Quiz, error)
+	// ListRecurrenceDue возвращает завершенные повторяющиеся викторины без паузы,
+	// для которых еще не создана следующая викторина серии, в порядке возрастания ID
+	ListRecurrenceDue(limit int) ([]entity.Quiz, error)
+	// CountByStatus возвращает число викторин по статусам
+	CountByStatus() (map[string]int64, error)
 }
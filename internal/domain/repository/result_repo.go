@@ -7,6 +7,9 @@ import (
 // ResultRepository определяет методы для работы с результатами
 type ResultRepository interface {
 	SaveUserAnswer(answer *entity.UserAnswer) error
+	// SaveUserAnswersBatch сохраняет ответы одной вставкой в транзакции: при
+	// ошибке не сохраняется ни один ответ пакета
+	SaveUserAnswersBatch(answers []*entity.UserAnswer) error
 	GetUserAnswers(userID uint, quizID uint) ([]entity.UserAnswer, error)
 	GetQuizUserAnswers(quizID uint) ([]entity.UserAnswer, error)
 	SaveResult(result *entity.Result) error
@@ -15,4 +18,12 @@ type ResultRepository interface {
 	GetUserResults(userID uint, limit, offset int) ([]entity.Result, error)
 	CalculateRanks(quizID uint) error
 	GetQuizWinners(quizID uint) ([]entity.Result, error)
+	// CountQuizData возвращает количество ответов и результатов викторины
+	CountQuizData(quizID uint) (answers int64, results int64, err error)
+	// CountAnswers возвращает общее число ответов во всех викторинах
+	CountAnswers() (int64, error)
+	// DeleteQuizUserAnswersBatch удаляет не более batchSize ответов викторины
+	DeleteQuizUserAnswersBatch(quizID uint, batchSize int) (int64, error)
+	// DeleteQuizResultsBatch удаляет не более batchSize результатов викторины
+	DeleteQuizResultsBatch(quizID uint, batchSize int) (int64, error)
 }
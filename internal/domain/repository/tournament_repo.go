@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"github.com/yourusername/trivia-api/internal/domain/entity"
+)
+
+// TournamentRepository определяет методы для работы с турнирами
+type TournamentRepository interface {
+	Create(tournament *entity.Tournament) error
+	GetByID(id uint) (*entity.Tournament, error)
+	// AddQuiz добавляет викторину в турнир; повторное добавление ничего не меняет
+	AddQuiz(tournamentID, quizID uint) error
+	// GetQuizIDs возвращает ID викторин турнира в порядке добавления
+	GetQuizIDs(tournamentID uint) ([]uint, error)
+	// GetResults возвращает результаты всех викторин турнира
+	GetResults(tournamentID uint) ([]entity.Result, error)
+}
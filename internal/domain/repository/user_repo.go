@@ -7,6 +7,8 @@ import (
 // UserRepository определяет методы для работы с пользователями
 type UserRepository interface {
 	Create(user *entity.User) error
+	// CreateBatch создает пользователей в одной транзакции: при ошибке не создается ни один
+	CreateBatch(users []*entity.User) error
 	GetByID(id uint) (*entity.User, error)
 	GetByEmail(email string) (*entity.User, error)
 	GetByUsername(username string) (*entity.User, error)
@@ -16,4 +18,6 @@ type UserRepository interface {
 	UpdateScore(userID uint, score int) error
 	IncrementGamesPlayed(userID uint) error
 	List(limit, offset int) ([]entity.User, error)
+	// Count возвращает число зарегистрированных пользователей
+	Count() (int64, error)
 }
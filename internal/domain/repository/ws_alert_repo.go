@@ -0,0 +1,21 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/yourusername/trivia-api/internal/domain/entity"
+)
+
+// WSAlertRepository определяет методы для хранения истории алертов WebSocket
+type WSAlertRepository interface {
+	// SaveAlert сохраняет алерт
+	SaveAlert(ctx context.Context, alert *entity.WSAlert) error
+
+	// GetAlerts возвращает алерты начиная с since, от новых к старым. Пустые
+	// severity и alertType не ограничивают выборку, limit <= 0 - без ограничения.
+	GetAlerts(ctx context.Context, severity, alertType string, since time.Time, limit int) ([]entity.WSAlert, error)
+
+	// DeleteOlderThan удаляет алерты старше указанного времени
+	DeleteOlderThan(ctx context.Context, cutoffTime time.Time) (int64, error)
+}
@@ -0,0 +1,21 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/yourusername/trivia-api/internal/domain/entity"
+)
+
+// WSMetricsRepository определяет методы для хранения истории метрик WebSocket
+type WSMetricsRepository interface {
+	// SaveSnapshot сохраняет снимок метрик
+	SaveSnapshot(ctx context.Context, snapshot *entity.WSMetricsSnapshot) error
+
+	// GetSnapshots возвращает снимки за указанный период, упорядоченные по времени.
+	// Пустой instanceID означает все экземпляры.
+	GetSnapshots(ctx context.Context, from, to time.Time, instanceID string, limit int) ([]entity.WSMetricsSnapshot, error)
+
+	// DeleteOlderThan удаляет снимки старше указанного времени
+	DeleteOlderThan(ctx context.Context, cutoffTime time.Time) (int64, error)
+}
@@ -0,0 +1,106 @@
+// Package apierror задает единый формат ответов об ошибках HTTP API.
+// Все обработчики и middleware возвращают ошибки в виде APIError, поэтому клиенту
+// достаточно проверить поле code, не разбирая текст сообщения.
+package apierror
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/trivia-api/internal/i18n"
+)
+
+// Code - стабильный машиночитаемый код ошибки. Значения не меняются между
+// версиями API; message предназначен для человека и может меняться.
+type Code string
+
+const (
+	// Некорректный запрос: тело не разбирается, неверный параметр пути или запроса
+	CodeInvalidRequest Code = "invalid_request"
+	// Ошибки валидации полей; details содержит список {field, message}
+	CodeValidation Code = "validation_error"
+
+	// Аутентификация
+	CodeUnauthorized       Code = "unauthorized"
+	CodeTokenMissing       Code = "token_missing"
+	CodeTokenFormat        Code = "token_format"
+	CodeTokenInvalid       Code = "token_invalid"
+	CodeTokenExpired       Code = "token_expired"
+	CodeCSRFMismatch       Code = "csrf_mismatch"
+	CodeInvalidCredentials Code = "invalid_credentials"
+
+	// Доступ и состояние ресурсов
+	CodeForbidden       Code = "forbidden"
+	CodeNotFound        Code = "not_found"
+	CodeSessionNotFound Code = "session_not_found"
+	CodeConflict        Code = "conflict"
+	// Вход отклонен: открыто максимальное количество сессий (details: active_sessions, session_limit)
+	CodeTooManySessions Code = "too_many_sessions"
+	// Превышен лимит запросов; заголовок Retry-After содержит паузу в секундах
+	CodeRateLimited Code = "rate_limited"
+
+	// Ошибки сервера
+	CodeServiceUnavailable Code = "service_unavailable"
+	CodeInternal           Code = "internal_error"
+)
+
+// RequestIDHeader - заголовок с идентификатором запроса (выставляется прокси).
+// Если он есть, идентификатор возвращается в ответе об ошибке для поиска в логах.
+const RequestIDHeader = "X-Request-ID"
+
+// APIError - тело ответа об ошибке
+type APIError struct {
+	Code      Code        `json:"code"`
+	Message   string      `json:"message"`
+	Details   interface{} `json:"details,omitempty"`
+	RequestID string      `json:"request_id,omitempty"`
+}
+
+// Error реализует интерфейс error
+func (e *APIError) Error() string {
+	return string(e.Code) + ": " + e.Message
+}
+
+// Respond отправляет ответ об ошибке
+func Respond(c *gin.Context, status int, code Code, message string) {
+	RespondDetails(c, status, code, message, nil)
+}
+
+// RespondDetails отправляет ответ об ошибке с дополнительными данными
+func RespondDetails(c *gin.Context, status int, code Code, message string, details interface{}) {
+	c.JSON(status, newError(c, code, message, details))
+}
+
+// Abort отправляет ответ об ошибке и прерывает цепочку обработчиков (для middleware)
+func Abort(c *gin.Context, status int, code Code, message string) {
+	c.AbortWithStatusJSON(status, newError(c, code, message, nil))
+}
+
+// Lang возвращает язык сообщений для клиента по заголовку Accept-Language
+func Lang(c *gin.Context) i18n.Lang {
+	return i18n.Parse(c.GetHeader(i18n.AcceptLanguageHeader))
+}
+
+// RespondKey отправляет ответ об ошибке с сообщением key из каталога i18n на
+// языке клиента
+func RespondKey(c *gin.Context, status int, code Code, key i18n.Key, args ...interface{}) {
+	RespondDetails(c, status, code, i18n.T(Lang(c), key, args...), nil)
+}
+
+// RespondKeyDetails отправляет ответ об ошибке с сообщением из каталога и дополнительными данными
+func RespondKeyDetails(c *gin.Context, status int, code Code, key i18n.Key, details interface{}) {
+	RespondDetails(c, status, code, i18n.T(Lang(c), key), details)
+}
+
+// AbortKey отправляет ответ об ошибке с сообщением из каталога и прерывает
+// цепочку обработчиков (для middleware)
+func AbortKey(c *gin.Context, status int, code Code, key i18n.Key, args ...interface{}) {
+	Abort(c, status, code, i18n.T(Lang(c), key, args...))
+}
+
+func newError(c *gin.Context, code Code, message string, details interface{}) *APIError {
+	return &APIError{
+		Code:      code,
+		Message:   message,
+		Details:   details,
+		RequestID: c.GetHeader(RequestIDHeader),
+	}
+}
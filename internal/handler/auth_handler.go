@@ -1,15 +1,22 @@
 package handler
 
 import (
+	"encoding/csv"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"log"
+	"mime"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/yourusername/trivia-api/internal/domain/entity"
+	"github.com/yourusername/trivia-api/internal/handler/apierror"
+	"github.com/yourusername/trivia-api/internal/i18n"
 	"github.com/yourusername/trivia-api/internal/service"
 	"github.com/yourusername/trivia-api/internal/websocket"
 	"github.com/yourusername/trivia-api/pkg/auth/manager"
@@ -77,14 +84,24 @@ type AuthResponse struct {
 
 // SessionInfo представляет информацию о сессии
 type SessionInfo struct {
-	ID        uint      `json:"id"`
-	DeviceID  string    `json:"device_id"`
-	IPAddress string    `json:"ip_address"`
-	UserAgent string    `json:"user_agent"`
+	ID        uint   `json:"id"`
+	DeviceID  string `json:"device_id"`
+	IPAddress string `json:"ip_address"`
+	UserAgent string `json:"user_agent"`
+	// Местоположение по IP-адресу (если определено)
+	Country   string    `json:"country,omitempty"`
+	City      string    `json:"city,omitempty"`
 	CreatedAt time.Time `json:"created_at"`
 	ExpiresAt time.Time `json:"expires_at"`
 }
 
+// DeviceSessions представляет сессии пользователя, сгруппированные по устройству
+type DeviceSessions struct {
+	DeviceID   string        `json:"device_id"`
+	Sessions   []SessionInfo `json:"sessions"`
+	LastActive time.Time     `json:"last_active"`
+}
+
 // ChangePasswordRequest представляет запрос на изменение пароля
 type ChangePasswordRequest struct {
 	OldPassword string `json:"old_password" binding:"required"`
@@ -102,18 +119,68 @@ type RevokeSessionRequest struct {
 	SessionID uint `json:"session_id" binding:"required"`
 }
 
+// RevokeDeviceRequest представляет запрос на отзыв всех сессий устройства
+type RevokeDeviceRequest struct {
+	DeviceID string `json:"device_id" binding:"required"`
+}
+
+// AdminRevokeUserSessionsRequest представляет запрос администратора на завершение всех сессий пользователя
+type AdminRevokeUserSessionsRequest struct {
+	UserID uint `json:"user_id" binding:"required"`
+}
+
+// AdminSetUserRoleRequest представляет запрос администратора на назначение роли пользователю
+type AdminSetUserRoleRequest struct {
+	UserID uint   `json:"user_id" binding:"required"`
+	Role   string `json:"role" binding:"required"`
+}
+
+// AdminSetEmailVerifiedRequest представляет запрос администратора на подтверждение email пользователя
+type AdminSetEmailVerifiedRequest struct {
+	UserID   uint  `json:"user_id" binding:"required"`
+	Verified *bool `json:"verified" binding:"required"`
+}
+
+// AdminWSTestRequest представляет запрос администратора на пробную доставку события WebSocket
+type AdminWSTestRequest struct {
+	UserID  uint            `json:"user_id" binding:"required"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+// AdminImportUsersRequest представляет JSON-запрос администратора на импорт пользователей
+type AdminImportUsersRequest struct {
+	Users []AdminImportUserRow `json:"users" binding:"required"`
+	// Atomic: при ошибке в любой строке не создавать ни одного пользователя
+	Atomic bool `json:"atomic"`
+}
+
+// AdminImportUserRow - строка импорта; без пароля будет сгенерирован временный
+type AdminImportUserRow struct {
+	Username string `json:"username"`
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+// LogoutAllRequest представляет необязательное тело запроса на выход со всех устройств
+type LogoutAllRequest struct {
+	// Устройство, с которого выполнен выход (передается в событии как initiated_by_device)
+	DeviceID string `json:"device_id" binding:"omitempty"`
+	// Соединение WebSocket вкладки-инициатора, которому событие не отправляется
+	ExcludeConnectionID string `json:"exclude_connection_id" binding:"omitempty"`
+}
+
 // Register обрабатывает запрос на регистрацию
 func (h *AuthHandler) Register(c *gin.Context) {
 	var req RegisterRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
 		return
 	}
 
 	// Регистрируем пользователя
 	user, err := h.authService.RegisterUser(req.Username, req.Email, req.Password)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
 		return
 	}
 
@@ -122,7 +189,7 @@ func (h *AuthHandler) Register(c *gin.Context) {
 	// Генерируем токены сразу после регистрации
 	tokenResp, err := h.tokenManager.GenerateTokenPair(user.ID, "", c.ClientIP(), c.Request.UserAgent())
 	if err != nil {
-		h.handleAuthError(c, fmt.Errorf("failed to generate tokens after registration: %w", err))
+		respondServiceError(c, fmt.Errorf("failed to generate tokens after registration: %w", err))
 		return
 	}
 
@@ -154,7 +221,7 @@ func (h *AuthHandler) Register(c *gin.Context) {
 func (h *AuthHandler) Login(c *gin.Context) {
 	var req LoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data", "details": err.Error()})
+		apierror.RespondKeyDetails(c, http.StatusBadRequest, apierror.CodeInvalidRequest, i18n.AuthInvalidRequest, err.Error())
 		return
 	}
 
@@ -172,7 +239,7 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	// Используем обновленный AuthService.LoginUser, который возвращает *manager.TokenResponse
 	tokenResp, err := h.authService.LoginUser(req.Email, req.Password, deviceID, ipAddress, userAgent)
 	if err != nil {
-		h.handleAuthError(c, err)
+		respondServiceError(c, err)
 		return
 	}
 
@@ -182,7 +249,7 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	if sessionErr != nil || len(activeSessions) == 0 {
 		log.Printf("[AuthHandler] Ошибка получения refresh токена после логина для пользователя ID=%d: %v", tokenResp.UserID, sessionErr)
 		// Фатальная ошибка? Или продолжить без refresh куки?
-		h.handleAuthError(c, fmt.Errorf("failed to retrieve refresh token after login"))
+		respondServiceError(c, fmt.Errorf("failed to retrieve refresh token after login"))
 		return
 	} else {
 		h.tokenManager.SetRefreshTokenCookie(c.Writer, activeSessions[0].Token) // Берем самый новый
@@ -213,14 +280,14 @@ func (h *AuthHandler) RefreshToken(c *gin.Context) {
 	// Получаем refresh токен из HttpOnly куки
 	refreshToken, err := h.tokenManager.GetRefreshTokenFromCookie(c.Request)
 	if err != nil {
-		h.handleAuthError(c, err)
+		respondServiceError(c, err)
 		return
 	}
 
 	// Получаем CSRF токен из заголовка
 	csrfToken := c.GetHeader(manager.CSRFHeader)
 	if csrfToken == "" {
-		h.handleAuthError(c, manager.NewTokenError(manager.InvalidCSRFToken, "CSRF token missing from header", nil))
+		respondServiceError(c, manager.NewTokenError(manager.InvalidCSRFToken, "CSRF token missing from header", nil))
 		return
 	}
 
@@ -235,7 +302,7 @@ func (h *AuthHandler) RefreshToken(c *gin.Context) {
 	// Используем обновленный AuthService.RefreshTokens
 	tokenResp, err := h.authService.RefreshTokens(refreshToken, csrfToken, deviceID, ipAddress, userAgent)
 	if err != nil {
-		h.handleAuthError(c, err)
+		respondServiceError(c, err)
 		return
 	}
 
@@ -246,7 +313,7 @@ func (h *AuthHandler) RefreshToken(c *gin.Context) {
 	activeSessions, sessionErr := h.authService.GetUserActiveSessions(tokenResp.UserID)
 	if sessionErr != nil || len(activeSessions) == 0 {
 		log.Printf("[AuthHandler] Ошибка получения нового refresh токена после обновления для пользователя ID=%d: %v", tokenResp.UserID, sessionErr)
-		h.handleAuthError(c, fmt.Errorf("failed to retrieve new refresh token after refresh"))
+		respondServiceError(c, fmt.Errorf("failed to retrieve new refresh token after refresh"))
 		return
 	} else {
 		h.tokenManager.SetRefreshTokenCookie(c.Writer, activeSessions[0].Token) // Устанавливаем новую куку
@@ -267,13 +334,13 @@ func (h *AuthHandler) GetMe(c *gin.Context) {
 	// Получаем ID пользователя из контекста (установлен middleware)
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		apierror.RespondKey(c, http.StatusUnauthorized, apierror.CodeUnauthorized, i18n.AuthUnauthorized)
 		return
 	}
 
 	user, err := h.authService.GetUserByID(userID.(uint))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondServiceError(c, err)
 		return
 	}
 
@@ -285,6 +352,7 @@ func (h *AuthHandler) GetMe(c *gin.Context) {
 		"games_played":    user.GamesPlayed,
 		"total_score":     user.TotalScore,
 		"highest_score":   user.HighestScore,
+		"role":            user.Role,
 	})
 }
 
@@ -305,12 +373,12 @@ func (h *AuthHandler) UpdateProfile(c *gin.Context) {
 
 	var req UpdateProfileRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
 		return
 	}
 
 	if err := h.authService.UpdateUserProfile(userID, req.Username, req.ProfilePicture); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
 		return
 	}
 
@@ -337,7 +405,7 @@ func (h *AuthHandler) Logout(c *gin.Context) {
 		}
 		// Другая ошибка при чтении cookie
 		log.Printf("[AuthHandler] Logout: Error reading refresh token cookie: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not process logout due to cookie error"})
+		apierror.RespondKey(c, http.StatusInternalServerError, apierror.CodeInternal, i18n.AuthLogoutFailed)
 		return
 	}
 
@@ -419,23 +487,34 @@ func (h *AuthHandler) LogoutAllDevices(c *gin.Context) {
 		return // checkCSRFToken handles response and abort
 	}
 
+	// Тело необязательно: старые клиенты отправляют запрос без него
+	var req LogoutAllRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			apierror.RespondKey(c, http.StatusBadRequest, apierror.CodeInvalidRequest, i18n.AuthInvalidRequest)
+			return
+		}
+	}
+
 	// 1. Инвалидировать все refresh токены пользователя
 	if err := h.authService.RevokeAllUserSessions(userID, "user_logout_all"); err != nil {
 		log.Printf("[AuthHandler] Ошибка при выходе из всех сессий: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось выйти из всех сессий", "error_type": "internal_error"})
+		apierror.RespondKey(c, http.StatusInternalServerError, apierror.CodeInternal, i18n.AuthLogoutAllFailed)
 		return
 	}
 
 	// Отправляем событие WebSocket для пользователя
 	if h.wsHub != nil {
 		logoutEvent := map[string]interface{}{
-			"event":     "logout_all_devices",
-			"user_id":   userID,
-			"timestamp": time.Now().Format(time.RFC3339),
-			"reason":    "user_logout_all",
+			"event":               "logout_all_devices",
+			"user_id":             userID,
+			"timestamp":           time.Now().Format(time.RFC3339),
+			"reason":              "user_logout_all",
+			"initiated_by_device": req.DeviceID,
 		}
 
-		if err := h.sendWebSocketNotification(userID, logoutEvent); err != nil {
+		// Вкладка-инициатор уже знает о выходе, поэтому ее соединение можно исключить
+		if err := h.sendWebSocketNotificationExcept(userID, logoutEvent, req.ExcludeConnectionID); err != nil {
 			log.Printf("[AuthHandler] Ошибка отправки уведомления через WebSocket: %v", err)
 			// Обработка ошибки не критична для основного функционала
 		}
@@ -453,33 +532,51 @@ func (h *AuthHandler) GetActiveSessions(c *gin.Context) {
 	// Получаем ID пользователя из контекста
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		apierror.RespondKey(c, http.StatusUnauthorized, apierror.CodeUnauthorized, i18n.AuthUnauthorized)
 		return
 	}
 
 	// Получаем список сессий
 	sessions, err := h.authService.GetUserActiveSessions(userID.(uint))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get active sessions"})
+		apierror.RespondKey(c, http.StatusInternalServerError, apierror.CodeInternal, i18n.AuthSessionsFailed)
 		return
 	}
 
-	// Формируем ответ
+	// Формируем ответ, дополнительно группируя сессии по устройствам
 	var result []SessionInfo
+	devices := make([]*DeviceSessions, 0)
+	deviceIndex := make(map[string]*DeviceSessions)
 	for _, session := range sessions {
-		result = append(result, SessionInfo{
+		info := SessionInfo{
 			ID:        session.ID,
 			DeviceID:  session.DeviceID,
 			IPAddress: session.IPAddress,
 			UserAgent: session.UserAgent,
+			Country:   session.Country,
+			City:      session.City,
 			CreatedAt: session.CreatedAt,
 			ExpiresAt: session.ExpiresAt,
-		})
+		}
+		result = append(result, info)
+
+		device, ok := deviceIndex[session.DeviceID]
+		if !ok {
+			device = &DeviceSessions{DeviceID: session.DeviceID}
+			deviceIndex[session.DeviceID] = device
+			devices = append(devices, device)
+		}
+		device.Sessions = append(device.Sessions, info)
+		if session.CreatedAt.After(device.LastActive) {
+			device.LastActive = session.CreatedAt
+		}
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"sessions": result,
-		"count":    len(result),
+		"sessions":     result,
+		"count":        len(result),
+		"devices":      devices,
+		"device_count": len(devices),
 	})
 }
 
@@ -490,7 +587,7 @@ func (h *AuthHandler) ResetAuth(c *gin.Context) {
 	// Проверяем, что пользователь - администратор
 	isAdmin, exists := c.Get("is_admin")
 	if !exists || !isAdmin.(bool) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
+		apierror.RespondKey(c, http.StatusForbidden, apierror.CodeForbidden, i18n.AuthAdminRequired)
 		return
 	}
 
@@ -501,7 +598,7 @@ func (h *AuthHandler) ResetAuth(c *gin.Context) {
 
 	var req ResetRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
 		return
 	}
 
@@ -538,10 +635,7 @@ func (h *AuthHandler) CheckRefreshToken(c *gin.Context) {
 				refreshToken = req.RefreshToken
 			} else {
 				log.Printf("[AuthHandler] Ошибка валидации данных при проверке refresh-токена: %v", err)
-				c.JSON(http.StatusBadRequest, gin.H{
-					"error":      "Требуется refresh-токен",
-					"error_type": "token_invalid",
-				})
+				apierror.RespondKey(c, http.StatusBadRequest, apierror.CodeTokenInvalid, i18n.AuthRefreshTokenRequired)
 				return
 			}
 		}
@@ -552,10 +646,7 @@ func (h *AuthHandler) CheckRefreshToken(c *gin.Context) {
 		}
 		if err := c.ShouldBindJSON(&req); err != nil {
 			log.Printf("[AuthHandler] Ошибка валидации данных при проверке refresh-токена: %v", err)
-			c.JSON(http.StatusBadRequest, gin.H{
-				"error":      "Требуется refresh-токен",
-				"error_type": "token_invalid",
-			})
+			apierror.RespondKey(c, http.StatusBadRequest, apierror.CodeTokenInvalid, i18n.AuthRefreshTokenRequired)
 			return
 		}
 		refreshToken = req.RefreshToken
@@ -565,10 +656,7 @@ func (h *AuthHandler) CheckRefreshToken(c *gin.Context) {
 	isValid, err := h.authService.CheckRefreshToken(refreshToken)
 	if err != nil {
 		log.Printf("[AuthHandler] Ошибка при проверке refresh-токена: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":      "Ошибка проверки токена",
-			"error_type": "server_error",
-		})
+		apierror.RespondKey(c, http.StatusInternalServerError, apierror.CodeInternal, i18n.AuthTokenCheckFailed)
 		return
 	}
 
@@ -595,10 +683,7 @@ func (h *AuthHandler) GetTokenInfo(c *gin.Context) {
 				refreshToken = req.RefreshToken
 			} else {
 				log.Printf("[AuthHandler] Ошибка валидации данных при получении информации о токене: %v", err)
-				c.JSON(http.StatusBadRequest, gin.H{
-					"error":      "Требуется refresh-токен",
-					"error_type": "token_invalid",
-				})
+				apierror.RespondKey(c, http.StatusBadRequest, apierror.CodeTokenInvalid, i18n.AuthRefreshTokenRequired)
 				return
 			}
 		}
@@ -609,10 +694,7 @@ func (h *AuthHandler) GetTokenInfo(c *gin.Context) {
 		}
 		if err := c.ShouldBindJSON(&req); err != nil {
 			log.Printf("[AuthHandler] Ошибка валидации данных при получении информации о токене: %v", err)
-			c.JSON(http.StatusBadRequest, gin.H{
-				"error":      "Требуется refresh-токен",
-				"error_type": "token_invalid",
-			})
+			apierror.RespondKey(c, http.StatusBadRequest, apierror.CodeTokenInvalid, i18n.AuthRefreshTokenRequired)
 			return
 		}
 		refreshToken = req.RefreshToken
@@ -625,10 +707,7 @@ func (h *AuthHandler) GetTokenInfo(c *gin.Context) {
 		info, err := h.tokenManager.GetTokenInfo(refreshToken)
 		if err != nil {
 			log.Printf("[AuthHandler] Ошибка при получении информации о токене: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error":      "Ошибка получения информации о токене",
-				"error_type": "server_error",
-			})
+			respondServiceError(c, err)
 			return
 		}
 		tokenInfo = info
@@ -637,10 +716,7 @@ func (h *AuthHandler) GetTokenInfo(c *gin.Context) {
 		info, err := h.authService.GetTokenInfo(refreshToken)
 		if err != nil {
 			log.Printf("[AuthHandler] Ошибка при получении информации о токене: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error":      "Ошибка получения информации о токене",
-				"error_type": "server_error",
-			})
+			apierror.RespondKey(c, http.StatusInternalServerError, apierror.CodeInternal, i18n.AuthTokenInfoFailed)
 			return
 		}
 
@@ -664,7 +740,7 @@ func (h *AuthHandler) DebugToken(c *gin.Context) {
 	// Этот метод доступен только для администраторов
 	isAdmin, exists := c.Get("is_admin")
 	if !exists || !isAdmin.(bool) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Только для администраторов"})
+		apierror.RespondKey(c, http.StatusForbidden, apierror.CodeForbidden, i18n.AuthAdminRequired)
 		return
 	}
 
@@ -674,7 +750,7 @@ func (h *AuthHandler) DebugToken(c *gin.Context) {
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		log.Printf("[AuthHandler] Ошибка валидации данных при отладке токена: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Требуется токен"})
+		apierror.RespondKey(c, http.StatusBadRequest, apierror.CodeInvalidRequest, i18n.AuthTokenRequired)
 		return
 	}
 
@@ -697,7 +773,7 @@ func (h *AuthHandler) ChangePassword(c *gin.Context) {
 	var req ChangePasswordRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		log.Printf("[ChangePassword] Ошибка валидации запроса: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
 		return
 	}
 
@@ -705,7 +781,7 @@ func (h *AuthHandler) ChangePassword(c *gin.Context) {
 
 	if err := h.authService.ChangePassword(userID, req.OldPassword, req.NewPassword); err != nil {
 		log.Printf("[ChangePassword] Ошибка при изменении пароля: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
 		return
 	}
 
@@ -718,7 +794,7 @@ func (h *AuthHandler) AdminResetPassword(c *gin.Context) {
 	// Проверяем, что пользователь - администратор
 	isAdmin, exists := c.Get("is_admin")
 	if !exists || !isAdmin.(bool) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Только для администраторов"})
+		apierror.RespondKey(c, http.StatusForbidden, apierror.CodeForbidden, i18n.AuthAdminRequired)
 		return
 	}
 
@@ -729,20 +805,20 @@ func (h *AuthHandler) AdminResetPassword(c *gin.Context) {
 
 	var req ResetPasswordRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
 		return
 	}
 
 	// Находим пользователя по email
 	user, err := h.authService.GetUserByEmail(req.Email)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Пользователь не найден"})
+		apierror.RespondKey(c, http.StatusNotFound, apierror.CodeNotFound, i18n.AuthUserNotFound)
 		return
 	}
 
 	// Обновляем пароль без проверки старого пароля
 	if err := h.authService.AdminResetPassword(user.ID, req.Password); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при сбросе пароля"})
+		apierror.RespondKey(c, http.StatusInternalServerError, apierror.CodeInternal, i18n.AuthPasswordResetFailed)
 		return
 	}
 
@@ -756,6 +832,259 @@ func (h *AuthHandler) AdminResetPassword(c *gin.Context) {
 	})
 }
 
+// AdminRevokeUserSessions завершает все сессии другого пользователя (только для
+// администраторов): отзываются refresh-токены, инвалидируются JWT, удаляются
+// CSRF-токены и закрываются соединения WebSocket с причиной "session_revoked"
+func (h *AuthHandler) AdminRevokeUserSessions(c *gin.Context) {
+	adminID := c.MustGet("user_id").(uint)
+
+	// CSRF Protection Check (Prefer middleware if router access is available)
+	if !h.checkCSRFToken(c, adminID) { // Check against admin's CSRF token
+		return // checkCSRFToken handles response and abort
+	}
+
+	var req AdminRevokeUserSessionsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindingError(c, err)
+		return
+	}
+
+	if err := h.authService.AdminRevokeUserSessions(adminID, req.UserID); err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	disconnected := 0
+	if disconnector, ok := h.wsHub.(websocket.UserDisconnector); ok {
+		disconnected = disconnector.DisconnectUser(fmt.Sprintf("%d", req.UserID), "session_revoked")
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "Все сессии пользователя завершены",
+		"user_id":      req.UserID,
+		"disconnected": disconnected,
+	})
+}
+
+// AdminSetUserRole назначает пользователю роль player, moderator или admin
+// (только для администраторов). Новая роль действует после обновления токенов.
+func (h *AuthHandler) AdminSetUserRole(c *gin.Context) {
+	adminID := c.MustGet("user_id").(uint)
+
+	// CSRF Protection Check (Prefer middleware if router access is available)
+	if !h.checkCSRFToken(c, adminID) { // Check against admin's CSRF token
+		return // checkCSRFToken handles response and abort
+	}
+
+	var req AdminSetUserRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindingError(c, err)
+		return
+	}
+
+	user, err := h.authService.AdminSetUserRole(adminID, req.UserID, req.Role)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"id":       user.ID,
+		"username": user.Username,
+		"role":     user.Role,
+	})
+}
+
+// AdminSetEmailVerified подтверждает email пользователя или снимает подтверждение
+// (только для администраторов). Нужен для викторин с require_verified_email.
+func (h *AuthHandler) AdminSetEmailVerified(c *gin.Context) {
+	adminID := c.MustGet("user_id").(uint)
+
+	// CSRF Protection Check (Prefer middleware if router access is available)
+	if !h.checkCSRFToken(c, adminID) { // Check against admin's CSRF token
+		return // checkCSRFToken handles response and abort
+	}
+
+	var req AdminSetEmailVerifiedRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindingError(c, err)
+		return
+	}
+
+	user, err := h.authService.AdminSetEmailVerified(adminID, req.UserID, *req.Verified)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"id":             user.ID,
+		"username":       user.Username,
+		"email_verified": user.EmailVerified,
+	})
+}
+
+// Способы доставки пробного события WebSocket
+const (
+	wsTestDeliveryLocal        = "local"         // Поставлено в очередь соединения на этом экземпляре
+	wsTestDeliveryRelayed      = "relayed"       // Передано остальным экземплярам кластера, доставка не подтверждается
+	wsTestDeliveryNotConnected = "not_connected" // Пользователь не подключен, кластерный режим выключен
+)
+
+// AdminTestWebSocketDelivery отправляет пользователю пробное событие admin:ws_test
+// (только для администраторов) и сообщает, удалось ли поставить его в очередь
+// соединения. Помогает разобраться в жалобах на отсутствие событий.
+func (h *AuthHandler) AdminTestWebSocketDelivery(c *gin.Context) {
+	adminID := c.MustGet("user_id").(uint)
+
+	// CSRF Protection Check (Prefer middleware if router access is available)
+	if !h.checkCSRFToken(c, adminID) { // Check against admin's CSRF token
+		return // checkCSRFToken handles response and abort
+	}
+
+	var req AdminWSTestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindingError(c, err)
+		return
+	}
+
+	if h.wsHub == nil {
+		apierror.Respond(c, http.StatusServiceUnavailable, apierror.CodeServiceUnavailable, "WebSocket is disabled")
+		return
+	}
+
+	message, err := json.Marshal(websocket.Event{
+		Type: "admin:ws_test",
+		Data: gin.H{"payload": req.Payload, "sent_at": time.Now().Format(time.RFC3339)},
+	})
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidRequest, "Invalid payload")
+		return
+	}
+
+	userID := fmt.Sprintf("%d", req.UserID)
+	connections, cluster := 0, false
+	if inspector, ok := h.wsHub.(websocket.DeliveryInspector); ok {
+		connections, cluster = inspector.UserConnectionCount(userID), inspector.ClusterEnabled()
+	}
+
+	// SendToUser возвращает true только при локальной доставке; ShardedHub в кластерном
+	// режиме сам пересылает сообщение остальным экземплярам
+	delivered := h.wsHub.SendToUser(userID, message)
+	delivery := wsTestDeliveryNotConnected
+	switch {
+	case delivered:
+		delivery = wsTestDeliveryLocal
+	case cluster:
+		delivery = wsTestDeliveryRelayed
+	}
+
+	log.Printf("[AuthHandler] Администратор ID=%d проверил доставку WebSocket пользователю ID=%d: %s", adminID, req.UserID, delivery)
+	c.JSON(http.StatusOK, gin.H{
+		"user_id":     req.UserID,
+		"delivered":   delivered,
+		"delivery":    delivery,
+		"connections": connections,
+		"cluster":     cluster,
+	})
+}
+
+// Максимальный размер тела запроса импорта пользователей
+const maxUserImportBodyBytes = 1 << 20
+
+// AdminImportUsers создает пользователей из JSON или CSV (только для администраторов).
+// CSV (Content-Type: text/csv) начинается со строки заголовка с колонками username,
+// email и необязательной password; атомарность задается параметром ?atomic=true.
+// Ответ содержит результат по каждой строке и сгенерированные временные пароли.
+func (h *AuthHandler) AdminImportUsers(c *gin.Context) {
+	adminID := c.MustGet("user_id").(uint)
+
+	// CSRF Protection Check (Prefer middleware if router access is available)
+	if !h.checkCSRFToken(c, adminID) { // Check against admin's CSRF token
+		return // checkCSRFToken handles response and abort
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxUserImportBodyBytes)
+
+	var rows []service.UserImportRow
+	var atomic bool
+	if mediaType, _, _ := mime.ParseMediaType(c.ContentType()); mediaType == "text/csv" {
+		var err error
+		if rows, err = parseUserImportCSV(c.Request.Body); err != nil {
+			apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
+			return
+		}
+		if atomic, err = strconv.ParseBool(c.DefaultQuery("atomic", "false")); err != nil {
+			apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidRequest, "atomic must be true or false")
+			return
+		}
+	} else {
+		var req AdminImportUsersRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondBindingError(c, err)
+			return
+		}
+		for _, u := range req.Users {
+			rows = append(rows, service.UserImportRow{Username: u.Username, Email: u.Email, Password: u.Password})
+		}
+		atomic = req.Atomic
+	}
+
+	result, err := h.authService.ImportUsers(adminID, rows, atomic)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// parseUserImportCSV разбирает CSV со строкой заголовка username,email[,password]
+func parseUserImportCSV(r io.Reader) ([]service.UserImportRow, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1 // Колонка password в строке может отсутствовать
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("CSV header is required: %v", err)
+	}
+	columns := map[string]int{"username": -1, "email": -1, "password": -1}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if _, ok := columns[name]; ok {
+			columns[name] = i
+		}
+	}
+	if columns["username"] < 0 || columns["email"] < 0 {
+		return nil, errors.New("CSV header must contain username and email columns")
+	}
+
+	field := func(record []string, column string) string {
+		if i := columns[column]; i >= 0 && i < len(record) {
+			return record[i]
+		}
+		return ""
+	}
+
+	var rows []service.UserImportRow
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV: %v", err)
+		}
+		rows = append(rows, service.UserImportRow{
+			Username: field(record, "username"),
+			Email:    field(record, "email"),
+			Password: field(record, "password"),
+		})
+	}
+	return rows, nil
+}
+
 // RevokeSession обрабатывает запрос на отзыв отдельной сессии
 func (h *AuthHandler) RevokeSession(c *gin.Context) {
 	userID := c.MustGet("user_id").(uint) // ID пользователя, который делает запрос
@@ -767,19 +1096,19 @@ func (h *AuthHandler) RevokeSession(c *gin.Context) {
 
 	var req RevokeSessionRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректные данные запроса", "error_type": "invalid_request"})
+		apierror.RespondKey(c, http.StatusBadRequest, apierror.CodeInvalidRequest, i18n.AuthInvalidRequest)
 		return
 	}
 
 	// Проверяем, что сессия принадлежит пользователю
 	token, err := h.authService.GetRefreshTokenByID(req.SessionID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Сессия не найдена", "error_type": "session_not_found"})
+		apierror.RespondKey(c, http.StatusNotFound, apierror.CodeSessionNotFound, i18n.AuthSessionNotFound)
 		return
 	}
 
 	if token.UserID != userID {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Доступ запрещен", "error_type": "forbidden"})
+		apierror.RespondKey(c, http.StatusForbidden, apierror.CodeForbidden, i18n.AuthAccessDenied)
 		return
 	}
 
@@ -793,7 +1122,7 @@ func (h *AuthHandler) RevokeSession(c *gin.Context) {
 	err = h.authService.RevokeSessionByID(req.SessionID, reason)
 	if err != nil {
 		log.Printf("[AuthHandler] Ошибка при отзыве сессии: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при отзыве сессии", "error_type": "internal_error"})
+		apierror.RespondKey(c, http.StatusInternalServerError, apierror.CodeInternal, i18n.AuthRevokeSessionFailed)
 		return
 	}
 
@@ -818,12 +1147,76 @@ func (h *AuthHandler) RevokeSession(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Сессия успешно завершена", "session_id": req.SessionID})
 }
 
+// RevokeDevice обрабатывает запрос на отзыв всех сессий пользователя на одном устройстве
+func (h *AuthHandler) RevokeDevice(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+
+	// CSRF Protection Check (Prefer middleware if router access is available)
+	if !h.checkCSRFToken(c, userID) {
+		return // checkCSRFToken handles response and abort
+	}
+
+	var req RevokeDeviceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.RespondKey(c, http.StatusBadRequest, apierror.CodeInvalidRequest, i18n.AuthInvalidRequest)
+		return
+	}
+
+	reason := c.Query("reason")
+	if reason == "" {
+		reason = "user_revoked_device"
+	}
+
+	// Сервис ищет сессии только среди сессий текущего пользователя,
+	// поэтому устройство другого пользователя будет считаться ненайденным
+	revoked, err := h.authService.RevokeDeviceSessions(userID, req.DeviceID, reason)
+	if err != nil {
+		if errors.Is(err, service.ErrSessionNotFound) {
+			apierror.RespondKey(c, http.StatusNotFound, apierror.CodeSessionNotFound, i18n.AuthDeviceSessionsAbsent)
+			return
+		}
+		log.Printf("[AuthHandler] Ошибка при отзыве сессий устройства %s: %v", req.DeviceID, err)
+		apierror.RespondKey(c, http.StatusInternalServerError, apierror.CodeInternal, i18n.AuthRevokeDeviceFailed)
+		return
+	}
+
+	// WebSocket-клиенты не привязаны к устройствам, поэтому уведомляем все подключения
+	// пользователя и закрываем их с причиной "session_revoked", как при завершении
+	// всех сессий: отозванное устройство не сможет переподключиться, а остальные
+	// переподключатся со своими действующими токенами
+	disconnected := 0
+	if h.wsHub != nil {
+		deviceEvent := map[string]interface{}{
+			"event":     "device_revoked",
+			"device_id": req.DeviceID,
+			"timestamp": time.Now().Format(time.RFC3339),
+			"reason":    reason,
+			"user_id":   userID,
+		}
+
+		if err := h.sendWebSocketNotification(userID, deviceEvent); err != nil {
+			log.Printf("[AuthHandler] Ошибка отправки уведомления через WebSocket: %v", err)
+		}
+
+		if disconnector, ok := h.wsHub.(websocket.UserDisconnector); ok {
+			disconnected = disconnector.DisconnectUser(fmt.Sprintf("%d", userID), "session_revoked")
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":          "Сессии устройства успешно завершены",
+		"device_id":        req.DeviceID,
+		"revoked_sessions": revoked,
+		"disconnected":     disconnected,
+	})
+}
+
 // GetSessionLimit возвращает текущий лимит сессий для пользователя
 func (h *AuthHandler) GetSessionLimit(c *gin.Context) {
 	// Получаем ID пользователя из контекста
 	userID, exists := c.Get("userID")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Пользователь не аутентифицирован", "error_type": "unauthorized"})
+		apierror.RespondKey(c, http.StatusUnauthorized, apierror.CodeUnauthorized, i18n.AuthUnauthorized)
 		return
 	}
 
@@ -840,7 +1233,7 @@ func (h *AuthHandler) GetSessionLimit(c *gin.Context) {
 	sessions, err := h.authService.GetUserActiveSessions(userID.(uint))
 	if err != nil {
 		log.Printf("[AuthHandler] Ошибка при получении активных сессий: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при получении активных сессий", "error_type": "internal_error"})
+		apierror.RespondKey(c, http.StatusInternalServerError, apierror.CodeInternal, i18n.AuthSessionsFailed)
 		return
 	}
 
@@ -856,7 +1249,7 @@ func (h *AuthHandler) UpdateSessionLimit(c *gin.Context) {
 	// Проверяем права администратора
 	isAdmin, exists := c.Get("is_admin")
 	if !exists || !isAdmin.(bool) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Требуются права администратора", "error_type": "forbidden"})
+		apierror.RespondKey(c, http.StatusForbidden, apierror.CodeForbidden, i18n.AuthAdminRequired)
 		return
 	}
 
@@ -870,7 +1263,7 @@ func (h *AuthHandler) UpdateSessionLimit(c *gin.Context) {
 		Limit int `json:"limit" binding:"required,min=1,max=100"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректные данные запроса", "error_type": "invalid_request"})
+		apierror.RespondKey(c, http.StatusBadRequest, apierror.CodeInvalidRequest, i18n.AuthInvalidRequest)
 		return
 	}
 
@@ -902,7 +1295,7 @@ func (h *AuthHandler) GenerateWsTicket(c *gin.Context) {
 	// Получаем ID пользователя из контекста (установлен middleware)
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized", "error_type": "token_missing"})
+		apierror.RespondKey(c, http.StatusUnauthorized, apierror.CodeTokenMissing, i18n.AuthUnauthorized)
 		return
 	}
 
@@ -912,17 +1305,18 @@ func (h *AuthHandler) GenerateWsTicket(c *gin.Context) {
 		// Если email нет в контексте, получаем из БД
 		user, err := h.authService.GetUserByID(userID.(uint))
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user data"})
+			apierror.RespondKey(c, http.StatusInternalServerError, apierror.CodeInternal, i18n.AuthUserDataFailed)
 			return
 		}
 		email = user.Email
 	}
 
 	// Генерируем WS-тикет через JWTService
-	ticket, err := h.authService.GenerateWsTicket(userID.(uint), email.(string))
+	// Роль установлена RequireAuth с учетом администратора с ID=1
+	ticket, err := h.authService.GenerateWsTicket(userID.(uint), email.(string), c.GetString("role"))
 	if err != nil {
 		log.Printf("[AuthHandler] Ошибка генерации WS-тикета: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate WebSocket ticket"})
+		apierror.RespondKey(c, http.StatusInternalServerError, apierror.CodeInternal, i18n.AuthWSTicketFailed)
 		return
 	}
 
@@ -962,18 +1356,12 @@ func (h *AuthHandler) checkCSRFToken(c *gin.Context, userID uint) bool {
 
 	csrfToken := c.GetHeader(manager.CSRFHeader)
 	if csrfToken == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":      "CSRF токен отсутствует",
-			"error_type": "csrf_mismatch",
-		})
+		apierror.RespondKey(c, http.StatusBadRequest, apierror.CodeCSRFMismatch, i18n.AuthCSRFMissing)
 		return false
 	}
 
 	if !h.tokenManager.VerifyCSRFToken(userID, csrfToken) {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":      "Неверный CSRF токен",
-			"error_type": "csrf_mismatch",
-		})
+		apierror.RespondKey(c, http.StatusBadRequest, apierror.CodeCSRFMismatch, i18n.AuthCSRFInvalid)
 		return false
 	}
 
@@ -991,33 +1379,6 @@ func (h *AuthHandler) handleTokenResponse(c *gin.Context, user *entity.User, tok
 	})
 }
 
-// handleAuthError обрабатывает ошибки аутентификации и возвращает соответствующие HTTP-ответы
-func (h *AuthHandler) handleAuthError(c *gin.Context, err error) {
-	var tokenErr *manager.TokenError
-	if errors.As(err, &tokenErr) {
-		switch tokenErr.Type {
-		case manager.ExpiredRefreshToken, manager.ExpiredAccessToken:
-			c.JSON(http.StatusUnauthorized, gin.H{"error": tokenErr.Message, "error_type": "token_expired"})
-		case manager.InvalidRefreshToken, manager.InvalidAccessToken:
-			c.JSON(http.StatusUnauthorized, gin.H{"error": tokenErr.Message, "error_type": "token_invalid"})
-		case manager.InvalidCSRFToken:
-			c.JSON(http.StatusForbidden, gin.H{"error": tokenErr.Message, "error_type": "csrf_mismatch"})
-		case manager.UserNotFound:
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials", "error_type": "invalid_credentials"})
-		case manager.TokenGenerationFailed:
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process request", "error_type": "token_generation_failed"})
-		default:
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "An internal error occurred", "error_type": "internal_server_error"})
-		}
-	} else if strings.Contains(err.Error(), "неверные учетные данные") || strings.Contains(err.Error(), "invalid email or password") {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials", "error_type": "invalid_credentials"})
-	} else {
-		// Общая ошибка
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "An internal error occurred", "error_type": "internal_server_error"})
-	}
-	log.Printf("[AuthHandler] Auth Error: %v", err) // Логируем реальную ошибку
-}
-
 // sendWebSocketNotification отправляет уведомление через WebSocket
 func (h *AuthHandler) sendWebSocketNotification(userID uint, event map[string]interface{}) error {
 	if h.wsHub == nil {
@@ -1036,3 +1397,14 @@ func (h *AuthHandler) sendWebSocketNotification(userID uint, event map[string]in
 
 	return nil
 }
+
+// sendWebSocketNotificationExcept отправляет уведомление всем соединениям пользователя,
+// кроме excludeConnectionID. Если хаб не поддерживает исключение, уведомление
+// получают все соединения.
+func (h *AuthHandler) sendWebSocketNotificationExcept(userID uint, event map[string]interface{}, excludeConnectionID string) error {
+	sender, ok := h.wsHub.(websocket.ExcludingSender)
+	if !ok || excludeConnectionID == "" {
+		return h.sendWebSocketNotification(userID, event)
+	}
+	return sender.SendJSONToUserExcept(fmt.Sprintf("%d", userID), event, excludeConnectionID)
+}
@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourusername/trivia-api/internal/middleware"
+	"github.com/yourusername/trivia-api/internal/websocket"
+)
+
+// deliveryHub - хаб с одним локально подключенным пользователем
+type deliveryHub struct {
+	userMessageHub
+	connected string
+	cluster   bool
+}
+
+func (h *deliveryHub) SendToUser(userID string, message []byte) bool {
+	if userID != h.connected {
+		return false
+	}
+	return h.userMessageHub.SendToUser(userID, message)
+}
+
+func (h *deliveryHub) UserConnectionCount(userID string) int {
+	if userID == h.connected {
+		return 1
+	}
+	return 0
+}
+
+func (h *deliveryHub) ClusterEnabled() bool { return h.cluster }
+
+var _ websocket.DeliveryInspector = (*deliveryHub)(nil)
+
+func newWSTestRouter(hub *deliveryHub) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	h := &AuthHandler{wsHub: hub}
+	router := gin.New()
+	router.POST("/ws-test", func(c *gin.Context) { c.Set("user_id", uint(1)) }, middleware.RateLimitPerUser(2, time.Minute), h.AdminTestWebSocketDelivery)
+	return router
+}
+
+func postWSTest(router *gin.Engine, body string) (*httptest.ResponseRecorder, map[string]interface{}) {
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/ws-test", strings.NewReader(body)))
+	var resp map[string]interface{}
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	return w, resp
+}
+
+func TestAdminTestWebSocketDelivery(t *testing.T) {
+	hub := &deliveryHub{connected: "7"}
+	router := newWSTestRouter(hub)
+
+	w, resp := postWSTest(router, `{"user_id": 7, "payload": {"ping": 1}}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, true, resp["delivered"])
+	assert.Equal(t, "local", resp["delivery"])
+	assert.Equal(t, float64(1), resp["connections"])
+	require.Len(t, hub.Messages(), 1)
+	assert.Contains(t, hub.Messages()[0], `"type":"admin:ws_test"`)
+	assert.Contains(t, hub.Messages()[0], `"payload":{"ping":1}`)
+
+	w, resp = postWSTest(router, `{"user_id": 8}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, false, resp["delivered"])
+	assert.Equal(t, "not_connected", resp["delivery"])
+	assert.Equal(t, float64(0), resp["connections"])
+
+	// Лимит запросов администратора исчерпан
+	w, _ = postWSTest(router, `{"user_id": 7}`)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+}
+
+func TestAdminTestWebSocketDelivery_RelayedInCluster(t *testing.T) {
+	router := newWSTestRouter(&deliveryHub{cluster: true})
+
+	w, resp := postWSTest(router, `{"user_id": 7}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, false, resp["delivered"])
+	assert.Equal(t, "relayed", resp["delivery"])
+	assert.Equal(t, true, resp["cluster"])
+}
@@ -0,0 +1,110 @@
+package dto
+
+import (
+	"sort"
+
+	"github.com/yourusername/trivia-api/internal/domain/entity"
+	"github.com/yourusername/trivia-api/internal/handler/helper"
+)
+
+// AnswerReviewItem представляет вопрос викторины вместе с ответом игрока на него
+type AnswerReviewItem struct {
+	QuestionID     uint                    `json:"question_id"`
+	QuestionNumber int                     `json:"question_number"`
+	Type           string                  `json:"type"`
+	Text           string                  `json:"text"`
+	Options        []helper.QuestionOption `json:"options"`
+	Answered       bool                    `json:"answered"`
+	SelectedOption int                     `json:"selected_option,omitempty"`
+	SelectedOrder  []int                   `json:"selected_order,omitempty"`
+	ResponseTimeMs int64                   `json:"response_time_ms,omitempty"`
+
+	// Заполняются только для завершенных викторин
+	CorrectOption *int  `json:"correct_option,omitempty"`
+	CorrectOrder  []int `json:"correct_order,omitempty"`
+	IsCorrect     *bool `json:"is_correct,omitempty"`
+	Score         *int  `json:"score,omitempty"`
+}
+
+// AnswerHistoryResponse представляет разбор ответов игрока по викторине
+type AnswerHistoryResponse struct {
+	QuizID         uint               `json:"quiz_id"`
+	QuizStatus     string             `json:"quiz_status"`
+	Revealed       bool               `json:"revealed"` // Раскрыты ли правильные ответы и очки
+	AnsweredCount  int                `json:"answered_count"`
+	TotalQuestions int                `json:"total_questions"`
+	TotalScore     *int               `json:"total_score,omitempty"`
+	Answers        []AnswerReviewItem `json:"answers"`
+}
+
+// NewAnswerHistoryResponse создает DTO с разбором ответов игрока.
+// Пока викторина не завершена, правильные ответы, корректность и очки не
+// раскрываются: в режиме отложенных результатов (Quiz.DelayedResults) игрок
+// не должен узнавать их раньше, чем вопрос будет закрыт для всех.
+func NewAnswerHistoryResponse(quiz *entity.Quiz, answers []entity.UserAnswer) *AnswerHistoryResponse {
+	revealed := quiz.IsCompleted()
+
+	// Учитываем только первый ответ на каждый вопрос
+	byQuestion := make(map[uint]*entity.UserAnswer, len(answers))
+	for i := range answers {
+		if _, ok := byQuestion[answers[i].QuestionID]; !ok {
+			byQuestion[answers[i].QuestionID] = &answers[i]
+		}
+	}
+
+	questions := make([]entity.Question, len(quiz.Questions))
+	copy(questions, quiz.Questions)
+	sort.Slice(questions, func(i, j int) bool { return questions[i].ID < questions[j].ID })
+
+	response := &AnswerHistoryResponse{
+		QuizID:         quiz.ID,
+		QuizStatus:     string(quiz.Status),
+		Revealed:       revealed,
+		TotalQuestions: len(questions),
+		Answers:        make([]AnswerReviewItem, 0, len(questions)),
+	}
+
+	totalScore := 0
+	for i := range questions {
+		q := &questions[i]
+		item := AnswerReviewItem{
+			QuestionID:     q.ID,
+			QuestionNumber: i + 1,
+			Type:           q.Type,
+			Text:           q.Text,
+			Options:        helper.ConvertOptionsToObjects(q.Options),
+		}
+
+		if answer, ok := byQuestion[q.ID]; ok {
+			response.AnsweredCount++
+			item.Answered = true
+			item.SelectedOption = answer.SelectedOption
+			item.SelectedOrder = answer.SelectedOrder
+			item.ResponseTimeMs = answer.ResponseTimeMs
+
+			if revealed {
+				isCorrect, score := answer.IsCorrect, answer.Score
+				item.IsCorrect = &isCorrect
+				item.Score = &score
+				totalScore += score
+			}
+		}
+
+		if revealed {
+			if q.IsOrdering() {
+				item.CorrectOrder = q.CorrectOrder
+			} else {
+				correctOption := q.CorrectOption
+				item.CorrectOption = &correctOption
+			}
+		}
+
+		response.Answers = append(response.Answers, item)
+	}
+
+	if revealed {
+		response.TotalScore = &totalScore
+	}
+
+	return response
+}
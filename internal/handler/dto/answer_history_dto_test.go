@@ -0,0 +1,64 @@
+package dto
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourusername/trivia-api/internal/domain/entity"
+)
+
+func answerHistoryQuiz(status entity.QuizStatus) *entity.Quiz {
+	return &entity.Quiz{
+		ID:     1,
+		Status: status,
+		Questions: []entity.Question{
+			{ID: 12, Type: entity.QuestionTypeSingleChoice, Text: "второй", Options: entity.StringArray{"a", "b"}, CorrectOption: 1},
+			{ID: 11, Type: entity.QuestionTypeSingleChoice, Text: "первый", Options: entity.StringArray{"a", "b"}, CorrectOption: 2},
+		},
+	}
+}
+
+func answerHistoryAnswers() []entity.UserAnswer {
+	return []entity.UserAnswer{
+		{QuestionID: 11, SelectedOption: 2, IsCorrect: true, ResponseTimeMs: 1500, Score: 8},
+	}
+}
+
+func TestNewAnswerHistoryResponse_CompletedQuizRevealsAnswers(t *testing.T) {
+	response := NewAnswerHistoryResponse(answerHistoryQuiz("completed"), answerHistoryAnswers())
+
+	assert.True(t, response.Revealed)
+	assert.Equal(t, 1, response.AnsweredCount)
+	assert.Equal(t, 2, response.TotalQuestions)
+	require.NotNil(t, response.TotalScore)
+	assert.Equal(t, 8, *response.TotalScore)
+
+	require.Len(t, response.Answers, 2)
+	answered, missed := response.Answers[0], response.Answers[1]
+
+	assert.Equal(t, uint(11), answered.QuestionID, "вопросы идут в порядке викторины")
+	assert.True(t, answered.Answered)
+	assert.Equal(t, int64(1500), answered.ResponseTimeMs)
+	require.NotNil(t, answered.CorrectOption)
+	assert.Equal(t, 2, *answered.CorrectOption)
+	require.NotNil(t, answered.IsCorrect)
+	assert.True(t, *answered.IsCorrect)
+
+	assert.False(t, missed.Answered)
+	require.NotNil(t, missed.CorrectOption)
+	assert.Nil(t, missed.Score)
+}
+
+func TestNewAnswerHistoryResponse_RunningQuizHidesAnswers(t *testing.T) {
+	response := NewAnswerHistoryResponse(answerHistoryQuiz("in_progress"), answerHistoryAnswers())
+
+	assert.False(t, response.Revealed)
+	assert.Nil(t, response.TotalScore)
+	for _, item := range response.Answers {
+		assert.Nil(t, item.CorrectOption)
+		assert.Nil(t, item.IsCorrect)
+		assert.Nil(t, item.Score)
+	}
+	assert.Equal(t, 2, response.Answers[0].SelectedOption, "собственный ответ игрока виден всегда")
+}
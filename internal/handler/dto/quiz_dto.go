@@ -11,24 +11,48 @@ import (
 type QuestionResponse struct {
 	ID           uint                    `json:"id"`
 	QuizID       uint                    `json:"quiz_id"`
+	Type         string                  `json:"type"`
 	Text         string                  `json:"text"`
 	Options      []helper.QuestionOption `json:"options"`
 	TimeLimitSec int                     `json:"time_limit_sec"`
 	PointValue   int                     `json:"point_value"`
+	Difficulty   string                  `json:"difficulty,omitempty"`
 	CreatedAt    time.Time               `json:"created_at"`
 	UpdatedAt    time.Time               `json:"updated_at"`
 }
 
 // QuizResponse представляет викторину в формате для ответа клиенту
 type QuizResponse struct {
-	ID            uint               `json:"id"`
-	Title         string             `json:"title"`
-	Description   string             `json:"description,omitempty"`
-	ScheduledTime time.Time          `json:"scheduled_time"`
-	Status        string             `json:"status"`
-	Questions     []QuestionResponse `json:"questions,omitempty"` // Слайс DTO вопросов
-	CreatedAt     time.Time          `json:"created_at"`
-	UpdatedAt     time.Time          `json:"updated_at"`
+	ID               uint               `json:"id"`
+	Title            string             `json:"title"`
+	Description      string             `json:"description,omitempty"`
+	ScheduledTime    time.Time          `json:"scheduled_time"`
+	Status           string             `json:"status"`
+	DelayedResults   bool               `json:"delayed_results"`
+	SuppressFeedback bool               `json:"suppress_answer_feedback"`
+	HideCorrect      bool               `json:"hide_correct_answer"`
+	JoinPolicy       string             `json:"join_policy"`
+	UniformPoints    int                `json:"uniform_point_value,omitempty"`
+	Multiplier       float64            `json:"points_multiplier,omitempty"`
+	WrongPenalty     int                `json:"wrong_answer_penalty,omitempty"`
+	ScoreFloor       int                `json:"score_floor,omitempty"`
+	FastestBonus     int                `json:"fastest_finger_bonus,omitempty"`
+	DifficultyScale  map[string]float64 `json:"difficulty_multipliers,omitempty"` // Только при difficulty_scaling
+	VerifiedEmail    bool               `json:"require_verified_email,omitempty"`
+	MinGamesPlayed   int                `json:"min_games_played,omitempty"`
+	ExternalCheck    bool               `json:"external_eligibility_check,omitempty"`
+	PacingMode       string             `json:"pacing_mode,omitempty"`
+	SelfPacedLimit   int                `json:"self_paced_time_limit_sec,omitempty"`
+	AutoAdvance      bool               `json:"auto_advance,omitempty"`
+	WindowFromAck    bool               `json:"answer_window_from_ack,omitempty"`
+	ReadTimeSec      int                `json:"question_read_time_sec,omitempty"`
+	MinPlayers       int                `json:"min_players,omitempty"`
+	MinPlayersPolicy string             `json:"min_players_policy,omitempty"`
+	MaxPlayers       int                `json:"max_players,omitempty"`
+	Lives            int                `json:"lives,omitempty"`
+	Questions        []QuestionResponse `json:"questions,omitempty"` // Слайс DTO вопросов
+	CreatedAt        time.Time          `json:"created_at"`
+	UpdatedAt        time.Time          `json:"updated_at"`
 }
 
 // NewQuestionResponse создает DTO для вопроса
@@ -41,10 +65,12 @@ func NewQuestionResponse(q *entity.Question) QuestionResponse {
 	return QuestionResponse{
 		ID:           q.ID,
 		QuizID:       q.QuizID,
+		Type:         q.Type,
 		Text:         q.Text,
 		Options:      optionsDTO, // Используем результат хелпера
 		TimeLimitSec: q.TimeLimitSec,
 		PointValue:   q.PointValue,
+		Difficulty:   q.Difficulty,
 		CreatedAt:    q.CreatedAt,
 		UpdatedAt:    q.UpdatedAt,
 	}
@@ -62,14 +88,43 @@ func NewQuizResponse(quiz *entity.Quiz) *QuizResponse {
 		questionsDTO[i] = NewQuestionResponse(&q)
 	}
 
-	return &QuizResponse{
-		ID:            quiz.ID,
-		Title:         quiz.Title,
-		Description:   quiz.Description,
-		ScheduledTime: quiz.ScheduledTime,
-		Status:        string(quiz.Status), // Преобразуем статус в строку
-		Questions:     questionsDTO,
-		CreatedAt:     quiz.CreatedAt,
-		UpdatedAt:     quiz.UpdatedAt,
+	response := &QuizResponse{
+		ID:               quiz.ID,
+		Title:            quiz.Title,
+		Description:      quiz.Description,
+		ScheduledTime:    quiz.ScheduledTime,
+		Status:           string(quiz.Status), // Преобразуем статус в строку
+		DelayedResults:   quiz.DelayedResults,
+		SuppressFeedback: quiz.SuppressAnswerFeedback,
+		HideCorrect:      quiz.HideCorrectAnswer,
+		JoinPolicy:       quiz.JoinPolicy,
+		UniformPoints:    quiz.UniformPointValue,
+		Multiplier:       quiz.PointsMultiplier,
+		WrongPenalty:     quiz.WrongAnswerPenalty,
+		ScoreFloor:       quiz.ScoreFloor,
+		FastestBonus:     quiz.FastestFingerBonus,
+		VerifiedEmail:    quiz.RequireVerifiedEmail,
+		MinGamesPlayed:   quiz.MinGamesPlayed,
+		ExternalCheck:    quiz.ExternalEligibilityCheck,
+		PacingMode:       quiz.PacingMode,
+		SelfPacedLimit:   quiz.SelfPacedTimeLimitSec,
+		AutoAdvance:      quiz.AutoAdvance,
+		WindowFromAck:    quiz.AnswerWindowFromAck,
+		ReadTimeSec:      quiz.QuestionReadTimeSec,
+		MinPlayers:       quiz.MinPlayers,
+		MinPlayersPolicy: quiz.MinPlayersPolicy,
+		MaxPlayers:       quiz.MaxPlayers,
+		Lives:            quiz.EliminationLives(),
+		Questions:        questionsDTO,
+		CreatedAt:        quiz.CreatedAt,
+		UpdatedAt:        quiz.UpdatedAt,
 	}
+	if quiz.DifficultyScaling {
+		response.DifficultyScale = map[string]float64{
+			entity.DifficultyEasy:   quiz.DifficultyMultipliers.For(entity.DifficultyEasy),
+			entity.DifficultyMedium: quiz.DifficultyMultipliers.For(entity.DifficultyMedium),
+			entity.DifficultyHard:   quiz.DifficultyMultipliers.For(entity.DifficultyHard),
+		}
+	}
+	return response
 }
@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/trivia-api/internal/handler/apierror"
+	"github.com/yourusername/trivia-api/internal/i18n"
+	"github.com/yourusername/trivia-api/internal/service"
+	"github.com/yourusername/trivia-api/pkg/auth/manager"
+)
+
+// respondServiceError преобразует ошибку сервиса в ответ APIError с подходящим
+// HTTP-статусом. Неизвестные ошибки логируются и возвращаются как internal_error
+// без текста исходной ошибки.
+func respondServiceError(c *gin.Context, err error) {
+	var tokenErr *manager.TokenError
+	if errors.As(err, &tokenErr) {
+		respondTokenError(c, tokenErr)
+		return
+	}
+
+	switch {
+	case errors.Is(err, service.ErrQuizNotFound), errors.Is(err, service.ErrUserNotFound),
+		errors.Is(err, service.ErrNotQuizParticipant), errors.Is(err, service.ErrTournamentNotFound):
+		apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+	case errors.Is(err, service.ErrSessionNotFound):
+		apierror.Respond(c, http.StatusNotFound, apierror.CodeSessionNotFound, err.Error())
+	case errors.Is(err, service.ErrQuizNotSchedulable), errors.Is(err, service.ErrQuizNotReplayable),
+		errors.Is(err, service.ErrQuizNotRecomputable), errors.Is(err, service.ErrRecomputeInProgress),
+		errors.Is(err, service.ErrReplayInProgress), errors.Is(err, service.ErrTooManyActiveQuizzes),
+		errors.Is(err, service.ErrRetentionInProgress), errors.Is(err, service.ErrQuizNotActive),
+		errors.Is(err, service.ErrQuizAlreadyStarted), errors.Is(err, service.ErrQuizFinished),
+		errors.Is(err, service.ErrInvalidStatusTransition), errors.Is(err, service.ErrQuizFull):
+		apierror.Respond(c, http.StatusConflict, apierror.CodeConflict, err.Error())
+	case errors.Is(err, service.ErrValidation):
+		apierror.Respond(c, http.StatusUnprocessableEntity, apierror.CodeValidation, err.Error())
+	case errors.Is(err, service.ErrUnauthorized):
+		apierror.Respond(c, http.StatusUnauthorized, apierror.CodeUnauthorized, err.Error())
+	case errors.Is(err, service.ErrForbidden), errors.Is(err, service.ErrInviteCodeRequired),
+		errors.Is(err, service.ErrInvalidInviteCode), errors.Is(err, service.ErrNotEligible),
+		errors.Is(err, service.ErrRemovedFromQuiz):
+		apierror.Respond(c, http.StatusForbidden, apierror.CodeForbidden, err.Error())
+	case strings.Contains(err.Error(), "неверные учетные данные") || strings.Contains(err.Error(), "invalid email or password"):
+		apierror.RespondKey(c, http.StatusUnauthorized, apierror.CodeInvalidCredentials, i18n.AuthInvalidCredentials)
+	default:
+		log.Printf("[Handler] ERROR: Внутренняя ошибка при обработке %s %s: %v", c.Request.Method, c.FullPath(), err)
+		apierror.RespondKey(c, http.StatusInternalServerError, apierror.CodeInternal, i18n.InternalError)
+	}
+}
+
+// respondTokenError преобразует ошибку TokenManager в ответ APIError. Сообщение
+// клиенту выбирается из каталога по типу ошибки на языке клиента, а текст
+// tokenErr.Message остается для логов.
+func respondTokenError(c *gin.Context, tokenErr *manager.TokenError) {
+	switch tokenErr.Type {
+	case manager.ExpiredRefreshToken, manager.ExpiredAccessToken:
+		apierror.RespondKey(c, http.StatusUnauthorized, apierror.CodeTokenExpired, i18n.AuthTokenExpired)
+	case manager.InvalidRefreshToken, manager.InvalidAccessToken:
+		apierror.RespondKey(c, http.StatusUnauthorized, apierror.CodeTokenInvalid, i18n.AuthTokenInvalid)
+	case manager.InvalidCSRFToken:
+		apierror.RespondKey(c, http.StatusForbidden, apierror.CodeCSRFMismatch, i18n.AuthCSRFInvalid)
+	case manager.UserNotFound:
+		apierror.RespondKey(c, http.StatusUnauthorized, apierror.CodeInvalidCredentials, i18n.AuthInvalidCredentials)
+	case manager.TooManySessions:
+		var limitErr *manager.SessionLimitError
+		if errors.As(tokenErr.Err, &limitErr) {
+			apierror.RespondKeyDetails(c, http.StatusConflict, apierror.CodeTooManySessions, i18n.AuthTooManySessions, gin.H{
+				"active_sessions": limitErr.ActiveSessions,
+				"session_limit":   limitErr.Limit,
+			})
+			return
+		}
+		apierror.RespondKey(c, http.StatusConflict, apierror.CodeTooManySessions, i18n.AuthTooManySessions)
+	default:
+		log.Printf("[Handler] ERROR: Ошибка токена при обработке %s %s: %v", c.Request.Method, c.FullPath(), tokenErr)
+		apierror.RespondKey(c, http.StatusInternalServerError, apierror.CodeInternal, i18n.AuthRequestFailed)
+	}
+}
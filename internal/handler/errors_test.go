@@ -0,0 +1,171 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourusername/trivia-api/internal/handler/apierror"
+	"github.com/yourusername/trivia-api/internal/middleware"
+	"github.com/yourusername/trivia-api/internal/service"
+	"github.com/yourusername/trivia-api/pkg/auth/manager"
+)
+
+// decodeBody разбирает ответ в map, чтобы проверять именно набор полей
+func decodeBody(t *testing.T, w *httptest.ResponseRecorder) map[string]interface{} {
+	t.Helper()
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	return body
+}
+
+func serviceErrorResponse(err error) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/quizzes/1", nil)
+	respondServiceError(c, err)
+	return w
+}
+
+func TestRespondServiceError(t *testing.T) {
+	cases := []struct {
+		err    error
+		status int
+		code   apierror.Code
+	}{
+		{fmt.Errorf("%w: id 1", service.ErrQuizNotFound), http.StatusNotFound, apierror.CodeNotFound},
+		{service.ErrReplayInProgress, http.StatusConflict, apierror.CodeConflict},
+		{service.ErrTooManyActiveQuizzes, http.StatusConflict, apierror.CodeConflict},
+		{fmt.Errorf("%w: quiz 1 is in progress", service.ErrQuizAlreadyStarted), http.StatusConflict, apierror.CodeConflict},
+		{fmt.Errorf("%w: quiz 1 is completed", service.ErrQuizFinished), http.StatusConflict, apierror.CodeConflict},
+		{fmt.Errorf("%w: нет активной викторины", service.ErrQuizNotActive), http.StatusConflict, apierror.CodeConflict},
+		{fmt.Errorf("%w: title is empty", service.ErrValidation), http.StatusUnprocessableEntity, apierror.CodeValidation},
+		{service.ErrSessionNotFound, http.StatusNotFound, apierror.CodeSessionNotFound},
+		{manager.NewTokenError(manager.ExpiredRefreshToken, "refresh token expired", nil), http.StatusUnauthorized, apierror.CodeTokenExpired},
+		{manager.NewTokenError(manager.InvalidCSRFToken, "CSRF token missing", nil), http.StatusForbidden, apierror.CodeCSRFMismatch},
+		{errors.New("invalid email or password"), http.StatusUnauthorized, apierror.CodeInvalidCredentials},
+		{errors.New("pq: connection refused"), http.StatusInternalServerError, apierror.CodeInternal},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.err.Error(), func(t *testing.T) {
+			w := serviceErrorResponse(tc.err)
+			require.Equal(t, tc.status, w.Code)
+
+			body := decodeBody(t, w)
+			assert.Equal(t, string(tc.code), body["code"])
+			assert.NotEmpty(t, body["message"])
+		})
+	}
+}
+
+func TestRespondServiceError_TooManySessions(t *testing.T) {
+	err := manager.NewTokenError(manager.TooManySessions, "too many sessions",
+		&manager.SessionLimitError{ActiveSessions: 5, Limit: 5})
+	w := serviceErrorResponse(fmt.Errorf("login: %w", err))
+	require.Equal(t, http.StatusConflict, w.Code)
+
+	body := decodeBody(t, w)
+	assert.Equal(t, string(apierror.CodeTooManySessions), body["code"])
+	assert.Equal(t, map[string]interface{}{"active_sessions": float64(5), "session_limit": float64(5)}, body["details"])
+}
+
+func TestRespondServiceError_HidesInternalErrors(t *testing.T) {
+	w := serviceErrorResponse(errors.New("pq: password authentication failed for user trivia"))
+
+	body := decodeBody(t, w)
+	assert.Equal(t, map[string]interface{}{"code": "internal_error", "message": "Internal server error"}, body,
+		"текст внутренней ошибки не должен попадать клиенту")
+}
+
+// TestRespondServiceError_Localized: одна и та же ошибка возвращается с текстом
+// на языке из Accept-Language, код ошибки не зависит от языка
+func TestRespondServiceError_Localized(t *testing.T) {
+	err := manager.NewTokenError(manager.InvalidCSRFToken, "недействительный CSRF токен", nil)
+	cases := []struct {
+		acceptLanguage string
+		message        string
+	}{
+		{"", "Invalid CSRF token"},
+		{"en-US,en;q=0.9", "Invalid CSRF token"},
+		{"ru-RU,ru;q=0.9,en;q=0.8", "Неверный CSRF токен"},
+		{"de-DE", "Invalid CSRF token"},
+	}
+
+	gin.SetMode(gin.TestMode)
+	for _, tc := range cases {
+		t.Run(tc.acceptLanguage, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPost, "/api/auth/refresh", nil)
+			if tc.acceptLanguage != "" {
+				c.Request.Header.Set("Accept-Language", tc.acceptLanguage)
+			}
+			respondServiceError(c, err)
+
+			require.Equal(t, http.StatusForbidden, w.Code)
+			assert.Equal(t, map[string]interface{}{"code": "csrf_mismatch", "message": tc.message}, decodeBody(t, w))
+		})
+	}
+}
+
+func TestAPIError_RequestID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	c.Request.Header.Set(apierror.RequestIDHeader, "req-42")
+
+	apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, "Quiz not found")
+
+	body := decodeBody(t, w)
+	assert.Equal(t, "req-42", body["request_id"])
+	assert.NotContains(t, body, "details")
+}
+
+func TestRespondBindingError_ValidationDetails(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/quizzes", strings.NewReader(`{"description": "no title"}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	var req CreateQuizRequest
+	respondBindingError(c, c.ShouldBindJSON(&req))
+
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	var resp struct {
+		apierror.APIError
+		Details []FieldError `json:"details"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, apierror.CodeValidation, resp.Code)
+	require.NotEmpty(t, resp.Details)
+	assert.Equal(t, "title", resp.Details[0].Field)
+}
+
+func TestExtractUintParam_ErrorShape(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/quizzes/:id", middleware.ExtractUintParam("id", "quizID"), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"id": c.MustGet("quizID")})
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/quizzes/7", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, map[string]interface{}{"id": float64(7)}, decodeBody(t, w), "успешные ответы не меняются")
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/quizzes/abc", nil))
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, map[string]interface{}{"code": "invalid_request", "message": "Invalid id"}, decodeBody(t, w))
+}
@@ -0,0 +1,26 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/trivia-api/pkg/auth"
+)
+
+// JWKSHandler публикует открытые ключи проверки JWT, чтобы другие сервисы
+// могли проверять токены без общего секрета
+type JWKSHandler struct {
+	jwtService *auth.JWTService
+}
+
+// NewJWKSHandler создает обработчик набора ключей
+func NewJWKSHandler(jwtService *auth.JWTService) *JWKSHandler {
+	return &JWKSHandler{jwtService: jwtService}
+}
+
+// GetJWKS возвращает набор открытых ключей (RFC 7517). При подписи HS256 набор пуст.
+func (h *JWKSHandler) GetJWKS(c *gin.Context) {
+	// Ключ меняется только при перезапуске с новым ключом
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.jwtService.JWKS())
+}
@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourusername/trivia-api/internal/domain/entity"
+	"github.com/yourusername/trivia-api/pkg/auth"
+)
+
+func getJWKS(t *testing.T, jwtService *auth.JWTService) auth.JWKSet {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil)
+	NewJWKSHandler(jwtService).GetJWKS(c)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var set auth.JWKSet
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &set))
+	return set
+}
+
+func TestJWKSHandler_AsymmetricAlgorithms(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	ecDER, err := x509.MarshalECPrivateKey(ecKey)
+	require.NoError(t, err)
+
+	cases := []struct {
+		alg string
+		kty string
+		pem []byte
+	}{
+		{auth.AlgorithmRS256, "RSA", pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)})},
+		{auth.AlgorithmES256, "EC", pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: ecDER})},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.alg, func(t *testing.T) {
+			hmacService := auth.NewJWTService("test-secret", 1, &memoryInvalidTokenRepo{}, 60, time.Hour)
+			hmacToken, err := hmacService.GenerateToken(&entity.User{ID: 7, Email: "u@example.com"})
+			require.NoError(t, err)
+
+			jwtService := auth.NewJWTService("test-secret", 1, &memoryInvalidTokenRepo{}, 60, time.Hour)
+			require.NoError(t, jwtService.EnableAsymmetricSigning(tc.alg, tc.pem))
+			assert.Equal(t, tc.alg, jwtService.Algorithm())
+
+			token, err := jwtService.GenerateToken(&entity.User{ID: 7, Email: "u@example.com"})
+			require.NoError(t, err)
+			claims, err := jwtService.ParseToken(context.Background(), token)
+			require.NoError(t, err)
+			assert.Equal(t, uint(7), claims.UserID)
+
+			ticket, err := jwtService.GenerateWSTicket(7, "u@example.com", entity.RolePlayer)
+			require.NoError(t, err)
+			_, err = jwtService.ParseWSTicket(ticket)
+			require.NoError(t, err)
+
+			// Токен, подписанный общим секретом, больше не принимается
+			_, err = jwtService.ParseToken(context.Background(), hmacToken)
+			assert.Error(t, err)
+
+			set := getJWKS(t, jwtService)
+			require.Len(t, set.Keys, 1)
+			assert.Equal(t, tc.kty, set.Keys[0].Kty)
+			assert.Equal(t, tc.alg, set.Keys[0].Alg)
+			assert.NotEmpty(t, set.Keys[0].Kid)
+		})
+	}
+}
+
+func TestJWKSHandler_HS256PublishesNoKeys(t *testing.T) {
+	jwtService := auth.NewJWTService("test-secret", 1, &memoryInvalidTokenRepo{}, 60, time.Hour)
+	assert.Equal(t, auth.AlgorithmHS256, jwtService.Algorithm())
+	assert.Empty(t, getJWKS(t, jwtService).Keys)
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(ecKey)
+	require.NoError(t, err)
+	assert.Error(t, jwtService.EnableAsymmetricSigning(auth.AlgorithmRS256, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		"RS256 с ключом EC должен отклоняться")
+}
@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/trivia-api/internal/service"
+	"github.com/yourusername/trivia-api/internal/websocket"
+)
+
+// MaintenanceHandler управляет режимом обслуживания и отдает состояние сервера
+type MaintenanceHandler struct {
+	maintenanceService *service.MaintenanceService
+	quizManager        *service.QuizManager
+	wsHub              websocket.HubInterface
+}
+
+// NewMaintenanceHandler создает обработчик режима обслуживания
+func NewMaintenanceHandler(
+	maintenanceService *service.MaintenanceService,
+	quizManager *service.QuizManager,
+	wsHub websocket.HubInterface,
+) *MaintenanceHandler {
+	return &MaintenanceHandler{
+		maintenanceService: maintenanceService,
+		quizManager:        quizManager,
+		wsHub:              wsHub,
+	}
+}
+
+// MaintenanceRequest представляет запрос на включение или выключение режима обслуживания
+type MaintenanceRequest struct {
+	Enabled *bool  `json:"enabled" binding:"required"`
+	Message string `json:"message" binding:"omitempty,max=500"`
+}
+
+// GetMaintenance возвращает текущее состояние режима обслуживания
+func (h *MaintenanceHandler) GetMaintenance(c *gin.Context) {
+	c.JSON(http.StatusOK, h.maintenanceService.State())
+}
+
+// SetMaintenance включает или выключает режим обслуживания. Открытые соединения
+// и идущие викторины не прерываются.
+func (h *MaintenanceHandler) SetMaintenance(c *gin.Context) {
+	var req MaintenanceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindingError(c, err)
+		return
+	}
+
+	var (
+		state service.MaintenanceState
+		err   error
+	)
+	if *req.Enabled {
+		state, err = h.maintenanceService.Enable(c.MustGet("user_id").(uint), req.Message)
+	} else {
+		state, err = h.maintenanceService.Disable()
+	}
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	log.Printf("[MaintenanceHandler] Режим обслуживания: %v, проводится викторин: %d", state.Enabled, len(h.quizManager.GetActiveQuizzes()))
+	c.JSON(http.StatusOK, state)
+}
+
+// Health возвращает состояние сервера для балансировщика и мониторинга.
+// В режиме обслуживания статус - "maintenance", но ответ остается 200: экземпляр
+// исправен и дорабатывает идущие викторины, его не нужно перезапускать.
+func (h *MaintenanceHandler) Health(c *gin.Context) {
+	maintenance := h.maintenanceService.State()
+	status := "ok"
+	if maintenance.Enabled {
+		status = "maintenance"
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, gin.H{
+		"status":             status,
+		"maintenance":        maintenance,
+		"active_quizzes":     len(h.quizManager.GetActiveQuizzes()),
+		"active_connections": h.wsHub.ClientCount(),
+		"timestamp":          time.Now().Format(time.RFC3339),
+	})
+}
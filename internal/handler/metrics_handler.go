@@ -0,0 +1,345 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/trivia-api/internal/domain/repository"
+	"github.com/yourusername/trivia-api/internal/handler/apierror"
+	"github.com/yourusername/trivia-api/internal/websocket"
+)
+
+// CacheStatsProvider отдает метрики кэша (локального резерва Redis)
+type CacheStatsProvider interface {
+	Stats() map[string]interface{}
+}
+
+// AckStatsProvider отдает метрики подтверждений критических WebSocket-событий
+type AckStatsProvider interface {
+	AckStats() map[string]interface{}
+}
+
+// TokenInvalidationStatsProvider отдает метрики проверки токенов по хранилищу инвалидаций
+type TokenInvalidationStatsProvider interface {
+	InvalidationCheckStats() map[string]interface{}
+}
+
+// CSRFTokenStatsProvider отдает метрики CSRF токенов, хранимых в памяти
+type CSRFTokenStatsProvider interface {
+	CSRFTokenStats() map[string]interface{}
+}
+
+// IPConnectionStatsProvider отдает число WebSocket-соединений по IP-адресам
+type IPConnectionStatsProvider interface {
+	IPConnectionStats() map[string]interface{}
+}
+
+// ConnectionStatsProvider отдает самые нагруженные или медленные WebSocket-соединения
+type ConnectionStatsProvider interface {
+	TopConnections(shardID int, sortBy string, limit int) ([]websocket.ConnectionStats, error)
+}
+
+// AlertHistoryProvider отдает историю алертов WebSocket-хаба
+type AlertHistoryProvider interface {
+	AlertHistory(filter websocket.AlertFilter) ([]websocket.AlertMessage, error)
+}
+
+// MetricsHandler обрабатывает запросы к истории метрик
+type MetricsHandler struct {
+	wsMetricsRepo repository.WSMetricsRepository
+	dbPool        *sql.DB
+	cacheFallback CacheStatsProvider
+	wsAcks        AckStatsProvider
+	tokenChecks   TokenInvalidationStatsProvider
+	csrfTokens    CSRFTokenStatsProvider
+	wsIPs         IPConnectionStatsProvider
+	wsConnections ConnectionStatsProvider
+	alerts        AlertHistoryProvider
+}
+
+// NewMetricsHandler создает новый обработчик метрик
+func NewMetricsHandler(wsMetricsRepo repository.WSMetricsRepository) *MetricsHandler {
+	return &MetricsHandler{
+		wsMetricsRepo: wsMetricsRepo,
+	}
+}
+
+// SetDBPool задает пул соединений БД для диагностики
+func (h *MetricsHandler) SetDBPool(db *sql.DB) {
+	h.dbPool = db
+}
+
+// SetCacheFallback задает локальный резерв кэша для диагностики
+func (h *MetricsHandler) SetCacheFallback(provider CacheStatsProvider) {
+	h.cacheFallback = provider
+}
+
+// SetWSAcks задает источник метрик подтверждений WebSocket-событий
+func (h *MetricsHandler) SetWSAcks(provider AckStatsProvider) {
+	h.wsAcks = provider
+}
+
+// SetTokenInvalidation задает источник метрик проверки инвалидированных токенов
+func (h *MetricsHandler) SetTokenInvalidation(provider TokenInvalidationStatsProvider) {
+	h.tokenChecks = provider
+}
+
+// SetCSRFTokens задает источник метрик CSRF токенов
+func (h *MetricsHandler) SetCSRFTokens(provider CSRFTokenStatsProvider) {
+	h.csrfTokens = provider
+}
+
+// SetWSIPConnections задает источник числа WebSocket-соединений по IP-адресам
+func (h *MetricsHandler) SetWSIPConnections(provider IPConnectionStatsProvider) {
+	h.wsIPs = provider
+}
+
+// SetWSConnections задает источник счетчиков отдельных WebSocket-соединений
+func (h *MetricsHandler) SetWSConnections(provider ConnectionStatsProvider) {
+	h.wsConnections = provider
+}
+
+// SetAlerts задает источник истории алертов WebSocket-хаба
+func (h *MetricsHandler) SetAlerts(provider AlertHistoryProvider) {
+	h.alerts = provider
+}
+
+// maxAlertsLimit - наибольшее число алертов в одном ответе
+const maxAlertsLimit = 1000
+
+// GetAlerts возвращает историю алертов WebSocket-хаба, от новых к старым.
+// Параметры: severity (info, warning, critical), type, since (RFC3339),
+// limit (по умолчанию 100, не больше 1000).
+func (h *MetricsHandler) GetAlerts(c *gin.Context) {
+	if h.alerts == nil {
+		apierror.Respond(c, http.StatusServiceUnavailable, apierror.CodeServiceUnavailable, "Alert history is unavailable")
+		return
+	}
+
+	filter := websocket.AlertFilter{
+		Severity: websocket.AlertSeverity(c.Query("severity")),
+		Type:     websocket.AlertType(c.Query("type")),
+	}
+	switch filter.Severity {
+	case "", websocket.AlertInfo, websocket.AlertWarning, websocket.AlertCritical:
+	default:
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidRequest, "Invalid 'severity', expected info, warning or critical")
+		return
+	}
+	if sinceStr := c.Query("since"); sinceStr != "" {
+		parsed, err := time.Parse(time.RFC3339, sinceStr)
+		if err != nil {
+			apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidRequest, "Invalid 'since' format, expected RFC3339")
+			return
+		}
+		filter.Since = parsed
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if err != nil || limit < 1 || limit > maxAlertsLimit {
+		limit = 100
+	}
+	filter.Limit = limit
+
+	alerts, err := h.alerts.AlertHistory(filter)
+	if err != nil {
+		apierror.Respond(c, http.StatusServiceUnavailable, apierror.CodeServiceUnavailable, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count":  len(alerts),
+		"alerts": alerts,
+	})
+}
+
+// GetWSConnections возвращает соединения экземпляра с наибольшим значением
+// выбранного счетчика. Параметры: shard (по умолчанию все шарды), sort
+// (sent, dropped, buffer, inbound_rate, rtt, age; по умолчанию sent), limit
+// (по умолчанию 20, не больше 100).
+func (h *MetricsHandler) GetWSConnections(c *gin.Context) {
+	if h.wsConnections == nil {
+		apierror.Respond(c, http.StatusServiceUnavailable, apierror.CodeServiceUnavailable, "WebSocket connection metrics are unavailable")
+		return
+	}
+
+	shardID := -1
+	if shardStr := c.Query("shard"); shardStr != "" {
+		parsed, err := strconv.Atoi(shardStr)
+		if err != nil || parsed < 0 {
+			apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidRequest, "Invalid 'shard', expected a non-negative integer")
+			return
+		}
+		shardID = parsed
+	}
+	sortBy := c.DefaultQuery("sort", websocket.ConnectionSortSent)
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit < 1 || limit > websocket.MaxTopConnections {
+		limit = 20
+	}
+
+	connections, err := h.wsConnections.TopConnections(shardID, sortBy, limit)
+	switch {
+	case errors.Is(err, websocket.ErrUnknownConnectionSort):
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
+		return
+	case errors.Is(err, websocket.ErrShardNotFound):
+		apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+		return
+	case err != nil:
+		apierror.Respond(c, http.StatusServiceUnavailable, apierror.CodeServiceUnavailable, err.Error())
+		return
+	}
+
+	response := gin.H{
+		"sort":        sortBy,
+		"count":       len(connections),
+		"connections": connections,
+	}
+	if shardID >= 0 {
+		response["shard"] = shardID
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// GetWSIPConnections возвращает лимит WebSocket-соединений с одного IP, число
+// отклоненных подключений и IP-адреса с наибольшим числом открытых соединений
+func (h *MetricsHandler) GetWSIPConnections(c *gin.Context) {
+	if h.wsIPs == nil {
+		apierror.Respond(c, http.StatusServiceUnavailable, apierror.CodeServiceUnavailable, "WebSocket IP connection metrics are unavailable")
+		return
+	}
+	c.JSON(http.StatusOK, h.wsIPs.IPConnectionStats())
+}
+
+// GetTokenInvalidationStats возвращает метрики проверки токенов по хранилищу
+// инвалидаций: число обращений, сбоев и токенов, принятых или отклоненных из-за сбоя
+func (h *MetricsHandler) GetTokenInvalidationStats(c *gin.Context) {
+	if h.tokenChecks == nil {
+		apierror.Respond(c, http.StatusServiceUnavailable, apierror.CodeServiceUnavailable, "Token invalidation metrics are unavailable")
+		return
+	}
+	c.JSON(http.StatusOK, h.tokenChecks.InvalidationCheckStats())
+}
+
+// GetCSRFTokenStats возвращает метрики CSRF токенов в памяти: число токенов и
+// пользователей, лимит на пользователя и число токенов, вытесненных сверх лимита
+func (h *MetricsHandler) GetCSRFTokenStats(c *gin.Context) {
+	if h.csrfTokens == nil {
+		apierror.Respond(c, http.StatusServiceUnavailable, apierror.CodeServiceUnavailable, "CSRF token metrics are unavailable")
+		return
+	}
+	c.JSON(http.StatusOK, h.csrfTokens.CSRFTokenStats())
+}
+
+// GetWSAckStats возвращает метрики подтверждений критических WebSocket-событий:
+// число неподтвержденных событий, повторы и задержку подтверждения
+func (h *MetricsHandler) GetWSAckStats(c *gin.Context) {
+	var stats map[string]interface{}
+	if h.wsAcks != nil {
+		stats = h.wsAcks.AckStats()
+	}
+	if stats == nil {
+		apierror.Respond(c, http.StatusServiceUnavailable, apierror.CodeServiceUnavailable, "WebSocket acks are disabled")
+		return
+	}
+	c.JSON(http.StatusOK, stats)
+}
+
+// GetCacheFallbackStats возвращает метрики локального резерва кэша: сколько раз
+// Redis был недоступен и критичные ключи читались или записывались локально
+func (h *MetricsHandler) GetCacheFallbackStats(c *gin.Context) {
+	if h.cacheFallback == nil {
+		apierror.Respond(c, http.StatusServiceUnavailable, apierror.CodeServiceUnavailable, "Cache fallback is disabled")
+		return
+	}
+	c.JSON(http.StatusOK, h.cacheFallback.Stats())
+}
+
+// DBPoolStats - состояние пула соединений БД (sql.DBStats в JSON)
+type DBPoolStats struct {
+	MaxOpenConnections int    `json:"max_open_connections"`
+	OpenConnections    int    `json:"open_connections"`
+	InUse              int    `json:"in_use"`
+	Idle               int    `json:"idle"`
+	WaitCount          int64  `json:"wait_count"`
+	WaitDuration       string `json:"wait_duration"`
+	WaitDurationMs     int64  `json:"wait_duration_ms"`
+	MaxIdleClosed      int64  `json:"max_idle_closed"`
+	MaxIdleTimeClosed  int64  `json:"max_idle_time_closed"`
+	MaxLifetimeClosed  int64  `json:"max_lifetime_closed"`
+}
+
+// GetDBPoolStats возвращает текущее состояние пула соединений БД.
+// Растущие wait_count и wait_duration означают, что соединений не хватает.
+func (h *MetricsHandler) GetDBPoolStats(c *gin.Context) {
+	if h.dbPool == nil {
+		apierror.Respond(c, http.StatusServiceUnavailable, apierror.CodeServiceUnavailable, "Database pool stats are not available")
+		return
+	}
+
+	stats := h.dbPool.Stats()
+	c.JSON(http.StatusOK, DBPoolStats{
+		MaxOpenConnections: stats.MaxOpenConnections,
+		OpenConnections:    stats.OpenConnections,
+		InUse:              stats.InUse,
+		Idle:               stats.Idle,
+		WaitCount:          stats.WaitCount,
+		WaitDuration:       stats.WaitDuration.String(),
+		WaitDurationMs:     stats.WaitDuration.Milliseconds(),
+		MaxIdleClosed:      stats.MaxIdleClosed,
+		MaxIdleTimeClosed:  stats.MaxIdleTimeClosed,
+		MaxLifetimeClosed:  stats.MaxLifetimeClosed,
+	})
+}
+
+// GetWSMetricsHistory возвращает снимки метрик WebSocket за период.
+// Параметры: from, to (RFC3339, по умолчанию последний час), instance_id, limit (по умолчанию 1000).
+func (h *MetricsHandler) GetWSMetricsHistory(c *gin.Context) {
+	to := time.Now()
+	from := to.Add(-time.Hour)
+
+	if fromStr := c.Query("from"); fromStr != "" {
+		parsed, err := time.Parse(time.RFC3339, fromStr)
+		if err != nil {
+			apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidRequest, "Invalid 'from' format, expected RFC3339")
+			return
+		}
+		from = parsed
+	}
+	if toStr := c.Query("to"); toStr != "" {
+		parsed, err := time.Parse(time.RFC3339, toStr)
+		if err != nil {
+			apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidRequest, "Invalid 'to' format, expected RFC3339")
+			return
+		}
+		to = parsed
+	}
+	if !from.Before(to) {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidRequest, "'from' must be before 'to'")
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "1000"))
+	if err != nil || limit < 1 || limit > 10000 {
+		limit = 1000
+	}
+
+	snapshots, err := h.wsMetricsRepo.GetSnapshots(c.Request.Context(), from, to, c.Query("instance_id"), limit)
+	if err != nil {
+		log.Printf("[MetricsHandler] Ошибка при получении истории метрик: %v", err)
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to get metrics history")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"from":      from,
+		"to":        to,
+		"count":     len(snapshots),
+		"snapshots": snapshots,
+	})
+}
@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/trivia-api/internal/service"
+)
+
+// NotificationPrefsHandler управляет настройками WebSocket-уведомлений текущего пользователя
+type NotificationPrefsHandler struct {
+	prefsService *service.NotificationPrefsService
+}
+
+// NewNotificationPrefsHandler создает обработчик настроек уведомлений
+func NewNotificationPrefsHandler(prefsService *service.NotificationPrefsService) *NotificationPrefsHandler {
+	return &NotificationPrefsHandler{prefsService: prefsService}
+}
+
+// NotificationPrefsRequest представляет запрос на изменение настроек уведомлений.
+// Не указанные поля сохраняют текущие значения.
+type NotificationPrefsRequest struct {
+	TokenExpiryWarnings   *bool `json:"token_expiry_warnings"`
+	SessionExpiryWarnings *bool `json:"session_expiry_warnings"`
+	SystemEvents          *bool `json:"system_events"`
+}
+
+// GetNotificationPrefs возвращает настройки уведомлений текущего пользователя
+func (h *NotificationPrefsHandler) GetNotificationPrefs(c *gin.Context) {
+	prefs, err := h.prefsService.Get(c.MustGet("user_id").(uint))
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, prefs)
+}
+
+// UpdateNotificationPrefs изменяет настройки уведомлений текущего пользователя
+func (h *NotificationPrefsHandler) UpdateNotificationPrefs(c *gin.Context) {
+	var req NotificationPrefsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindingError(c, err)
+		return
+	}
+
+	userID := c.MustGet("user_id").(uint)
+	prefs, err := h.prefsService.Get(userID)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	if req.TokenExpiryWarnings != nil {
+		prefs.TokenExpiryWarnings = *req.TokenExpiryWarnings
+	}
+	if req.SessionExpiryWarnings != nil {
+		prefs.SessionExpiryWarnings = *req.SessionExpiryWarnings
+	}
+	if req.SystemEvents != nil {
+		prefs.SystemEvents = *req.SystemEvents
+	}
+
+	prefs, err = h.prefsService.Update(userID, prefs)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, prefs)
+}
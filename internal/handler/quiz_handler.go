@@ -3,6 +3,7 @@ package handler
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"log"
 	"net/http"
 	"strconv"
@@ -10,6 +11,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/yourusername/trivia-api/internal/domain/entity"
+	"github.com/yourusername/trivia-api/internal/handler/apierror"
 	"github.com/yourusername/trivia-api/internal/handler/dto"
 	"github.com/yourusername/trivia-api/internal/service"
 )
@@ -39,64 +41,168 @@ type CreateQuizRequest struct {
 	Title         string    `json:"title" binding:"required,min=3,max=100"`
 	Description   string    `json:"description" binding:"omitempty,max=500"`
 	ScheduledTime time.Time `json:"scheduled_time" binding:"required"`
+	// Сообщать результаты ответов только после закрытия вопроса
+	DelayedResults bool `json:"delayed_results"`
+	// Сразу после ответа отправлять только подтверждение приема, результат - после раскрытия
+	SuppressAnswerFeedback bool `json:"suppress_answer_feedback"`
+	// Сообщать результат сразу, но правильный ответ - только при раскрытии
+	HideCorrectAnswer bool `json:"hide_correct_answer"`
+	// Можно ли присоединиться к идущей викторине: before_start_only (по умолчанию) или anytime
+	JoinPolicy string `json:"join_policy" binding:"omitempty,oneof=before_start_only anytime"`
+	// Единая стоимость всех вопросов (0 - у каждого вопроса своя point_value)
+	UniformPointValue int `json:"uniform_point_value" binding:"omitempty,min=1,max=100"`
+	// Множитель очков для всех вопросов (0 - без множителя)
+	PointsMultiplier float64 `json:"points_multiplier" binding:"omitempty,gt=0,lte=10"`
+	// Штраф за неверный ответ, вычитаемый из итогового счета (0 - без штрафа)
+	WrongAnswerPenalty int `json:"wrong_answer_penalty" binding:"omitempty,min=0,max=100"`
+	// Нижняя граница, ниже которой штрафы не опускают итоговый счет
+	ScoreFloor int `json:"score_floor" binding:"omitempty,min=0"`
+	// Бонус первому правильно ответившему на каждый вопрос (0 - без бонуса)
+	FastestFingerBonus int `json:"fastest_finger_bonus" binding:"omitempty,min=0,max=100"`
+	// Стоимость вопроса умножается на множитель его сложности; нулевые множители -
+	// по умолчанию (easy 0.5, medium 1, hard 2)
+	DifficultyScaling     bool                         `json:"difficulty_scaling"`
+	DifficultyMultipliers entity.DifficultyMultipliers `json:"difficulty_multipliers"`
+	// Видимость: public (по умолчанию), unlisted (только по ссылке) или private (по коду приглашения)
+	Visibility string `json:"visibility" binding:"omitempty,oneof=public unlisted private"`
+	// Условия участия: подтвержденный email, минимум сыгранных игр, внешняя проверка
+	RequireVerifiedEmail     bool `json:"require_verified_email"`
+	MinGamesPlayed           int  `json:"min_games_played" binding:"omitempty,min=0,max=10000"`
+	ExternalEligibilityCheck bool `json:"external_eligibility_check"`
+	// Темп прохождения: synchronized (по умолчанию) или self_paced с общим лимитом времени
+	PacingMode            string `json:"pacing_mode" binding:"omitempty,oneof=synchronized self_paced"`
+	SelfPacedTimeLimitSec int    `json:"self_paced_time_limit_sec" binding:"omitempty,min=0"`
+	// Досрочное закрытие вопроса, когда ответили все активные игроки
+	AutoAdvance bool `json:"auto_advance"`
+	// Отсчет времени на ответ от подтверждения получения вопроса игроком
+	AnswerWindowFromAck bool `json:"answer_window_from_ack"`
+	// Время на чтение вопроса до открытия приема ответов, в секундах
+	QuestionReadTimeSec int `json:"question_read_time_sec" binding:"omitempty,min=0"`
+	// Минимум игроков в комнате к началу и поведение при нехватке: wait (по умолчанию) или cancel
+	MinPlayers       int    `json:"min_players" binding:"omitempty,min=0"`
+	MinPlayersPolicy string `json:"min_players_policy" binding:"omitempty,oneof=wait cancel"`
+	// Максимум игроков, занявших место (0 - без ограничения)
+	MaxPlayers int `json:"max_players" binding:"omitempty,min=0"`
+	// Число жизней в формате на выбывание (0 и 1 - выбывание с первой ошибки)
+	Lives int `json:"lives" binding:"omitempty,min=0"`
+}
+
+// adminQuizResponse - викторина в ответе администратору: в отличие от публичных
+// ответов содержит код приглашения приватной викторины
+type adminQuizResponse struct {
+	*entity.Quiz
+	InviteCode string `json:"invite_code,omitempty"`
+}
+
+func newAdminQuizResponse(quiz *entity.Quiz) adminQuizResponse {
+	return adminQuizResponse{Quiz: quiz, InviteCode: quiz.InviteCode}
+}
+
+// CloneQuizRequest представляет необязательные переопределения для копии викторины
+type CloneQuizRequest struct {
+	Title         *string    `json:"title" binding:"omitempty,min=3,max=100"`
+	Description   *string    `json:"description" binding:"omitempty,max=500"`
+	ScheduledTime *time.Time `json:"scheduled_time"`
 }
 
 // CreateQuiz обрабатывает запрос на создание викторины
 func (h *QuizHandler) CreateQuiz(c *gin.Context) {
 	var req CreateQuizRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondBindingError(c, err)
 		return
 	}
 
-	quiz, err := h.quizService.CreateQuiz(req.Title, req.Description, req.ScheduledTime)
+	// Повторная проверка после обрезки пробелов: "   " проходит биндинг, но не должна сохраняться
+	if errs := normalizeCreateQuizRequest(&req); len(errs) > 0 {
+		respondValidationErrors(c, errs)
+		return
+	}
+
+	format := service.QuizFormatOptions{
+		DelayedResults:         req.DelayedResults,
+		SuppressAnswerFeedback: req.SuppressAnswerFeedback,
+		HideCorrectAnswer:      req.HideCorrectAnswer,
+		JoinPolicy:             req.JoinPolicy,
+		Visibility:             req.Visibility,
+
+		RequireVerifiedEmail:     req.RequireVerifiedEmail,
+		MinGamesPlayed:           req.MinGamesPlayed,
+		ExternalEligibilityCheck: req.ExternalEligibilityCheck,
+
+		PacingMode:            req.PacingMode,
+		SelfPacedTimeLimitSec: req.SelfPacedTimeLimitSec,
+		AutoAdvance:           req.AutoAdvance,
+		AnswerWindowFromAck:   req.AnswerWindowFromAck,
+		QuestionReadTimeSec:   req.QuestionReadTimeSec,
+		MinPlayers:            req.MinPlayers,
+		MinPlayersPolicy:      req.MinPlayersPolicy,
+		MaxPlayers:            req.MaxPlayers,
+		Lives:                 req.Lives,
+	}
+	quiz, err := h.quizService.CreateQuiz(req.Title, req.Description, req.ScheduledTime, format, service.QuizScoringOptions{
+		UniformPointValue:  req.UniformPointValue,
+		PointsMultiplier:   req.PointsMultiplier,
+		WrongAnswerPenalty: req.WrongAnswerPenalty,
+		ScoreFloor:         req.ScoreFloor,
+		FastestFingerBonus: req.FastestFingerBonus,
+
+		DifficultyScaling:     req.DifficultyScaling,
+		DifficultyMultipliers: req.DifficultyMultipliers,
+	})
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
 		return
 	}
 
-	c.JSON(http.StatusCreated, quiz)
+	c.JSON(http.StatusCreated, newAdminQuizResponse(quiz))
 }
 
 // GetQuiz возвращает информацию о викторине
 func (h *QuizHandler) GetQuiz(c *gin.Context) {
 	quizID := c.MustGet("quizID").(uint) // Получаем из контекста
 
-	quiz, err := h.quizService.GetQuizByID(quizID)
+	quiz, err := h.quizService.GetVisibleQuiz(quizID, c.Query("invite_code"))
 	if err != nil {
-		// TODO: Улучшить обработку ошибок (п.7)
-		c.JSON(http.StatusNotFound, gin.H{"error": "Quiz not found"})
+		respondServiceError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, quiz)
 }
 
-// GetActiveQuiz возвращает информацию об активной викторине
-func (h *QuizHandler) GetActiveQuiz(c *gin.Context) {
+// GetActiveQuizzes возвращает список проводимых сейчас викторин
+func (h *QuizHandler) GetActiveQuizzes(c *gin.Context) {
 	// Проверяем сначала в QuizManager
-	activeQuiz := h.quizManager.GetActiveQuiz()
-	if activeQuiz != nil {
-		c.JSON(http.StatusOK, activeQuiz)
+	if activeQuizzes := h.quizManager.GetActiveQuizzes(); len(activeQuizzes) > 0 {
+		// В списке только публичные викторины
+		listed := make([]*entity.Quiz, 0, len(activeQuizzes))
+		for _, quiz := range activeQuizzes {
+			if quiz.IsListed() {
+				listed = append(listed, quiz)
+			}
+		}
+		c.JSON(http.StatusOK, listed)
 		return
 	}
 
-	// Если не найдена активная викторина у менеджера, ищем в БД
-	quiz, err := h.quizService.GetActiveQuiz()
+	// Если у менеджера нет активных викторин, ищем в БД (их может проводить другой экземпляр)
+	quizzes, err := h.quizService.GetActiveQuizzes()
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "No active quiz"})
+		log.Printf("[QuizHandler] Ошибка при получении активных викторин: %v", err)
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to get active quizzes")
 		return
 	}
 
-	c.JSON(http.StatusOK, quiz)
+	c.JSON(http.StatusOK, quizzes)
 }
 
 // GetScheduledQuizzes возвращает список запланированных викторин
 func (h *QuizHandler) GetScheduledQuizzes(c *gin.Context) {
-	quizzes, err := h.quizService.GetScheduledQuizzes()
+	quizzes, err := h.quizService.GetPublicScheduledQuizzes()
 	if err != nil {
 		log.Printf("[QuizHandler] Ошибка при получении запланированных викторин: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondServiceError(c, err)
 		return
 	}
 
@@ -106,7 +212,7 @@ func (h *QuizHandler) GetScheduledQuizzes(c *gin.Context) {
 	jsonData, err := json.Marshal(quizzes)
 	if err != nil {
 		log.Printf("[QuizHandler] Ошибка при маршалинге JSON: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error during JSON marshaling"})
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Internal server error during JSON marshaling")
 		return
 	}
 
@@ -120,12 +226,18 @@ func (h *QuizHandler) GetScheduledQuizzes(c *gin.Context) {
 // AddQuestionsRequest представляет запрос на добавление вопросов
 type AddQuestionsRequest struct {
 	Questions []struct {
+		Type          string   `json:"type" binding:"omitempty,oneof=single_choice ordering"`
 		Text          string   `json:"text" binding:"required,min=3,max=500"`
-		Options       []string `json:"options" binding:"required,min=2,max=5"`
-		CorrectOption int      `json:"correct_option" binding:"required,min=0"`
-		TimeLimitSec  int      `json:"time_limit_sec" binding:"required,min=5,max=60"`
-		PointValue    int      `json:"point_value" binding:"required,min=1,max=100"`
-	} `json:"questions" binding:"required,min=1"`
+		Options       []string `json:"options" binding:"required,dive,required,max=200"`
+		CorrectOption int      `json:"correct_option" binding:"omitempty,min=1"`
+		// Для вопросов ordering: номера вариантов (с 1) в правильном порядке
+		CorrectOrder  []int  `json:"correct_order"`
+		ScoringMethod string `json:"scoring_method" binding:"omitempty,oneof=exact kendall_tau adjacent"`
+		TimeLimitSec  int    `json:"time_limit_sec" binding:"required,min=5,max=60"`
+		PointValue    int    `json:"point_value" binding:"required,min=1,max=100"`
+		// Сложность: easy, medium (по умолчанию) или hard
+		Difficulty string `json:"difficulty" binding:"omitempty,oneof=easy medium hard"`
+	} `json:"questions" binding:"required,min=1,max=50"`
 }
 
 // AddQuestions обрабатывает запрос на добавление вопросов к викторине
@@ -134,7 +246,13 @@ func (h *QuizHandler) AddQuestions(c *gin.Context) {
 
 	var req AddQuestionsRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondBindingError(c, err)
+		return
+	}
+
+	minOptions, maxOptions := h.quizService.OptionLimits()
+	if errs := normalizeAddQuestionsRequest(&req, minOptions, maxOptions); len(errs) > 0 {
+		respondValidationErrors(c, errs)
 		return
 	}
 
@@ -142,16 +260,20 @@ func (h *QuizHandler) AddQuestions(c *gin.Context) {
 	questions := make([]entity.Question, 0, len(req.Questions))
 	for _, q := range req.Questions {
 		questions = append(questions, entity.Question{
+			Type:          q.Type,
 			Text:          q.Text,
 			Options:       entity.StringArray(q.Options),
 			CorrectOption: q.CorrectOption,
+			CorrectOrder:  entity.IntArray(q.CorrectOrder),
+			ScoringMethod: q.ScoringMethod,
 			TimeLimitSec:  q.TimeLimitSec,
 			PointValue:    q.PointValue,
+			Difficulty:    q.Difficulty,
 		})
 	}
 
 	if err := h.quizService.AddQuestions(quizID, questions); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
 		return
 	}
 
@@ -169,32 +291,246 @@ func (h *QuizHandler) ScheduleQuiz(c *gin.Context) {
 
 	var req ScheduleQuizRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
 		return
 	}
 
 	// Сначала обновляем время в базе данных
 	if err := h.quizService.ScheduleQuiz(quizID, req.ScheduledTime); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondServiceError(c, err)
 		return
 	}
 
 	// Затем планируем викторину через QuizManager
 	if err := h.quizManager.ScheduleQuiz(quizID, req.ScheduledTime); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondServiceError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Quiz scheduled successfully"})
 }
 
+// CloneQuiz создает копию викторины с вопросами. Тело запроса необязательно.
+func (h *QuizHandler) CloneQuiz(c *gin.Context) {
+	quizID := c.MustGet("quizID").(uint) // Получаем из контекста
+
+	var req CloneQuizRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondBindingError(c, err)
+			return
+		}
+	}
+	if errs := normalizeCloneQuizRequest(&req); len(errs) > 0 {
+		respondValidationErrors(c, errs)
+		return
+	}
+
+	quiz, err := h.quizService.CloneQuiz(quizID, service.CloneQuizOptions{
+		Title:         req.Title,
+		Description:   req.Description,
+		ScheduledTime: req.ScheduledTime,
+	})
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	log.Printf("[QuizHandler] Викторина #%d скопирована в #%d (%d вопросов)", quizID, quiz.ID, quiz.QuestionCount)
+	c.JSON(http.StatusCreated, newAdminQuizResponse(quiz))
+}
+
+// ExportQuiz возвращает викторину с настройками, вопросами и правильными ответами
+// в версионированном формате для хранения в git и переноса между серверами
+func (h *QuizHandler) ExportQuiz(c *gin.Context) {
+	quizID := c.MustGet("quizID").(uint) // Получаем из контекста
+
+	export, err := h.quizService.ExportQuiz(quizID)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="quiz-%d.json"`, quizID))
+	c.JSON(http.StatusOK, export)
+}
+
+// ImportQuiz создает новую викторину из экспорта ExportQuiz. Все ошибки
+// проверки возвращаются в details ответа validation_error.
+func (h *QuizHandler) ImportQuiz(c *gin.Context) {
+	var req service.QuizExport
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindingError(c, err)
+		return
+	}
+
+	quiz, err := h.quizService.ImportQuiz(&req)
+	if err != nil {
+		var importErr *service.QuizImportError
+		if errors.As(err, &importErr) {
+			errs := make(ValidationErrors, 0, len(importErr.Fields))
+			for _, fe := range importErr.Fields {
+				errs = append(errs, FieldError{Field: fe.Field, Message: fe.Message})
+			}
+			respondValidationErrors(c, errs)
+			return
+		}
+		respondServiceError(c, err)
+		return
+	}
+
+	log.Printf("[QuizHandler] Импортирована викторина #%d (%d вопросов)", quiz.ID, quiz.QuestionCount)
+	c.JSON(http.StatusCreated, newAdminQuizResponse(quiz))
+}
+
+// RecurrenceRequest представляет изменение правила повторения викторины.
+// Отсутствующие поля не меняются.
+type RecurrenceRequest struct {
+	// Интервал повторения в минутах; 0 отменяет повторение
+	IntervalMin *int `json:"interval_min" binding:"omitempty,min=0"`
+	// Приостановить (true) или возобновить (false) серию
+	Paused *bool `json:"paused"`
+}
+
+// SetRecurrence задает, приостанавливает или отменяет повторение викторины
+func (h *QuizHandler) SetRecurrence(c *gin.Context) {
+	quizID := c.MustGet("quizID").(uint) // Получаем из контекста
+
+	var req RecurrenceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindingError(c, err)
+		return
+	}
+	if req.IntervalMin == nil && req.Paused == nil {
+		respondValidationErrors(c, ValidationErrors{{Field: "interval_min", Message: "either interval_min or paused is required"}})
+		return
+	}
+
+	quiz, err := h.quizService.SetRecurrence(quizID, service.RecurrenceOptions{
+		IntervalMin: req.IntervalMin,
+		Paused:      req.Paused,
+	})
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	log.Printf("[QuizHandler] Повторение викторины #%d: интервал %d мин, пауза: %v",
+		quizID, quiz.RecurrenceIntervalMin, quiz.RecurrencePaused)
+	c.JSON(http.StatusOK, quiz)
+}
+
+// RotateInviteCode выпускает новый код приглашения приватной викторины
+func (h *QuizHandler) RotateInviteCode(c *gin.Context) {
+	quizID := c.MustGet("quizID").(uint) // Получаем из контекста
+
+	code, err := h.quizService.RotateInviteCode(quizID)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	log.Printf("[QuizHandler] Выпущен новый код приглашения для викторины #%d", quizID)
+	c.JSON(http.StatusOK, gin.H{"quiz_id": quizID, "invite_code": code})
+}
+
+// GetQuizReadiness возвращает результат пробной проверки викторины перед проведением
+func (h *QuizHandler) GetQuizReadiness(c *gin.Context) {
+	quizID := c.MustGet("quizID").(uint) // Получаем из контекста
+
+	readiness, err := h.quizService.CheckReadiness(quizID)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, readiness)
+}
+
+// GetQuizParticipation возвращает распределение игроков викторины по странам
+func (h *QuizHandler) GetQuizParticipation(c *gin.Context) {
+	quizID := c.MustGet("quizID").(uint) // Получаем из контекста
+
+	participation, err := h.resultService.GetQuizParticipation(quizID)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, participation)
+}
+
+// RecomputeQuizResults заново подсчитывает результаты завершенной викторины по
+// сохраненным ответам. По умолчанию выполняется в режиме dry-run и только
+// возвращает различия; для сохранения нужно передать dry_run=false.
+func (h *QuizHandler) RecomputeQuizResults(c *gin.Context) {
+	quizID := c.MustGet("quizID").(uint) // Получаем из контекста
+
+	dryRun, err := strconv.ParseBool(c.DefaultQuery("dry_run", "true"))
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidRequest, "Invalid 'dry_run' value, expected true or false")
+		return
+	}
+
+	report, err := h.resultService.RecomputeQuizResults(quizID, c.MustGet("user_id").(uint), dryRun)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// ReplayQuiz запускает повтор завершенной викторины для новой аудитории
+func (h *QuizHandler) ReplayQuiz(c *gin.Context) {
+	quizID := c.MustGet("quizID").(uint) // Получаем из контекста
+
+	if err := h.quizManager.StartReplay(quizID); err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "Quiz replay started", "quiz_id": quizID})
+}
+
+// KickPlayerRequest представляет запрос на исключение игрока из идущей викторины
+type KickPlayerRequest struct {
+	UserID uint   `json:"user_id" binding:"required"`
+	Reason string `json:"reason" binding:"omitempty,max=200"`
+}
+
+// KickPlayer исключает игрока из идущей викторины
+func (h *QuizHandler) KickPlayer(c *gin.Context) {
+	quizID := c.MustGet("quizID").(uint) // Получаем из контекста
+
+	var req KickPlayerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindingError(c, err)
+		return
+	}
+	if req.Reason == "" {
+		req.Reason = "removed_by_moderator"
+	}
+
+	participants, err := h.quizManager.KickPlayer(quizID, req.UserID, c.MustGet("user_id").(uint), req.Reason)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"quiz_id":      quizID,
+		"user_id":      req.UserID,
+		"participants": participants,
+	})
+}
+
 // CancelQuiz обрабатывает запрос на отмену викторины
 func (h *QuizHandler) CancelQuiz(c *gin.Context) {
 	quizID := c.MustGet("quizID").(uint) // Получаем из контекста
 
 	if err := h.quizManager.CancelQuiz(quizID); err != nil {
-		// TODO: Улучшить обработку ошибок (п.7)
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondServiceError(c, err)
 		return
 	}
 
@@ -205,10 +541,9 @@ func (h *QuizHandler) CancelQuiz(c *gin.Context) {
 func (h *QuizHandler) GetQuizWithQuestions(c *gin.Context) {
 	quizID := c.MustGet("quizID").(uint) // Получаем из контекста
 
-	quiz, err := h.quizService.GetQuizWithQuestions(quizID)
+	quiz, err := h.quizService.GetVisibleQuizWithQuestions(quizID, c.Query("invite_code"))
 	if err != nil {
-		// TODO: Улучшить обработку ошибок (п.7)
-		c.JSON(http.StatusNotFound, gin.H{"error": "Quiz not found"})
+		respondServiceError(c, err)
 		return
 	}
 
@@ -233,7 +568,7 @@ func (h *QuizHandler) GetQuizResults(c *gin.Context) {
 	results, err := h.resultService.GetQuizResults(quizID)
 	if err != nil {
 		// TODO: Улучшить обработку ошибок (п.7)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondServiceError(c, err)
 		return
 	}
 
@@ -247,19 +582,39 @@ func (h *QuizHandler) GetUserQuizResult(c *gin.Context) {
 	// Получаем ID пользователя из контекста
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		apierror.Respond(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "Unauthorized")
 		return
 	}
 
 	result, err := h.resultService.GetUserResult(userID.(uint), quizID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Result not found"})
+		apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, "Result not found")
 		return
 	}
 
 	c.JSON(http.StatusOK, result)
 }
 
+// GetUserQuizAnswers возвращает разбор ответов пользователя по вопросам викторины.
+// Правильные ответы и очки раскрываются только после завершения викторины.
+func (h *QuizHandler) GetUserQuizAnswers(c *gin.Context) {
+	quizID := c.MustGet("quizID").(uint) // Получаем из контекста
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		apierror.Respond(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "Unauthorized")
+		return
+	}
+
+	quiz, answers, err := h.resultService.GetUserAnswerHistory(userID.(uint), quizID)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewAnswerHistoryResponse(quiz, answers))
+}
+
 // ListQuizzes возвращает список викторин с пагинацией
 func (h *QuizHandler) ListQuizzes(c *gin.Context) {
 	pageStr := c.DefaultQuery("page", "1")
@@ -277,26 +632,107 @@ func (h *QuizHandler) ListQuizzes(c *gin.Context) {
 
 	quizzes, err := h.quizService.ListQuizzes(page, pageSize)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondServiceError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, quizzes)
 }
 
-// handleQuizError обрабатывает ошибки от сервисов викторин и отправляет соответствующий HTTP ответ
-func (h *QuizHandler) handleQuizError(c *gin.Context, err error) {
-	// Определяем тип ошибки и возвращаем соответствующий статус
-	// TODO: Определить и использовать специфичные типы ошибок из сервисов
-	if errors.Is(err, service.ErrQuizNotFound) { // Пример кастомной ошибки
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
-	} else if errors.Is(err, service.ErrQuizNotSchedulable) { // Пример кастомной ошибки
-		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
-	} else if errors.Is(err, service.ErrValidation) { // Пример кастомной ошибки
-		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
-	} else {
-		// Общая ошибка сервера
-		log.Printf("ERROR: Internal server error in QuizHandler: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+// GetAnswerQueueMetrics возвращает метрики очереди обработки ответов
+func (h *QuizHandler) GetAnswerQueueMetrics(c *gin.Context) {
+	c.JSON(http.StatusOK, h.quizManager.GetAnswerQueueMetrics())
+}
+
+// GetMyActiveQuiz возвращает участие текущего пользователя в проводимой викторине.
+// 204, если пользователь сейчас ни в одной викторине не участвует.
+func (h *QuizHandler) GetMyActiveQuiz(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+
+	participation := h.quizManager.GetUserParticipation(userID)
+	if participation == nil {
+		c.Status(http.StatusNoContent)
+		return
+	}
+
+	c.JSON(http.StatusOK, participation)
+}
+
+// GetQuizSync возвращает снимок состояния викторины для переподключившегося игрока:
+// текущий вопрос без неоткрытого ответа, оставшееся время, свой ответ, выбывание
+// и таблицу лидеров. Для приватной викторины игроку, еще не занявшему место,
+// нужен код приглашения в параметре invite_code.
+func (h *QuizHandler) GetQuizSync(c *gin.Context) {
+	quizID := c.MustGet("quizID").(uint) // Получаем из контекста
+
+	snapshot, err := h.quizManager.GetQuizSync(quizID, c.MustGet("user_id").(uint), c.Query("invite_code"))
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, snapshot)
+}
+
+// JoinQuizRequest представляет запрос на присоединение к викторине
+type JoinQuizRequest struct {
+	InviteCode string `json:"invite_code" binding:"omitempty,max=32"`
+}
+
+// JoinQuiz присоединяет текущего пользователя к викторине до подключения по
+// WebSocket: занимает место и возвращает состояние викторины
+func (h *QuizHandler) JoinQuiz(c *gin.Context) {
+	quizID := c.MustGet("quizID").(uint) // Получаем из контекста
+
+	var req JoinQuizRequest
+	// Тело необязательно: код приглашения нужен только для приватной викторины
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondBindingError(c, err)
+			return
+		}
 	}
+
+	state, err := h.quizManager.JoinQuiz(quizID, c.MustGet("user_id").(uint), req.InviteCode)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, state)
+}
+
+// LeaveQuiz освобождает место текущего пользователя в викторине
+func (h *QuizHandler) LeaveQuiz(c *gin.Context) {
+	quizID := c.MustGet("quizID").(uint) // Получаем из контекста
+
+	if err := h.quizManager.LeaveQuiz(quizID, c.MustGet("user_id").(uint)); err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Left the quiz", "quiz_id": quizID})
+}
+
+// GetConcurrencyMetrics возвращает число проводимых викторин, пиковое значение и лимит
+func (h *QuizHandler) GetConcurrencyMetrics(c *gin.Context) {
+	c.JSON(http.StatusOK, h.quizManager.GetConcurrencyMetrics())
+}
+
+// ConcurrencyLimitRequest представляет запрос на изменение лимита одновременных викторин
+type ConcurrencyLimitRequest struct {
+	MaxConcurrentQuizzes *int `json:"max_concurrent_quizzes" binding:"required,min=0"`
+}
+
+// SetConcurrencyLimit изменяет лимит одновременно проводимых викторин (0 - без ограничения).
+// Лимит действует до перезапуска сервера.
+func (h *QuizHandler) SetConcurrencyLimit(c *gin.Context) {
+	var req ConcurrencyLimitRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindingError(c, err)
+		return
+	}
+
+	h.quizManager.SetMaxConcurrentQuizzes(*req.MaxConcurrentQuizzes)
+	c.JSON(http.StatusOK, h.quizManager.GetConcurrencyMetrics())
 }
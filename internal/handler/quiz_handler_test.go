@@ -0,0 +1,126 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/yourusername/trivia-api/internal/domain/entity"
+	"github.com/yourusername/trivia-api/internal/domain/repository"
+	"github.com/yourusername/trivia-api/internal/middleware"
+	"github.com/yourusername/trivia-api/internal/service"
+)
+
+// statusQuizRepo отдает викторины из памяти; failing имитирует недоступность БД
+type statusQuizRepo struct {
+	repository.QuizRepository
+	quizzes map[uint]*entity.Quiz
+	failing bool
+}
+
+func (r *statusQuizRepo) GetByID(id uint) (*entity.Quiz, error) {
+	if r.failing {
+		return nil, errors.New("pq: connection refused")
+	}
+	quiz, ok := r.quizzes[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	copied := *quiz
+	return &copied, nil
+}
+
+func (r *statusQuizRepo) GetWithQuestions(id uint) (*entity.Quiz, error) {
+	return r.GetByID(id)
+}
+
+func newQuizStatusRouter(repo *statusQuizRepo) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	h := NewQuizHandler(service.NewQuizService(repo, nil, nil), nil, nil)
+
+	router := gin.New()
+	quiz := router.Group("/api/quizzes/:id", middleware.ExtractUintParam("id", "quizID"))
+	quiz.GET("", h.GetQuiz)
+	quiz.GET("/with-questions", h.GetQuizWithQuestions)
+	quiz.PUT("/schedule", h.ScheduleQuiz)
+	return router
+}
+
+func TestQuizHandler_StatusPerQuizState(t *testing.T) {
+	repo := &statusQuizRepo{quizzes: map[uint]*entity.Quiz{
+		1: {ID: 1, Status: "scheduled"},
+		2: {ID: 2, Status: "in_progress"},
+	}}
+	router := newQuizStatusRouter(repo)
+	schedule := `{"scheduled_time": "` + time.Now().Add(-time.Hour).UTC().Format(time.RFC3339) + `"}`
+
+	cases := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		status int
+		code   string
+	}{
+		{"викторина найдена", http.MethodGet, "/api/quizzes/1", "", http.StatusOK, ""},
+		{"викторины нет", http.MethodGet, "/api/quizzes/42", "", http.StatusNotFound, "not_found"},
+		{"викторины с вопросами нет", http.MethodGet, "/api/quizzes/42/with-questions", "", http.StatusNotFound, "not_found"},
+		{"перенос несуществующей", http.MethodPut, "/api/quizzes/42/schedule", schedule, http.StatusNotFound, "not_found"},
+		{"перенос идущей", http.MethodPut, "/api/quizzes/2/schedule", schedule, http.StatusConflict, "conflict"},
+		{"время в прошлом", http.MethodPut, "/api/quizzes/1/schedule", schedule, http.StatusUnprocessableEntity, "validation_error"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.status, w.Code, w.Body.String())
+			if tc.code != "" {
+				assert.Equal(t, tc.code, decodeBody(t, w)["code"])
+			}
+		})
+	}
+
+	// Ошибка БД не выдается за отсутствие викторины
+	repo.failing = true
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/quizzes/1", nil))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
+
+func TestQuizHandler_ImportQuizValidationDetails(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := NewQuizHandler(service.NewQuizService(&statusQuizRepo{}, nil, nil), nil, nil)
+	router := gin.New()
+	router.POST("/api/quizzes/import", h.ImportQuiz)
+
+	body := `{"format_version": 1, "quiz": {"title": "Импорт", "scheduled_time": "` +
+		time.Now().Add(time.Hour).UTC().Format(time.RFC3339) + `"}, "questions": []}`
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/quizzes/import", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+	resp := decodeBody(t, w)
+	assert.Equal(t, "validation_error", resp["code"])
+	details, _ := resp["details"].([]interface{})
+	if assert.Len(t, details, 1) {
+		assert.Equal(t, "questions", details[0].(map[string]interface{})["field"])
+	}
+
+	// Некорректный JSON отклоняется без обращения к сервису
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/api/quizzes/import", strings.NewReader(`{"questions": {}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "invalid_request", decodeBody(t, w)["code"])
+}
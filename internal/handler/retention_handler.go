@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/trivia-api/internal/handler/apierror"
+	"github.com/yourusername/trivia-api/internal/service"
+)
+
+// RetentionHandler обрабатывает ручной запуск очистки старых викторин
+type RetentionHandler struct {
+	retentionService *service.RetentionService
+}
+
+// NewRetentionHandler создает новый обработчик очистки
+func NewRetentionHandler(retentionService *service.RetentionService) *RetentionHandler {
+	return &RetentionHandler{
+		retentionService: retentionService,
+	}
+}
+
+// RunCleanup запускает очистку старых викторин.
+// По умолчанию выполняется в режиме dry-run; для удаления нужно передать dry_run=false.
+func (h *RetentionHandler) RunCleanup(c *gin.Context) {
+	dryRun, err := strconv.ParseBool(c.DefaultQuery("dry_run", "true"))
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidRequest, "Invalid 'dry_run' value, expected true or false")
+		return
+	}
+
+	report, err := h.retentionService.Run(c.Request.Context(), dryRun)
+	if err != nil {
+		if errors.Is(err, service.ErrRetentionInProgress) || errors.Is(err, service.ErrValidation) {
+			respondServiceError(c, err)
+			return
+		}
+		log.Printf("[RetentionHandler] Ошибка при очистке викторин: %v", err)
+		apierror.RespondDetails(c, http.StatusInternalServerError, apierror.CodeInternal, "Retention cleanup failed", report)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
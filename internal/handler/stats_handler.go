@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/trivia-api/internal/service"
+)
+
+// StatsHandler отдает статистику платформы
+type StatsHandler struct {
+	statsService *service.StatsService
+}
+
+// NewStatsHandler создает обработчик статистики
+func NewStatsHandler(statsService *service.StatsService) *StatsHandler {
+	return &StatsHandler{statsService: statsService}
+}
+
+// GetPublicStats возвращает общую статистику платформы (без аутентификации)
+func (h *StatsHandler) GetPublicStats(c *gin.Context) {
+	stats, err := h.statsService.PublicStats()
+	if err != nil {
+		log.Printf("[StatsHandler] Ошибка при подсчете статистики: %v", err)
+		respondServiceError(c, err)
+		return
+	}
+	// Статистика и так обновляется не чаще раза в CacheTTL
+	c.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", int(h.statsService.CacheTTL().Seconds())))
+	c.JSON(http.StatusOK, stats)
+}
+
+// GetAdminStats возвращает полную статистику платформы для администраторов
+func (h *StatsHandler) GetAdminStats(c *gin.Context) {
+	stats, err := h.statsService.AdminStats()
+	if err != nil {
+		log.Printf("[StatsHandler] Ошибка при подсчете статистики: %v", err)
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, stats)
+}
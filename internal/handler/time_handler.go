@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/trivia-api/internal/handler/apierror"
+)
+
+// ServerTimeResponse - ответ GET /api/time.
+// Поля client_send_ms и server_receive_ms/server_send_ms нужны для оценки
+// смещения часов по схеме NTP и заполняются, если клиент передал client_send_ms.
+type ServerTimeResponse struct {
+	ServerTimeMs      int64  `json:"server_time_ms"`
+	ServerTimeRFC3339 string `json:"server_time_rfc3339"`
+	ClientSendMs      int64  `json:"client_send_ms,omitempty"`
+	ServerReceiveMs   int64  `json:"server_receive_ms,omitempty"`
+	ServerSendMs      int64  `json:"server_send_ms,omitempty"`
+}
+
+// TimeHandler отдает время сервера для синхронизации часов клиентов
+type TimeHandler struct{}
+
+// NewTimeHandler создает обработчик времени сервера
+func NewTimeHandler() *TimeHandler {
+	return &TimeHandler{}
+}
+
+// GetServerTime возвращает текущее время сервера. Не требует аутентификации
+// и не обращается к БД. Необязательный параметр client_send_ms - время отправки
+// запроса по часам клиента (Unix, мс).
+func (h *TimeHandler) GetServerTime(c *gin.Context) {
+	received := time.Now()
+
+	resp := ServerTimeResponse{}
+	if clientSend := c.Query("client_send_ms"); clientSend != "" {
+		clientSendMs, err := strconv.ParseInt(clientSend, 10, 64)
+		if err != nil || clientSendMs <= 0 {
+			apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidRequest, "Invalid 'client_send_ms', expected Unix time in milliseconds")
+			return
+		}
+		resp.ClientSendMs = clientSendMs
+		resp.ServerReceiveMs = received.UnixMilli()
+	}
+
+	now := time.Now()
+	resp.ServerTimeMs = now.UnixMilli()
+	resp.ServerTimeRFC3339 = now.UTC().Format(time.RFC3339Nano)
+	if resp.ClientSendMs != 0 {
+		resp.ServerSendMs = resp.ServerTimeMs
+	}
+
+	// Ответ нельзя кэшировать: устаревшее время хуже его отсутствия
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, resp)
+}
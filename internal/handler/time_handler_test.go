@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func getServerTime(query string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/time"+query, nil)
+	NewTimeHandler().GetServerTime(c)
+	return w
+}
+
+func TestTimeHandler_GetServerTime(t *testing.T) {
+	before := time.Now().UnixMilli()
+	w := getServerTime("")
+	after := time.Now().UnixMilli()
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+
+	var resp ServerTimeResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.GreaterOrEqual(t, resp.ServerTimeMs, before)
+	assert.LessOrEqual(t, resp.ServerTimeMs, after)
+
+	parsed, err := time.Parse(time.RFC3339Nano, resp.ServerTimeRFC3339)
+	require.NoError(t, err)
+	assert.Equal(t, resp.ServerTimeMs, parsed.UnixMilli())
+	assert.Zero(t, resp.ServerReceiveMs, "без client_send_ms поля NTP не заполняются")
+	assert.NotContains(t, w.Body.String(), "client_send_ms")
+}
+
+func TestTimeHandler_RoundTripFields(t *testing.T) {
+	w := getServerTime("?client_send_ms=1760600000000")
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp ServerTimeResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, int64(1760600000000), resp.ClientSendMs)
+	assert.NotZero(t, resp.ServerReceiveMs)
+	assert.GreaterOrEqual(t, resp.ServerSendMs, resp.ServerReceiveMs)
+
+	assert.Equal(t, http.StatusBadRequest, getServerTime("?client_send_ms=abc").Code)
+}
@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/trivia-api/internal/service"
+)
+
+// TournamentHandler обрабатывает запросы, связанные с турнирами
+type TournamentHandler struct {
+	tournamentService *service.TournamentService
+	resultService     *service.ResultService
+}
+
+// NewTournamentHandler создает новый обработчик турниров
+func NewTournamentHandler(tournamentService *service.TournamentService, resultService *service.ResultService) *TournamentHandler {
+	return &TournamentHandler{
+		tournamentService: tournamentService,
+		resultService:     resultService,
+	}
+}
+
+// CreateTournamentRequest представляет запрос на создание турнира
+type CreateTournamentRequest struct {
+	Title       string `json:"title" binding:"required,min=3,max=100"`
+	Description string `json:"description" binding:"omitempty,max=500"`
+	// Правило подсчета: sum (по умолчанию) или best_n
+	ScoringMode string `json:"scoring_mode" binding:"omitempty,oneof=sum best_n"`
+	// Сколько лучших результатов игрока учитывать при best_n
+	BestN int `json:"best_n" binding:"omitempty,min=1,max=100"`
+}
+
+// AddTournamentQuizRequest представляет запрос на добавление викторины в турнир
+type AddTournamentQuizRequest struct {
+	QuizID uint `json:"quiz_id" binding:"required"`
+}
+
+// CreateTournament создает турнир
+func (h *TournamentHandler) CreateTournament(c *gin.Context) {
+	var req CreateTournamentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindingError(c, err)
+		return
+	}
+
+	tournament, err := h.tournamentService.CreateTournament(service.TournamentOptions{
+		Title:       req.Title,
+		Description: req.Description,
+		ScoringMode: req.ScoringMode,
+		BestN:       req.BestN,
+	})
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, tournament)
+}
+
+// GetTournament возвращает турнир и ID его викторин
+func (h *TournamentHandler) GetTournament(c *gin.Context) {
+	tournamentID := c.MustGet("tournamentID").(uint) // Получаем из контекста
+
+	tournament, err := h.tournamentService.GetTournament(tournamentID)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tournament)
+}
+
+// AddQuiz добавляет викторину в турнир
+func (h *TournamentHandler) AddQuiz(c *gin.Context) {
+	tournamentID := c.MustGet("tournamentID").(uint) // Получаем из контекста
+
+	var req AddTournamentQuizRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindingError(c, err)
+		return
+	}
+
+	tournament, err := h.tournamentService.AddQuiz(tournamentID, req.QuizID)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	log.Printf("[TournamentHandler] Викторина #%d добавлена в турнир #%d", req.QuizID, tournamentID)
+	c.JSON(http.StatusOK, tournament)
+}
+
+// GetStandings возвращает общий зачет турнира
+func (h *TournamentHandler) GetStandings(c *gin.Context) {
+	tournamentID := c.MustGet("tournamentID").(uint) // Получаем из контекста
+
+	standings, err := h.resultService.TournamentStandings(tournamentID)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, standings)
+}
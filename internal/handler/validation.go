@@ -0,0 +1,230 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/yourusername/trivia-api/internal/domain/entity"
+	"github.com/yourusername/trivia-api/internal/handler/apierror"
+)
+
+func init() {
+	// Ошибки биндинга должны ссылаться на поля так же, как их видит клиент - по JSON-именам
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(func(field reflect.StructField) string {
+			name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+			if name == "-" || name == "" {
+				return field.Name
+			}
+			return name
+		})
+	}
+}
+
+// Ограничения на пользовательский текст викторин и вопросов.
+// Весь этот текст рассылается каждому участнику через WebSocket,
+// поэтому размеры ограничены и на уровне биндинга, и после нормализации.
+const (
+	minQuizTitleLength     = 3
+	maxQuizTitleLength     = 100
+	maxQuizDescriptionLen  = 500
+	minQuestionTextLength  = 3
+	maxQuestionTextLength  = 500
+	maxOptionTextLength    = 200
+	maxQuestionsPerRequest = 50
+)
+
+// FieldError описывает ошибку валидации одного поля запроса
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationErrors накапливает ошибки валидации полей
+type ValidationErrors []FieldError
+
+// Add добавляет ошибку для поля
+func (v *ValidationErrors) Add(field, format string, args ...interface{}) {
+	*v = append(*v, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// checkLength проверяет длину строки в символах (а не байтах)
+func (v *ValidationErrors) checkLength(field, value string, min, max int) {
+	length := utf8.RuneCountInString(value)
+	if length < min {
+		if min == 1 {
+			v.Add(field, "must not be empty")
+		} else {
+			v.Add(field, "must be at least %d characters", min)
+		}
+		return
+	}
+	if length > max {
+		v.Add(field, "must be at most %d characters", max)
+	}
+}
+
+// respondValidationErrors отправляет ответ validation_error со списком ошибок полей в details
+func respondValidationErrors(c *gin.Context, errs ValidationErrors) {
+	apierror.RespondDetails(c, http.StatusBadRequest, apierror.CodeValidation, "Validation failed", errs)
+}
+
+// respondBindingError преобразует ошибку биндинга gin в структурированный ответ.
+// Ошибки разбора JSON (не валидации) возвращаются как одно общее поле.
+func respondBindingError(c *gin.Context, err error) {
+	var verrs validator.ValidationErrors
+	if !errors.As(err, &verrs) {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidRequest, "Invalid request body")
+		return
+	}
+
+	errs := make(ValidationErrors, 0, len(verrs))
+	for _, fe := range verrs {
+		errs = append(errs, FieldError{
+			Field:   bindingFieldPath(fe),
+			Message: bindingErrorMessage(fe),
+		})
+	}
+	respondValidationErrors(c, errs)
+}
+
+// bindingFieldPath возвращает путь к полю без имени корневой структуры,
+// например "questions[0].options[1]"
+func bindingFieldPath(fe validator.FieldError) string {
+	ns := fe.Namespace()
+	if idx := strings.Index(ns, "."); idx >= 0 {
+		return ns[idx+1:]
+	}
+	return ns
+}
+
+// bindingErrorMessage формирует читаемое сообщение для ошибки тега валидации
+func bindingErrorMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "min":
+		return fmt.Sprintf("must be at least %s", fe.Param())
+	case "max":
+		return fmt.Sprintf("must be at most %s", fe.Param())
+	default:
+		return fmt.Sprintf("failed '%s' validation", fe.Tag())
+	}
+}
+
+// normalizeCloneQuizRequest обрезает пробелы и проверяет переопределения копии викторины
+func normalizeCloneQuizRequest(req *CloneQuizRequest) ValidationErrors {
+	var errs ValidationErrors
+
+	if req.Title != nil {
+		title := strings.TrimSpace(*req.Title)
+		req.Title = &title
+		errs.checkLength("title", title, minQuizTitleLength, maxQuizTitleLength)
+	}
+	if req.Description != nil {
+		description := strings.TrimSpace(*req.Description)
+		req.Description = &description
+		errs.checkLength("description", description, 0, maxQuizDescriptionLen)
+	}
+
+	return errs
+}
+
+// normalizeCreateQuizRequest обрезает пробелы и проверяет поля запроса на создание викторины
+func normalizeCreateQuizRequest(req *CreateQuizRequest) ValidationErrors {
+	var errs ValidationErrors
+
+	req.Title = strings.TrimSpace(req.Title)
+	req.Description = strings.TrimSpace(req.Description)
+
+	errs.checkLength("title", req.Title, minQuizTitleLength, maxQuizTitleLength)
+	errs.checkLength("description", req.Description, 0, maxQuizDescriptionLen)
+
+	return errs
+}
+
+// normalizeAddQuestionsRequest обрезает пробелы и проверяет тексты вопросов и вариантов ответов.
+// Допустимое число вариантов задается настройками QuizService.
+func normalizeAddQuestionsRequest(req *AddQuestionsRequest, minOptions, maxOptions int) ValidationErrors {
+	var errs ValidationErrors
+
+	if len(req.Questions) > maxQuestionsPerRequest {
+		errs.Add("questions", "must contain at most %d questions", maxQuestionsPerRequest)
+		return errs
+	}
+
+	for i := range req.Questions {
+		q := &req.Questions[i]
+		prefix := fmt.Sprintf("questions[%d]", i)
+
+		q.Text = strings.TrimSpace(q.Text)
+		errs.checkLength(prefix+".text", q.Text, minQuestionTextLength, maxQuestionTextLength)
+
+		if len(q.Options) < minOptions || len(q.Options) > maxOptions {
+			errs.Add(prefix+".options", "must contain between %d and %d options", minOptions, maxOptions)
+		}
+
+		seen := make(map[string]int, len(q.Options))
+		for j := range q.Options {
+			q.Options[j] = strings.TrimSpace(q.Options[j])
+			field := fmt.Sprintf("%s.options[%d]", prefix, j)
+			errs.checkLength(field, q.Options[j], 1, maxOptionTextLength)
+
+			// Одинаковые варианты неразличимы для игрока
+			key := strings.ToLower(q.Options[j])
+			if first, ok := seen[key]; ok && key != "" {
+				errs.Add(field, "duplicates options[%d]", first)
+			} else {
+				seen[key] = j
+			}
+		}
+
+		if q.Type == "" {
+			q.Type = entity.QuestionTypeSingleChoice
+		}
+		if q.Difficulty == "" {
+			q.Difficulty = entity.DifficultyMedium
+		}
+
+		switch q.Type {
+		case entity.QuestionTypeOrdering:
+			if !isOptionPermutation(q.CorrectOrder, len(q.Options)) {
+				errs.Add(prefix+".correct_order", "must list every option number exactly once")
+			}
+			if q.ScoringMethod == "" {
+				q.ScoringMethod = entity.OrderingScoringExact
+			}
+		default:
+			// Варианты нумеруются с 1 (см. helper.ConvertOptionsToObjects)
+			if q.CorrectOption < 1 || q.CorrectOption > len(q.Options) {
+				errs.Add(prefix+".correct_option", "must reference an existing option")
+			}
+			q.CorrectOrder = nil
+			q.ScoringMethod = ""
+		}
+	}
+
+	return errs
+}
+
+// isOptionPermutation проверяет, что order содержит каждый номер варианта от 1 до n ровно один раз
+func isOptionPermutation(order []int, n int) bool {
+	if len(order) != n {
+		return false
+	}
+	seen := make([]bool, n+1)
+	for _, option := range order {
+		if option < 1 || option > n || seen[option] {
+			return false
+		}
+		seen[option] = true
+	}
+	return true
+}
@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// questionsRequest собирает запрос с одним вопросом single_choice
+func questionsRequest(t *testing.T, options []string, correct int) *AddQuestionsRequest {
+	body, err := json.Marshal(map[string]interface{}{
+		"questions": []map[string]interface{}{{
+			"text":           "Столица Франции?",
+			"options":        options,
+			"correct_option": correct,
+			"time_limit_sec": 10,
+			"point_value":    10,
+		}},
+	})
+	require.NoError(t, err)
+
+	var req AddQuestionsRequest
+	require.NoError(t, json.Unmarshal(body, &req))
+	return &req
+}
+
+func TestNormalizeAddQuestionsRequest_Options(t *testing.T) {
+	cases := []struct {
+		name    string
+		options []string
+		correct int
+		field   string
+	}{
+		{"слишком мало вариантов", []string{"Париж"}, 1, "questions[0].options"},
+		{"слишком много вариантов", []string{"a", "b", "c", "d", "e", "f", "g"}, 1, "questions[0].options"},
+		{"повтор варианта", []string{"Париж", "Лион", " париж"}, 1, "questions[0].options[2]"},
+		{"правильный ответ за рамками", []string{"Париж", "Лион"}, 3, "questions[0].correct_option"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			errs := normalizeAddQuestionsRequest(questionsRequest(t, tc.options, tc.correct), 2, 6)
+			require.Len(t, errs, 1)
+			assert.Equal(t, tc.field, errs[0].Field)
+		})
+	}
+}
+
+func TestNormalizeAddQuestionsRequest_KeepsOptionOrder(t *testing.T) {
+	req := questionsRequest(t, []string{" Лион ", "Париж", "Марсель"}, 2)
+	require.Empty(t, normalizeAddQuestionsRequest(req, 2, 6))
+	assert.Equal(t, []string{"Лион", "Париж", "Марсель"}, req.Questions[0].Options)
+}
@@ -2,17 +2,23 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	gorillaws "github.com/gorilla/websocket"
+	"github.com/yourusername/trivia-api/internal/handler/apierror"
+	"github.com/yourusername/trivia-api/internal/i18n"
 	"github.com/yourusername/trivia-api/internal/service"
 	"github.com/yourusername/trivia-api/internal/websocket"
 	"github.com/yourusername/trivia-api/pkg/auth"
+	"github.com/yourusername/trivia-api/pkg/auth/manager"
+	"github.com/yourusername/trivia-api/pkg/geoip"
 )
 
 // WSHandler обрабатывает WebSocket соединения
@@ -21,8 +27,62 @@ type WSHandler struct {
 	wsManager   *websocket.Manager
 	quizManager *service.QuizManager
 	jwtService  *auth.JWTService
+
+	// Подключение по куке access_token (если тикет не передан).
+	// Выключено по умолчанию, см. EnableAccessTokenCookieAuth.
+	cookieAuthEnabled bool
+	// Источники (Origin), с которых разрешено подключение по куке
+	cookieAuthOrigins map[string]bool
+
+	// Upgrader соединений; при включенном бинарном протоколе объявляет его подпротокол
+	upgrader *gorillaws.Upgrader
+
+	// Источники (Origin), с которых браузер может подключиться к /ws
+	allowedOrigins map[string]bool
+	// Проверка Origin выключена (только для разработки), см. AllowAllOrigins
+	allowAllOrigins bool
+
+	// debug:ping доступен только администраторам (иначе остальным отдаются несекретные поля)
+	debugPingAdminOnly bool
+
+	// Размер буфера исходящих сообщений клиента (0 - значение по умолчанию)
+	clientBufferSize int
+
+	// Политика записи (0 - значения по умолчанию)
+	writeTimeout       time.Duration
+	slowWriteThreshold time.Duration
+	maxSlowWrites      int
+
+	// Предупреждение о скором истечении refresh-токена (см. EnableRefreshExpiryWarnings)
+	refreshTokenInfo   RefreshTokenInfoProvider
+	refreshWarningLead time.Duration
+
+	// Режим обслуживания: новые подключения отклоняются (см. SetMaintenance)
+	maintenance MaintenanceStateProvider
+
+	// Местоположение подключений по IP (nil - не определяется, см. SetGeoProvider)
+	geoProvider geoip.Provider
+
+	// Лимит соединений с одного IP (nil - без ограничения, см. SetIPConnectionLimit)
+	ipLimit *ipConnectionLimit
+}
+
+// MaintenanceStateProvider сообщает, включен ли режим обслуживания (реализуется MaintenanceService)
+type MaintenanceStateProvider interface {
+	IsEnabled() bool
 }
 
+// maintenanceCloseReason - причина закрытия соединения в режиме обслуживания
+const maintenanceCloseReason = "maintenance"
+
+// RefreshTokenInfoProvider возвращает сроки действия refresh-токена (реализуется TokenManager)
+type RefreshTokenInfoProvider interface {
+	GetTokenInfo(refreshToken string) (*manager.TokenInfo, error)
+}
+
+// wsAdminRole - роль клиента, которому доступна подробная диагностика
+const wsAdminRole = "admin"
+
 // NewWSHandler создает новый обработчик WebSocket
 func NewWSHandler(
 	wsHub websocket.HubInterface,
@@ -36,6 +96,7 @@ func NewWSHandler(
 		quizManager: quizManager,
 		jwtService:  jwtService,
 	}
+	handler.upgrader = handler.newUpgrader()
 
 	// Регистрируем обработчики сообщений один раз при создании обработчика
 	handler.registerMessageHandlers()
@@ -43,62 +104,330 @@ func NewWSHandler(
 	return handler
 }
 
-var upgrader = gorillaws.Upgrader{
-	ReadBufferSize:  1024,
-	WriteBufferSize: 1024,
-	CheckOrigin: func(r *http.Request) bool {
-		// В продакшене здесь должна быть проверка допустимых источников
-		origin := r.Header.Get("Origin")
-		log.Printf("WebSocket: checking origin: %s", origin)
-		// Всегда разрешаем для тестирования
+// EnableAccessTokenCookieAuth разрешает подключение по куке access_token, если тикет не передан.
+//
+// Компромисс безопасности: кука живет столько же, сколько access-токен (часы, а не 30 секунд
+// как тикет), и браузер отправляет ее автоматически. Поэтому при подключении по куке
+// выполняется полная проверка инвалидации токена, а запросы с заголовком Origin
+// принимаются только с allowedOrigins - иначе сторонний сайт мог бы открыть WebSocket
+// от имени пользователя (cross-site WebSocket hijacking).
+func (h *WSHandler) EnableAccessTokenCookieAuth(allowedOrigins []string) {
+	h.cookieAuthEnabled = true
+	h.cookieAuthOrigins = make(map[string]bool, len(allowedOrigins))
+	for _, origin := range allowedOrigins {
+		h.cookieAuthOrigins[origin] = true
+	}
+	log.Printf("[WSHandler] Разрешено подключение WebSocket по куке %s (источники: %v)", manager.AccessTokenCookie, allowedOrigins)
+}
+
+// SetAllowedOrigins задает источники, с которых браузер может открыть WebSocket
+// (обычно тот же список, что и для CORS). Запросы с другим Origin отклоняются
+// с кодом 403. Запросы без Origin (не из браузера, например боты) принимаются:
+// cross-site WebSocket hijacking возможен только из браузера, а он Origin передает всегда.
+func (h *WSHandler) SetAllowedOrigins(origins []string) {
+	h.allowedOrigins = make(map[string]bool, len(origins))
+	for _, origin := range origins {
+		h.allowedOrigins[origin] = true
+	}
+}
+
+// AllowAllOrigins отключает проверку Origin. Только для разработки.
+func (h *WSHandler) AllowAllOrigins() {
+	h.allowAllOrigins = true
+	log.Printf("[WSHandler] ВНИМАНИЕ: проверка Origin для WebSocket ОТКЛЮЧЕНА. " +
+		"Любой сайт может подключиться от имени пользователя (cross-site WebSocket hijacking). " +
+		"Не используйте websocket.allowAllOrigins в продакшене!")
+}
+
+// checkOrigin проверяет заголовок Origin запроса на подключение
+func (h *WSHandler) checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" || h.allowAllOrigins {
 		return true
-	},
-	// Добавляем заголовки для CORS
-	EnableCompression: true,
+	}
+	return h.allowedOrigins[origin]
+}
+
+// SetDebugPingAdminOnly ограничивает команду debug:ping администраторами
+func (h *WSHandler) SetDebugPingAdminOnly(adminOnly bool) {
+	h.debugPingAdminOnly = adminOnly
+}
+
+// SetClientBufferSize задает размер буфера исходящих сообщений для новых соединений.
+// Клиент, буфер которого переполнился, отключается, поэтому при всплесках рассылки
+// в начале викторины медленным клиентам нужен запас.
+func (h *WSHandler) SetClientBufferSize(size int) {
+	h.clientBufferSize = size
+}
+
+// SetWritePolicy задает тайм-аут записи и порог медленной записи для новых соединений.
+// Клиент, у которого maxSlowWrites записей подряд заняли больше slowThreshold,
+// отключается как медленный потребитель. Нулевые значения оставляют значения по умолчанию.
+func (h *WSHandler) SetWritePolicy(timeout, slowThreshold time.Duration, maxSlowWrites int) {
+	h.writeTimeout = timeout
+	h.slowWriteThreshold = slowThreshold
+	h.maxSlowWrites = maxSlowWrites
+}
+
+// EnableRefreshExpiryWarnings включает предупреждение REFRESH_TOKEN_EXPIRE_SOON за lead
+// до истечения refresh-токена, с которым установлено соединение (кука refresh_token).
+// Клиенты без куки предупреждение не получают и могут узнать срок через /api/auth/token-info.
+func (h *WSHandler) EnableRefreshExpiryWarnings(provider RefreshTokenInfoProvider, lead time.Duration) {
+	h.refreshTokenInfo = provider
+	h.refreshWarningLead = lead
+}
+
+// SetMaintenance включает проверку режима обслуживания: пока он включен, новые
+// подключения закрываются сразу после установки с кодом 1012 (Service Restart).
+// Уже открытые соединения не затрагиваются.
+func (h *WSHandler) SetMaintenance(provider MaintenanceStateProvider) {
+	h.maintenance = provider
+}
+
+// SetGeoProvider включает определение местоположения подключений по IP-адресу.
+// Страна игрока сохраняется при отметке в викторине и попадает в аналитику участия.
+func (h *WSHandler) SetGeoProvider(provider geoip.Provider) {
+	h.geoProvider = provider
+}
+
+// rejectForMaintenance закрывает новое соединение кодом 1012. Соединение сначала
+// устанавливается: браузерный клиент не видит HTTP-статус неудачного рукопожатия,
+// а код закрытия позволяет ему отличить обслуживание от сетевой ошибки.
+func (h *WSHandler) rejectForMaintenance(c *gin.Context) {
+	conn, err := h.upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		log.Printf("WebSocket: ошибка при отклонении подключения в режиме обслуживания: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	closeMessage := gorillaws.FormatCloseMessage(gorillaws.CloseServiceRestart, maintenanceCloseReason)
+	if err := conn.WriteControl(gorillaws.CloseMessage, closeMessage, time.Now().Add(time.Second)); err != nil {
+		log.Printf("WebSocket: не удалось отправить кадр закрытия в режиме обслуживания: %v", err)
+	}
+}
+
+// EnableBinaryProtocol разрешает клиентам запрашивать компактный бинарный формат
+// частых событий через заголовок Sec-WebSocket-Protocol: trivia.binary.v1.
+// Клиенты без этого заголовка продолжают работать в JSON.
+func (h *WSHandler) EnableBinaryProtocol() {
+	binaryUpgrader := *h.upgrader
+	binaryUpgrader.Subprotocols = []string{websocket.BinaryProtocolName}
+	h.upgrader = &binaryUpgrader
+	h.wsManager.EnableBinaryProtocol()
+}
+
+// newUpgrader создает upgrader, проверяющий Origin по настройкам обработчика
+func (h *WSHandler) newUpgrader() *gorillaws.Upgrader {
+	return &gorillaws.Upgrader{
+		ReadBufferSize:    1024,
+		WriteBufferSize:   1024,
+		CheckOrigin:       h.checkOrigin,
+		EnableCompression: true,
+	}
 }
 
 // HandleConnection обрабатывает входящее WebSocket соединение
 func (h *WSHandler) HandleConnection(c *gin.Context) {
-	// Получаем тикет из запроса (?ticket=...)
-	ticket := c.Query("ticket")
-	log.Printf("WebSocket: received ticket: %s", ticket)
-
-	if ticket == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing ticket"})
+	// Origin проверяется до аутентификации, чтобы чужой сайт не узнал даже результат проверки тикета
+	if !h.checkOrigin(c.Request) {
+		log.Printf("WebSocket: подключение с недопустимого источника %q отклонено", c.GetHeader("Origin"))
+		apierror.Respond(c, http.StatusForbidden, apierror.CodeForbidden, "Origin not allowed")
 		return
 	}
 
-	// Проверяем тикет с использованием специальной функции ParseWSTicket
-	claims, err := h.jwtService.ParseWSTicket(ticket)
-	if err != nil {
-		log.Printf("WebSocket: Invalid or expired ticket - %v", err)
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired ticket"})
+	// В режиме обслуживания новые подключения не принимаются
+	if h.maintenance != nil && h.maintenance.IsEnabled() {
+		log.Printf("WebSocket: подключение отклонено, включен режим обслуживания")
+		h.rejectForMaintenance(c)
 		return
 	}
 
+	// Лимит соединений с IP проверяется до аутентификации: отклоненное
+	// подключение не расходует одноразовый тикет
+	releaseIP, ok := h.acquireIPConnection(c)
+	if !ok {
+		return // Ответ уже отправлен
+	}
+
+	claims, ok := h.authenticateConnection(c)
+	if !ok {
+		releaseIP()
+		return // Ответ уже отправлен
+	}
+
 	// Логируем все заголовки запроса
 	log.Printf("WebSocket: Request headers:")
 	for name, values := range c.Request.Header {
+		// Учетные данные в логи не попадают
+		if name == "Cookie" || name == "Authorization" {
+			log.Printf("  %s: [скрыто]", name)
+			continue
+		}
 		for _, value := range values {
 			log.Printf("  %s: %s", name, value)
 		}
 	}
 
 	// Устанавливаем соединение
-	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	conn, err := h.upgrader.Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
+		releaseIP()
 		log.Printf("Error upgrading connection: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to upgrade: %v", err)})
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, fmt.Sprintf("Failed to upgrade: %v", err))
 		return
 	}
 
 	log.Printf("WebSocket: Connection upgraded for UserID: %d", claims.UserID)
 
 	// Создаем нового клиента
-	client := websocket.NewClient(h.wsHub, conn, fmt.Sprintf("%d", claims.UserID))
+	clientConfig := websocket.DefaultClientConfig()
+	if h.clientBufferSize > 0 {
+		clientConfig.BufferSize = h.clientBufferSize
+	}
+	if h.writeTimeout > 0 {
+		clientConfig.WriteWait = h.writeTimeout
+	}
+	clientConfig.SlowWriteThreshold = h.slowWriteThreshold
+	clientConfig.MaxSlowWrites = h.maxSlowWrites
+	client := websocket.NewClientWithConfig(h.wsHub, conn, fmt.Sprintf("%d", claims.UserID), clientConfig)
+	client.SetLang(i18n.Parse(c.GetHeader(i18n.AcceptLanguageHeader)))
+	// Администратор определяется так же, как в AuthMiddleware: по роли из
+	// токена или тикета либо по ID=1
+	if claims.IsAdmin() {
+		client.AddRole(wsAdminRole)
+	}
+	if h.geoProvider != nil {
+		client.SetLocation(geoip.Locate(h.geoProvider, c.ClientIP()))
+	}
+	if conn.Subprotocol() == websocket.BinaryProtocolName {
+		client.SetBinaryProtocol(true)
+		log.Printf("WebSocket: пользователь %d использует бинарный протокол", claims.UserID)
+	}
+
+	// Время отключения нужно, чтобы не выбивать игрока за опоздание после переподключения
+	userID := claims.UserID
+	stopRefreshWarning := h.scheduleRefreshExpiryWarning(c.Request, userID)
+	client.SetDisconnectHandler(func(disconnected *websocket.Client) {
+		releaseIP()
+		stopRefreshWarning()
+		// Старое соединение закрывается уже после переподключения: игрок не уходил
+		if !h.wsManager.HasNewerConnection(disconnected) {
+			h.quizManager.HandleDisconnect(userID)
+		}
+		if quizID := disconnected.GetQuizID(); quizID != 0 {
+			h.quizManager.LeaveQuizRoom(quizID, disconnected.ConnectionID, service.LeaveReasonDisconnected)
+		}
+	})
+
+	// Запускаем прослушивание сообщений. Незарегистрированное соединение уже
+	// закрыто; обработчик отключения вызывается не во всех таких случаях, поэтому
+	// место в лимите IP освобождается здесь (повторное освобождение не действует)
+	if !client.StartPumps(h.wsManager.HandleMessage) {
+		releaseIP()
+		stopRefreshWarning()
+		return
+	}
+
+	// Критические события, не подтвержденные в прошлых соединениях, отправляются повторно
+	h.wsManager.RedeliverUnacked(client.UserID)
+}
 
-	// Запускаем прослушивание сообщений
-	client.StartPumps(h.wsManager.HandleMessage)
+// scheduleRefreshExpiryWarning планирует предупреждение об истечении refresh-токена
+// соединения и возвращает функцию отмены. Если токен уже истекает в пределах
+// refreshWarningLead, предупреждение отправляется сразу.
+func (h *WSHandler) scheduleRefreshExpiryWarning(r *http.Request, userID uint) (stop func()) {
+	noop := func() {}
+	if h.refreshTokenInfo == nil || h.refreshWarningLead <= 0 {
+		return noop
+	}
+	cookie, err := r.Cookie(manager.RefreshTokenCookie)
+	if err != nil || cookie.Value == "" {
+		return noop
+	}
+	info, err := h.refreshTokenInfo.GetTokenInfo(cookie.Value)
+	if err != nil || !info.RefreshTokenExpires.After(time.Now()) {
+		return noop
+	}
+
+	delay := time.Until(info.RefreshTokenExpires.Add(-h.refreshWarningLead))
+	if delay < 0 {
+		delay = 0
+	}
+	refreshToken := cookie.Value
+	timer := time.AfterFunc(delay, func() {
+		h.sendRefreshExpiryWarning(userID, refreshToken)
+	})
+	return func() { timer.Stop() }
+}
+
+// sendRefreshExpiryWarning отправляет предупреждение, если токен все еще действует:
+// после обновления токенов старый токен недействителен, а новый истекает позже
+func (h *WSHandler) sendRefreshExpiryWarning(userID uint, refreshToken string) {
+	info, err := h.refreshTokenInfo.GetTokenInfo(refreshToken)
+	if err != nil {
+		return
+	}
+	if time.Until(info.RefreshTokenExpires) > h.refreshWarningLead {
+		return
+	}
+	sessionLimited := info.SessionExpiresAt != nil && !info.SessionExpiresAt.After(info.RefreshTokenExpires)
+	h.wsManager.SendRefreshTokenExpirationWarning(strconv.FormatUint(uint64(userID), 10), info.RefreshTokenExpires, sessionLimited)
+}
+
+// authenticateConnection проверяет учетные данные подключения. Тикет (?ticket=...)
+// имеет приоритет; кука access_token используется, только если тикета нет и
+// такой способ включен. При ошибке отправляет ответ и возвращает false.
+func (h *WSHandler) authenticateConnection(c *gin.Context) (*auth.JWTCustomClaims, bool) {
+	if ticket := c.Query("ticket"); ticket != "" {
+		// Проверяем тикет с использованием специальной функции ParseWSTicket
+		claims, err := h.jwtService.ParseWSTicket(ticket)
+		if err != nil {
+			log.Printf("WebSocket: Invalid or expired ticket - %v", err)
+			apierror.Respond(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "Invalid or expired ticket")
+			return nil, false
+		}
+		return claims, true
+	}
+
+	if !h.cookieAuthEnabled {
+		apierror.Respond(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "Missing ticket")
+		return nil, false
+	}
+
+	cookie, err := c.Request.Cookie(manager.AccessTokenCookie)
+	if err != nil || cookie.Value == "" {
+		apierror.Respond(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "Missing ticket or access token")
+		return nil, false
+	}
+
+	// Браузер отправляет куку на любой сайт, поэтому проверяем источник запроса.
+	// Клиенты вне браузера заголовок Origin обычно не передают.
+	if origin := c.GetHeader("Origin"); origin != "" && !h.cookieAuthOrigins[origin] {
+		log.Printf("WebSocket: подключение по куке с недопустимого источника %s отклонено", origin)
+		apierror.Respond(c, http.StatusForbidden, apierror.CodeForbidden, "Origin not allowed")
+		return nil, false
+	}
+
+	// ParseToken выполняет полную проверку, включая инвалидацию токенов пользователя
+	claims, err := h.jwtService.ParseToken(c.Request.Context(), cookie.Value)
+	if errors.Is(err, auth.ErrInvalidationCheckUnavailable) {
+		apierror.Respond(c, http.StatusServiceUnavailable, apierror.CodeServiceUnavailable, "Token verification is temporarily unavailable")
+		return nil, false
+	}
+	if err != nil {
+		log.Printf("WebSocket: Invalid access token cookie - %v", err)
+		apierror.Respond(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "Invalid or expired access token")
+		return nil, false
+	}
+	// WS-тикет в куке не принимаем: для него ParseToken пропускает проверку инвалидации
+	if claims.Usage == "websocket_auth" {
+		apierror.Respond(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "Invalid or expired access token")
+		return nil, false
+	}
+
+	log.Printf("WebSocket: пользователь %d аутентифицирован по куке %s", claims.UserID, manager.AccessTokenCookie)
+	return claims, true
 }
 
 // registerMessageHandlers регистрирует обработчики для различных типов сообщений
@@ -106,16 +435,37 @@ func (h *WSHandler) registerMessageHandlers() {
 	// Обработчик для события готовности пользователя
 	h.wsManager.RegisterHandler("user:ready", func(data json.RawMessage, client *websocket.Client) error {
 		var readyEvent struct {
-			QuizID uint `json:"quiz_id"`
+			QuizID     uint   `json:"quiz_id"`
+			InviteCode string `json:"invite_code"` // Обязателен для приватных викторин
 		}
 		// Ошибка парсинга - фатальна для этого сообщения
 		if err := json.Unmarshal(data, &readyEvent); err != nil {
 			log.Printf("[WSHandler] Ошибка парсинга user:ready: %v, Data: %s", err, string(data))
 			// Отправляем ошибку клиенту перед закрытием
-			h.wsManager.SendErrorToClient(client, "invalid_format", "Failed to parse user:ready event")
+			h.wsManager.SendLocalizedError(client, "invalid_format", i18n.WSInvalidEvent, "user:ready")
 			return fmt.Errorf("failed to parse user:ready event: %w", err)
 		}
 
+		// Получаем UserID клиента
+		userID, err := h.parseUserID(client)
+		if err != nil {
+			return err // Ошибка парсинга ID фатальна
+		}
+
+		// Приватная викторина доступна только по коду приглашения, а викторина с
+		// условиями участия - только прошедшим проверки: иначе клиент не
+		// подписывается на события викторины и не становится участником
+		if err := h.quizManager.CheckJoinAccess(readyEvent.QuizID, userID, readyEvent.InviteCode); err != nil {
+			log.Printf("[WSHandler] User %s не допущен к викторине %d: %v", client.UserID, readyEvent.QuizID, err)
+			h.sendJoinError(client, err)
+			return nil
+		}
+
+		// Переход в другую викторину - выход из комнаты предыдущей
+		if previousQuizID := client.GetQuizID(); previousQuizID != 0 && previousQuizID != readyEvent.QuizID {
+			h.quizManager.LeaveQuizRoom(previousQuizID, client.ConnectionID, service.LeaveReasonLeft)
+		}
+
 		// Устанавливаем QuizID у клиента
 		client.SetQuizID(readyEvent.QuizID)
 		log.Printf("[WSHandler] User %s set QuizID to %d", client.UserID, readyEvent.QuizID)
@@ -125,23 +475,25 @@ func (h *WSHandler) registerMessageHandlers() {
 			// Логируем ошибку подписки, но не обязательно закрывать соединение
 			log.Printf("[WSHandler] Ошибка при подписке User %s на Quiz %d: %v", client.UserID, readyEvent.QuizID, err)
 			// Можно отправить ошибку клиенту
-			h.wsManager.SendErrorToClient(client, "subscribe_error", fmt.Sprintf("Failed to subscribe to quiz %d", readyEvent.QuizID))
+			h.wsManager.SendLocalizedError(client, "subscribe_error", i18n.WSSubscribeFailed, readyEvent.QuizID)
 			// return err // Не возвращаем ошибку, чтобы не закрывать соединение сразу
 		}
 		// ===>>> КОНЕЦ ИЗМЕНЕНИЯ <<<===
 
-		// Получаем UserID клиента
-		userID, err := h.parseUserID(client)
-		if err != nil {
-			return err // Ошибка парсинга ID фатальна
-		}
-
 		// Вызываем QuizManager, логируем ошибку, но не закрываем соединение
 		if err := h.quizManager.HandleReadyEvent(userID, readyEvent.QuizID); err != nil {
 			log.Printf("[WSHandler] Ошибка при обработке HandleReadyEvent для пользователя %d, викторины %d: %v", userID, readyEvent.QuizID, err)
 			// Опционально: отправить ошибку клиенту
-			h.wsManager.SendErrorToClient(client, "ready_error", err.Error())
+			h.sendJoinError(client, err)
+			// Не присоединившийся игрок (исключен, нет мест, нужно присоединение,
+			// викторина уже идет) не должен получать события викторины
+			if unsubErr := h.wsManager.UnsubscribeClientFromQuiz(client); unsubErr != nil {
+				log.Printf("[WSHandler] Ошибка при отписке User %s от Quiz %d: %v", client.UserID, readyEvent.QuizID, unsubErr)
+			}
+			return nil
 		}
+		h.quizManager.RecordParticipantCountry(userID, readyEvent.QuizID, client.Location().Country)
+		h.quizManager.EnterQuizRoom(userID, readyEvent.QuizID, client.ConnectionID)
 		return nil // Возвращаем nil, чтобы не закрывать соединение
 	})
 
@@ -150,12 +502,13 @@ func (h *WSHandler) registerMessageHandlers() {
 		var answerEvent struct {
 			QuestionID     uint  `json:"question_id"`
 			SelectedOption int   `json:"selected_option"`
+			Order          []int `json:"order"` // Для вопросов ordering: номера вариантов в выбранном порядке
 			Timestamp      int64 `json:"timestamp"`
 		}
 		// Ошибка парсинга - фатальна
 		if err := json.Unmarshal(data, &answerEvent); err != nil {
 			log.Printf("[WSHandler] Ошибка парсинга user:answer: %v, Data: %s", err, string(data))
-			h.wsManager.SendErrorToClient(client, "invalid_format", "Failed to parse user:answer event")
+			h.wsManager.SendLocalizedError(client, "invalid_format", i18n.WSInvalidEvent, "user:answer")
 			return err
 		}
 
@@ -166,24 +519,95 @@ func (h *WSHandler) registerMessageHandlers() {
 		}
 
 		// Вызываем QuizManager, логируем ошибку, но не закрываем соединение
-		if err := h.quizManager.ProcessAnswer(
-			userID,
-			answerEvent.QuestionID,
-			answerEvent.SelectedOption,
-			answerEvent.Timestamp,
-		); err != nil {
+		if len(answerEvent.Order) > 0 {
+			err = h.quizManager.ProcessOrderingAnswer(userID, answerEvent.QuestionID, answerEvent.Order, answerEvent.Timestamp)
+		} else {
+			err = h.quizManager.ProcessAnswer(
+				userID,
+				answerEvent.QuestionID,
+				answerEvent.SelectedOption,
+				answerEvent.Timestamp,
+			)
+		}
+		if err != nil {
 			log.Printf("[WSHandler] Ошибка при обработке ProcessAnswer для пользователя %d, вопроса %d: %v", userID, answerEvent.QuestionID, err)
 			// Отправляем специфичную ошибку клиенту
-			h.wsManager.SendErrorToClient(client, "answer_error", err.Error())
+			h.sendAnswerError(client, err)
 		}
 		return nil // Возвращаем nil, чтобы не закрывать соединение
 	})
 
+	// Подтверждение получения вопроса: в викторинах с answer_window_from_ack
+	// время на ответ игрока отсчитывается от него
+	h.wsManager.RegisterHandler("quiz:question_received", func(data json.RawMessage, client *websocket.Client) error {
+		var receivedEvent struct {
+			QuestionID uint `json:"question_id"`
+		}
+		if err := json.Unmarshal(data, &receivedEvent); err != nil {
+			log.Printf("[WSHandler] Ошибка парсинга quiz:question_received: %v, Data: %s", err, string(data))
+			h.wsManager.SendLocalizedError(client, "invalid_format", i18n.WSInvalidEvent, "quiz:question_received")
+			return err
+		}
+
+		userID, err := h.parseUserID(client)
+		if err != nil {
+			return err
+		}
+
+		if err := h.quizManager.AcknowledgeQuestion(userID, receivedEvent.QuestionID); err != nil {
+			log.Printf("[WSHandler] Подтверждение вопроса %d пользователем %d не учтено: %v", receivedEvent.QuestionID, userID, err)
+			h.sendAnswerError(client, err)
+		}
+		return nil
+	})
+
+	// Запрос полной таблицы лидеров: клиент, пропустивший версию
+	// quiz:leaderboard_delta, заново получает quiz:leaderboard
+	h.wsManager.RegisterHandler("quiz:leaderboard_request", func(data json.RawMessage, client *websocket.Client) error {
+		var requestEvent struct {
+			QuizID uint `json:"quiz_id"`
+		}
+		if err := json.Unmarshal(data, &requestEvent); err != nil {
+			log.Printf("[WSHandler] Ошибка парсинга quiz:leaderboard_request: %v, Data: %s", err, string(data))
+			h.wsManager.SendLocalizedError(client, "invalid_format", i18n.WSInvalidEvent, "quiz:leaderboard_request")
+			return err
+		}
+
+		userID, err := h.parseUserID(client)
+		if err != nil {
+			return err
+		}
+
+		if err := h.quizManager.RequestLeaderboard(userID, requestEvent.QuizID); err != nil {
+			h.sendAnswerError(client, err)
+		}
+		return nil
+	})
+
+	// Ответ на connection:takeover_requested: отдать ли место соединению из другой вкладки
+	h.wsManager.RegisterHandler(websocket.TakeoverResponseEvent, func(data json.RawMessage, client *websocket.Client) error {
+		var responseEvent struct {
+			Accept bool `json:"accept"`
+		}
+		if err := json.Unmarshal(data, &responseEvent); err != nil {
+			log.Printf("[WSHandler] Ошибка парсинга %s: %v, Data: %s", websocket.TakeoverResponseEvent, err, string(data))
+			h.wsManager.SendLocalizedError(client, "invalid_format", i18n.WSInvalidEvent, websocket.TakeoverResponseEvent)
+			return err
+		}
+
+		if err := h.wsManager.ResolveTakeover(client, responseEvent.Accept); err != nil {
+			h.wsManager.SendLocalizedError(client, "no_pending_takeover", i18n.WSNoPendingTakeover)
+		}
+		return nil
+	})
+
 	// Обработчик для проверки соединения
 	h.wsManager.RegisterHandler("user:heartbeat", func(data json.RawMessage, client *websocket.Client) error {
 		// Отправляем ответ клиенту
+		// connection_id нужен клиенту, чтобы исключать свое соединение из уведомлений (logout-all)
 		heartbeatResponse := map[string]interface{}{
-			"timestamp": time.Now().UnixNano() / int64(time.Millisecond),
+			"timestamp":     time.Now().UnixNano() / int64(time.Millisecond),
+			"connection_id": client.ConnectionID,
 		}
 		// Ошибка отправки здесь может быть проигнорирована или залогирована
 		if err := h.wsManager.SendEventToUser(client.UserID, "server:heartbeat", heartbeatResponse); err != nil {
@@ -191,16 +615,163 @@ func (h *WSHandler) registerMessageHandlers() {
 		}
 		return nil // Никогда не закрываем соединение из-за heartbeat
 	})
+
+	// Диагностика соединения для поддержки игроков: администратор получает
+	// подробные сведения (шард, роли, буфер), остальные - только данные о соединении
+	h.wsManager.RegisterHandler("debug:ping", func(data json.RawMessage, client *websocket.Client) error {
+		isAdmin := client.HasRole(wsAdminRole)
+		if h.debugPingAdminOnly && !isAdmin {
+			h.wsManager.SendLocalizedError(client, "forbidden", i18n.WSDebugPingAdminOnly)
+			return nil
+		}
+
+		diagnostics := h.wsManager.ConnectionDiagnostics(client, isAdmin)
+		if err := h.wsManager.SendEventToUser(client.UserID, "debug:pong", diagnostics); err != nil {
+			log.Printf("[WSHandler] WARNING: Ошибка при отправке debug:pong пользователю %s: %v", client.UserID, err)
+		}
+		return nil
+	})
+
+	// Обработчик запроса текущих подписок клиента
+	h.wsManager.RegisterHandler("subscriptions:list", func(data json.RawMessage, client *websocket.Client) error {
+		h.sendSubscriptions(client)
+		return nil
+	})
+
+	// Обработчик замены набора подписок одним сообщением (например, после переподключения)
+	h.wsManager.RegisterHandler("subscriptions:set", func(data json.RawMessage, client *websocket.Client) error {
+		var setEvent struct {
+			Types []string `json:"types"`
+		}
+		if err := json.Unmarshal(data, &setEvent); err != nil {
+			log.Printf("[WSHandler] Ошибка парсинга subscriptions:set: %v, Data: %s", err, string(data))
+			h.wsManager.SendLocalizedError(client, "invalid_format", i18n.WSInvalidEvent, "subscriptions:set")
+			return nil
+		}
+
+		// Набор применяется целиком или не применяется вовсе
+		var unknown []string
+		for _, messageType := range setEvent.Types {
+			if !websocket.IsSubscribableMessageType(messageType) {
+				unknown = append(unknown, messageType)
+			}
+		}
+		if len(unknown) > 0 {
+			h.wsManager.SendLocalizedError(client, "invalid_subscription", i18n.WSUnknownSubscriptions, strings.Join(unknown, ", "))
+			return nil
+		}
+
+		client.SetSubscriptions(setEvent.Types)
+		h.sendSubscriptions(client)
+		return nil
+	})
+}
+
+// sendSubscriptions отправляет клиенту его текущие подписки и список доступных типов
+func (h *WSHandler) sendSubscriptions(client *websocket.Client) {
+	subscriptions := client.GetSubscriptions()
+	if subscriptions == nil {
+		subscriptions = []string{}
+	}
+	response := map[string]interface{}{
+		"subscriptions": subscriptions,
+		"available":     websocket.SubscribableMessageTypes(),
+	}
+	if err := h.wsManager.SendEventToUser(client.UserID, "server:subscriptions", response); err != nil {
+		log.Printf("[WSHandler] WARNING: Ошибка при отправке server:subscriptions пользователю %s: %v", client.UserID, err)
+	}
 }
 
 // --- Вспомогательные методы ---
 
+// sendJoinError сообщает клиенту, почему он не допущен к викторине, на его языке.
+// Причина отказа по условиям участия и прочие ошибки передаются как есть.
+func (h *WSHandler) sendJoinError(client *websocket.Client, err error) {
+	code := joinAccessErrorCode(err)
+	switch code {
+	case "invite_code_required":
+		h.wsManager.SendLocalizedError(client, code, i18n.WSInviteCodeRequired)
+	case "invalid_invite_code":
+		h.wsManager.SendLocalizedError(client, code, i18n.WSInvalidInviteCode)
+	case "quiz_not_found":
+		h.wsManager.SendLocalizedError(client, code, i18n.WSQuizNotFound)
+	case "quiz_already_started":
+		h.wsManager.SendLocalizedError(client, code, i18n.WSQuizAlreadyStarted)
+	case "quiz_finished":
+		h.wsManager.SendLocalizedError(client, code, i18n.WSQuizFinished)
+	case "removed_from_quiz":
+		h.wsManager.SendLocalizedError(client, code, i18n.WSRemovedFromQuiz)
+	case "not_eligible":
+		reason := err.Error()
+		if i := strings.Index(reason, service.ErrNotEligible.Error()+": "); i >= 0 {
+			reason = reason[i+len(service.ErrNotEligible.Error())+2:]
+		}
+		h.wsManager.SendLocalizedError(client, code, i18n.WSNotEligible, reason)
+	case "join_required":
+		h.wsManager.SendLocalizedError(client, code, i18n.WSJoinRequired)
+	case "quiz_full":
+		h.wsManager.SendLocalizedError(client, code, i18n.WSQuizFull)
+	default:
+		h.wsManager.SendLocalizedError(client, code, i18n.WSJoinFailed, err.Error())
+	}
+}
+
+// sendAnswerError сообщает клиенту, что его ответ или подтверждение не принято
+func (h *WSHandler) sendAnswerError(client *websocket.Client, err error) {
+	code := answerErrorCode(err)
+	switch code {
+	case "quiz_not_active":
+		h.wsManager.SendLocalizedError(client, code, i18n.WSQuizNotActive)
+	case "question_read_phase":
+		h.wsManager.SendLocalizedError(client, code, i18n.WSQuestionReadPhase)
+	default:
+		h.wsManager.SendLocalizedError(client, code, i18n.WSAnswerRejected, err.Error())
+	}
+}
+
+// joinAccessErrorCode возвращает код ошибки WebSocket для отказа в присоединении к викторине
+func joinAccessErrorCode(err error) string {
+	switch {
+	case errors.Is(err, service.ErrInviteCodeRequired):
+		return "invite_code_required"
+	case errors.Is(err, service.ErrInvalidInviteCode):
+		return "invalid_invite_code"
+	case errors.Is(err, service.ErrQuizNotFound):
+		return "quiz_not_found"
+	case errors.Is(err, service.ErrQuizAlreadyStarted):
+		return "quiz_already_started"
+	case errors.Is(err, service.ErrQuizFinished):
+		return "quiz_finished"
+	case errors.Is(err, service.ErrRemovedFromQuiz):
+		return "removed_from_quiz"
+	case errors.Is(err, service.ErrNotEligible):
+		return "not_eligible"
+	case errors.Is(err, service.ErrJoinRequired):
+		return "join_required"
+	case errors.Is(err, service.ErrQuizFull):
+		return "quiz_full"
+	default:
+		return "ready_error"
+	}
+}
+
+// answerErrorCode возвращает код ошибки для клиента, ответ которого не принят
+func answerErrorCode(err error) string {
+	if errors.Is(err, service.ErrQuizNotActive) {
+		return "quiz_not_active"
+	}
+	if errors.Is(err, service.ErrQuestionReadPhase) {
+		return "question_read_phase"
+	}
+	return "answer_error"
+}
+
 // parseUserID извлекает и парсит UserID из клиента
 func (h *WSHandler) parseUserID(client *websocket.Client) (uint, error) {
 	userIDUint64, err := strconv.ParseUint(client.UserID, 10, 32)
 	if err != nil {
 		log.Printf("[WSHandler] CRITICAL: Ошибка конвертации UserID '%s' в uint: %v", client.UserID, err)
-		h.wsManager.SendErrorToClient(client, "internal_error", "Invalid user ID format")
+		h.wsManager.SendLocalizedError(client, "internal_error", i18n.WSInvalidUserID)
 		return 0, fmt.Errorf("failed to parse user ID: %w", err) // Фатальная ошибка
 	}
 	return uint(userIDUint64), nil
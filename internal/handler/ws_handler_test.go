@@ -0,0 +1,478 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	gorillaws "github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourusername/trivia-api/internal/config"
+	"github.com/yourusername/trivia-api/internal/domain/entity"
+	"github.com/yourusername/trivia-api/internal/domain/repository"
+	"github.com/yourusername/trivia-api/internal/service"
+	"github.com/yourusername/trivia-api/internal/websocket"
+	"github.com/yourusername/trivia-api/pkg/auth"
+	"github.com/yourusername/trivia-api/pkg/auth/manager"
+)
+
+// memoryInvalidTokenRepo - репозиторий инвалидаций без БД
+type memoryInvalidTokenRepo struct {
+	repository.InvalidTokenRepository
+}
+
+func (r *memoryInvalidTokenRepo) AddInvalidToken(ctx context.Context, userID uint, invalidationTime time.Time) error {
+	return nil
+}
+
+func (r *memoryInvalidTokenRepo) IsTokenInvalid(ctx context.Context, userID uint, tokenIssuedAt time.Time) (bool, error) {
+	return false, nil
+}
+
+func (r *memoryInvalidTokenRepo) GetAllInvalidTokens(ctx context.Context) ([]entity.InvalidToken, error) {
+	return nil, nil
+}
+
+func (r *memoryInvalidTokenRepo) CleanupOldInvalidTokens(ctx context.Context, cutoffTime time.Time) error {
+	return nil
+}
+
+const testOrigin = "http://localhost:5173"
+
+func newTestWSHandler(cookieAuth bool) (*WSHandler, *auth.JWTService) {
+	jwtService := auth.NewJWTService("test-secret", 1, &memoryInvalidTokenRepo{}, 60, time.Hour)
+	h := &WSHandler{jwtService: jwtService}
+	if cookieAuth {
+		h.EnableAccessTokenCookieAuth([]string{testOrigin})
+	}
+	return h, jwtService
+}
+
+// authenticate вызывает authenticateConnection для запроса с указанными учетными данными
+func authenticate(h *WSHandler, ticket, cookie, origin string) (*auth.JWTCustomClaims, int) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+
+	url := "/ws"
+	if ticket != "" {
+		url += "?ticket=" + ticket
+	}
+	c.Request = httptest.NewRequest(http.MethodGet, url, nil)
+	if cookie != "" {
+		c.Request.AddCookie(&http.Cookie{Name: manager.AccessTokenCookie, Value: cookie})
+	}
+	if origin != "" {
+		c.Request.Header.Set("Origin", origin)
+	}
+
+	claims, ok := h.authenticateConnection(c)
+	if !ok {
+		return nil, w.Code
+	}
+	return claims, http.StatusOK
+}
+
+func TestAuthenticateConnection_CookieDisabledByDefault(t *testing.T) {
+	h, jwtService := newTestWSHandler(false)
+	token, err := jwtService.GenerateToken(&entity.User{ID: 7, Email: "p@example.com"})
+	require.NoError(t, err)
+
+	_, code := authenticate(h, "", token, "")
+	assert.Equal(t, http.StatusUnauthorized, code)
+}
+
+func TestAuthenticateConnection_PrefersTicket(t *testing.T) {
+	h, jwtService := newTestWSHandler(true)
+	ticket, err := jwtService.GenerateWSTicket(7, "p@example.com", entity.RolePlayer)
+	require.NoError(t, err)
+
+	// Невалидная кука не мешает подключению по тикету
+	claims, code := authenticate(h, ticket, "garbage", "")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, uint(7), claims.UserID)
+}
+
+func TestAuthenticateConnection_AccessTokenCookie(t *testing.T) {
+	h, jwtService := newTestWSHandler(true)
+	token, err := jwtService.GenerateToken(&entity.User{ID: 7, Email: "p@example.com"})
+	require.NoError(t, err)
+
+	claims, code := authenticate(h, "", token, testOrigin)
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, uint(7), claims.UserID)
+
+	_, code = authenticate(h, "", token, "https://evil.example.com")
+	assert.Equal(t, http.StatusForbidden, code)
+
+	// Для куки действует полная проверка инвалидации
+	require.NoError(t, jwtService.InvalidateTokensForUser(context.Background(), 7))
+	_, code = authenticate(h, "", token, testOrigin)
+	assert.Equal(t, http.StatusUnauthorized, code)
+}
+
+func TestAuthenticateConnection_RejectsTicketInCookie(t *testing.T) {
+	h, jwtService := newTestWSHandler(true)
+	ticket, err := jwtService.GenerateWSTicket(7, "p@example.com", entity.RolePlayer)
+	require.NoError(t, err)
+
+	_, code := authenticate(h, "", ticket, "")
+	assert.Equal(t, http.StatusUnauthorized, code)
+}
+
+func TestHandleConnection_RejectsDisallowedOrigin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h, jwtService := newTestWSHandler(false)
+	h.upgrader = h.newUpgrader()
+	h.SetAllowedOrigins([]string{testOrigin})
+
+	ticket, err := jwtService.GenerateWSTicket(7, "p@example.com", entity.RolePlayer)
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/ws?ticket="+ticket, nil)
+	c.Request.Header.Set("Origin", "https://evil.example.com")
+
+	h.HandleConnection(c)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+// maintenanceSwitch - режим обслуживания для тестов
+type maintenanceSwitch bool
+
+func (m maintenanceSwitch) IsEnabled() bool { return bool(m) }
+
+func TestHandleConnection_RejectsDuringMaintenance(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h, _ := newTestWSHandler(false)
+	h.upgrader = h.newUpgrader()
+	h.AllowAllOrigins()
+	h.SetMaintenance(maintenanceSwitch(true))
+
+	router := gin.New()
+	router.GET("/ws", h.HandleConnection)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	// Тикет не нужен: в режиме обслуживания соединение закрывается до аутентификации
+	conn, _, err := gorillaws.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws", nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, _, err = conn.ReadMessage()
+	var closeErr *gorillaws.CloseError
+	require.ErrorAs(t, err, &closeErr)
+	assert.Equal(t, gorillaws.CloseServiceRestart, closeErr.Code)
+	assert.Equal(t, maintenanceCloseReason, closeErr.Text)
+}
+
+// ipTestContext создает контекст запроса на подключение с адреса ip
+func ipTestContext(ip, ticket string) (*gin.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/ws?ticket="+ticket, nil)
+	c.Request.RemoteAddr = ip + ":40000"
+	return c, w
+}
+
+func TestHandleConnection_RejectsOverIPLimit(t *testing.T) {
+	h, jwtService := newTestWSHandler(false)
+	h.upgrader = h.newUpgrader()
+	h.AllowAllOrigins()
+	require.NoError(t, h.SetIPConnectionLimit(websocket.NewLocalIPConnectionCounter(), 2, nil))
+
+	var releases []func()
+	for i := 0; i < 2; i++ {
+		c, _ := ipTestContext("203.0.113.7", "")
+		release, ok := h.acquireIPConnection(c)
+		require.True(t, ok)
+		releases = append(releases, release)
+	}
+
+	// Третье подключение с того же IP отклоняется до установки соединения
+	ticket, err := jwtService.GenerateWSTicket(7, "p@example.com", entity.RolePlayer)
+	require.NoError(t, err)
+	c, w := ipTestContext("203.0.113.7", ticket)
+	h.HandleConnection(c)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "rate_limited", decodeBody(t, w)["code"])
+	_, code := authenticate(h, ticket, "", "")
+	assert.Equal(t, http.StatusOK, code, "отклоненное по лимиту подключение не расходует тикет")
+
+	// Другие IP лимит не затрагивает
+	c, _ = ipTestContext("203.0.113.8", "")
+	_, ok := h.acquireIPConnection(c)
+	assert.True(t, ok)
+
+	stats := h.IPConnectionStats()
+	assert.Equal(t, int64(1), stats["rejected_total"])
+	assert.Equal(t, 2, stats["ips"])
+
+	// После закрытия соединения место освобождается; повторное снятие учета не действует
+	releases[0]()
+	releases[0]()
+	c, _ = ipTestContext("203.0.113.7", "")
+	_, ok = h.acquireIPConnection(c)
+	assert.True(t, ok)
+	c, _ = ipTestContext("203.0.113.7", "")
+	_, ok = h.acquireIPConnection(c)
+	assert.False(t, ok)
+}
+
+func TestAcquireIPConnection_AllowList(t *testing.T) {
+	h, _ := newTestWSHandler(false)
+	counter := websocket.NewLocalIPConnectionCounter()
+	require.NoError(t, h.SetIPConnectionLimit(counter, 1, []string{"198.51.100.0/24", "2001:db8::1"}))
+
+	for _, ip := range []string{"198.51.100.10", "198.51.100.10", "2001:db8::1", "2001:db8::1"} {
+		c, _ := ipTestContext(ip, "")
+		if ip == "2001:db8::1" {
+			c.Request.RemoteAddr = "[" + ip + "]:40000"
+		}
+		_, ok := h.acquireIPConnection(c)
+		assert.True(t, ok, ip)
+	}
+	counts, err := counter.Counts()
+	require.NoError(t, err)
+	assert.Empty(t, counts, "подключения из списка исключений не учитываются")
+
+	assert.Error(t, h.SetIPConnectionLimit(counter, 1, []string{"bots.local"}))
+}
+
+func TestCheckOrigin(t *testing.T) {
+	h, _ := newTestWSHandler(false)
+	h.SetAllowedOrigins([]string{testOrigin})
+
+	request := func(origin string) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "/ws", nil)
+		if origin != "" {
+			r.Header.Set("Origin", origin)
+		}
+		return r
+	}
+
+	assert.True(t, h.checkOrigin(request(testOrigin)))
+	assert.True(t, h.checkOrigin(request("")), "non-browser clients send no Origin")
+	assert.False(t, h.checkOrigin(request("https://evil.example.com")))
+
+	h.AllowAllOrigins()
+	assert.True(t, h.checkOrigin(request("https://evil.example.com")))
+}
+
+// refreshInfoStub возвращает заданные сроки refresh-токена
+type refreshInfoStub struct {
+	mu    sync.Mutex
+	infos map[string]*manager.TokenInfo
+}
+
+func (s *refreshInfoStub) GetTokenInfo(refreshToken string) (*manager.TokenInfo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	info, ok := s.infos[refreshToken]
+	if !ok {
+		return nil, manager.NewTokenError(manager.InvalidRefreshToken, "Недействительный refresh-токен", nil)
+	}
+	return info, nil
+}
+
+func (s *refreshInfoStub) revoke(refreshToken string) {
+	s.mu.Lock()
+	delete(s.infos, refreshToken)
+	s.mu.Unlock()
+}
+
+// userMessageHub запоминает сообщения, отправленные пользователям
+type userMessageHub struct {
+	websocket.HubInterface
+	mu       sync.Mutex
+	messages []string
+}
+
+func (h *userMessageHub) SendToUser(userID string, message []byte) bool {
+	h.mu.Lock()
+	h.messages = append(h.messages, userID+":"+string(message))
+	h.mu.Unlock()
+	return true
+}
+
+func (h *userMessageHub) Messages() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]string(nil), h.messages...)
+}
+
+func newRefreshWarningHandler(lead time.Duration, infos map[string]*manager.TokenInfo) (*WSHandler, *userMessageHub, *refreshInfoStub) {
+	hub := &userMessageHub{}
+	provider := &refreshInfoStub{infos: infos}
+	h := &WSHandler{wsManager: websocket.NewManager(hub)}
+	h.EnableRefreshExpiryWarnings(provider, lead)
+	return h, hub, provider
+}
+
+func refreshCookieRequest(token string) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "/ws", nil)
+	if token != "" {
+		r.AddCookie(&http.Cookie{Name: manager.RefreshTokenCookie, Value: token})
+	}
+	return r
+}
+
+func TestRefreshExpiryWarning_SentWhenWithinLead(t *testing.T) {
+	sessionEnd := time.Now().Add(36 * time.Hour)
+	h, hub, _ := newRefreshWarningHandler(48*time.Hour, map[string]*manager.TokenInfo{
+		"rt": {RefreshTokenExpires: sessionEnd, SessionExpiresAt: &sessionEnd},
+	})
+
+	stop := h.scheduleRefreshExpiryWarning(refreshCookieRequest("rt"), 7)
+	defer stop()
+
+	require.Eventually(t, func() bool { return len(hub.Messages()) == 1 }, time.Second, 5*time.Millisecond)
+	var msg struct {
+		Type string `json:"type"`
+		Data struct {
+			ExpiresIn int    `json:"expires_in"`
+			Reason    string `json:"reason"`
+		} `json:"data"`
+	}
+	raw := strings.TrimPrefix(hub.Messages()[0], "7:")
+	require.NoError(t, json.Unmarshal([]byte(raw), &msg))
+	assert.Equal(t, websocket.REFRESH_TOKEN_EXPIRE_SOON, msg.Type)
+	assert.InDelta(t, (36 * time.Hour).Seconds(), msg.Data.ExpiresIn, 5)
+	assert.Equal(t, "session_limit", msg.Data.Reason)
+}
+
+func TestRefreshExpiryWarning_ScheduledAndCancelled(t *testing.T) {
+	h, hub, _ := newRefreshWarningHandler(time.Hour, map[string]*manager.TokenInfo{
+		"rt": {RefreshTokenExpires: time.Now().Add(time.Hour + 50*time.Millisecond)},
+	})
+
+	// Отключение до наступления срока предупреждения отменяет его
+	stop := h.scheduleRefreshExpiryWarning(refreshCookieRequest("rt"), 7)
+	stop()
+	time.Sleep(100 * time.Millisecond)
+	assert.Empty(t, hub.Messages())
+
+	stop = h.scheduleRefreshExpiryWarning(refreshCookieRequest("rt"), 7)
+	defer stop()
+	require.Eventually(t, func() bool { return len(hub.Messages()) == 1 }, time.Second, 5*time.Millisecond)
+	assert.Contains(t, hub.Messages()[0], `"reason":"inactivity"`)
+}
+
+func TestRefreshExpiryWarning_SkippedAfterRotationOrWithoutCookie(t *testing.T) {
+	h, hub, provider := newRefreshWarningHandler(time.Hour, map[string]*manager.TokenInfo{
+		"rt": {RefreshTokenExpires: time.Now().Add(time.Hour + 50*time.Millisecond)},
+	})
+
+	stop := h.scheduleRefreshExpiryWarning(refreshCookieRequest("rt"), 7)
+	defer stop()
+	// Клиент обновил токены: старый токен больше недействителен
+	provider.revoke("rt")
+
+	h.scheduleRefreshExpiryWarning(refreshCookieRequest(""), 8)
+	h.scheduleRefreshExpiryWarning(refreshCookieRequest("unknown"), 9)
+
+	time.Sleep(150 * time.Millisecond)
+	assert.Empty(t, hub.Messages())
+}
+
+// readyCache - кэш в памяти для user:ready
+type readyCache struct {
+	repository.CacheRepository
+	mu   sync.Mutex
+	data map[string]string
+}
+
+func (c *readyCache) Set(key string, value interface{}, _ time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.data[key] = fmt.Sprint(value)
+	return nil
+}
+
+func (c *readyCache) Get(key string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.data[key], nil
+}
+
+func (c *readyCache) Exists(key string) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, ok := c.data[key]
+	return ok, nil
+}
+
+func (c *readyCache) Delete(key string) error {
+	_, err := c.DeleteIfExists(key)
+	return err
+}
+
+func (c *readyCache) DeleteIfExists(key string) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, ok := c.data[key]
+	delete(c.data, key)
+	return ok, nil
+}
+
+func (c *readyCache) SetNX(key string, value interface{}, _ time.Duration) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.data[key]; ok {
+		return false, nil
+	}
+	c.data[key] = fmt.Sprint(value)
+	return true, nil
+}
+
+func (c *readyCache) Increment(key string) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n, _ := strconv.ParseInt(c.data[key], 10, 64)
+	n++
+	c.data[key] = strconv.FormatInt(n, 10)
+	return n, nil
+}
+
+func (c *readyCache) ExpireAt(string, time.Time) error { return nil }
+
+// TestUserReady_FullQuizNotSubscribed: игрок, не получивший места в заполненной
+// викторине, не подписывается на ее события
+func TestUserReady_FullQuizNotSubscribed(t *testing.T) {
+	hub := websocket.NewShardedHub(config.WebSocketConfig{Sharding: config.ShardingConfig{ShardCount: 1}}, nil)
+	defer hub.Close()
+	wsManager := websocket.NewManager(hub)
+	repo := &statusQuizRepo{quizzes: map[uint]*entity.Quiz{
+		1: {ID: 1, Status: entity.QuizStatusScheduled, MaxPlayers: 1, ScheduledTime: time.Now().Add(time.Hour)},
+	}}
+	quizManager := service.NewQuizManager(repo, nil, nil, nil, &readyCache{data: make(map[string]string)}, wsManager, nil)
+	defer quizManager.Shutdown()
+	NewWSHandler(hub, wsManager, quizManager, nil)
+
+	ready := []byte(`{"type":"user:ready","data":{"quiz_id":1}}`)
+	first := websocket.NewClient(hub, nil, "7")
+	second := websocket.NewClient(hub, nil, "8")
+	for _, client := range []*websocket.Client{first, second} {
+		registered := make(chan struct{}, 1)
+		hub.RegisterSync(client, registered)
+		<-registered
+	}
+	require.NoError(t, wsManager.HandleMessage(ready, first))
+	require.NoError(t, wsManager.HandleMessage(ready, second))
+
+	assert.Equal(t, uint(1), first.GetQuizID())
+	assert.Zero(t, second.GetQuizID(), "игрок без места не привязан к викторине")
+	assert.True(t, hub.UnsubscribeUserFromQuiz("7", 1))
+	assert.False(t, hub.UnsubscribeUserFromQuiz("8", 1), "игрок без места не подписан на события викторины")
+}
@@ -0,0 +1,169 @@
+package handler
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/trivia-api/internal/handler/apierror"
+	"github.com/yourusername/trivia-api/internal/websocket"
+)
+
+// maxReportedIPs - сколько IP-адресов с наибольшим числом соединений попадает в диагностику
+const maxReportedIPs = 100
+
+// ipConnectionLimit ограничивает число одновременных WebSocket-соединений с одного IP
+type ipConnectionLimit struct {
+	counter websocket.IPConnectionCounter
+	limit   int
+	// Адреса и подсети, на которые лимит не распространяется (например, нагрузочные боты)
+	allowList []*net.IPNet
+
+	rejected      atomic.Int64
+	counterErrors atomic.Int64
+}
+
+// SetIPConnectionLimit ограничивает число одновременных соединений с одного IP-адреса.
+// Подключение сверх лимита отклоняется до установки соединения ответом 429.
+// counter определяет область подсчета: экземпляр или весь кластер (Redis).
+// allowList - адреса и подсети (CIDR), на которые лимит не распространяется.
+// limit 0 выключает ограничение.
+func (h *WSHandler) SetIPConnectionLimit(counter websocket.IPConnectionCounter, limit int, allowList []string) error {
+	if limit <= 0 {
+		h.ipLimit = nil
+		return nil
+	}
+	ipLimit := &ipConnectionLimit{counter: counter, limit: limit}
+	for _, entry := range allowList {
+		network, err := parseIPOrCIDR(entry)
+		if err != nil {
+			return err
+		}
+		ipLimit.allowList = append(ipLimit.allowList, network)
+	}
+	h.ipLimit = ipLimit
+	log.Printf("[WSHandler] Лимит WebSocket-соединений с одного IP: %d (исключения: %v)", limit, allowList)
+	return nil
+}
+
+// parseIPOrCIDR разбирает IP-адрес или подсеть в формате CIDR
+func parseIPOrCIDR(entry string) (*net.IPNet, error) {
+	if !strings.Contains(entry, "/") {
+		ip := net.ParseIP(entry)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid IP address %q", entry)
+		}
+		bits := 8 * net.IPv4len
+		if ip.To4() == nil {
+			bits = 8 * net.IPv6len
+		}
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+	}
+	_, network, err := net.ParseCIDR(entry)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CIDR %q", entry)
+	}
+	return network, nil
+}
+
+// allowed сообщает, входит ли ip в список исключений
+func (l *ipConnectionLimit) allowed(ip string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, network := range l.allowList {
+		if network.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// acquireIPConnection учитывает новое соединение с IP клиента. Возвращает функцию
+// снятия учета (вызывается при закрытии соединения) или false, если лимит
+// достигнут и ответ уже отправлен. При недоступности счетчика соединение
+// принимается без учета.
+func (h *WSHandler) acquireIPConnection(c *gin.Context) (release func(), ok bool) {
+	noop := func() {}
+	l := h.ipLimit
+	if l == nil {
+		return noop, true
+	}
+	ip := c.ClientIP()
+	if l.allowed(ip) {
+		return noop, true
+	}
+
+	count, acquired, err := l.counter.Acquire(ip, l.limit)
+	if err != nil {
+		l.counterErrors.Add(1)
+		log.Printf("WebSocket: не удалось учесть подключение с %s, лимит не применяется: %v", ip, err)
+		return noop, true
+	}
+	if !acquired {
+		l.rejected.Add(1)
+		log.Printf("WebSocket: подключение с %s отклонено, открыто соединений: %d (лимит %d)", ip, count, l.limit)
+		apierror.Respond(c, http.StatusTooManyRequests, apierror.CodeRateLimited, "Too many connections from this IP address")
+		return nil, false
+	}
+
+	var released atomic.Bool
+	return func() {
+		if !released.CompareAndSwap(false, true) {
+			return
+		}
+		if err := l.counter.Release(ip); err != nil {
+			l.counterErrors.Add(1)
+			log.Printf("WebSocket: не удалось снять учет соединения с %s: %v", ip, err)
+		}
+	}, true
+}
+
+// IPConnectionStats возвращает лимит соединений с одного IP, число отклоненных
+// подключений и IP-адреса с наибольшим числом открытых соединений
+func (h *WSHandler) IPConnectionStats() map[string]interface{} {
+	l := h.ipLimit
+	if l == nil {
+		return map[string]interface{}{"enabled": false}
+	}
+
+	stats := map[string]interface{}{
+		"enabled":        true,
+		"limit":          l.limit,
+		"allow_list":     len(l.allowList),
+		"rejected_total": l.rejected.Load(),
+		"counter_errors": l.counterErrors.Load(),
+	}
+	counts, err := l.counter.Counts()
+	if err != nil {
+		stats["error"] = err.Error()
+		return stats
+	}
+
+	type ipCount struct {
+		IP          string `json:"ip"`
+		Connections int64  `json:"connections"`
+	}
+	byIP := make([]ipCount, 0, len(counts))
+	for ip, n := range counts {
+		byIP = append(byIP, ipCount{IP: ip, Connections: n})
+	}
+	sort.Slice(byIP, func(i, j int) bool {
+		if byIP[i].Connections != byIP[j].Connections {
+			return byIP[i].Connections > byIP[j].Connections
+		}
+		return byIP[i].IP < byIP[j].IP
+	})
+	stats["ips"] = len(byIP)
+	if len(byIP) > maxReportedIPs {
+		byIP = byIP[:maxReportedIPs]
+	}
+	stats["by_ip"] = byIP
+	return stats
+}
@@ -0,0 +1,78 @@
+// Package i18n переводит сообщения, которые видит пользователь: тексты ошибок
+// HTTP API и WebSocket. Вместо строк обработчики передают ключ из каталога, а
+// язык выбирается по заголовку Accept-Language. Логи сервера не переводятся.
+package i18n
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Lang - код языка (первая часть тега BCP 47: en, ru)
+type Lang string
+
+const (
+	English Lang = "en"
+	Russian Lang = "ru"
+)
+
+// DefaultLang - язык сообщений, если клиент не указал поддерживаемый язык
+const DefaultLang = English
+
+// AcceptLanguageHeader - заголовок со списком предпочитаемых клиентом языков
+const AcceptLanguageHeader = "Accept-Language"
+
+// Key - ключ сообщения в каталоге
+type Key string
+
+// Supported возвращает список языков каталога
+func Supported() []Lang {
+	return []Lang{English, Russian}
+}
+
+// IsSupported проверяет, есть ли язык в каталоге
+func IsSupported(lang Lang) bool {
+	_, ok := catalog[lang]
+	return ok
+}
+
+// Parse выбирает язык по значению Accept-Language, например
+// "ru-RU,ru;q=0.9,en;q=0.8": берется поддерживаемый язык с наибольшим весом q,
+// при равных весах - первый в списке. Регион тега не учитывается. Если ни один
+// язык не поддерживается, возвращается DefaultLang.
+func Parse(acceptLanguage string) Lang {
+	best, bestQ := DefaultLang, 0.0
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		primary, _, _ := strings.Cut(tag, "-")
+		lang := Lang(strings.ToLower(strings.TrimSpace(primary)))
+		if q > bestQ && IsSupported(lang) {
+			best, bestQ = lang, q
+		}
+	}
+	return best
+}
+
+// T возвращает сообщение key на языке lang, подставляя args как в fmt.Sprintf.
+// Если перевода нет, используется DefaultLang, а если нет и его - сам ключ.
+func T(lang Lang, key Key, args ...interface{}) string {
+	format, ok := catalog[lang][key]
+	if !ok {
+		if format, ok = catalog[DefaultLang][key]; !ok {
+			format = string(key)
+		}
+	}
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
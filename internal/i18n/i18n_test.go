@@ -0,0 +1,52 @@
+package i18n
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		header string
+		want   Lang
+	}{
+		{"", DefaultLang},
+		{"ru", Russian},
+		{"ru-RU,ru;q=0.9,en-US;q=0.8,en;q=0.7", Russian},
+		{"en-US,en;q=0.9,ru;q=0.8", English},
+		{"de-DE,de;q=0.9,ru;q=0.5", Russian},
+		{"en;q=0.4, RU;q=0.6", Russian},
+		{"fr", DefaultLang},
+		{"*", DefaultLang},
+		{"ru;q=abc,en;q=0.1", English},
+	}
+	for _, tc := range cases {
+		assert.Equal(t, tc.want, Parse(tc.header), "Accept-Language: %q", tc.header)
+	}
+}
+
+func TestT(t *testing.T) {
+	assert.Equal(t, "Invalid CSRF token", T(English, AuthCSRFInvalid))
+	assert.Equal(t, "Неверный CSRF токен", T(Russian, AuthCSRFInvalid))
+	assert.Equal(t, "Требуется роль 'admin'", T(Russian, AuthRoleRequired, "admin"))
+	assert.Equal(t, "Invalid CSRF token", T("de", AuthCSRFInvalid), "нет языка - язык по умолчанию")
+	assert.Equal(t, "no.such.key", T(Russian, "no.such.key"), "нет ключа - сам ключ")
+}
+
+// TestCatalogComplete: каждый ключ переведен на все языки с теми же подстановками
+func TestCatalogComplete(t *testing.T) {
+	for key, english := range catalog[DefaultLang] {
+		for _, lang := range Supported() {
+			text, ok := catalog[lang][key]
+			if assert.True(t, ok, "%s: нет перевода %s", lang, key) {
+				assert.NotEmpty(t, text)
+				assert.Equal(t, strings.Count(english, "%"), strings.Count(text, "%"), "%s: подстановки в %s", lang, key)
+			}
+		}
+	}
+	for _, lang := range Supported() {
+		assert.Len(t, catalog[lang], len(catalog[DefaultLang]), "%s: лишние ключи", lang)
+	}
+}
@@ -0,0 +1,188 @@
+package i18n
+
+// Ключи сообщений об ошибках аутентификации
+const (
+	AuthUnauthorized         Key = "auth.unauthorized"
+	AuthHeaderRequired       Key = "auth.header_required"
+	AuthHeaderFormat         Key = "auth.header_format"
+	AuthTokenInvalid         Key = "auth.token_invalid"
+	AuthTokenExpired         Key = "auth.token_expired"
+	AuthTokenCheckDown       Key = "auth.token_check_unavailable"
+	AuthTokenRequired        Key = "auth.token_required"
+	AuthRefreshTokenRequired Key = "auth.refresh_token_required"
+	AuthInvalidCredentials   Key = "auth.invalid_credentials"
+	AuthInvalidRequest       Key = "auth.invalid_request"
+	AuthCSRFMissing          Key = "auth.csrf_missing"
+	AuthCSRFInvalid          Key = "auth.csrf_invalid"
+	AuthTooManySessions      Key = "auth.too_many_sessions"
+	AuthAdminRequired        Key = "auth.admin_required"
+	AuthRoleRequired         Key = "auth.role_required"
+	AuthAccessDenied         Key = "auth.access_denied"
+	AuthUserNotFound         Key = "auth.user_not_found"
+	AuthSessionNotFound      Key = "auth.session_not_found"
+	AuthDeviceSessionsAbsent Key = "auth.device_sessions_not_found"
+	AuthLogoutFailed         Key = "auth.logout_failed"
+	AuthLogoutAllFailed      Key = "auth.logout_all_failed"
+	AuthSessionsFailed       Key = "auth.sessions_failed"
+	AuthRevokeSessionFailed  Key = "auth.revoke_session_failed"
+	AuthRevokeDeviceFailed   Key = "auth.revoke_device_failed"
+	AuthTokenCheckFailed     Key = "auth.token_check_failed"
+	AuthTokenInfoFailed      Key = "auth.token_info_failed"
+	AuthPasswordResetFailed  Key = "auth.password_reset_failed"
+	AuthUserDataFailed       Key = "auth.user_data_failed"
+	AuthWSTicketFailed       Key = "auth.ws_ticket_failed"
+	AuthRequestFailed        Key = "auth.request_failed"
+)
+
+// Общие ошибки
+const (
+	InternalError Key = "internal_error"
+)
+
+// Ключи сообщений server:error WebSocket
+const (
+	WSInvalidJSON          Key = "ws.invalid_json"
+	WSBinaryNotNegotiated  Key = "ws.binary_not_negotiated"
+	WSInvalidBinaryFrame   Key = "ws.invalid_binary_frame"
+	WSUnknownMessageType   Key = "ws.unknown_message_type"
+	WSInvalidEvent         Key = "ws.invalid_event"
+	WSSubscribeFailed      Key = "ws.subscribe_failed"
+	WSUnknownSubscriptions Key = "ws.unknown_subscriptions"
+	WSDebugPingAdminOnly   Key = "ws.debug_ping_admin_only"
+	WSInvalidUserID        Key = "ws.invalid_user_id"
+	WSInviteCodeRequired   Key = "ws.invite_code_required"
+	WSInvalidInviteCode    Key = "ws.invalid_invite_code"
+	WSQuizNotFound         Key = "ws.quiz_not_found"
+	WSQuizAlreadyStarted   Key = "ws.quiz_already_started"
+	WSQuizFinished         Key = "ws.quiz_finished"
+	WSRemovedFromQuiz      Key = "ws.removed_from_quiz"
+	WSNotEligible          Key = "ws.not_eligible"
+	WSJoinRequired         Key = "ws.join_required"
+	WSQuizFull             Key = "ws.quiz_full"
+	WSJoinFailed           Key = "ws.join_failed"
+	WSQuizNotActive        Key = "ws.quiz_not_active"
+	WSQuestionReadPhase    Key = "ws.question_read_phase"
+	WSAnswerRejected       Key = "ws.answer_rejected"
+	WSNoPendingTakeover    Key = "ws.no_pending_takeover"
+)
+
+// catalog - тексты сообщений по языкам. Новый ключ добавляется во все языки
+// (это проверяет тест каталога); %s и %d подставляются из аргументов T.
+var catalog = map[Lang]map[Key]string{
+	English: {
+		AuthUnauthorized:         "Unauthorized",
+		AuthHeaderRequired:       "Authorization header is required",
+		AuthHeaderFormat:         "Authorization header format must be Bearer {token}",
+		AuthTokenInvalid:         "Invalid or expired token",
+		AuthTokenExpired:         "Token has expired",
+		AuthTokenCheckDown:       "Token verification is temporarily unavailable",
+		AuthTokenRequired:        "Token is required",
+		AuthRefreshTokenRequired: "Refresh token is required",
+		AuthInvalidCredentials:   "Invalid credentials",
+		AuthInvalidRequest:       "Invalid request data",
+		AuthCSRFMissing:          "CSRF token is missing",
+		AuthCSRFInvalid:          "Invalid CSRF token",
+		AuthTooManySessions:      "Too many active sessions, end one of them to sign in",
+		AuthAdminRequired:        "Admin rights required",
+		AuthRoleRequired:         "Role '%s' required",
+		AuthAccessDenied:         "Access denied",
+		AuthUserNotFound:         "User not found",
+		AuthSessionNotFound:      "Session not found",
+		AuthDeviceSessionsAbsent: "No sessions found for the device",
+		AuthLogoutFailed:         "Could not log out",
+		AuthLogoutAllFailed:      "Could not log out of all sessions",
+		AuthSessionsFailed:       "Could not get active sessions",
+		AuthRevokeSessionFailed:  "Could not revoke the session",
+		AuthRevokeDeviceFailed:   "Could not revoke the device sessions",
+		AuthTokenCheckFailed:     "Could not verify the token",
+		AuthTokenInfoFailed:      "Could not get token information",
+		AuthPasswordResetFailed:  "Could not reset the password",
+		AuthUserDataFailed:       "Failed to fetch user data",
+		AuthWSTicketFailed:       "Failed to generate WebSocket ticket",
+		AuthRequestFailed:        "Failed to process request",
+
+		InternalError: "Internal server error",
+
+		WSInvalidJSON:          "Invalid JSON format",
+		WSBinaryNotNegotiated:  "Binary protocol was not negotiated",
+		WSInvalidBinaryFrame:   "Invalid binary frame",
+		WSUnknownMessageType:   "Unknown message type: %s",
+		WSInvalidEvent:         "Failed to parse %s event",
+		WSSubscribeFailed:      "Failed to subscribe to quiz %d",
+		WSUnknownSubscriptions: "Unknown subscription types: %s",
+		WSDebugPingAdminOnly:   "debug:ping is available to administrators only",
+		WSInvalidUserID:        "Invalid user ID format",
+		WSInviteCodeRequired:   "An invite code is required to join this quiz",
+		WSInvalidInviteCode:    "Invalid invite code",
+		WSQuizNotFound:         "Quiz not found",
+		WSQuizAlreadyStarted:   "The quiz has already started",
+		WSQuizFinished:         "The quiz has finished",
+		WSRemovedFromQuiz:      "You have been removed from this quiz",
+		WSNotEligible:          "You are not eligible to join this quiz: %s",
+		WSJoinRequired:         "Join the quiz before getting ready",
+		WSQuizFull:             "The quiz has no free spots",
+		WSJoinFailed:           "Could not join the quiz: %s",
+		WSQuizNotActive:        "The quiz is not in progress",
+		WSQuestionReadPhase:    "Answers are not accepted while the question is being read",
+		WSAnswerRejected:       "Answer was not accepted: %s",
+		WSNoPendingTakeover:    "No other connection is waiting to take over",
+	},
+	Russian: {
+		AuthUnauthorized:         "Требуется вход",
+		AuthHeaderRequired:       "Нужен заголовок Authorization",
+		AuthHeaderFormat:         "Заголовок Authorization должен иметь вид Bearer {token}",
+		AuthTokenInvalid:         "Токен недействителен или истек",
+		AuthTokenExpired:         "Срок действия токена истек",
+		AuthTokenCheckDown:       "Проверка токена временно недоступна",
+		AuthTokenRequired:        "Требуется токен",
+		AuthRefreshTokenRequired: "Требуется refresh-токен",
+		AuthInvalidCredentials:   "Неверный email или пароль",
+		AuthInvalidRequest:       "Некорректные данные запроса",
+		AuthCSRFMissing:          "CSRF токен отсутствует",
+		AuthCSRFInvalid:          "Неверный CSRF токен",
+		AuthTooManySessions:      "Превышен лимит активных сессий, завершите одну из них",
+		AuthAdminRequired:        "Требуются права администратора",
+		AuthRoleRequired:         "Требуется роль '%s'",
+		AuthAccessDenied:         "Доступ запрещен",
+		AuthUserNotFound:         "Пользователь не найден",
+		AuthSessionNotFound:      "Сессия не найдена",
+		AuthDeviceSessionsAbsent: "Сессии устройства не найдены",
+		AuthLogoutFailed:         "Не удалось выйти",
+		AuthLogoutAllFailed:      "Не удалось выйти из всех сессий",
+		AuthSessionsFailed:       "Не удалось получить активные сессии",
+		AuthRevokeSessionFailed:  "Не удалось завершить сессию",
+		AuthRevokeDeviceFailed:   "Не удалось завершить сессии устройства",
+		AuthTokenCheckFailed:     "Не удалось проверить токен",
+		AuthTokenInfoFailed:      "Не удалось получить информацию о токене",
+		AuthPasswordResetFailed:  "Не удалось сбросить пароль",
+		AuthUserDataFailed:       "Не удалось получить данные пользователя",
+		AuthWSTicketFailed:       "Не удалось выдать тикет WebSocket",
+		AuthRequestFailed:        "Не удалось обработать запрос",
+
+		InternalError: "Внутренняя ошибка сервера",
+
+		WSInvalidJSON:          "Некорректный формат JSON",
+		WSBinaryNotNegotiated:  "Бинарный протокол не согласован",
+		WSInvalidBinaryFrame:   "Некорректный бинарный кадр",
+		WSUnknownMessageType:   "Неизвестный тип сообщения: %s",
+		WSInvalidEvent:         "Не удалось разобрать событие %s",
+		WSSubscribeFailed:      "Не удалось подписаться на викторину %d",
+		WSUnknownSubscriptions: "Неизвестные типы подписок: %s",
+		WSDebugPingAdminOnly:   "debug:ping доступен только администраторам",
+		WSInvalidUserID:        "Некорректный ID пользователя",
+		WSInviteCodeRequired:   "Для участия в викторине нужен код приглашения",
+		WSInvalidInviteCode:    "Неверный код приглашения",
+		WSQuizNotFound:         "Викторина не найдена",
+		WSQuizAlreadyStarted:   "Викторина уже началась",
+		WSQuizFinished:         "Викторина завершена",
+		WSRemovedFromQuiz:      "Вы исключены из викторины",
+		WSNotEligible:          "Вы не можете участвовать в викторине: %s",
+		WSJoinRequired:         "Сначала присоединитесь к викторине",
+		WSQuizFull:             "В викторине нет свободных мест",
+		WSJoinFailed:           "Не удалось присоединиться к викторине: %s",
+		WSQuizNotActive:        "Викторина сейчас не проводится",
+		WSQuestionReadPhase:    "Пока вопрос читается, ответы не принимаются",
+		WSAnswerRejected:       "Ответ не принят: %s",
+		WSNoPendingTakeover:    "Нет другого соединения, ожидающего передачи",
+	},
+}
@@ -1,19 +1,22 @@
 package middleware
 
 import (
+	"errors"
 	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/yourusername/trivia-api/internal/domain/entity"
+	"github.com/yourusername/trivia-api/internal/handler/apierror"
+	"github.com/yourusername/trivia-api/internal/i18n"
 	"github.com/yourusername/trivia-api/pkg/auth"
 	"github.com/yourusername/trivia-api/pkg/auth/manager"
 )
 
 // AuthMiddleware обеспечивает аутентификацию для защищенных маршрутов
 type AuthMiddleware struct {
-	jwtService *auth.JWTService
-	// tokenService *auth.TokenService    // УДАЛЕНО: Устаревшее поле
-	tokenManager *manager.TokenManager // Новый менеджер токенов
+	jwtService   *auth.JWTService
+	tokenManager *manager.TokenManager
 }
 
 // NewAuthMiddlewareWithManager создает новый middleware с использованием TokenManager
@@ -37,16 +40,14 @@ func (m *AuthMiddleware) RequireAuth() gin.HandlerFunc {
 				// Если токен в куки не найден, проверяем заголовок для обратной совместимости
 				authHeader := c.GetHeader("Authorization")
 				if authHeader == "" {
-					c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized", "error_type": "token_missing"})
-					c.Abort()
+					apierror.AbortKey(c, http.StatusUnauthorized, apierror.CodeTokenMissing, i18n.AuthUnauthorized)
 					return
 				}
 
 				// Проверяем формат заголовка Bearer {token}
 				parts := strings.Split(authHeader, " ")
 				if len(parts) != 2 || parts[0] != "Bearer" {
-					c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header format must be Bearer {token}", "error_type": "token_format"})
-					c.Abort()
+					apierror.AbortKey(c, http.StatusUnauthorized, apierror.CodeTokenFormat, i18n.AuthHeaderFormat)
 					return
 				}
 				token = parts[1]
@@ -56,16 +57,14 @@ func (m *AuthMiddleware) RequireAuth() gin.HandlerFunc {
 			// но оставляем на всякий случай, если middleware создается без него
 			authHeader := c.GetHeader("Authorization")
 			if authHeader == "" {
-				c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header is required", "error_type": "token_missing"})
-				c.Abort()
+				apierror.AbortKey(c, http.StatusUnauthorized, apierror.CodeTokenMissing, i18n.AuthHeaderRequired)
 				return
 			}
 
 			// Проверяем формат заголовка Bearer {token}
 			parts := strings.Split(authHeader, " ")
 			if len(parts) != 2 || parts[0] != "Bearer" {
-				c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header format must be Bearer {token}", "error_type": "token_format"})
-				c.Abort()
+				apierror.AbortKey(c, http.StatusUnauthorized, apierror.CodeTokenFormat, i18n.AuthHeaderFormat)
 				return
 			}
 			token = parts[1]
@@ -73,9 +72,13 @@ func (m *AuthMiddleware) RequireAuth() gin.HandlerFunc {
 
 		// Проверяем токен
 		claims, err := m.jwtService.ParseToken(c, token)
+		if errors.Is(err, auth.ErrInvalidationCheckUnavailable) {
+			// Токен может быть действительным: клиент не должен завершать сессию
+			apierror.AbortKey(c, http.StatusServiceUnavailable, apierror.CodeServiceUnavailable, i18n.AuthTokenCheckDown)
+			return
+		}
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token", "error_type": "token_invalid"})
-			c.Abort()
+			apierror.AbortKey(c, http.StatusUnauthorized, apierror.CodeTokenInvalid, i18n.AuthTokenInvalid)
 			return
 		}
 
@@ -83,9 +86,9 @@ func (m *AuthMiddleware) RequireAuth() gin.HandlerFunc {
 		c.Set("user_id", claims.UserID)
 		c.Set("email", claims.Email)
 
-		// Для администраторов добавляем флаг is_admin на основе проверки ID
-		// (в будущих версиях это можно заменить на проверку claims.Role)
-		if claims.UserID == 1 {
+		// Пользователь с ID=1 остается администратором независимо от роли в токене
+		c.Set("role", claims.EffectiveRole())
+		if claims.IsAdmin() {
 			c.Set("is_admin", true)
 		}
 
@@ -99,8 +102,7 @@ func (m *AuthMiddleware) AdminOnly() gin.HandlerFunc {
 		// Проверяем, аутентифицирован ли пользователь
 		userID, exists := c.Get("user_id")
 		if !exists {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
-			c.Abort()
+			apierror.AbortKey(c, http.StatusUnauthorized, apierror.CodeUnauthorized, i18n.AuthUnauthorized)
 			return
 		}
 
@@ -109,8 +111,7 @@ func (m *AuthMiddleware) AdminOnly() gin.HandlerFunc {
 		if !exists || !isAdmin.(bool) {
 			// Для обратной совместимости также проверяем по ID
 			if userID.(uint) != 1 {
-				c.JSON(http.StatusForbidden, gin.H{"error": "Admin rights required"})
-				c.Abort()
+				apierror.AbortKey(c, http.StatusForbidden, apierror.CodeForbidden, i18n.AuthAdminRequired)
 				return
 			}
 		}
@@ -119,6 +120,25 @@ func (m *AuthMiddleware) AdminOnly() gin.HandlerFunc {
 	}
 }
 
+// RequireRole пропускает пользователей с ролью не ниже role: например,
+// RequireRole(entity.RoleModerator) доступен модераторам и администраторам.
+// Роль берется из токена и меняется для пользователя после обновления токенов.
+func (m *AuthMiddleware) RequireRole(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, exists := c.Get("user_id"); !exists {
+			apierror.AbortKey(c, http.StatusUnauthorized, apierror.CodeUnauthorized, i18n.AuthUnauthorized)
+			return
+		}
+
+		if !entity.RoleAtLeast(c.GetString("role"), role) {
+			apierror.AbortKey(c, http.StatusForbidden, apierror.CodeForbidden, i18n.AuthRoleRequired, role)
+			return
+		}
+
+		c.Next()
+	}
+}
+
 // LogRequestInfo добавляет информацию о запросе в логи
 func (m *AuthMiddleware) LogRequestInfo() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -0,0 +1,90 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourusername/trivia-api/internal/domain/entity"
+	"github.com/yourusername/trivia-api/internal/domain/repository"
+	"github.com/yourusername/trivia-api/pkg/auth"
+)
+
+// noInvalidTokens - репозиторий без инвалидированных токенов
+type noInvalidTokens struct {
+	repository.InvalidTokenRepository
+}
+
+func (noInvalidTokens) IsTokenInvalid(ctx context.Context, userID uint, issuedAt time.Time) (bool, error) {
+	return false, nil
+}
+
+func (noInvalidTokens) GetAllInvalidTokens(ctx context.Context) ([]entity.InvalidToken, error) {
+	return nil, nil
+}
+
+func (noInvalidTokens) CleanupOldInvalidTokens(ctx context.Context, cutoffTime time.Time) error {
+	return nil
+}
+
+// newRoleTestRouter повторяет разделение маршрутов из main.go: проведение
+// викторин доступно модераторам, сброс паролей - только администраторам
+func newRoleTestRouter(t *testing.T) (*gin.Engine, *auth.JWTService) {
+	gin.SetMode(gin.TestMode)
+	jwtService := auth.NewJWTService("test-secret", 1, noInvalidTokens{}, 60, time.Hour)
+	m := NewAuthMiddlewareWithManager(jwtService, nil)
+
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	router := gin.New()
+	router.PUT("/api/quizzes/1/cancel", m.RequireAuth(), m.RequireRole(entity.RoleModerator), ok)
+	router.POST("/api/auth/admin/reset-password", m.RequireAuth(), m.AdminOnly(), ok)
+	return router, jwtService
+}
+
+func requestAs(t *testing.T, router *gin.Engine, jwtService *auth.JWTService, user *entity.User, method, path string) int {
+	token, err := jwtService.GenerateToken(user)
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(method, path, nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w.Code
+}
+
+func TestRequireRole_ModeratorControlsQuizzesButCannotResetPasswords(t *testing.T) {
+	router, jwtService := newRoleTestRouter(t)
+
+	tests := []struct {
+		name          string
+		user          *entity.User
+		quizControl   int
+		resetPassword int
+	}{
+		{"модератор", &entity.User{ID: 5, Role: entity.RoleModerator}, http.StatusOK, http.StatusForbidden},
+		{"игрок", &entity.User{ID: 6, Role: entity.RolePlayer}, http.StatusForbidden, http.StatusForbidden},
+		{"токен без роли", &entity.User{ID: 7}, http.StatusForbidden, http.StatusForbidden},
+		{"администратор по роли", &entity.User{ID: 8, Role: entity.RoleAdmin}, http.StatusOK, http.StatusOK},
+		{"пользователь с ID=1", &entity.User{ID: 1}, http.StatusOK, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.quizControl, requestAs(t, router, jwtService, tt.user, http.MethodPut, "/api/quizzes/1/cancel"))
+			assert.Equal(t, tt.resetPassword, requestAs(t, router, jwtService, tt.user, http.MethodPost, "/api/auth/admin/reset-password"))
+		})
+	}
+}
+
+func TestRequireRole_Unauthenticated(t *testing.T) {
+	router, _ := newRoleTestRouter(t)
+
+	req := httptest.NewRequest(http.MethodPut, "/api/quizzes/1/cancel", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/trivia-api/internal/handler/apierror"
+)
+
+// MaintenanceChecker сообщает, включен ли режим обслуживания
+type MaintenanceChecker interface {
+	IsEnabled() bool
+}
+
+// RejectDuringMaintenance создает middleware, отвечающее 503 на запросы, которые
+// начинают новую активность (создание и запуск викторин), пока включен режим обслуживания
+func RejectDuringMaintenance(checker MaintenanceChecker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if checker.IsEnabled() {
+			c.Header("Retry-After", "60")
+			apierror.Abort(c, http.StatusServiceUnavailable, apierror.CodeServiceUnavailable,
+				"Service is in maintenance mode, new quizzes cannot be started")
+			return
+		}
+		c.Next()
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/yourusername/trivia-api/internal/handler/apierror"
 )
 
 // ExtractUintParam создает middleware для извлечения и валидации числового параметра URL.
@@ -16,8 +17,7 @@ func ExtractUintParam(paramName, contextKey string) gin.HandlerFunc {
 		idStr := c.Param(paramName)
 		id, err := strconv.ParseUint(idStr, 10, 32)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid %s", paramName)})
-			c.Abort()
+			apierror.Abort(c, http.StatusBadRequest, apierror.CodeInvalidRequest, fmt.Sprintf("Invalid %s", paramName))
 			return
 		}
 		// Сохраняем как uint для единообразия
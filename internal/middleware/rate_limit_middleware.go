@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/trivia-api/internal/handler/apierror"
+)
+
+// RateLimitPerUser создает middleware, пропускающее не больше limit запросов
+// пользователя за окно window (счетчик сбрасывается в начале каждого окна).
+// Пользователь определяется по user_id из RequireAuth, без него - по IP клиента.
+// Счетчики хранятся в памяти экземпляра, поэтому middleware подходит для редких
+// служебных запросов, а не для защиты от нагрузки.
+func RateLimitPerUser(limit int, window time.Duration) gin.HandlerFunc {
+	type counter struct {
+		start time.Time
+		count int
+	}
+	var (
+		mu       sync.Mutex
+		counters = make(map[string]*counter)
+	)
+
+	return func(c *gin.Context) {
+		key := c.ClientIP()
+		if userID, ok := c.Get("user_id"); ok {
+			key = fmt.Sprintf("user:%v", userID)
+		}
+
+		now := time.Now()
+		mu.Lock()
+		for k, ctr := range counters {
+			if now.Sub(ctr.start) >= window {
+				delete(counters, k)
+			}
+		}
+		ctr, ok := counters[key]
+		if !ok {
+			ctr = &counter{start: now}
+			counters[key] = ctr
+		}
+		ctr.count++
+		exceeded := ctr.count > limit
+		retryAfter := ctr.start.Add(window).Sub(now)
+		mu.Unlock()
+
+		if exceeded {
+			c.Header("Retry-After", fmt.Sprintf("%d", int(math.Ceil(retryAfter.Seconds()))))
+			apierror.Abort(c, http.StatusTooManyRequests, apierror.CodeRateLimited, "Too many requests, try again later")
+			return
+		}
+		c.Next()
+	}
+}
@@ -2,13 +2,18 @@ package postgres
 
 import (
 	"errors"
+	"fmt"
 	"time"
 
 	"gorm.io/gorm"
 
 	"github.com/yourusername/trivia-api/internal/domain/entity"
+	"github.com/yourusername/trivia-api/internal/domain/repository"
 )
 
+// errQuizNotFound сохраняет прежний текст ошибки и распознается через errors.Is(err, repository.ErrNotFound)
+var errQuizNotFound = fmt.Errorf("quiz not found: %w", repository.ErrNotFound)
+
 // QuizRepo реализует repository.QuizRepository
 type QuizRepo struct {
 	db *gorm.DB
@@ -24,36 +29,54 @@ func (r *QuizRepo) Create(quiz *entity.Quiz) error {
 	return r.db.Create(quiz).Error
 }
 
+// CreateWithQuestions создает викторину и ее вопросы в одной транзакции.
+// QuizID вопросов и QuestionCount викторины заполняются автоматически.
+func (r *QuizRepo) CreateWithQuestions(quiz *entity.Quiz, questions []entity.Question) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		quiz.QuestionCount = len(questions)
+		// Вопросы создаются отдельно, чтобы не зависеть от quiz.Questions
+		if err := tx.Omit("Questions").Create(quiz).Error; err != nil {
+			return err
+		}
+		if len(questions) == 0 {
+			return nil
+		}
+		for i := range questions {
+			questions[i].QuizID = quiz.ID
+		}
+		return tx.Create(&questions).Error
+	})
+}
+
 // GetByID возвращает викторину по ID
 func (r *QuizRepo) GetByID(id uint) (*entity.Quiz, error) {
 	var quiz entity.Quiz
 	err := r.db.First(&quiz, id).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("quiz not found")
+			return nil, errQuizNotFound
 		}
 		return nil, err
 	}
 	return &quiz, nil
 }
 
-// GetActive возвращает активную викторину
-func (r *QuizRepo) GetActive() (*entity.Quiz, error) {
-	var quiz entity.Quiz
-	err := r.db.Where("status = ?", "in_progress").First(&quiz).Error
+// ListActive возвращает проводимые сейчас викторины
+func (r *QuizRepo) ListActive() ([]entity.Quiz, error) {
+	var quizzes []entity.Quiz
+	err := r.db.Where("status = ?", entity.QuizStatusInProgress).
+		Order("id").
+		Find(&quizzes).Error
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("no active quiz")
-		}
 		return nil, err
 	}
-	return &quiz, nil
+	return quizzes, nil
 }
 
 // GetScheduled возвращает все запланированные викторины
 func (r *QuizRepo) GetScheduled() ([]entity.Quiz, error) {
 	var quizzes []entity.Quiz
-	err := r.db.Where("status = ? AND scheduled_time > ?", "scheduled", time.Now()).
+	err := r.db.Where("status = ? AND scheduled_time > ?", entity.QuizStatusScheduled, time.Now()).
 		Order("scheduled_time").
 		Find(&quizzes).Error
 	if err != nil {
@@ -68,19 +91,41 @@ func (r *QuizRepo) GetWithQuestions(id uint) (*entity.Quiz, error) {
 	err := r.db.Preload("Questions").First(&quiz, id).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("quiz not found")
+			return nil, errQuizNotFound
 		}
 		return nil, err
 	}
 	return &quiz, nil
 }
 
-// UpdateStatus обновляет статус викторины
-func (r *QuizRepo) UpdateStatus(quizID uint, status string) error {
-	return r.db.Model(&entity.Quiz{}).
-		Where("id = ?", quizID).
-		Update("status", status).
-		Error
+// UpdateStatus меняет статус викторины с from на to одним условным UPDATE,
+// чтобы параллельная смена статуса не была перезаписана
+func (r *QuizRepo) UpdateStatus(quizID uint, from, to entity.QuizStatus) error {
+	result := r.db.Model(&entity.Quiz{}).
+		Where("id = ? AND status = ?", quizID, from).
+		Update("status", to)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("quiz %d: %w: status is no longer %s", quizID, entity.ErrInvalidStatusTransition, from)
+	}
+	return nil
+}
+
+// UpdateScheduledTime меняет время проведения условным UPDATE, чтобы перенос
+// не затронул викторину, статус которой параллельно сменился
+func (r *QuizRepo) UpdateScheduledTime(quizID uint, status entity.QuizStatus, scheduledTime time.Time) error {
+	result := r.db.Model(&entity.Quiz{}).
+		Where("id = ? AND status = ?", quizID, status).
+		Update("scheduled_time", scheduledTime)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("quiz %d: %w: status is no longer %s", quizID, entity.ErrInvalidStatusTransition, status)
+	}
+	return nil
 }
 
 // Update обновляет информацию о викторине
@@ -95,7 +140,61 @@ func (r *QuizRepo) List(limit, offset int) ([]entity.Quiz, error) {
 	return quizzes, err
 }
 
+// ListPublic возвращает публичные викторины с пагинацией
+func (r *QuizRepo) ListPublic(limit, offset int) ([]entity.Quiz, error) {
+	var quizzes []entity.Quiz
+	err := r.db.Where("visibility = ?", entity.VisibilityPublic).
+		Limit(limit).Offset(offset).Order("id DESC").Find(&quizzes).Error
+	return quizzes, err
+}
+
 // Delete удаляет викторину
 func (r *QuizRepo) Delete(id uint) error {
 	return r.db.Delete(&entity.Quiz{}, id).Error
 }
+
+// ListFinishedBefore возвращает завершенные и отмененные викторины,
+// запланированные раньше cutoff, в порядке возрастания ID. Викторины турниров
+// не возвращаются: их результаты нужны для общего зачета, а удаление викторины
+// каскадно убрало бы ее из турнира
+func (r *QuizRepo) ListFinishedBefore(cutoff time.Time, limit int) ([]entity.Quiz, error) {
+	var quizzes []entity.Quiz
+	err := r.db.Where("status IN ? AND scheduled_time < ?", []entity.QuizStatus{entity.QuizStatusCompleted, entity.QuizStatusCancelled}, cutoff).
+		Where("NOT EXISTS (SELECT 1 FROM tournament_quizzes tq WHERE tq.quiz_id = quizzes.id)").
+		Order("id").
+		Limit(limit).
+		Find(&quizzes).Error
+	return quizzes, err
+}
+
+// ListRecurrenceDue возвращает завершенные повторяющиеся викторины, для которых
+// еще не создана следующая викторина серии, в порядке возрастания ID
+func (r *QuizRepo) ListRecurrenceDue(limit int) ([]entity.Quiz, error) {
+	var quizzes []entity.Quiz
+	err := r.db.Where("status = ? AND recurrence_interval_min > 0 AND NOT recurrence_paused", entity.QuizStatusCompleted).
+		Where("NOT EXISTS (SELECT 1 FROM quizzes next WHERE next.recurrence_source_id = quizzes.id)").
+		Order("id").
+		Limit(limit).
+		Find(&quizzes).Error
+	return quizzes, err
+}
+
+// CountByStatus возвращает число викторин по статусам одним запросом
+func (r *QuizRepo) CountByStatus() (map[string]int64, error) {
+	var rows []struct {
+		Status string
+		Count  int64
+	}
+	err := r.db.Model(&entity.Quiz{}).
+		Select("status, COUNT(*) AS count").
+		Group("status").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+	}
+	return counts, nil
+}
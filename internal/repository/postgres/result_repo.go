@@ -23,6 +23,15 @@ func (r *ResultRepo) SaveUserAnswer(answer *entity.UserAnswer) error {
 	return r.db.Create(answer).Error
 }
 
+// SaveUserAnswersBatch сохраняет ответы одним INSERT с несколькими строками.
+// GORM выполняет его в транзакции, поэтому пакет сохраняется целиком или не сохраняется.
+func (r *ResultRepo) SaveUserAnswersBatch(answers []*entity.UserAnswer) error {
+	if len(answers) == 0 {
+		return nil
+	}
+	return r.db.Create(answers).Error
+}
+
 // GetUserAnswers возвращает все ответы пользователя для конкретной викторины
 func (r *ResultRepo) GetUserAnswers(userID uint, quizID uint) ([]entity.UserAnswer, error) {
 	var answers []entity.UserAnswer
@@ -73,10 +82,10 @@ func (r *ResultRepo) GetUserResults(userID uint, limit, offset int) ([]entity.Re
 
 // CalculateRanks вычисляет ранги всех участников викторины
 func (r *ResultRepo) CalculateRanks(quizID uint) error {
-	// Получаем все результаты викторины, отсортированные по счету
+	// Получаем все результаты викторины
 	var results []entity.Result
 	if err := r.db.Where("quiz_id = ?", quizID).
-		Order("score DESC, correct_answers DESC").
+		Order("id").
 		Find(&results).Error; err != nil {
 		return err
 	}
@@ -87,90 +96,35 @@ func (r *ResultRepo) CalculateRanks(quizID uint) error {
 		return err
 	}
 
-	totalQuestions := len(quiz.Questions)
-	prizeFundPerUser := 0
-
-	// Определяем победителей (ответившие на все вопросы правильно И не выбывшие)
-	var winners []entity.Result
-	if totalQuestions > 0 {
-		for _, result := range results {
-			if result.CorrectAnswers == totalQuestions && !result.IsEliminated {
-				winners = append(winners, result)
-			}
-		}
-	}
+	// Если к викторине можно присоединиться во время проведения, у игроков разное
+	// число доступных вопросов, и ранги считаются по доле правильных ответов
+	winners := entity.RankResults(results, len(quiz.Questions), quiz.AllowsLateJoin())
 
 	// Установка базового призового фонда
 	const totalPrizeFund = 1000000 // 1,000,000 (можно настроить)
 
 	// Распределяем призовой фонд между победителями
-	if len(winners) > 0 {
-		prizeFundPerUser = totalPrizeFund / len(winners)
-	} else {
-		prizeFundPerUser = 0 // Явно устанавливаем 0, если победителей нет
+	prizeFundPerUser := 0
+	if winners > 0 {
+		prizeFundPerUser = totalPrizeFund / winners
 	}
 
-	// Вычисляем ранги с учетом одинаковых очков
-	if len(results) > 0 {
-		currentRank := 1
-		currentScore := results[0].Score
-		results[0].Rank = currentRank
-
-		// Отмечаем победителей и устанавливаем призовой фонд
-		if totalQuestions > 0 && results[0].CorrectAnswers == totalQuestions && !results[0].IsEliminated {
-			results[0].IsWinner = true
-			results[0].PrizeFund = prizeFundPerUser
-		} else {
-			results[0].IsWinner = false
-			results[0].PrizeFund = 0
+	for i := range results {
+		results[i].PrizeFund = 0
+		if results[i].IsWinner {
+			results[i].PrizeFund = prizeFundPerUser
 		}
 
-		// Обновляем первый результат (только rank, is_winner, prize_fund)
+		// Обновляем результат в БД (только rank, is_winner, prize_fund)
 		if err := r.db.Model(&entity.Result{}).
-			Where("id = ?", results[0].ID).
+			Where("id = ?", results[i].ID).
 			Updates(map[string]interface{}{
-				"rank":       results[0].Rank,
-				"is_winner":  results[0].IsWinner,
-				"prize_fund": results[0].PrizeFund,
+				"rank":       results[i].Rank,
+				"is_winner":  results[i].IsWinner,
+				"prize_fund": results[i].PrizeFund,
 			}).Error; err != nil {
 			return err
 		}
-
-		// Обрабатываем остальные результаты
-		skippedPositions := 0
-		for i := 1; i < len(results); i++ {
-			if results[i].Score < currentScore {
-				// Если очки отличаются, увеличиваем ранг с учетом пропущенных мест
-				currentRank = i + 1
-				currentScore = results[i].Score
-				skippedPositions = 0
-			} else {
-				// Если очки равны, сохраняем тот же ранг
-				skippedPositions++
-			}
-
-			results[i].Rank = currentRank
-
-			// Определяем, является ли игрок победителем
-			if totalQuestions > 0 && results[i].CorrectAnswers == totalQuestions && !results[i].IsEliminated {
-				results[i].IsWinner = true
-				results[i].PrizeFund = prizeFundPerUser
-			} else {
-				results[i].IsWinner = false
-				results[i].PrizeFund = 0
-			}
-
-			// Обновляем результат в БД (только rank, is_winner, prize_fund)
-			if err := r.db.Model(&entity.Result{}).
-				Where("id = ?", results[i].ID).
-				Updates(map[string]interface{}{
-					"rank":       results[i].Rank,
-					"is_winner":  results[i].IsWinner,
-					"prize_fund": results[i].PrizeFund,
-				}).Error; err != nil {
-				return err
-			}
-		}
 	}
 
 	return nil
@@ -191,3 +145,41 @@ func (r *ResultRepo) GetQuizWinners(quizID uint) ([]entity.Result, error) {
 		Find(&winners).Error
 	return winners, err
 }
+
+// CountQuizData возвращает количество ответов и результатов викторины
+func (r *ResultRepo) CountQuizData(quizID uint) (int64, int64, error) {
+	var answers, results int64
+	if err := r.db.Model(&entity.UserAnswer{}).Where("quiz_id = ?", quizID).Count(&answers).Error; err != nil {
+		return 0, 0, err
+	}
+	if err := r.db.Model(&entity.Result{}).Where("quiz_id = ?", quizID).Count(&results).Error; err != nil {
+		return 0, 0, err
+	}
+	return answers, results, nil
+}
+
+// CountAnswers возвращает общее число ответов во всех викторинах
+func (r *ResultRepo) CountAnswers() (int64, error) {
+	var count int64
+	err := r.db.Model(&entity.UserAnswer{}).Count(&count).Error
+	return count, err
+}
+
+// DeleteQuizUserAnswersBatch удаляет не более batchSize ответов викторины.
+// Удаление по ID из подзапроса с LIMIT держит блокировки короткими.
+func (r *ResultRepo) DeleteQuizUserAnswersBatch(quizID uint, batchSize int) (int64, error) {
+	result := r.db.Exec(
+		"DELETE FROM user_answers WHERE id IN (SELECT id FROM user_answers WHERE quiz_id = ? LIMIT ?)",
+		quizID, batchSize,
+	)
+	return result.RowsAffected, result.Error
+}
+
+// DeleteQuizResultsBatch удаляет не более batchSize результатов викторины
+func (r *ResultRepo) DeleteQuizResultsBatch(quizID uint, batchSize int) (int64, error) {
+	result := r.db.Exec(
+		"DELETE FROM results WHERE id IN (SELECT id FROM results WHERE quiz_id = ? LIMIT ?)",
+		quizID, batchSize,
+	)
+	return result.RowsAffected, result.Error
+}
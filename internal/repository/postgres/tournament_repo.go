@@ -0,0 +1,68 @@
+package postgres
+
+import (
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/yourusername/trivia-api/internal/domain/entity"
+	"github.com/yourusername/trivia-api/internal/domain/repository"
+)
+
+// errTournamentNotFound распознается через errors.Is(err, repository.ErrNotFound)
+var errTournamentNotFound = fmt.Errorf("tournament not found: %w", repository.ErrNotFound)
+
+// TournamentRepo реализует repository.TournamentRepository
+type TournamentRepo struct {
+	db *gorm.DB
+}
+
+// NewTournamentRepo создает новый репозиторий турниров
+func NewTournamentRepo(db *gorm.DB) *TournamentRepo {
+	return &TournamentRepo{db: db}
+}
+
+// Create создает новый турнир
+func (r *TournamentRepo) Create(tournament *entity.Tournament) error {
+	return r.db.Create(tournament).Error
+}
+
+// GetByID возвращает турнир по ID
+func (r *TournamentRepo) GetByID(id uint) (*entity.Tournament, error) {
+	var tournament entity.Tournament
+	if err := r.db.First(&tournament, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errTournamentNotFound
+		}
+		return nil, err
+	}
+	return &tournament, nil
+}
+
+// AddQuiz добавляет викторину в турнир; повторное добавление ничего не меняет
+func (r *TournamentRepo) AddQuiz(tournamentID, quizID uint) error {
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&entity.TournamentQuiz{TournamentID: tournamentID, QuizID: quizID}).Error
+}
+
+// GetQuizIDs возвращает ID викторин турнира в порядке добавления
+func (r *TournamentRepo) GetQuizIDs(tournamentID uint) ([]uint, error) {
+	var quizIDs []uint
+	err := r.db.Model(&entity.TournamentQuiz{}).
+		Where("tournament_id = ?", tournamentID).
+		Order("created_at, quiz_id").
+		Pluck("quiz_id", &quizIDs).Error
+	return quizIDs, err
+}
+
+// GetResults возвращает результаты всех викторин турнира одним запросом
+func (r *TournamentRepo) GetResults(tournamentID uint) ([]entity.Result, error) {
+	var results []entity.Result
+	err := r.db.Joins("JOIN tournament_quizzes tq ON tq.quiz_id = results.quiz_id").
+		Where("tq.tournament_id = ?", tournamentID).
+		Order("results.user_id, results.score DESC").
+		Find(&results).Error
+	return results, err
+}
@@ -2,6 +2,7 @@ package postgres
 
 import (
 	"errors"
+	"fmt"
 	"log"
 	"time"
 
@@ -9,8 +10,12 @@ import (
 	"gorm.io/gorm"
 
 	"github.com/yourusername/trivia-api/internal/domain/entity"
+	"github.com/yourusername/trivia-api/internal/domain/repository"
 )
 
+// errUserNotFound сохраняет прежний текст ошибки и распознается через errors.Is(err, repository.ErrNotFound)
+var errUserNotFound = fmt.Errorf("user not found: %w", repository.ErrNotFound)
+
 // UserRepo реализует repository.UserRepository
 type UserRepo struct {
 	db *gorm.DB
@@ -26,13 +31,25 @@ func (r *UserRepo) Create(user *entity.User) error {
 	return r.db.Create(user).Error
 }
 
+// CreateBatch создает пользователей в одной транзакции
+func (r *UserRepo) CreateBatch(users []*entity.User) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for _, user := range users {
+			if err := tx.Create(user).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
 // GetByID возвращает пользователя по ID
 func (r *UserRepo) GetByID(id uint) (*entity.User, error) {
 	var user entity.User
 	err := r.db.First(&user, id).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("user not found")
+			return nil, errUserNotFound
 		}
 		return nil, err
 	}
@@ -45,7 +62,7 @@ func (r *UserRepo) GetByEmail(email string) (*entity.User, error) {
 	err := r.db.Where("email = ?", email).First(&user).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("user not found")
+			return nil, errUserNotFound
 		}
 		return nil, err
 	}
@@ -58,7 +75,7 @@ func (r *UserRepo) GetByUsername(username string) (*entity.User, error) {
 	err := r.db.Where("username = ?", username).First(&user).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("user not found")
+			return nil, errUserNotFound
 		}
 		return nil, err
 	}
@@ -156,3 +173,10 @@ func (r *UserRepo) List(limit, offset int) ([]entity.User, error) {
 	err := r.db.Limit(limit).Offset(offset).Order("id").Find(&users).Error
 	return users, err
 }
+
+// Count возвращает число зарегистрированных пользователей
+func (r *UserRepo) Count() (int64, error) {
+	var count int64
+	err := r.db.Model(&entity.User{}).Count(&count).Error
+	return count, err
+}
@@ -0,0 +1,60 @@
+package postgres
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/yourusername/trivia-api/internal/domain/entity"
+)
+
+// WSAlertRepo реализует repository.WSAlertRepository
+type WSAlertRepo struct {
+	db *gorm.DB
+}
+
+// NewWSAlertRepo создает новый репозиторий истории алертов WebSocket
+func NewWSAlertRepo(db *gorm.DB) *WSAlertRepo {
+	return &WSAlertRepo{db: db}
+}
+
+// SaveAlert сохраняет алерт
+func (r *WSAlertRepo) SaveAlert(ctx context.Context, alert *entity.WSAlert) error {
+	return r.db.WithContext(ctx).Create(alert).Error
+}
+
+// GetAlerts возвращает алерты начиная с since, от новых к старым
+func (r *WSAlertRepo) GetAlerts(ctx context.Context, severity, alertType string, since time.Time, limit int) ([]entity.WSAlert, error) {
+	var alerts []entity.WSAlert
+
+	query := r.db.WithContext(ctx).
+		Where("created_at >= ?", since).
+		Order("created_at DESC, id DESC")
+	if severity != "" {
+		query = query.Where("severity = ?", severity)
+	}
+	if alertType != "" {
+		query = query.Where("type = ?", alertType)
+	}
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+
+	if err := query.Find(&alerts).Error; err != nil {
+		log.Printf("Ошибка при получении истории алертов WebSocket: %v", err)
+		return nil, err
+	}
+	return alerts, nil
+}
+
+// DeleteOlderThan удаляет алерты старше указанного времени
+func (r *WSAlertRepo) DeleteOlderThan(ctx context.Context, cutoffTime time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Where("created_at < ?", cutoffTime).Delete(&entity.WSAlert{})
+	if result.Error != nil {
+		log.Printf("Ошибка при очистке истории алертов WebSocket: %v", result.Error)
+		return 0, result.Error
+	}
+	return result.RowsAffected, nil
+}
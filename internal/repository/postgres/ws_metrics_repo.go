@@ -0,0 +1,57 @@
+package postgres
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/yourusername/trivia-api/internal/domain/entity"
+)
+
+// WSMetricsRepo реализует repository.WSMetricsRepository
+type WSMetricsRepo struct {
+	db *gorm.DB
+}
+
+// NewWSMetricsRepo создает новый репозиторий истории метрик WebSocket
+func NewWSMetricsRepo(db *gorm.DB) *WSMetricsRepo {
+	return &WSMetricsRepo{db: db}
+}
+
+// SaveSnapshot сохраняет снимок метрик
+func (r *WSMetricsRepo) SaveSnapshot(ctx context.Context, snapshot *entity.WSMetricsSnapshot) error {
+	return r.db.WithContext(ctx).Create(snapshot).Error
+}
+
+// GetSnapshots возвращает снимки за указанный период
+func (r *WSMetricsRepo) GetSnapshots(ctx context.Context, from, to time.Time, instanceID string, limit int) ([]entity.WSMetricsSnapshot, error) {
+	var snapshots []entity.WSMetricsSnapshot
+
+	query := r.db.WithContext(ctx).
+		Where("collected_at >= ? AND collected_at <= ?", from, to).
+		Order("collected_at ASC")
+	if instanceID != "" {
+		query = query.Where("instance_id = ?", instanceID)
+	}
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+
+	if err := query.Find(&snapshots).Error; err != nil {
+		log.Printf("Ошибка при получении истории метрик WebSocket: %v", err)
+		return nil, err
+	}
+	return snapshots, nil
+}
+
+// DeleteOlderThan удаляет снимки старше указанного времени
+func (r *WSMetricsRepo) DeleteOlderThan(ctx context.Context, cutoffTime time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Where("collected_at < ?", cutoffTime).Delete(&entity.WSMetricsSnapshot{})
+	if result.Error != nil {
+		log.Printf("Ошибка при очистке истории метрик WebSocket: %v", result.Error)
+		return 0, result.Error
+	}
+	return result.RowsAffected, nil
+}
@@ -10,6 +10,9 @@ import (
 	"github.com/go-redis/redis/v8"
 )
 
+// errKeyNotFound возвращается Get и GetJSON, если ключа нет в Redis
+var errKeyNotFound = errors.New("key not found")
+
 // CacheRepo реализует repository.CacheRepository
 type CacheRepo struct {
 	client redis.UniversalClient
@@ -37,7 +40,7 @@ func (r *CacheRepo) Get(key string) (string, error) {
 	val, err := r.client.Get(r.ctx, key).Result()
 	if err != nil {
 		if errors.Is(err, redis.Nil) {
-			return "", errors.New("key not found")
+			return "", errKeyNotFound
 		}
 		return "", err
 	}
@@ -49,6 +52,16 @@ func (r *CacheRepo) Delete(key string) error {
 	return r.client.Del(r.ctx, key).Err()
 }
 
+// DeleteIfExists удаляет ключ и сообщает, был ли он удален. Из нескольких
+// одновременных вызовов для одного ключа true получает только один.
+func (r *CacheRepo) DeleteIfExists(key string) (bool, error) {
+	deleted, err := r.client.Del(r.ctx, key).Result()
+	if err != nil {
+		return false, err
+	}
+	return deleted > 0, nil
+}
+
 // Increment увеличивает значение на 1
 func (r *CacheRepo) Increment(key string) (int64, error) {
 	return r.client.Incr(r.ctx, key).Result()
@@ -68,7 +81,7 @@ func (r *CacheRepo) GetJSON(key string, dest interface{}) error {
 	data, err := r.client.Get(r.ctx, key).Bytes()
 	if err != nil {
 		if errors.Is(err, redis.Nil) {
-			return errors.New("key not found")
+			return errKeyNotFound
 		}
 		return err
 	}
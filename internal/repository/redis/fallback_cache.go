@@ -0,0 +1,354 @@
+package redis
+
+import (
+	"container/list"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"path"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/yourusername/trivia-api/internal/domain/repository"
+)
+
+// DefaultFallbackKeyPatterns - критичные ключи по умолчанию: время начала вопросов,
+// от которого считается время ответа
+var DefaultFallbackKeyPatterns = []string{"quiz:*:question:*:start_time"}
+
+// defaultFallbackCapacity - емкость локального кэша, если она не задана
+const defaultFallbackCapacity = 10000
+
+// FallbackCacheRepo оборачивает кэш Redis и дублирует критичные короткоживущие
+// ключи в локальный LRU-кэш процесса. Если Redis возвращает ошибку, операции
+// с критичными ключами выполняются над локальной копией, поэтому кратковременная
+// недоступность Redis не ломает идущую викторину. Остальные ключи передаются
+// в Redis без изменений.
+//
+// Локальная копия есть только у экземпляра, записавшего ключ: в кластере другие
+// экземпляры ее не видят, а SetNX и Increment во время сбоя атомарны лишь
+// в пределах одного процесса.
+type FallbackCacheRepo struct {
+	inner    repository.CacheRepository
+	patterns []string
+	capacity int
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List // Начало списка - самые недавно использованные ключи
+
+	redisErrors    atomic.Int64
+	fallbackReads  atomic.Int64
+	fallbackWrites atomic.Int64
+	evictions      atomic.Int64
+}
+
+// fallbackEntry - значение ключа в локальном кэше
+type fallbackEntry struct {
+	key       string
+	value     string
+	expiresAt time.Time // Нулевое время - без срока действия
+}
+
+// Проверка компилятором, что обертка реализует CacheRepository
+var _ repository.CacheRepository = (*FallbackCacheRepo)(nil)
+
+// NewFallbackCacheRepo создает обертку с локальным кэшем на capacity ключей.
+// patterns - шаблоны критичных ключей в формате path.Match; если не заданы,
+// используются DefaultFallbackKeyPatterns.
+func NewFallbackCacheRepo(inner repository.CacheRepository, capacity int, patterns []string) *FallbackCacheRepo {
+	if capacity <= 0 {
+		capacity = defaultFallbackCapacity
+	}
+	if len(patterns) == 0 {
+		patterns = DefaultFallbackKeyPatterns
+	}
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			log.Printf("[FallbackCache] WARNING: Некорректный шаблон ключа %q: %v", pattern, err)
+		}
+	}
+	log.Printf("[FallbackCache] Локальный резерв для ключей %v, емкость %d", patterns, capacity)
+	return &FallbackCacheRepo{
+		inner:    inner,
+		patterns: patterns,
+		capacity: capacity,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// Set сохраняет значение в Redis, а критичный ключ - и в локальном кэше
+func (r *FallbackCacheRepo) Set(key string, value interface{}, expiration time.Duration) error {
+	err := r.inner.Set(key, value, expiration)
+	if !r.isCritical(key) {
+		return err
+	}
+	r.store(key, fmt.Sprint(value), expiration)
+	return r.absorbWriteError("Set", key, err)
+}
+
+// Get получает значение из Redis. Критичный ключ читается локально, если Redis
+// недоступен или не знает ключа, записанного во время сбоя.
+func (r *FallbackCacheRepo) Get(key string) (string, error) {
+	value, err := r.inner.Get(key)
+	if err == nil || !r.isCritical(key) {
+		return value, err
+	}
+	if local, ok := r.load(key); ok {
+		r.noteFallbackRead("Get", key, err)
+		return local, nil
+	}
+	return "", err
+}
+
+// Delete удаляет ключ из Redis и локального кэша. Ошибка Redis возвращается:
+// после восстановления ключ в Redis останется.
+func (r *FallbackCacheRepo) Delete(key string) error {
+	err := r.inner.Delete(key)
+	if r.isCritical(key) {
+		r.remove(key)
+	}
+	return err
+}
+
+// DeleteIfExists удаляет ключ из Redis и локального кэша и сообщает, был ли он
+// удален в Redis. Ошибка Redis возвращается так же, как в Delete.
+func (r *FallbackCacheRepo) DeleteIfExists(key string) (bool, error) {
+	deleted, err := r.inner.DeleteIfExists(key)
+	if r.isCritical(key) {
+		r.remove(key)
+	}
+	return deleted, err
+}
+
+// Increment увеличивает значение на 1. Во время сбоя Redis критичный ключ
+// увеличивается в локальном кэше.
+func (r *FallbackCacheRepo) Increment(key string) (int64, error) {
+	n, err := r.inner.Increment(key)
+	if !r.isCritical(key) {
+		return n, err
+	}
+	if err == nil {
+		r.storeKeepingExpiry(key, strconv.FormatInt(n, 10))
+		return n, nil
+	}
+
+	r.mu.Lock()
+	var current int64
+	if entry, ok := r.getLocked(key); ok {
+		current, _ = strconv.ParseInt(entry.value, 10, 64)
+	}
+	current++
+	r.setLocked(key, strconv.FormatInt(current, 10), r.expiryLocked(key))
+	r.mu.Unlock()
+	return current, r.absorbWriteError("Increment", key, err)
+}
+
+// SetJSON сохраняет структуру JSON в Redis, а критичный ключ - и локально
+func (r *FallbackCacheRepo) SetJSON(key string, value interface{}, expiration time.Duration) error {
+	err := r.inner.SetJSON(key, value, expiration)
+	if !r.isCritical(key) {
+		return err
+	}
+	data, marshalErr := json.Marshal(value)
+	if marshalErr != nil {
+		return marshalErr
+	}
+	r.store(key, string(data), expiration)
+	return r.absorbWriteError("SetJSON", key, err)
+}
+
+// GetJSON получает структуру JSON; критичный ключ читается локально так же, как в Get
+func (r *FallbackCacheRepo) GetJSON(key string, dest interface{}) error {
+	err := r.inner.GetJSON(key, dest)
+	if err == nil || !r.isCritical(key) {
+		return err
+	}
+	if local, ok := r.load(key); ok {
+		r.noteFallbackRead("GetJSON", key, err)
+		return json.Unmarshal([]byte(local), dest)
+	}
+	return err
+}
+
+// Exists проверяет существование ключа; при ошибке Redis - по локальному кэшу
+func (r *FallbackCacheRepo) Exists(key string) (bool, error) {
+	exists, err := r.inner.Exists(key)
+	if err == nil || !r.isCritical(key) {
+		return exists, err
+	}
+	if _, ok := r.load(key); ok {
+		r.noteFallbackRead("Exists", key, err)
+		return true, nil
+	}
+	return false, err
+}
+
+// ExpireAt устанавливает время истечения ключа в Redis и локальном кэше
+func (r *FallbackCacheRepo) ExpireAt(key string, expiration time.Time) error {
+	err := r.inner.ExpireAt(key, expiration)
+	if r.isCritical(key) {
+		r.mu.Lock()
+		if entry, ok := r.getLocked(key); ok {
+			entry.expiresAt = expiration
+		}
+		r.mu.Unlock()
+	}
+	return err
+}
+
+// SetNX устанавливает значение, только если ключа нет. Во время сбоя Redis
+// проверка критичного ключа выполняется по локальному кэшу этого экземпляра.
+func (r *FallbackCacheRepo) SetNX(key string, value interface{}, expiration time.Duration) (bool, error) {
+	set, err := r.inner.SetNX(key, value, expiration)
+	if !r.isCritical(key) {
+		return set, err
+	}
+	if err == nil {
+		if set {
+			r.store(key, fmt.Sprint(value), expiration)
+		}
+		return set, nil
+	}
+
+	r.mu.Lock()
+	_, exists := r.getLocked(key)
+	if !exists {
+		r.setLocked(key, fmt.Sprint(value), expiryFor(expiration))
+	}
+	r.mu.Unlock()
+	return !exists, r.absorbWriteError("SetNX", key, err)
+}
+
+// Stats возвращает метрики использования локального резерва
+func (r *FallbackCacheRepo) Stats() map[string]interface{} {
+	r.mu.Lock()
+	entries := len(r.entries)
+	r.mu.Unlock()
+	return map[string]interface{}{
+		"redis_errors":    r.redisErrors.Load(),
+		"fallback_reads":  r.fallbackReads.Load(),
+		"fallback_writes": r.fallbackWrites.Load(),
+		"local_entries":   entries,
+		"local_capacity":  r.capacity,
+		"evictions":       r.evictions.Load(),
+		"key_patterns":    r.patterns,
+	}
+}
+
+// isCritical сообщает, дублируется ли ключ в локальном кэше
+func (r *FallbackCacheRepo) isCritical(key string) bool {
+	for _, pattern := range r.patterns {
+		if ok, _ := path.Match(pattern, key); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// absorbWriteError учитывает ошибку Redis при записи критичного ключа.
+// Значение уже сохранено локально, поэтому вызывающему ошибка не возвращается.
+func (r *FallbackCacheRepo) absorbWriteError(op, key string, err error) error {
+	if err == nil {
+		return nil
+	}
+	r.redisErrors.Add(1)
+	if r.fallbackWrites.Add(1)%100 == 1 {
+		log.Printf("[FallbackCache] WARNING: Redis недоступен (%s %s): %v. Ключ сохранен локально", op, key, err)
+	}
+	return nil
+}
+
+// noteFallbackRead учитывает чтение локальной копии. Отсутствие ключа в Redis
+// ошибкой Redis не считается.
+func (r *FallbackCacheRepo) noteFallbackRead(op, key string, err error) {
+	if !errors.Is(err, errKeyNotFound) {
+		r.redisErrors.Add(1)
+	}
+	if r.fallbackReads.Add(1)%100 == 1 {
+		log.Printf("[FallbackCache] WARNING: Redis недоступен (%s %s): %v. Используется локальное значение", op, key, err)
+	}
+}
+
+func (r *FallbackCacheRepo) store(key, value string, expiration time.Duration) {
+	r.mu.Lock()
+	r.setLocked(key, value, expiryFor(expiration))
+	r.mu.Unlock()
+}
+
+// storeKeepingExpiry обновляет значение, сохраняя срок действия локальной копии
+func (r *FallbackCacheRepo) storeKeepingExpiry(key, value string) {
+	r.mu.Lock()
+	r.setLocked(key, value, r.expiryLocked(key))
+	r.mu.Unlock()
+}
+
+func (r *FallbackCacheRepo) load(key string) (string, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entry, ok := r.getLocked(key)
+	if !ok {
+		return "", false
+	}
+	return entry.value, true
+}
+
+func (r *FallbackCacheRepo) remove(key string) {
+	r.mu.Lock()
+	if elem, ok := r.entries[key]; ok {
+		r.order.Remove(elem)
+		delete(r.entries, key)
+	}
+	r.mu.Unlock()
+}
+
+// getLocked возвращает действующую запись и отмечает ее как недавно использованную
+func (r *FallbackCacheRepo) getLocked(key string) (*fallbackEntry, bool) {
+	elem, ok := r.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*fallbackEntry)
+	if !entry.expiresAt.IsZero() && time.Now().After(entry.expiresAt) {
+		r.order.Remove(elem)
+		delete(r.entries, key)
+		return nil, false
+	}
+	r.order.MoveToFront(elem)
+	return entry, true
+}
+
+func (r *FallbackCacheRepo) expiryLocked(key string) time.Time {
+	if entry, ok := r.getLocked(key); ok {
+		return entry.expiresAt
+	}
+	return time.Time{}
+}
+
+// setLocked сохраняет запись и вытесняет самые давно использованные сверх емкости
+func (r *FallbackCacheRepo) setLocked(key, value string, expiresAt time.Time) {
+	if elem, ok := r.entries[key]; ok {
+		entry := elem.Value.(*fallbackEntry)
+		entry.value, entry.expiresAt = value, expiresAt
+		r.order.MoveToFront(elem)
+		return
+	}
+	r.entries[key] = r.order.PushFront(&fallbackEntry{key: key, value: value, expiresAt: expiresAt})
+	for r.order.Len() > r.capacity {
+		oldest := r.order.Back()
+		r.order.Remove(oldest)
+		delete(r.entries, oldest.Value.(*fallbackEntry).key)
+		r.evictions.Add(1)
+	}
+}
+
+func expiryFor(expiration time.Duration) time.Time {
+	if expiration <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(expiration)
+}
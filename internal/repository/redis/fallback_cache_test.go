@@ -0,0 +1,109 @@
+package redis
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourusername/trivia-api/internal/domain/repository"
+)
+
+// flakyCache - кэш в памяти, который можно "отключить", как недоступный Redis
+type flakyCache struct {
+	repository.CacheRepository
+	data map[string]string
+	down bool
+}
+
+var errRedisDown = errors.New("dial tcp: connection refused")
+
+func (c *flakyCache) Set(key string, value interface{}, expiration time.Duration) error {
+	if c.down {
+		return errRedisDown
+	}
+	c.data[key] = fmt.Sprint(value)
+	return nil
+}
+
+func (c *flakyCache) Get(key string) (string, error) {
+	if c.down {
+		return "", errRedisDown
+	}
+	value, ok := c.data[key]
+	if !ok {
+		return "", errKeyNotFound
+	}
+	return value, nil
+}
+
+func (c *flakyCache) Delete(key string) error {
+	if c.down {
+		return errRedisDown
+	}
+	delete(c.data, key)
+	return nil
+}
+
+const startKey = "quiz:1:question:10:start_time"
+
+func TestFallbackCacheRepo_CriticalKeySurvivesOutage(t *testing.T) {
+	inner := &flakyCache{data: make(map[string]string)}
+	cache := NewFallbackCacheRepo(inner, 10, nil)
+
+	// Записано до сбоя - читается во время сбоя
+	require.NoError(t, cache.Set(startKey, 1700000000000, time.Hour))
+	inner.down = true
+	value, err := cache.Get(startKey)
+	require.NoError(t, err)
+	assert.Equal(t, "1700000000000", value)
+
+	// Записано во время сбоя - читается и после восстановления
+	otherKey := "quiz:1:question:11:start_time"
+	require.NoError(t, cache.Set(otherKey, 1700000030000, time.Hour))
+	inner.down = false
+	value, err = cache.Get(otherKey)
+	require.NoError(t, err)
+	assert.Equal(t, "1700000030000", value)
+
+	stats := cache.Stats()
+	assert.Equal(t, int64(1), stats["fallback_writes"])
+	assert.Equal(t, int64(2), stats["fallback_reads"])
+	assert.Equal(t, int64(2), stats["redis_errors"], "отсутствие ключа в Redis не считается ошибкой")
+}
+
+func TestFallbackCacheRepo_OtherKeysPassThrough(t *testing.T) {
+	inner := &flakyCache{data: make(map[string]string), down: true}
+	cache := NewFallbackCacheRepo(inner, 10, nil)
+
+	assert.ErrorIs(t, cache.Set("maintenance:state", "on", 0), errRedisDown)
+	_, err := cache.Get("maintenance:state")
+	assert.ErrorIs(t, err, errRedisDown)
+
+	// Критичного ключа нет и локально - ошибка Redis возвращается
+	_, err = cache.Get(startKey)
+	assert.ErrorIs(t, err, errRedisDown)
+	assert.Equal(t, 0, cache.Stats()["local_entries"])
+}
+
+func TestFallbackCacheRepo_EvictsAndExpires(t *testing.T) {
+	inner := &flakyCache{data: make(map[string]string), down: true}
+	cache := NewFallbackCacheRepo(inner, 2, []string{"quiz:*"})
+
+	require.NoError(t, cache.Set("quiz:1", "a", time.Hour))
+	require.NoError(t, cache.Set("quiz:2", "b", time.Hour))
+	_, err := cache.Get("quiz:1") // quiz:1 использован позже quiz:2
+	require.NoError(t, err)
+	require.NoError(t, cache.Set("quiz:3", "c", time.Hour))
+
+	_, err = cache.Get("quiz:2")
+	assert.Error(t, err, "давно не использованный ключ вытеснен")
+	assert.Equal(t, int64(1), cache.Stats()["evictions"])
+
+	require.NoError(t, cache.Set("quiz:4", "d", time.Millisecond))
+	time.Sleep(5 * time.Millisecond)
+	_, err = cache.Get("quiz:4")
+	assert.Error(t, err, "истекший ключ не возвращается")
+}
@@ -11,26 +11,28 @@ import (
 	"github.com/yourusername/trivia-api/internal/domain/repository"
 	"github.com/yourusername/trivia-api/pkg/auth"
 	"github.com/yourusername/trivia-api/pkg/auth/manager"
+	"github.com/yourusername/trivia-api/pkg/moderation"
 )
 
 // AuthService предоставляет методы для работы с аутентификацией и пользователями
 type AuthService struct {
 	userRepo   repository.UserRepository
 	jwtService *auth.JWTService // Нужен для ParseToken и Invalidate
-	// tokenService     *auth.TokenService             // УДАЛЕНО
-	tokenManager     *manager.TokenManager             // Теперь основная зависимость для токенов
+	// Единственный путь работы с access/refresh токенами
+	tokenManager     *manager.TokenManager
 	refreshTokenRepo repository.RefreshTokenRepository // Оставляем для прямого доступа, если нужно
 	invalidTokenRepo repository.InvalidTokenRepository // Добавляем репозиторий инвалидных токенов
+	// Фильтр имен пользователей; nil - модерация выключена
+	nameFilter *moderation.NameFilter
 }
 
 // NewAuthService создает новый сервис аутентификации
 func NewAuthService(
 	userRepo repository.UserRepository,
 	jwtService *auth.JWTService,
-	// tokenService *auth.TokenService, // УДАЛЕНО
-	tokenManager *manager.TokenManager, // ПРИНИМАЕМ TokenManager
+	tokenManager *manager.TokenManager,
 	refreshTokenRepo repository.RefreshTokenRepository,
-	invalidTokenRepo repository.InvalidTokenRepository, // ПРИНИМАЕМ InvalidTokenRepo
+	invalidTokenRepo repository.InvalidTokenRepository,
 ) *AuthService {
 	if userRepo == nil {
 		log.Fatal("UserRepository is required for AuthService")
@@ -38,7 +40,7 @@ func NewAuthService(
 	if jwtService == nil {
 		log.Fatal("JWTService is required for AuthService")
 	}
-	if tokenManager == nil { // ПРОВЕРЯЕМ TokenManager
+	if tokenManager == nil {
 		log.Fatal("TokenManager is required for AuthService")
 	}
 	if refreshTokenRepo == nil {
@@ -49,33 +51,23 @@ func NewAuthService(
 	}
 
 	return &AuthService{
-		userRepo:   userRepo,
-		jwtService: jwtService,
-		// tokenService:     tokenService, // УДАЛЕНО
-		tokenManager:     tokenManager, // ИСПОЛЬЗУЕМ TokenManager
+		userRepo:         userRepo,
+		jwtService:       jwtService,
+		tokenManager:     tokenManager,
 		refreshTokenRepo: refreshTokenRepo,
 		invalidTokenRepo: invalidTokenRepo,
 	}
 }
 
+// SetNameFilter включает проверку имен пользователей при регистрации и обновлении профиля
+func (s *AuthService) SetNameFilter(filter *moderation.NameFilter) {
+	s.nameFilter = filter
+}
+
 // RegisterUser регистрирует нового пользователя
 func (s *AuthService) RegisterUser(username, email, password string) (*entity.User, error) {
-	// Проверяем, существует ли пользователь с таким email
-	_, err := s.userRepo.GetByEmail(email)
-	if err == nil {
-		return nil, errors.New("user with this email already exists")
-	}
-	if !errors.Is(err, repository.ErrNotFound) && !errors.Is(err, errors.New("user not found")) {
-		return nil, fmt.Errorf("failed to check email existence: %w", err)
-	}
-
-	// Проверяем, существует ли пользователь с таким username
-	_, err = s.userRepo.GetByUsername(username)
-	if err == nil {
-		return nil, errors.New("user with this username already exists")
-	}
-	if !errors.Is(err, repository.ErrNotFound) && !errors.Is(err, errors.New("user not found")) {
-		return nil, fmt.Errorf("failed to check username existence: %w", err)
+	if err := s.checkNewUser(username, email); err != nil {
+		return nil, err
 	}
 
 	// Хеширование пароля убрано отсюда.
@@ -94,6 +86,32 @@ func (s *AuthService) RegisterUser(username, email, password string) (*entity.Us
 	return user, nil
 }
 
+// checkNewUser проверяет, что имя допустимо, а email и имя пользователя не заняты
+func (s *AuthService) checkNewUser(username, email string) error {
+	if err := s.nameFilter.Check(username); err != nil {
+		return err
+	}
+
+	// Проверяем, существует ли пользователь с таким email
+	_, err := s.userRepo.GetByEmail(email)
+	if err == nil {
+		return errors.New("user with this email already exists")
+	}
+	if !errors.Is(err, repository.ErrNotFound) {
+		return fmt.Errorf("failed to check email existence: %w", err)
+	}
+
+	// Проверяем, существует ли пользователь с таким username
+	_, err = s.userRepo.GetByUsername(username)
+	if err == nil {
+		return errors.New("user with this username already exists")
+	}
+	if !errors.Is(err, repository.ErrNotFound) {
+		return fmt.Errorf("failed to check username existence: %w", err)
+	}
+	return nil
+}
+
 // AuthResponse содержит данные для ответа на запрос авторизации
 type AuthResponse struct {
 	User         *entity.User `json:"user"`
@@ -114,6 +132,11 @@ func (s *AuthService) LoginUser(email, password, deviceID, ipAddress, userAgent
 	tokenResp, err := s.tokenManager.GenerateTokenPair(user.ID, deviceID, ipAddress, userAgent)
 	if err != nil {
 		log.Printf("[AuthService] Ошибка генерации токенов для пользователя ID=%d: %v", user.ID, err)
+		// Отказ из-за лимита сессий передается как есть, чтобы клиент увидел причину
+		var tokenErr *manager.TokenError
+		if errors.As(err, &tokenErr) && tokenErr.Type == manager.TooManySessions {
+			return nil, err
+		}
 		return nil, fmt.Errorf("ошибка генерации токенов")
 	}
 
@@ -160,8 +183,11 @@ func (s *AuthService) UpdateUserProfile(userID uint, username, profilePicture st
 		return err
 	}
 
-	// Если имя пользователя изменилось, проверяем, что оно уникально
+	// Если имя пользователя изменилось, проверяем, что оно допустимо и уникально
 	if username != user.Username {
+		if err := s.nameFilter.Check(username); err != nil {
+			return err
+		}
 		existingUser, _ := s.userRepo.GetByUsername(username)
 		if existingUser != nil {
 			return errors.New("username already taken")
@@ -275,12 +301,8 @@ func (s *AuthService) GetUserActiveSessions(userID uint) ([]entity.RefreshToken,
 	return sessions, nil
 }
 
-// CheckRefreshToken проверяет действительность refresh токена
-// Обновлено: Логика проверки теперь полностью в TokenManager, этот метод можно удалить или сделать прокси
+// CheckRefreshToken проверяет действительность refresh токена через TokenManager
 func (s *AuthService) CheckRefreshToken(refreshToken string) (bool, error) {
-	// Проксируем вызов к TokenManager
-	// return s.tokenManager.CheckRefreshToken(refreshToken) // У TokenManager нет такого публичного метода
-	// Вместо этого можно использовать GetTokenInfo или RefreshTokens с проверкой ошибки
 	_, err := s.tokenManager.GetTokenInfo(refreshToken)
 	if err != nil {
 		var tokenErr *manager.TokenError
@@ -332,6 +354,64 @@ func (s *AuthService) AdminResetPassword(userID uint, newPassword string) error
 	return s.LogoutAllDevices(userID)
 }
 
+// AdminRevokeUserSessions завершает все сессии пользователя по решению администратора
+// (например, при компрометации аккаунта): отзываются refresh-токены, инвалидируются
+// выданные JWT и удаляются CSRF-токены. Соединения WebSocket закрывает вызывающий код.
+func (s *AuthService) AdminRevokeUserSessions(adminID, userID uint) error {
+	if _, err := s.userRepo.GetByID(userID); err != nil {
+		return fmt.Errorf("%w: %v", ErrUserNotFound, err)
+	}
+
+	if err := s.LogoutAllDevices(userID); err != nil {
+		return err
+	}
+
+	log.Printf("[AuthService] AUDIT: администратор ID=%d отозвал все сессии пользователя ID=%d", adminID, userID)
+	return nil
+}
+
+// AdminSetUserRole назначает пользователю роль. Выданные JWT инвалидируются,
+// чтобы новая роль применилась при ближайшем обновлении токенов.
+func (s *AuthService) AdminSetUserRole(adminID, userID uint, role string) (*entity.User, error) {
+	if !entity.IsValidRole(role) {
+		return nil, fmt.Errorf("%w: unknown role %q", ErrValidation, role)
+	}
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUserNotFound, err)
+	}
+
+	if err := s.userRepo.UpdateProfile(userID, map[string]interface{}{"role": role}); err != nil {
+		return nil, fmt.Errorf("failed to update user role: %w", err)
+	}
+	if err := s.InvalidateUserTokens(userID); err != nil {
+		log.Printf("[AuthService] Ошибка при инвалидации JWT пользователя ID=%d после смены роли: %v", userID, err)
+	}
+
+	log.Printf("[AuthService] AUDIT: администратор ID=%d назначил пользователю ID=%d роль %s (была %s)", adminID, userID, role, user.Role)
+	user.Role = role
+	return user, nil
+}
+
+// AdminSetEmailVerified отмечает email пользователя подтвержденным или снимает
+// отметку. Подтверждение проверяется условием участия require_verified_email
+// при следующем присоединении к викторине.
+func (s *AuthService) AdminSetEmailVerified(adminID, userID uint, verified bool) (*entity.User, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUserNotFound, err)
+	}
+
+	if err := s.userRepo.UpdateProfile(userID, map[string]interface{}{"email_verified": verified}); err != nil {
+		return nil, fmt.Errorf("failed to update email verification: %w", err)
+	}
+
+	log.Printf("[AuthService] AUDIT: администратор ID=%d изменил подтверждение email пользователя ID=%d: %t (было %t)",
+		adminID, userID, verified, user.EmailVerified)
+	user.EmailVerified = verified
+	return user, nil
+}
+
 // GetRefreshTokenByUserID получает активный refresh токен пользователя
 func (s *AuthService) GetRefreshTokenByUserID(userID uint) (*entity.RefreshToken, error) {
 	tokens, err := s.refreshTokenRepo.GetActiveTokensForUser(userID)
@@ -451,6 +531,44 @@ func (s *AuthService) RevokeAllUserSessions(userID uint, reason string) error {
 	return nil
 }
 
+// RevokeDeviceSessions отзывает все активные сессии пользователя на указанном устройстве.
+// Сессии других устройств не затрагиваются. Возвращает количество отозванных сессий
+// или ErrSessionNotFound, если у пользователя нет активных сессий на этом устройстве.
+func (s *AuthService) RevokeDeviceSessions(userID uint, deviceID, reason string) (int, error) {
+	tokens, err := s.refreshTokenRepo.GetActiveTokensForUser(userID)
+	if err != nil {
+		return 0, fmt.Errorf("не удалось получить активные сессии: %w", err)
+	}
+
+	found := 0
+	revoked := 0
+	for _, token := range tokens {
+		// Токены выбираются только среди сессий пользователя, поэтому чужое устройство
+		// отозвать нельзя - для него просто не найдется сессий
+		if token.UserID != userID || token.DeviceID != deviceID {
+			continue
+		}
+		found++
+
+		if err := s.refreshTokenRepo.MarkTokenAsExpiredByID(token.ID); err != nil {
+			log.Printf("[AuthService] Ошибка при отзыве сессии ID=%d устройства %s: %v", token.ID, deviceID, err)
+			// Продолжаем отзыв других сессий устройства
+			continue
+		}
+		revoked++
+	}
+
+	if found == 0 {
+		return 0, ErrSessionNotFound
+	}
+	if revoked == 0 {
+		return 0, fmt.Errorf("не удалось отозвать сессии устройства %s", deviceID)
+	}
+
+	log.Printf("[AuthService] Отозвано %d сессий устройства %s пользователя ID=%d. Причина: %s", revoked, deviceID, userID, reason)
+	return revoked, nil
+}
+
 // GetActiveSessionsWithDetails возвращает детализированную информацию об активных сессиях пользователя
 // Обновлено для использования TokenManager
 func (s *AuthService) GetActiveSessionsWithDetails(userID uint) ([]map[string]interface{}, error) {
@@ -472,8 +590,8 @@ func (s *AuthService) GetActiveSessionsWithDetails(userID uint) ([]map[string]in
 
 // GenerateWsTicket генерирует короткоживущий тикет для аутентификации WebSocket
 // Использует jwtService напрямую
-func (s *AuthService) GenerateWsTicket(userID uint, email string) (string, error) {
-	ticket, err := s.jwtService.GenerateWSTicket(userID, email)
+func (s *AuthService) GenerateWsTicket(userID uint, email, role string) (string, error) {
+	ticket, err := s.jwtService.GenerateWSTicket(userID, email, role)
 	if err != nil {
 		log.Printf("[AuthService] Ошибка генерации WebSocket тикета для пользователя ID=%d: %v", userID, err)
 		return "", fmt.Errorf("ошибка генерации тикета")
@@ -0,0 +1,338 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/yourusername/trivia-api/internal/domain/entity"
+	"github.com/yourusername/trivia-api/internal/domain/repository"
+	"github.com/yourusername/trivia-api/pkg/auth"
+	"github.com/yourusername/trivia-api/pkg/auth/manager"
+	"github.com/yourusername/trivia-api/pkg/moderation"
+)
+
+// MockRefreshTokenRepository мок репозитория refresh-токенов
+type MockRefreshTokenRepository struct {
+	mock.Mock
+}
+
+func (m *MockRefreshTokenRepository) CreateToken(refreshToken *entity.RefreshToken) (uint, error) {
+	args := m.Called(refreshToken)
+	return args.Get(0).(uint), args.Error(1)
+}
+
+func (m *MockRefreshTokenRepository) GetTokenByValue(token string) (*entity.RefreshToken, error) {
+	args := m.Called(token)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.RefreshToken), args.Error(1)
+}
+
+func (m *MockRefreshTokenRepository) GetTokenByID(id uint) (*entity.RefreshToken, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.RefreshToken), args.Error(1)
+}
+
+func (m *MockRefreshTokenRepository) CheckToken(token string) (bool, error) {
+	args := m.Called(token)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRefreshTokenRepository) MarkTokenAsExpired(token string) error {
+	args := m.Called(token)
+	return args.Error(0)
+}
+
+func (m *MockRefreshTokenRepository) MarkTokenAsExpiredByID(id uint) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockRefreshTokenRepository) DeleteToken(token string) error {
+	args := m.Called(token)
+	return args.Error(0)
+}
+
+func (m *MockRefreshTokenRepository) MarkAllAsExpiredForUser(userID uint) error {
+	args := m.Called(userID)
+	return args.Error(0)
+}
+
+func (m *MockRefreshTokenRepository) CleanupExpiredTokens() (int64, error) {
+	args := m.Called()
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockRefreshTokenRepository) GetActiveTokensForUser(userID uint) ([]*entity.RefreshToken, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entity.RefreshToken), args.Error(1)
+}
+
+func (m *MockRefreshTokenRepository) CountTokensForUser(userID uint) (int, error) {
+	args := m.Called(userID)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockRefreshTokenRepository) MarkOldestAsExpiredForUser(userID uint, limit int) error {
+	args := m.Called(userID, limit)
+	return args.Error(0)
+}
+
+// userSessions возвращает набор сессий пользователя на двух устройствах
+func userSessions(userID uint) []*entity.RefreshToken {
+	return []*entity.RefreshToken{
+		{ID: 1, UserID: userID, DeviceID: "phone"},
+		{ID: 2, UserID: userID, DeviceID: "laptop"},
+		{ID: 3, UserID: userID, DeviceID: "phone"},
+		{ID: 4, UserID: userID, DeviceID: "tablet"},
+	}
+}
+
+func TestRevokeDeviceSessions_LeavesOtherDevicesIntact(t *testing.T) {
+	repo := new(MockRefreshTokenRepository)
+	s := &AuthService{refreshTokenRepo: repo}
+
+	repo.On("GetActiveTokensForUser", uint(42)).Return(userSessions(42), nil)
+	repo.On("MarkTokenAsExpiredByID", uint(1)).Return(nil)
+	repo.On("MarkTokenAsExpiredByID", uint(3)).Return(nil)
+
+	revoked, err := s.RevokeDeviceSessions(42, "phone", "test")
+	require.NoError(t, err)
+	assert.Equal(t, 2, revoked)
+
+	repo.AssertExpectations(t)
+	repo.AssertNumberOfCalls(t, "MarkTokenAsExpiredByID", 2)
+	repo.AssertNotCalled(t, "MarkTokenAsExpiredByID", uint(2))
+	repo.AssertNotCalled(t, "MarkTokenAsExpiredByID", uint(4))
+}
+
+func TestRevokeDeviceSessions_UnknownDevice(t *testing.T) {
+	repo := new(MockRefreshTokenRepository)
+	s := &AuthService{refreshTokenRepo: repo}
+
+	// Устройство другого пользователя не попадает в выборку сессий текущего
+	repo.On("GetActiveTokensForUser", uint(42)).Return(userSessions(42), nil)
+
+	revoked, err := s.RevokeDeviceSessions(42, "foreign-device", "test")
+	assert.ErrorIs(t, err, ErrSessionNotFound)
+	assert.Equal(t, 0, revoked)
+	repo.AssertNotCalled(t, "MarkTokenAsExpiredByID", mock.Anything)
+}
+
+// stubInvalidTokenRepository хранит инвалидации токенов в памяти
+type stubInvalidTokenRepository struct {
+	repository.InvalidTokenRepository
+	invalidated []uint
+}
+
+func (r *stubInvalidTokenRepository) AddInvalidToken(ctx context.Context, userID uint, invalidationTime time.Time) error {
+	r.invalidated = append(r.invalidated, userID)
+	return nil
+}
+
+func (r *stubInvalidTokenRepository) IsTokenInvalid(ctx context.Context, userID uint, tokenIssuedAt time.Time) (bool, error) {
+	return false, nil
+}
+
+func (r *stubInvalidTokenRepository) GetAllInvalidTokens(ctx context.Context) ([]entity.InvalidToken, error) {
+	return nil, nil
+}
+
+func (r *stubInvalidTokenRepository) CleanupOldInvalidTokens(ctx context.Context, cutoffTime time.Time) error {
+	return nil
+}
+
+// stubUserRepository нужен только для конструктора TokenManager
+type stubUserRepository struct {
+	repository.UserRepository
+	users map[uint]*entity.User
+}
+
+func (r *stubUserRepository) GetByID(id uint) (*entity.User, error) {
+	if user, ok := r.users[id]; ok {
+		return user, nil
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *stubUserRepository) UpdateProfile(userID uint, updates map[string]interface{}) error {
+	if role, ok := updates["role"].(string); ok {
+		r.users[userID].Role = role
+	}
+	return nil
+}
+
+// newTokenManagerAuthService собирает AuthService так же, как main.go:
+// все операции с токенами идут через TokenManager поверх репозитория-мока
+func newTokenManagerAuthService(repo *MockRefreshTokenRepository) (*AuthService, *stubInvalidTokenRepository) {
+	invalidRepo := &stubInvalidTokenRepository{}
+	userRepo := &stubUserRepository{users: map[uint]*entity.User{7: {ID: 7, Email: "player@example.com"}}}
+	jwtService := auth.NewJWTService("test-secret", 1, invalidRepo, 60, time.Hour)
+	tokenManager := manager.NewTokenManager(jwtService, repo, userRepo)
+	return NewAuthService(userRepo, jwtService, tokenManager, repo, invalidRepo), invalidRepo
+}
+
+func TestAuthService_LogoutUserUsesTokenManager(t *testing.T) {
+	repo := new(MockRefreshTokenRepository)
+	s, _ := newTokenManagerAuthService(repo)
+
+	repo.On("MarkTokenAsExpired", "valid-token").Return(nil).Once()
+	repo.On("MarkTokenAsExpired", "unknown-token").Return(repository.ErrNotFound).Once()
+
+	assert.NoError(t, s.LogoutUser("valid-token"))
+	// Несуществующий токен - выход все равно считается успешным
+	assert.NoError(t, s.LogoutUser("unknown-token"))
+	repo.AssertExpectations(t)
+}
+
+func TestAuthService_LogoutAllDevicesRevokesRefreshAndJWT(t *testing.T) {
+	repo := new(MockRefreshTokenRepository)
+	s, invalidRepo := newTokenManagerAuthService(repo)
+
+	repo.On("MarkAllAsExpiredForUser", uint(7)).Return(nil).Once()
+
+	require.NoError(t, s.LogoutAllDevices(7))
+	repo.AssertExpectations(t)
+	assert.Contains(t, invalidRepo.invalidated, uint(7), "JWT пользователя должны быть инвалидированы")
+}
+
+func TestAuthService_AdminRevokeUserSessions(t *testing.T) {
+	repo := new(MockRefreshTokenRepository)
+	s, invalidRepo := newTokenManagerAuthService(repo)
+
+	repo.On("CreateToken", mock.Anything).Return(uint(1), nil)
+	repo.On("CountTokensForUser", uint(7)).Return(1, nil)
+	repo.On("MarkAllAsExpiredForUser", uint(7)).Return(nil).Once()
+
+	tokens, err := s.tokenManager.GenerateTokenPair(7, "phone", "127.0.0.1", "test")
+	require.NoError(t, err)
+	_, err = s.jwtService.ParseToken(context.Background(), tokens.AccessToken)
+	require.NoError(t, err)
+	require.True(t, s.tokenManager.VerifyCSRFToken(7, tokens.CSRFToken))
+
+	require.NoError(t, s.AdminRevokeUserSessions(1, 7))
+
+	repo.AssertExpectations(t)
+	assert.Contains(t, invalidRepo.invalidated, uint(7))
+	_, err = s.jwtService.ParseToken(context.Background(), tokens.AccessToken)
+	assert.Error(t, err, "выданный ранее JWT больше не принимается")
+	assert.False(t, s.tokenManager.VerifyCSRFToken(7, tokens.CSRFToken), "CSRF-токены пользователя удалены")
+
+	assert.ErrorIs(t, s.AdminRevokeUserSessions(1, 42), ErrUserNotFound)
+}
+
+func TestTokenManager_SessionLimitEvictOldest(t *testing.T) {
+	repo := new(MockRefreshTokenRepository)
+	s, _ := newTokenManagerAuthService(repo)
+	s.tokenManager.SetMaxRefreshTokensPerUser(3)
+
+	// У пользователя уже 3 сессии: вход проходит, самая старая завершается
+	repo.On("CreateToken", mock.Anything).Return(uint(4), nil).Once()
+	repo.On("CountTokensForUser", uint(7)).Return(4, nil).Once()
+	repo.On("MarkOldestAsExpiredForUser", uint(7), 3).Return(nil).Once()
+
+	_, err := s.tokenManager.GenerateTokenPair(7, "phone", "127.0.0.1", "test")
+	require.NoError(t, err)
+	repo.AssertExpectations(t)
+}
+
+func TestTokenManager_SessionLimitRejectNew(t *testing.T) {
+	repo := new(MockRefreshTokenRepository)
+	s, _ := newTokenManagerAuthService(repo)
+	s.tokenManager.SetMaxRefreshTokensPerUser(3)
+	s.tokenManager.SetSessionLimitPolicy(manager.SessionLimitPolicyRejectNew)
+
+	// Одно место до лимита: вход проходит, старые сессии не трогаются
+	repo.On("CountTokensForUser", uint(7)).Return(2, nil).Once()
+	repo.On("CreateToken", mock.Anything).Return(uint(3), nil).Once()
+	repo.On("CountTokensForUser", uint(7)).Return(3, nil).Once()
+	_, err := s.tokenManager.GenerateTokenPair(7, "phone", "127.0.0.1", "test")
+	require.NoError(t, err)
+
+	// Лимит достигнут: вход отклоняется до создания токена
+	repo.On("CountTokensForUser", uint(7)).Return(3, nil).Once()
+	_, err = s.tokenManager.GenerateTokenPair(7, "laptop", "127.0.0.1", "test")
+
+	var tokenErr *manager.TokenError
+	require.ErrorAs(t, err, &tokenErr)
+	assert.Equal(t, manager.TooManySessions, tokenErr.Type)
+	var limitErr *manager.SessionLimitError
+	require.ErrorAs(t, tokenErr.Err, &limitErr)
+	assert.Equal(t, manager.SessionLimitError{ActiveSessions: 3, Limit: 3}, *limitErr)
+	repo.AssertExpectations(t)
+	repo.AssertNotCalled(t, "MarkOldestAsExpiredForUser", mock.Anything, mock.Anything)
+}
+
+func TestAuthService_AdminSetUserRole(t *testing.T) {
+	s, invalidRepo := newTokenManagerAuthService(new(MockRefreshTokenRepository))
+
+	user, err := s.AdminSetUserRole(1, 7, entity.RoleModerator)
+	require.NoError(t, err)
+	assert.Equal(t, entity.RoleModerator, user.Role)
+	stored, _ := s.userRepo.GetByID(7)
+	assert.Equal(t, entity.RoleModerator, stored.Role)
+	assert.Contains(t, invalidRepo.invalidated, uint(7), "токены со старой ролью инвалидированы")
+
+	_, err = s.AdminSetUserRole(1, 7, "owner")
+	assert.ErrorIs(t, err, ErrValidation)
+	_, err = s.AdminSetUserRole(1, 42, entity.RoleModerator)
+	assert.ErrorIs(t, err, ErrUserNotFound)
+}
+
+func TestAuthService_AdminSetEmailVerified(t *testing.T) {
+	s, _ := newTokenManagerAuthService(new(MockRefreshTokenRepository))
+
+	user, err := s.AdminSetEmailVerified(1, 7, true)
+	require.NoError(t, err)
+	assert.True(t, user.EmailVerified)
+	stored, _ := s.userRepo.GetByID(7)
+	assert.True(t, stored.EmailVerified, "подтверждение сохранено и видно проверке участия")
+
+	_, err = s.AdminSetEmailVerified(1, 42, true)
+	assert.ErrorIs(t, err, ErrUserNotFound)
+}
+
+func TestAuthService_CheckRefreshTokenAndInfo(t *testing.T) {
+	repo := new(MockRefreshTokenRepository)
+	s, _ := newTokenManagerAuthService(repo)
+
+	expiresAt := time.Now().Add(24 * time.Hour)
+	repo.On("GetTokenByValue", "valid-token").Return(&entity.RefreshToken{ID: 1, UserID: 7, ExpiresAt: expiresAt}, nil)
+	repo.On("GetTokenByValue", "unknown-token").Return(nil, repository.ErrNotFound)
+
+	ok, err := s.CheckRefreshToken("valid-token")
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = s.CheckRefreshToken("unknown-token")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	info, err := s.GetTokenInfo("valid-token")
+	require.NoError(t, err)
+	assert.True(t, info.RefreshTokenExpires.Equal(expiresAt))
+
+	_, err = s.GetTokenInfo("unknown-token")
+	assert.Error(t, err)
+}
+
+func TestAuthService_RegisterUserRejectsBlockedName(t *testing.T) {
+	// stubUserRepository паникует при обращении, поэтому проверка должна сработать до запросов к БД
+	s := &AuthService{userRepo: &stubUserRepository{}}
+	s.SetNameFilter(moderation.NewNameFilter([]string{"badword"}, nil))
+
+	user, err := s.RegisterUser("B@dW0rd_42", "player@example.com", "secret123")
+	assert.Nil(t, user)
+	assert.ErrorIs(t, err, moderation.ErrNameNotAllowed)
+}
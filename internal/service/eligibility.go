@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/yourusername/trivia-api/internal/domain/entity"
+)
+
+// MaxMinGamesPlayed - максимальное требование к числу сыгранных игр
+const MaxMinGamesPlayed = 10000
+
+// externalEligibilityTimeout ограничивает время внешней проверки при присоединении
+const externalEligibilityTimeout = 3 * time.Second
+
+// EligibilityChecker решает, может ли пользователь присоединиться к викторине.
+// Отказ возвращается ошибкой из Ineligible с понятной игроку причиной; любая
+// другая ошибка означает, что проверку выполнить не удалось.
+type EligibilityChecker interface {
+	CheckEligibility(ctx context.Context, quiz *entity.Quiz, user *entity.User) error
+}
+
+// Ineligible возвращает ошибку отказа в участии с причиной
+func Ineligible(reason string) error {
+	return fmt.Errorf("%w: %s", ErrNotEligible, reason)
+}
+
+// NoopEligibilityChecker допускает всех. Используется как внешняя проверка по
+// умолчанию, пока она не задана через SetExternalEligibilityChecker.
+type NoopEligibilityChecker struct{}
+
+// CheckEligibility всегда допускает пользователя
+func (NoopEligibilityChecker) CheckEligibility(context.Context, *entity.Quiz, *entity.User) error {
+	return nil
+}
+
+// VerifiedEmailCheck требует подтвержденный email, если этого требует викторина
+type VerifiedEmailCheck struct{}
+
+// CheckEligibility проверяет подтверждение email
+func (VerifiedEmailCheck) CheckEligibility(_ context.Context, quiz *entity.Quiz, user *entity.User) error {
+	if quiz.RequireVerifiedEmail && !user.EmailVerified {
+		return Ineligible("a verified email is required")
+	}
+	return nil
+}
+
+// MinGamesPlayedCheck требует не меньше quiz.MinGamesPlayed сыгранных игр
+type MinGamesPlayedCheck struct{}
+
+// CheckEligibility проверяет число сыгранных игр
+func (MinGamesPlayedCheck) CheckEligibility(_ context.Context, quiz *entity.Quiz, user *entity.User) error {
+	if user.GamesPlayed < quiz.MinGamesPlayed {
+		return Ineligible(fmt.Sprintf("at least %d games played are required, you have played %d", quiz.MinGamesPlayed, user.GamesPlayed))
+	}
+	return nil
+}
+
+// EligibilityCheckers выполняет проверки по порядку и возвращает первый отказ
+type EligibilityCheckers []EligibilityChecker
+
+// CheckEligibility выполняет все проверки
+func (checks EligibilityCheckers) CheckEligibility(ctx context.Context, quiz *entity.Quiz, user *entity.User) error {
+	for _, check := range checks {
+		if err := check.CheckEligibility(ctx, quiz, user); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// builtinEligibilityChecks - встроенные проверки, настраиваемые полями викторины
+var builtinEligibilityChecks = EligibilityCheckers{VerifiedEmailCheck{}, MinGamesPlayedCheck{}}
+
+// SetExternalEligibilityChecker задает внешнюю проверку участия (например, запрос
+// к сервису призового фонда). Она применяется к викторинам с включенной
+// external_eligibility_check после встроенных проверок. nil возвращает проверку
+// по умолчанию, допускающую всех.
+func (qm *QuizManager) SetExternalEligibilityChecker(checker EligibilityChecker) {
+	if checker == nil {
+		checker = NoopEligibilityChecker{}
+	}
+	qm.stateMutex.Lock()
+	qm.externalEligibility = checker
+	qm.stateMutex.Unlock()
+}
+
+// checkEligibility проверяет условия участия в викторине. Викторины без условий
+// открыты всем авторизованным пользователям. Если внешнюю проверку выполнить не
+// удалось, в участии отказывается: условия призовых викторин не обходятся при сбоях.
+func (qm *QuizManager) checkEligibility(quiz *entity.Quiz, userID uint) error {
+	if !quiz.HasEntryRequirements() {
+		return nil
+	}
+
+	user, err := qm.resultService.userRepo.GetByID(userID)
+	if err != nil {
+		return fmt.Errorf("failed to get user %d for eligibility check: %w", userID, err)
+	}
+	if err := builtinEligibilityChecks.CheckEligibility(qm.ctx, quiz, user); err != nil {
+		return err
+	}
+	if !quiz.ExternalEligibilityCheck {
+		return nil
+	}
+
+	qm.stateMutex.RLock()
+	external := qm.externalEligibility
+	qm.stateMutex.RUnlock()
+
+	ctx, cancel := context.WithTimeout(qm.ctx, externalEligibilityTimeout)
+	defer cancel()
+	if err := external.CheckEligibility(ctx, quiz, user); err != nil {
+		if errors.Is(err, ErrNotEligible) {
+			return err
+		}
+		log.Printf("[QuizManager] Ошибка внешней проверки участия пользователя #%d в викторине #%d: %v", userID, quiz.ID, err)
+		return Ineligible("eligibility check is unavailable, please try again later")
+	}
+	return nil
+}
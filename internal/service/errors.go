@@ -1,14 +1,35 @@
 package service
 
-import "errors"
+import (
+	"errors"
+
+	"github.com/yourusername/trivia-api/internal/domain/entity"
+)
 
 // Определяем кастомные ошибки для сервисов
 var (
-	ErrQuizNotFound       = errors.New("quiz not found")
-	ErrQuizNotSchedulable = errors.New("quiz cannot be scheduled in its current state")
-	ErrValidation         = errors.New("validation failed")
-	ErrUserNotFound       = errors.New("user not found")
-	ErrUnauthorized       = errors.New("unauthorized")
-	ErrForbidden          = errors.New("forbidden")
+	ErrQuizNotFound         = errors.New("quiz not found")
+	ErrQuizNotActive        = errors.New("quiz is not in progress")
+	ErrQuizAlreadyStarted   = errors.New("quiz has already started")
+	ErrQuizFinished         = errors.New("quiz has already finished")
+	ErrQuizNotSchedulable   = errors.New("quiz cannot be scheduled in its current state")
+	ErrQuizNotReplayable    = errors.New("only completed quizzes with questions can be replayed")
+	ErrReplayInProgress     = errors.New("quiz replay is already running")
+	ErrTooManyActiveQuizzes = errors.New("maximum number of concurrent quizzes reached")
+	ErrValidation           = errors.New("validation failed")
+	ErrUserNotFound         = errors.New("user not found")
+	ErrUnauthorized         = errors.New("unauthorized")
+	ErrForbidden            = errors.New("forbidden")
+	ErrSessionNotFound      = errors.New("session not found")
+	ErrInviteCodeRequired   = errors.New("invite code is required to join this quiz")
+	ErrInvalidInviteCode    = errors.New("invalid invite code")
+	ErrNotQuizParticipant   = errors.New("user is not a participant of this quiz")
+	ErrRemovedFromQuiz      = errors.New("user was removed from this quiz")
+	ErrNotEligible          = errors.New("user is not eligible to join this quiz")
+	ErrJoinRequired         = errors.New("user must join the quiz before getting ready")
 	// Добавьте другие специфичные ошибки по мере необходимости
 )
+
+// ErrInvalidStatusTransition возвращается при недопустимой смене статуса викторины,
+// например при попытке запланировать отмененную викторину
+var ErrInvalidStatusTransition = entity.ErrInvalidStatusTransition
@@ -0,0 +1,199 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/yourusername/trivia-api/internal/domain/entity"
+)
+
+// Заголовки запроса вебхука итоговых результатов
+const (
+	// FinalResultsSignatureHeader содержит HMAC-SHA256 тела запроса: "sha256=<hex>"
+	FinalResultsSignatureHeader = "X-Trivia-Signature"
+	// FinalResultsIDHeader дублирует finalization_id для дедупликации на стороне получателя
+	FinalResultsIDHeader = "Idempotency-Key"
+)
+
+// FinalStanding описывает место одного участника в итоговой таблице
+type FinalStanding struct {
+	UserID         uint   `json:"user_id"`
+	Username       string `json:"username"`
+	Rank           int    `json:"rank"`
+	Score          int    `json:"score"`
+	CorrectAnswers int    `json:"correct_answers"`
+	TotalQuestions int    `json:"total_questions"`
+	IsWinner       bool   `json:"is_winner"`
+	IsEliminated   bool   `json:"is_eliminated"`
+	PrizeFund      int    `json:"prize_fund"`
+}
+
+// FinalResults - окончательные результаты викторины после расчета рангов и призов.
+// Отправляются событием quiz:final_results и вебхуком.
+type FinalResults struct {
+	// FinalizationID одинаков для одних и тех же итогов, поэтому повторная
+	// доставка (ретрай или повторная финализация без изменений) распознается получателем
+	FinalizationID string          `json:"finalization_id"`
+	QuizID         uint            `json:"quiz_id"`
+	FinalizedAt    time.Time       `json:"finalized_at"`
+	TotalPlayers   int             `json:"total_players"`
+	TotalWinners   int             `json:"total_winners"`
+	Standings      []FinalStanding `json:"standings"`
+}
+
+// NewFinalResults собирает итоговую таблицу из сохраненных результатов
+func NewFinalResults(quizID uint, results []entity.Result, finalizedAt time.Time) *FinalResults {
+	standings := make([]FinalStanding, 0, len(results))
+	winners := 0
+	for _, r := range results {
+		if r.IsWinner {
+			winners++
+		}
+		standings = append(standings, FinalStanding{
+			UserID:         r.UserID,
+			Username:       r.Username,
+			Rank:           r.Rank,
+			Score:          r.Score,
+			CorrectAnswers: r.CorrectAnswers,
+			TotalQuestions: r.TotalQuestions,
+			IsWinner:       r.IsWinner,
+			IsEliminated:   r.IsEliminated,
+			PrizeFund:      r.PrizeFund,
+		})
+	}
+	sort.SliceStable(standings, func(i, j int) bool {
+		if standings[i].Rank != standings[j].Rank {
+			return standings[i].Rank < standings[j].Rank
+		}
+		return standings[i].UserID < standings[j].UserID
+	})
+
+	return &FinalResults{
+		FinalizationID: finalizationID(quizID, standings),
+		QuizID:         quizID,
+		FinalizedAt:    finalizedAt,
+		TotalPlayers:   len(standings),
+		TotalWinners:   winners,
+		Standings:      standings,
+	}
+}
+
+// finalizationID вычисляется из содержимого итогов, а не из времени финализации
+func finalizationID(quizID uint, standings []FinalStanding) string {
+	data, _ := json.Marshal(standings)
+	sum := sha256.Sum256(append([]byte(fmt.Sprintf("%d:", quizID)), data...))
+	return fmt.Sprintf("quiz-%d-%s", quizID, hex.EncodeToString(sum[:8]))
+}
+
+// FinalResultsWebhookOptions содержит настройки вебхука итоговых результатов
+type FinalResultsWebhookOptions struct {
+	URL         string        // Адрес получателя
+	Secret      string        // Ключ подписи HMAC-SHA256; пустой - запрос не подписывается
+	MaxAttempts int           // Число попыток доставки
+	Timeout     time.Duration // Таймаут одного запроса
+	RetryDelay  time.Duration // Задержка перед второй попыткой, далее удваивается
+}
+
+// FinalResultsWebhook доставляет итоговые результаты во внешние системы
+// (выдача призов, CRM) с подписью и повторными попытками
+type FinalResultsWebhook struct {
+	options FinalResultsWebhookOptions
+	client  *http.Client
+}
+
+// NewFinalResultsWebhook создает отправителя вебхука
+func NewFinalResultsWebhook(options FinalResultsWebhookOptions) *FinalResultsWebhook {
+	if options.MaxAttempts <= 0 {
+		options.MaxAttempts = 5
+	}
+	if options.Timeout <= 0 {
+		options.Timeout = 10 * time.Second
+	}
+	if options.RetryDelay <= 0 {
+		options.RetryDelay = time.Second
+	}
+
+	return &FinalResultsWebhook{
+		options: options,
+		client:  &http.Client{Timeout: options.Timeout},
+	}
+}
+
+// Sign возвращает значение заголовка подписи для тела запроса
+func (w *FinalResultsWebhook) Sign(body []byte) string {
+	mac := hmac.New(sha256.New, []byte(w.options.Secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Deliver отправляет итоги, повторяя попытку при сетевых ошибках, 5xx и 429.
+// Остальные ответы 4xx считаются окончательным отказом получателя.
+func (w *FinalResultsWebhook) Deliver(ctx context.Context, results *FinalResults) error {
+	body, err := json.Marshal(results)
+	if err != nil {
+		return fmt.Errorf("ошибка сериализации итогов: %w", err)
+	}
+
+	delay := w.options.RetryDelay
+	var lastErr error
+	for attempt := 1; attempt <= w.options.MaxAttempts; attempt++ {
+		retry, err := w.send(ctx, body, results.FinalizationID)
+		if err == nil {
+			log.Printf("[FinalResultsWebhook] Итоги викторины #%d (%s) доставлены с попытки %d",
+				results.QuizID, results.FinalizationID, attempt)
+			return nil
+		}
+		lastErr = err
+		if !retry || attempt == w.options.MaxAttempts {
+			break
+		}
+
+		log.Printf("[FinalResultsWebhook] Попытка %d/%d доставки итогов викторины #%d не удалась: %v. Повтор через %v",
+			attempt, w.options.MaxAttempts, results.QuizID, err, delay)
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		delay *= 2
+	}
+
+	return fmt.Errorf("итоги викторины #%d не доставлены: %w", results.QuizID, lastErr)
+}
+
+// send выполняет одну попытку и сообщает, имеет ли смысл повторять
+func (w *FinalResultsWebhook) send(ctx context.Context, body []byte, finalizationID string) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.options.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(FinalResultsIDHeader, finalizationID)
+	if w.options.Secret != "" {
+		req.Header.Set(FinalResultsSignatureHeader, w.Sign(body))
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return false, nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return true, fmt.Errorf("получатель ответил %d", resp.StatusCode)
+	default:
+		return false, fmt.Errorf("получатель отклонил запрос: %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourusername/trivia-api/internal/domain/entity"
+)
+
+func TestNewFinalResults_SortsByRankWithStableID(t *testing.T) {
+	results := []entity.Result{
+		{UserID: 3, Score: 10, Rank: 2},
+		{UserID: 1, Score: 30, Rank: 1, IsWinner: true, PrizeFund: 1000},
+		{UserID: 2, Score: 10, Rank: 2},
+	}
+
+	final := NewFinalResults(5, results, time.Now())
+	require.Len(t, final.Standings, 3)
+	assert.Equal(t, []uint{1, 2, 3}, []uint{final.Standings[0].UserID, final.Standings[1].UserID, final.Standings[2].UserID})
+	assert.Equal(t, 1, final.TotalWinners)
+
+	// Те же итоги в другом порядке и в другое время - тот же идентификатор
+	again := NewFinalResults(5, []entity.Result{results[2], results[0], results[1]}, time.Now().Add(time.Hour))
+	assert.Equal(t, final.FinalizationID, again.FinalizationID)
+
+	results[0].Score = 11
+	assert.NotEqual(t, final.FinalizationID, NewFinalResults(5, results, time.Now()).FinalizationID)
+}
+
+func TestFinalResultsWebhook_SignsAndRetries(t *testing.T) {
+	var attempts int32
+	webhook := NewFinalResultsWebhook(FinalResultsWebhookOptions{
+		Secret:      "webhook-secret",
+		MaxAttempts: 3,
+		RetryDelay:  time.Millisecond,
+	})
+	final := NewFinalResults(5, []entity.Result{{UserID: 1, Rank: 1}}, time.Now())
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		assert.Equal(t, webhook.Sign(body), r.Header.Get(FinalResultsSignatureHeader))
+		assert.Equal(t, final.FinalizationID, r.Header.Get(FinalResultsIDHeader))
+
+		var received FinalResults
+		assert.NoError(t, json.Unmarshal(body, &received))
+		assert.Equal(t, final.FinalizationID, received.FinalizationID)
+
+		if atomic.AddInt32(&attempts, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	webhook.options.URL = server.URL
+
+	require.NoError(t, webhook.Deliver(context.Background(), final))
+	assert.Equal(t, int32(3), atomic.LoadInt32(&attempts))
+}
+
+func TestFinalResultsWebhook_DoesNotRetryClientErrors(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	webhook := NewFinalResultsWebhook(FinalResultsWebhookOptions{URL: server.URL, RetryDelay: time.Millisecond})
+	err := webhook.Deliver(context.Background(), NewFinalResults(5, nil, time.Now()))
+	assert.Error(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&attempts))
+}
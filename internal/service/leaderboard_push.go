@@ -0,0 +1,226 @@
+package service
+
+import (
+	"encoding/json"
+	"log"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/yourusername/trivia-api/internal/domain/entity"
+	"github.com/yourusername/trivia-api/internal/websocket"
+)
+
+// LeaderboardPushSize - число строк таблицы лидеров, рассылаемой комнате
+// викторины после раскрытия ответа на вопрос
+const LeaderboardPushSize = 100
+
+// LeaderboardSnapshot - полная таблица лидеров (quiz:leaderboard)
+type LeaderboardSnapshot struct {
+	QuizID  uint               `json:"quiz_id"`
+	Version int64              `json:"version"`
+	Results []LeaderboardEntry `json:"results"`
+}
+
+// LeaderboardDelta - изменения таблицы лидеров версии BaseVersion, после
+// применения которых получается таблица версии Version (quiz:leaderboard_delta)
+type LeaderboardDelta struct {
+	QuizID      uint               `json:"quiz_id"`
+	Version     int64              `json:"version"`
+	BaseVersion int64              `json:"base_version"`
+	Changed     []LeaderboardEntry `json:"changed"` // Новые строки и строки, у которых изменились место, счет или выбывание
+	Removed     []uint             `json:"removed"` // Игроки, выпавшие из таблицы
+}
+
+// diffLeaderboard находит строки next, которых нет в prev или которые
+// изменились, и игроков prev, которых нет в next
+func diffLeaderboard(prev, next []LeaderboardEntry) (changed []LeaderboardEntry, removed []uint) {
+	previous := make(map[uint]LeaderboardEntry, len(prev))
+	for _, entry := range prev {
+		previous[entry.UserID] = entry
+	}
+	current := make(map[uint]bool, len(next))
+	changed = []LeaderboardEntry{}
+	for _, entry := range next {
+		current[entry.UserID] = true
+		if old, ok := previous[entry.UserID]; !ok || old != entry {
+			changed = append(changed, entry)
+		}
+	}
+	removed = []uint{}
+	for _, entry := range prev {
+		if !current[entry.UserID] {
+			removed = append(removed, entry.UserID)
+		}
+	}
+	return changed, removed
+}
+
+// ApplyLeaderboardDelta применяет изменения к таблице версии delta.BaseVersion
+// так же, как это делает клиент: выпавшие игроки удаляются, измененные строки
+// заменяются, новые добавляются, строки упорядочиваются по месту, а при
+// равных местах - по ID игрока. Исходная таблица не меняется.
+func ApplyLeaderboardDelta(board []LeaderboardEntry, delta *LeaderboardDelta) []LeaderboardEntry {
+	byUser := make(map[uint]LeaderboardEntry, len(board)+len(delta.Changed))
+	for _, entry := range board {
+		byUser[entry.UserID] = entry
+	}
+	for _, userID := range delta.Removed {
+		delete(byUser, userID)
+	}
+	for _, entry := range delta.Changed {
+		byUser[entry.UserID] = entry
+	}
+
+	result := make([]LeaderboardEntry, 0, len(byUser))
+	for _, entry := range byUser {
+		result = append(result, entry)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Rank != result[j].Rank {
+			return result[i].Rank < result[j].Rank
+		}
+		return result[i].UserID < result[j].UserID
+	})
+	return result
+}
+
+// pushedLeaderboard - последняя разосланная таблица лидеров викторины
+type pushedLeaderboard struct {
+	version int64
+	entries []LeaderboardEntry
+}
+
+// nextLeaderboardEvent выбирает событие для рассылки таблицы entries после
+// таблицы prev (nil - таблица еще не рассылалась). Изменения отправляются,
+// только если они короче полной таблицы; без изменений событие не нужно (ok = false).
+func nextLeaderboardEvent(quizID uint, prev *pushedLeaderboard, entries []LeaderboardEntry, deltas bool) (eventType string, data interface{}, next *pushedLeaderboard, ok bool) {
+	next = &pushedLeaderboard{version: 1, entries: entries}
+	full := &LeaderboardSnapshot{QuizID: quizID, Results: entries}
+	if prev == nil {
+		full.Version = next.version
+		return "quiz:leaderboard", full, next, true
+	}
+
+	changed, removed := diffLeaderboard(prev.entries, entries)
+	if len(changed) == 0 && len(removed) == 0 {
+		return "", nil, prev, false
+	}
+	next.version = prev.version + 1
+	full.Version = next.version
+	if !deltas {
+		return "quiz:leaderboard", full, next, true
+	}
+
+	delta := &LeaderboardDelta{
+		QuizID:      quizID,
+		Version:     next.version,
+		BaseVersion: prev.version,
+		Changed:     changed,
+		Removed:     removed,
+	}
+	deltaJSON, deltaErr := json.Marshal(delta)
+	fullJSON, fullErr := json.Marshal(full)
+	if deltaErr != nil || fullErr != nil || len(deltaJSON) >= len(fullJSON) {
+		return "quiz:leaderboard", full, next, true
+	}
+	return "quiz:leaderboard_delta", delta, next, true
+}
+
+// LeaderboardPusher рассылает комнате викторины таблицу лидеров после
+// раскрытия ответа на каждый вопрос. Первая рассылка - полная таблица, затем,
+// если включены изменения, только отличия от предыдущей рассылки. Клиент
+// хранит таблицу и применяет к ней изменения по версиям; новому игроку и
+// клиенту, пропустившему версию, отправляется полная таблица.
+type LeaderboardPusher struct {
+	resultService *ResultService
+	wsManager     *websocket.Manager
+
+	mu     sync.Mutex
+	deltas bool
+	boards map[uint]*pushedLeaderboard
+}
+
+// NewLeaderboardPusher создает рассылку таблицы лидеров. Изменения выключены:
+// каждый раз рассылается полная таблица.
+func NewLeaderboardPusher(resultService *ResultService, wsManager *websocket.Manager) *LeaderboardPusher {
+	return &LeaderboardPusher{
+		resultService: resultService,
+		wsManager:     wsManager,
+		boards:        make(map[uint]*pushedLeaderboard),
+	}
+}
+
+// SetDeltas включает или выключает рассылку изменений вместо полной таблицы
+func (p *LeaderboardPusher) SetDeltas(enabled bool) {
+	p.mu.Lock()
+	p.deltas = enabled
+	p.mu.Unlock()
+}
+
+// Publish считает таблицу лидеров викторины по сохраненным ответам и
+// рассылает ее или ее изменения комнате викторины
+func (p *LeaderboardPusher) Publish(quiz *entity.Quiz) {
+	if p.resultService == nil || p.wsManager == nil {
+		return
+	}
+
+	// Имена игроков, уже бывших в таблице, не запрашиваются повторно
+	p.mu.Lock()
+	var knownNames map[uint]string
+	if prev := p.boards[quiz.ID]; prev != nil {
+		knownNames = make(map[uint]string, len(prev.entries))
+		for _, entry := range prev.entries {
+			knownNames[entry.UserID] = entry.Username
+		}
+	}
+	p.mu.Unlock()
+
+	entries, err := p.resultService.liveLeaderboard(quiz, 0, LeaderboardPushSize, knownNames)
+	if err != nil {
+		log.Printf("[LeaderboardPusher] Не удалось посчитать таблицу лидеров викторины #%d: %v", quiz.ID, err)
+		return
+	}
+
+	p.mu.Lock()
+	eventType, data, next, ok := nextLeaderboardEvent(quiz.ID, p.boards[quiz.ID], entries, p.deltas)
+	p.boards[quiz.ID] = next
+	p.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	fullEvent := map[string]interface{}{
+		"type": eventType,
+		"data": data,
+	}
+	if err := p.wsManager.BroadcastEventToQuiz(quiz.ID, fullEvent); err != nil {
+		log.Printf("[LeaderboardPusher] Ошибка при рассылке %s викторины #%d: %v", eventType, quiz.ID, err)
+	}
+}
+
+// SendFull отправляет игроку последнюю разосланную полную таблицу лидеров
+// викторины. Если таблица еще не рассылалась, ничего не отправляется.
+func (p *LeaderboardPusher) SendFull(quizID, userID uint) {
+	if p.wsManager == nil {
+		return
+	}
+	p.mu.Lock()
+	board := p.boards[quizID]
+	p.mu.Unlock()
+	if board == nil {
+		return
+	}
+
+	snapshot := &LeaderboardSnapshot{QuizID: quizID, Version: board.version, Results: board.entries}
+	if err := p.wsManager.SendEventToUser(strconv.FormatUint(uint64(userID), 10), "quiz:leaderboard", snapshot); err != nil {
+		log.Printf("[LeaderboardPusher] Ошибка при отправке таблицы лидеров викторины #%d пользователю #%d: %v", quizID, userID, err)
+	}
+}
+
+// Forget удаляет сохраненную таблицу завершенной викторины
+func (p *LeaderboardPusher) Forget(quizID uint) {
+	p.mu.Lock()
+	delete(p.boards, quizID)
+	p.mu.Unlock()
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourusername/trivia-api/internal/domain/entity"
+)
+
+// TestLeaderboardDeltas_ReconstructBoard: клиент, применяющий изменения к
+// хранимой таблице, после каждого вопроса получает ту же таблицу, что и сервер
+func TestLeaderboardDeltas_ReconstructBoard(t *testing.T) {
+	quiz := &entity.Quiz{ID: 1}
+	random := rand.New(rand.NewSource(42))
+
+	var answers []entity.UserAnswer
+	var server *pushedLeaderboard
+	var client []LeaderboardEntry
+	var clientVersion int64
+	deltasSent := 0
+
+	for questionID := uint(1); questionID <= 10; questionID++ {
+		for userID := uint(1); userID <= 40; userID++ {
+			correct := random.Intn(3) > 0
+			answer := entity.UserAnswer{UserID: userID, QuestionID: questionID, IsCorrect: correct}
+			if correct {
+				answer.Score = random.Intn(10) + 1
+			}
+			answers = append(answers, answer)
+		}
+		board := rankLiveAnswers(quiz, answers, 0, 15)
+		for i := range board {
+			board[i].Username = fmt.Sprintf("player%d", board[i].UserID)
+		}
+
+		eventType, data, next, ok := nextLeaderboardEvent(quiz.ID, server, board, true)
+		server = next
+		if !ok {
+			continue
+		}
+		switch event := data.(type) {
+		case *LeaderboardSnapshot:
+			assert.Equal(t, "quiz:leaderboard", eventType)
+			client, clientVersion = event.Results, event.Version
+		case *LeaderboardDelta:
+			assert.Equal(t, "quiz:leaderboard_delta", eventType)
+			require.Equal(t, clientVersion, event.BaseVersion, "изменения применяются к хранимой версии")
+			client, clientVersion = ApplyLeaderboardDelta(client, event), event.Version
+			deltasSent++
+		}
+		assert.Equal(t, board, client, "таблица после вопроса %d", questionID)
+		assert.Equal(t, server.version, clientVersion)
+	}
+	assert.Positive(t, deltasSent, "таблица обновлялась изменениями")
+}
+
+func TestNextLeaderboardEvent(t *testing.T) {
+	board := []LeaderboardEntry{
+		{Rank: 1, UserID: 1, Username: "alice", Score: 30, CorrectAnswers: 3},
+		{Rank: 2, UserID: 2, Username: "bob", Score: 20, CorrectAnswers: 2},
+		{Rank: 3, UserID: 3, Username: "carol", Score: 10, CorrectAnswers: 1},
+	}
+
+	t.Run("первая рассылка - полная таблица", func(t *testing.T) {
+		eventType, data, next, ok := nextLeaderboardEvent(1, nil, board, true)
+		require.True(t, ok)
+		assert.Equal(t, "quiz:leaderboard", eventType)
+		assert.Equal(t, &LeaderboardSnapshot{QuizID: 1, Version: 1, Results: board}, data)
+		assert.Equal(t, int64(1), next.version)
+	})
+
+	prev := &pushedLeaderboard{version: 4, entries: board}
+
+	t.Run("без изменений ничего не рассылается", func(t *testing.T) {
+		_, _, next, ok := nextLeaderboardEvent(1, prev, append([]LeaderboardEntry(nil), board...), true)
+		assert.False(t, ok)
+		assert.Same(t, prev, next, "версия не меняется")
+	})
+
+	t.Run("изменение одного места", func(t *testing.T) {
+		updated := append([]LeaderboardEntry(nil), board...)
+		updated[2].Score, updated[2].CorrectAnswers = 15, 2
+		eventType, data, next, ok := nextLeaderboardEvent(1, prev, updated, true)
+		require.True(t, ok)
+		assert.Equal(t, "quiz:leaderboard_delta", eventType)
+		assert.Equal(t, &LeaderboardDelta{
+			QuizID: 1, Version: 5, BaseVersion: 4,
+			Changed: []LeaderboardEntry{updated[2]},
+			Removed: []uint{},
+		}, data)
+		assert.Equal(t, int64(5), next.version)
+	})
+
+	t.Run("изменения длиннее полной таблицы", func(t *testing.T) {
+		reshuffled := []LeaderboardEntry{
+			{Rank: 1, UserID: 3, Username: "carol", Score: 40, CorrectAnswers: 4},
+			{Rank: 2, UserID: 4, Username: "dave", Score: 35, CorrectAnswers: 4},
+		}
+		eventType, data, _, ok := nextLeaderboardEvent(1, prev, reshuffled, true)
+		require.True(t, ok)
+		assert.Equal(t, "quiz:leaderboard", eventType)
+		assert.Equal(t, &LeaderboardSnapshot{QuizID: 1, Version: 5, Results: reshuffled}, data)
+	})
+
+	t.Run("изменения выключены", func(t *testing.T) {
+		updated := append([]LeaderboardEntry(nil), board...)
+		updated[2].Score = 15
+		eventType, data, _, ok := nextLeaderboardEvent(1, prev, updated, false)
+		require.True(t, ok)
+		assert.Equal(t, "quiz:leaderboard", eventType)
+		assert.Equal(t, int64(5), data.(*LeaderboardSnapshot).Version)
+	})
+}
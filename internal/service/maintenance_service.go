@@ -0,0 +1,110 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/yourusername/trivia-api/internal/domain/repository"
+)
+
+// maintenanceKey - ключ Redis с состоянием режима обслуживания (общий для всех экземпляров)
+const maintenanceKey = "system:maintenance"
+
+// Как долго экземпляр использует прочитанное из Redis состояние. Проверка
+// выполняется при каждом подключении к WebSocket, поэтому Redis не опрашивается
+// на каждый запрос; включение доходит до всех экземпляров за это время.
+const maintenanceStateTTL = 2 * time.Second
+
+// MaintenanceState описывает режим обслуживания
+type MaintenanceState struct {
+	Enabled   bool       `json:"enabled"`
+	Message   string     `json:"message,omitempty"`
+	Since     *time.Time `json:"since,omitempty"`
+	EnabledBy uint       `json:"enabled_by,omitempty"`
+}
+
+// MaintenanceService управляет режимом обслуживания перед развертыванием:
+// новые викторины и подключения к WebSocket не принимаются, а уже идущие
+// викторины и открытые соединения продолжают работать
+type MaintenanceService struct {
+	cacheRepo repository.CacheRepository
+
+	mu       sync.Mutex
+	state    MaintenanceState
+	loadedAt time.Time
+}
+
+// NewMaintenanceService создает сервис режима обслуживания
+func NewMaintenanceService(cacheRepo repository.CacheRepository) *MaintenanceService {
+	return &MaintenanceService{cacheRepo: cacheRepo}
+}
+
+// Enable включает режим обслуживания на всех экземплярах
+func (s *MaintenanceService) Enable(userID uint, message string) (MaintenanceState, error) {
+	now := time.Now()
+	state := MaintenanceState{Enabled: true, Message: message, Since: &now, EnabledBy: userID}
+	if err := s.cacheRepo.SetJSON(maintenanceKey, state, 0); err != nil {
+		return MaintenanceState{}, fmt.Errorf("failed to enable maintenance mode: %w", err)
+	}
+	s.remember(state)
+	log.Printf("[MaintenanceService] Режим обслуживания включен пользователем #%d: %s", userID, message)
+	return state, nil
+}
+
+// Disable выключает режим обслуживания на всех экземплярах
+func (s *MaintenanceService) Disable() (MaintenanceState, error) {
+	if err := s.cacheRepo.Delete(maintenanceKey); err != nil {
+		return MaintenanceState{}, fmt.Errorf("failed to disable maintenance mode: %w", err)
+	}
+	state := MaintenanceState{}
+	s.remember(state)
+	log.Println("[MaintenanceService] Режим обслуживания выключен")
+	return state, nil
+}
+
+// State возвращает текущее состояние режима обслуживания. Если Redis недоступен,
+// возвращается последнее известное состояние.
+func (s *MaintenanceService) State() MaintenanceState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.loadedAt.IsZero() && time.Since(s.loadedAt) < maintenanceStateTTL {
+		return s.state
+	}
+
+	state, err := s.load()
+	if err != nil {
+		log.Printf("[MaintenanceService] WARNING: Не удалось прочитать режим обслуживания: %v", err)
+		return s.state
+	}
+	s.state, s.loadedAt = state, time.Now()
+	return state
+}
+
+// IsEnabled сообщает, включен ли режим обслуживания
+func (s *MaintenanceService) IsEnabled() bool {
+	return s.State().Enabled
+}
+
+// load читает состояние из Redis; отсутствие ключа означает, что режим выключен
+func (s *MaintenanceService) load() (MaintenanceState, error) {
+	var state MaintenanceState
+	exists, err := s.cacheRepo.Exists(maintenanceKey)
+	if err != nil || !exists {
+		return state, err
+	}
+	if err := s.cacheRepo.GetJSON(maintenanceKey, &state); err != nil {
+		return MaintenanceState{}, err
+	}
+	return state, nil
+}
+
+// remember сохраняет только что записанное состояние, чтобы этот экземпляр
+// применил его сразу, не дожидаясь истечения maintenanceStateTTL
+func (s *MaintenanceService) remember(state MaintenanceState) {
+	s.mu.Lock()
+	s.state, s.loadedAt = state, time.Now()
+	s.mu.Unlock()
+}
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourusername/trivia-api/internal/domain/entity"
+	"github.com/yourusername/trivia-api/internal/domain/repository"
+)
+
+// jsonCache - общий для нескольких экземпляров кеш, как Redis
+type jsonCache struct {
+	repository.CacheRepository
+	data map[string][]byte
+	down bool
+}
+
+func (c *jsonCache) SetJSON(key string, value interface{}, expiration time.Duration) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	c.data[key] = data
+	return nil
+}
+
+func (c *jsonCache) GetJSON(key string, dest interface{}) error {
+	data, ok := c.data[key]
+	if !ok {
+		return errors.New("key not found")
+	}
+	return json.Unmarshal(data, dest)
+}
+
+func (c *jsonCache) Exists(key string) (bool, error) {
+	if c.down {
+		return false, errors.New("redis: connection refused")
+	}
+	_, ok := c.data[key]
+	return ok, nil
+}
+
+func (c *jsonCache) Delete(key string) error {
+	delete(c.data, key)
+	return nil
+}
+
+func TestMaintenanceService_SharedAcrossInstances(t *testing.T) {
+	cache := &jsonCache{data: make(map[string][]byte)}
+	first, second := NewMaintenanceService(cache), NewMaintenanceService(cache)
+	assert.False(t, second.IsEnabled())
+
+	state, err := first.Enable(1, "Обновление сервера")
+	require.NoError(t, err)
+	assert.True(t, state.Enabled)
+	assert.True(t, first.IsEnabled(), "включивший экземпляр применяет режим сразу")
+
+	// Второй экземпляр видит изменение после истечения локального кеша
+	second.loadedAt = time.Time{}
+	got := second.State()
+	assert.True(t, got.Enabled)
+	assert.Equal(t, "Обновление сервера", got.Message)
+	assert.Equal(t, uint(1), got.EnabledBy)
+
+	_, err = first.Disable()
+	require.NoError(t, err)
+	assert.False(t, first.IsEnabled())
+	second.loadedAt = time.Time{}
+	assert.False(t, second.IsEnabled())
+}
+
+func TestMaintenanceService_KeepsLastStateWhenRedisUnavailable(t *testing.T) {
+	cache := &jsonCache{data: make(map[string][]byte)}
+	s := NewMaintenanceService(cache)
+	_, err := s.Enable(1, "")
+	require.NoError(t, err)
+
+	cache.down = true
+	s.loadedAt = time.Time{}
+	assert.True(t, s.IsEnabled())
+}
+
+func TestRecurrenceService_SkipsDuringMaintenance(t *testing.T) {
+	s, _, quizRepo, _ := newRecurrenceFixture(entity.Quiz{
+		ID: 1, ScheduledTime: time.Now().Add(-time.Hour), Status: "completed", RecurrenceIntervalMin: 60,
+	})
+	maintenance := NewMaintenanceService(&jsonCache{data: make(map[string][]byte)})
+	s.SetMaintenance(maintenance)
+	_, err := maintenance.Enable(1, "")
+	require.NoError(t, err)
+
+	created, err := s.Run(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, created, "в режиме обслуживания новые викторины серий не создаются")
+
+	_, err = maintenance.Disable()
+	require.NoError(t, err)
+	created, err = s.Run(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, created)
+	assert.Len(t, quizRepo.quizzes, 2)
+}
@@ -0,0 +1,102 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/yourusername/trivia-api/internal/domain/repository"
+	"github.com/yourusername/trivia-api/internal/websocket"
+)
+
+// notificationPrefsKeyPrefix - префикс ключа Redis с настройками уведомлений пользователя
+const notificationPrefsKeyPrefix = "user:notification_prefs:"
+
+// NotificationPrefs - какие необязательные системные события пользователь получает
+// по WebSocket. По умолчанию все включены. Критичные события (TOKEN_EXPIRED,
+// принудительное отключение сессии) доставляются всегда.
+type NotificationPrefs struct {
+	// TOKEN_EXPIRE_SOON - скорое истечение access-токена
+	TokenExpiryWarnings bool `json:"token_expiry_warnings"`
+	// REFRESH_TOKEN_EXPIRE_SOON - скоро потребуется повторный вход
+	SessionExpiryWarnings bool `json:"session_expiry_warnings"`
+	// События system:* (объявления и прочие служебные сообщения)
+	SystemEvents bool `json:"system_events"`
+}
+
+// DefaultNotificationPrefs возвращает настройки пользователя, который их не менял
+func DefaultNotificationPrefs() NotificationPrefs {
+	return NotificationPrefs{TokenExpiryWarnings: true, SessionExpiryWarnings: true, SystemEvents: true}
+}
+
+// NotificationPrefsService хранит настройки уведомлений пользователей в Redis
+// и решает, доставлять ли пользователю необязательное системное событие
+type NotificationPrefsService struct {
+	cacheRepo repository.CacheRepository
+}
+
+// Проверка компилятором, что сервис подходит фильтру уведомлений WebSocket
+var _ websocket.NotificationFilter = (*NotificationPrefsService)(nil)
+
+// NewNotificationPrefsService создает сервис настроек уведомлений
+func NewNotificationPrefsService(cacheRepo repository.CacheRepository) *NotificationPrefsService {
+	return &NotificationPrefsService{cacheRepo: cacheRepo}
+}
+
+// Get возвращает настройки пользователя; если они не сохранялись - настройки по умолчанию
+func (s *NotificationPrefsService) Get(userID uint) (NotificationPrefs, error) {
+	key := notificationPrefsKey(userID)
+	exists, err := s.cacheRepo.Exists(key)
+	if err != nil {
+		return NotificationPrefs{}, fmt.Errorf("failed to get notification preferences: %w", err)
+	}
+	if !exists {
+		return DefaultNotificationPrefs(), nil
+	}
+
+	var prefs NotificationPrefs
+	if err := s.cacheRepo.GetJSON(key, &prefs); err != nil {
+		return NotificationPrefs{}, fmt.Errorf("failed to get notification preferences: %w", err)
+	}
+	return prefs, nil
+}
+
+// Update сохраняет настройки пользователя без срока действия
+func (s *NotificationPrefsService) Update(userID uint, prefs NotificationPrefs) (NotificationPrefs, error) {
+	if err := s.cacheRepo.SetJSON(notificationPrefsKey(userID), prefs, 0); err != nil {
+		return NotificationPrefs{}, fmt.Errorf("failed to save notification preferences: %w", err)
+	}
+	log.Printf("[NotificationPrefs] Настройки уведомлений пользователя #%d обновлены: %+v", userID, prefs)
+	return prefs, nil
+}
+
+// AllowsNotification сообщает, нужно ли доставлять событие eventType пользователю.
+// Вызывается только для отключаемых событий; если настройки прочитать не удалось,
+// событие доставляется - лишнее уведомление лучше потерянного.
+func (s *NotificationPrefsService) AllowsNotification(userID string, eventType string) bool {
+	id, err := strconv.ParseUint(userID, 10, 64)
+	if err != nil {
+		return true
+	}
+	prefs, err := s.Get(uint(id))
+	if err != nil {
+		log.Printf("[NotificationPrefs] WARNING: Не удалось прочитать настройки пользователя #%s: %v", userID, err)
+		return true
+	}
+
+	switch {
+	case eventType == websocket.TOKEN_EXPIRE_SOON:
+		return prefs.TokenExpiryWarnings
+	case eventType == websocket.REFRESH_TOKEN_EXPIRE_SOON:
+		return prefs.SessionExpiryWarnings
+	case strings.HasPrefix(eventType, websocket.SystemEventPrefix):
+		return prefs.SystemEvents
+	default:
+		return true
+	}
+}
+
+func notificationPrefsKey(userID uint) string {
+	return notificationPrefsKeyPrefix + strconv.FormatUint(uint64(userID), 10)
+}
@@ -0,0 +1,33 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourusername/trivia-api/internal/websocket"
+)
+
+func TestNotificationPrefsService(t *testing.T) {
+	cache := &jsonCache{data: map[string][]byte{}}
+	prefs := NewNotificationPrefsService(cache)
+
+	// Без сохраненных настроек доставляется все
+	current, err := prefs.Get(5)
+	require.NoError(t, err)
+	assert.Equal(t, DefaultNotificationPrefs(), current)
+	assert.True(t, prefs.AllowsNotification("5", websocket.TOKEN_EXPIRE_SOON))
+
+	_, err = prefs.Update(5, NotificationPrefs{SessionExpiryWarnings: true})
+	require.NoError(t, err)
+	assert.False(t, prefs.AllowsNotification("5", websocket.TOKEN_EXPIRE_SOON))
+	assert.True(t, prefs.AllowsNotification("5", websocket.REFRESH_TOKEN_EXPIRE_SOON))
+	assert.False(t, prefs.AllowsNotification("5", "system:announcement"))
+	assert.True(t, prefs.AllowsNotification("6", websocket.TOKEN_EXPIRE_SOON), "настройки хранятся отдельно для каждого пользователя")
+
+	// Если Redis недоступен, уведомление доставляется
+	cache.down = true
+	_, err = prefs.Get(5)
+	assert.Error(t, err)
+	assert.True(t, prefs.AllowsNotification("5", websocket.TOKEN_EXPIRE_SOON))
+}
@@ -0,0 +1,417 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/yourusername/trivia-api/internal/domain/entity"
+)
+
+// QuizExportFormatVersion - версия формата экспорта викторины. Увеличивается
+// при несовместимых изменениях: импорт принимает только известную версию.
+const QuizExportFormatVersion = 1
+
+// Ограничения полей импорта совпадают с созданием викторины и добавлением
+// вопросов (CreateQuizRequest, AddQuestionsRequest)
+const (
+	importMinTitle        = 3
+	importMaxTitle        = 100
+	importMaxDescription  = 500
+	importMinQuestionText = 3
+	importMaxQuestionText = 500
+	importMaxOptionText   = 200
+	importMinTimeLimitSec = 5
+	importMaxTimeLimitSec = 60
+	importMaxPointValue   = 100
+)
+
+// QuizExport - самодостаточное описание викторины: настройки, вопросы и
+// правильные ответы. Данные проведения (результаты, ответы, повторение серии,
+// код приглашения) не экспортируются.
+type QuizExport struct {
+	FormatVersion int                  `json:"format_version"`
+	ExportedAt    time.Time            `json:"exported_at"`
+	Quiz          QuizExportSettings   `json:"quiz"`
+	Questions     []QuizExportQuestion `json:"questions"`
+}
+
+// QuizExportSettings - настройки викторины в экспорте
+type QuizExportSettings struct {
+	Title         string    `json:"title"`
+	Description   string    `json:"description"`
+	ScheduledTime time.Time `json:"scheduled_time"`
+
+	DelayedResults         bool   `json:"delayed_results"`
+	SuppressAnswerFeedback bool   `json:"suppress_answer_feedback"`
+	HideCorrectAnswer      bool   `json:"hide_correct_answer"`
+	JoinPolicy             string `json:"join_policy"`
+	Visibility             string `json:"visibility"`
+
+	RequireVerifiedEmail     bool `json:"require_verified_email"`
+	MinGamesPlayed           int  `json:"min_games_played"`
+	ExternalEligibilityCheck bool `json:"external_eligibility_check"`
+
+	PacingMode            string `json:"pacing_mode"`
+	SelfPacedTimeLimitSec int    `json:"self_paced_time_limit_sec"`
+	AutoAdvance           bool   `json:"auto_advance"`
+	AnswerWindowFromAck   bool   `json:"answer_window_from_ack"`
+	QuestionReadTimeSec   int    `json:"question_read_time_sec"`
+
+	MinPlayers       int    `json:"min_players"`
+	MinPlayersPolicy string `json:"min_players_policy"`
+	MaxPlayers       int    `json:"max_players"`
+	Lives            int    `json:"lives,omitempty"`
+
+	UniformPointValue  int     `json:"uniform_point_value"`
+	PointsMultiplier   float64 `json:"points_multiplier"`
+	WrongAnswerPenalty int     `json:"wrong_answer_penalty"`
+	ScoreFloor         int     `json:"score_floor"`
+	FastestFingerBonus int     `json:"fastest_finger_bonus"`
+
+	DifficultyScaling     bool                          `json:"difficulty_scaling,omitempty"`
+	DifficultyMultipliers *entity.DifficultyMultipliers `json:"difficulty_multipliers,omitempty"`
+}
+
+// QuizExportQuestion - вопрос в экспорте вместе с правильным ответом
+type QuizExportQuestion struct {
+	Type          string   `json:"type"`
+	Text          string   `json:"text"`
+	Options       []string `json:"options"`
+	CorrectOption int      `json:"correct_option,omitempty"`
+	CorrectOrder  []int    `json:"correct_order,omitempty"`
+	ScoringMethod string   `json:"scoring_method,omitempty"`
+	TimeLimitSec  int      `json:"time_limit_sec"`
+	PointValue    int      `json:"point_value"`
+	Difficulty    string   `json:"difficulty,omitempty"`
+}
+
+// QuizImportFieldError описывает ошибку в поле импортируемой викторины
+type QuizImportFieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// QuizImportError - все ошибки проверки импортируемой викторины
+type QuizImportError struct {
+	Fields []QuizImportFieldError
+}
+
+func (e *QuizImportError) Error() string {
+	return fmt.Sprintf("%v: quiz import has %d invalid fields, first: %s %s",
+		ErrValidation, len(e.Fields), e.Fields[0].Field, e.Fields[0].Message)
+}
+
+// Unwrap позволяет проверять ошибку через errors.Is(err, ErrValidation)
+func (e *QuizImportError) Unwrap() error {
+	return ErrValidation
+}
+
+func (e *QuizImportError) add(field, format string, args ...interface{}) {
+	e.Fields = append(e.Fields, QuizImportFieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// ExportQuiz возвращает описание викторины с вопросами и правильными ответами
+func (s *QuizService) ExportQuiz(quizID uint) (*QuizExport, error) {
+	quiz, err := s.quizRepo.GetByID(quizID)
+	if err != nil {
+		return nil, quizLookupError(quizID, err)
+	}
+	questions, err := s.questionRepo.GetByQuizID(quizID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get questions of quiz %d: %w", quizID, err)
+	}
+
+	export := &QuizExport{
+		FormatVersion: QuizExportFormatVersion,
+		ExportedAt:    time.Now().UTC(),
+		Quiz: QuizExportSettings{
+			Title:         quiz.Title,
+			Description:   quiz.Description,
+			ScheduledTime: quiz.ScheduledTime,
+
+			DelayedResults:         quiz.DelayedResults,
+			SuppressAnswerFeedback: quiz.SuppressAnswerFeedback,
+			HideCorrectAnswer:      quiz.HideCorrectAnswer,
+			JoinPolicy:             quiz.JoinPolicy,
+			Visibility:             quiz.Visibility,
+
+			RequireVerifiedEmail:     quiz.RequireVerifiedEmail,
+			MinGamesPlayed:           quiz.MinGamesPlayed,
+			ExternalEligibilityCheck: quiz.ExternalEligibilityCheck,
+
+			PacingMode:            quiz.PacingMode,
+			SelfPacedTimeLimitSec: quiz.SelfPacedTimeLimitSec,
+			AutoAdvance:           quiz.AutoAdvance,
+			AnswerWindowFromAck:   quiz.AnswerWindowFromAck,
+			QuestionReadTimeSec:   quiz.QuestionReadTimeSec,
+
+			MinPlayers:       quiz.MinPlayers,
+			MinPlayersPolicy: quiz.MinPlayersPolicy,
+			MaxPlayers:       quiz.MaxPlayers,
+			Lives:            quiz.EliminationLives(),
+
+			UniformPointValue:  quiz.UniformPointValue,
+			PointsMultiplier:   quiz.PointsMultiplier,
+			WrongAnswerPenalty: quiz.WrongAnswerPenalty,
+			ScoreFloor:         quiz.ScoreFloor,
+			FastestFingerBonus: quiz.FastestFingerBonus,
+
+			DifficultyScaling: quiz.DifficultyScaling,
+		},
+		Questions: make([]QuizExportQuestion, len(questions)),
+	}
+	for i, q := range questions {
+		export.Questions[i] = QuizExportQuestion{
+			Type:          q.Type,
+			Text:          q.Text,
+			Options:       append([]string(nil), q.Options...),
+			CorrectOption: q.CorrectOption,
+			CorrectOrder:  append([]int(nil), q.CorrectOrder...),
+			ScoringMethod: q.ScoringMethod,
+			TimeLimitSec:  q.TimeLimitSec,
+			PointValue:    q.PointValue,
+			Difficulty:    q.Difficulty,
+		}
+	}
+	if quiz.DifficultyScaling {
+		multipliers := quiz.DifficultyMultipliers
+		export.Quiz.DifficultyMultipliers = &multipliers
+	}
+	return export, nil
+}
+
+// ImportQuiz создает новую запланированную викторину из экспорта. Все поля
+// проверяются до записи, ошибки возвращаются списком в *QuizImportError.
+// Время проведения в прошлом сдвигается, как при копировании, на целое число
+// недель до ближайшего момента в будущем. Код приглашения приватной викторины
+// выпускается заново. Планирование в QuizManager выполняется отдельно.
+func (s *QuizService) ImportQuiz(export *QuizExport) (*entity.Quiz, error) {
+	importErr := &QuizImportError{}
+	if export.FormatVersion != QuizExportFormatVersion {
+		importErr.add("format_version", "unsupported format version %d, expected %d", export.FormatVersion, QuizExportFormatVersion)
+		return nil, importErr
+	}
+
+	settings := export.Quiz
+	settings.Title = strings.TrimSpace(settings.Title)
+	settings.Description = strings.TrimSpace(settings.Description)
+	checkImportLength(importErr, "quiz.title", settings.Title, importMinTitle, importMaxTitle)
+	checkImportLength(importErr, "quiz.description", settings.Description, 0, importMaxDescription)
+
+	now := time.Now()
+	scheduledTime := settings.ScheduledTime
+	switch {
+	case scheduledTime.IsZero():
+		importErr.add("quiz.scheduled_time", "is required")
+	case !scheduledTime.After(now):
+		scheduledTime = nextWeeklyOccurrence(scheduledTime, now)
+	default:
+		if err := s.validateScheduledTime(scheduledTime, now); err != nil {
+			importErr.add("quiz.scheduled_time", "%s", validationMessage(err))
+		}
+	}
+
+	format := QuizFormatOptions{
+		DelayedResults:           settings.DelayedResults,
+		SuppressAnswerFeedback:   settings.SuppressAnswerFeedback,
+		HideCorrectAnswer:        settings.HideCorrectAnswer,
+		JoinPolicy:               settings.JoinPolicy,
+		Visibility:               settings.Visibility,
+		RequireVerifiedEmail:     settings.RequireVerifiedEmail,
+		MinGamesPlayed:           settings.MinGamesPlayed,
+		ExternalEligibilityCheck: settings.ExternalEligibilityCheck,
+		PacingMode:               settings.PacingMode,
+		SelfPacedTimeLimitSec:    settings.SelfPacedTimeLimitSec,
+		AutoAdvance:              settings.AutoAdvance,
+		AnswerWindowFromAck:      settings.AnswerWindowFromAck,
+		QuestionReadTimeSec:      settings.QuestionReadTimeSec,
+		MinPlayers:               settings.MinPlayers,
+		MinPlayersPolicy:         settings.MinPlayersPolicy,
+		MaxPlayers:               settings.MaxPlayers,
+		Lives:                    settings.Lives,
+	}
+	if err := format.validate(); err != nil {
+		importErr.add("quiz", "%s", validationMessage(err))
+	}
+	scoring := QuizScoringOptions{
+		UniformPointValue:  settings.UniformPointValue,
+		PointsMultiplier:   settings.PointsMultiplier,
+		WrongAnswerPenalty: settings.WrongAnswerPenalty,
+		ScoreFloor:         settings.ScoreFloor,
+		FastestFingerBonus: settings.FastestFingerBonus,
+		DifficultyScaling:  settings.DifficultyScaling,
+	}
+	if settings.DifficultyMultipliers != nil {
+		scoring.DifficultyMultipliers = *settings.DifficultyMultipliers
+	}
+	if err := scoring.validate(); err != nil {
+		importErr.add("quiz", "%s", validationMessage(err))
+	} else if format.PacingMode == entity.PacingSelfPaced && scoring.FastestFingerBonus > 0 {
+		importErr.add("quiz.fastest_finger_bonus", "is not supported for %s pacing", entity.PacingSelfPaced)
+	}
+
+	questions := s.importQuestions(importErr, export.Questions)
+	if len(importErr.Fields) > 0 {
+		return nil, importErr
+	}
+
+	quiz := &entity.Quiz{
+		Title:                    settings.Title,
+		Description:              settings.Description,
+		ScheduledTime:            scheduledTime,
+		Status:                   entity.QuizStatusScheduled,
+		DelayedResults:           format.DelayedResults,
+		SuppressAnswerFeedback:   format.SuppressAnswerFeedback,
+		HideCorrectAnswer:        format.HideCorrectAnswer,
+		JoinPolicy:               format.JoinPolicy,
+		UniformPointValue:        scoring.UniformPointValue,
+		PointsMultiplier:         scoring.PointsMultiplier,
+		WrongAnswerPenalty:       scoring.WrongAnswerPenalty,
+		ScoreFloor:               scoring.ScoreFloor,
+		FastestFingerBonus:       scoring.FastestFingerBonus,
+		DifficultyScaling:        scoring.DifficultyScaling,
+		DifficultyMultipliers:    scoring.DifficultyMultipliers,
+		Visibility:               format.Visibility,
+		RequireVerifiedEmail:     format.RequireVerifiedEmail,
+		MinGamesPlayed:           format.MinGamesPlayed,
+		ExternalEligibilityCheck: format.ExternalEligibilityCheck,
+		PacingMode:               format.PacingMode,
+		SelfPacedTimeLimitSec:    format.SelfPacedTimeLimitSec,
+		AutoAdvance:              format.AutoAdvance,
+		AnswerWindowFromAck:      format.AnswerWindowFromAck,
+		QuestionReadTimeSec:      format.QuestionReadTimeSec,
+		MinPlayers:               format.MinPlayers,
+		MinPlayersPolicy:         format.MinPlayersPolicy,
+		MaxPlayers:               format.MaxPlayers,
+		Lives:                    format.Lives,
+	}
+	if quiz.RequiresInviteCode() {
+		code, err := generateInviteCode()
+		if err != nil {
+			return nil, err
+		}
+		quiz.InviteCode = code
+	}
+
+	if err := s.quizRepo.CreateWithQuestions(quiz, questions); err != nil {
+		return nil, fmt.Errorf("failed to import quiz: %w", err)
+	}
+
+	log.Printf("[QuizService] Импортирована викторина #%d (формат v%d, %d вопросов)", quiz.ID, export.FormatVersion, len(questions))
+	quiz.Questions = questions
+	return quiz, nil
+}
+
+// importQuestions проверяет вопросы экспорта и преобразует их в entity.Question
+func (s *QuizService) importQuestions(importErr *QuizImportError, exported []QuizExportQuestion) []entity.Question {
+	if len(exported) == 0 || len(exported) > MaxQuizQuestions {
+		importErr.add("questions", "must contain between 1 and %d questions", MaxQuizQuestions)
+	}
+
+	questions := make([]entity.Question, len(exported))
+	for i, q := range exported {
+		prefix := fmt.Sprintf("questions[%d]", i)
+
+		text := strings.TrimSpace(q.Text)
+		checkImportLength(importErr, prefix+".text", text, importMinQuestionText, importMaxQuestionText)
+
+		if len(q.Options) < s.minOptions || len(q.Options) > s.maxOptions {
+			importErr.add(prefix+".options", "must contain between %d and %d options", s.minOptions, s.maxOptions)
+		}
+		options := make(entity.StringArray, len(q.Options))
+		seen := make(map[string]int, len(q.Options))
+		for j, option := range q.Options {
+			options[j] = strings.TrimSpace(option)
+			field := fmt.Sprintf("%s.options[%d]", prefix, j)
+			checkImportLength(importErr, field, options[j], 1, importMaxOptionText)
+
+			// Одинаковые варианты неразличимы для игрока
+			key := strings.ToLower(options[j])
+			if first, ok := seen[key]; ok && key != "" {
+				importErr.add(field, "duplicates options[%d]", first)
+			} else {
+				seen[key] = j
+			}
+		}
+
+		question := entity.Question{
+			Type:         q.Type,
+			Text:         text,
+			Options:      options,
+			TimeLimitSec: q.TimeLimitSec,
+			PointValue:   q.PointValue,
+			Difficulty:   q.Difficulty,
+		}
+		if !entity.IsValidDifficulty(question.Difficulty) {
+			importErr.add(prefix+".difficulty", "must be %s, %s or %s", entity.DifficultyEasy, entity.DifficultyMedium, entity.DifficultyHard)
+		}
+		switch q.Type {
+		case "", entity.QuestionTypeSingleChoice:
+			question.Type = entity.QuestionTypeSingleChoice
+			question.CorrectOption = q.CorrectOption
+			if q.CorrectOption < 1 || q.CorrectOption > len(q.Options) {
+				importErr.add(prefix+".correct_option", "must reference an existing option")
+			}
+		case entity.QuestionTypeOrdering:
+			question.CorrectOrder = entity.IntArray(q.CorrectOrder)
+			question.ScoringMethod = q.ScoringMethod
+			if question.ScoringMethod == "" {
+				question.ScoringMethod = entity.OrderingScoringExact
+			}
+			if !isImportedPermutation(q.CorrectOrder, len(q.Options)) {
+				importErr.add(prefix+".correct_order", "must list every option number exactly once")
+			}
+			if !entity.IsValidOrderingScoring(q.ScoringMethod) {
+				importErr.add(prefix+".scoring_method", "must be %s, %s or %s",
+					entity.OrderingScoringExact, entity.OrderingScoringKendallTau, entity.OrderingScoringAdjacent)
+			}
+		default:
+			importErr.add(prefix+".type", "must be %s or %s", entity.QuestionTypeSingleChoice, entity.QuestionTypeOrdering)
+		}
+
+		if q.TimeLimitSec < importMinTimeLimitSec || q.TimeLimitSec > importMaxTimeLimitSec {
+			importErr.add(prefix+".time_limit_sec", "must be between %d and %d", importMinTimeLimitSec, importMaxTimeLimitSec)
+		}
+		if q.PointValue < 1 || q.PointValue > importMaxPointValue {
+			importErr.add(prefix+".point_value", "must be between 1 and %d", importMaxPointValue)
+		}
+		questions[i] = question
+	}
+	return questions
+}
+
+// checkImportLength проверяет длину строки в символах (а не байтах)
+func checkImportLength(importErr *QuizImportError, field, value string, min, max int) {
+	length := utf8.RuneCountInString(value)
+	switch {
+	case length < min && min == 1:
+		importErr.add(field, "must not be empty")
+	case length < min:
+		importErr.add(field, "must be at least %d characters", min)
+	case length > max:
+		importErr.add(field, "must be at most %d characters", max)
+	}
+}
+
+// isImportedPermutation проверяет, что order содержит каждый номер варианта от 1 до n ровно один раз
+func isImportedPermutation(order []int, n int) bool {
+	if len(order) != n {
+		return false
+	}
+	seen := make([]bool, n+1)
+	for _, option := range order {
+		if option < 1 || option > n || seen[option] {
+			return false
+		}
+		seen[option] = true
+	}
+	return true
+}
+
+// validationMessage возвращает текст ошибки проверки без префикса ErrValidation
+func validationMessage(err error) string {
+	return strings.TrimPrefix(err.Error(), ErrValidation.Error()+": ")
+}
@@ -0,0 +1,158 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourusername/trivia-api/internal/domain/entity"
+)
+
+// newExportFixture - викторина из newCloneFixture с текстами вопросов допустимой длины
+func newExportFixture() (*QuizService, *cloneQuizRepo) {
+	s, quizRepo := newCloneFixture()
+	questions := s.questionRepo.(*cloneQuestionRepo).questions
+	questions[0].Text = "Какой вариант верный?"
+	questions[1].Text = "Расставьте по порядку"
+	return s, quizRepo
+}
+
+func TestExportImportQuiz_RoundTrip(t *testing.T) {
+	s, quizRepo := newExportFixture()
+	source := quizRepo.source
+	source.ScheduledTime = time.Now().Add(48 * time.Hour).Truncate(time.Second)
+	source.JoinPolicy = entity.JoinPolicyAnytime
+	source.Visibility = entity.VisibilityPrivate
+	source.InviteCode = "ABCDEFGH"
+	source.PacingMode = entity.PacingSynchronized
+	source.QuestionReadTimeSec = 5
+	source.MinPlayers = 4
+	source.MinPlayersPolicy = entity.MinPlayersPolicyCancel
+	source.MaxPlayers = 50
+	source.Lives = 3
+	source.DifficultyScaling = true
+	source.DifficultyMultipliers = entity.DifficultyMultipliers{Easy: 1, Medium: 1.5, Hard: 3}
+	source.PointsMultiplier = 2
+	source.WrongAnswerPenalty = 3
+
+	export, err := s.ExportQuiz(source.ID)
+	require.NoError(t, err)
+	assert.Equal(t, QuizExportFormatVersion, export.FormatVersion)
+	require.Len(t, export.Questions, 2)
+	assert.Equal(t, 2, export.Questions[0].CorrectOption, "правильные ответы входят в экспорт")
+
+	// Экспорт переносится как JSON-файл
+	data, err := json.Marshal(export)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), source.InviteCode)
+	var decoded QuizExport
+	require.NoError(t, json.Unmarshal(data, &decoded))
+
+	imported, err := s.ImportQuiz(&decoded)
+	require.NoError(t, err)
+
+	assert.Equal(t, entity.QuizStatusScheduled, imported.Status)
+	assert.True(t, source.ScheduledTime.Equal(imported.ScheduledTime))
+	assert.Equal(t, source.Title, imported.Title)
+	assert.Equal(t, source.Description, imported.Description)
+	assert.Equal(t, source.DelayedResults, imported.DelayedResults)
+	assert.Equal(t, source.JoinPolicy, imported.JoinPolicy)
+	assert.Equal(t, source.Visibility, imported.Visibility)
+	assert.Equal(t, source.QuestionReadTimeSec, imported.QuestionReadTimeSec)
+	assert.Equal(t, source.MinPlayers, imported.MinPlayers)
+	assert.Equal(t, source.MinPlayersPolicy, imported.MinPlayersPolicy)
+	assert.Equal(t, source.MaxPlayers, imported.MaxPlayers)
+	assert.Equal(t, source.Lives, imported.Lives)
+	assert.True(t, imported.DifficultyScaling)
+	assert.Equal(t, source.DifficultyMultipliers, imported.DifficultyMultipliers)
+	assert.Equal(t, source.PointsMultiplier, imported.PointsMultiplier)
+	assert.Equal(t, source.WrongAnswerPenalty, imported.WrongAnswerPenalty)
+	assert.NotEmpty(t, imported.InviteCode)
+	assert.NotEqual(t, source.InviteCode, imported.InviteCode, "код приглашения выпускается заново")
+
+	original, err := s.questionRepo.GetByQuizID(source.ID)
+	require.NoError(t, err)
+	require.Len(t, quizRepo.createdQuestion, len(original))
+	for i, q := range quizRepo.createdQuestion {
+		assert.Equal(t, imported.ID, q.QuizID)
+		assert.Equal(t, original[i].Type, q.Type)
+		assert.Equal(t, original[i].Text, q.Text)
+		assert.Equal(t, original[i].Options, q.Options)
+		assert.Equal(t, original[i].CorrectOption, q.CorrectOption)
+		assert.Equal(t, original[i].CorrectOrder, q.CorrectOrder)
+		assert.Equal(t, original[i].ScoringMethod, q.ScoringMethod)
+		assert.Equal(t, original[i].TimeLimitSec, q.TimeLimitSec)
+		assert.Equal(t, original[i].PointValue, q.PointValue)
+	}
+
+	// Повторный экспорт импортированной викторины совпадает с исходным
+	quizRepo.source = imported
+	s.questionRepo.(*cloneQuestionRepo).questions = quizRepo.createdQuestion
+	again, err := s.ExportQuiz(imported.ID)
+	require.NoError(t, err)
+	assert.Equal(t, decoded.Quiz, again.Quiz)
+	assert.Equal(t, decoded.Questions, again.Questions)
+}
+
+func TestImportQuiz_PastScheduleShiftedLikeClone(t *testing.T) {
+	s, quizRepo := newExportFixture()
+
+	export, err := s.ExportQuiz(1)
+	require.NoError(t, err)
+	imported, err := s.ImportQuiz(export)
+	require.NoError(t, err)
+
+	assert.True(t, imported.ScheduledTime.After(time.Now()))
+	assert.Equal(t, 0, int(imported.ScheduledTime.Sub(quizRepo.source.ScheduledTime)%(7*24*time.Hour)))
+}
+
+func TestImportQuiz_ReportsAllValidationErrors(t *testing.T) {
+	s, quizRepo := newCloneFixture()
+
+	export := &QuizExport{
+		FormatVersion: QuizExportFormatVersion,
+		Quiz: QuizExportSettings{
+			Title:         "  ",
+			ScheduledTime: time.Now().Add(time.Hour),
+			PacingMode:    "turbo",
+		},
+		Questions: []QuizExportQuestion{
+			{Text: "Столица Франции?", Options: []string{"Париж", "париж"}, CorrectOption: 3, TimeLimitSec: 10, PointValue: 10},
+			{Type: entity.QuestionTypeOrdering, Text: "Порядок", Options: []string{"a", "b", "c"}, CorrectOrder: []int{1, 1, 2}, TimeLimitSec: 90, PointValue: 10},
+		},
+	}
+
+	_, err := s.ImportQuiz(export)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrValidation))
+	var importErr *QuizImportError
+	require.True(t, errors.As(err, &importErr))
+
+	fields := make([]string, 0, len(importErr.Fields))
+	for _, fe := range importErr.Fields {
+		fields = append(fields, fe.Field)
+	}
+	assert.ElementsMatch(t, []string{
+		"quiz.title",
+		"quiz",
+		"questions[0].options[1]",
+		"questions[0].correct_option",
+		"questions[1].correct_order",
+		"questions[1].time_limit_sec",
+	}, fields)
+	assert.Nil(t, quizRepo.created, "викторина с ошибками не создается")
+}
+
+func TestImportQuiz_UnsupportedFormatVersion(t *testing.T) {
+	s, quizRepo := newCloneFixture()
+
+	_, err := s.ImportQuiz(&QuizExport{FormatVersion: QuizExportFormatVersion + 1})
+	var importErr *QuizImportError
+	require.True(t, errors.As(err, &importErr))
+	require.Len(t, importErr.Fields, 1)
+	assert.Equal(t, "format_version", importErr.Fields[0].Field)
+	assert.Nil(t, quizRepo.created)
+}
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/yourusername/trivia-api/internal/domain/entity"
+	"github.com/yourusername/trivia-api/internal/service/quizmanager"
+)
+
+// ErrQuizFull возвращается при присоединении к викторине, все места которой заняты
+var ErrQuizFull = quizmanager.ErrQuizFull
+
+// QuizJoinState - состояние викторины для присоединившегося игрока
+type QuizJoinState struct {
+	QuizID          uint      `json:"quiz_id"`
+	Status          string    `json:"status"`
+	ScheduledTime   time.Time `json:"scheduled_time"`
+	JoinedPlayers   int64     `json:"joined_players"`  // Занятые места
+	MaxPlayers      int       `json:"max_players"`     // 0 - без ограничения
+	PlayersInRoom   int64     `json:"players_in_room"` // Игроки, подключенные к комнате викторины
+	CurrentQuestion int       `json:"current_question"`
+	TotalQuestions  int       `json:"total_questions"`
+}
+
+// JoinQuiz присоединяет игрока к викторине до подключения по WebSocket: проверяет
+// доступ (видимость, код приглашения, условия участия), исключение модератором,
+// политику присоединения к идущей викторине и занимает место с учетом
+// Quiz.MaxPlayers. Повторное присоединение не занимает второе место.
+func (qm *QuizManager) JoinQuiz(quizID, userID uint, inviteCode string) (*QuizJoinState, error) {
+	quiz, err := qm.quizRepo.GetByID(quizID)
+	if err != nil {
+		return nil, quizLookupError(quizID, err)
+	}
+	if err := qm.checkJoinAccess(quiz, userID, inviteCode); err != nil {
+		return nil, err
+	}
+	if qm.answerProcessor.IsKicked(quizID, userID) {
+		return nil, fmt.Errorf("%w: quiz %d", ErrRemovedFromQuiz, quizID)
+	}
+
+	qm.stateMutex.RLock()
+	_, inProgress := qm.activeQuizzes[quizID]
+	qm.stateMutex.RUnlock()
+	if inProgress && !quiz.AllowsLateJoin() && !qm.joins.IsJoined(quizID, userID) {
+		if participating, _ := qm.answerProcessor.ParticipationStatus(quizID, userID); !participating {
+			return nil, fmt.Errorf("%w: quiz %d does not allow late join", ErrQuizAlreadyStarted, quizID)
+		}
+	}
+
+	reserved, err := qm.joins.Reserve(quiz, userID, inProgress)
+	if err != nil {
+		return nil, err
+	}
+	if reserved {
+		log.Printf("[QuizManager] Пользователь #%d присоединился к викторине #%d", userID, quizID)
+	}
+	return qm.joinState(quiz), nil
+}
+
+// LeaveQuiz освобождает место игрока в викторине: игрок отписывается от ее
+// событий и покидает комнату (quiz:player_left с причиной left). Сохраненные
+// ответы остаются в результатах. Для игрока без места возвращает ErrNotQuizParticipant.
+func (qm *QuizManager) LeaveQuiz(quizID, userID uint) error {
+	released, err := qm.joins.Release(quizID, userID)
+	if err != nil {
+		return err
+	}
+	if !released {
+		return fmt.Errorf("%w: user %d, quiz %d", ErrNotQuizParticipant, userID, quizID)
+	}
+
+	qm.wsManager.RemoveUserFromQuiz(fmt.Sprintf("%d", userID), quizID)
+	qm.stateMutex.Lock()
+	if active, ok := qm.activeQuizzes[quizID]; ok {
+		active.state.Answers().RemovePlayer(userID)
+	}
+	delete(qm.readyPlayers[quizID], userID)
+	qm.stateMutex.Unlock()
+	qm.presence.LeaveUser(quizID, userID, quizmanager.LeaveReasonLeft)
+
+	log.Printf("[QuizManager] Пользователь #%d покинул викторину #%d", userID, quizID)
+	return nil
+}
+
+// claimJoin связывает user:ready с местом игрока. При RequireJoin место должно
+// быть занято заранее через JoinQuiz (иначе ErrJoinRequired), без него
+// user:ready занимает место сам. Возвращает true, если место занято сейчас.
+func (qm *QuizManager) claimJoin(userID, quizID uint, active *activeQuiz, inProgress bool) (bool, error) {
+	if qm.joins.IsJoined(quizID, userID) {
+		return false, nil
+	}
+	if qm.config.RequireJoin {
+		return false, fmt.Errorf("%w: quiz %d", ErrJoinRequired, quizID)
+	}
+
+	var quiz *entity.Quiz
+	if inProgress {
+		quiz = active.state.Quiz
+	} else {
+		var err error
+		if quiz, err = qm.quizRepo.GetByID(quizID); err != nil {
+			return false, quizLookupError(quizID, err)
+		}
+	}
+	return qm.joins.Reserve(quiz, userID, inProgress)
+}
+
+// releaseJoin освобождает место, занятое user:ready, если присоединение не удалось
+func (qm *QuizManager) releaseJoin(quizID, userID uint) {
+	if _, err := qm.joins.Release(quizID, userID); err != nil {
+		log.Printf("[QuizManager] WARNING: Не удалось освободить место пользователя #%d в викторине #%d: %v",
+			userID, quizID, err)
+	}
+}
+
+// joinState собирает состояние викторины для ответа на присоединение
+func (qm *QuizManager) joinState(quiz *entity.Quiz) *QuizJoinState {
+	state := &QuizJoinState{
+		QuizID:         quiz.ID,
+		Status:         string(quiz.Status),
+		ScheduledTime:  quiz.ScheduledTime,
+		JoinedPlayers:  qm.joins.Count(quiz.ID),
+		MaxPlayers:     quiz.MaxPlayers,
+		PlayersInRoom:  qm.presence.Count(quiz.ID),
+		TotalQuestions: quiz.QuestionCount,
+	}
+
+	qm.stateMutex.RLock()
+	active, inProgress := qm.activeQuizzes[quiz.ID]
+	qm.stateMutex.RUnlock()
+	if inProgress {
+		_, state.CurrentQuestion, _ = active.state.CurrentQuestionSnapshot()
+		state.TotalQuestions = len(active.state.Quiz.Questions)
+	}
+	return state
+}
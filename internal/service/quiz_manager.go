@@ -2,8 +2,10 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"sort"
 	"sync"
 	"time"
 
@@ -20,15 +22,34 @@ type QuizManager struct {
 	scheduler       *quizmanager.Scheduler
 	questionManager *quizmanager.QuestionManager
 	answerProcessor *quizmanager.AnswerProcessor
+	answerPool      *quizmanager.AnswerPool
+	answerWriter    *quizmanager.AnswerWriter
+	presence        *quizmanager.PresenceFeed
+	joins           *quizmanager.JoinRegistry
+	leaderboard     *LeaderboardPusher
+	config          *quizmanager.Config
 
 	// Репозитории для прямого доступа
 	quizRepo      repository.QuizRepository
 	resultService *ResultService
 	wsManager     *websocket.Manager
 
-	// Состояние активной викторины
-	activeQuizState *quizmanager.ActiveQuizState
-	stateMutex      sync.RWMutex
+	// Активные викторины по ID. Каждая проводится независимо, в своих горутинах.
+	activeQuizzes map[uint]*activeQuiz
+	// Повторы завершенных викторин. Хранятся отдельно от активных, чтобы
+	// GetActiveQuizzes и прием ответов не принимали их за живые викторины.
+	replays map[uint]context.CancelFunc
+	// Игроки, отметившиеся до старта и не отключившиеся, по викторинам. При старте
+	// становятся активными игроками для досрочного закрытия вопросов (Quiz.AutoAdvance).
+	readyPlayers map[uint]map[uint]bool
+	stateMutex   sync.RWMutex
+
+	// Метрики одновременного проведения викторин
+	peakActiveQuizzes int   // Максимум одновременно активных викторин с запуска сервера
+	rejectedStarts    int64 // Запуски, отклоненные из-за лимита MaxConcurrentQuizzes
+
+	// Внешняя проверка условий участия (по умолчанию допускает всех)
+	externalEligibility EligibilityChecker
 
 	// Контекст для управления жизненным циклом
 	ctx    context.Context
@@ -38,6 +59,25 @@ type QuizManager struct {
 	// deps *quizmanager.Dependencies
 }
 
+// ActiveParticipation - участие пользователя в проводимой викторине
+type ActiveParticipation struct {
+	QuizID          uint   `json:"quiz_id"`
+	QuizTitle       string `json:"quiz_title"`
+	Eliminated      bool   `json:"eliminated"`
+	CurrentQuestion int    `json:"current_question"` // 0 - первый вопрос еще не отправлен
+	TotalQuestions  int    `json:"total_questions"`
+	QuestionOpen    bool   `json:"question_open"` // Принимаются ли сейчас ответы на текущий вопрос
+	RemainingMs     int64  `json:"remaining_ms"`  // Оставшееся время на текущий вопрос
+	ServerTimestamp int64  `json:"server_timestamp"`
+}
+
+// activeQuiz - состояние проводимой викторины и отмена ее контекста
+// (останавливает вопросы и сторожевой таймер)
+type activeQuiz struct {
+	state  *quizmanager.ActiveQuizState
+	cancel context.CancelFunc
+}
+
 // NewQuizManager создает новый экземпляр менеджера викторин
 func NewQuizManager(
 	quizRepo repository.QuizRepository,
@@ -68,18 +108,41 @@ func NewQuizManager(
 	scheduler := quizmanager.NewScheduler(config, deps)
 	questionManager := quizmanager.NewQuestionManager(config, deps)
 	answerProcessor := quizmanager.NewAnswerProcessor(config, deps)
+	answerPool := quizmanager.NewAnswerPool(config, deps, answerProcessor)
+	answerWriter := quizmanager.NewAnswerWriter(config, deps)
+	answerProcessor.SetAnswerWriter(answerWriter)
 
 	qm := &QuizManager{
 		scheduler:       scheduler,
 		questionManager: questionManager,
 		answerProcessor: answerProcessor,
+		answerPool:      answerPool,
+		answerWriter:    answerWriter,
+		presence:        quizmanager.NewPresenceFeed(config, deps),
+		joins:           quizmanager.NewJoinRegistry(deps),
+		leaderboard:     NewLeaderboardPusher(resultService, wsManager),
+		config:          config,
 		quizRepo:        quizRepo,
 		resultService:   resultService,
 		wsManager:       wsManager,
+		activeQuizzes:   make(map[uint]*activeQuiz),
+		replays:         make(map[uint]context.CancelFunc),
+		readyPlayers:    make(map[uint]map[uint]bool),
 		ctx:             ctx,
 		cancel:          cancel,
+
+		externalEligibility: NoopEligibilityChecker{},
 	}
 
+	questionManager.SetBeforeRevealHook(qm.flushAnswersBeforeReveal)
+	questionManager.SetAnswerRevealHook(qm.handleAnswerReveal)
+	// Минимум игроков к началу проверяется по комнате викторины на всех экземплярах
+	scheduler.SetRoomPlayerCounter(qm.presence.Count)
+
+	// Запускаем пул обработки ответов и пакетную запись ответов
+	answerPool.Start(ctx)
+	answerWriter.Start(ctx)
+
 	// Запускаем слушателя событий
 	go qm.handleEvents()
 
@@ -104,14 +167,9 @@ func (qm *QuizManager) handleEvents() {
 			// Обрабатываем событие запуска викторины
 			go qm.handleQuizStart(quizID)
 
-		case <-questionDoneCh:
-			// Обрабатываем событие завершения вопросов
-			qm.stateMutex.RLock()
-			activeState := qm.activeQuizState
-			qm.stateMutex.RUnlock()
-			if activeState != nil && activeState.Quiz != nil {
-				go qm.finishQuiz(activeState.Quiz.ID)
-			}
+		case quizID := <-questionDoneCh:
+			// Все вопросы викторины отправлены - завершаем ее
+			go qm.finishQuiz(quizID)
 		}
 	}
 }
@@ -124,71 +182,179 @@ func (qm *QuizManager) ScheduleQuiz(quizID uint, scheduledTime time.Time) error
 
 // CancelQuiz отменяет запланированную викторину
 func (qm *QuizManager) CancelQuiz(quizID uint) error {
+	quiz, err := qm.quizRepo.GetByID(quizID)
+	if err != nil {
+		return quizLookupError(quizID, err)
+	}
+	// Отменить можно только викторину, которая еще не началась
+	if err := quizStartedError(quiz); err != nil {
+		return err
+	}
+
 	log.Printf("[QuizManager] Отмена викторины #%d", quizID)
-	return qm.scheduler.CancelQuiz(quizID)
+	if err := qm.scheduler.CancelQuiz(quizID); err != nil {
+		return err
+	}
+	qm.stateMutex.Lock()
+	delete(qm.readyPlayers, quizID)
+	qm.stateMutex.Unlock()
+	return nil
 }
 
-// handleQuizStart обрабатывает запуск викторины
+// handleQuizStart обрабатывает запуск викторины. Викторина, запуск которой
+// отклонен из-за лимита одновременных викторин, отменяется, чтобы не остаться
+// в статусе in_progress.
 func (qm *QuizManager) handleQuizStart(quizID uint) {
+	err := qm.startQuiz(quizID)
+	if !errors.Is(err, ErrTooManyActiveQuizzes) {
+		return
+	}
+	log.Printf("[QuizManager] ERROR: Запуск викторины #%d отклонен: %v", quizID, err)
+	quiz, err := qm.quizRepo.GetByID(quizID)
+	if err == nil {
+		err = quizmanager.UpdateQuizStatus(qm.quizRepo, quiz, entity.QuizStatusCancelled)
+	}
+	if err != nil {
+		log.Printf("[QuizManager] Ошибка при отмене отклоненной викторины #%d: %v", quizID, err)
+	}
+	qm.wsManager.BroadcastEvent("quiz:cancelled", map[string]interface{}{
+		"quiz_id": quizID,
+		"message": "Quiz has been cancelled: too many quizzes are running",
+	})
+}
+
+// startQuiz запускает проведение викторины. Возвращает ErrTooManyActiveQuizzes,
+// если уже проводится MaxConcurrentQuizzes викторин.
+func (qm *QuizManager) startQuiz(quizID uint) error {
 	log.Printf("[QuizManager] Обработка запуска викторины #%d", quizID)
 
 	// Получаем викторину с вопросами
 	quiz, err := qm.quizRepo.GetWithQuestions(quizID)
 	if err != nil {
 		log.Printf("[QuizManager] Ошибка при получении викторины #%d: %v", quizID, err)
-		return
+		return err
 	}
 
 	// Убеждаемся, что у викторины есть вопросы
 	if len(quiz.Questions) == 0 {
 		log.Printf("[QuizManager] Викторина #%d не имеет вопросов, запуск отменён", quizID)
-		return
+		return nil
 	}
 
 	// Создаем состояние активной викторины
 	newState := quizmanager.NewActiveQuizState(quiz)
+	if quiz.IsSelfPaced() {
+		newState.SelfPaced = quizmanager.NewSelfPacedRun(quiz, time.Now())
+		log.Printf("[QuizManager] Викторина #%d проходится самостоятельно, срок: %v", quizID, newState.SelfPaced.Deadline)
+	}
+	if quiz.DelayedResults {
+		log.Printf("[QuizManager] Викторина #%d проводится с отложенными результатами", quizID)
+	}
 
 	// Блокируем для записи
 	qm.stateMutex.Lock()
-	// Проверяем, не запущена ли уже другая викторина (на всякий случай)
-	if qm.activeQuizState != nil {
-		log.Printf("[QuizManager] WARNING: Попытка запустить викторину #%d, когда викторина #%d уже активна!", quizID, qm.activeQuizState.Quiz.ID)
+	// Другие викторины могут проводиться одновременно, но одну и ту же дважды не запускаем
+	if _, exists := qm.activeQuizzes[quizID]; exists {
+		log.Printf("[QuizManager] WARNING: Попытка повторно запустить уже активную викторину #%d", quizID)
 		qm.stateMutex.Unlock()
-		return
+		return nil
+	}
+	if limit := qm.config.MaxConcurrentQuizzes; limit > 0 && len(qm.activeQuizzes) >= limit {
+		qm.rejectedStarts++
+		delete(qm.readyPlayers, quizID)
+		qm.stateMutex.Unlock()
+		return fmt.Errorf("%w: limit is %d", ErrTooManyActiveQuizzes, limit)
+	}
+	quizCtx, quizCancel := context.WithCancel(qm.ctx)
+	qm.answerProcessor.ResetDeferredResults(quiz)
+	qm.activeQuizzes[quizID] = &activeQuiz{state: newState, cancel: quizCancel}
+	for userID := range qm.readyPlayers[quizID] {
+		newState.Answers().AddPlayer(userID)
 	}
-	qm.activeQuizState = newState
+	delete(qm.readyPlayers, quizID)
+	activeCount := len(qm.activeQuizzes)
+	if activeCount > qm.peakActiveQuizzes {
+		qm.peakActiveQuizzes = activeCount
+	}
+	maxDuration := qm.config.MaxQuizDuration(quiz)
 	qm.stateMutex.Unlock()
 
+	log.Printf("[QuizManager] Викторина #%d запущена, активных викторин: %d", quizID, activeCount)
+
+	// При самостоятельном прохождении вопросы отправляются каждому игроку отдельно,
+	// а викторину завершает общий лимит времени
+	if newState.SelfPaced != nil {
+		go qm.runSelfPacedQuiz(quizCtx, newState.SelfPaced)
+		return nil
+	}
+
+	// Запускаем сторожевой таймер максимальной длительности
+	go qm.runQuizWatchdog(quizCtx, quizID, maxDuration)
+
 	// Запускаем процесс отправки вопросов
 	go func() {
-		if err := qm.questionManager.RunQuizQuestions(qm.ctx, newState); err != nil {
+		if err := qm.questionManager.RunQuizQuestions(quizCtx, newState); err != nil {
 			log.Printf("[QuizManager] Ошибка при выполнении викторины #%d: %v", quizID, err)
 			// В случае ошибки выполнения, также завершаем викторину
 			qm.finishQuiz(quizID)
 		}
 	}()
+	return nil
 }
 
-// finishQuiz завершает викторину и подсчитывает результаты
-func (qm *QuizManager) finishQuiz(quizID uint) {
+// runQuizWatchdog принудительно завершает викторину, если она не завершилась
+// за maxDuration. Завершается вместе с контекстом викторины.
+func (qm *QuizManager) runQuizWatchdog(ctx context.Context, quizID uint, maxDuration time.Duration) {
+	timer := time.NewTimer(maxDuration)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return
+	case <-timer.C:
+		log.Printf("[QuizManager] ERROR: Викторина #%d не завершилась за %v, принудительное завершение", quizID, maxDuration)
+		if !qm.finishQuiz(quizID) {
+			return
+		}
+
+		endEvent := map[string]interface{}{
+			"type": "quiz:end",
+			"data": map[string]interface{}{
+				"quiz_id": quizID,
+				"reason":  "max_duration_exceeded",
+				"message": "Викторина завершена досрочно",
+			},
+		}
+		if err := qm.wsManager.BroadcastEventToQuiz(quizID, endEvent); err != nil {
+			log.Printf("[QuizManager] Ошибка при отправке события quiz:end для викторины #%d: %v", quizID, err)
+		}
+	}
+}
+
+// finishQuiz завершает викторину и подсчитывает результаты.
+// Возвращает false, если викторина не была активной.
+func (qm *QuizManager) finishQuiz(quizID uint) bool {
 	log.Printf("[QuizManager] Завершение викторины #%d", quizID)
 
 	// Блокируем для чтения и записи
 	qm.stateMutex.Lock()
 	defer qm.stateMutex.Unlock() // Гарантируем разблокировку
 
-	if qm.activeQuizState == nil || qm.activeQuizState.Quiz == nil || qm.activeQuizState.Quiz.ID != quizID {
+	active, exists := qm.activeQuizzes[quizID]
+	if !exists {
 		log.Printf("[QuizManager] Ошибка: викторина #%d не является активной или уже завершена.", quizID)
-		return
+		return false
 	}
 
+	// Останавливаем отправку вопросов и сторожевой таймер этой викторины
+	active.cancel()
+
 	// Обновляем статус викторины
-	quiz := qm.activeQuizState.Quiz
-	quiz.Status = "completed"
+	quiz := active.state.Quiz
 	// Для timestamp завершения используем текущее время
 	completedAt := time.Now()
 
-	if err := qm.quizRepo.Update(quiz); err != nil {
+	if err := quizmanager.UpdateQuizStatus(qm.quizRepo, quiz, entity.QuizStatusCompleted); err != nil {
 		log.Printf("[QuizManager] Ошибка при обновлении статуса викторины #%d: %v", quizID, err)
 		// Продолжаем несмотря на ошибку
 	}
@@ -198,7 +364,7 @@ func (qm *QuizManager) finishQuiz(quizID uint) {
 		"quiz_id":  quizID,
 		"title":    quiz.Title,
 		"message":  "Викторина завершена! Подсчет результатов...",
-		"status":   "completed",
+		"status":   entity.QuizStatusCompleted,
 		"ended_at": completedAt,
 	}
 
@@ -213,50 +379,635 @@ func (qm *QuizManager) finishQuiz(quizID uint) {
 		log.Printf("[QuizManager] Ошибка при отправке события о завершении викторины #%d: %v", quizID, err)
 	}
 
+	selfPaced := active.state.SelfPaced
+
 	// --- Вызов определения победителей ---
 	// Запускаем асинхронно, чтобы не блокировать завершение викторины
 	go func(ctx context.Context, currentQuizID uint) {
 		// Даем небольшую задержку, чтобы убедиться, что все последние события обработаны
-		time.Sleep(2 * time.Second)
+		select {
+		case <-time.After(2 * time.Second):
+		case <-ctx.Done():
+			return
+		}
+		// Итоги считаются по ответам в БД - записываем накопленные
+		qm.flushAnswers(ctx, currentQuizID)
+		// Рассылка таблицы после последнего вопроса к этому времени завершена
+		qm.leaderboard.Forget(currentQuizID)
+		// При самостоятельном прохождении итоговые результаты считаются по ответам
+		// всех начавших прохождение игроков
+		if selfPaced != nil {
+			qm.resultService.SaveSelfPacedResults(currentQuizID, selfPaced.Participants())
+		}
 		if err := qm.resultService.DetermineWinnersAndAllocatePrizes(ctx, currentQuizID); err != nil {
 			log.Printf("[QuizManager] Ошибка при определении победителей для викторины #%d: %v", currentQuizID, err)
 		}
+		// Ответы викторины обработаны - буфер отложенных результатов ее больше не хранит
+		qm.answerProcessor.ReleaseDeferredResults(quiz)
 	}(qm.ctx, quizID) // Передаем quizID в горутину
 
-	// Сбрасываем активную викторину
-	qm.activeQuizState = nil
+	// Убираем викторину из активных
+	delete(qm.activeQuizzes, quizID)
+	return true
+}
+
+// runSelfPacedQuiz объявляет начало самостоятельного прохождения и завершает
+// викторину по истечении общего лимита времени. Игроки получают первый вопрос
+// по user:ready, следующие - после ответа на предыдущий.
+func (qm *QuizManager) runSelfPacedQuiz(ctx context.Context, run *quizmanager.SelfPacedRun) {
+	quizID := run.Quiz.ID
+	startEvent := map[string]interface{}{
+		"type": "quiz:start",
+		"data": map[string]interface{}{
+			"quiz_id":        quizID,
+			"title":          run.Quiz.Title,
+			"question_count": len(run.Quiz.Questions),
+			"pacing_mode":    entity.PacingSelfPaced,
+			"time_limit_sec": run.Quiz.SelfPacedTimeLimitSec,
+			"ends_at":        run.Deadline.UnixMilli(),
+		},
+	}
+	if err := qm.wsManager.BroadcastEventToQuiz(quizID, startEvent); err != nil {
+		log.Printf("[QuizManager] Ошибка при отправке события quiz:start для викторины #%d: %v", quizID, err)
+	}
+
+	timer := time.NewTimer(time.Until(run.Deadline))
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return
+	case <-timer.C:
+	}
+
+	log.Printf("[QuizManager] Время самостоятельного прохождения викторины #%d истекло", quizID)
+	qm.finishQuiz(quizID)
+}
+
+// SetMaxDurationSlackFactor устанавливает множитель запаса для максимальной длительности викторины.
+// Применяется к викторинам, запущенным после вызова.
+func (qm *QuizManager) SetMaxDurationSlackFactor(factor float64) {
+	if factor < 1 {
+		log.Printf("[QuizManager] Некорректный множитель длительности %.2f, используется 1.0", factor)
+		factor = 1
+	}
+	qm.stateMutex.Lock()
+	qm.config.MaxDurationSlackFactor = factor
+	qm.stateMutex.Unlock()
+}
+
+// activeStateForQuestion находит активную викторину, в которой есть вопрос.
+// Сначала проверяются текущие вопросы, затем полные списки вопросов, чтобы ответ
+// на уже закрытый вопрос получил понятную ошибку от PrepareSubmission.
+func (qm *QuizManager) activeStateForQuestion(questionID uint) (*quizmanager.ActiveQuizState, error) {
+	qm.stateMutex.RLock()
+	defer qm.stateMutex.RUnlock()
+
+	if len(qm.activeQuizzes) == 0 {
+		return nil, fmt.Errorf("%w: нет активной викторины", ErrQuizNotActive)
+	}
+	for _, active := range qm.activeQuizzes {
+		if current, _ := active.state.GetCurrentQuestion(); current != nil && current.ID == questionID {
+			return active.state, nil
+		}
+	}
+	for _, active := range qm.activeQuizzes {
+		for i := range active.state.Quiz.Questions {
+			if active.state.Quiz.Questions[i].ID == questionID {
+				return active.state, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("%w: вопрос #%d не относится ни к одной активной викторине", ErrQuizNotActive, questionID)
+}
+
+// SetReconnectGrace включает прощение опоздавших ответов после переподключения:
+// окно после отключения и число прощений на игрока за викторину. window <= 0 выключает.
+func (qm *QuizManager) SetReconnectGrace(window time.Duration, reprievesPerQuiz int) {
+	if reprievesPerQuiz < 0 {
+		reprievesPerQuiz = 0
+	}
+	qm.stateMutex.Lock()
+	qm.config.ReconnectGraceWindow = window
+	qm.config.ReconnectReprievesPerQuiz = reprievesPerQuiz
+	qm.stateMutex.Unlock()
+}
+
+// SetDisconnectGrace задает, сколько отключившийся игрок еще ожидается на
+// текущем вопросе викторины с досрочным закрытием. 0 - не ожидается.
+func (qm *QuizManager) SetDisconnectGrace(grace time.Duration) {
+	if grace < 0 {
+		grace = 0
+	}
+	qm.stateMutex.Lock()
+	qm.config.DisconnectGrace = grace
+	qm.stateMutex.Unlock()
+}
+
+// SetMaxConcurrentQuizzes задает максимальное число одновременно проводимых
+// викторин. 0 снимает ограничение. Уже идущие викторины не прерываются.
+func (qm *QuizManager) SetMaxConcurrentQuizzes(limit int) {
+	if limit < 0 {
+		limit = 0
+	}
+	qm.stateMutex.Lock()
+	qm.config.MaxConcurrentQuizzes = limit
+	qm.stateMutex.Unlock()
+	log.Printf("[QuizManager] Лимит одновременных викторин: %d", limit)
+}
+
+// GetConcurrencyMetrics возвращает метрики одновременного проведения викторин
+func (qm *QuizManager) GetConcurrencyMetrics() map[string]interface{} {
+	qm.stateMutex.RLock()
+	defer qm.stateMutex.RUnlock()
+	return map[string]interface{}{
+		"active_quizzes":         len(qm.activeQuizzes),
+		"peak_active_quizzes":    qm.peakActiveQuizzes,
+		"max_concurrent_quizzes": qm.config.MaxConcurrentQuizzes,
+		"rejected_starts":        qm.rejectedStarts,
+	}
+}
+
+// HandleDisconnect фиксирует отключение пользователя от WebSocket. Отключившийся
+// игрок больше не ожидается при досрочном закрытии вопросов, пока снова не
+// отправит user:ready.
+func (qm *QuizManager) HandleDisconnect(userID uint) {
+	qm.answerProcessor.RecordDisconnect(userID, time.Now())
+
+	qm.stateMutex.Lock()
+	defer qm.stateMutex.Unlock()
+	for _, players := range qm.readyPlayers {
+		delete(players, userID)
+	}
+	// Игрок может переподключиться по новому соединению и ответить на тот же
+	// вопрос: до истечения DisconnectGrace вопрос его ждет
+	for _, active := range qm.activeQuizzes {
+		active.state.Answers().RemovePlayerAfter(userID, qm.config.DisconnectGrace)
+	}
 }
 
 // ProcessAnswer обрабатывает ответ пользователя на вопрос
 func (qm *QuizManager) ProcessAnswer(userID, questionID uint, selectedOption int, timestamp int64) error {
-	// Блокируем для чтения
+	activeState, err := qm.activeStateForQuestion(questionID)
+	if err != nil {
+		return err
+	}
+
+	// Дешевые проверки выполняем сразу, тяжелую обработку отдаем в пул
+	submission, err := qm.prepareSubmission(userID, questionID, selectedOption, timestamp, activeState)
+	if err != nil {
+		return err
+	}
+
+	return qm.submitAnswer(submission)
+}
+
+// submitAnswer передает ответ в пул обработки. Ответ, не принятый пулом,
+// перестает быть кандидатом на бонус первому правильному ответу, а игрок
+// самостоятельного прохождения возвращается к вопросу.
+func (qm *QuizManager) submitAnswer(submission *quizmanager.AnswerSubmission) error {
+	if err := qm.answerPool.Submit(submission); err != nil {
+		submission.DiscardFastestFinger()
+		submission.ReturnSelfPacedQuestion()
+		return err
+	}
+	return nil
+}
+
+// prepareSubmission выбирает прием ответа по темпу прохождения викторины
+func (qm *QuizManager) prepareSubmission(userID, questionID uint, selectedOption int, timestamp int64, activeState *quizmanager.ActiveQuizState) (*quizmanager.AnswerSubmission, error) {
+	if activeState.SelfPaced == nil {
+		return qm.answerProcessor.PrepareSubmission(userID, questionID, selectedOption, timestamp, activeState)
+	}
+	submission, err := qm.answerProcessor.PrepareSelfPacedSubmission(userID, questionID, selectedOption, activeState)
+	if errors.Is(err, quizmanager.ErrSelfPacedTimeOver) {
+		return nil, fmt.Errorf("%w: %v", ErrQuizFinished, err)
+	}
+	return submission, err
+}
+
+// ProcessOrderingAnswer обрабатывает ответ пользователя на вопрос ordering
+func (qm *QuizManager) ProcessOrderingAnswer(userID, questionID uint, order []int, timestamp int64) error {
+	activeState, err := qm.activeStateForQuestion(questionID)
+	if err != nil {
+		return err
+	}
+	// При самостоятельном прохождении прием ответа переводит игрока к следующему
+	// вопросу, поэтому тип вопроса проверяется заранее
+	if activeState.SelfPaced != nil {
+		if question := activeState.Quiz.QuestionByID(questionID); question != nil && !question.IsOrdering() {
+			return fmt.Errorf("вопрос #%d не является вопросом на упорядочивание", questionID)
+		}
+	}
+
+	submission, err := qm.prepareSubmission(userID, questionID, 0, timestamp, activeState)
+	if err != nil {
+		return err
+	}
+	if !submission.Question.IsOrdering() {
+		submission.DiscardFastestFinger()
+		return fmt.Errorf("вопрос #%d не является вопросом на упорядочивание", questionID)
+	}
+	submission.SelectedOrder = order
+	submission.OfferFastestFinger()
+
+	return qm.submitAnswer(submission)
+}
+
+// AcknowledgeQuestion учитывает подтверждение получения открытого вопроса
+// игроком (quiz:question_received). В викторине с Quiz.AnswerWindowFromAck время
+// на ответ игрока отсчитывается от первого подтверждения; в остальных
+// викторинах подтверждение ни на что не влияет.
+func (qm *QuizManager) AcknowledgeQuestion(userID, questionID uint) error {
+	activeState, err := qm.activeStateForQuestion(questionID)
+	if err != nil {
+		return err
+	}
+	if !activeState.Quiz.AnswerWindowFromAck {
+		return nil
+	}
+	current, _, startMs := activeState.CurrentQuestionSnapshot()
+	// Во время чтения вопроса подтверждение принимается, но время на ответ
+	// все равно отсчитывается не раньше открытия приема ответов
+	if current == nil || current.ID != questionID || (startMs == 0 && activeState.CurrentQuestionReadUntil() == 0) {
+		return fmt.Errorf("вопрос #%d не открыт для ответов", questionID)
+	}
+	activeState.Acks().Acknowledge(questionID, userID, time.Now().UnixMilli())
+	return nil
+}
+
+// GetAnswerQueueMetrics возвращает метрики очереди обработки ответов
+func (qm *QuizManager) GetAnswerQueueMetrics() map[string]interface{} {
+	metrics := qm.answerPool.GetMetrics()
+	metrics["persistence"] = qm.answerWriter.GetMetrics()
+	return metrics
+}
+
+// SetAnswerBatching задает размер пакета и интервал пакетной записи ответов в
+// БД. Размер пакета 1 выключает буферизацию: каждый ответ записывается сразу.
+// Нулевые значения оставляют настройки по умолчанию.
+func (qm *QuizManager) SetAnswerBatching(batchSize int, flushInterval time.Duration) {
+	if batchSize <= 0 {
+		batchSize = qm.config.AnswerWriteBatchSize
+	}
+	if flushInterval <= 0 {
+		flushInterval = qm.config.AnswerWriteFlushInterval
+	}
+	qm.answerWriter.Configure(batchSize, flushInterval)
+	log.Printf("[QuizManager] Пакетная запись ответов: размер пакета %d, интервал %v", batchSize, flushInterval)
+}
+
+// SetMinPlayersGrace задает, сколько ждать игроков после назначенного времени
+// викторины с Quiz.MinPlayers и политикой wait, прежде чем отменить ее
+func (qm *QuizManager) SetMinPlayersGrace(grace time.Duration) {
+	qm.config.MinPlayersGrace = grace
+	log.Printf("[QuizManager] Ожидание игроков до отмены викторины: %v", grace)
+}
+
+// SetLeaderboardDeltas включает рассылку изменений таблицы лидеров
+// (quiz:leaderboard_delta) вместо полной таблицы после каждого вопроса
+func (qm *QuizManager) SetLeaderboardDeltas(enabled bool) {
+	qm.leaderboard.SetDeltas(enabled)
+	log.Printf("[QuizManager] Рассылка изменений таблицы лидеров: %v", enabled)
+}
+
+// SetRequireJoin задает, нужно ли присоединиться к викторине через
+// POST /api/quizzes/:id/join перед user:ready
+func (qm *QuizManager) SetRequireJoin(required bool) {
+	qm.config.RequireJoin = required
+	log.Printf("[QuizManager] Присоединение перед user:ready обязательно: %v", required)
+}
+
+// handleAnswerReveal вызывается после раскрытия ответа на вопрос: рассылает
+// отложенные результаты и обновленную таблицу лидеров
+func (qm *QuizManager) handleAnswerReveal(questionID uint) {
+	qm.answerProcessor.RevealResults(questionID)
+
+	state, err := qm.activeStateForQuestion(questionID)
+	if err != nil {
+		log.Printf("[QuizManager] Таблица лидеров после вопроса #%d не разослана: %v", questionID, err)
+		return
+	}
+	// Таблица считается по ответам в БД и не задерживает следующий вопрос
+	go qm.leaderboard.Publish(state.Quiz)
+}
+
+// RequestLeaderboard отправляет игроку полную таблицу лидеров идущей
+// викторины, например после пропуска версии изменений
+func (qm *QuizManager) RequestLeaderboard(userID, quizID uint) error {
 	qm.stateMutex.RLock()
-	activeState := qm.activeQuizState
+	_, active := qm.activeQuizzes[quizID]
 	qm.stateMutex.RUnlock()
+	if !active {
+		return fmt.Errorf("%w: викторина #%d", ErrQuizNotActive, quizID)
+	}
+	qm.leaderboard.SendFull(quizID, userID)
+	return nil
+}
 
-	if activeState == nil {
-		return fmt.Errorf("нет активной викторины")
+// flushAnswersBeforeReveal записывает накопленные ответы перед раскрытием
+// ответа на вопрос
+func (qm *QuizManager) flushAnswersBeforeReveal(ctx context.Context, questionID uint) {
+	if err := qm.answerWriter.FlushWithRetry(ctx, qm.config.MaxRetries, qm.config.RetryInterval); err != nil {
+		log.Printf("[QuizManager] WARNING: Не удалось записать ответы перед раскрытием вопроса #%d, осталось %d: %v",
+			questionID, qm.answerWriter.Pending(), err)
 	}
+}
 
-	return qm.answerProcessor.ProcessAnswer(
-		qm.ctx, userID, questionID, selectedOption, timestamp, activeState)
+// flushAnswers записывает накопленные ответы перед чтением ответов викторины из БД
+func (qm *QuizManager) flushAnswers(ctx context.Context, quizID uint) {
+	if err := qm.answerWriter.FlushWithRetry(ctx, qm.config.MaxRetries, qm.config.RetryInterval); err != nil {
+		log.Printf("[QuizManager] WARNING: Не удалось записать накопленные ответы викторины #%d, осталось %d: %v",
+			quizID, qm.answerWriter.Pending(), err)
+	}
+}
+
+// CheckJoinAccess проверяет, может ли игрок присоединиться к викторине с учетом
+// ее видимости: для приватной викторины нужен действующий код приглашения.
+// Викторина читается из БД, чтобы перевыпуск кода действовал сразу, в том числе
+// для идущей викторины. К завершенной или отмененной викторине присоединиться
+// нельзя (ErrQuizFinished). Затем проверяются условия участия (ErrNotEligible).
+func (qm *QuizManager) CheckJoinAccess(quizID, userID uint, inviteCode string) error {
+	quiz, err := qm.quizRepo.GetByID(quizID)
+	if err != nil {
+		return quizLookupError(quizID, err)
+	}
+	return qm.checkJoinAccess(quiz, userID, inviteCode)
+}
+
+func (qm *QuizManager) checkJoinAccess(quiz *entity.Quiz, userID uint, inviteCode string) error {
+	// К завершенной или отмененной викторине присоединиться нельзя
+	if quiz.IsCompleted() || quiz.IsCancelled() {
+		return quizStartedError(quiz)
+	}
+
+	if err := checkInviteCode(quiz, inviteCode); err != nil {
+		return err
+	}
+	return qm.checkEligibility(quiz, userID)
+}
+
+// checkInviteCode проверяет код приглашения, если викторина его требует
+func checkInviteCode(quiz *entity.Quiz, inviteCode string) error {
+	if !quiz.RequiresInviteCode() {
+		return nil
+	}
+	if inviteCode == "" {
+		return ErrInviteCodeRequired
+	}
+	if !quiz.CheckInviteCode(inviteCode) {
+		return ErrInvalidInviteCode
+	}
+	return nil
 }
 
 // HandleReadyEvent обрабатывает событие готовности пользователя
 func (qm *QuizManager) HandleReadyEvent(userID uint, quizID uint) error {
-	return qm.answerProcessor.HandleReadyEvent(qm.ctx, userID, quizID)
+	qm.stateMutex.RLock()
+	active, inProgress := qm.activeQuizzes[quizID]
+	qm.stateMutex.RUnlock()
+
+	if qm.answerProcessor.IsKicked(quizID, userID) {
+		return fmt.Errorf("%w: quiz %d", ErrRemovedFromQuiz, quizID)
+	}
+	reserved, err := qm.claimJoin(userID, quizID, active, inProgress)
+	if err != nil {
+		return err
+	}
+
+	// К идущей викторине можно присоединиться только при join_policy = anytime
+	if inProgress {
+		if _, err := qm.questionManager.JoinInProgress(userID, active.state); err != nil {
+			if reserved {
+				qm.releaseJoin(quizID, userID)
+			}
+			if errors.Is(err, quizmanager.ErrLateJoinNotAllowed) {
+				return fmt.Errorf("%w: %v", ErrQuizAlreadyStarted, err)
+			}
+			return err
+		}
+	}
+
+	if err := qm.answerProcessor.HandleReadyEvent(qm.ctx, userID, quizID); err != nil {
+		if errors.Is(err, quizmanager.ErrParticipantKicked) {
+			return fmt.Errorf("%w: quiz %d", ErrRemovedFromQuiz, quizID)
+		}
+		return err
+	}
+
+	qm.trackReadyPlayer(userID, quizID)
+
+	// При самостоятельном прохождении user:ready выдает игроку его текущий вопрос:
+	// первый при начале и тот же после переподключения
+	if inProgress && active.state.SelfPaced != nil {
+		if err := qm.answerProcessor.ServeSelfPacedQuestion(active.state.SelfPaced, userID); err != nil {
+			if errors.Is(err, quizmanager.ErrSelfPacedTimeOver) {
+				return fmt.Errorf("%w: %v", ErrQuizFinished, err)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// trackReadyPlayer учитывает отметившегося игрока в числе активных для досрочного
+// закрытия вопросов: до старта - в списке отметившихся, во время проведения - в
+// учете ответов викторины. Выбывший игрок, вернувшийся наблюдать, не учитывается.
+func (qm *QuizManager) trackReadyPlayer(userID, quizID uint) {
+	if _, eliminated := qm.answerProcessor.ParticipationStatus(quizID, userID); eliminated {
+		return
+	}
+
+	qm.stateMutex.Lock()
+	defer qm.stateMutex.Unlock()
+	if active, inProgress := qm.activeQuizzes[quizID]; inProgress {
+		active.state.Answers().AddPlayer(userID)
+		return
+	}
+	if qm.readyPlayers[quizID] == nil {
+		qm.readyPlayers[quizID] = make(map[uint]bool)
+	}
+	qm.readyPlayers[quizID][userID] = true
 }
 
-// GetActiveQuiz возвращает активную викторину
-func (qm *QuizManager) GetActiveQuiz() *entity.Quiz {
+// KickPlayer исключает игрока из проводимой викторины по решению модератора.
+// Игрок выбывает и отписывается от событий викторины, его сохраненные ответы
+// остаются в результатах. Возвращает число участников после исключения.
+func (qm *QuizManager) KickPlayer(quizID, userID, moderatorID uint, reason string) (int64, error) {
+	qm.stateMutex.RLock()
+	_, inProgress := qm.activeQuizzes[quizID]
+	qm.stateMutex.RUnlock()
+	if !inProgress {
+		return 0, fmt.Errorf("%w: quiz %d", ErrQuizNotActive, quizID)
+	}
+
+	participants, err := qm.answerProcessor.KickParticipant(quizID, userID, reason)
+	if err != nil {
+		if errors.Is(err, quizmanager.ErrNotParticipant) {
+			return 0, fmt.Errorf("%w: user %d, quiz %d", ErrNotQuizParticipant, userID, quizID)
+		}
+		return 0, err
+	}
+	qm.wsManager.RemoveUserFromQuiz(fmt.Sprintf("%d", userID), quizID)
+	qm.stateMutex.RLock()
+	if active, ok := qm.activeQuizzes[quizID]; ok {
+		active.state.Answers().RemovePlayer(userID)
+	}
+	qm.stateMutex.RUnlock()
+	qm.presence.LeaveUser(quizID, userID, quizmanager.LeaveReasonKicked)
+	// Место исключенного игрока освобождается для других
+	qm.releaseJoin(quizID, userID)
+
+	log.Printf("[QuizManager] AUDIT: модератор ID=%d исключил пользователя ID=%d из викторины #%d (причина: %s), участников: %d",
+		moderatorID, userID, quizID, reason, participants)
+	return participants, nil
+}
+
+// RecordParticipantCountry запоминает страну, из которой игрок подключился к викторине
+func (qm *QuizManager) RecordParticipantCountry(userID, quizID uint, country string) {
+	qm.answerProcessor.RecordParticipantCountry(quizID, userID, country)
+}
+
+// Причины выхода игрока из комнаты викторины (quiz:player_left)
+const (
+	LeaveReasonDisconnected = quizmanager.LeaveReasonDisconnected
+	LeaveReasonLeft         = quizmanager.LeaveReasonLeft
+)
+
+// ErrQuestionReadPhase возвращается для ответа, отправленного до открытия приема ответов
+var ErrQuestionReadPhase = quizmanager.ErrQuestionReadPhase
+
+// EnterQuizRoom учитывает вход соединения игрока в комнату викторины и сообщает
+// комнате о новом игроке (quiz:player_joined)
+func (qm *QuizManager) EnterQuizRoom(userID, quizID uint, connectionID string) {
+	displayName := ""
+	if qm.resultService != nil && qm.resultService.userRepo != nil {
+		if user, err := qm.resultService.userRepo.GetByID(userID); err == nil {
+			displayName = user.Username
+		} else {
+			log.Printf("[QuizManager] Не удалось получить имя пользователя #%d для комнаты викторины #%d: %v", userID, quizID, err)
+		}
+	}
+	qm.presence.Join(quizID, userID, connectionID, displayName)
+	// Новый игрок получает полную таблицу, к которой применяются следующие изменения
+	qm.leaderboard.SendFull(quizID, userID)
+}
+
+// LeaveQuizRoom учитывает выход соединения из комнаты викторины и, если это
+// последнее соединение игрока, сообщает комнате о его уходе (quiz:player_left)
+func (qm *QuizManager) LeaveQuizRoom(quizID uint, connectionID, reason string) {
+	qm.presence.Leave(quizID, connectionID, reason)
+}
+
+// GetActiveQuizzes возвращает викторины, которые проводятся сейчас, в порядке возрастания ID
+func (qm *QuizManager) GetActiveQuizzes() []*entity.Quiz {
 	// Блокируем для чтения
 	qm.stateMutex.RLock()
 	defer qm.stateMutex.RUnlock()
 
-	if qm.activeQuizState == nil {
-		return nil
+	quizzes := make([]*entity.Quiz, 0, len(qm.activeQuizzes))
+	for _, active := range qm.activeQuizzes {
+		quizzes = append(quizzes, active.state.Quiz)
 	}
-	return qm.activeQuizState.Quiz
+	sort.Slice(quizzes, func(i, j int) bool { return quizzes[i].ID < quizzes[j].ID })
+	return quizzes
+}
+
+// GetUserParticipation возвращает участие пользователя в одной из проводимых
+// викторин или nil, если он сейчас ни в одной не участвует. Состояние берется
+// из памяти и кэша без обращения к БД, поэтому метод можно опрашивать.
+func (qm *QuizManager) GetUserParticipation(userID uint) *ActiveParticipation {
+	qm.stateMutex.RLock()
+	states := make([]*quizmanager.ActiveQuizState, 0, len(qm.activeQuizzes))
+	for _, active := range qm.activeQuizzes {
+		states = append(states, active.state)
+	}
+	qm.stateMutex.RUnlock()
+	sort.Slice(states, func(i, j int) bool { return states[i].Quiz.ID < states[j].Quiz.ID })
+
+	for _, state := range states {
+		participating, eliminated := qm.answerProcessor.ParticipationStatus(state.Quiz.ID, userID)
+		if !participating {
+			continue
+		}
+
+		question, number, startMs := state.CurrentQuestionSnapshot()
+		nowMs := time.Now().UnixMilli()
+		participation := &ActiveParticipation{
+			QuizID:          state.Quiz.ID,
+			QuizTitle:       state.Quiz.Title,
+			Eliminated:      eliminated,
+			CurrentQuestion: number,
+			TotalQuestions:  len(state.Quiz.Questions),
+			ServerTimestamp: nowMs,
+		}
+		if question != nil && startMs > 0 {
+			windowStartMs := state.AnswerWindowStart(question.ID, userID, startMs, qm.config.MaxQuestionAckOffsetMs)
+			remaining := windowStartMs + int64(question.TimeLimitSec)*1000 - nowMs
+			if remaining > 0 {
+				participation.QuestionOpen = true
+				participation.RemainingMs = remaining
+			}
+		}
+		return participation
+	}
+	return nil
+}
+
+// StartReplay повторяет вопросы завершенной викторины с исходными таймингами
+// для новой аудитории. События помечаются replay=true, ответы не принимаются,
+// результаты не пересчитываются.
+func (qm *QuizManager) StartReplay(quizID uint) error {
+	quiz, err := qm.quizRepo.GetWithQuestions(quizID)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrQuizNotFound, err)
+	}
+	if !quiz.IsCompleted() || len(quiz.Questions) == 0 {
+		return ErrQuizNotReplayable
+	}
+
+	state := quizmanager.NewActiveQuizState(quiz)
+	state.Replay = true
+
+	qm.stateMutex.Lock()
+	if _, exists := qm.replays[quizID]; exists {
+		qm.stateMutex.Unlock()
+		return ErrReplayInProgress
+	}
+	replayCtx, replayCancel := context.WithCancel(qm.ctx)
+	qm.replays[quizID] = replayCancel
+	qm.stateMutex.Unlock()
+
+	log.Printf("[QuizManager] Запущен повтор викторины #%d (%d вопросов)", quizID, len(quiz.Questions))
+
+	go func() {
+		defer func() {
+			qm.stateMutex.Lock()
+			delete(qm.replays, quizID)
+			qm.stateMutex.Unlock()
+			replayCancel()
+		}()
+
+		if err := qm.questionManager.RunQuizQuestions(replayCtx, state); err != nil {
+			log.Printf("[QuizManager] Ошибка при повторе викторины #%d: %v", quizID, err)
+		}
+		if replayCtx.Err() != nil {
+			return
+		}
+
+		endEvent := map[string]interface{}{
+			"type": "quiz:end",
+			"data": map[string]interface{}{
+				"quiz_id": quizID,
+				"replay":  true,
+				"message": "Повтор викторины завершен",
+			},
+		}
+		if err := qm.wsManager.BroadcastEventToQuiz(quizID, endEvent); err != nil {
+			log.Printf("[QuizManager] Ошибка при отправке события quiz:end повтора викторины #%d: %v", quizID, err)
+		}
+	}()
+
+	return nil
 }
 
 // AutoFillQuizQuestions автоматически заполняет викторину вопросами
@@ -264,14 +1015,40 @@ func (qm *QuizManager) AutoFillQuizQuestions(quizID uint) error {
 	return qm.questionManager.AutoFillQuizQuestions(qm.ctx, quizID)
 }
 
-// Shutdown корректно завершает работу менеджера викторин
+// shutdownTimeout ограничивает ожидание записи накопленных ответов при остановке
+const shutdownTimeout = 10 * time.Second
+
+// Shutdown корректно завершает работу менеджера викторин: останавливает
+// обработку ответов и записывает в БД ответы, оставшиеся в буфере записи.
+// Ожидание ограничено shutdownTimeout.
 func (qm *QuizManager) Shutdown() {
 	log.Println("[QuizManager] Завершение работы менеджера викторин...")
 
 	// Отменяем контекст для завершения всех операций
 	qm.cancel()
 
-	// Здесь могли бы быть дополнительные действия по завершению работы
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	// Воркеры пула дописывают в буфер ответы, которые обрабатывают сейчас
+	poolStopped := make(chan struct{})
+	go func() {
+		qm.answerPool.Wait()
+		close(poolStopped)
+	}()
+	select {
+	case <-poolStopped:
+	case <-ctx.Done():
+		log.Println("[QuizManager] WARNING: Воркеры обработки ответов не остановились вовремя")
+	}
+
+	if err := qm.answerWriter.FlushWithRetry(ctx, qm.config.MaxRetries, qm.config.RetryInterval); err != nil {
+		log.Printf("[QuizManager] WARNING: Остановка: не удалось записать %d накопленных ответов: %v",
+			qm.answerWriter.Pending(), err)
+	}
+	if err := qm.answerWriter.Wait(ctx); err != nil {
+		log.Printf("[QuizManager] WARNING: Запись ответов не остановилась вовремя: %v", err)
+	}
 
 	log.Println("[QuizManager] Менеджер викторин остановлен")
 }
@@ -1,9 +1,12 @@
 package service
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strconv"
+	"sync"
 	"testing"
 	"time"
 
@@ -11,6 +14,8 @@ import (
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	"github.com/yourusername/trivia-api/internal/domain/entity"
+	"github.com/yourusername/trivia-api/internal/domain/repository"
+	"github.com/yourusername/trivia-api/internal/service/quizmanager"
 	"github.com/yourusername/trivia-api/internal/websocket"
 )
 
@@ -35,8 +40,8 @@ func (m *MockQuizRepository) GetWithQuestions(id uint) (*entity.Quiz, error) {
 	return args.Get(0).(*entity.Quiz), args.Error(1)
 }
 
-func (m *MockQuizRepository) UpdateStatus(id uint, status string) error {
-	args := m.Called(id, status)
+func (m *MockQuizRepository) UpdateStatus(id uint, from, to entity.QuizStatus) error {
+	args := m.Called(id, from, to)
 	return args.Error(0)
 }
 
@@ -51,13 +56,10 @@ func (m *MockQuizRepository) Create(quiz *entity.Quiz) error {
 	return args.Error(0)
 }
 
-// Добавляем недостающий метод GetActive
-func (m *MockQuizRepository) GetActive() (*entity.Quiz, error) {
+// Добавляем недостающий метод ListActive
+func (m *MockQuizRepository) ListActive() ([]entity.Quiz, error) {
 	args := m.Called()
-	if args.Get(0) == nil {
-		return nil, args.Error(1)
-	}
-	return args.Get(0).(*entity.Quiz), args.Error(1)
+	return args.Get(0).([]entity.Quiz), args.Error(1)
 }
 
 // Добавляем недостающий метод GetScheduled
@@ -99,6 +101,11 @@ func (m *MockCacheRepository) Delete(key string) error {
 	return args.Error(0)
 }
 
+func (m *MockCacheRepository) DeleteIfExists(key string) (bool, error) {
+	args := m.Called(key)
+	return args.Bool(0), args.Error(1)
+}
+
 // Добавляем недостающий метод Exists
 func (m *MockCacheRepository) Exists(key string) (bool, error) {
 	args := m.Called(key)
@@ -189,6 +196,11 @@ func (m *MockResultRepository) SaveUserAnswer(answer *entity.UserAnswer) error {
 	return args.Error(0)
 }
 
+func (m *MockResultRepository) SaveUserAnswersBatch(answers []*entity.UserAnswer) error {
+	args := m.Called(answers)
+	return args.Error(0)
+}
+
 func (m *MockResultRepository) CalculateRanks(quizID uint) error {
 	args := m.Called(quizID)
 	return args.Error(0)
@@ -528,7 +540,7 @@ func TestQuizManagerWithWebSocketMock(t *testing.T) {
 
 		// Настраиваем моки
 		mockQuizRepo.On("GetByID", quizID).Return(quiz, nil).Once()
-		mockQuizRepo.On("UpdateStatus", quizID, "cancelled").Return(nil).Once()
+		mockQuizRepo.On("UpdateStatus", quizID, entity.QuizStatusScheduled, entity.QuizStatusCancelled).Return(nil).Once()
 
 		// Симулируем несколько типов ошибок WebSocket
 		cancelQuizError := errors.New("simulated WebSocket failure during quiz cancellation")
@@ -547,7 +559,7 @@ func TestQuizManagerWithWebSocketMock(t *testing.T) {
 			}
 
 			// Обновляем статус
-			err = mockQuizRepo.UpdateStatus(quizID, "cancelled")
+			err = mockQuizRepo.UpdateStatus(quizID, entity.QuizStatusScheduled, entity.QuizStatusCancelled)
 			require.NoError(t, err, fmt.Sprintf("UpdateStatus не должен возвращать ошибку, но вернул: %v", err))
 
 			// Отправляем уведомление
@@ -692,3 +704,880 @@ func TestQuizManager_SendsCorrectOptionsFormat(t *testing.T) {
 	// Проверяем, что все моки были вызваны
 	mockWSManager.AssertExpectations(t)
 }
+
+// parallelQuizRepo отдает викторины с вопросами и запоминает завершенные и отмененные
+type parallelQuizRepo struct {
+	repository.QuizRepository
+	mu        sync.Mutex
+	quizzes   map[uint]*entity.Quiz
+	completed []uint
+	cancelled []uint
+}
+
+func (r *parallelQuizRepo) GetByID(id uint) (*entity.Quiz, error) {
+	quiz, ok := r.quizzes[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return quiz, nil
+}
+
+func (r *parallelQuizRepo) GetWithQuestions(id uint) (*entity.Quiz, error) {
+	return r.quizzes[id], nil
+}
+
+func (r *parallelQuizRepo) UpdateStatus(id uint, from, to entity.QuizStatus) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	switch to {
+	case entity.QuizStatusCancelled:
+		r.cancelled = append(r.cancelled, id)
+	case entity.QuizStatusCompleted:
+		r.completed = append(r.completed, id)
+	}
+	return nil
+}
+
+func (r *parallelQuizRepo) Cancelled() []uint {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]uint(nil), r.cancelled...)
+}
+
+func (r *parallelQuizRepo) Completed() []uint {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]uint(nil), r.completed...)
+}
+
+// parallelCache - кэш в памяти с атомарным SetNX
+type parallelCache struct {
+	repository.CacheRepository
+	mu       sync.Mutex
+	data     map[string]bool
+	counters map[string]int64
+}
+
+func (c *parallelCache) Set(key string, value interface{}, expiration time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.data[key] = true
+	return nil
+}
+
+func (c *parallelCache) Get(key string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if n, ok := c.counters[key]; ok {
+		return strconv.FormatInt(n, 10), nil
+	}
+	return "", nil
+}
+
+func (c *parallelCache) Exists(key string) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.data[key], nil
+}
+
+func (c *parallelCache) Delete(key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.data, key)
+	return nil
+}
+
+func (c *parallelCache) DeleteIfExists(key string) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ok := c.data[key]
+	delete(c.data, key)
+	return ok, nil
+}
+
+func (c *parallelCache) Increment(key string) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.counters == nil {
+		c.counters = make(map[string]int64)
+	}
+	c.counters[key]++
+	return c.counters[key], nil
+}
+
+func (c *parallelCache) ExpireAt(key string, expireTime time.Time) error { return nil }
+
+func (c *parallelCache) SetNX(key string, value interface{}, expiration time.Duration) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.data[key] {
+		return false, nil
+	}
+	c.data[key] = true
+	return true, nil
+}
+
+// parallelResults запоминает сохраненные ответы и викторины, по которым посчитаны ранги
+type parallelResults struct {
+	repository.ResultRepository
+	mu      sync.Mutex
+	answers []entity.UserAnswer
+	ranked  []uint
+}
+
+func (r *parallelResults) CalculateRanks(quizID uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ranked = append(r.ranked, quizID)
+	return nil
+}
+
+func (r *parallelResults) GetQuizResults(quizID uint) ([]entity.Result, error) {
+	return nil, nil
+}
+
+func (r *parallelResults) SaveUserAnswer(answer *entity.UserAnswer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.answers = append(r.answers, *answer)
+	return nil
+}
+
+func (r *parallelResults) SaveUserAnswersBatch(answers []*entity.UserAnswer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, answer := range answers {
+		r.answers = append(r.answers, *answer)
+	}
+	return nil
+}
+
+func (r *parallelResults) GetQuizUserAnswers(quizID uint) ([]entity.UserAnswer, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var answers []entity.UserAnswer
+	for _, a := range r.answers {
+		if a.QuizID == quizID {
+			answers = append(answers, a)
+		}
+	}
+	return answers, nil
+}
+
+func (r *parallelResults) QuizIDs() map[uint]uint {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	quizByQuestion := make(map[uint]uint)
+	for _, a := range r.answers {
+		quizByQuestion[a.QuestionID] = a.QuizID
+	}
+	return quizByQuestion
+}
+
+// silentHub принимает и отбрасывает все события
+type silentHub struct{}
+
+func (silentHub) BroadcastJSON(v interface{}) error                 { return nil }
+func (silentHub) SendJSONToUser(userID string, v interface{}) error { return nil }
+func (silentHub) SendToUser(userID string, message []byte) bool     { return true }
+func (silentHub) GetMetrics() map[string]interface{}                { return nil }
+func (silentHub) ClientCount() int                                  { return 0 }
+
+func parallelQuiz(id uint) *entity.Quiz {
+	// Запускаемая викторина уже переведена планировщиком в in_progress
+	quiz := &entity.Quiz{ID: id, Title: fmt.Sprintf("room %d", id), Status: entity.QuizStatusInProgress}
+	for n := uint(1); n <= 2; n++ {
+		quiz.Questions = append(quiz.Questions, entity.Question{
+			ID:            id*10 + n,
+			QuizID:        id,
+			Options:       entity.StringArray{"a", "b"},
+			CorrectOption: 1,
+			TimeLimitSec:  1,
+			PointValue:    10,
+		})
+	}
+	return quiz
+}
+
+// TestQuizManager_ParallelQuizzes проводит две викторины одновременно: ответы
+// попадают в свою викторину, а завершение одной не затрагивает другую
+func TestQuizManager_ParallelQuizzes(t *testing.T) {
+	quizRepo := &parallelQuizRepo{quizzes: map[uint]*entity.Quiz{1: parallelQuiz(1), 2: parallelQuiz(2)}}
+	cache := &parallelCache{data: make(map[string]bool)}
+	results := &parallelResults{}
+
+	wsManager := websocket.NewManager(silentHub{})
+	resultService := NewResultService(results, nil, quizRepo, nil, cache, nil, wsManager)
+	qm := NewQuizManager(quizRepo, nil, results, resultService, cache, wsManager, nil)
+	defer qm.Shutdown()
+	qm.config.QuestionDelayMs = 10
+	qm.config.AnswerRevealDelayMs = 5
+	qm.config.InterQuestionDelayMs = 10
+
+	require.NoError(t, qm.HandleReadyEvent(7, 1))
+	require.NoError(t, qm.HandleReadyEvent(7, 2))
+	qm.handleQuizStart(1)
+	qm.handleQuizStart(2)
+	qm.handleQuizStart(2) // Повторный запуск активной викторины игнорируется
+
+	active := qm.GetActiveQuizzes()
+	require.Len(t, active, 2)
+	assert.Equal(t, uint(1), active[0].ID)
+	assert.Equal(t, uint(2), active[1].ID)
+
+	// Отвечаем на первый вопрос каждой викторины, как только он открыт
+	for _, questionID := range []uint{11, 21} {
+		questionID := questionID
+		require.Eventually(t, func() bool {
+			return qm.ProcessAnswer(7, questionID, 1, time.Now().UnixMilli()) == nil
+		}, time.Second, 5*time.Millisecond, "ответ на вопрос #%d не принят", questionID)
+	}
+	assert.Error(t, qm.ProcessAnswer(7, 99, 1, time.Now().UnixMilli()), "вопрос вне активных викторин")
+
+	// Ключи кэша разведены по викторинам
+	assert.Eventually(t, func() bool {
+		ok1, _ := cache.Exists("quiz:1:question:11:start_time")
+		ok2, _ := cache.Exists("quiz:2:question:21:start_time")
+		return ok1 && ok2
+	}, time.Second, 5*time.Millisecond)
+
+	require.True(t, qm.finishQuiz(1))
+	active = qm.GetActiveQuizzes()
+	require.Len(t, active, 1)
+	assert.Equal(t, uint(2), active[0].ID, "завершение викторины #1 не должно затрагивать #2")
+	assert.False(t, qm.finishQuiz(1))
+
+	// Викторина #2 доходит до конца сама
+	require.Eventually(t, func() bool { return len(qm.GetActiveQuizzes()) == 0 }, 5*time.Second, 20*time.Millisecond)
+	assert.Equal(t, []uint{1, 2}, quizRepo.Completed())
+
+	require.Eventually(t, func() bool { return len(results.QuizIDs()) == 2 }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, map[uint]uint{11: 1, 21: 2}, results.QuizIDs())
+}
+
+// TestQuizManager_MaxConcurrentQuizzes проверяет, что запуск сверх лимита
+// одновременных викторин отклоняется, а отклоненная викторина отменяется
+func TestQuizManager_MaxConcurrentQuizzes(t *testing.T) {
+	quizRepo := &parallelQuizRepo{quizzes: map[uint]*entity.Quiz{1: parallelQuiz(1), 2: parallelQuiz(2), 3: parallelQuiz(3)}}
+	cache := &parallelCache{data: make(map[string]bool)}
+	results := &parallelResults{}
+
+	wsManager := websocket.NewManager(silentHub{})
+	resultService := NewResultService(results, nil, quizRepo, nil, cache, nil, wsManager)
+	qm := NewQuizManager(quizRepo, nil, results, resultService, cache, wsManager, nil)
+	defer qm.Shutdown()
+	qm.config.QuestionDelayMs = 10
+	qm.SetMaxConcurrentQuizzes(2)
+
+	require.NoError(t, qm.startQuiz(1))
+	require.NoError(t, qm.startQuiz(2))
+	assert.ErrorIs(t, qm.startQuiz(3), ErrTooManyActiveQuizzes)
+
+	qm.handleQuizStart(3)
+	assert.Len(t, qm.GetActiveQuizzes(), 2)
+	assert.Equal(t, []uint{3}, quizRepo.Cancelled(), "отклоненная викторина отменяется")
+
+	metrics := qm.GetConcurrencyMetrics()
+	assert.Equal(t, 2, metrics["active_quizzes"])
+	assert.Equal(t, 2, metrics["peak_active_quizzes"])
+	assert.Equal(t, 2, metrics["max_concurrent_quizzes"])
+	assert.Equal(t, int64(2), metrics["rejected_starts"])
+
+	// После завершения одной из викторин место освобождается
+	require.True(t, qm.finishQuiz(1))
+	require.NoError(t, qm.startQuiz(3))
+	assert.Equal(t, 2, qm.GetConcurrencyMetrics()["peak_active_quizzes"])
+
+	// 0 снимает ограничение
+	qm.SetMaxConcurrentQuizzes(0)
+	require.NoError(t, qm.startQuiz(1))
+	assert.Equal(t, 3, qm.GetConcurrencyMetrics()["peak_active_quizzes"])
+}
+
+// TestQuizManager_WatchdogFinishesStuckQuiz проверяет, что сторожевой таймер
+// завершает викторину, не уложившуюся в предельную длительность
+func TestQuizManager_WatchdogFinishesStuckQuiz(t *testing.T) {
+	quiz := parallelQuiz(1)
+	for i := range quiz.Questions {
+		quiz.Questions[i].TimeLimitSec = 60 // Вопросы заведомо не успевают закончиться
+	}
+	quizRepo := &parallelQuizRepo{quizzes: map[uint]*entity.Quiz{1: quiz}}
+	cache := &parallelCache{data: make(map[string]bool)}
+	results := &parallelResults{}
+
+	wsManager := websocket.NewManager(silentHub{})
+	resultService := NewResultService(results, nil, quizRepo, nil, cache, nil, wsManager)
+	qm := NewQuizManager(quizRepo, nil, results, resultService, cache, wsManager, nil)
+	defer qm.Shutdown()
+	qm.SetMaxDurationSlackFactor(0.5) // Некорректный множитель заменяется на 1
+	assert.Equal(t, 1.0, qm.config.MaxDurationSlackFactor)
+
+	require.NoError(t, qm.startQuiz(1))
+	require.Len(t, qm.GetActiveQuizzes(), 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	qm.runQuizWatchdog(ctx, 1, 10*time.Millisecond)
+	assert.Empty(t, qm.GetActiveQuizzes())
+	assert.Equal(t, []uint{1}, quizRepo.Completed())
+
+	// Таймер уже завершенной викторины ничего не делает
+	qm.runQuizWatchdog(ctx, 1, time.Millisecond)
+	assert.Equal(t, []uint{1}, quizRepo.Completed())
+
+	// Отмена контекста викторины останавливает таймер до срабатывания
+	require.NoError(t, qm.startQuiz(1))
+	cancel()
+	qm.runQuizWatchdog(ctx, 1, time.Hour)
+	assert.Len(t, qm.GetActiveQuizzes(), 1)
+}
+
+// TestQuizManager_GetUserParticipation проверяет статус участия для
+// переподключающегося клиента
+func TestQuizManager_GetUserParticipation(t *testing.T) {
+	quiz := parallelQuiz(2)
+	for i := range quiz.Questions {
+		quiz.Questions[i].TimeLimitSec = 5
+	}
+	quizRepo := &parallelQuizRepo{quizzes: map[uint]*entity.Quiz{1: parallelQuiz(1), 2: quiz}}
+	cache := &parallelCache{data: make(map[string]bool)}
+	results := &parallelResults{}
+
+	wsManager := websocket.NewManager(silentHub{})
+	resultService := NewResultService(results, nil, quizRepo, nil, cache, nil, wsManager)
+	qm := NewQuizManager(quizRepo, nil, results, resultService, cache, wsManager, nil)
+	defer qm.Shutdown()
+	qm.config.QuestionDelayMs = 10
+
+	require.NoError(t, cache.Set("quiz:2:ready_users:7", "1", time.Hour))
+	require.NoError(t, cache.Set("quiz:2:ready_users:8", "1", time.Hour))
+	require.NoError(t, cache.Set("quiz:2:eliminated:8", "1", time.Hour))
+	assert.Nil(t, qm.GetUserParticipation(7), "до старта викторины участия нет")
+
+	qm.handleQuizStart(1)
+	qm.handleQuizStart(2)
+
+	var participation *ActiveParticipation
+	require.Eventually(t, func() bool {
+		participation = qm.GetUserParticipation(7)
+		return participation != nil && participation.QuestionOpen
+	}, time.Second, 5*time.Millisecond)
+	assert.Equal(t, uint(2), participation.QuizID)
+	assert.False(t, participation.Eliminated)
+	assert.Equal(t, 1, participation.CurrentQuestion)
+	assert.Equal(t, 2, participation.TotalQuestions)
+	assert.InDelta(t, 5000, participation.RemainingMs, 1000)
+
+	eliminated := qm.GetUserParticipation(8)
+	require.NotNil(t, eliminated)
+	assert.True(t, eliminated.Eliminated)
+
+	assert.Nil(t, qm.GetUserParticipation(9), "пользователь не отмечался ни в одной викторине")
+
+	require.True(t, qm.finishQuiz(2))
+	assert.Nil(t, qm.GetUserParticipation(7), "после завершения викторины участия нет")
+}
+
+// TestQuizManager_ReplayDoesNotScore повторяет завершенную викторину: она не видна
+// как активная, ответы не принимаются, результаты и статус не меняются
+func TestQuizManager_ReplayDoesNotScore(t *testing.T) {
+	completed := parallelQuiz(1)
+	completed.Status = "completed"
+	scheduled := parallelQuiz(2)
+	scheduled.Status = "scheduled"
+	quizRepo := &parallelQuizRepo{quizzes: map[uint]*entity.Quiz{1: completed, 2: scheduled}}
+	cache := &parallelCache{data: make(map[string]bool)}
+	results := &parallelResults{}
+
+	wsManager := websocket.NewManager(silentHub{})
+	resultService := NewResultService(results, nil, quizRepo, nil, cache, nil, wsManager)
+	qm := NewQuizManager(quizRepo, nil, results, resultService, cache, wsManager, nil)
+	defer qm.Shutdown()
+	qm.config.QuestionDelayMs = 10
+	qm.config.AnswerRevealDelayMs = 5
+	qm.config.InterQuestionDelayMs = 10
+
+	assert.ErrorIs(t, qm.StartReplay(2), ErrQuizNotReplayable)
+
+	require.NoError(t, qm.StartReplay(1))
+	assert.ErrorIs(t, qm.StartReplay(1), ErrReplayInProgress)
+	assert.Empty(t, qm.GetActiveQuizzes())
+
+	// Пока идет первый вопрос повтора, ответ на него не принимается
+	time.Sleep(50 * time.Millisecond)
+	assert.Error(t, qm.ProcessAnswer(7, 11, 1, time.Now().UnixMilli()))
+
+	require.Eventually(t, func() bool {
+		qm.stateMutex.RLock()
+		defer qm.stateMutex.RUnlock()
+		return len(qm.replays) == 0
+	}, 5*time.Second, 20*time.Millisecond)
+
+	ok, _ := cache.Exists("quiz:1:question:11:start_time")
+	assert.False(t, ok, "время вопросов повтора не сохраняется")
+	assert.Empty(t, quizRepo.Completed())
+	assert.Empty(t, results.QuizIDs())
+	results.mu.Lock()
+	assert.Empty(t, results.ranked)
+	results.mu.Unlock()
+
+	// Завершенный повтор можно запустить снова
+	require.NoError(t, qm.StartReplay(1))
+}
+
+func TestQuizManager_CheckJoinAccess(t *testing.T) {
+	public, unlisted, private := parallelQuiz(1), parallelQuiz(2), parallelQuiz(3)
+	unlisted.Visibility = entity.VisibilityUnlisted
+	private.Visibility, private.InviteCode = entity.VisibilityPrivate, "ABCD2345"
+	quizRepo := &parallelQuizRepo{quizzes: map[uint]*entity.Quiz{1: public, 2: unlisted, 3: private}}
+	cache := &parallelCache{data: make(map[string]bool)}
+	results := &parallelResults{}
+
+	wsManager := websocket.NewManager(silentHub{})
+	resultService := NewResultService(results, nil, quizRepo, nil, cache, nil, wsManager)
+	qm := NewQuizManager(quizRepo, nil, results, resultService, cache, wsManager, nil)
+	defer qm.Shutdown()
+
+	assert.NoError(t, qm.CheckJoinAccess(1, 7, ""))
+	assert.NoError(t, qm.CheckJoinAccess(2, 7, ""), "unlisted викторина доступна по ID без кода")
+	assert.ErrorIs(t, qm.CheckJoinAccess(3, 7, ""), ErrInviteCodeRequired)
+	assert.ErrorIs(t, qm.CheckJoinAccess(3, 7, "WRONG234"), ErrInvalidInviteCode)
+	assert.NoError(t, qm.CheckJoinAccess(3, 7, "abcd2345"))
+	assert.ErrorIs(t, qm.CheckJoinAccess(42, 7, ""), ErrQuizNotFound)
+}
+
+// TestQuizManager_GetQuizSync_PrivateQuiz: снимок приватной викторины получает
+// только занявший место игрок или знающий код приглашения
+func TestQuizManager_GetQuizSync_PrivateQuiz(t *testing.T) {
+	private := parallelQuiz(3)
+	private.Status = entity.QuizStatusScheduled
+	private.Visibility, private.InviteCode = entity.VisibilityPrivate, "ABCD2345"
+	quizRepo := &parallelQuizRepo{quizzes: map[uint]*entity.Quiz{3: private}}
+	cache := &parallelCache{data: make(map[string]bool)}
+	results := &parallelResults{}
+
+	wsManager := websocket.NewManager(silentHub{})
+	resultService := NewResultService(results, nil, quizRepo, nil, cache, nil, wsManager)
+	qm := NewQuizManager(quizRepo, nil, results, resultService, cache, wsManager, nil)
+	defer qm.Shutdown()
+
+	_, err := qm.GetQuizSync(3, 7, "")
+	assert.ErrorIs(t, err, ErrInviteCodeRequired)
+	_, err = qm.GetQuizSync(3, 7, "WRONG234")
+	assert.ErrorIs(t, err, ErrInvalidInviteCode)
+
+	snapshot, err := qm.GetQuizSync(3, 7, "abcd2345")
+	require.NoError(t, err)
+	assert.Equal(t, uint(3), snapshot.QuizID)
+
+	// Занявшему место игроку код больше не нужен
+	_, err = qm.JoinQuiz(3, 8, "ABCD2345")
+	require.NoError(t, err)
+	_, err = qm.GetQuizSync(3, 8, "")
+	assert.NoError(t, err)
+}
+
+func TestQuizManager_JoinQuiz_InviteCode(t *testing.T) {
+	private := parallelQuiz(3)
+	private.Visibility, private.InviteCode = entity.VisibilityPrivate, "ABCD2345"
+	quizRepo := &parallelQuizRepo{quizzes: map[uint]*entity.Quiz{3: private}}
+	cache := &parallelCache{data: make(map[string]bool)}
+	results := &parallelResults{}
+
+	wsManager := websocket.NewManager(silentHub{})
+	resultService := NewResultService(results, nil, quizRepo, nil, cache, nil, wsManager)
+	qm := NewQuizManager(quizRepo, nil, results, resultService, cache, wsManager, nil)
+	defer qm.Shutdown()
+	qm.SetRequireJoin(true)
+
+	_, err := qm.JoinQuiz(3, 7, "")
+	assert.ErrorIs(t, err, ErrInviteCodeRequired)
+	_, err = qm.JoinQuiz(3, 7, "WRONG234")
+	assert.ErrorIs(t, err, ErrInvalidInviteCode)
+	assert.ErrorIs(t, qm.HandleReadyEvent(7, 3), ErrJoinRequired, "без присоединения user:ready отклоняется")
+
+	state, err := qm.JoinQuiz(3, 7, "abcd2345")
+	require.NoError(t, err)
+	assert.Equal(t, uint(3), state.QuizID)
+	assert.Equal(t, private.ScheduledTime, state.ScheduledTime)
+	_, err = qm.JoinQuiz(3, 7, "abcd2345")
+	assert.NoError(t, err, "повторное присоединение допустимо")
+	assert.NoError(t, qm.HandleReadyEvent(7, 3))
+
+	require.NoError(t, qm.LeaveQuiz(3, 7))
+	assert.ErrorIs(t, qm.LeaveQuiz(3, 7), ErrNotQuizParticipant)
+	assert.ErrorIs(t, qm.HandleReadyEvent(7, 3), ErrJoinRequired, "после выхода нужно присоединиться снова")
+}
+
+func TestQuizManager_QuizStateErrors(t *testing.T) {
+	running, completed, cancelled := parallelQuiz(1), parallelQuiz(2), parallelQuiz(3)
+	running.Status, completed.Status, cancelled.Status = "in_progress", "completed", "cancelled"
+	quizRepo := &parallelQuizRepo{quizzes: map[uint]*entity.Quiz{1: running, 2: completed, 3: cancelled}}
+	cache := &parallelCache{data: make(map[string]bool)}
+	results := &parallelResults{}
+
+	wsManager := websocket.NewManager(silentHub{})
+	resultService := NewResultService(results, nil, quizRepo, nil, cache, nil, wsManager)
+	qm := NewQuizManager(quizRepo, nil, results, resultService, cache, wsManager, nil)
+	defer qm.Shutdown()
+
+	// Присоединение
+	assert.NoError(t, qm.CheckJoinAccess(1, 7, ""), "к идущей викторине проверяется правило присоединения, а не доступ")
+	assert.ErrorIs(t, qm.CheckJoinAccess(2, 7, ""), ErrQuizFinished)
+	assert.ErrorIs(t, qm.CheckJoinAccess(3, 7, ""), ErrQuizFinished)
+
+	// Отмена
+	assert.ErrorIs(t, qm.CancelQuiz(1), ErrQuizAlreadyStarted)
+	assert.ErrorIs(t, qm.CancelQuiz(2), ErrQuizFinished)
+	assert.ErrorIs(t, qm.CancelQuiz(42), ErrQuizNotFound)
+
+	// Ответ без проводимой викторины
+	assert.ErrorIs(t, qm.ProcessAnswer(7, 10, 1, time.Now().UnixMilli()), ErrQuizNotActive)
+}
+
+// externalEligibilityStub - внешняя проверка участия с заданным ответом
+type externalEligibilityStub struct {
+	err   error
+	calls int
+}
+
+func (s *externalEligibilityStub) CheckEligibility(context.Context, *entity.Quiz, *entity.User) error {
+	s.calls++
+	return s.err
+}
+
+func TestQuizManager_CheckJoinAccess_Eligibility(t *testing.T) {
+	open, verified, veteran, external := parallelQuiz(1), parallelQuiz(2), parallelQuiz(3), parallelQuiz(4)
+	verified.RequireVerifiedEmail = true
+	veteran.MinGamesPlayed = 5
+	external.ExternalEligibilityCheck = true
+	quizRepo := &parallelQuizRepo{quizzes: map[uint]*entity.Quiz{1: open, 2: verified, 3: veteran, 4: external}}
+	users := &stubUserRepository{users: map[uint]*entity.User{
+		7: {ID: 7, GamesPlayed: 2},
+		8: {ID: 8, GamesPlayed: 5, EmailVerified: true},
+	}}
+	cache := &parallelCache{data: make(map[string]bool)}
+	results := &parallelResults{}
+
+	wsManager := websocket.NewManager(silentHub{})
+	resultService := NewResultService(results, users, quizRepo, nil, cache, nil, wsManager)
+	qm := NewQuizManager(quizRepo, nil, results, resultService, cache, wsManager, nil)
+	defer qm.Shutdown()
+
+	// Викторина без условий открыта всем, пользователь даже не запрашивается
+	assert.NoError(t, qm.CheckJoinAccess(1, 42, ""))
+
+	err := qm.CheckJoinAccess(2, 7, "")
+	assert.ErrorIs(t, err, ErrNotEligible)
+	assert.Contains(t, err.Error(), "verified email")
+	assert.NoError(t, qm.CheckJoinAccess(2, 8, ""))
+
+	err = qm.CheckJoinAccess(3, 7, "")
+	assert.ErrorIs(t, err, ErrNotEligible)
+	assert.Contains(t, err.Error(), "at least 5 games played")
+	assert.NoError(t, qm.CheckJoinAccess(3, 8, ""))
+
+	// Без заданной внешней проверки викторина открыта
+	assert.NoError(t, qm.CheckJoinAccess(4, 7, ""))
+
+	stub := &externalEligibilityStub{err: Ineligible("prize pool is limited to residents")}
+	qm.SetExternalEligibilityChecker(stub)
+	err = qm.CheckJoinAccess(4, 7, "")
+	assert.ErrorIs(t, err, ErrNotEligible)
+	assert.Contains(t, err.Error(), "residents")
+	assert.NoError(t, qm.CheckJoinAccess(3, 8, ""), "внешняя проверка применяется только к викторинам, где она включена")
+	assert.Equal(t, 1, stub.calls)
+
+	// Сбой внешней проверки не открывает доступ
+	stub.err = errors.New("connection refused")
+	assert.ErrorIs(t, qm.CheckJoinAccess(4, 8, ""), ErrNotEligible)
+}
+
+// TestQuizManager_SelfPaced проводит викторину с самостоятельным прохождением:
+// игрок получает вопросы по одному в своем темпе, а викторину завершает общий срок
+func TestQuizManager_SelfPaced(t *testing.T) {
+	quiz := parallelQuiz(1)
+	quiz.PacingMode = entity.PacingSelfPaced
+	quiz.SelfPacedTimeLimitSec = 1
+	quizRepo := &parallelQuizRepo{quizzes: map[uint]*entity.Quiz{1: quiz}}
+	cache := &parallelCache{data: make(map[string]bool)}
+	results := &parallelResults{}
+
+	wsManager := websocket.NewManager(silentHub{})
+	resultService := NewResultService(results, nil, quizRepo, nil, cache, nil, wsManager)
+	qm := NewQuizManager(quizRepo, nil, results, resultService, cache, wsManager, nil)
+	defer qm.Shutdown()
+
+	require.NoError(t, qm.HandleReadyEvent(7, 1))
+	qm.handleQuizStart(1)
+	require.Len(t, qm.GetActiveQuizzes(), 1)
+
+	// До user:ready после старта игрок не получил вопрос и ответить не может
+	assert.Error(t, qm.ProcessAnswer(7, 11, 1, time.Now().UnixMilli()))
+	require.NoError(t, qm.HandleReadyEvent(7, 1))
+	assert.ErrorIs(t, qm.HandleReadyEvent(8, 1), ErrQuizAlreadyStarted, "при before_start_only новые игроки после старта не допускаются")
+
+	require.NoError(t, qm.ProcessAnswer(7, 11, 1, time.Now().UnixMilli()))
+	assert.Error(t, qm.ProcessAnswer(7, 11, 1, time.Now().UnixMilli()), "повторный ответ на пройденный вопрос")
+	// Следующий вопрос выдается после обработки ответа
+	require.Eventually(t, func() bool {
+		return qm.ProcessAnswer(7, 12, 0, time.Now().UnixMilli()) == nil
+	}, time.Second, 5*time.Millisecond)
+
+	require.Eventually(t, func() bool {
+		results.mu.Lock()
+		defer results.mu.Unlock()
+		return len(results.answers) == 2
+	}, time.Second, 5*time.Millisecond)
+	results.mu.Lock()
+	assert.Equal(t, 10, results.answers[0].Score)
+	assert.False(t, results.answers[1].IsCorrect)
+	assert.False(t, results.answers[1].IsEliminated, "при самостоятельном прохождении игроки не выбывают")
+	results.mu.Unlock()
+
+	// По истечении общего срока викторина завершается сама
+	require.Eventually(t, func() bool { return len(qm.GetActiveQuizzes()) == 0 }, 3*time.Second, 20*time.Millisecond)
+	assert.Equal(t, []uint{1}, quizRepo.Completed())
+	assert.ErrorIs(t, qm.ProcessAnswer(7, 12, 1, time.Now().UnixMilli()), ErrQuizNotActive)
+}
+
+// TestQuizManager_SelfPacedOverloadedPool: ответ, отклоненный переполненной
+// очередью, не переводит игрока к следующему вопросу
+func TestQuizManager_SelfPacedOverloadedPool(t *testing.T) {
+	quiz := parallelQuiz(1)
+	quiz.PacingMode = entity.PacingSelfPaced
+	quiz.SelfPacedTimeLimitSec = 60
+	quizRepo := &parallelQuizRepo{quizzes: map[uint]*entity.Quiz{1: quiz}}
+	cache := &parallelCache{data: make(map[string]bool)}
+	results := &parallelResults{}
+
+	wsManager := websocket.NewManager(silentHub{})
+	resultService := NewResultService(results, nil, quizRepo, nil, cache, nil, wsManager)
+	qm := NewQuizManager(quizRepo, nil, results, resultService, cache, wsManager, nil)
+	defer qm.Shutdown()
+
+	require.NoError(t, qm.HandleReadyEvent(7, 1))
+	qm.handleQuizStart(1)
+	require.NoError(t, qm.HandleReadyEvent(7, 1))
+
+	// Пул без воркеров с заполненной очередью и без буфера отложенных ответов
+	pool := qm.answerPool
+	overloaded := quizmanager.NewAnswerPool(&quizmanager.Config{AnswerQueueSize: 1},
+		&quizmanager.Dependencies{WSManager: wsManager}, nil)
+	require.NoError(t, overloaded.Submit(&quizmanager.AnswerSubmission{UserID: 99}))
+	qm.answerPool = overloaded
+
+	assert.ErrorIs(t, qm.ProcessAnswer(7, 11, 1, time.Now().UnixMilli()), quizmanager.ErrAnswerQueueOverloaded)
+	qm.stateMutex.RLock()
+	run := qm.activeQuizzes[1].state.SelfPaced
+	qm.stateMutex.RUnlock()
+	answered, _ := run.Progress(7)
+	assert.Zero(t, answered, "отклоненный ответ не засчитывается как пройденный вопрос")
+
+	qm.answerPool = pool
+	require.NoError(t, qm.ProcessAnswer(7, 11, 1, time.Now().UnixMilli()), "игрок отвечает на тот же вопрос повторно")
+	require.Eventually(t, func() bool {
+		results.mu.Lock()
+		defer results.mu.Unlock()
+		return len(results.answers) == 1
+	}, time.Second, 5*time.Millisecond)
+}
+
+// TestQuizManager_AutoAdvance проверяет досрочное закрытие вопросов: отметившиеся
+// до старта игроки ответили или отключились - викторина не ждет таймеров
+func TestQuizManager_AutoAdvance(t *testing.T) {
+	quiz := parallelQuiz(1)
+	quiz.AutoAdvance = true
+	for i := range quiz.Questions {
+		quiz.Questions[i].TimeLimitSec = 10
+	}
+	quizRepo := &parallelQuizRepo{quizzes: map[uint]*entity.Quiz{1: quiz}}
+	cache := &parallelCache{data: make(map[string]bool)}
+	results := &parallelResults{}
+
+	wsManager := websocket.NewManager(silentHub{})
+	resultService := NewResultService(results, nil, quizRepo, nil, cache, nil, wsManager)
+	qm := NewQuizManager(quizRepo, nil, results, resultService, cache, wsManager, nil)
+	defer qm.Shutdown()
+	qm.config.QuestionDelayMs = 10
+	qm.config.AnswerRevealDelayMs = 5
+	qm.config.InterQuestionDelayMs = 10
+	qm.config.DisconnectGrace = 50 * time.Millisecond
+
+	require.NoError(t, qm.HandleReadyEvent(7, 1))
+	require.NoError(t, qm.HandleReadyEvent(8, 1))
+	started := time.Now()
+	qm.handleQuizStart(1)
+
+	answer := func(userID, questionID uint) {
+		require.Eventually(t, func() bool {
+			return qm.ProcessAnswer(userID, questionID, 1, time.Now().UnixMilli()) == nil
+		}, time.Second, 5*time.Millisecond, "ответ пользователя #%d на вопрос #%d не принят", userID, questionID)
+	}
+	answer(7, 11)
+	answer(8, 11)
+	// Пока ждут второго вопроса, игрок #8 отключается - ждать его ответа не нужно
+	answer(7, 12)
+	qm.HandleDisconnect(8)
+
+	require.Eventually(t, func() bool { return len(qm.GetActiveQuizzes()) == 0 }, 5*time.Second, 20*time.Millisecond)
+	assert.Less(t, time.Since(started), 10*time.Second, "вопросы должны закрываться досрочно")
+	assert.Equal(t, []uint{1}, quizRepo.Completed())
+}
+
+// TestQuizManager_AnswerAfterReconnect: игрок теряет соединение во время вопроса,
+// переподключается и отвечает по новому соединению. Ответ принимается по ID
+// пользователя и вопроса и засчитывается, а вопрос с auto_advance не
+// закрывается досрочно, пока игрок переподключается.
+func TestQuizManager_AnswerAfterReconnect(t *testing.T) {
+	quiz := parallelQuiz(1)
+	quiz.AutoAdvance = true
+	for i := range quiz.Questions {
+		quiz.Questions[i].TimeLimitSec = 10
+	}
+	quizRepo := &parallelQuizRepo{quizzes: map[uint]*entity.Quiz{1: quiz}}
+	cache := &parallelCache{data: make(map[string]bool)}
+	results := &parallelResults{}
+
+	wsManager := websocket.NewManager(silentHub{})
+	resultService := NewResultService(results, nil, quizRepo, nil, cache, nil, wsManager)
+	qm := NewQuizManager(quizRepo, nil, results, resultService, cache, wsManager, nil)
+	defer qm.Shutdown()
+	qm.config.QuestionDelayMs = 10
+	qm.config.AnswerRevealDelayMs = 5
+	qm.config.InterQuestionDelayMs = 10
+	qm.SetDisconnectGrace(2 * time.Second)
+
+	require.NoError(t, qm.HandleReadyEvent(7, 1))
+	require.NoError(t, qm.HandleReadyEvent(8, 1))
+	qm.handleQuizStart(1)
+
+	answer := func(userID, questionID uint) {
+		require.Eventually(t, func() bool {
+			return qm.ProcessAnswer(userID, questionID, 1, time.Now().UnixMilli()) == nil
+		}, time.Second, 5*time.Millisecond, "ответ пользователя #%d на вопрос #%d не принят", userID, questionID)
+	}
+	answered := func(userID, questionID uint) *entity.UserAnswer {
+		results.mu.Lock()
+		defer results.mu.Unlock()
+		for i := range results.answers {
+			if results.answers[i].UserID == userID && results.answers[i].QuestionID == questionID {
+				return &results.answers[i]
+			}
+		}
+		return nil
+	}
+
+	// Соединение игрока #8 обрывается, пока игрок #7 отвечает
+	answer(7, 11)
+	qm.HandleDisconnect(8)
+	time.Sleep(100 * time.Millisecond)
+	participation := qm.GetUserParticipation(7)
+	require.NotNil(t, participation)
+	assert.Equal(t, 1, participation.CurrentQuestion, "вопрос ждет переподключения игрока")
+
+	// Новое соединение: игрок снова отмечается и отвечает на тот же вопрос
+	require.NoError(t, qm.HandleReadyEvent(8, 1))
+	answer(8, 11)
+	require.Eventually(t, func() bool { return answered(8, 11) != nil }, 2*time.Second, 10*time.Millisecond)
+	saved := answered(8, 11)
+	assert.True(t, saved.IsCorrect)
+	assert.Positive(t, saved.Score, "ответ после переподключения засчитывается")
+	assert.False(t, saved.IsEliminated)
+
+	answer(7, 12)
+	answer(8, 12)
+	require.Eventually(t, func() bool { return len(qm.GetActiveQuizzes()) == 0 }, 5*time.Second, 20*time.Millisecond)
+}
+
+// TestQuizManager_ShutdownFlushesAnswers: ответы, накопленные в буфере пакетной
+// записи, записываются в БД при остановке менеджера
+func TestQuizManager_ShutdownFlushesAnswers(t *testing.T) {
+	quizRepo := &parallelQuizRepo{quizzes: map[uint]*entity.Quiz{}}
+	cache := &parallelCache{data: make(map[string]bool)}
+	results := &parallelResults{}
+
+	wsManager := websocket.NewManager(silentHub{})
+	qm := NewQuizManager(quizRepo, nil, results, nil, cache, wsManager, nil)
+	qm.SetAnswerBatching(100, time.Hour) // Без Shutdown ответы остались бы в буфере
+
+	for userID := uint(1); userID <= 3; userID++ {
+		require.NoError(t, qm.answerWriter.Add(&entity.UserAnswer{UserID: userID, QuizID: 1, QuestionID: 11}))
+	}
+	require.Equal(t, 3, qm.answerWriter.Pending())
+
+	qm.Shutdown()
+	assert.Equal(t, 0, qm.answerWriter.Pending())
+	answers, err := results.GetQuizUserAnswers(1)
+	require.NoError(t, err)
+	assert.Len(t, answers, 3)
+}
+
+// TestQuizManager_FullQuizRejectsAnswers: игрок, не получивший места в заполненной
+// викторине, не может отвечать - его ответы не сохраняются и не засчитываются
+func TestQuizManager_FullQuizRejectsAnswers(t *testing.T) {
+	quiz := parallelQuiz(1)
+	quiz.MaxPlayers = 1
+	quizRepo := &parallelQuizRepo{quizzes: map[uint]*entity.Quiz{1: quiz}}
+	cache := &parallelCache{data: make(map[string]bool)}
+	results := &parallelResults{}
+
+	wsManager := websocket.NewManager(silentHub{})
+	resultService := NewResultService(results, nil, quizRepo, nil, cache, nil, wsManager)
+	qm := NewQuizManager(quizRepo, nil, results, resultService, cache, wsManager, nil)
+	defer qm.Shutdown()
+	qm.config.QuestionDelayMs = 10
+	qm.config.AnswerRevealDelayMs = 5
+	qm.config.InterQuestionDelayMs = 10
+
+	require.NoError(t, qm.HandleReadyEvent(7, 1))
+	assert.ErrorIs(t, qm.HandleReadyEvent(8, 1), ErrQuizFull)
+	qm.handleQuizStart(1)
+
+	for _, userID := range []uint{7, 8} {
+		userID := userID
+		require.Eventually(t, func() bool {
+			return qm.ProcessAnswer(userID, 11, 1, time.Now().UnixMilli()) == nil
+		}, time.Second, 5*time.Millisecond)
+	}
+	require.Eventually(t, func() bool { return len(qm.GetActiveQuizzes()) == 0 }, 5*time.Second, 20*time.Millisecond)
+
+	answers, err := results.GetQuizUserAnswers(1)
+	require.NoError(t, err)
+	require.Len(t, answers, 1)
+	assert.Equal(t, uint(7), answers[0].UserID, "ответ игрока без места не сохраняется")
+}
+
+// TestQuizManager_LateJoinRejectedAnswers: игрок, опоздавший к викторине с
+// присоединением только до начала, не должен набирать очки
+func TestQuizManager_LateJoinRejectedAnswers(t *testing.T) {
+	quiz := parallelQuiz(1)
+	quiz.JoinPolicy = entity.JoinPolicyBeforeStartOnly
+	quizRepo := &parallelQuizRepo{quizzes: map[uint]*entity.Quiz{1: quiz}}
+	cache := &parallelCache{data: make(map[string]bool)}
+	results := &parallelResults{}
+
+	wsManager := websocket.NewManager(silentHub{})
+	resultService := NewResultService(results, nil, quizRepo, nil, cache, nil, wsManager)
+	qm := NewQuizManager(quizRepo, nil, results, resultService, cache, wsManager, nil)
+	defer qm.Shutdown()
+	qm.config.QuestionDelayMs = 10
+	qm.config.AnswerRevealDelayMs = 5
+	qm.config.InterQuestionDelayMs = 10
+
+	require.NoError(t, qm.HandleReadyEvent(7, 1))
+	qm.handleQuizStart(1)
+	require.Eventually(t, func() bool {
+		return qm.ProcessAnswer(7, 11, 1, time.Now().UnixMilli()) == nil
+	}, time.Second, 5*time.Millisecond)
+
+	assert.ErrorIs(t, qm.HandleReadyEvent(8, 1), ErrQuizAlreadyStarted)
+	require.NoError(t, qm.ProcessAnswer(8, 11, 1, time.Now().UnixMilli()))
+	require.Eventually(t, func() bool { return len(qm.GetActiveQuizzes()) == 0 }, 5*time.Second, 20*time.Millisecond)
+
+	answers, err := results.GetQuizUserAnswers(1)
+	require.NoError(t, err)
+	for _, answer := range answers {
+		assert.Equal(t, uint(7), answer.UserID, "ответ опоздавшего игрока не сохраняется")
+	}
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/yourusername/trivia-api/internal/domain/entity"
+)
+
+// CountryParticipation - число игроков викторины из одной страны
+type CountryParticipation struct {
+	Country string `json:"country"`
+	Players int    `json:"players"`
+}
+
+// QuizParticipation - аналитика участия в викторине по итоговым результатам
+type QuizParticipation struct {
+	QuizID  uint `json:"quiz_id"`
+	Players int  `json:"players"`
+	// Число разных стран среди игроков с определенным местоположением
+	Countries int `json:"countries"`
+	// Игроки, местоположение которых не определено
+	UnknownCountry int                    `json:"unknown_country"`
+	ByCountry      []CountryParticipation `json:"by_country"`
+}
+
+// GetQuizParticipation возвращает распределение игроков викторины по странам.
+// Страна определяется по IP-адресу подключения, если включен провайдер геолокации.
+func (s *ResultService) GetQuizParticipation(quizID uint) (*QuizParticipation, error) {
+	if _, err := s.quizRepo.GetByID(quizID); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrQuizNotFound, err)
+	}
+	results, err := s.resultRepo.GetQuizResults(quizID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get results of quiz %d: %w", quizID, err)
+	}
+	return summarizeParticipation(quizID, results), nil
+}
+
+// summarizeParticipation группирует результаты по странам: сначала страны
+// с большим числом игроков, при равенстве - по коду страны
+func summarizeParticipation(quizID uint, results []entity.Result) *QuizParticipation {
+	participation := &QuizParticipation{
+		QuizID:    quizID,
+		Players:   len(results),
+		ByCountry: []CountryParticipation{},
+	}
+
+	counts := make(map[string]int)
+	for _, result := range results {
+		if result.Country == "" {
+			participation.UnknownCountry++
+			continue
+		}
+		counts[result.Country]++
+	}
+	for country, players := range counts {
+		participation.ByCountry = append(participation.ByCountry, CountryParticipation{Country: country, Players: players})
+	}
+	sort.Slice(participation.ByCountry, func(i, j int) bool {
+		a, b := participation.ByCountry[i], participation.ByCountry[j]
+		if a.Players != b.Players {
+			return a.Players > b.Players
+		}
+		return a.Country < b.Country
+	})
+	participation.Countries = len(participation.ByCountry)
+	return participation
+}
@@ -0,0 +1,33 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yourusername/trivia-api/internal/domain/entity"
+)
+
+func TestSummarizeParticipation(t *testing.T) {
+	results := []entity.Result{
+		{UserID: 1, Country: "DE"},
+		{UserID: 2, Country: "FR"},
+		{UserID: 3, Country: "DE"},
+		{UserID: 4},
+		{UserID: 5, Country: "AT"},
+	}
+
+	participation := summarizeParticipation(7, results)
+	assert.Equal(t, uint(7), participation.QuizID)
+	assert.Equal(t, 5, participation.Players)
+	assert.Equal(t, 3, participation.Countries)
+	assert.Equal(t, 1, participation.UnknownCountry)
+	assert.Equal(t, []CountryParticipation{
+		{Country: "DE", Players: 2},
+		{Country: "AT", Players: 1},
+		{Country: "FR", Players: 1},
+	}, participation.ByCountry)
+
+	empty := summarizeParticipation(7, nil)
+	assert.Zero(t, empty.Countries)
+	assert.NotNil(t, empty.ByCountry, "пустой список отдается как [], а не null")
+}
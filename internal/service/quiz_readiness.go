@@ -0,0 +1,129 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/yourusername/trivia-api/internal/domain/entity"
+	"github.com/yourusername/trivia-api/internal/service/quizmanager"
+)
+
+// Названия проверок готовности викторины
+const (
+	ReadinessCheckStatus        = "status"
+	ReadinessCheckQuestionCount = "question_count"
+	ReadinessCheckQuestions     = "questions_valid"
+	ReadinessCheckScheduledTime = "scheduled_time"
+)
+
+// ReadinessCheck - результат одной проверки готовности викторины
+type ReadinessCheck struct {
+	Name    string `json:"name"`
+	Passed  bool   `json:"passed"`
+	Message string `json:"message,omitempty"`
+}
+
+// QuizReadiness - результат проверки готовности викторины к проведению
+type QuizReadiness struct {
+	QuizID uint             `json:"quiz_id"`
+	Ready  bool             `json:"ready"`
+	Checks []ReadinessCheck `json:"checks"`
+	// Расчетная длительность цикла вопросов без анонса и зала ожидания
+	EstimatedDurationSec int `json:"estimated_duration_sec"`
+}
+
+// CheckReadiness выполняет пробную проверку викторины перед проведением:
+// статус, число и корректность вопросов, время проведения и расчетную длительность.
+// Викторина и вопросы не изменяются.
+func (s *QuizService) CheckReadiness(quizID uint) (*QuizReadiness, error) {
+	quiz, err := s.quizRepo.GetByID(quizID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrQuizNotFound, err)
+	}
+	questions, err := s.questionRepo.GetByQuizID(quizID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get questions of quiz %d: %w", quizID, err)
+	}
+
+	readiness := &QuizReadiness{
+		QuizID: quizID,
+		Checks: []ReadinessCheck{
+			checkReadinessStatus(quiz),
+			checkReadinessQuestionCount(len(questions)),
+			s.checkReadinessQuestions(questions),
+			s.checkReadinessScheduledTime(quiz.ScheduledTime, time.Now()),
+		},
+		EstimatedDurationSec: int(quizmanager.DefaultConfig().ExpectedQuizDuration(questions).Seconds()) + len(questions)*quiz.QuestionReadTimeSec,
+	}
+	readiness.Ready = true
+	for _, check := range readiness.Checks {
+		readiness.Ready = readiness.Ready && check.Passed
+	}
+	return readiness, nil
+}
+
+func checkReadinessStatus(quiz *entity.Quiz) ReadinessCheck {
+	check := ReadinessCheck{Name: ReadinessCheckStatus, Passed: quiz.IsScheduled()}
+	if !check.Passed {
+		check.Message = fmt.Sprintf("quiz status is %q, expected scheduled", quiz.Status)
+	}
+	return check
+}
+
+func checkReadinessQuestionCount(count int) ReadinessCheck {
+	check := ReadinessCheck{Name: ReadinessCheckQuestionCount, Passed: count > 0 && count <= MaxQuizQuestions}
+	switch {
+	case count == 0:
+		check.Message = "quiz has no questions"
+	case count > MaxQuizQuestions:
+		check.Message = fmt.Sprintf("quiz has %d questions, maximum is %d", count, MaxQuizQuestions)
+	case count < MaxQuizQuestions:
+		// Недостающие вопросы добавляет автозаполнение перед стартом
+		check.Message = fmt.Sprintf("%d of %d questions, the rest will be auto-filled before start", count, MaxQuizQuestions)
+	}
+	return check
+}
+
+func (s *QuizService) checkReadinessQuestions(questions []entity.Question) ReadinessCheck {
+	var problems []string
+	for i := range questions {
+		if err := s.validateReadyQuestion(i, &questions[i]); err != nil {
+			problems = append(problems, strings.TrimPrefix(err.Error(), ErrValidation.Error()+": "))
+		}
+	}
+	check := ReadinessCheck{Name: ReadinessCheckQuestions, Passed: len(problems) == 0}
+	if !check.Passed {
+		check.Message = strings.Join(problems, "; ")
+	}
+	return check
+}
+
+// validateReadyQuestion проверяет вопрос так же, как при добавлении, и дополнительно
+// то, что могло устареть после изменения ограничений: текст, лимит времени и порядок
+func (s *QuizService) validateReadyQuestion(index int, q *entity.Question) error {
+	if strings.TrimSpace(q.Text) == "" {
+		return fmt.Errorf("%w: question %d has empty text", ErrValidation, index+1)
+	}
+	if q.TimeLimitSec <= 0 {
+		return fmt.Errorf("%w: question %d time_limit_sec must be positive, got %d", ErrValidation, index+1, q.TimeLimitSec)
+	}
+	for i, option := range q.Options {
+		if strings.TrimSpace(option) == "" {
+			return fmt.Errorf("%w: question %d option %d is empty", ErrValidation, index+1, i+1)
+		}
+	}
+	if q.IsOrdering() && len(q.CorrectOrder) != len(q.Options) {
+		return fmt.Errorf("%w: question %d correct_order must list all %d options", ErrValidation, index+1, len(q.Options))
+	}
+	return s.validateQuestionOptions(index, q)
+}
+
+func (s *QuizService) checkReadinessScheduledTime(scheduledTime, now time.Time) ReadinessCheck {
+	check := ReadinessCheck{Name: ReadinessCheckScheduledTime, Passed: true}
+	if err := s.validateScheduledTime(scheduledTime, now); err != nil {
+		check.Passed = false
+		check.Message = strings.TrimPrefix(err.Error(), ErrValidation.Error()+": ")
+	}
+	return check
+}
@@ -1,22 +1,271 @@
 package service
 
 import (
+	"crypto/rand"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/yourusername/trivia-api/internal/domain/entity"
 	"github.com/yourusername/trivia-api/internal/domain/repository"
+	"github.com/yourusername/trivia-api/internal/service/quizmanager"
 )
 
 // Максимальное количество вопросов в викторине
 const MaxQuizQuestions = 10
 
+// Число вариантов ответа в вопросе по умолчанию. Клиенты рассчитывают разметку
+// на фиксированный диапазон, поэтому ограничение проверяется при добавлении вопросов.
+const (
+	DefaultMinQuestionOptions = 2
+	DefaultMaxQuestionOptions = 6
+)
+
+// Допустимые значения настроек стоимости вопросов викторины
+const (
+	MaxUniformPointValue  = 100
+	MaxPointsMultiplier   = 10
+	MaxWrongAnswerPenalty = 100
+	MaxFastestFingerBonus = 100
+)
+
+// MaxDifficultyMultiplier - наибольший множитель стоимости вопроса по сложности
+const MaxDifficultyMultiplier = 10
+
+// QuizFormatOptions задает формат проведения: когда игроки узнают результаты
+// своих ответов и можно ли присоединиться к уже идущей викторине
+type QuizFormatOptions struct {
+	// DelayedResults: результаты ответов сообщаются только после закрытия вопроса
+	DelayedResults bool
+	// SuppressAnswerFeedback: сразу после ответа - только нейтральное подтверждение,
+	// правильность и выбывание - после раскрытия ответа
+	SuppressAnswerFeedback bool
+	// HideCorrectAnswer: результат сообщается сразу, но без правильного ответа -
+	// он раскрывается всем вместе в quiz:answer_reveal
+	HideCorrectAnswer bool
+	// JoinPolicy: entity.JoinPolicyBeforeStartOnly (по умолчанию) или entity.JoinPolicyAnytime
+	JoinPolicy string
+	// Visibility: entity.VisibilityPublic (по умолчанию), entity.VisibilityUnlisted
+	// или entity.VisibilityPrivate
+	Visibility string
+	// Условия участия: подтвержденный email, минимум сыгранных игр и внешняя
+	// проверка (см. QuizManager.SetExternalEligibilityChecker)
+	RequireVerifiedEmail     bool
+	MinGamesPlayed           int
+	ExternalEligibilityCheck bool
+	// PacingMode: entity.PacingSynchronized (по умолчанию) или entity.PacingSelfPaced
+	PacingMode string
+	// SelfPacedTimeLimitSec: общий лимит времени прохождения, только для self_paced
+	SelfPacedTimeLimitSec int
+	// AutoAdvance: вопрос закрывается досрочно, когда ответили все активные игроки
+	AutoAdvance bool
+	// AnswerWindowFromAck: время на ответ отсчитывается от подтверждения получения вопроса
+	AnswerWindowFromAck bool
+	// QuestionReadTimeSec: время на чтение вопроса до открытия приема ответов
+	QuestionReadTimeSec int
+	// MinPlayers: минимум игроков в комнате к началу; MinPlayersPolicy:
+	// entity.MinPlayersPolicyWait (по умолчанию) или entity.MinPlayersPolicyCancel
+	MinPlayers       int
+	MinPlayersPolicy string
+	// MaxPlayers: максимум игроков, занявших место (0 - без ограничения)
+	MaxPlayers int
+	// Lives: число жизней в формате на выбывание (0 заменяется на 1)
+	Lives int
+}
+
+// Границы общего лимита времени самостоятельного прохождения
+const (
+	MinSelfPacedTimeLimitSec = 60
+	MaxSelfPacedTimeLimitSec = 24 * 60 * 60
+)
+
+// MaxQuestionReadTimeSec - максимальное время на чтение вопроса
+const MaxQuestionReadTimeSec = 30
+
+// MaxMinPlayers - наибольший допустимый минимум игроков к началу викторины
+const MaxMinPlayers = 100000
+
+// MaxMaxPlayers - наибольший допустимый лимит мест в викторине
+const MaxMaxPlayers = 1000000
+
+// MaxLives - наибольшее число жизней игрока в викторине
+const MaxLives = 10
+
+// validate проверяет настройки и подставляет правило присоединения и видимость по умолчанию
+func (o *QuizFormatOptions) validate() error {
+	switch o.JoinPolicy {
+	case "":
+		o.JoinPolicy = entity.JoinPolicyBeforeStartOnly
+	case entity.JoinPolicyBeforeStartOnly, entity.JoinPolicyAnytime:
+	default:
+		return fmt.Errorf("%w: join_policy must be %s or %s", ErrValidation, entity.JoinPolicyBeforeStartOnly, entity.JoinPolicyAnytime)
+	}
+	switch o.Visibility {
+	case "":
+		o.Visibility = entity.VisibilityPublic
+	case entity.VisibilityPublic, entity.VisibilityUnlisted, entity.VisibilityPrivate:
+	default:
+		return fmt.Errorf("%w: visibility must be %s, %s or %s", ErrValidation,
+			entity.VisibilityPublic, entity.VisibilityUnlisted, entity.VisibilityPrivate)
+	}
+	if o.MinGamesPlayed < 0 || o.MinGamesPlayed > MaxMinGamesPlayed {
+		return fmt.Errorf("%w: min_games_played must be between 0 and %d", ErrValidation, MaxMinGamesPlayed)
+	}
+	if o.MinPlayers < 0 || o.MinPlayers > MaxMinPlayers {
+		return fmt.Errorf("%w: min_players must be between 0 and %d", ErrValidation, MaxMinPlayers)
+	}
+	if o.MaxPlayers < 0 || o.MaxPlayers > MaxMaxPlayers {
+		return fmt.Errorf("%w: max_players must be between 0 and %d", ErrValidation, MaxMaxPlayers)
+	}
+	if o.MaxPlayers > 0 && o.MinPlayers > o.MaxPlayers {
+		return fmt.Errorf("%w: min_players must not exceed max_players", ErrValidation)
+	}
+	if o.Lives < 0 || o.Lives > MaxLives {
+		return fmt.Errorf("%w: lives must be between 0 and %d", ErrValidation, MaxLives)
+	}
+	if o.Lives == 0 {
+		o.Lives = 1
+	}
+	switch o.MinPlayersPolicy {
+	case "":
+		o.MinPlayersPolicy = entity.MinPlayersPolicyWait
+	case entity.MinPlayersPolicyWait, entity.MinPlayersPolicyCancel:
+	default:
+		return fmt.Errorf("%w: min_players_policy must be %s or %s", ErrValidation, entity.MinPlayersPolicyWait, entity.MinPlayersPolicyCancel)
+	}
+	return o.validatePacing()
+}
+
+// validatePacing проверяет темп прохождения. У самостоятельного прохождения нет
+// общего закрытия вопроса, поэтому отложенные результаты с ним несовместимы.
+func (o *QuizFormatOptions) validatePacing() error {
+	switch o.PacingMode {
+	case "":
+		o.PacingMode = entity.PacingSynchronized
+	case entity.PacingSynchronized, entity.PacingSelfPaced:
+	default:
+		return fmt.Errorf("%w: pacing_mode must be %s or %s", ErrValidation, entity.PacingSynchronized, entity.PacingSelfPaced)
+	}
+	if o.QuestionReadTimeSec < 0 || o.QuestionReadTimeSec > MaxQuestionReadTimeSec {
+		return fmt.Errorf("%w: question_read_time_sec must be between 0 and %d", ErrValidation, MaxQuestionReadTimeSec)
+	}
+	if o.PacingMode == entity.PacingSynchronized {
+		if o.SelfPacedTimeLimitSec != 0 {
+			return fmt.Errorf("%w: self_paced_time_limit_sec is only allowed for %s pacing", ErrValidation, entity.PacingSelfPaced)
+		}
+		return nil
+	}
+	if o.AutoAdvance {
+		return fmt.Errorf("%w: auto_advance is only allowed for %s pacing", ErrValidation, entity.PacingSynchronized)
+	}
+	if o.AnswerWindowFromAck {
+		return fmt.Errorf("%w: answer_window_from_ack is only allowed for %s pacing", ErrValidation, entity.PacingSynchronized)
+	}
+	if o.QuestionReadTimeSec != 0 {
+		return fmt.Errorf("%w: question_read_time_sec is only allowed for %s pacing", ErrValidation, entity.PacingSynchronized)
+	}
+	if o.SelfPacedTimeLimitSec < MinSelfPacedTimeLimitSec || o.SelfPacedTimeLimitSec > MaxSelfPacedTimeLimitSec {
+		return fmt.Errorf("%w: self_paced_time_limit_sec must be between %d and %d", ErrValidation, MinSelfPacedTimeLimitSec, MaxSelfPacedTimeLimitSec)
+	}
+	if o.DelayedResults || o.SuppressAnswerFeedback {
+		return fmt.Errorf("%w: delayed_results and suppress_answer_feedback are not supported for %s pacing", ErrValidation, entity.PacingSelfPaced)
+	}
+	return nil
+}
+
+// Длина кода приглашения и алфавит без похожих символов (0/O, 1/I/L)
+const (
+	inviteCodeLength   = 8
+	inviteCodeAlphabet = "ABCDEFGHJKMNPQRSTUVWXYZ23456789"
+)
+
+// generateInviteCode создает случайный код приглашения
+func generateInviteCode() (string, error) {
+	buf := make([]byte, inviteCodeLength)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate invite code: %w", err)
+	}
+	for i, b := range buf {
+		buf[i] = inviteCodeAlphabet[int(b)%len(inviteCodeAlphabet)]
+	}
+	return string(buf), nil
+}
+
+// QuizScoringOptions задает стоимость вопросов на уровне викторины.
+// Нулевые значения означают отсутствие переопределения.
+type QuizScoringOptions struct {
+	UniformPointValue int
+	PointsMultiplier  float64
+	// Штраф за неверный ответ и нижняя граница итогового счета (по умолчанию 0)
+	WrongAnswerPenalty int
+	ScoreFloor         int
+	// Бонус первому правильно ответившему на вопрос (0 - без бонуса)
+	FastestFingerBonus int
+	// Масштабирование стоимости вопросов по сложности; нулевые множители
+	// заменяются entity.DefaultDifficultyMultipliers
+	DifficultyScaling     bool
+	DifficultyMultipliers entity.DifficultyMultipliers
+}
+
+// validate проверяет настройки и подставляет множитель по умолчанию
+func (o *QuizScoringOptions) validate() error {
+	if o.UniformPointValue < 0 || o.UniformPointValue > MaxUniformPointValue {
+		return fmt.Errorf("%w: uniform_point_value must be between 1 and %d", ErrValidation, MaxUniformPointValue)
+	}
+	if o.PointsMultiplier == 0 {
+		o.PointsMultiplier = 1
+	}
+	if o.PointsMultiplier < 0 || o.PointsMultiplier > MaxPointsMultiplier {
+		return fmt.Errorf("%w: points_multiplier must be greater than 0 and at most %d", ErrValidation, MaxPointsMultiplier)
+	}
+	if o.WrongAnswerPenalty < 0 || o.WrongAnswerPenalty > MaxWrongAnswerPenalty {
+		return fmt.Errorf("%w: wrong_answer_penalty must be between 0 and %d", ErrValidation, MaxWrongAnswerPenalty)
+	}
+	if o.ScoreFloor < 0 {
+		return fmt.Errorf("%w: score_floor must not be negative", ErrValidation)
+	}
+	if o.FastestFingerBonus < 0 || o.FastestFingerBonus > MaxFastestFingerBonus {
+		return fmt.Errorf("%w: fastest_finger_bonus must be between 0 and %d", ErrValidation, MaxFastestFingerBonus)
+	}
+	return o.validateDifficulty()
+}
+
+// validateDifficulty проверяет множители сложности и подставляет значения по умолчанию
+func (o *QuizScoringOptions) validateDifficulty() error {
+	multipliers := []struct {
+		name  string
+		value *float64
+		def   float64
+	}{
+		{entity.DifficultyEasy, &o.DifficultyMultipliers.Easy, entity.DefaultDifficultyMultipliers.Easy},
+		{entity.DifficultyMedium, &o.DifficultyMultipliers.Medium, entity.DefaultDifficultyMultipliers.Medium},
+		{entity.DifficultyHard, &o.DifficultyMultipliers.Hard, entity.DefaultDifficultyMultipliers.Hard},
+	}
+	for _, m := range multipliers {
+		if *m.value == 0 {
+			*m.value = m.def
+		}
+		if *m.value < 0 || *m.value > MaxDifficultyMultiplier {
+			return fmt.Errorf("%w: difficulty_multipliers.%s must be greater than 0 and at most %d", ErrValidation, m.name, MaxDifficultyMultiplier)
+		}
+	}
+	if o.DifficultyMultipliers.Easy > o.DifficultyMultipliers.Medium || o.DifficultyMultipliers.Medium > o.DifficultyMultipliers.Hard {
+		return fmt.Errorf("%w: difficulty_multipliers must not decrease from easy to hard", ErrValidation)
+	}
+	return nil
+}
+
 // QuizService предоставляет методы для работы с викторинами
 type QuizService struct {
 	quizRepo     repository.QuizRepository
 	questionRepo repository.QuestionRepository
 	cacheRepo    repository.CacheRepository
+
+	minOptions int
+	maxOptions int
+	// Насколько далеко вперед можно планировать викторину. 0 - без ограничения.
+	maxScheduleLead time.Duration
 }
 
 // NewQuizService создает новый сервис викторин
@@ -29,23 +278,126 @@ func NewQuizService(
 		quizRepo:     quizRepo,
 		questionRepo: questionRepo,
 		cacheRepo:    cacheRepo,
+		minOptions:   DefaultMinQuestionOptions,
+		maxOptions:   DefaultMaxQuestionOptions,
+	}
+}
+
+// SetOptionLimits задает допустимое число вариантов ответа в вопросе
+func (s *QuizService) SetOptionLimits(min, max int) {
+	s.minOptions = min
+	s.maxOptions = max
+}
+
+// OptionLimits возвращает допустимое число вариантов ответа в вопросе
+func (s *QuizService) OptionLimits() (min, max int) {
+	return s.minOptions, s.maxOptions
+}
+
+// SetMaxScheduleLead ограничивает, насколько далеко вперед можно запланировать
+// викторину. 0 - без ограничения.
+func (s *QuizService) SetMaxScheduleLead(lead time.Duration) {
+	s.maxScheduleLead = lead
+}
+
+// validateScheduledTime проверяет время проведения: оно не может быть в прошлом
+// (с допуском quizmanager.ScheduleClockSkew на расхождение часов) и дальше
+// maxScheduleLead от текущего момента
+func (s *QuizService) validateScheduledTime(scheduledTime, now time.Time) error {
+	if scheduledTime.Before(now.Add(-quizmanager.ScheduleClockSkew)) {
+		return fmt.Errorf("%w: scheduled time must be in the future", ErrValidation)
+	}
+	if s.maxScheduleLead > 0 && scheduledTime.After(now.Add(s.maxScheduleLead)) {
+		return fmt.Errorf("%w: scheduled time must be no later than %s", ErrValidation,
+			now.Add(s.maxScheduleLead).UTC().Format(time.RFC3339))
 	}
+	return nil
+}
+
+// validateQuestionOptions проверяет число вариантов, их уникальность и номер правильного ответа.
+// Варианты хранятся и отправляются клиентам в исходном порядке с номерами от 1.
+func (s *QuizService) validateQuestionOptions(index int, q *entity.Question) error {
+	if len(q.Options) < s.minOptions || len(q.Options) > s.maxOptions {
+		return fmt.Errorf("%w: question %d must have between %d and %d options, got %d",
+			ErrValidation, index+1, s.minOptions, s.maxOptions, len(q.Options))
+	}
+
+	seen := make(map[string]int, len(q.Options))
+	for i, option := range q.Options {
+		key := strings.ToLower(strings.TrimSpace(option))
+		if first, ok := seen[key]; ok {
+			return fmt.Errorf("%w: question %d option %d duplicates option %d", ErrValidation, index+1, i+1, first)
+		}
+		seen[key] = i + 1
+	}
+
+	if q.Type != entity.QuestionTypeOrdering && (q.CorrectOption < 1 || q.CorrectOption > len(q.Options)) {
+		return fmt.Errorf("%w: question %d correct_option must be between 1 and %d, got %d",
+			ErrValidation, index+1, len(q.Options), q.CorrectOption)
+	}
+	return nil
 }
 
 // CreateQuiz создает новую викторину
-func (s *QuizService) CreateQuiz(title, description string, scheduledTime time.Time) (*entity.Quiz, error) {
-	// Проверяем, что время проведения в будущем
-	if scheduledTime.Before(time.Now()) {
-		return nil, errors.New("scheduled time must be in the future")
+func (s *QuizService) CreateQuiz(title, description string, scheduledTime time.Time, format QuizFormatOptions, scoring QuizScoringOptions) (*entity.Quiz, error) {
+	if err := s.validateScheduledTime(scheduledTime, time.Now()); err != nil {
+		return nil, err
+	}
+	if err := format.validate(); err != nil {
+		return nil, err
+	}
+	if err := scoring.validate(); err != nil {
+		return nil, err
+	}
+	// Первенство ответа определяется по общему старту вопроса, которого нет при self_paced
+	if format.PacingMode == entity.PacingSelfPaced && scoring.FastestFingerBonus > 0 {
+		return nil, fmt.Errorf("%w: fastest_finger_bonus is not supported for %s pacing", ErrValidation, entity.PacingSelfPaced)
 	}
 
 	// Создаем новую викторину
 	quiz := &entity.Quiz{
-		Title:         title,
-		Description:   description,
-		ScheduledTime: scheduledTime,
-		Status:        "scheduled",
-		QuestionCount: 0,
+		Title:          title,
+		Description:    description,
+		ScheduledTime:  scheduledTime,
+		Status:         entity.QuizStatusScheduled,
+		QuestionCount:  0,
+		DelayedResults: format.DelayedResults,
+		// Нейтральное подтверждение вместо результата до раскрытия ответа
+		SuppressAnswerFeedback: format.SuppressAnswerFeedback,
+		HideCorrectAnswer:      format.HideCorrectAnswer,
+		JoinPolicy:             format.JoinPolicy,
+		// Стоимость вопросов на уровне викторины
+		UniformPointValue:  scoring.UniformPointValue,
+		PointsMultiplier:   scoring.PointsMultiplier,
+		WrongAnswerPenalty: scoring.WrongAnswerPenalty,
+		ScoreFloor:         scoring.ScoreFloor,
+		FastestFingerBonus: scoring.FastestFingerBonus,
+		Visibility:         format.Visibility,
+		// Масштабирование стоимости вопросов по сложности
+		DifficultyScaling:     scoring.DifficultyScaling,
+		DifficultyMultipliers: scoring.DifficultyMultipliers,
+		// Условия участия
+		RequireVerifiedEmail:     format.RequireVerifiedEmail,
+		MinGamesPlayed:           format.MinGamesPlayed,
+		ExternalEligibilityCheck: format.ExternalEligibilityCheck,
+		// Темп прохождения
+		PacingMode:            format.PacingMode,
+		SelfPacedTimeLimitSec: format.SelfPacedTimeLimitSec,
+		AutoAdvance:           format.AutoAdvance,
+		AnswerWindowFromAck:   format.AnswerWindowFromAck,
+		QuestionReadTimeSec:   format.QuestionReadTimeSec,
+		// Минимум игроков к началу
+		MinPlayers:       format.MinPlayers,
+		MinPlayersPolicy: format.MinPlayersPolicy,
+		MaxPlayers:       format.MaxPlayers,
+		Lives:            format.Lives,
+	}
+	if quiz.RequiresInviteCode() {
+		code, err := generateInviteCode()
+		if err != nil {
+			return nil, err
+		}
+		quiz.InviteCode = code
 	}
 
 	// Сохраняем викторину в БД
@@ -56,21 +408,273 @@ func (s *QuizService) CreateQuiz(title, description string, scheduledTime time.T
 	return quiz, nil
 }
 
+// CloneQuizOptions содержит необязательные переопределения для копии викторины
+type CloneQuizOptions struct {
+	Title         *string
+	Description   *string
+	ScheduledTime *time.Time
+	// Копия продолжает серию повторяющейся викторины: переносится интервал
+	// повторения, а RecurrenceSourceID указывает на исходную викторину
+	ContinueRecurrence bool
+}
+
+// CloneQuiz создает новую запланированную викторину с копиями вопросов исходной.
+// Копируются только настройки и вопросы: результаты, ответы и данные проведения
+// (лидерборды в кеше, состояние менеджера) привязаны к ID викторины и не переносятся.
+// Без переопределения время проведения сдвигается от исходного на целое число недель
+// до ближайшего момента в будущем. Планирование в QuizManager выполняется отдельно,
+// как и для новых викторин.
+func (s *QuizService) CloneQuiz(sourceID uint, opts CloneQuizOptions) (*entity.Quiz, error) {
+	source, err := s.quizRepo.GetByID(sourceID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrQuizNotFound, err)
+	}
+
+	questions, err := s.questionRepo.GetByQuizID(sourceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get questions of quiz %d: %w", sourceID, err)
+	}
+
+	now := time.Now()
+	scheduledTime := nextWeeklyOccurrence(source.ScheduledTime, now)
+	if opts.ScheduledTime != nil {
+		if err := s.validateScheduledTime(*opts.ScheduledTime, now); err != nil {
+			return nil, err
+		}
+		scheduledTime = *opts.ScheduledTime
+	}
+
+	clone := &entity.Quiz{
+		Title:          source.Title,
+		Description:    source.Description,
+		ScheduledTime:  scheduledTime,
+		Status:         entity.QuizStatusScheduled,
+		DelayedResults: source.DelayedResults,
+		// Режим сообщения результатов - часть формата викторины
+		SuppressAnswerFeedback: source.SuppressAnswerFeedback,
+		HideCorrectAnswer:      source.HideCorrectAnswer,
+		JoinPolicy:             source.JoinPolicy,
+		// Стоимость вопросов копируется вместе с форматом
+		UniformPointValue:  source.UniformPointValue,
+		PointsMultiplier:   source.PointsMultiplier,
+		WrongAnswerPenalty: source.WrongAnswerPenalty,
+		ScoreFloor:         source.ScoreFloor,
+		FastestFingerBonus: source.FastestFingerBonus,
+		// Множители сложности - тоже часть стоимости вопросов
+		DifficultyScaling:     source.DifficultyScaling,
+		DifficultyMultipliers: source.DifficultyMultipliers,
+		// Приглашенные в серию игроки входят в следующие викторины по тому же коду
+		Visibility: source.Visibility,
+		InviteCode: source.InviteCode,
+		// Условия участия копии те же, что у исходной викторины
+		RequireVerifiedEmail:     source.RequireVerifiedEmail,
+		MinGamesPlayed:           source.MinGamesPlayed,
+		ExternalEligibilityCheck: source.ExternalEligibilityCheck,
+		PacingMode:               source.PacingMode,
+		SelfPacedTimeLimitSec:    source.SelfPacedTimeLimitSec,
+		AutoAdvance:              source.AutoAdvance,
+		AnswerWindowFromAck:      source.AnswerWindowFromAck,
+		QuestionReadTimeSec:      source.QuestionReadTimeSec,
+		MinPlayers:               source.MinPlayers,
+		MinPlayersPolicy:         source.MinPlayersPolicy,
+		MaxPlayers:               source.MaxPlayers,
+		Lives:                    source.Lives,
+	}
+	if opts.Title != nil {
+		clone.Title = *opts.Title
+	}
+	if opts.Description != nil {
+		clone.Description = *opts.Description
+	}
+	if opts.ContinueRecurrence {
+		clone.RecurrenceIntervalMin = source.RecurrenceIntervalMin
+		clone.RecurrenceSourceID = &source.ID
+	}
+
+	clonedQuestions := make([]entity.Question, len(questions))
+	for i, q := range questions {
+		clonedQuestions[i] = entity.Question{
+			Type:          q.Type,
+			Text:          q.Text,
+			Options:       append(entity.StringArray(nil), q.Options...),
+			CorrectOption: q.CorrectOption,
+			CorrectOrder:  append(entity.IntArray(nil), q.CorrectOrder...),
+			ScoringMethod: q.ScoringMethod,
+			TimeLimitSec:  q.TimeLimitSec,
+			PointValue:    q.PointValue,
+			Difficulty:    q.Difficulty,
+		}
+	}
+
+	if err := s.quizRepo.CreateWithQuestions(clone, clonedQuestions); err != nil {
+		return nil, fmt.Errorf("failed to clone quiz %d: %w", sourceID, err)
+	}
+
+	clone.Questions = clonedQuestions
+	return clone, nil
+}
+
+// nextWeeklyOccurrence возвращает ближайший момент после now, отстоящий от from на целое число недель
+func nextWeeklyOccurrence(from, now time.Time) time.Time {
+	return entity.NextOccurrenceAfter(from, now, 7*24*time.Hour)
+}
+
+// MinRecurrenceIntervalMin - минимальный интервал повторения викторины в минутах
+const MinRecurrenceIntervalMin = 60
+
+// RecurrenceOptions содержит изменения правила повторения викторины.
+// Поля со значением nil не меняются.
+type RecurrenceOptions struct {
+	IntervalMin *int  // Интервал в минутах; 0 отменяет повторение
+	Paused      *bool // Приостановить или возобновить повторение
+}
+
+// SetRecurrence меняет правило повторения викторины. Правило действует на следующие
+// викторины серии: пауза или отмена у запланированной викторины означает, что после
+// ее завершения копия не будет создана. Уже созданные викторины серии не меняются.
+func (s *QuizService) SetRecurrence(quizID uint, opts RecurrenceOptions) (*entity.Quiz, error) {
+	if opts.IntervalMin != nil && *opts.IntervalMin != 0 && *opts.IntervalMin < MinRecurrenceIntervalMin {
+		return nil, fmt.Errorf("%w: recurrence interval must be 0 or at least %d minutes, got %d",
+			ErrValidation, MinRecurrenceIntervalMin, *opts.IntervalMin)
+	}
+
+	quiz, err := s.quizRepo.GetByID(quizID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrQuizNotFound, err)
+	}
+
+	if opts.IntervalMin != nil {
+		quiz.RecurrenceIntervalMin = *opts.IntervalMin
+	}
+	if opts.Paused != nil {
+		quiz.RecurrencePaused = *opts.Paused
+	}
+	if !quiz.IsRecurring() {
+		quiz.RecurrencePaused = false
+	}
+
+	if err := s.quizRepo.Update(quiz); err != nil {
+		return nil, fmt.Errorf("failed to update recurrence of quiz %d: %w", quizID, err)
+	}
+	return quiz, nil
+}
+
+// RotateInviteCode выпускает новый код приглашения приватной викторины.
+// Прежний код перестает действовать; уже присоединившиеся игроки остаются в викторине.
+func (s *QuizService) RotateInviteCode(quizID uint) (string, error) {
+	quiz, err := s.quizRepo.GetByID(quizID)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrQuizNotFound, err)
+	}
+	if !quiz.RequiresInviteCode() {
+		return "", fmt.Errorf("%w: invite codes are used only by %s quizzes", ErrValidation, entity.VisibilityPrivate)
+	}
+
+	code, err := generateInviteCode()
+	if err != nil {
+		return "", err
+	}
+	quiz.InviteCode = code
+	if err := s.quizRepo.Update(quiz); err != nil {
+		return "", fmt.Errorf("failed to update invite code of quiz %d: %w", quizID, err)
+	}
+	return code, nil
+}
+
+// quizLookupError преобразует ошибку получения викторины: отсутствие записи -
+// ErrQuizNotFound, остальные ошибки (например, недоступность БД) остаются внутренними
+func quizLookupError(quizID uint, err error) error {
+	if errors.Is(err, repository.ErrNotFound) {
+		return fmt.Errorf("%w: id %d", ErrQuizNotFound, quizID)
+	}
+	return fmt.Errorf("failed to get quiz %d: %w", quizID, err)
+}
+
+// quizStartedError возвращает ErrQuizAlreadyStarted для идущей викторины,
+// ErrQuizFinished - для завершенной или отмененной и nil для запланированной
+func quizStartedError(quiz *entity.Quiz) error {
+	switch {
+	case quiz.IsActive():
+		return fmt.Errorf("%w: quiz %d is in progress", ErrQuizAlreadyStarted, quiz.ID)
+	case quiz.IsCompleted(), quiz.IsCancelled():
+		return fmt.Errorf("%w: quiz %d is %s", ErrQuizFinished, quiz.ID, quiz.Status)
+	}
+	return nil
+}
+
 // GetQuizByID возвращает викторину по ID
 func (s *QuizService) GetQuizByID(quizID uint) (*entity.Quiz, error) {
-	return s.quizRepo.GetByID(quizID)
+	quiz, err := s.quizRepo.GetByID(quizID)
+	if err != nil {
+		return nil, quizLookupError(quizID, err)
+	}
+	return quiz, nil
+}
+
+// GetVisibleQuiz возвращает викторину по ID с учетом видимости: приватная
+// викторина без действующего кода приглашения (inviteCode) не отличается от
+// отсутствующей. Публичные и unlisted викторины доступны по ID без кода.
+func (s *QuizService) GetVisibleQuiz(quizID uint, inviteCode string) (*entity.Quiz, error) {
+	quiz, err := s.GetQuizByID(quizID)
+	if err != nil {
+		return nil, err
+	}
+	return visibleQuiz(quiz, inviteCode)
+}
+
+// GetVisibleQuizWithQuestions возвращает викторину с вопросами с учетом
+// видимости, как GetVisibleQuiz
+func (s *QuizService) GetVisibleQuizWithQuestions(quizID uint, inviteCode string) (*entity.Quiz, error) {
+	quiz, err := s.GetQuizWithQuestions(quizID)
+	if err != nil {
+		return nil, err
+	}
+	return visibleQuiz(quiz, inviteCode)
 }
 
-// GetActiveQuiz возвращает активную викторину
-func (s *QuizService) GetActiveQuiz() (*entity.Quiz, error) {
-	return s.quizRepo.GetActive()
+// visibleQuiz скрывает приватную викторину от не знающих код приглашения
+func visibleQuiz(quiz *entity.Quiz, inviteCode string) (*entity.Quiz, error) {
+	if quiz.RequiresInviteCode() && !quiz.CheckInviteCode(inviteCode) {
+		return nil, fmt.Errorf("%w: id %d", ErrQuizNotFound, quiz.ID)
+	}
+	return quiz, nil
 }
 
-// GetScheduledQuizzes возвращает список запланированных викторин
+// GetActiveQuizzes возвращает проводимые сейчас публичные викторины
+func (s *QuizService) GetActiveQuizzes() ([]entity.Quiz, error) {
+	quizzes, err := s.quizRepo.ListActive()
+	if err != nil {
+		return nil, err
+	}
+	return listedQuizzes(quizzes), nil
+}
+
+// GetScheduledQuizzes возвращает список всех запланированных викторин
+// независимо от видимости (используется для планирования при старте)
 func (s *QuizService) GetScheduledQuizzes() ([]entity.Quiz, error) {
 	return s.quizRepo.GetScheduled()
 }
 
+// GetPublicScheduledQuizzes возвращает запланированные публичные викторины
+func (s *QuizService) GetPublicScheduledQuizzes() ([]entity.Quiz, error) {
+	quizzes, err := s.quizRepo.GetScheduled()
+	if err != nil {
+		return nil, err
+	}
+	return listedQuizzes(quizzes), nil
+}
+
+// listedQuizzes оставляет только викторины, которые показываются в списках
+func listedQuizzes(quizzes []entity.Quiz) []entity.Quiz {
+	listed := quizzes[:0]
+	for _, quiz := range quizzes {
+		if quiz.IsListed() {
+			listed = append(listed, quiz)
+		}
+	}
+	return listed
+}
+
 // AddQuestions добавляет вопросы к викторине
 func (s *QuizService) AddQuestions(quizID uint, questions []entity.Question) error {
 	// Получаем викторину, чтобы убедиться, что она существует
@@ -84,6 +688,12 @@ func (s *QuizService) AddQuestions(quizID uint, questions []entity.Question) err
 		return errors.New("can only add questions to a scheduled quiz")
 	}
 
+	for i := range questions {
+		if err := s.validateQuestionOptions(i, &questions[i]); err != nil {
+			return err
+		}
+	}
+
 	// Получаем существующие вопросы
 	existingQuestions, err := s.questionRepo.GetByQuizID(quizID)
 	if err != nil {
@@ -116,35 +726,52 @@ func (s *QuizService) ScheduleQuiz(quizID uint, scheduledTime time.Time) error {
 	// Получаем викторину
 	quiz, err := s.quizRepo.GetByID(quizID)
 	if err != nil {
-		return err
+		return quizLookupError(quizID, err)
 	}
 
-	// Проверяем, что время проведения в будущем
-	if scheduledTime.Before(time.Now()) {
-		return errors.New("scheduled time must be in the future")
+	// Идущую викторину нельзя перенести; завершенную можно запланировать повторно
+	if quiz.IsActive() {
+		return quizStartedError(quiz)
 	}
 
-	// Обновляем время проведения
-	quiz.ScheduledTime = scheduledTime
+	if err := s.validateScheduledTime(scheduledTime, time.Now()); err != nil {
+		return err
+	}
 
-	// Если викторина завершена, меняем статус на "scheduled"
-	if quiz.IsCompleted() {
-		fmt.Printf("[QuizService] Изменение статуса викторины ID=%d с 'completed' на 'scheduled'\n", quizID)
-		quiz.Status = "scheduled"
+	// Завершенная викторина возвращается в статус scheduled; отмененную
+	// запланировать нельзя
+	from := quiz.Status
+	if err := quiz.TransitionTo(entity.QuizStatusScheduled); err != nil {
+		return err
 	}
 
-	return s.quizRepo.Update(quiz)
+	// Обновляем только время и статус условными UPDATE: полная запись строки
+	// перезаписала бы параллельный запуск или отмену викторины. Время меняется
+	// первым, чтобы запланированная викторина не оказалась со старым временем.
+	if err := s.quizRepo.UpdateScheduledTime(quizID, from, scheduledTime); err != nil {
+		return err
+	}
+	quiz.ScheduledTime = scheduledTime
+	if from == entity.QuizStatusScheduled {
+		return nil
+	}
+	return s.quizRepo.UpdateStatus(quizID, from, entity.QuizStatusScheduled)
 }
 
 // GetQuizWithQuestions возвращает викторину с вопросами
 func (s *QuizService) GetQuizWithQuestions(quizID uint) (*entity.Quiz, error) {
-	return s.quizRepo.GetWithQuestions(quizID)
+	quiz, err := s.quizRepo.GetWithQuestions(quizID)
+	if err != nil {
+		return nil, quizLookupError(quizID, err)
+	}
+	return quiz, nil
 }
 
-// ListQuizzes возвращает список викторин с пагинацией
+// ListQuizzes возвращает список публичных викторин с пагинацией.
+// Викторины unlisted и private доступны только по ID.
 func (s *QuizService) ListQuizzes(page, pageSize int) ([]entity.Quiz, error) {
 	offset := (page - 1) * pageSize
-	return s.quizRepo.List(pageSize, offset)
+	return s.quizRepo.ListPublic(pageSize, offset)
 }
 
 // DeleteQuiz удаляет викторину
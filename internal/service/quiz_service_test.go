@@ -0,0 +1,478 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourusername/trivia-api/internal/domain/entity"
+	"github.com/yourusername/trivia-api/internal/domain/repository"
+)
+
+// cloneQuizRepo хранит исходную викторину и созданную копию
+type cloneQuizRepo struct {
+	repository.QuizRepository
+	source          *entity.Quiz
+	created         *entity.Quiz
+	createdQuestion []entity.Question
+}
+
+func (r *cloneQuizRepo) GetByID(id uint) (*entity.Quiz, error) {
+	if r.source == nil || r.source.ID != id {
+		return nil, repository.ErrNotFound
+	}
+	return r.source, nil
+}
+
+func (r *cloneQuizRepo) Create(quiz *entity.Quiz) error {
+	quiz.ID = 100
+	r.created = quiz
+	return nil
+}
+
+func (r *cloneQuizRepo) CreateWithQuestions(quiz *entity.Quiz, questions []entity.Question) error {
+	quiz.ID = 100
+	quiz.QuestionCount = len(questions)
+	for i := range questions {
+		questions[i].QuizID = quiz.ID
+	}
+	r.created, r.createdQuestion = quiz, questions
+	return nil
+}
+
+// cloneQuestionRepo возвращает вопросы исходной викторины
+type cloneQuestionRepo struct {
+	repository.QuestionRepository
+	questions []entity.Question
+}
+
+func (r *cloneQuestionRepo) GetByQuizID(quizID uint) ([]entity.Question, error) {
+	return r.questions, nil
+}
+
+func newCloneFixture() (*QuizService, *cloneQuizRepo) {
+	quizRepo := &cloneQuizRepo{source: &entity.Quiz{
+		ID:             1,
+		Title:          "Еженедельная викторина",
+		Description:    "Формат на каждую пятницу",
+		ScheduledTime:  time.Now().Add(-30 * time.Hour),
+		Status:         "completed",
+		QuestionCount:  2,
+		DelayedResults: true,
+	}}
+	questionRepo := &cloneQuestionRepo{questions: []entity.Question{
+		{ID: 11, QuizID: 1, Type: entity.QuestionTypeSingleChoice, Text: "q1", Options: entity.StringArray{"a", "b"}, CorrectOption: 2, TimeLimitSec: 10, PointValue: 10},
+		{ID: 12, QuizID: 1, Type: entity.QuestionTypeOrdering, Text: "q2", Options: entity.StringArray{"a", "b", "c"}, CorrectOrder: entity.IntArray{3, 1, 2}, ScoringMethod: entity.OrderingScoringKendallTau, TimeLimitSec: 15, PointValue: 20},
+	}}
+	return NewQuizService(quizRepo, questionRepo, nil), quizRepo
+}
+
+func TestCloneQuiz_CopiesQuestionsIntoNewScheduledQuiz(t *testing.T) {
+	s, quizRepo := newCloneFixture()
+
+	clone, err := s.CloneQuiz(1, CloneQuizOptions{})
+	require.NoError(t, err)
+
+	assert.Equal(t, uint(100), clone.ID)
+	assert.Equal(t, entity.QuizStatusScheduled, clone.Status)
+	assert.Equal(t, quizRepo.source.Title, clone.Title)
+	assert.True(t, clone.DelayedResults)
+	assert.Equal(t, 2, clone.QuestionCount)
+
+	// Без переопределения время сдвигается на целое число недель в будущее
+	assert.True(t, clone.ScheduledTime.After(time.Now()))
+	assert.Equal(t, 7*24*time.Hour, clone.ScheduledTime.Sub(quizRepo.source.ScheduledTime))
+
+	require.Len(t, quizRepo.createdQuestion, 2)
+	for i, q := range quizRepo.createdQuestion {
+		assert.Zero(t, q.ID, "вопрос должен быть создан заново")
+		assert.Equal(t, uint(100), q.QuizID)
+		assert.Equal(t, s.questionRepo.(*cloneQuestionRepo).questions[i].Text, q.Text)
+	}
+	assert.Equal(t, 2, quizRepo.createdQuestion[0].CorrectOption)
+	assert.Equal(t, entity.IntArray{3, 1, 2}, quizRepo.createdQuestion[1].CorrectOrder)
+	assert.Equal(t, entity.OrderingScoringKendallTau, quizRepo.createdQuestion[1].ScoringMethod)
+}
+
+func TestCloneQuiz_Overrides(t *testing.T) {
+	s, quizRepo := newCloneFixture()
+	quizRepo.source.Visibility, quizRepo.source.InviteCode = entity.VisibilityPrivate, "ABCD2345"
+	title := "Новая викторина"
+	scheduled := time.Now().Add(48 * time.Hour)
+
+	clone, err := s.CloneQuiz(1, CloneQuizOptions{Title: &title, ScheduledTime: &scheduled})
+	require.NoError(t, err)
+	assert.Equal(t, title, clone.Title)
+	assert.True(t, clone.ScheduledTime.Equal(scheduled))
+	assert.Equal(t, entity.VisibilityPrivate, clone.Visibility)
+	assert.Equal(t, "ABCD2345", clone.InviteCode, "приглашенные входят в копию по тому же коду")
+
+	past := time.Now().Add(-time.Hour)
+	_, err = s.CloneQuiz(1, CloneQuizOptions{ScheduledTime: &past})
+	assert.ErrorIs(t, err, ErrValidation)
+
+	_, err = s.CloneQuiz(42, CloneQuizOptions{})
+	assert.ErrorIs(t, err, ErrQuizNotFound)
+}
+
+func TestCreateQuiz_ValidatesScoringOptions(t *testing.T) {
+	s := NewQuizService(&cloneQuizRepo{}, &cloneQuestionRepo{}, nil)
+	scheduled := time.Now().Add(time.Hour)
+
+	_, err := s.CreateQuiz("Викторина", "", scheduled, QuizFormatOptions{}, QuizScoringOptions{UniformPointValue: MaxUniformPointValue + 1})
+	assert.ErrorIs(t, err, ErrValidation)
+
+	_, err = s.CreateQuiz("Викторина", "", scheduled, QuizFormatOptions{}, QuizScoringOptions{PointsMultiplier: -1})
+	assert.ErrorIs(t, err, ErrValidation)
+
+	_, err = s.CreateQuiz("Викторина", "", scheduled, QuizFormatOptions{JoinPolicy: "sometimes"}, QuizScoringOptions{})
+	assert.ErrorIs(t, err, ErrValidation)
+
+	_, err = s.CreateQuiz("Викторина", "", scheduled, QuizFormatOptions{}, QuizScoringOptions{WrongAnswerPenalty: -1})
+	assert.ErrorIs(t, err, ErrValidation)
+
+	_, err = s.CreateQuiz("Викторина", "", scheduled, QuizFormatOptions{}, QuizScoringOptions{ScoreFloor: -10})
+	assert.ErrorIs(t, err, ErrValidation)
+
+	_, err = s.CreateQuiz("Викторина", "", scheduled, QuizFormatOptions{}, QuizScoringOptions{
+		DifficultyScaling: true, DifficultyMultipliers: entity.DifficultyMultipliers{Hard: MaxDifficultyMultiplier + 1}})
+	assert.ErrorIs(t, err, ErrValidation)
+
+	_, err = s.CreateQuiz("Викторина", "", scheduled, QuizFormatOptions{}, QuizScoringOptions{
+		DifficultyScaling: true, DifficultyMultipliers: entity.DifficultyMultipliers{Easy: 3, Medium: 1, Hard: 2}})
+	assert.ErrorIs(t, err, ErrValidation, "простой вопрос не может стоить больше сложного")
+
+	scaled, err := s.CreateQuiz("Викторина", "", scheduled, QuizFormatOptions{}, QuizScoringOptions{
+		DifficultyScaling: true, DifficultyMultipliers: entity.DifficultyMultipliers{Hard: 3}})
+	require.NoError(t, err)
+	assert.Equal(t, entity.DifficultyMultipliers{Easy: 0.5, Medium: 1, Hard: 3}, scaled.DifficultyMultipliers)
+
+	quiz, err := s.CreateQuiz("Викторина", "", scheduled, QuizFormatOptions{}, QuizScoringOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, entity.JoinPolicyBeforeStartOnly, quiz.JoinPolicy)
+	assert.Zero(t, quiz.WrongAnswerPenalty)
+	assert.Zero(t, quiz.ScoreFloor)
+}
+
+func TestCreateQuiz_Pacing(t *testing.T) {
+	s := NewQuizService(&cloneQuizRepo{}, &cloneQuestionRepo{}, nil)
+	scheduled := time.Now().Add(time.Hour)
+	selfPaced := func(limit int) QuizFormatOptions {
+		return QuizFormatOptions{PacingMode: entity.PacingSelfPaced, SelfPacedTimeLimitSec: limit}
+	}
+
+	_, err := s.CreateQuiz("Викторина", "", scheduled, QuizFormatOptions{PacingMode: "relaxed"}, QuizScoringOptions{})
+	assert.ErrorIs(t, err, ErrValidation)
+	_, err = s.CreateQuiz("Викторина", "", scheduled, QuizFormatOptions{SelfPacedTimeLimitSec: 600}, QuizScoringOptions{})
+	assert.ErrorIs(t, err, ErrValidation, "лимит прохождения только для self_paced")
+	_, err = s.CreateQuiz("Викторина", "", scheduled, selfPaced(0), QuizScoringOptions{})
+	assert.ErrorIs(t, err, ErrValidation)
+	_, err = s.CreateQuiz("Викторина", "", scheduled, selfPaced(MaxSelfPacedTimeLimitSec+1), QuizScoringOptions{})
+	assert.ErrorIs(t, err, ErrValidation)
+
+	delayed := selfPaced(600)
+	delayed.DelayedResults = true
+	_, err = s.CreateQuiz("Викторина", "", scheduled, delayed, QuizScoringOptions{})
+	assert.ErrorIs(t, err, ErrValidation)
+	_, err = s.CreateQuiz("Викторина", "", scheduled, selfPaced(600), QuizScoringOptions{FastestFingerBonus: 5})
+	assert.ErrorIs(t, err, ErrValidation)
+	autoAdvance := selfPaced(600)
+	autoAdvance.AutoAdvance = true
+	_, err = s.CreateQuiz("Викторина", "", scheduled, autoAdvance, QuizScoringOptions{})
+	assert.ErrorIs(t, err, ErrValidation, "досрочное закрытие только для synchronized")
+	fromAck := selfPaced(600)
+	fromAck.AnswerWindowFromAck = true
+	_, err = s.CreateQuiz("Викторина", "", scheduled, fromAck, QuizScoringOptions{})
+	assert.ErrorIs(t, err, ErrValidation, "отсчет от подтверждения только для synchronized")
+	readTime := selfPaced(600)
+	readTime.QuestionReadTimeSec = 3
+	_, err = s.CreateQuiz("Викторина", "", scheduled, readTime, QuizScoringOptions{})
+	assert.ErrorIs(t, err, ErrValidation, "время на чтение только для synchronized")
+	_, err = s.CreateQuiz("Викторина", "", scheduled, QuizFormatOptions{QuestionReadTimeSec: MaxQuestionReadTimeSec + 1}, QuizScoringOptions{})
+	assert.ErrorIs(t, err, ErrValidation)
+
+	quiz, err := s.CreateQuiz("Викторина", "", scheduled, QuizFormatOptions{}, QuizScoringOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, entity.PacingSynchronized, quiz.PacingMode)
+
+	quiz, err = s.CreateQuiz("Викторина", "", scheduled, selfPaced(600), QuizScoringOptions{})
+	require.NoError(t, err)
+	assert.True(t, quiz.IsSelfPaced())
+	assert.Equal(t, 10*time.Minute, quiz.SelfPacedTimeLimit())
+}
+
+// visibilityQuizRepo отдает викторины с разной видимостью
+type visibilityQuizRepo struct {
+	repository.QuizRepository
+	quizzes []entity.Quiz
+}
+
+func (r *visibilityQuizRepo) ListActive() ([]entity.Quiz, error) {
+	return append([]entity.Quiz(nil), r.quizzes...), nil
+}
+
+func (r *visibilityQuizRepo) GetScheduled() ([]entity.Quiz, error) {
+	return append([]entity.Quiz(nil), r.quizzes...), nil
+}
+
+func TestQuizService_ListsOnlyPublicQuizzes(t *testing.T) {
+	s := NewQuizService(&visibilityQuizRepo{quizzes: []entity.Quiz{
+		{ID: 1, Visibility: entity.VisibilityPublic},
+		{ID: 2, Visibility: entity.VisibilityUnlisted},
+		{ID: 3, Visibility: entity.VisibilityPrivate, InviteCode: "ABCD2345"},
+		{ID: 4}, // Викторины, созданные до появления видимости, считаются публичными
+	}}, nil, nil)
+
+	ids := func(quizzes []entity.Quiz) []uint {
+		var found []uint
+		for _, q := range quizzes {
+			found = append(found, q.ID)
+		}
+		return found
+	}
+
+	active, err := s.GetActiveQuizzes()
+	require.NoError(t, err)
+	assert.Equal(t, []uint{1, 4}, ids(active))
+
+	scheduled, err := s.GetPublicScheduledQuizzes()
+	require.NoError(t, err)
+	assert.Equal(t, []uint{1, 4}, ids(scheduled))
+
+	all, err := s.GetScheduledQuizzes()
+	require.NoError(t, err)
+	assert.Equal(t, []uint{1, 2, 3, 4}, ids(all), "для планирования нужны все викторины")
+}
+
+func TestCreateQuiz_Visibility(t *testing.T) {
+	s := NewQuizService(&cloneQuizRepo{}, &cloneQuestionRepo{}, nil)
+	scheduled := time.Now().Add(time.Hour)
+
+	_, err := s.CreateQuiz("Викторина", "", scheduled, QuizFormatOptions{Visibility: "hidden"}, QuizScoringOptions{})
+	assert.ErrorIs(t, err, ErrValidation)
+
+	quiz, err := s.CreateQuiz("Викторина", "", scheduled, QuizFormatOptions{}, QuizScoringOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, entity.VisibilityPublic, quiz.Visibility)
+	assert.Empty(t, quiz.InviteCode)
+
+	quiz, err = s.CreateQuiz("Викторина", "", scheduled, QuizFormatOptions{Visibility: entity.VisibilityUnlisted}, QuizScoringOptions{})
+	require.NoError(t, err)
+	assert.False(t, quiz.IsListed())
+	assert.Empty(t, quiz.InviteCode, "unlisted викторина доступна по ссылке без кода")
+
+	quiz, err = s.CreateQuiz("Викторина", "", scheduled, QuizFormatOptions{Visibility: entity.VisibilityPrivate}, QuizScoringOptions{})
+	require.NoError(t, err)
+	assert.Len(t, quiz.InviteCode, inviteCodeLength)
+	assert.True(t, quiz.CheckInviteCode(strings.ToLower(quiz.InviteCode)), "код не зависит от регистра")
+	assert.False(t, quiz.CheckInviteCode(""))
+}
+
+func TestRotateInviteCode(t *testing.T) {
+	quizRepo := &recurrenceQuizRepo{quizzes: map[uint]*entity.Quiz{
+		1: {ID: 1, Visibility: entity.VisibilityPrivate, InviteCode: "ABCD2345"},
+		2: {ID: 2, Visibility: entity.VisibilityUnlisted},
+	}, nextID: 2}
+	s := NewQuizService(quizRepo, &cloneQuestionRepo{}, nil)
+
+	code, err := s.RotateInviteCode(1)
+	require.NoError(t, err)
+	assert.NotEqual(t, "ABCD2345", code)
+	assert.Equal(t, code, quizRepo.quizzes[1].InviteCode)
+	assert.False(t, quizRepo.quizzes[1].CheckInviteCode("ABCD2345"), "прежний код перестает действовать")
+
+	_, err = s.RotateInviteCode(2)
+	assert.ErrorIs(t, err, ErrValidation)
+
+	_, err = s.RotateInviteCode(42)
+	assert.ErrorIs(t, err, ErrQuizNotFound)
+}
+
+func TestAddQuestions_ValidatesOptions(t *testing.T) {
+	quizRepo := &cloneQuizRepo{source: &entity.Quiz{ID: 1, Status: "scheduled", ScheduledTime: time.Now().Add(time.Hour)}}
+	s := NewQuizService(quizRepo, &cloneQuestionRepo{}, nil)
+
+	question := func(options []string, correct int) entity.Question {
+		return entity.Question{Text: "Вопрос", Options: entity.StringArray(options), CorrectOption: correct, TimeLimitSec: 10, PointValue: 10}
+	}
+	cases := map[string]entity.Question{
+		"один вариант":                question([]string{"a"}, 1),
+		"больше максимума":            question([]string{"a", "b", "c", "d", "e", "f", "g"}, 1),
+		"повтор варианта":             question([]string{"Москва", "Париж", " москва "}, 1),
+		"правильный ответ за рамками": question([]string{"a", "b", "c"}, 4),
+		"правильный ответ не задан":   question([]string{"a", "b"}, 0),
+	}
+	for name, q := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := s.AddQuestions(1, []entity.Question{q})
+			assert.ErrorIs(t, err, ErrValidation)
+		})
+	}
+
+	s.SetOptionLimits(4, 4)
+	assert.ErrorIs(t, s.AddQuestions(1, []entity.Question{question([]string{"a", "b", "c"}, 1)}), ErrValidation)
+}
+
+func TestQuizService_ValidatesScheduledTime(t *testing.T) {
+	s := NewQuizService(&cloneQuizRepo{}, &cloneQuestionRepo{}, nil)
+	s.SetMaxScheduleLead(365 * 24 * time.Hour)
+	now := time.Now()
+
+	cases := []struct {
+		name      string
+		scheduled time.Time
+		valid     bool
+	}{
+		{"в прошлом", now.Add(-time.Minute), false},
+		{"отставание в пределах допуска", now.Add(-2 * time.Second), true},
+		{"через минуту", now.Add(time.Minute), true},
+		{"через полгода", now.AddDate(0, 6, 0), true},
+		{"дальше горизонта", now.AddDate(2, 0, 0), false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := s.CreateQuiz("Викторина", "", tc.scheduled, QuizFormatOptions{}, QuizScoringOptions{})
+			if tc.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, ErrValidation)
+			}
+		})
+	}
+
+	// Без ограничения горизонта далекое время допустимо
+	s.SetMaxScheduleLead(0)
+	_, err := s.CreateQuiz("Викторина", "", now.AddDate(2, 0, 0), QuizFormatOptions{}, QuizScoringOptions{})
+	assert.NoError(t, err)
+}
+
+func TestScheduleQuiz_RejectsPastTime(t *testing.T) {
+	quizRepo := &recurrenceQuizRepo{quizzes: map[uint]*entity.Quiz{
+		1: {ID: 1, Status: "scheduled", ScheduledTime: time.Now().Add(time.Hour)},
+	}, nextID: 1}
+	s := NewQuizService(quizRepo, &cloneQuestionRepo{}, nil)
+
+	err := s.ScheduleQuiz(1, time.Now().Add(-time.Hour))
+	assert.ErrorIs(t, err, ErrValidation)
+
+	next := time.Now().Add(2 * time.Hour)
+	require.NoError(t, s.ScheduleQuiz(1, next))
+	assert.True(t, quizRepo.quizzes[1].ScheduledTime.Equal(next))
+}
+
+func TestScheduleQuiz_QuizStateErrors(t *testing.T) {
+	quizRepo := &recurrenceQuizRepo{quizzes: map[uint]*entity.Quiz{
+		1: {ID: 1, Status: "in_progress", ScheduledTime: time.Now().Add(-time.Minute)},
+		2: {ID: 2, Status: "completed", ScheduledTime: time.Now().Add(-time.Hour)},
+		3: {ID: 3, Status: "cancelled", ScheduledTime: time.Now().Add(time.Hour)},
+	}, nextID: 3}
+	s := NewQuizService(quizRepo, &cloneQuestionRepo{}, nil)
+	next := time.Now().Add(time.Hour)
+
+	assert.ErrorIs(t, s.ScheduleQuiz(1, next), ErrQuizAlreadyStarted)
+	assert.ErrorIs(t, s.ScheduleQuiz(42, next), ErrQuizNotFound)
+	require.NoError(t, s.ScheduleQuiz(2, next), "завершенную викторину можно запланировать повторно")
+	assert.Equal(t, entity.QuizStatusScheduled, quizRepo.quizzes[2].Status)
+	assert.ErrorIs(t, s.ScheduleQuiz(3, next), ErrInvalidStatusTransition, "отмененную викторину запланировать нельзя")
+	assert.Equal(t, entity.QuizStatusCancelled, quizRepo.quizzes[3].Status)
+
+	_, err := s.GetQuizByID(42)
+	assert.ErrorIs(t, err, ErrQuizNotFound)
+}
+
+// staleQuizRepo отдает викторину в статусе, который уже сменился в хранилище
+type staleQuizRepo struct {
+	*recurrenceQuizRepo
+	status entity.QuizStatus
+}
+
+func (r *staleQuizRepo) GetByID(id uint) (*entity.Quiz, error) {
+	quiz, err := r.recurrenceQuizRepo.GetByID(id)
+	if err == nil {
+		quiz.Status = r.status
+	}
+	return quiz, err
+}
+
+func TestScheduleQuiz_KeepsConcurrentStatusChange(t *testing.T) {
+	scheduled := time.Now().Add(-time.Minute)
+	quizRepo := &recurrenceQuizRepo{quizzes: map[uint]*entity.Quiz{
+		// Викторина успела запуститься после чтения в статусе completed
+		1: {ID: 1, Status: entity.QuizStatusInProgress, ScheduledTime: scheduled},
+	}, nextID: 1}
+	s := NewQuizService(&staleQuizRepo{recurrenceQuizRepo: quizRepo, status: entity.QuizStatusCompleted}, &cloneQuestionRepo{}, nil)
+
+	assert.ErrorIs(t, s.ScheduleQuiz(1, time.Now().Add(time.Hour)), ErrInvalidStatusTransition)
+	assert.Equal(t, entity.QuizStatusInProgress, quizRepo.quizzes[1].Status)
+	assert.True(t, quizRepo.quizzes[1].ScheduledTime.Equal(scheduled))
+}
+
+func TestGetVisibleQuiz_PrivateNeedsInviteCode(t *testing.T) {
+	quizRepo := &cloneQuizRepo{source: &entity.Quiz{ID: 1, Visibility: entity.VisibilityPrivate, InviteCode: "ABCD2345"}}
+	s := NewQuizService(quizRepo, &cloneQuestionRepo{}, nil)
+
+	_, err := s.GetVisibleQuiz(1, "")
+	assert.ErrorIs(t, err, ErrQuizNotFound, "приватная викторина не видна без кода")
+	_, err = s.GetVisibleQuiz(1, "WRONG234")
+	assert.ErrorIs(t, err, ErrQuizNotFound)
+	quiz, err := s.GetVisibleQuiz(1, "abcd2345")
+	require.NoError(t, err)
+	assert.Equal(t, uint(1), quiz.ID)
+
+	quizRepo.source.Visibility = entity.VisibilityUnlisted
+	_, err = s.GetVisibleQuiz(1, "")
+	assert.NoError(t, err, "unlisted викторина доступна по ID")
+}
+
+func TestCheckReadiness(t *testing.T) {
+	quizRepo := &cloneQuizRepo{source: &entity.Quiz{ID: 1, Status: "scheduled", ScheduledTime: time.Now().Add(time.Hour)}}
+	questionRepo := &cloneQuestionRepo{questions: []entity.Question{
+		{ID: 11, Type: entity.QuestionTypeSingleChoice, Text: "q1", Options: entity.StringArray{"a", "b"}, CorrectOption: 2, TimeLimitSec: 10},
+		{ID: 12, Type: entity.QuestionTypeSingleChoice, Text: "q2", Options: entity.StringArray{"a", "b", "c"}, CorrectOption: 1, TimeLimitSec: 20},
+	}}
+	s := NewQuizService(quizRepo, questionRepo, nil)
+
+	checks := func(r *QuizReadiness) map[string]bool {
+		result := make(map[string]bool)
+		for _, c := range r.Checks {
+			result[c.Name] = c.Passed
+		}
+		return result
+	}
+
+	readiness, err := s.CheckReadiness(1)
+	require.NoError(t, err)
+	assert.True(t, readiness.Ready)
+	// 30 секунд лимитов и по 1.2 секунды служебных задержек на вопрос
+	assert.Equal(t, 32, readiness.EstimatedDurationSec)
+
+	// Вопрос с правильным ответом за рамками, время в прошлом, викторина уже завершена
+	questionRepo.questions[1].CorrectOption = 4
+	quizRepo.source.ScheduledTime = time.Now().Add(-time.Hour)
+	quizRepo.source.Status = "completed"
+
+	readiness, err = s.CheckReadiness(1)
+	require.NoError(t, err)
+	assert.False(t, readiness.Ready)
+	assert.Equal(t, map[string]bool{
+		ReadinessCheckStatus:        false,
+		ReadinessCheckQuestionCount: true,
+		ReadinessCheckQuestions:     false,
+		ReadinessCheckScheduledTime: false,
+	}, checks(readiness))
+	for _, c := range readiness.Checks {
+		if c.Name == ReadinessCheckQuestions {
+			assert.Contains(t, c.Message, "question 2 correct_option")
+		}
+	}
+
+	questionRepo.questions = nil
+	readiness, err = s.CheckReadiness(1)
+	require.NoError(t, err)
+	assert.False(t, checks(readiness)[ReadinessCheckQuestionCount])
+
+	_, err = s.CheckReadiness(42)
+	assert.ErrorIs(t, err, ErrQuizNotFound)
+}
@@ -0,0 +1,388 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/yourusername/trivia-api/internal/domain/entity"
+	"github.com/yourusername/trivia-api/internal/handler/helper"
+)
+
+const (
+	// SyncLeaderboardSize - число строк таблицы лидеров в снимке синхронизации
+	SyncLeaderboardSize = 10
+	// liveLeaderboardTTL - время жизни таблицы лидеров идущей викторины в кэше.
+	// При массовом переподключении таблица считается по ответам не чаще раза за TTL.
+	liveLeaderboardTTL = 3 * time.Second
+)
+
+// QuizSync - снимок состояния викторины для переподключившегося игрока:
+// все, что нужно клиенту, чтобы продолжить игру одним запросом
+type QuizSync struct {
+	QuizID         uint   `json:"quiz_id"`
+	Title          string `json:"title"`
+	Status         string `json:"status"`
+	TotalQuestions int    `json:"total_questions"`
+	// Текущий вопрос; отсутствует до первого вопроса и после последнего
+	CurrentQuestion *SyncQuestion `json:"current_question,omitempty"`
+	QuestionOpen    bool          `json:"question_open"` // Принимаются ли сейчас ответы на текущий вопрос
+	RemainingMs     int64         `json:"remaining_ms"`  // Оставшееся время на текущий вопрос
+	// Ответ игрока на текущий вопрос, если он уже отвечал
+	MyAnswer        *SyncAnswer        `json:"my_answer,omitempty"`
+	Participating   bool               `json:"participating"`
+	Eliminated      bool               `json:"eliminated"`
+	Kicked          bool               `json:"kicked"`
+	Leaderboard     []LeaderboardEntry `json:"leaderboard"`
+	ServerTimestamp int64              `json:"server_timestamp"`
+}
+
+// SyncQuestion - текущий вопрос. Правильный ответ заполняется только после
+// его рассылки всем участникам (quiz:answer_reveal).
+type SyncQuestion struct {
+	ID            uint                    `json:"question_id"`
+	Number        int                     `json:"number"`
+	Type          string                  `json:"type"`
+	Text          string                  `json:"text"`
+	Options       []helper.QuestionOption `json:"options"`
+	TimeLimit     int                     `json:"time_limit"`
+	PointValue    int                     `json:"point_value"`
+	StartTime     int64                   `json:"start_time"`                // 0 - вопрос еще не открыт
+	AnswersOpenAt int64                   `json:"answers_open_at,omitempty"` // Открытие приема ответов во время чтения вопроса
+	EndsAtMs      int64                   `json:"ends_at_ms,omitempty"`      // Срок ответа (Unix, мс) с учетом времени на чтение
+	Revealed      bool                    `json:"revealed"`
+	CorrectOption *int                    `json:"correct_option,omitempty"`
+	CorrectOrder  []int                   `json:"correct_order,omitempty"`
+}
+
+// SyncAnswer - ответ игрока на текущий вопрос. Если результат игроку еще не
+// сообщается (отложенные результаты) или ответ еще обрабатывается, приходит
+// только зафиксированный выбор и result_pending = true.
+type SyncAnswer struct {
+	SelectedOption int   `json:"selected_option"`
+	SelectedOrder  []int `json:"selected_order,omitempty"`
+	ResultPending  bool  `json:"result_pending"`
+	IsCorrect      *bool `json:"is_correct,omitempty"`
+	PointsEarned   *int  `json:"points_earned,omitempty"`
+	PenaltyApplied int   `json:"penalty_applied,omitempty"`
+	TimeTakenMs    int64 `json:"time_taken_ms,omitempty"`
+	FastestFinger  bool  `json:"fastest_finger,omitempty"`
+}
+
+// LeaderboardEntry - строка таблицы лидеров
+type LeaderboardEntry struct {
+	Rank           int    `json:"rank"`
+	UserID         uint   `json:"user_id"`
+	Username       string `json:"username"`
+	Score          int    `json:"score"`
+	CorrectAnswers int    `json:"correct_answers"`
+	IsEliminated   bool   `json:"is_eliminated"`
+}
+
+// GetQuizSync собирает снимок состояния викторины для игрока. Для идущей
+// викторины состояние берется из памяти, кэша и сохраненных ответов; для
+// остальных возвращаются сведения о викторине и, после завершения, итоговая
+// таблица. Правильный ответ на текущий вопрос и результаты, которые игрок еще
+// не должен знать, в снимок не попадают.
+//
+// Снимок приватной викторины получает только занявший в ней место игрок или
+// приславший действующий код приглашения (inviteCode).
+func (qm *QuizManager) GetQuizSync(quizID, userID uint, inviteCode string) (*QuizSync, error) {
+	if err := qm.checkSyncAccess(quizID, userID, inviteCode); err != nil {
+		return nil, err
+	}
+
+	qm.stateMutex.RLock()
+	active, inProgress := qm.activeQuizzes[quizID]
+	qm.stateMutex.RUnlock()
+
+	if !inProgress {
+		return qm.inactiveQuizSync(quizID, userID)
+	}
+
+	state := active.state
+	quiz := state.Quiz
+	nowMs := time.Now().UnixMilli()
+	snapshot := &QuizSync{
+		QuizID:          quiz.ID,
+		Title:           quiz.Title,
+		Status:          string(quiz.Status),
+		TotalQuestions:  len(quiz.Questions),
+		Kicked:          qm.answerProcessor.IsKicked(quiz.ID, userID),
+		ServerTimestamp: nowMs,
+	}
+	snapshot.Participating, snapshot.Eliminated = qm.answerProcessor.ParticipationStatus(quiz.ID, userID)
+
+	question, number, startMs := state.CurrentQuestionSnapshot()
+	revealed := question != nil && state.QuestionRevealed(question.ID)
+	// Пока ответ на текущий вопрос не раскрыт, ответы на него не учитываются
+	// в таблице лидеров и выбывании: иначе они раскрыли бы правильность
+	var hiddenQuestionID uint
+	if question != nil {
+		snapshot.CurrentQuestion = newSyncQuestion(quiz, question, number, startMs, revealed)
+		if startMs == 0 {
+			if readUntilMs := state.CurrentQuestionReadUntil(); readUntilMs > 0 {
+				snapshot.CurrentQuestion.AnswersOpenAt = readUntilMs
+				snapshot.CurrentQuestion.EndsAtMs = readUntilMs + int64(question.TimeLimitSec)*1000
+			}
+		}
+		if startMs > 0 {
+			if remaining := startMs + int64(question.TimeLimitSec)*1000 - nowMs; remaining > 0 {
+				snapshot.QuestionOpen = true
+				snapshot.RemainingMs = remaining
+			}
+		}
+		if !revealed {
+			hiddenQuestionID = question.ID
+		}
+	}
+
+	// Ответ на текущий вопрос может еще ждать пакетной записи
+	if err := qm.answerWriter.Flush(); err != nil {
+		log.Printf("[QuizManager] WARNING: Не удалось записать накопленные ответы перед синхронизацией: %v", err)
+	}
+	answers, err := qm.resultService.resultRepo.GetUserAnswers(userID, quiz.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get answers of user %d: %w", userID, err)
+	}
+	if question != nil {
+		snapshot.MyAnswer = qm.syncAnswer(quiz, question.ID, userID, answers, revealed)
+	}
+	// При отложенных результатах игрок не должен узнать о выбывании на текущем
+	// вопросе до раскрытия: выбывание считается по остальным ответам
+	if snapshot.Eliminated && hiddenQuestionID != 0 && quiz.DefersAnswerResults() && snapshot.MyAnswer != nil && !snapshot.Kicked {
+		snapshot.Eliminated = eliminatedExcept(answers, hiddenQuestionID)
+	}
+
+	leaderboard, err := qm.resultService.LiveLeaderboard(quiz, hiddenQuestionID, SyncLeaderboardSize)
+	if err != nil {
+		return nil, err
+	}
+	snapshot.Leaderboard = leaderboard
+	return snapshot, nil
+}
+
+// inactiveQuizSync возвращает снимок викторины, которая сейчас не проводится
+// checkSyncAccess проверяет видимость викторины для снимка состояния. Игроку,
+// уже занявшему место, код не нужен; остальным викторина читается из БД, чтобы
+// перевыпуск кода действовал сразу.
+func (qm *QuizManager) checkSyncAccess(quizID, userID uint, inviteCode string) error {
+	if qm.joins.IsJoined(quizID, userID) {
+		return nil
+	}
+	quiz, err := qm.quizRepo.GetByID(quizID)
+	if err != nil {
+		return quizLookupError(quizID, err)
+	}
+	return checkInviteCode(quiz, inviteCode)
+}
+
+func (qm *QuizManager) inactiveQuizSync(quizID, userID uint) (*QuizSync, error) {
+	quiz, err := qm.quizRepo.GetWithQuestions(quizID)
+	if err != nil {
+		return nil, quizLookupError(quizID, err)
+	}
+
+	snapshot := &QuizSync{
+		QuizID:          quiz.ID,
+		Title:           quiz.Title,
+		Status:          string(quiz.Status),
+		TotalQuestions:  len(quiz.Questions),
+		Kicked:          qm.answerProcessor.IsKicked(quiz.ID, userID),
+		Leaderboard:     []LeaderboardEntry{},
+		ServerTimestamp: time.Now().UnixMilli(),
+	}
+	snapshot.Participating, snapshot.Eliminated = qm.answerProcessor.ParticipationStatus(quiz.ID, userID)
+
+	if quiz.IsCompleted() {
+		leaderboard, err := qm.resultService.FinalLeaderboard(quiz.ID, SyncLeaderboardSize)
+		if err != nil {
+			return nil, err
+		}
+		snapshot.Leaderboard = leaderboard
+	}
+	return snapshot, nil
+}
+
+// syncAnswer возвращает ответ игрока на вопрос. Результат раскрывается, только
+// если игрок уже получил его в quiz:answer_result.
+func (qm *QuizManager) syncAnswer(quiz *entity.Quiz, questionID, userID uint, answers []entity.UserAnswer, revealed bool) *SyncAnswer {
+	for _, answer := range answers {
+		if answer.QuestionID != questionID {
+			continue
+		}
+		synced := &SyncAnswer{
+			SelectedOption: answer.SelectedOption,
+			SelectedOrder:  []int(answer.SelectedOrder),
+		}
+		if quiz.DefersAnswerResults() && !revealed {
+			synced.ResultPending = true
+			return synced
+		}
+		isCorrect, points := answer.IsCorrect, answer.Score
+		synced.IsCorrect = &isCorrect
+		synced.PointsEarned = &points
+		synced.PenaltyApplied = answer.Penalty
+		synced.TimeTakenMs = answer.ResponseTimeMs
+		synced.FastestFinger = answer.FastestFinger
+		return synced
+	}
+
+	// Ответ зафиксирован, но еще ждет обработки в очереди
+	if lock := qm.answerProcessor.LockedAnswer(quiz.ID, userID, questionID); lock != nil {
+		return &SyncAnswer{SelectedOption: lock.Option, SelectedOrder: lock.Order, ResultPending: true}
+	}
+	return nil
+}
+
+// newSyncQuestion формирует текущий вопрос; правильный ответ - только после раскрытия
+func newSyncQuestion(quiz *entity.Quiz, question *entity.Question, number int, startMs int64, revealed bool) *SyncQuestion {
+	synced := &SyncQuestion{
+		ID:         question.ID,
+		Number:     number,
+		Type:       question.Type,
+		Text:       question.Text,
+		Options:    helper.ConvertOptionsToObjects(question.Options),
+		TimeLimit:  question.TimeLimitSec,
+		PointValue: quiz.EffectivePointValue(question),
+		StartTime:  startMs,
+		Revealed:   revealed,
+	}
+	if startMs > 0 {
+		synced.EndsAtMs = startMs + int64(question.TimeLimitSec)*1000
+	}
+	if revealed {
+		correctOption := question.CorrectOption
+		synced.CorrectOption = &correctOption
+		if question.IsOrdering() {
+			synced.CorrectOrder = []int(question.CorrectOrder)
+		}
+	}
+	return synced
+}
+
+// eliminatedExcept сообщает, выбыл ли игрок на вопросах, кроме указанного
+// (0 - учитываются все ответы)
+func eliminatedExcept(answers []entity.UserAnswer, questionID uint) bool {
+	for _, answer := range answers {
+		if answer.QuestionID != questionID && answer.IsEliminated {
+			return true
+		}
+	}
+	return false
+}
+
+// LiveLeaderboard возвращает первые limit строк таблицы лидеров идущей викторины,
+// посчитанной по сохраненным ответам. Ответы на вопрос hiddenQuestionID (еще не
+// раскрытый) не учитываются. Таблица кэшируется на liveLeaderboardTTL.
+func (s *ResultService) LiveLeaderboard(quiz *entity.Quiz, hiddenQuestionID uint, limit int) ([]LeaderboardEntry, error) {
+	cacheKey := fmt.Sprintf("quiz:%d:live_leaderboard:%d", quiz.ID, hiddenQuestionID)
+	var cached []LeaderboardEntry
+	if err := s.cacheRepo.GetJSON(cacheKey, &cached); err == nil && cached != nil {
+		return cached, nil
+	}
+
+	leaderboard, err := s.liveLeaderboard(quiz, hiddenQuestionID, limit, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.cacheRepo.SetJSON(cacheKey, leaderboard, liveLeaderboardTTL); err != nil {
+		log.Printf("[ResultService] WARNING: Не удалось сохранить таблицу лидеров викторины #%d в кэш: %v", quiz.ID, err)
+	}
+	return leaderboard, nil
+}
+
+// liveLeaderboard считает таблицу лидеров идущей викторины без кэша. Имена
+// игроков из knownNames не запрашиваются повторно.
+func (s *ResultService) liveLeaderboard(quiz *entity.Quiz, hiddenQuestionID uint, limit int, knownNames map[uint]string) ([]LeaderboardEntry, error) {
+	answers, err := s.resultRepo.GetQuizUserAnswers(quiz.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get answers of quiz %d: %w", quiz.ID, err)
+	}
+	leaderboard := rankLiveAnswers(quiz, answers, hiddenQuestionID, limit)
+	for i := range leaderboard {
+		if name, ok := knownNames[leaderboard[i].UserID]; ok {
+			leaderboard[i].Username = name
+			continue
+		}
+		if s.userRepo == nil {
+			continue
+		}
+		if user, err := s.userRepo.GetByID(leaderboard[i].UserID); err == nil {
+			leaderboard[i].Username = user.Username
+		}
+	}
+	return leaderboard, nil
+}
+
+// FinalLeaderboard возвращает первые limit строк итоговой таблицы завершенной викторины
+func (s *ResultService) FinalLeaderboard(quizID uint, limit int) ([]LeaderboardEntry, error) {
+	results, err := s.resultRepo.GetQuizResults(quizID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get results of quiz %d: %w", quizID, err)
+	}
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Rank < results[j].Rank })
+
+	leaderboard := make([]LeaderboardEntry, 0, min(limit, len(results)))
+	for _, result := range results {
+		if len(leaderboard) == limit {
+			break
+		}
+		leaderboard = append(leaderboard, LeaderboardEntry{
+			Rank:           result.Rank,
+			UserID:         result.UserID,
+			Username:       result.Username,
+			Score:          result.Score,
+			CorrectAnswers: result.CorrectAnswers,
+			IsEliminated:   result.IsEliminated,
+		})
+	}
+	return leaderboard, nil
+}
+
+// rankLiveAnswers подсчитывает счет каждого игрока так же, как итоговый результат,
+// и возвращает первые limit мест: по убыванию счета, затем правильных ответов.
+// Игроки с одинаковыми счетом и числом правильных ответов делят место.
+func rankLiveAnswers(quiz *entity.Quiz, answers []entity.UserAnswer, hiddenQuestionID uint, limit int) []LeaderboardEntry {
+	byUser := make(map[uint][]entity.UserAnswer)
+	for _, answer := range answers {
+		if answer.QuestionID == hiddenQuestionID {
+			continue
+		}
+		byUser[answer.UserID] = append(byUser[answer.UserID], answer)
+	}
+
+	leaderboard := make([]LeaderboardEntry, 0, len(byUser))
+	for userID, userAnswers := range byUser {
+		score, correct := scoreAnswers(quiz, userAnswers)
+		leaderboard = append(leaderboard, LeaderboardEntry{
+			UserID:         userID,
+			Score:          score,
+			CorrectAnswers: correct,
+			IsEliminated:   eliminatedExcept(userAnswers, 0),
+		})
+	}
+	sort.Slice(leaderboard, func(i, j int) bool {
+		a, b := leaderboard[i], leaderboard[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if a.CorrectAnswers != b.CorrectAnswers {
+			return a.CorrectAnswers > b.CorrectAnswers
+		}
+		return a.UserID < b.UserID
+	})
+
+	for i := range leaderboard {
+		leaderboard[i].Rank = i + 1
+		if i > 0 && leaderboard[i].Score == leaderboard[i-1].Score && leaderboard[i].CorrectAnswers == leaderboard[i-1].CorrectAnswers {
+			leaderboard[i].Rank = leaderboard[i-1].Rank
+		}
+	}
+	if len(leaderboard) > limit {
+		leaderboard = leaderboard[:limit]
+	}
+	return leaderboard
+}
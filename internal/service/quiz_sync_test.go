@@ -0,0 +1,43 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourusername/trivia-api/internal/domain/entity"
+)
+
+func TestRankLiveAnswers_HidesOpenQuestionAndSharesTies(t *testing.T) {
+	quiz := &entity.Quiz{ID: 1}
+	answers := []entity.UserAnswer{
+		{UserID: 1, QuestionID: 10, Score: 10, IsCorrect: true},
+		{UserID: 2, QuestionID: 10, Score: 10, IsCorrect: true},
+		{UserID: 3, QuestionID: 10, Score: 0, IsEliminated: true},
+		// Ответы на еще не раскрытый вопрос не должны менять таблицу
+		{UserID: 1, QuestionID: 11, Score: 10, IsCorrect: true},
+		{UserID: 4, QuestionID: 11, Score: 10, IsCorrect: true},
+	}
+
+	leaderboard := rankLiveAnswers(quiz, answers, 11, 10)
+	require.Len(t, leaderboard, 3)
+
+	assert.Equal(t, LeaderboardEntry{Rank: 1, UserID: 1, Score: 10, CorrectAnswers: 1}, leaderboard[0])
+	assert.Equal(t, LeaderboardEntry{Rank: 1, UserID: 2, Score: 10, CorrectAnswers: 1}, leaderboard[1])
+	assert.Equal(t, LeaderboardEntry{Rank: 3, UserID: 3, IsEliminated: true}, leaderboard[2])
+
+	assert.Len(t, rankLiveAnswers(quiz, answers, 11, 2), 2)
+}
+
+func TestNewSyncQuestion_HidesCorrectOptionUntilRevealed(t *testing.T) {
+	quiz := &entity.Quiz{ID: 1}
+	question := &entity.Question{ID: 10, Text: "Q", Options: entity.StringArray{"a", "b"}, CorrectOption: 1, TimeLimitSec: 10, PointValue: 5}
+
+	open := newSyncQuestion(quiz, question, 1, 1000, false)
+	assert.Nil(t, open.CorrectOption)
+	assert.False(t, open.Revealed)
+
+	revealed := newSyncQuestion(quiz, question, 1, 1000, true)
+	require.NotNil(t, revealed.CorrectOption)
+	assert.Equal(t, 1, *revealed.CorrectOption)
+}
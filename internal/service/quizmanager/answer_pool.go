@@ -0,0 +1,259 @@
+package quizmanager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/yourusername/trivia-api/internal/websocket"
+)
+
+// ErrAnswerQueueOverloaded возвращается, когда очередь ответов и буфер
+// отложенных ответов заполнены полностью
+var ErrAnswerQueueOverloaded = errors.New("answer queue overloaded")
+
+// AnswerPool принимает ответы пользователей в ограниченную очередь и обрабатывает
+// их фиксированным числом воркеров. При насыщении очереди пул переходит в режим
+// сброса нагрузки: ответ сразу подтверждается пользователю, откладывается
+// и обрабатывается позже пакетами, а в систему алертов уходит предупреждение.
+type AnswerPool struct {
+	config    *Config
+	deps      *Dependencies
+	processor *AnswerProcessor
+
+	queue chan *AnswerSubmission
+
+	// Отложенные ответы (режим сброса нагрузки)
+	pendingMu sync.Mutex
+	pending   []*AnswerSubmission
+
+	// Время последнего алерта о насыщении (Unix ms)
+	lastAlertMs atomic.Int64
+
+	// Счетчики для метрик
+	processedTotal   atomic.Int64
+	failedTotal      atomic.Int64
+	shedTotal        atomic.Int64
+	rejectedTotal    atomic.Int64
+	saturationEvents atomic.Int64
+
+	wg sync.WaitGroup
+}
+
+// NewAnswerPool создает новый пул обработки ответов
+func NewAnswerPool(config *Config, deps *Dependencies, processor *AnswerProcessor) *AnswerPool {
+	queueSize := config.AnswerQueueSize
+	if queueSize <= 0 {
+		queueSize = 1
+	}
+
+	return &AnswerPool{
+		config:    config,
+		deps:      deps,
+		processor: processor,
+		queue:     make(chan *AnswerSubmission, queueSize),
+	}
+}
+
+// Start запускает воркеры и обработчик отложенных ответов.
+// Все горутины завершаются при отмене контекста.
+func (p *AnswerPool) Start(ctx context.Context) {
+	workers := p.config.AnswerWorkers
+	if workers <= 0 {
+		workers = 1
+	}
+
+	for i := 0; i < workers; i++ {
+		p.wg.Add(1)
+		go p.worker(ctx)
+	}
+
+	p.wg.Add(1)
+	go p.flushLoop(ctx)
+
+	log.Printf("[AnswerPool] Запущено %d воркеров, емкость очереди: %d", workers, cap(p.queue))
+}
+
+// Wait ожидает завершения всех горутин пула
+func (p *AnswerPool) Wait() {
+	p.wg.Wait()
+}
+
+// Submit ставит ответ в очередь на обработку. Если очередь заполнена,
+// ответ откладывается до пакетной обработки; ошибка возвращается только
+// когда переполнен и буфер отложенных ответов.
+func (p *AnswerPool) Submit(sub *AnswerSubmission) error {
+	select {
+	case p.queue <- sub:
+		return nil
+	default:
+	}
+
+	// Очередь насыщена - переходим к сбросу нагрузки
+	p.saturationEvents.Add(1)
+	p.alertSaturation()
+
+	p.pendingMu.Lock()
+	if len(p.pending) >= p.config.AnswerShedMaxPending {
+		p.pendingMu.Unlock()
+		p.rejectedTotal.Add(1)
+		log.Printf("[AnswerPool] Ответ пользователя #%d на вопрос #%d отклонен: буфер отложенных ответов заполнен",
+			sub.UserID, sub.QuestionID)
+		return ErrAnswerQueueOverloaded
+	}
+	p.pending = append(p.pending, sub)
+	p.pendingMu.Unlock()
+	p.shedTotal.Add(1)
+
+	// Подтверждаем прием, чтобы клиент не отправлял ответ повторно.
+	// Результат придет позже, после пакетной обработки.
+	receivedEvent := map[string]interface{}{
+		"question_id":     sub.QuestionID,
+		"selected_option": sub.SelectedOption,
+		"status":          "queued",
+	}
+	if err := p.deps.WSManager.SendEventToUser(fmt.Sprintf("%d", sub.UserID), "quiz:answer_received", receivedEvent); err != nil {
+		log.Printf("[AnswerPool] Ошибка при отправке подтверждения приема ответа пользователю #%d: %v", sub.UserID, err)
+	}
+
+	return nil
+}
+
+// worker обрабатывает ответы из очереди
+func (p *AnswerPool) worker(ctx context.Context) {
+	defer p.wg.Done()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case sub := <-p.queue:
+			p.process(ctx, sub)
+		}
+	}
+}
+
+// flushLoop периодически обрабатывает отложенные ответы пакетами
+func (p *AnswerPool) flushLoop(ctx context.Context) {
+	defer p.wg.Done()
+
+	interval := p.config.AnswerShedFlushInterval
+	if interval <= 0 {
+		interval = 200 * time.Millisecond
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			p.pendingMu.Lock()
+			if len(p.pending) > 0 {
+				log.Printf("[AnswerPool] Остановка: %d отложенных ответов не обработано", len(p.pending))
+			}
+			p.pendingMu.Unlock()
+			return
+		case <-ticker.C:
+			batch := p.takeBatch()
+			for _, sub := range batch {
+				p.process(ctx, sub)
+			}
+			if len(batch) > 0 {
+				log.Printf("[AnswerPool] Обработан пакет отложенных ответов: %d", len(batch))
+			}
+		}
+	}
+}
+
+// takeBatch извлекает очередной пакет отложенных ответов
+func (p *AnswerPool) takeBatch() []*AnswerSubmission {
+	p.pendingMu.Lock()
+	defer p.pendingMu.Unlock()
+
+	n := len(p.pending)
+	if n == 0 {
+		return nil
+	}
+	if batchSize := p.config.AnswerShedBatchSize; batchSize > 0 && n > batchSize {
+		n = batchSize
+	}
+
+	batch := make([]*AnswerSubmission, n)
+	copy(batch, p.pending[:n])
+	p.pending = p.pending[n:]
+	return batch
+}
+
+// process обрабатывает один ответ и сообщает пользователю об ошибке
+func (p *AnswerPool) process(ctx context.Context, sub *AnswerSubmission) {
+	if err := p.processor.ProcessSubmission(ctx, sub); err != nil {
+		p.failedTotal.Add(1)
+		log.Printf("[AnswerPool] Ошибка при обработке ответа пользователя #%d на вопрос #%d: %v",
+			sub.UserID, sub.QuestionID, err)
+
+		errorEvent := map[string]string{
+			"code":    "answer_error",
+			"message": err.Error(),
+		}
+		if sendErr := p.deps.WSManager.SendEventToUser(fmt.Sprintf("%d", sub.UserID), "server:error", errorEvent); sendErr != nil {
+			log.Printf("[AnswerPool] Ошибка при отправке ошибки ответа пользователю #%d: %v", sub.UserID, sendErr)
+		}
+		return
+	}
+	p.processedTotal.Add(1)
+}
+
+// alertSaturation отправляет алерт о насыщении очереди не чаще AnswerAlertCooldown
+func (p *AnswerPool) alertSaturation() {
+	now := time.Now().UnixMilli()
+	last := p.lastAlertMs.Load()
+	if now-last < p.config.AnswerAlertCooldown.Milliseconds() {
+		return
+	}
+	if !p.lastAlertMs.CompareAndSwap(last, now) {
+		return
+	}
+
+	p.pendingMu.Lock()
+	pending := len(p.pending)
+	p.pendingMu.Unlock()
+
+	severity := websocket.AlertWarning
+	if pending >= p.config.AnswerShedMaxPending/2 {
+		severity = websocket.AlertCritical
+	}
+
+	p.deps.WSManager.SendAlert(websocket.AlertAnswerQueueSaturated, severity,
+		fmt.Sprintf("Очередь ответов заполнена (%d/%d), включен сброс нагрузки", len(p.queue), cap(p.queue)),
+		map[string]interface{}{
+			"queue_depth":    len(p.queue),
+			"queue_capacity": cap(p.queue),
+			"shed_pending":   pending,
+			"shed_total":     p.shedTotal.Load(),
+			"rejected_total": p.rejectedTotal.Load(),
+		})
+}
+
+// GetMetrics возвращает метрики очереди ответов
+func (p *AnswerPool) GetMetrics() map[string]interface{} {
+	p.pendingMu.Lock()
+	pending := len(p.pending)
+	p.pendingMu.Unlock()
+
+	return map[string]interface{}{
+		"queue_depth":       len(p.queue),
+		"queue_capacity":    cap(p.queue),
+		"workers":           p.config.AnswerWorkers,
+		"shed_pending":      pending,
+		"shed_max_pending":  p.config.AnswerShedMaxPending,
+		"processed_total":   p.processedTotal.Load(),
+		"failed_total":      p.failedTotal.Load(),
+		"shed_total":        p.shedTotal.Load(),
+		"rejected_total":    p.rejectedTotal.Load(),
+		"saturation_events": p.saturationEvents.Load(),
+	}
+}
@@ -0,0 +1,92 @@
+package quizmanager
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestAnswerPool(queueSize, maxPending int) (*AnswerPool, *recordingHub) {
+	ap, hub := newTestProcessor()
+	config := DefaultConfig()
+	config.AnswerWorkers = 1
+	config.AnswerQueueSize = queueSize
+	config.AnswerShedMaxPending = maxPending
+	config.AnswerShedFlushInterval = 10 * time.Millisecond
+	return NewAnswerPool(config, ap.deps, ap), hub
+}
+
+// TestAnswerPool_ShedsLoadWhenQueueFull проверяет, что при заполненной очереди
+// ответ откладывается с подтверждением приема, а сверх буфера отклоняется
+func TestAnswerPool_ShedsLoadWhenQueueFull(t *testing.T) {
+	pool, hub := newTestAnswerPool(1, 1)
+
+	require.NoError(t, pool.Submit(testSubmission(1, 2, false)))
+	assert.Empty(t, hub.Events(), "ответ из очереди подтверждается после обработки")
+
+	require.NoError(t, pool.Submit(testSubmission(2, 2, false)))
+	assert.Equal(t, []string{"2:quiz:answer_received"}, hub.Events())
+
+	assert.ErrorIs(t, pool.Submit(testSubmission(3, 2, false)), ErrAnswerQueueOverloaded)
+
+	metrics := pool.GetMetrics()
+	assert.Equal(t, 1, metrics["queue_depth"])
+	assert.Equal(t, 1, metrics["shed_pending"])
+	assert.Equal(t, int64(1), metrics["shed_total"])
+	assert.Equal(t, int64(1), metrics["rejected_total"])
+	assert.Equal(t, int64(2), metrics["saturation_events"])
+}
+
+// TestAnswerPool_ProcessesQueuedAndShedAnswers проверяет, что воркеры
+// обрабатывают и ответы очереди, и отложенные ответы
+func TestAnswerPool_ProcessesQueuedAndShedAnswers(t *testing.T) {
+	pool, hub := newTestAnswerPool(1, 10)
+	for userID := uint(1); userID <= 3; userID++ {
+		require.NoError(t, pool.Submit(testSubmission(userID, 2, false)))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	pool.Start(ctx)
+	require.Eventually(t, func() bool {
+		return pool.GetMetrics()["processed_total"] == int64(3)
+	}, time.Second, 5*time.Millisecond)
+	cancel()
+	pool.Wait()
+
+	for _, event := range []string{"1:quiz:answer_result", "2:quiz:answer_result", "3:quiz:answer_result"} {
+		assert.Contains(t, hub.Events(), event)
+	}
+	assert.Equal(t, 0, pool.GetMetrics()["shed_pending"])
+}
+
+// TestAnswerPool_ReportsProcessingErrors проверяет, что об отклоненном ответе
+// игрок узнает из server:error
+func TestAnswerPool_ReportsProcessingErrors(t *testing.T) {
+	pool, hub := newTestAnswerPool(4, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	pool.Start(ctx)
+	defer func() {
+		cancel()
+		pool.Wait()
+	}()
+
+	// Исключенный игрок больше не отвечает
+	require.NoError(t, pool.deps.CacheRepo.Set(KickedKey(1, 2), "spam", time.Hour))
+	require.NoError(t, pool.Submit(testSubmission(1, 2, false)))
+	require.NoError(t, pool.Submit(testSubmission(2, 2, false)))
+	require.Eventually(t, func() bool {
+		return pool.GetMetrics()["failed_total"] == int64(1)
+	}, time.Second, 5*time.Millisecond)
+	require.Eventually(t, func() bool {
+		for _, event := range hub.Events() {
+			if event == "2:server:error" {
+				return true
+			}
+		}
+		return false
+	}, time.Second, 5*time.Millisecond)
+	assert.Equal(t, int64(1), pool.GetMetrics()["processed_total"])
+}
@@ -2,8 +2,11 @@ package quizmanager
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"strconv"
 	"time"
 
 	"github.com/yourusername/trivia-api/internal/domain/entity"
@@ -16,14 +19,82 @@ type AnswerProcessor struct {
 
 	// Зависимости
 	deps *Dependencies
+
+	// Результаты, ожидающие закрытия вопроса (режим отложенных результатов)
+	results *ResultBuffer
+
+	// Пакетная запись ответов в БД (nil - каждый ответ записывается сразу)
+	writer *AnswerWriter
 }
 
 // NewAnswerProcessor создает новый процессор ответов
 func NewAnswerProcessor(config *Config, deps *Dependencies) *AnswerProcessor {
-	return &AnswerProcessor{
+	ap := &AnswerProcessor{
 		config: config,
 		deps:   deps,
 	}
+	ap.results = NewResultBuffer(func(userID string, eventType string, data interface{}) error {
+		return ap.deps.WSManager.SendEventToUser(userID, eventType, data)
+	})
+	return ap
+}
+
+// ErrQuestionReadPhase возвращается для ответа, отправленного во время чтения
+// вопроса, до quiz:answer_window_open
+var ErrQuestionReadPhase = errors.New("answers are not accepted during the question read phase")
+
+// SetAnswerWriter задает буфер пакетной записи ответов
+func (ap *AnswerProcessor) SetAnswerWriter(writer *AnswerWriter) {
+	ap.writer = writer
+}
+
+// saveAnswer записывает ответ в БД или ставит его в пакет на запись
+func (ap *AnswerProcessor) saveAnswer(answer *entity.UserAnswer) error {
+	if ap.writer != nil && ap.writer.Enabled() {
+		return ap.writer.Add(answer)
+	}
+	return ap.deps.ResultRepo.SaveUserAnswer(answer)
+}
+
+// AnswerSubmission описывает принятый ответ пользователя вместе со снимком
+// текущего вопроса на момент приема. Снимок позволяет обработать ответ позже
+// (например, из очереди), не завися от того, что вопрос уже сменился.
+type AnswerSubmission struct {
+	UserID          uint
+	QuizID          uint
+	QuestionID      uint
+	SelectedOption  int
+	SelectedOrder   []int // Порядок вариантов для вопросов ordering
+	Timestamp       int64
+	Question        *entity.Question
+	QuestionStartMs int64 // Начало времени на ответ игрока: рассылка вопроса или подтверждение его получения
+	// Стоимость вопроса в этой викторине (Quiz.EffectivePointValue). Очки
+	// считаются только от нее, PointValue снимка вопроса не используется.
+	PointValue int
+	// Штраф за неверный ответ (Quiz.WrongAnswerPenalty)
+	WrongAnswerPenalty int
+	// Результат не сообщается до закрытия вопроса (Quiz.DefersAnswerResults)
+	DelayedResults bool
+	// Сразу после обработки отправляется нейтральное подтверждение (Quiz.SuppressAnswerFeedback)
+	AcknowledgeOnly bool
+	// Немедленный результат не раскрывает правильный ответ (Quiz.HideCorrectAnswer)
+	HideCorrectAnswer bool
+	// Время приема ответа сервером (Unix ms)
+	ReceivedAtMs int64
+	// Бонус первому правильно ответившему (Quiz.FastestFingerBonus) и учет
+	// первенства в состоянии викторины. nil - бонус в викторине не начисляется.
+	FastestFingerBonus int
+	FastestFinger      *FastestFingerTracker
+	// Номер кандидата на первенство в FastestFinger (0 - не зарегистрирован)
+	fastestFingerSeq uint64
+	// Самостоятельное прохождение: нет лимита времени вопроса и выбывания, очки
+	// начисляются без учета скорости, после ответа игрок получает следующий вопрос
+	SelfPaced *SelfPacedRun
+	// Учет ответов активных игроков для досрочного закрытия вопроса
+	// (Quiz.AutoAdvance). nil - вопрос закрывается только по таймеру.
+	Answers *AnswerTracker
+	// Учет жизней игроков (Quiz.Lives > 1). nil - игрок выбывает с первой ошибки.
+	Lives *LivesTracker
 }
 
 // ProcessAnswer обрабатывает ответ пользователя
@@ -35,18 +106,171 @@ func (ap *AnswerProcessor) ProcessAnswer(
 	timestamp int64,
 	quizState *ActiveQuizState,
 ) error {
-	log.Printf("[AnswerProcessor] Обработка ответа пользователя #%d на вопрос #%d, выбранный вариант: %d",
-		userID, questionID, selectedOption)
+	submission, err := ap.PrepareSubmission(userID, questionID, selectedOption, timestamp, quizState)
+	if err != nil {
+		return err
+	}
+	return ap.ProcessSubmission(ctx, submission)
+}
 
+// PrepareSubmission выполняет дешевые проверки ответа (без обращений к Redis и БД)
+// и фиксирует снимок текущего вопроса
+func (ap *AnswerProcessor) PrepareSubmission(
+	userID uint,
+	questionID uint,
+	selectedOption int,
+	timestamp int64,
+	quizState *ActiveQuizState,
+) (*AnswerSubmission, error) {
 	// Проверяем наличие активной викторины
 	if quizState == nil || quizState.Quiz == nil {
 		log.Printf("[AnswerProcessor] Ошибка: нет активной викторины для ответа пользователя #%d", userID)
-		return fmt.Errorf("no active quiz")
+		return nil, fmt.Errorf("no active quiz")
+	}
+
+	// Получаем текущий вопрос и время его начала одним снимком состояния,
+	// чтобы не засчитать ответ по времени старта другого вопроса
+	currentQuestion, _, startTime := quizState.CurrentQuestionSnapshot()
+	if currentQuestion == nil || currentQuestion.ID != questionID {
+		log.Printf("[AnswerProcessor] Ошибка: вопрос #%d не является текущим активным вопросом", questionID)
+		return nil, fmt.Errorf("question is not the current active question")
 	}
 
-	quizID := quizState.Quiz.ID
+	if startTime == 0 && quizState.CurrentQuestionReadUntil() > 0 {
+		// Вопрос показан, но идет время на чтение (Quiz.QuestionReadTimeSec)
+		log.Printf("[AnswerProcessor] Ответ пользователя #%d на вопрос #%d отклонен: идет время на чтение вопроса", userID, questionID)
+		return nil, ErrQuestionReadPhase
+	}
+	if startTime == 0 {
+		// Вопрос выбран, но еще не отправлен участникам (задержка перед отправкой)
+		log.Printf("[AnswerProcessor] Вопрос #%d викторины #%d еще не открыт для ответов", questionID, quizState.Quiz.ID)
+		return nil, fmt.Errorf("question is not open for answers yet")
+	}
+
+	submission := &AnswerSubmission{
+		UserID:          userID,
+		QuizID:          quizState.Quiz.ID,
+		QuestionID:      questionID,
+		SelectedOption:  selectedOption,
+		Timestamp:       timestamp,
+		Question:        currentQuestion,
+		QuestionStartMs: quizState.AnswerWindowStart(questionID, userID, startTime, ap.config.MaxQuestionAckOffsetMs),
+		PointValue:      quizState.Quiz.EffectivePointValue(currentQuestion),
+		// Штраф фиксируется вместе со стоимостью вопроса на момент ответа
+		WrongAnswerPenalty: quizState.Quiz.WrongAnswerPenalty,
+		DelayedResults:     quizState.Quiz.DefersAnswerResults(),
+		AcknowledgeOnly:    quizState.Quiz.SuppressAnswerFeedback,
+		HideCorrectAnswer:  quizState.Quiz.HideCorrectAnswer,
+		ReceivedAtMs:       time.Now().UnixMilli(),
+	}
+	if bonus := quizState.Quiz.FastestFingerBonus; bonus > 0 {
+		submission.FastestFingerBonus = bonus
+		submission.FastestFinger = quizState.FastestFinger()
+	}
+	if quizState.Quiz.AutoAdvance {
+		submission.Answers = quizState.Answers()
+	}
+	submission.OfferFastestFinger()
+	if quizState.Quiz.EliminationLives() > 1 {
+		submission.Lives = quizState.Lives()
+	}
+	return submission, nil
+}
+
+// OfferFastestFinger регистрирует правильный ответ, принятый в срок, кандидатом
+// на бонус первому правильному ответу. Вызывается при приеме ответа, чтобы
+// первенство определялось временем приема, а не порядком обработки воркерами;
+// для вопросов ordering - после того, как задан SelectedOrder.
+func (sub *AnswerSubmission) OfferFastestFinger() {
+	if sub.FastestFinger == nil || sub.fastestFingerSeq != 0 || !sub.correctInTime() {
+		return
+	}
+	sub.fastestFingerSeq = sub.FastestFinger.Offer(sub.QuestionID, sub.UserID, sub.ReceivedAtMs)
+}
+
+// DiscardFastestFinger снимает кандидата на первенство, если ответ не будет
+// обработан (например, отклонен очередью ответов)
+func (sub *AnswerSubmission) DiscardFastestFinger() {
+	sub.settleFastestFinger(false)
+}
+
+// settleFastestFinger снимает кандидата после обработки ответа и сообщает,
+// получил ли ответ первенство. Ответ, не зарегистрированный при приеме,
+// регистрируется здесь со своим временем приема.
+func (sub *AnswerSubmission) settleFastestFinger(accepted bool) bool {
+	if sub.FastestFinger == nil {
+		return false
+	}
+	if sub.fastestFingerSeq == 0 {
+		if !accepted {
+			return false
+		}
+		sub.fastestFingerSeq = sub.FastestFinger.Offer(sub.QuestionID, sub.UserID, sub.ReceivedAtMs)
+	}
+	seq := sub.fastestFingerSeq
+	sub.fastestFingerSeq = 0
+	return sub.FastestFinger.Settle(sub.QuestionID, seq, accepted)
+}
+
+// correctInTime сообщает по снимку вопроса, правилен ли ответ и принят ли он в срок
+func (sub *AnswerSubmission) correctInTime() bool {
+	question := sub.Question
+	if question.IsOrdering() {
+		if question.OrderingCredit(sub.SelectedOrder) != 1 {
+			return false
+		}
+	} else if !question.IsCorrect(sub.SelectedOption) {
+		return false
+	}
+	if sub.Timestamp-sub.QuestionStartMs > int64(question.TimeLimitSec*1000) {
+		return false
+	}
+	return sub.Answers == nil || !sub.Answers.ClosedBefore(sub.QuestionID, sub.ReceivedAtMs)
+}
+
+// ProcessSubmission проверяет выбывание и повторные ответы, подсчитывает очки,
+// сохраняет ответ и уведомляет пользователя
+func (ap *AnswerProcessor) ProcessSubmission(ctx context.Context, sub *AnswerSubmission) (err error) {
+	// Отклоненный до подсчета очков ответ перестает быть кандидатом на первенство
+	defer sub.DiscardFastestFinger()
+	// При самостоятельном прохождении незаписанный ответ возвращает игрока к вопросу
+	defer func() {
+		if err != nil {
+			sub.ReturnSelfPacedQuestion()
+		}
+	}()
+
+	userID := sub.UserID
+	quizID := sub.QuizID
+	questionID := sub.QuestionID
+	selectedOption := sub.SelectedOption
+	currentQuestion := sub.Question
+
+	log.Printf("[AnswerProcessor] Обработка ответа пользователя #%d на вопрос #%d, выбранный вариант: %d",
+		userID, questionID, selectedOption)
 
 	// -------------------- Начало проверок выбывания и дубликатов --------------------
+	// Повторная отправка уже зафиксированного ответа получает то же подтверждение.
+	// Проверка идет до проверки выбывания, чтобы повтор не раскрыл выбывание раньше времени.
+	answerKey := AnswerLockKey(quizID, userID, questionID)
+	if ap.resendAnswerAck(sub) {
+		return nil
+	}
+
+	// Отвечать могут только игроки, занявшие место в викторине: получившему
+	// отказ в присоединении (нет мест, нужен код приглашения, викторина уже
+	// идет) ответ не засчитывается
+	if !HasJoined(ap.deps.CacheRepo, quizID, userID) {
+		log.Printf("[AnswerProcessor] Пользователь #%d не занимает место в викторине #%d, ответ отклонен", userID, quizID)
+		return fmt.Errorf("%w: quiz %d", ErrNotParticipant, quizID)
+	}
+
+	// Исключенный модератором игрок больше не отвечает
+	if ap.IsKicked(quizID, userID) {
+		log.Printf("[AnswerProcessor] Пользователь #%d исключен из викторины #%d, ответ отклонен", userID, quizID)
+		return ErrParticipantKicked
+	}
+
 	// Проверяем, не выбыл ли пользователь
 	eliminationKey := fmt.Sprintf("quiz:%d:eliminated:%d", quizID, userID)
 	isEliminated, _ := ap.deps.CacheRepo.Exists(eliminationKey)
@@ -57,11 +281,15 @@ func (ap *AnswerProcessor) ProcessAnswer(
 		return fmt.Errorf("user is eliminated from this quiz")
 	}
 
-	// ===>>> ИЗМЕНЕНИЕ: Используем атомарный SetNX вместо Exists + Set <<<===
-	// Пытаемся установить флаг, что пользователь ответил на этот вопрос.
-	// SetNX вернет true, если ключ УСПЕШНО установлен (т.е. его не было).
-	answerKey := fmt.Sprintf("quiz:%d:user:%d:question:%d", quizID, userID, questionID)
-	wasSet, err := ap.deps.CacheRepo.SetNX(answerKey, "1", 1*time.Hour)
+	// Атомарно фиксируем ответ: SetNX вернет true, только если ключа еще не было.
+	// В ключе хранится выбранный вариант и номер подтверждения для повторных отправок.
+	lock := AnswerLock{
+		Seq:    ap.nextAnswerSeq(quizID),
+		Option: selectedOption,
+		Order:  sub.SelectedOrder,
+	}
+	lockValue, _ := json.Marshal(lock)
+	wasSet, err := ap.deps.CacheRepo.SetNX(answerKey, string(lockValue), 1*time.Hour)
 
 	// Логируем ошибку Redis, но не обязательно прерываем выполнение, если не критично
 	if err != nil {
@@ -70,22 +298,18 @@ func (ap *AnswerProcessor) ProcessAnswer(
 		// return fmt.Errorf("redis error during answer check: %w", err)
 	}
 
-	// Если ключ НЕ был установлен (wasSet == false), значит он уже существовал.
+	// Если ключ НЕ был установлен (wasSet == false), значит ответ уже зафиксирован
+	// параллельной отправкой
 	if !wasSet {
 		log.Printf("[AnswerProcessor] Пользователь #%d уже отвечал на вопрос #%d викторины #%d (определено через SetNX)", userID, questionID, quizID)
+		if ap.resendAnswerAck(sub) {
+			return nil
+		}
 		return fmt.Errorf("user already answered this question")
 	}
-	// ===>>> КОНЕЦ ИЗМЕНЕНИЯ <<<===
-
-	// Получаем текущий вопрос из состояния
-	currentQuestion, _ := quizState.GetCurrentQuestion()
-	if currentQuestion == nil || currentQuestion.ID != questionID {
-		log.Printf("[AnswerProcessor] Ошибка: вопрос #%d не является текущим активным вопросом", questionID)
-		return fmt.Errorf("question is not the current active question")
-	}
 
 	// Создаем ключ для Redis для проверки статуса пользователя
-	userStatusKey := fmt.Sprintf("quiz:%d:user:%d:status", quizState.Quiz.ID, userID)
+	userStatusKey := fmt.Sprintf("quiz:%d:user:%d:status", quizID, userID)
 
 	// Проверяем, не выбыл ли пользователь уже
 	userStatus, _ := ap.deps.CacheRepo.Get(userStatusKey)
@@ -105,41 +329,102 @@ func (ap *AnswerProcessor) ProcessAnswer(
 		return fmt.Errorf("user is already eliminated from the quiz")
 	}
 
-	// Получаем время начала вопроса из состояния викторины
-	startTime := quizState.GetCurrentQuestionStartTime()
-	if startTime == 0 {
-		// Обработка случая, если время старта не установлено (ошибка логики)
-		log.Printf("[AnswerProcessor] CRITICAL: Время начала для вопроса #%d не найдено в состоянии викторины #%d", questionID, quizID)
-		return fmt.Errorf("internal error: question start time not found in state")
-	}
-
 	// Вычисляем время ответа
-	responseTimeMs := timestamp - startTime
+	responseTimeMs := sub.Timestamp - sub.QuestionStartMs
 
 	// Проверяем, что время ответа не превышает лимит
 	timeLimit := int64(currentQuestion.TimeLimitSec * 1000)
 	isTimeLimitExceeded := responseTimeMs > timeLimit
 
+	// Ответ, принятый после досрочного закрытия вопроса, опоздал так же, как
+	// ответ после истечения времени: правильный ответ уже раскрыт
+	if sub.Answers != nil && sub.Answers.ClosedBefore(questionID, sub.ReceivedAtMs) {
+		isTimeLimitExceeded = true
+	}
+
 	// Проверяем, выбывает ли пользователь из-за слишком долгого ответа
 	isCriticalTimeExceeded := responseTimeMs > ap.config.EliminationTimeMs
 
-	// Проверяем, правильный ли ответ
-	isCorrect := currentQuestion.IsCorrect(selectedOption)
+	// При самостоятельном прохождении время ограничено только сроком викторины,
+	// а очки не зависят от скорости: вопросы приходят игрокам в разное время
+	scoringTimeMs := responseTimeMs
+	if sub.SelfPaced != nil {
+		isTimeLimitExceeded, isCriticalTimeExceeded = false, false
+		scoringTimeMs = 0
+	}
+
+	// Проверяем, правильный ли ответ, и вычисляем количество очков.
+	// Для вопросов ordering правильным считается только полностью верный порядок,
+	// но частичная правильность приносит часть очков (в зависимости от ScoringMethod).
+	var (
+		isCorrect bool
+		score     int
+		credit    float64
+	)
 	correctOption := currentQuestion.CorrectOption
+	// Очки считаются от стоимости вопроса в этой викторине, снимок вопроса не меняется
+	scoredQuestion := *currentQuestion
+	scoredQuestion.PointValue = sub.PointValue
+	if currentQuestion.IsOrdering() {
+		credit = currentQuestion.OrderingCredit(sub.SelectedOrder)
+		isCorrect = credit == 1
+		score = scoredQuestion.CalculateOrderingPoints(credit, scoringTimeMs)
+	} else {
+		isCorrect = currentQuestion.IsCorrect(selectedOption)
+		score = scoredQuestion.CalculatePoints(isCorrect, scoringTimeMs)
+	}
+
+	// Опоздание из-за кратковременного отключения прощается: ответ считается пропущенным
+	reprieved := isTimeLimitExceeded && ap.grantReconnectReprieve(sub)
+	if reprieved {
+		isCorrect, score, credit = false, 0, 0
+		log.Printf("[AnswerProcessor] Пользователь #%d опоздал с ответом на вопрос #%d после переподключения, выбывание прощено",
+			userID, questionID)
+	}
 
-	// Вычисляем количество очков
-	score := currentQuestion.CalculatePoints(isCorrect, responseTimeMs)
+	// Штраф за неверный ответ вычитается из итогового счета в ResultService.
+	// Прощенное опоздание считается пропуском и не штрафуется.
+	penalty := 0
+	if !isCorrect && !reprieved && sub.WrongAnswerPenalty > 0 {
+		penalty = sub.WrongAnswerPenalty
+	}
 
-	// Проверяем, нужно ли выбывать пользователю (неверный ответ или слишком долгий ответ)
-	userShouldBeEliminated := !isCorrect || responseTimeMs > timeLimit
+	// Бонус получает только первый по времени приема правильный ответ, принятый в срок
+	fastestFinger := false
+	if sub.FastestFinger != nil {
+		fastestFinger = sub.settleFastestFinger(isCorrect && !isTimeLimitExceeded)
+		if fastestFinger {
+			score += sub.FastestFingerBonus
+			log.Printf("[AnswerProcessor] Пользователь #%d первым правильно ответил на вопрос #%d, бонус: %d",
+				userID, questionID, sub.FastestFingerBonus)
+		}
+	}
+
+	// Проверяем, нужно ли выбывать пользователю (неверный ответ или слишком долгий ответ).
+	// При самостоятельном прохождении игрок не выбывает, а отвечает на все вопросы.
+	// С несколькими жизнями ошибка отнимает жизнь, а выбывает игрок без жизней.
+	strike := sub.SelfPaced == nil && !reprieved && (!isCorrect || isTimeLimitExceeded)
+	userShouldBeEliminated := strike
 	eliminationReason := ""
-	if userShouldBeEliminated {
+	livesRemaining := 0
+	if strike {
 		if !isCorrect {
 			eliminationReason = "incorrect_answer"
 		} else {
 			eliminationReason = "time_exceeded"
 		}
-
+	}
+	if sub.Lives != nil {
+		if strike {
+			livesRemaining = ap.loseLife(sub)
+			userShouldBeEliminated = livesRemaining == 0
+			log.Printf("[AnswerProcessor] Пользователь #%d теряет жизнь в викторине #%d (%s), осталось: %d",
+				userID, quizID, eliminationReason, livesRemaining)
+		} else {
+			livesRemaining = ap.remainingLives(sub)
+		}
+	}
+	if userShouldBeEliminated {
 		log.Printf("[AnswerProcessor] Пользователь #%d выбывает из викторины #%d. Причина: %s", userID, quizID, eliminationReason)
 
 		// Устанавливаем статус пользователя как "выбывший" в Redis
@@ -148,8 +433,11 @@ func (ap *AnswerProcessor) ProcessAnswer(
 			log.Printf("[AnswerProcessor] WARNING: Не удалось установить статус выбывшего пользователя #%d в Redis: %v", userID, err)
 		}
 
-		// Отправляем уведомление о выбывании пользователю
-		ap.sendEliminationNotification(userID, quizID, eliminationReason)
+		// Отправляем уведомление о выбывании пользователю.
+		// В режиме отложенных результатов оно уйдет вместе с результатом ответа.
+		if !sub.DelayedResults {
+			ap.sendEliminationNotification(userID, quizID, eliminationReason)
+		}
 	}
 
 	// Создаем запись об ответе
@@ -158,20 +446,34 @@ func (ap *AnswerProcessor) ProcessAnswer(
 		QuizID:            quizID,
 		QuestionID:        questionID,
 		SelectedOption:    selectedOption,
+		SelectedOrder:     entity.IntArray(sub.SelectedOrder),
 		IsCorrect:         isCorrect,
 		ResponseTimeMs:    responseTimeMs,
 		Score:             score,
+		Penalty:           penalty,
+		FastestFinger:     fastestFinger,
 		IsEliminated:      userShouldBeEliminated, // Сохраняем статус выбывания в ответе
 		EliminationReason: eliminationReason,      // Сохраняем причину
 	}
 
-	// Сохраняем ответ в БД
-	if err := ap.deps.ResultRepo.SaveUserAnswer(userAnswer); err != nil {
+	// Сохраняем ответ в БД (или в пакет на запись до раскрытия ответа)
+	if err := ap.saveAnswer(userAnswer); err != nil {
 		log.Printf("[AnswerProcessor] Ошибка при сохранении ответа пользователя #%d на вопрос #%d: %v",
 			userID, questionID, err)
+		// Ответ не записан: снимаем фиксацию, чтобы клиент мог отправить его повторно
+		if delErr := ap.deps.CacheRepo.Delete(answerKey); delErr != nil {
+			log.Printf("[AnswerProcessor] WARNING: Не удалось снять фиксацию ответа пользователя #%d: %v", userID, delErr)
+		}
 		return fmt.Errorf("failed to save user answer: %w", err)
 	}
 
+	// Ответ записан - подтверждаем его до результата
+	ap.sendAnswerAck(sub, lock, false)
+
+	if sub.Answers != nil {
+		sub.Answers.RecordAnswer(userID, questionID, userShouldBeEliminated)
+	}
+
 	// Отправляем результат пользователю
 	answerResultEvent := map[string]interface{}{
 		"question_id":         questionID,
@@ -179,10 +481,65 @@ func (ap *AnswerProcessor) ProcessAnswer(
 		"your_answer":         selectedOption,
 		"is_correct":          isCorrect,
 		"points_earned":       score,
+		"penalty_applied":     penalty,
 		"time_taken_ms":       responseTimeMs,
 		"is_eliminated":       userShouldBeEliminated,
 		"time_limit_exceeded": isTimeLimitExceeded,
 	}
+	if reprieved {
+		answerResultEvent["reprieved"] = true
+	}
+	if sub.Lives != nil {
+		answerResultEvent["lives_remaining"] = livesRemaining
+	}
+	if fastestFinger {
+		answerResultEvent["fastest_finger"] = true
+		answerResultEvent["fastest_finger_bonus"] = sub.FastestFingerBonus
+	}
+	if currentQuestion.IsOrdering() {
+		answerResultEvent["your_order"] = sub.SelectedOrder
+		answerResultEvent["correct_order"] = []int(currentQuestion.CorrectOrder)
+		answerResultEvent["credit"] = credit
+	}
+
+	if sub.SelfPaced != nil {
+		// Правильный ответ не раскрывается: другие игроки могут еще не дойти до вопроса
+		delete(answerResultEvent, "correct_option")
+		delete(answerResultEvent, "correct_order")
+		if err := ap.deps.WSManager.SendEventToUser(fmt.Sprintf("%d", userID), "quiz:answer_result", answerResultEvent); err != nil {
+			log.Printf("[AnswerProcessor] Ошибка при отправке результата ответа пользователю #%d: %v", userID, err)
+		}
+		if err := ap.ServeSelfPacedQuestion(sub.SelfPaced, userID); err != nil {
+			log.Printf("[AnswerProcessor] Не удалось отправить следующий вопрос пользователю #%d: %v", userID, err)
+		}
+		return nil
+	}
+
+	if sub.DelayedResults {
+		if sub.AcknowledgeOnly {
+			ap.sendAnswerAcknowledgment(sub)
+		}
+		var lifeLost map[string]interface{}
+		if strike && sub.Lives != nil {
+			lifeLost = lifeLostEventData(sub, livesRemaining, eliminationReason)
+		}
+		ap.deferResult(sub, answerResultEvent, userShouldBeEliminated, eliminationReason, fastestFinger, lifeLost)
+		return nil
+	}
+
+	if fastestFinger {
+		ap.broadcastFastestFinger(sub)
+	}
+	if strike && sub.Lives != nil {
+		ap.broadcastLifeLost(sub, livesRemaining, eliminationReason)
+	}
+
+	// Результат уходит до закрытия вопроса: правильный ответ из него убираем,
+	// иначе первый ответивший узнает его раньше остальных
+	if sub.HideCorrectAnswer {
+		delete(answerResultEvent, "correct_option")
+		delete(answerResultEvent, "correct_order")
+	}
 
 	if err := ap.deps.WSManager.SendEventToUser(fmt.Sprintf("%d", userID), "quiz:answer_result", answerResultEvent); err != nil {
 		log.Printf("[AnswerProcessor] Ошибка при отправке результата ответа пользователю #%d: %v", userID, err)
@@ -212,19 +569,86 @@ func (ap *AnswerProcessor) ProcessAnswer(
 	return nil
 }
 
+// RecordDisconnect запоминает время отключения пользователя для прощения опоздания
+// (см. Config.ReconnectGraceWindow). Время хранится в кэше, так как игрок может
+// переподключиться к другому экземпляру сервиса.
+func (ap *AnswerProcessor) RecordDisconnect(userID uint, at time.Time) {
+	if ap.config.ReconnectGraceWindow <= 0 {
+		return
+	}
+	key := fmt.Sprintf("user:%d:disconnected_at", userID)
+	if err := ap.deps.CacheRepo.Set(key, at.UnixMilli(), time.Hour); err != nil {
+		log.Printf("[AnswerProcessor] WARNING: Не удалось сохранить время отключения пользователя #%d: %v", userID, err)
+	}
+}
+
+// grantReconnectReprieve решает, простить ли опоздавший ответ: пользователь должен
+// отключиться во время вопроса, ответить в пределах окна после отключения и не
+// исчерпать лимит прощений в этой викторине
+func (ap *AnswerProcessor) grantReconnectReprieve(sub *AnswerSubmission) bool {
+	grace := ap.config.ReconnectGraceWindow
+	if grace <= 0 || ap.config.ReconnectReprievesPerQuiz <= 0 {
+		return false
+	}
+
+	raw, err := ap.deps.CacheRepo.Get(fmt.Sprintf("user:%d:disconnected_at", sub.UserID))
+	if err != nil || raw == "" {
+		return false
+	}
+	disconnectedAt, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		return false
+	}
+
+	questionEndMs := sub.QuestionStartMs + int64(sub.Question.TimeLimitSec)*1000
+	if disconnectedAt < sub.QuestionStartMs || disconnectedAt > questionEndMs {
+		return false // Отключение было не во время этого вопроса
+	}
+	if sub.Timestamp-disconnectedAt > grace.Milliseconds() {
+		return false // Игрок вернулся слишком поздно
+	}
+
+	reprievesKey := fmt.Sprintf("quiz:%d:user:%d:reprieves", sub.QuizID, sub.UserID)
+	used, err := ap.deps.CacheRepo.Increment(reprievesKey)
+	if err != nil {
+		log.Printf("[AnswerProcessor] WARNING: Не удалось учесть прощение для пользователя #%d: %v", sub.UserID, err)
+		return false
+	}
+	if used == 1 {
+		if err := ap.deps.CacheRepo.ExpireAt(reprievesKey, time.Now().Add(24*time.Hour)); err != nil {
+			log.Printf("[AnswerProcessor] WARNING: Не удалось задать срок хранения счетчика прощений: %v", err)
+		}
+	}
+	return used <= int64(ap.config.ReconnectReprievesPerQuiz)
+}
+
 // HandleReadyEvent обрабатывает событие готовности пользователя
 func (ap *AnswerProcessor) HandleReadyEvent(ctx context.Context, userID uint, quizID uint) error {
 	log.Printf("[AnswerProcessor] Пользователь #%d отметился как готовый к викторине #%d", userID, quizID)
 
 	// Создаем ключ для Redis и сохраняем информацию о готовности
+	if ap.IsKicked(quizID, userID) {
+		log.Printf("[AnswerProcessor] Пользователь #%d исключен из викторины #%d и не может присоединиться", userID, quizID)
+		return ErrParticipantKicked
+	}
+
 	readyKey := fmt.Sprintf("quiz:%d:ready_users", quizID)
 	userReadyKey := fmt.Sprintf("%s:%d", readyKey, userID)
 
-	if err := ap.deps.CacheRepo.Set(userReadyKey, "1", time.Hour); err != nil {
+	firstReady, err := ap.deps.CacheRepo.SetNX(userReadyKey, "1", time.Hour)
+	if err != nil {
 		log.Printf("[AnswerProcessor] Ошибка при сохранении готовности пользователя #%d к викторине #%d: %v",
 			userID, quizID, err)
 		return fmt.Errorf("failed to save ready status: %w", err)
 	}
+	// Переподключившийся игрок уже учтен в числе участников, ему только продлевается готовность
+	if firstReady {
+		if _, err := ap.deps.CacheRepo.Increment(participantsJoinedKey(quizID)); err != nil {
+			log.Printf("[AnswerProcessor] WARNING: Не удалось учесть участника викторины #%d: %v", quizID, err)
+		}
+	} else if err := ap.deps.CacheRepo.ExpireAt(userReadyKey, time.Now().Add(time.Hour)); err != nil {
+		log.Printf("[AnswerProcessor] WARNING: Не удалось продлить готовность пользователя #%d: %v", userID, err)
+	}
 
 	// Отправляем информацию о готовности пользователя всем участникам
 	fullEvent := map[string]interface{}{
@@ -248,14 +672,278 @@ func (ap *AnswerProcessor) HandleReadyEvent(ctx context.Context, userID uint, qu
 	return nil
 }
 
-// Новый вспомогательный метод для отправки уведомления о выбывании
-func (ap *AnswerProcessor) sendEliminationNotification(userID uint, quizID uint, reason string) {
-	eliminationEvent := map[string]interface{}{
+// ParticipationStatus возвращает, отметился ли пользователь как участник викторины
+// (до старта или при позднем присоединении) и выбыл ли он из нее
+func (ap *AnswerProcessor) ParticipationStatus(quizID, userID uint) (participating, eliminated bool) {
+	participating, _ = ap.deps.CacheRepo.Exists(fmt.Sprintf("quiz:%d:ready_users:%d", quizID, userID))
+	if !participating {
+		return false, false
+	}
+	eliminated, _ = ap.deps.CacheRepo.Exists(fmt.Sprintf("quiz:%d:eliminated:%d", quizID, userID))
+	return true, eliminated
+}
+
+// AnswerLock - зафиксированный ответ пользователя на вопрос. Хранится в кэше
+// под AnswerLockKey и используется для повторной отправки quiz:answer_ack.
+type AnswerLock struct {
+	Seq    int64 `json:"seq"`
+	Option int   `json:"option"`
+	Order  []int `json:"order,omitempty"`
+}
+
+// AnswerLockKey - ключ кэша, фиксирующий ответ пользователя на вопрос
+func AnswerLockKey(quizID, userID, questionID uint) string {
+	return fmt.Sprintf("quiz:%d:user:%d:question:%d", quizID, userID, questionID)
+}
+
+// nextAnswerSeq выдает номер подтверждения ответа. Номера растут в пределах
+// викторины и общие для всех экземпляров сервиса, но могут идти с пропусками.
+// При ошибке Redis возвращается 0.
+func (ap *AnswerProcessor) nextAnswerSeq(quizID uint) int64 {
+	key := fmt.Sprintf("quiz:%d:answer_seq", quizID)
+	seq, err := ap.deps.CacheRepo.Increment(key)
+	if err != nil {
+		log.Printf("[AnswerProcessor] WARNING: Не удалось получить номер подтверждения ответа в викторине #%d: %v", quizID, err)
+		return 0
+	}
+	if seq == 1 {
+		if err := ap.deps.CacheRepo.ExpireAt(key, time.Now().Add(24*time.Hour)); err != nil {
+			log.Printf("[AnswerProcessor] WARNING: Не удалось задать срок хранения счетчика подтверждений: %v", err)
+		}
+	}
+	return seq
+}
+
+// LockedAnswer возвращает зафиксированный ответ пользователя на вопрос или nil,
+// если ответа нет (или он зафиксирован в старом формате)
+func (ap *AnswerProcessor) LockedAnswer(quizID, userID, questionID uint) *AnswerLock {
+	raw, err := ap.deps.CacheRepo.Get(AnswerLockKey(quizID, userID, questionID))
+	if err != nil || raw == "" {
+		return nil
+	}
+	var lock AnswerLock
+	if err := json.Unmarshal([]byte(raw), &lock); err != nil {
+		return nil
+	}
+	return &lock
+}
+
+// resendAnswerAck повторно подтверждает уже зафиксированный ответ. Возвращает false,
+// если ответ на вопрос еще не зафиксирован.
+func (ap *AnswerProcessor) resendAnswerAck(sub *AnswerSubmission) bool {
+	raw, err := ap.deps.CacheRepo.Get(AnswerLockKey(sub.QuizID, sub.UserID, sub.QuestionID))
+	if err != nil || raw == "" {
+		return false
+	}
+	var lock AnswerLock
+	if err := json.Unmarshal([]byte(raw), &lock); err != nil {
+		// Ключ в старом формате ("1") - ответ зафиксирован, но выбор неизвестен
+		log.Printf("[AnswerProcessor] WARNING: Не удалось разобрать фиксацию ответа пользователя #%d: %v", sub.UserID, err)
+		return false
+	}
+
+	log.Printf("[AnswerProcessor] Повторная отправка ответа пользователем #%d на вопрос #%d, подтверждение #%d",
+		sub.UserID, sub.QuestionID, lock.Seq)
+	ap.sendAnswerAck(sub, lock, true)
+	return true
+}
+
+// sendAnswerAck отправляет quiz:answer_ack - окончательное подтверждение того, что
+// ответ записан. Содержит зафиксированный выбор (а не присланный при повторе)
+// и не раскрывает ни правильность, ни выбывание.
+func (ap *AnswerProcessor) sendAnswerAck(sub *AnswerSubmission, lock AnswerLock, duplicate bool) {
+	ackEvent := map[string]interface{}{
+		"question_id": sub.QuestionID,
+		"seq":         lock.Seq,
+		"duplicate":   duplicate,
+	}
+	if sub.Question.IsOrdering() {
+		ackEvent["locked_order"] = lock.Order
+	} else {
+		ackEvent["locked_option"] = lock.Option
+	}
+	if err := ap.deps.WSManager.SendEventToUser(fmt.Sprintf("%d", sub.UserID), "quiz:answer_ack", ackEvent); err != nil {
+		log.Printf("[AnswerProcessor] Ошибка при отправке quiz:answer_ack пользователю #%d: %v", sub.UserID, err)
+	}
+}
+
+// sendAnswerAcknowledgment подтверждает прием ответа, не раскрывая ни правильность,
+// ни выбывание: игрок не должен узнать, что остался в игре, до quiz:answer_reveal
+func (ap *AnswerProcessor) sendAnswerAcknowledgment(sub *AnswerSubmission) {
+	ackEvent := map[string]interface{}{
+		"question_id": sub.QuestionID,
+		"status":      "accepted",
+	}
+	if sub.Question.IsOrdering() {
+		ackEvent["your_order"] = sub.SelectedOrder
+	} else {
+		ackEvent["selected_option"] = sub.SelectedOption
+	}
+	if err := ap.deps.WSManager.SendEventToUser(fmt.Sprintf("%d", sub.UserID), "quiz:answer_received", ackEvent); err != nil {
+		log.Printf("[AnswerProcessor] Ошибка при отправке подтверждения ответа пользователю #%d: %v", sub.UserID, err)
+	}
+}
+
+// deferResult откладывает результат ответа и уведомление о выбывании до закрытия вопроса.
+// Если вопрос уже закрыт (ответ обработан из очереди после раскрытия), события отправляются сразу.
+func (ap *AnswerProcessor) deferResult(sub *AnswerSubmission, answerResultEvent map[string]interface{}, eliminated bool, eliminationReason string, fastestFinger bool, lifeLost map[string]interface{}) {
+	events := []DeferredEvent{{UserID: sub.UserID, EventType: "quiz:answer_result", Data: answerResultEvent}}
+	if fastestFinger {
+		// Рассылка всем участникам раскрыла бы правильность ответа до закрытия вопроса,
+		// поэтому в этом режиме о первенстве узнает только сам игрок
+		events = append(events, DeferredEvent{
+			UserID:    sub.UserID,
+			EventType: "quiz:fastest_finger",
+			Data:      fastestFingerEventData(sub),
+		})
+	}
+	if lifeLost != nil {
+		// Как и первенство, потеря жизни раскрыла бы правильность ответа,
+		// поэтому о ней узнает только сам игрок
+		events = append(events, DeferredEvent{
+			UserID:    sub.UserID,
+			EventType: "quiz:life_lost",
+			Data:      lifeLost,
+		})
+	}
+	if eliminated {
+		events = append(events, DeferredEvent{
+			UserID:    sub.UserID,
+			EventType: "quiz:elimination",
+			Data:      eliminationEventData(sub.UserID, sub.QuizID, eliminationReason),
+		})
+	}
+
+	if ap.results.Defer(sub.QuestionID, events...) {
+		log.Printf("[AnswerProcessor] Результат ответа пользователя #%d на вопрос #%d отложен до закрытия вопроса",
+			sub.UserID, sub.QuestionID)
+		return
+	}
+	ap.results.SendNow(events...)
+}
+
+// RevealResults отправляет отложенные результаты по закрытому вопросу
+func (ap *AnswerProcessor) RevealResults(questionID uint) {
+	if sent := ap.results.Flush(questionID); sent > 0 {
+		log.Printf("[AnswerProcessor] Отправлено %d отложенных событий по вопросу #%d", sent, questionID)
+	}
+}
+
+// ResetDeferredResults очищает отложенные результаты вопросов викторины перед ее запуском.
+// Результаты других активных викторин не затрагиваются.
+func (ap *AnswerProcessor) ResetDeferredResults(quiz *entity.Quiz) {
+	ap.results.Discard(quizQuestionIDs(quiz)...)
+}
+
+// ReleaseDeferredResults освобождает отложенные результаты вопросов завершенной
+// викторины после подсчета итогов: оставшиеся события отправляются, состояние
+// вопросов удаляется из буфера
+func (ap *AnswerProcessor) ReleaseDeferredResults(quiz *entity.Quiz) {
+	if sent := ap.results.Release(quizQuestionIDs(quiz)...); sent > 0 {
+		log.Printf("[AnswerProcessor] Отправлено %d отложенных событий по завершенной викторине #%d", sent, quiz.ID)
+	}
+}
+
+// quizQuestionIDs возвращает ID вопросов викторины
+func quizQuestionIDs(quiz *entity.Quiz) []uint {
+	questionIDs := make([]uint, len(quiz.Questions))
+	for i := range quiz.Questions {
+		questionIDs[i] = quiz.Questions[i].ID
+	}
+	return questionIDs
+}
+
+// broadcastFastestFinger сообщает участникам викторины, кто первым правильно ответил на вопрос
+func (ap *AnswerProcessor) broadcastFastestFinger(sub *AnswerSubmission) {
+	fullEvent := map[string]interface{}{
+		"type": "quiz:fastest_finger",
+		"data": fastestFingerEventData(sub),
+	}
+	if err := ap.deps.WSManager.BroadcastEventToQuiz(sub.QuizID, fullEvent); err != nil {
+		log.Printf("[AnswerProcessor] Ошибка при рассылке quiz:fastest_finger по вопросу #%d: %v", sub.QuestionID, err)
+	}
+}
+
+// fastestFingerEventData формирует данные события quiz:fastest_finger
+func fastestFingerEventData(sub *AnswerSubmission) map[string]interface{} {
+	return map[string]interface{}{
+		"quiz_id":      sub.QuizID,
+		"question_id":  sub.QuestionID,
+		"user_id":      sub.UserID,
+		"bonus_points": sub.FastestFingerBonus,
+		"received_at":  sub.ReceivedAtMs,
+	}
+}
+
+// broadcastLifeLost сообщает участникам викторины, что игрок потерял жизнь
+func (ap *AnswerProcessor) broadcastLifeLost(sub *AnswerSubmission, livesRemaining int, reason string) {
+	fullEvent := map[string]interface{}{
+		"type": "quiz:life_lost",
+		"data": lifeLostEventData(sub, livesRemaining, reason),
+	}
+	if err := ap.deps.WSManager.BroadcastEventToQuiz(sub.QuizID, fullEvent); err != nil {
+		log.Printf("[AnswerProcessor] Ошибка при рассылке quiz:life_lost по вопросу #%d: %v", sub.QuestionID, err)
+	}
+}
+
+// lifeLostEventData формирует данные события quiz:life_lost
+func lifeLostEventData(sub *AnswerSubmission, livesRemaining int, reason string) map[string]interface{} {
+	return map[string]interface{}{
+		"quiz_id":         sub.QuizID,
+		"question_id":     sub.QuestionID,
+		"user_id":         sub.UserID,
+		"lives_remaining": livesRemaining,
+		"reason":          reason,
+	}
+}
+
+// livesLostKey - ключ кэша с числом жизней, потерянных игроком в викторине.
+// Хранится рядом с ключом выбывания, чтобы жизни учитывались на всех экземплярах.
+func livesLostKey(quizID, userID uint) string {
+	return fmt.Sprintf("quiz:%d:lives_lost:%d", quizID, userID)
+}
+
+// loseLife отнимает у игрока жизнь в общем счете и возвращает число оставшихся.
+// Если кэш недоступен, жизнь учитывается только на этом экземпляре.
+func (ap *AnswerProcessor) loseLife(sub *AnswerSubmission) int {
+	key := livesLostKey(sub.QuizID, sub.UserID)
+	lost, err := ap.deps.CacheRepo.Increment(key)
+	if err != nil {
+		log.Printf("[AnswerProcessor] WARNING: Не удалось учесть потерю жизни пользователя #%d в Redis: %v", sub.UserID, err)
+		return sub.Lives.LoseLife(sub.UserID)
+	}
+	if lost == 1 {
+		if err := ap.deps.CacheRepo.ExpireAt(key, time.Now().Add(24*time.Hour)); err != nil {
+			log.Printf("[AnswerProcessor] WARNING: Не удалось задать срок хранения потерянных жизней пользователя #%d: %v", sub.UserID, err)
+		}
+	}
+	return sub.Lives.Observe(sub.UserID, int(lost))
+}
+
+// remainingLives возвращает число оставшихся жизней игрока по общему счету
+func (ap *AnswerProcessor) remainingLives(sub *AnswerSubmission) int {
+	raw, err := ap.deps.CacheRepo.Get(livesLostKey(sub.QuizID, sub.UserID))
+	if err == nil && raw != "" {
+		if lost, err := strconv.Atoi(raw); err == nil {
+			return sub.Lives.Observe(sub.UserID, lost)
+		}
+	}
+	return sub.Lives.Remaining(sub.UserID)
+}
+
+// eliminationEventData формирует данные события quiz:elimination
+func eliminationEventData(userID uint, quizID uint, reason string) map[string]interface{} {
+	return map[string]interface{}{
 		"quiz_id": quizID,
 		"user_id": userID, // Включаем UserID, чтобы клиент мог это проверить
 		"reason":  reason,
 		"message": "Вы выбыли из викторины и можете только наблюдать",
 	}
+}
+
+// Новый вспомогательный метод для отправки уведомления о выбывании
+func (ap *AnswerProcessor) sendEliminationNotification(userID uint, quizID uint, reason string) {
+	eliminationEvent := eliminationEventData(userID, quizID, reason)
 
 	if err := ap.deps.WSManager.SendEventToUser(fmt.Sprintf("%d", userID), "quiz:elimination", eliminationEvent); err != nil {
 		log.Printf("[AnswerProcessor] Ошибка при отправке уведомления о выбывании пользователю #%d: %v", userID, err)
@@ -0,0 +1,956 @@
+package quizmanager
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourusername/trivia-api/internal/domain/entity"
+	"github.com/yourusername/trivia-api/internal/domain/repository"
+	"github.com/yourusername/trivia-api/internal/websocket"
+)
+
+// recordingHub запоминает события, отправленные пользователям
+type recordingHub struct {
+	mu     sync.Mutex
+	events []string // "userID:type"
+	data   []interface{}
+}
+
+func (h *recordingHub) BroadcastJSON(v interface{}) error { return nil }
+
+func (h *recordingHub) SendJSONToUser(userID string, v interface{}) error {
+	event := v.(websocket.Event)
+	h.mu.Lock()
+	h.events = append(h.events, userID+":"+event.Type)
+	h.data = append(h.data, event.Data)
+	h.mu.Unlock()
+	return nil
+}
+
+func (h *recordingHub) SendToUser(userID string, message []byte) bool {
+	var event websocket.Event
+	_ = json.Unmarshal(message, &event)
+	return h.SendJSONToUser(userID, event) == nil
+}
+
+func (h *recordingHub) GetMetrics() map[string]interface{} { return nil }
+
+func (h *recordingHub) ClientCount() int { return 0 }
+
+func (h *recordingHub) Events() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]string(nil), h.events...)
+}
+
+// memoryCache - минимальная реализация кэша в памяти
+type memoryCache struct {
+	repository.CacheRepository
+	mu   sync.Mutex
+	data map[string]string
+	// Все игроки считаются занявшими места в викторинах (см. newJoinedCache)
+	joinedAll bool
+	// Сроки хранения, заданные через ExpireAt
+	expires map[string]time.Time
+}
+
+// newJoinedCache создает кэш, в котором все игроки уже заняли места в
+// викторинах: тестам обработки ответов не нужно проходить присоединение
+func newJoinedCache() *memoryCache {
+	return &memoryCache{data: make(map[string]string), joinedAll: true}
+}
+
+func (c *memoryCache) Set(key string, value interface{}, expiration time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.data[key] = fmt.Sprint(value)
+	return nil
+}
+
+func (c *memoryCache) Get(key string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.data[key], nil
+}
+
+func (c *memoryCache) Delete(key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.data, key)
+	return nil
+}
+
+func (c *memoryCache) DeleteIfExists(key string) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, ok := c.data[key]
+	delete(c.data, key)
+	return ok, nil
+}
+
+func (c *memoryCache) Exists(key string) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, ok := c.data[key]
+	return ok || (c.joinedAll && strings.Contains(key, ":joins:user:")), nil
+}
+
+func (c *memoryCache) SetNX(key string, value interface{}, expiration time.Duration) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.data[key]; ok {
+		return false, nil
+	}
+	c.data[key] = fmt.Sprint(value)
+	return true, nil
+}
+
+func (c *memoryCache) Increment(key string) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n, _ := strconv.ParseInt(c.data[key], 10, 64)
+	n++
+	c.data[key] = strconv.FormatInt(n, 10)
+	return n, nil
+}
+
+func (c *memoryCache) ExpireAt(key string, expireTime time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.expires == nil {
+		c.expires = make(map[string]time.Time)
+	}
+	c.expires[key] = expireTime
+	return nil
+}
+
+// memoryResults сохраняет ответы в памяти
+type memoryResults struct {
+	repository.ResultRepository
+	mu      sync.Mutex
+	answers []*entity.UserAnswer
+}
+
+func (r *memoryResults) SaveUserAnswer(answer *entity.UserAnswer) error {
+	r.mu.Lock()
+	r.answers = append(r.answers, answer)
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *memoryResults) SaveUserAnswersBatch(answers []*entity.UserAnswer) error {
+	r.mu.Lock()
+	r.answers = append(r.answers, answers...)
+	r.mu.Unlock()
+	return nil
+}
+
+func newTestProcessor() (*AnswerProcessor, *recordingHub) {
+	hub := &recordingHub{}
+	deps := &Dependencies{
+		CacheRepo:  newJoinedCache(),
+		ResultRepo: &memoryResults{},
+		WSManager:  websocket.NewManager(hub),
+	}
+	return NewAnswerProcessor(DefaultConfig(), deps), hub
+}
+
+func testSubmission(userID uint, selected int, delayed bool) *AnswerSubmission {
+	now := time.Now().UnixMilli()
+	return &AnswerSubmission{
+		UserID:         userID,
+		QuizID:         1,
+		QuestionID:     10,
+		SelectedOption: selected,
+		Timestamp:      now,
+		Question: &entity.Question{
+			ID:            10,
+			QuizID:        1,
+			Options:       entity.StringArray{"a", "b", "c"},
+			CorrectOption: 2,
+			TimeLimitSec:  10,
+			PointValue:    10,
+		},
+		QuestionStartMs: now - 1000,
+		PointValue:      10,
+		DelayedResults:  delayed,
+	}
+}
+
+func TestProcessSubmission_ImmediateResults(t *testing.T) {
+	ap, hub := newTestProcessor()
+
+	require.NoError(t, ap.ProcessSubmission(context.Background(), testSubmission(1, 2, false)))
+	require.NoError(t, ap.ProcessSubmission(context.Background(), testSubmission(2, 1, false)))
+
+	// Результаты и выбывание приходят сразу, до закрытия вопроса
+	events := hub.Events()
+	assert.Contains(t, events, "1:quiz:answer_result")
+	assert.Contains(t, events, "2:quiz:answer_result")
+	assert.Contains(t, events, "2:quiz:elimination")
+
+	before := len(events)
+	ap.RevealResults(10)
+	assert.Len(t, hub.Events(), before, "при раскрытии ничего не должно досылаться")
+}
+
+func TestProcessSubmission_HideCorrectAnswer(t *testing.T) {
+	ap, hub := newTestProcessor()
+
+	for userID, selected := range map[uint]int{1: 2, 2: 1} {
+		sub := testSubmission(userID, selected, false)
+		sub.HideCorrectAnswer = true
+		require.NoError(t, ap.ProcessSubmission(context.Background(), sub))
+	}
+
+	// Свой результат приходит сразу, но правильный ответ - только в quiz:answer_reveal
+	for _, event := range []string{"1:quiz:answer_result", "2:quiz:answer_result"} {
+		data := hub.eventData(event)
+		require.NotNil(t, data, event)
+		assert.NotContains(t, data, "correct_option")
+		assert.Contains(t, data, "is_correct")
+	}
+	assert.Equal(t, true, hub.eventData("1:quiz:answer_result")["is_correct"])
+	assert.Contains(t, hub.Events(), "2:quiz:elimination")
+}
+
+func TestProcessSubmission_DelayedResults(t *testing.T) {
+	ap, hub := newTestProcessor()
+
+	require.NoError(t, ap.ProcessSubmission(context.Background(), testSubmission(1, 2, true)))
+	require.NoError(t, ap.ProcessSubmission(context.Background(), testSubmission(2, 1, true)))
+
+	// До закрытия вопроса ни результат, ни выбывание не отправляются - только подтверждения записи
+	assert.Equal(t, []string{"1:quiz:answer_ack", "2:quiz:answer_ack"}, hub.Events())
+
+	ap.RevealResults(10)
+	assert.Equal(t, []string{
+		"1:quiz:answer_ack",
+		"2:quiz:answer_ack",
+		"1:quiz:answer_result",
+		"2:quiz:answer_result",
+		"2:quiz:elimination",
+	}, hub.Events())
+
+	// Ответ, обработанный после закрытия вопроса, отправляется сразу
+	require.NoError(t, ap.ProcessSubmission(context.Background(), testSubmission(3, 2, true)))
+	assert.Contains(t, hub.Events(), "3:quiz:answer_result")
+}
+
+func TestProcessSubmission_SuppressedFeedbackStillEliminates(t *testing.T) {
+	ap, hub := newTestProcessor()
+	cache := ap.deps.CacheRepo.(*memoryCache)
+	results := ap.deps.ResultRepo.(*memoryResults)
+
+	sub := testSubmission(2, 1, true)
+	sub.AcknowledgeOnly = true
+	require.NoError(t, ap.ProcessSubmission(context.Background(), sub))
+
+	// До раскрытия игрок получает только нейтральные подтверждения
+	assert.Equal(t, []string{"2:quiz:answer_ack", "2:quiz:answer_received"}, hub.Events())
+
+	// Но выбывание уже рассчитано и учтено
+	eliminated, _ := cache.Exists("quiz:1:eliminated:2")
+	assert.True(t, eliminated)
+	require.Len(t, results.answers, 1)
+	assert.True(t, results.answers[0].IsEliminated)
+	assert.Equal(t, "incorrect_answer", results.answers[0].EliminationReason)
+
+	ap.RevealResults(10)
+	assert.Equal(t, []string{
+		"2:quiz:answer_ack",
+		"2:quiz:answer_received",
+		"2:quiz:answer_result",
+		"2:quiz:elimination",
+	}, hub.Events())
+}
+
+// eventData возвращает данные последнего события указанного типа
+func (h *recordingHub) eventData(event string) map[string]interface{} {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for i := len(h.events) - 1; i >= 0; i-- {
+		if h.events[i] == event {
+			data, _ := h.data[i].(map[string]interface{})
+			return data
+		}
+	}
+	return nil
+}
+
+func TestProcessSubmission_AnswerAckIsIdempotent(t *testing.T) {
+	ap, hub := newTestProcessor()
+	results := ap.deps.ResultRepo.(*memoryResults)
+
+	require.NoError(t, ap.ProcessSubmission(context.Background(), testSubmission(1, 2, false)))
+	first := hub.eventData("1:quiz:answer_ack")
+	require.NotNil(t, first)
+	assert.Equal(t, 2, first["locked_option"])
+	assert.Equal(t, false, first["duplicate"])
+	assert.NotZero(t, first["seq"])
+
+	// Клиент не получил подтверждение и отправил ответ снова, уже с другим вариантом
+	require.NoError(t, ap.ProcessSubmission(context.Background(), testSubmission(1, 3, false)))
+	retry := hub.eventData("1:quiz:answer_ack")
+	assert.Equal(t, 2, retry["locked_option"], "подтверждается зафиксированный вариант")
+	assert.Equal(t, first["seq"], retry["seq"])
+	assert.Equal(t, true, retry["duplicate"])
+
+	assert.Len(t, results.answers, 1, "повтор не засчитывается повторно")
+	assert.Equal(t, []string{"1:quiz:answer_ack", "1:quiz:answer_result", "1:quiz:answer_ack"}, hub.Events())
+}
+
+func TestProcessSubmission_AnswerAckRetryDoesNotRevealElimination(t *testing.T) {
+	ap, hub := newTestProcessor()
+
+	sub := testSubmission(2, 1, true)
+	sub.AcknowledgeOnly = true
+	require.NoError(t, ap.ProcessSubmission(context.Background(), sub))
+
+	// Игрок уже выбыл, но повтор получает то же подтверждение, а не ошибку о выбывании
+	retry := testSubmission(2, 1, true)
+	retry.AcknowledgeOnly = true
+	require.NoError(t, ap.ProcessSubmission(context.Background(), retry))
+	assert.Equal(t, []string{"2:quiz:answer_ack", "2:quiz:answer_received", "2:quiz:answer_ack"}, hub.Events())
+	assert.Equal(t, true, hub.eventData("2:quiz:answer_ack")["duplicate"])
+}
+
+func TestProcessSubmission_AnswerAckOrdering(t *testing.T) {
+	ap, hub := newTestProcessor()
+
+	sub := testSubmission(1, 0, false)
+	sub.Question.Type = entity.QuestionTypeOrdering
+	sub.Question.CorrectOrder = entity.IntArray{3, 1, 2}
+	sub.SelectedOrder = []int{3, 1, 2}
+	require.NoError(t, ap.ProcessSubmission(context.Background(), sub))
+
+	ack := hub.eventData("1:quiz:answer_ack")
+	assert.Equal(t, []int{3, 1, 2}, ack["locked_order"])
+	assert.NotContains(t, ack, "locked_option")
+}
+
+func TestProcessSubmission_RequiresJoin(t *testing.T) {
+	ap, hub := newTestProcessor()
+	results := ap.deps.ResultRepo.(*memoryResults)
+
+	// Игрок не занял место (нет мест, викторина уже идет): ответ не принимается
+	ap.deps.CacheRepo.(*memoryCache).joinedAll = false
+	assert.ErrorIs(t, ap.ProcessSubmission(context.Background(), testSubmission(1, 2, false)), ErrNotParticipant)
+	assert.Empty(t, results.answers)
+	assert.Empty(t, hub.Events())
+
+	require.NoError(t, ap.deps.CacheRepo.Set(JoinKey(1, 1), joinedBeforeStart, time.Hour))
+	require.NoError(t, ap.ProcessSubmission(context.Background(), testSubmission(1, 2, false)))
+	assert.Len(t, results.answers, 1)
+}
+
+func TestResultBuffer_ResetDropsPending(t *testing.T) {
+	ap, hub := newTestProcessor()
+
+	require.NoError(t, ap.ProcessSubmission(context.Background(), testSubmission(1, 2, true)))
+
+	// Запуск другой викторины не затрагивает отложенные результаты вопроса #10
+	ap.ResetDeferredResults(&entity.Quiz{ID: 2, Questions: []entity.Question{{ID: 20}}})
+	assert.Len(t, ap.results.pending[10], 1)
+
+	ap.ResetDeferredResults(&entity.Quiz{ID: 1, Questions: []entity.Question{{ID: 10}}})
+	ap.RevealResults(10)
+
+	assert.Equal(t, []string{"1:quiz:answer_ack"}, hub.Events(), "отложенный результат сброшен")
+}
+
+func TestResultBuffer_ReleaseForgetsFinishedQuiz(t *testing.T) {
+	ap, hub := newTestProcessor()
+	quiz := &entity.Quiz{ID: 1, Questions: []entity.Question{{ID: 10}, {ID: 11}}}
+
+	require.NoError(t, ap.ProcessSubmission(context.Background(), testSubmission(1, 2, true)))
+	ap.RevealResults(11)
+	require.Len(t, ap.results.pending, 1)
+	require.Len(t, ap.results.closed, 1)
+
+	// Нераскрытый результат отправляется, состояние вопросов викторины удаляется
+	ap.ReleaseDeferredResults(quiz)
+	assert.Empty(t, ap.results.pending)
+	assert.Empty(t, ap.results.closed)
+	assert.Contains(t, hub.Events(), "1:quiz:answer_result")
+}
+
+func TestPrepareSubmission_QuizPointOverrides(t *testing.T) {
+	ap, _ := newTestProcessor()
+	question := testSubmission(1, 2, false).Question
+
+	quizState := NewActiveQuizState(&entity.Quiz{ID: 1, UniformPointValue: 50, PointsMultiplier: 2})
+	quizState.SetCurrentQuestion(question, 1)
+	quizState.SetCurrentQuestionStartTime(time.Now().UnixMilli())
+
+	sub, err := ap.PrepareSubmission(1, question.ID, 2, time.Now().UnixMilli(), quizState)
+	require.NoError(t, err)
+	// Единая стоимость заменяет PointValue вопроса (10), затем применяется множитель
+	assert.Equal(t, 100, sub.PointValue)
+
+	require.NoError(t, ap.ProcessSubmission(context.Background(), sub))
+	results := ap.deps.ResultRepo.(*memoryResults)
+	require.Len(t, results.answers, 1)
+	assert.Equal(t, 100, results.answers[0].Score, "быстрый верный ответ получает полную стоимость")
+	assert.Equal(t, 10, question.PointValue, "снимок вопроса не должен меняться")
+}
+
+// TestProcessSubmission_SmallPointValue: уменьшенная настройками викторины
+// стоимость не подменяется базовой стоимостью вопроса
+func TestProcessSubmission_SmallPointValue(t *testing.T) {
+	ap, _ := newTestProcessor()
+	question := testSubmission(1, 2, false).Question
+
+	quizState := NewActiveQuizState(&entity.Quiz{ID: 1, PointsMultiplier: 0.01})
+	quizState.SetCurrentQuestion(question, 1)
+	quizState.SetCurrentQuestionStartTime(time.Now().UnixMilli())
+
+	sub, err := ap.PrepareSubmission(1, question.ID, 2, time.Now().UnixMilli(), quizState)
+	require.NoError(t, err)
+	assert.Equal(t, 1, sub.PointValue)
+
+	require.NoError(t, ap.ProcessSubmission(context.Background(), sub))
+	results := ap.deps.ResultRepo.(*memoryResults)
+	require.Len(t, results.answers, 1)
+	assert.Equal(t, 1, results.answers[0].Score, "очки не берутся из PointValue вопроса (10)")
+}
+
+// TestProcessSubmission_DifficultyScaling: при одинаковой базовой стоимости
+// правильный ответ на сложный вопрос приносит больше очков, чем на простой
+func TestProcessSubmission_DifficultyScaling(t *testing.T) {
+	ap, hub := newTestProcessor()
+	quizState := NewActiveQuizState(&entity.Quiz{ID: 1, DifficultyScaling: true})
+
+	earned := make(map[string]int)
+	for i, difficulty := range []string{entity.DifficultyEasy, entity.DifficultyHard} {
+		userID := uint(i + 1)
+		question := testSubmission(userID, 2, false).Question
+		question.ID = uint(20 + i)
+		question.Difficulty = difficulty
+		quizState.SetCurrentQuestion(question, i+1)
+		quizState.SetCurrentQuestionStartTime(time.Now().UnixMilli())
+
+		sub, err := ap.PrepareSubmission(userID, question.ID, 2, time.Now().UnixMilli(), quizState)
+		require.NoError(t, err)
+		require.NoError(t, ap.ProcessSubmission(context.Background(), sub))
+		earned[difficulty] = hub.eventData(fmt.Sprintf("%d:quiz:answer_result", userID))["points_earned"].(int)
+
+		data := questionEventData(quizState.Quiz, question, i+1, time.Now().UnixMilli())
+		assert.Equal(t, sub.PointValue, data["point_value"], "quiz:question показывает стоимость с учетом сложности")
+		assert.Equal(t, difficulty, data["difficulty"])
+	}
+	assert.Greater(t, earned[entity.DifficultyHard], earned[entity.DifficultyEasy])
+}
+
+func TestProcessSubmission_WrongAnswerPenalty(t *testing.T) {
+	ap, hub := newTestProcessor()
+	results := ap.deps.ResultRepo.(*memoryResults)
+
+	wrong := testSubmission(1, 1, false)
+	wrong.WrongAnswerPenalty = 5
+	require.NoError(t, ap.ProcessSubmission(context.Background(), wrong))
+	assert.Equal(t, 5, hub.eventData("1:quiz:answer_result")["penalty_applied"])
+
+	right := testSubmission(2, 2, false)
+	right.WrongAnswerPenalty = 5
+	require.NoError(t, ap.ProcessSubmission(context.Background(), right))
+	assert.Equal(t, 0, hub.eventData("2:quiz:answer_result")["penalty_applied"])
+
+	require.Len(t, results.answers, 2)
+	assert.Equal(t, 5, results.answers[0].Penalty)
+	assert.Zero(t, results.answers[1].Penalty)
+}
+
+func TestProcessSubmission_FastestFingerFlagsExactlyOne(t *testing.T) {
+	ap, hub := newTestProcessor()
+	results := ap.deps.ResultRepo.(*memoryResults)
+
+	question := testSubmission(1, 2, false).Question
+	quizState := NewActiveQuizState(&entity.Quiz{ID: 1, FastestFingerBonus: 7})
+	quizState.SetCurrentQuestion(question, 1)
+	quizState.SetCurrentQuestionStartTime(time.Now().UnixMilli())
+
+	// Одновременные правильные ответы обрабатываются параллельно, как воркерами пула
+	const players = 50
+	var wg sync.WaitGroup
+	for i := 1; i <= players; i++ {
+		wg.Add(1)
+		go func(userID uint) {
+			defer wg.Done()
+			sub, err := ap.PrepareSubmission(userID, question.ID, 2, time.Now().UnixMilli(), quizState)
+			if assert.NoError(t, err) {
+				assert.NoError(t, ap.ProcessSubmission(context.Background(), sub))
+			}
+		}(uint(i))
+	}
+	wg.Wait()
+
+	require.Len(t, results.answers, players)
+	flagged := 0
+	var winner *entity.UserAnswer
+	for _, answer := range results.answers {
+		if answer.FastestFinger {
+			flagged++
+			winner = answer
+		}
+	}
+	require.Equal(t, 1, flagged, "бонус получает ровно один игрок")
+
+	winnerID, ok := quizState.FastestFinger().Winner(question.ID)
+	require.True(t, ok)
+	assert.Equal(t, winner.UserID, winnerID)
+	assert.Equal(t, 10+7, winner.Score, "бонус добавляется к очкам за ответ")
+
+	flaggedResults := 0
+	for i, event := range hub.Events() {
+		if !strings.HasSuffix(event, ":quiz:answer_result") {
+			continue
+		}
+		if hub.data[i].(map[string]interface{})["fastest_finger"] == true {
+			flaggedResults++
+			assert.Equal(t, fmt.Sprintf("%d:quiz:answer_result", winnerID), event)
+		}
+	}
+	assert.Equal(t, 1, flaggedResults)
+}
+
+// TestProcessSubmission_FastestFingerByReceiveTime: первенство получает ответ,
+// раньше принятый сервером, даже если воркер обработал его позже
+func TestProcessSubmission_FastestFingerByReceiveTime(t *testing.T) {
+	ap, hub := newTestProcessor()
+
+	question := testSubmission(1, 2, false).Question
+	quizState := NewActiveQuizState(&entity.Quiz{ID: 1, FastestFingerBonus: 7})
+	quizState.SetCurrentQuestion(question, 1)
+	quizState.SetCurrentQuestionStartTime(time.Now().UnixMilli())
+
+	first, err := ap.PrepareSubmission(1, question.ID, 2, time.Now().UnixMilli(), quizState)
+	require.NoError(t, err)
+	second, err := ap.PrepareSubmission(2, question.ID, 2, time.Now().UnixMilli(), quizState)
+	require.NoError(t, err)
+	second.ReceivedAtMs = first.ReceivedAtMs + 5
+
+	secondDone := make(chan error, 1)
+	go func() { secondDone <- ap.ProcessSubmission(context.Background(), second) }()
+	time.Sleep(50 * time.Millisecond)
+	_, decided := quizState.FastestFinger().Winner(question.ID)
+	assert.False(t, decided, "первенство ждет обработки более раннего ответа")
+
+	require.NoError(t, ap.ProcessSubmission(context.Background(), first))
+	require.NoError(t, <-secondDone)
+	assert.Equal(t, true, hub.eventData("1:quiz:answer_result")["fastest_finger"])
+	assert.Nil(t, hub.eventData("2:quiz:answer_result")["fastest_finger"], "раньше принят ответ игрока #1")
+	winner, ok := quizState.FastestFinger().Winner(question.ID)
+	require.True(t, ok)
+	assert.Equal(t, uint(1), winner)
+}
+
+// TestProcessSubmission_FastestFingerPassesOverRejected: более ранний ответ
+// исключенного игрока отклоняется, и бонус получает следующий правильный ответ
+func TestProcessSubmission_FastestFingerPassesOverRejected(t *testing.T) {
+	ap, hub := newTestProcessor()
+
+	question := testSubmission(1, 2, false).Question
+	quizState := NewActiveQuizState(&entity.Quiz{ID: 1, FastestFingerBonus: 7})
+	quizState.SetCurrentQuestion(question, 1)
+	quizState.SetCurrentQuestionStartTime(time.Now().UnixMilli())
+	require.NoError(t, ap.deps.CacheRepo.Set(KickedKey(1, 1), "1", time.Hour))
+
+	kicked, err := ap.PrepareSubmission(1, question.ID, 2, time.Now().UnixMilli(), quizState)
+	require.NoError(t, err)
+	player, err := ap.PrepareSubmission(2, question.ID, 2, time.Now().UnixMilli(), quizState)
+	require.NoError(t, err)
+	player.ReceivedAtMs = kicked.ReceivedAtMs + 5
+
+	playerDone := make(chan error, 1)
+	go func() { playerDone <- ap.ProcessSubmission(context.Background(), player) }()
+	time.Sleep(50 * time.Millisecond)
+
+	assert.ErrorIs(t, ap.ProcessSubmission(context.Background(), kicked), ErrParticipantKicked)
+	require.NoError(t, <-playerDone)
+
+	winner, ok := quizState.FastestFinger().Winner(question.ID)
+	require.True(t, ok, "отклоненный ответ не лишает бонуса остальных")
+	assert.Equal(t, uint(2), winner)
+	assert.Equal(t, true, hub.eventData("2:quiz:answer_result")["fastest_finger"])
+}
+
+// TestFastestFingerTracker_DiscardedCandidate: снятый кандидат не мешает
+// следующему по времени приема ответу
+func TestFastestFingerTracker_DiscardedCandidate(t *testing.T) {
+	tracker := NewFastestFingerTracker()
+	early := tracker.Offer(10, 1, 1000)
+	late := tracker.Offer(10, 2, 1005)
+
+	assert.False(t, tracker.Settle(10, early, false), "ответ отклонен очередью")
+	assert.True(t, tracker.Settle(10, late, true))
+	winner, _ := tracker.Winner(10)
+	assert.Equal(t, uint(2), winner)
+}
+
+func TestProcessSubmission_FastestFingerIgnoresWrongAnswers(t *testing.T) {
+	ap, hub := newTestProcessor()
+	tracker := NewFastestFingerTracker()
+
+	wrong := testSubmission(1, 1, false)
+	wrong.FastestFinger, wrong.FastestFingerBonus = tracker, 5
+	require.NoError(t, ap.ProcessSubmission(context.Background(), wrong))
+
+	right := testSubmission(2, 2, true)
+	right.FastestFinger, right.FastestFingerBonus = tracker, 5
+	require.NoError(t, ap.ProcessSubmission(context.Background(), right))
+
+	winner, ok := tracker.Winner(10)
+	require.True(t, ok)
+	assert.Equal(t, uint(2), winner)
+
+	// В режиме отложенных результатов о первенстве сообщается только при закрытии вопроса
+	assert.NotContains(t, hub.Events(), "2:quiz:fastest_finger")
+	ap.RevealResults(10)
+	assert.Contains(t, hub.Events(), "2:quiz:fastest_finger")
+	assert.Equal(t, true, hub.eventData("2:quiz:answer_result")["fastest_finger"])
+}
+
+// lateSubmission - верный ответ пользователя, пришедший через 12 секунд после
+// начала вопроса с лимитом 10 секунд
+func lateSubmission(userID, questionID uint) *AnswerSubmission {
+	sub := testSubmission(userID, 2, false)
+	sub.QuestionID = questionID
+	sub.Question.ID = questionID
+	sub.QuestionStartMs = sub.Timestamp - 12000
+	return sub
+}
+
+func TestProcessSubmission_ReconnectDuringQuestionIsReprieved(t *testing.T) {
+	ap, hub := newTestProcessor()
+	ap.config.ReconnectGraceWindow = 10 * time.Second
+	results := ap.deps.ResultRepo.(*memoryResults)
+
+	// Игрок отключился на 5-й секунде вопроса и ответил после переподключения
+	first := lateSubmission(1, 10)
+	ap.RecordDisconnect(1, time.UnixMilli(first.QuestionStartMs+5000))
+	require.NoError(t, ap.ProcessSubmission(context.Background(), first))
+
+	require.Len(t, results.answers, 1)
+	assert.False(t, results.answers[0].IsEliminated, "опоздание после переподключения прощается")
+	assert.False(t, results.answers[0].IsCorrect, "прощенный ответ засчитывается как пропущенный")
+	assert.Zero(t, results.answers[0].Score)
+	assert.NotContains(t, hub.Events(), "1:quiz:elimination")
+
+	// Единственное прощение за викторину уже использовано
+	second := lateSubmission(1, 11)
+	ap.RecordDisconnect(1, time.UnixMilli(second.QuestionStartMs+5000))
+	require.NoError(t, ap.ProcessSubmission(context.Background(), second))
+
+	require.Len(t, results.answers, 2)
+	assert.True(t, results.answers[1].IsEliminated)
+	assert.Equal(t, "time_exceeded", results.answers[1].EliminationReason)
+}
+
+func TestProcessSubmission_ReconnectReprieveConditions(t *testing.T) {
+	cases := []struct {
+		name         string
+		grace        time.Duration
+		disconnectAt func(sub *AnswerSubmission) int64 // 0 - отключения не было
+	}{
+		{"no disconnect", 10 * time.Second, func(*AnswerSubmission) int64 { return 0 }},
+		{"disconnect before question", 10 * time.Second, func(s *AnswerSubmission) int64 { return s.QuestionStartMs - 1000 }},
+		{"returned after grace window", 3 * time.Second, func(s *AnswerSubmission) int64 { return s.QuestionStartMs + 5000 }},
+		{"feature disabled", 0, func(s *AnswerSubmission) int64 { return s.QuestionStartMs + 5000 }},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ap, _ := newTestProcessor()
+			ap.config.ReconnectGraceWindow = tc.grace
+			sub := lateSubmission(1, 10)
+			if at := tc.disconnectAt(sub); at != 0 {
+				ap.deps.CacheRepo.Set("user:1:disconnected_at", at, time.Hour)
+			}
+
+			require.NoError(t, ap.ProcessSubmission(context.Background(), sub))
+			answers := ap.deps.ResultRepo.(*memoryResults).answers
+			require.Len(t, answers, 1)
+			assert.True(t, answers[0].IsEliminated)
+		})
+	}
+}
+
+func TestRecordParticipantCountry(t *testing.T) {
+	ap, _ := newTestProcessor()
+
+	ap.RecordParticipantCountry(1, 7, "DE")
+	ap.RecordParticipantCountry(1, 8, "")
+
+	assert.Equal(t, "DE", ParticipantCountry(ap.deps.CacheRepo, 1, 7))
+	assert.Empty(t, ParticipantCountry(ap.deps.CacheRepo, 1, 8), "неопределенная страна не сохраняется")
+	assert.Empty(t, ParticipantCountry(ap.deps.CacheRepo, 2, 7))
+}
+
+func TestKickParticipant_KickedPlayerCannotAnswer(t *testing.T) {
+	ap, hub := newTestProcessor()
+	results := ap.deps.ResultRepo.(*memoryResults)
+
+	require.NoError(t, ap.HandleReadyEvent(context.Background(), 1, 1))
+	require.NoError(t, ap.HandleReadyEvent(context.Background(), 2, 1))
+	require.NoError(t, ap.HandleReadyEvent(context.Background(), 1, 1)) // переподключение не учитывается дважды
+	assert.Equal(t, int64(2), ap.ParticipantCount(1))
+
+	// Ответ до исключения сохраняется и остается в результатах
+	require.NoError(t, ap.ProcessSubmission(context.Background(), testSubmission(1, 2, false)))
+
+	participants, err := ap.KickParticipant(1, 1, "spam")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), participants)
+	assert.Equal(t, "spam", hub.eventData("1:quiz:kicked")["reason"])
+
+	next := testSubmission(1, 2, false)
+	next.QuestionID, next.Question.ID = 11, 11
+	assert.ErrorIs(t, ap.ProcessSubmission(context.Background(), next), ErrParticipantKicked)
+	require.Len(t, results.answers, 1, "ответ исключенного игрока не сохраняется")
+
+	_, eliminated := ap.ParticipationStatus(1, 1)
+	assert.True(t, eliminated, "исключенный игрок помечается выбывшим")
+	assert.ErrorIs(t, ap.HandleReadyEvent(context.Background(), 1, 1), ErrParticipantKicked)
+
+	// Повторное исключение не меняет число участников
+	participants, err = ap.KickParticipant(1, 1, "spam")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), participants)
+
+	_, err = ap.KickParticipant(1, 3, "spam")
+	assert.ErrorIs(t, err, ErrNotParticipant)
+}
+
+func selfPacedQuiz() *entity.Quiz {
+	return &entity.Quiz{
+		ID:                    1,
+		PacingMode:            entity.PacingSelfPaced,
+		SelfPacedTimeLimitSec: 60,
+		Questions: []entity.Question{
+			{ID: 10, QuizID: 1, Text: "Q1", Options: entity.StringArray{"a", "b"}, CorrectOption: 0, TimeLimitSec: 10, PointValue: 10},
+			{ID: 11, QuizID: 1, Text: "Q2", Options: entity.StringArray{"a", "b"}, CorrectOption: 1, TimeLimitSec: 10, PointValue: 10},
+		},
+	}
+}
+
+func TestSelfPacedRun_PlayersProgressIndependently(t *testing.T) {
+	start := time.Now()
+	run := NewSelfPacedRun(selfPacedQuiz(), start)
+
+	_, _, err := run.TakeAnswer(1, 10, start)
+	assert.ErrorIs(t, err, ErrSelfPacedNotStarted)
+
+	q, number, servedAt, err := run.CurrentQuestion(1, start)
+	require.NoError(t, err)
+	assert.Equal(t, uint(10), q.ID)
+	assert.Equal(t, 1, number)
+
+	// Повторный запрос (переподключение) возвращает тот же вопрос с прежним временем получения
+	_, _, again, err := run.CurrentQuestion(1, start.Add(5*time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, servedAt, again)
+
+	_, _, err = run.TakeAnswer(1, 11, start.Add(time.Second))
+	assert.ErrorIs(t, err, ErrNotCurrentSelfPacedQuestion)
+	_, _, err = run.TakeAnswer(1, 10, start.Add(time.Second))
+	require.NoError(t, err)
+	_, _, err = run.TakeAnswer(1, 10, start.Add(time.Second))
+	assert.ErrorIs(t, err, ErrNotCurrentSelfPacedQuestion, "повторный ответ на пройденный вопрос")
+
+	// Второй игрок начинает с первого вопроса независимо от первого
+	q, _, _, err = run.CurrentQuestion(2, start.Add(2*time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, uint(10), q.ID)
+
+	q, number, _, err = run.CurrentQuestion(1, start.Add(2*time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, uint(11), q.ID)
+	assert.Equal(t, 2, number)
+	_, _, err = run.TakeAnswer(1, 11, start.Add(3*time.Second))
+	require.NoError(t, err)
+	_, _, _, err = run.CurrentQuestion(1, start.Add(3*time.Second))
+	assert.ErrorIs(t, err, ErrSelfPacedCompleted)
+
+	answered, started := run.Progress(1)
+	assert.Equal(t, 2, answered)
+	assert.True(t, started)
+	assert.Equal(t, []uint{1, 2}, run.Participants())
+
+	// После общего срока ни вопросы, ни ответы не принимаются
+	_, _, _, err = run.CurrentQuestion(2, run.Deadline)
+	assert.ErrorIs(t, err, ErrSelfPacedTimeOver)
+	_, _, err = run.TakeAnswer(2, 10, run.Deadline)
+	assert.ErrorIs(t, err, ErrSelfPacedTimeOver)
+}
+
+func TestProcessSubmission_SelfPacedServesNextQuestion(t *testing.T) {
+	ap, hub := newTestProcessor()
+	quiz := selfPacedQuiz()
+	state := NewActiveQuizState(quiz)
+	state.SelfPaced = NewSelfPacedRun(quiz, time.Now())
+
+	require.NoError(t, ap.ServeSelfPacedQuestion(state.SelfPaced, 1))
+	first := hub.eventData("1:quiz:question")
+	require.NotNil(t, first)
+	assert.Equal(t, true, first["self_paced"])
+	assert.Contains(t, first, "ends_at")
+
+	// Неверный ответ не приводит к выбыванию, игрок сразу получает следующий вопрос
+	sub, err := ap.PrepareSelfPacedSubmission(1, 10, 1, state)
+	require.NoError(t, err)
+	require.NoError(t, ap.ProcessSubmission(context.Background(), sub))
+	result := hub.eventData("1:quiz:answer_result")
+	require.NotNil(t, result)
+	assert.Equal(t, false, result["is_correct"])
+	assert.NotContains(t, result, "correct_option")
+	assert.NotContains(t, hub.Events(), "1:quiz:elimination")
+	assert.Equal(t, 2, hub.eventData("1:quiz:question")["number"])
+
+	sub, err = ap.PrepareSelfPacedSubmission(1, 11, 1, state)
+	require.NoError(t, err)
+	require.NoError(t, ap.ProcessSubmission(context.Background(), sub))
+	complete := hub.eventData("1:quiz:self_paced_complete")
+	require.NotNil(t, complete)
+	assert.Equal(t, 2, complete["answered"])
+
+	_, err = ap.PrepareSelfPacedSubmission(1, 11, 1, state)
+	assert.ErrorIs(t, err, ErrSelfPacedCompleted)
+}
+
+func TestQuestionAcks_WindowStartCapped(t *testing.T) {
+	acks := NewQuestionAcks()
+	const startMs, maxOffsetMs = 100000, 2000
+
+	assert.Equal(t, int64(startMs), acks.WindowStart(10, 1, startMs, maxOffsetMs), "без подтверждения - от рассылки")
+
+	assert.True(t, acks.Acknowledge(10, 1, startMs+1500))
+	assert.False(t, acks.Acknowledge(10, 1, startMs+1900), "повторное подтверждение не сдвигает начало")
+	assert.Equal(t, int64(startMs+1500), acks.WindowStart(10, 1, startMs, maxOffsetMs))
+
+	acks.Acknowledge(10, 2, startMs+60000)
+	assert.Equal(t, int64(startMs+maxOffsetMs), acks.WindowStart(10, 2, startMs, maxOffsetMs), "задержка подтверждения ограничена")
+
+	acks.Acknowledge(10, 3, startMs-500)
+	assert.Equal(t, int64(startMs), acks.WindowStart(10, 3, startMs, maxOffsetMs))
+}
+
+// TestProcessSubmission_AnswerWindowFromAck проверяет, что время на ответ
+// отсчитывается от подтверждения получения вопроса, а клиент, задержавший
+// подтверждение, получает не больше MaxQuestionAckOffsetMs дополнительного времени
+func TestProcessSubmission_AnswerWindowFromAck(t *testing.T) {
+	ap, hub := newTestProcessor()
+	question := testSubmission(1, 2, false).Question // 10 секунд на ответ
+
+	quizState := NewActiveQuizState(&entity.Quiz{ID: 1, AnswerWindowFromAck: true})
+	quizState.SetCurrentQuestion(question, 1)
+	startMs := time.Now().UnixMilli() - 11000
+	quizState.SetCurrentQuestionStartTime(startMs)
+
+	// Медленный клиент подтвердил получение через 1.5 с и ответил через 11 с после
+	// рассылки: от подтверждения прошло 9.5 с, ответ успел
+	quizState.Acks().Acknowledge(question.ID, 1, startMs+1500)
+	slow, err := ap.PrepareSubmission(1, question.ID, 2, startMs+11000, quizState)
+	require.NoError(t, err)
+	assert.Equal(t, startMs+1500, slow.QuestionStartMs)
+	require.NoError(t, ap.ProcessSubmission(context.Background(), slow))
+	assert.Equal(t, true, hub.eventData("1:quiz:answer_result")["is_correct"])
+	assert.Equal(t, false, hub.eventData("1:quiz:answer_result")["time_limit_exceeded"])
+
+	// Клиент, задержавший подтверждение на 8 с, получает только 2 с форы
+	quizState.Acks().Acknowledge(question.ID, 2, startMs+8000)
+	delayed, err := ap.PrepareSubmission(2, question.ID, 2, startMs+12500, quizState)
+	require.NoError(t, err)
+	assert.Equal(t, startMs+ap.config.MaxQuestionAckOffsetMs, delayed.QuestionStartMs)
+	require.NoError(t, ap.ProcessSubmission(context.Background(), delayed))
+	assert.Equal(t, true, hub.eventData("2:quiz:answer_result")["time_limit_exceeded"])
+
+	// Без подтверждения время считается от рассылки
+	unacked, err := ap.PrepareSubmission(3, question.ID, 2, startMs+11000, quizState)
+	require.NoError(t, err)
+	assert.Equal(t, startMs, unacked.QuestionStartMs)
+}
+
+// TestProcessSubmission_LivesDelayElimination: игрок с тремя жизнями переживает
+// неверный и опоздавший ответы и выбывает на третьей ошибке
+func TestProcessSubmission_LivesDelayElimination(t *testing.T) {
+	ap, hub := newTestProcessor()
+	cache := ap.deps.CacheRepo.(*memoryCache)
+	lives := NewLivesTracker(3)
+
+	wrong := func(questionID uint) *AnswerSubmission {
+		sub := testSubmission(1, 1, false)
+		sub.QuestionID = questionID
+		sub.Question.ID = questionID
+		return sub
+	}
+	submissions := []*AnswerSubmission{wrong(10), lateSubmission(1, 11), wrong(12)}
+
+	for i, sub := range submissions[:2] {
+		sub.Lives = lives
+		require.NoError(t, ap.ProcessSubmission(context.Background(), sub))
+		result := hub.eventData("1:quiz:answer_result")
+		assert.Equal(t, false, result["is_eliminated"])
+		assert.Equal(t, 2-i, result["lives_remaining"])
+	}
+	assert.NotContains(t, hub.Events(), "1:quiz:elimination")
+	eliminated, _ := cache.Exists("quiz:1:eliminated:1")
+	assert.False(t, eliminated)
+
+	// Правильный ответ жизнь не отнимает
+	right := testSubmission(1, 2, false)
+	right.QuestionID, right.Question.ID, right.Lives = 13, 13, lives
+	require.NoError(t, ap.ProcessSubmission(context.Background(), right))
+	assert.Equal(t, 1, hub.eventData("1:quiz:answer_result")["lives_remaining"])
+
+	submissions[2].Lives = lives
+	require.NoError(t, ap.ProcessSubmission(context.Background(), submissions[2]))
+	result := hub.eventData("1:quiz:answer_result")
+	assert.Equal(t, true, result["is_eliminated"])
+	assert.Equal(t, 0, result["lives_remaining"])
+	assert.Contains(t, hub.Events(), "1:quiz:elimination")
+	eliminated, _ = cache.Exists("quiz:1:eliminated:1")
+	assert.True(t, eliminated)
+}
+
+// TestProcessSubmission_LivesSharedAcrossInstances: жизни, потерянные на одном
+// экземпляре, учитываются на другом (у каждого экземпляра свой LivesTracker)
+func TestProcessSubmission_LivesSharedAcrossInstances(t *testing.T) {
+	ap, hub := newTestProcessor()
+
+	first := testSubmission(1, 1, false)
+	first.Lives = NewLivesTracker(2)
+	require.NoError(t, ap.ProcessSubmission(context.Background(), first))
+	assert.Equal(t, 1, hub.eventData("1:quiz:answer_result")["lives_remaining"])
+
+	second := testSubmission(1, 1, false)
+	second.QuestionID, second.Question.ID = 11, 11
+	second.Lives = NewLivesTracker(2)
+	require.NoError(t, ap.ProcessSubmission(context.Background(), second))
+	result := hub.eventData("1:quiz:answer_result")
+	assert.Equal(t, true, result["is_eliminated"])
+	assert.Equal(t, 0, result["lives_remaining"])
+}
+
+// TestProcessSubmission_LifeLostDeferred: при отложенных результатах о потере
+// жизни узнает только сам игрок после закрытия вопроса
+func TestProcessSubmission_LifeLostDeferred(t *testing.T) {
+	ap, hub := newTestProcessor()
+
+	sub := testSubmission(1, 1, true)
+	sub.Lives = NewLivesTracker(2)
+	require.NoError(t, ap.ProcessSubmission(context.Background(), sub))
+	assert.Equal(t, []string{"1:quiz:answer_ack"}, hub.Events())
+
+	ap.RevealResults(10)
+	assert.Equal(t, []string{"1:quiz:answer_ack", "1:quiz:answer_result", "1:quiz:life_lost"}, hub.Events())
+	assert.Equal(t, 1, hub.eventData("1:quiz:life_lost")["lives_remaining"])
+}
@@ -0,0 +1,256 @@
+package quizmanager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/yourusername/trivia-api/internal/domain/entity"
+)
+
+// ErrAnswerWriteBufferFull возвращается, когда буфер записи ответов заполнен
+// (обычно из-за длительной недоступности БД)
+var ErrAnswerWriteBufferFull = errors.New("answer write buffer is full")
+
+// AnswerWriter накапливает ответы игроков и записывает их в БД пакетами: по
+// интервалу, при заполнении пакета и перед раскрытием ответа на вопрос. Вместо
+// отдельного INSERT на каждый ответ на вопрос с тысячами игроков приходится
+// несколько пакетных вставок.
+//
+// Ответы записываются в порядке приема одним потоком, created_at задается в
+// момент приема. Пакет вставляется в одной транзакции, поэтому после ошибки
+// он возвращается в начало буфера и повторяется целиком без дублей.
+type AnswerWriter struct {
+	deps *Dependencies
+
+	mu            sync.Mutex
+	pending       []*entity.UserAnswer
+	keys          map[string]struct{} // quizID:questionID:userID ответов в буфере
+	batchSize     int
+	flushInterval time.Duration
+	maxPending    int
+
+	// Записи выполняются по одной, чтобы сохранить порядок ответов
+	flushMu sync.Mutex
+	kick    chan struct{}
+	// Закрывается, когда периодическая запись остановлена (см. Wait)
+	done chan struct{}
+
+	// Счетчики для метрик
+	bufferedTotal   atomic.Int64
+	writtenTotal    atomic.Int64
+	batchesTotal    atomic.Int64
+	flushErrors     atomic.Int64
+	duplicatesTotal atomic.Int64
+	rejectedTotal   atomic.Int64
+	lastFlushMs     atomic.Int64
+}
+
+// NewAnswerWriter создает буфер записи ответов с настройками из config
+func NewAnswerWriter(config *Config, deps *Dependencies) *AnswerWriter {
+	w := &AnswerWriter{
+		deps:       deps,
+		keys:       make(map[string]struct{}),
+		maxPending: config.AnswerWriteMaxPending,
+		kick:       make(chan struct{}, 1),
+		done:       make(chan struct{}),
+	}
+	w.Configure(config.AnswerWriteBatchSize, config.AnswerWriteFlushInterval)
+	return w
+}
+
+// Configure задает размер пакета и интервал записи. Размер пакета 1 и меньше
+// выключает буферизацию: ответы записываются сразу при обработке.
+func (w *AnswerWriter) Configure(batchSize int, flushInterval time.Duration) {
+	if flushInterval <= 0 {
+		flushInterval = 250 * time.Millisecond
+	}
+	w.mu.Lock()
+	w.batchSize = batchSize
+	w.flushInterval = flushInterval
+	w.mu.Unlock()
+}
+
+// Enabled сообщает, включена ли пакетная запись
+func (w *AnswerWriter) Enabled() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.batchSize > 1
+}
+
+// Start запускает периодическую запись. При отмене контекста оставшиеся ответы
+// записываются перед выходом.
+func (w *AnswerWriter) Start(ctx context.Context) {
+	go func() {
+		defer close(w.done)
+		for {
+			w.mu.Lock()
+			interval := w.flushInterval
+			w.mu.Unlock()
+
+			timer := time.NewTimer(interval)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				if err := w.Flush(); err != nil {
+					log.Printf("[AnswerWriter] Остановка: не удалось записать %d ответов: %v", w.Pending(), err)
+				}
+				return
+			case <-timer.C:
+			case <-w.kick:
+				timer.Stop()
+			}
+			if err := w.Flush(); err != nil {
+				log.Printf("[AnswerWriter] Ошибка пакетной записи ответов, повтор через %v: %v", interval, err)
+			}
+		}
+	}()
+}
+
+// Add ставит ответ в очередь на запись. Повторный ответ игрока на тот же вопрос,
+// еще не записанный в БД, игнорируется.
+func (w *AnswerWriter) Add(answer *entity.UserAnswer) error {
+	if answer.CreatedAt.IsZero() {
+		answer.CreatedAt = time.Now()
+	}
+	key := fmt.Sprintf("%d:%d:%d", answer.QuizID, answer.QuestionID, answer.UserID)
+
+	w.mu.Lock()
+	if _, ok := w.keys[key]; ok {
+		w.mu.Unlock()
+		w.duplicatesTotal.Add(1)
+		return nil
+	}
+	if w.maxPending > 0 && len(w.pending) >= w.maxPending {
+		w.mu.Unlock()
+		w.rejectedTotal.Add(1)
+		return ErrAnswerWriteBufferFull
+	}
+	w.keys[key] = struct{}{}
+	w.pending = append(w.pending, answer)
+	full := len(w.pending) >= w.batchSize
+	w.mu.Unlock()
+	w.bufferedTotal.Add(1)
+
+	if full {
+		select {
+		case w.kick <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// Flush записывает все накопленные ответы. При ошибке незаписанные ответы
+// остаются в буфере в прежнем порядке.
+func (w *AnswerWriter) Flush() error {
+	w.flushMu.Lock()
+	defer w.flushMu.Unlock()
+
+	w.mu.Lock()
+	batch := w.pending
+	w.pending = nil
+	batchSize := w.batchSize
+	w.mu.Unlock()
+	if batchSize < 1 {
+		batchSize = len(batch)
+	}
+
+	for len(batch) > 0 {
+		n := min(batchSize, len(batch))
+		if err := w.deps.ResultRepo.SaveUserAnswersBatch(batch[:n]); err != nil {
+			w.flushErrors.Add(1)
+			w.mu.Lock()
+			w.pending = append(batch, w.pending...)
+			w.mu.Unlock()
+			return fmt.Errorf("failed to save %d answers: %w", n, err)
+		}
+
+		w.mu.Lock()
+		for _, answer := range batch[:n] {
+			delete(w.keys, fmt.Sprintf("%d:%d:%d", answer.QuizID, answer.QuestionID, answer.UserID))
+		}
+		w.mu.Unlock()
+		w.batchesTotal.Add(1)
+		w.writtenTotal.Add(int64(n))
+		batch = batch[n:]
+	}
+	w.lastFlushMs.Store(time.Now().UnixMilli())
+	return nil
+}
+
+// FlushWithRetry записывает накопленные ответы, повторяя попытку при ошибке.
+// Вызывается перед раскрытием ответа на вопрос и подсчетом результатов, чтобы
+// они опирались на все принятые ответы.
+func (w *AnswerWriter) FlushWithRetry(ctx context.Context, attempts int, interval time.Duration) error {
+	if attempts < 1 {
+		attempts = 1
+	}
+	var err error
+	for i := 0; i < attempts; i++ {
+		if err = w.Flush(); err == nil {
+			return nil
+		}
+		if i == attempts-1 {
+			break
+		}
+		select {
+		case <-time.After(interval):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return err
+}
+
+// Wait ожидает остановки периодической записи, запущенной Start, вместе с
+// последней записью при отмене контекста
+func (w *AnswerWriter) Wait(ctx context.Context) error {
+	select {
+	case <-w.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Pending возвращает число ответов, ожидающих записи
+func (w *AnswerWriter) Pending() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return len(w.pending)
+}
+
+// GetMetrics возвращает метрики пакетной записи ответов
+func (w *AnswerWriter) GetMetrics() map[string]interface{} {
+	w.mu.Lock()
+	pending := len(w.pending)
+	batchSize := w.batchSize
+	interval := w.flushInterval
+	w.mu.Unlock()
+
+	metrics := map[string]interface{}{
+		"enabled":           batchSize > 1,
+		"batch_size":        batchSize,
+		"flush_interval_ms": interval.Milliseconds(),
+		"pending":           pending,
+		"max_pending":       w.maxPending,
+		"buffered_total":    w.bufferedTotal.Load(),
+		"written_total":     w.writtenTotal.Load(),
+		"batches_total":     w.batchesTotal.Load(),
+		"flush_errors":      w.flushErrors.Load(),
+		"duplicates_total":  w.duplicatesTotal.Load(),
+		"rejected_total":    w.rejectedTotal.Load(),
+	}
+	if batches := w.batchesTotal.Load(); batches > 0 {
+		metrics["avg_batch_size"] = float64(w.writtenTotal.Load()) / float64(batches)
+	}
+	if last := w.lastFlushMs.Load(); last > 0 {
+		metrics["last_flush_at"] = time.UnixMilli(last).UTC()
+	}
+	return metrics
+}
@@ -0,0 +1,171 @@
+package quizmanager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourusername/trivia-api/internal/domain/entity"
+	"github.com/yourusername/trivia-api/internal/domain/repository"
+	"github.com/yourusername/trivia-api/internal/websocket"
+)
+
+// countingResults считает обращения к БД на запись ответов и может имитировать сбой
+type countingResults struct {
+	repository.ResultRepository
+	mu      sync.Mutex
+	writes  int
+	batches [][]*entity.UserAnswer
+	answers []*entity.UserAnswer
+	err     error
+}
+
+func (r *countingResults) SaveUserAnswer(answer *entity.UserAnswer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.writes++
+	r.answers = append(r.answers, answer)
+	return nil
+}
+
+func (r *countingResults) SaveUserAnswersBatch(answers []*entity.UserAnswer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.writes++
+	if r.err != nil {
+		return r.err
+	}
+	r.batches = append(r.batches, answers)
+	r.answers = append(r.answers, answers...)
+	return nil
+}
+
+func newTestWriter(repo *countingResults, batchSize int) *AnswerWriter {
+	config := DefaultConfig()
+	config.AnswerWriteBatchSize = batchSize
+	return NewAnswerWriter(config, &Dependencies{ResultRepo: repo})
+}
+
+func TestAnswerWriter_BatchesInOrder(t *testing.T) {
+	repo := &countingResults{}
+	w := newTestWriter(repo, 500)
+
+	for userID := uint(1); userID <= 1200; userID++ {
+		require.NoError(t, w.Add(&entity.UserAnswer{UserID: userID, QuizID: 1, QuestionID: 10}))
+	}
+	require.NoError(t, w.Flush())
+
+	require.Len(t, repo.batches, 3)
+	assert.Len(t, repo.batches[0], 500)
+	assert.Len(t, repo.batches[2], 200)
+	require.Len(t, repo.answers, 1200)
+	for i, answer := range repo.answers {
+		assert.Equal(t, uint(i+1), answer.UserID, "ответы записываются в порядке приема")
+		assert.False(t, answer.CreatedAt.IsZero(), "время приема задается при постановке в буфер")
+	}
+	assert.Equal(t, 0, w.Pending())
+}
+
+func TestAnswerWriter_FailedBatchRetriedWithoutDuplicates(t *testing.T) {
+	repo := &countingResults{err: errors.New("connection refused")}
+	w := newTestWriter(repo, 500)
+
+	for userID := uint(1); userID <= 3; userID++ {
+		require.NoError(t, w.Add(&entity.UserAnswer{UserID: userID, QuizID: 1, QuestionID: 10}))
+	}
+	require.Error(t, w.Flush())
+	assert.Equal(t, 3, w.Pending(), "незаписанный пакет остается в буфере")
+
+	// Повтор еще не записанного ответа не попадает в буфер второй раз
+	require.NoError(t, w.Add(&entity.UserAnswer{UserID: 2, QuizID: 1, QuestionID: 10}))
+	require.NoError(t, w.Add(&entity.UserAnswer{UserID: 4, QuizID: 1, QuestionID: 10}))
+
+	repo.err = nil
+	require.NoError(t, w.FlushWithRetry(context.Background(), 3, time.Millisecond))
+	require.Len(t, repo.answers, 4)
+	for i, answer := range repo.answers {
+		assert.Equal(t, uint(i+1), answer.UserID)
+	}
+	metrics := w.GetMetrics()
+	assert.Equal(t, int64(1), metrics["flush_errors"])
+	assert.Equal(t, int64(1), metrics["duplicates_total"])
+	assert.Equal(t, int64(4), metrics["written_total"])
+}
+
+func TestProcessSubmission_BatchedWrite(t *testing.T) {
+	ap, hub := newTestProcessor()
+	repo := &countingResults{}
+	ap.deps.ResultRepo = repo
+	ap.SetAnswerWriter(newTestWriter(repo, 500))
+
+	require.NoError(t, ap.ProcessSubmission(context.Background(), testSubmission(1, 2, false)))
+	assert.Contains(t, hub.Events(), "1:quiz:answer_ack")
+	assert.Empty(t, repo.answers, "ответ ждет пакетной записи")
+
+	require.NoError(t, ap.writer.Flush())
+	require.Len(t, repo.answers, 1)
+	assert.True(t, repo.answers[0].IsCorrect)
+	assert.Equal(t, 1, repo.writes)
+}
+
+func TestProcessSubmission_WriteBufferFull(t *testing.T) {
+	ap, hub := newTestProcessor()
+	repo := &countingResults{}
+	ap.deps.ResultRepo = repo
+	w := newTestWriter(repo, 500)
+	w.maxPending = 1
+	ap.SetAnswerWriter(w)
+
+	require.NoError(t, ap.ProcessSubmission(context.Background(), testSubmission(1, 2, false)))
+	require.Error(t, ap.ProcessSubmission(context.Background(), testSubmission(2, 2, false)))
+	assert.NotContains(t, hub.Events(), "2:quiz:answer_ack")
+
+	// Фиксация снята: после записи буфера ответ можно отправить повторно
+	require.NoError(t, w.Flush())
+	require.NoError(t, ap.ProcessSubmission(context.Background(), testSubmission(2, 2, false)))
+	assert.Contains(t, hub.Events(), "2:quiz:answer_ack")
+}
+
+// BenchmarkAnswerPersistence сравнивает число обращений к БД на запись при
+// обработке ответов одного вопроса: по одному ответу и пакетами.
+func BenchmarkAnswerPersistence(b *testing.B) {
+	const players = 10000
+	for _, batchSize := range []int{1, 500} {
+		b.Run(fmt.Sprintf("batch=%d", batchSize), func(b *testing.B) {
+			repo := &countingResults{}
+			config := DefaultConfig()
+			config.AnswerWriteBatchSize = batchSize
+			config.AnswerWriteMaxPending = players
+			deps := &Dependencies{
+				CacheRepo:  &memoryCache{data: make(map[string]string)},
+				ResultRepo: repo,
+				WSManager:  websocket.NewManager(&recordingHub{}),
+			}
+			ap := NewAnswerProcessor(config, deps)
+			w := NewAnswerWriter(config, deps)
+			ap.SetAnswerWriter(w)
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				deps.CacheRepo = &memoryCache{data: make(map[string]string)}
+				repo.answers = nil
+				b.StartTimer()
+				for userID := uint(1); userID <= players; userID++ {
+					if err := ap.ProcessSubmission(context.Background(), testSubmission(userID, 2, false)); err != nil {
+						b.Fatal(err)
+					}
+				}
+				if err := w.Flush(); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(repo.writes)/float64(b.N), "db_writes/question")
+		})
+	}
+}
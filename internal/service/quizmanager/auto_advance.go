@@ -0,0 +1,157 @@
+package quizmanager
+
+import (
+	"sync"
+	"time"
+)
+
+// AnswerTracker следит, ответили ли на текущий вопрос все активные игроки
+// викторины (подключенные и не выбывшие), чтобы при Quiz.AutoAdvance закрыть
+// вопрос досрочно. Ответы обрабатываются воркерами параллельно, а игроки
+// подключаются и отключаются во время вопроса, поэтому состояние меняется
+// только под мьютексом. Как и ActiveQuizState, учет ведется в памяти экземпляра.
+type AnswerTracker struct {
+	mu          sync.Mutex
+	players     map[uint]bool // Активные игроки
+	questionID  uint          // Вопрос, ответы на который ожидаются; 0 - нет
+	answered    map[uint]bool
+	allAnswered chan struct{} // Закрывается, когда ответили все активные игроки
+	signalled   bool
+	closedAtMs  map[uint]int64 // questionID -> время досрочного закрытия (Unix ms)
+	// Отключившиеся игроки, которые еще ожидаются до истечения времени на переподключение
+	disconnected map[uint]*time.Timer
+}
+
+// NewAnswerTracker создает пустой учет ответов
+func NewAnswerTracker() *AnswerTracker {
+	return &AnswerTracker{
+		players:    make(map[uint]bool),
+		answered:   make(map[uint]bool),
+		closedAtMs: make(map[uint]int64),
+
+		disconnected: make(map[uint]*time.Timer),
+	}
+}
+
+// AddPlayer добавляет игрока в число активных. Присоединившийся во время вопроса
+// игрок тоже ожидается: закрыть вопрос, не дав ему ответить, нельзя.
+func (t *AnswerTracker) AddPlayer(userID uint) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.stopDisconnectLocked(userID)
+	t.players[userID] = true
+}
+
+// RemovePlayer исключает игрока из активных (отключение, выбывание, исключение
+// модератором). Если остальные уже ответили, вопрос закрывается.
+func (t *AnswerTracker) RemovePlayer(userID uint) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.stopDisconnectLocked(userID)
+	delete(t.players, userID)
+	t.checkLocked()
+}
+
+// RemovePlayerAfter исключает отключившегося игрока из активных через grace,
+// если он не вернется раньше (AddPlayer). Пока идет отсчет, вопрос не
+// закрывается досрочно без ответа игрока, переподключившегося по другому
+// соединению. grace <= 0 исключает игрока сразу.
+func (t *AnswerTracker) RemovePlayerAfter(userID uint, grace time.Duration) {
+	if grace <= 0 {
+		t.RemovePlayer(userID)
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.players[userID] {
+		return
+	}
+	t.stopDisconnectLocked(userID)
+	var timer *time.Timer
+	timer = time.AfterFunc(grace, func() {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		if t.disconnected[userID] != timer {
+			return // Игрок вернулся или отключился повторно
+		}
+		delete(t.disconnected, userID)
+		delete(t.players, userID)
+		t.checkLocked()
+	})
+	t.disconnected[userID] = timer
+}
+
+// stopDisconnectLocked отменяет отложенное исключение игрока
+func (t *AnswerTracker) stopDisconnectLocked(userID uint) {
+	if timer, ok := t.disconnected[userID]; ok {
+		timer.Stop()
+		delete(t.disconnected, userID)
+	}
+}
+
+// ActivePlayers возвращает число активных игроков
+func (t *AnswerTracker) ActivePlayers() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.players)
+}
+
+// StartQuestion начинает учет ответов на вопрос. Возвращаемый канал закрывается,
+// когда на него ответят все активные игроки; без активных игроков - не закрывается.
+func (t *AnswerTracker) StartQuestion(questionID uint) <-chan struct{} {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.questionID = questionID
+	t.answered = make(map[uint]bool)
+	t.allAnswered = make(chan struct{})
+	t.signalled = false
+	return t.allAnswered
+}
+
+// RecordAnswer учитывает сохраненный ответ игрока. Выбывший с этим ответом игрок
+// больше не ожидается на следующих вопросах.
+func (t *AnswerTracker) RecordAnswer(userID, questionID uint, eliminated bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if eliminated {
+		delete(t.players, userID)
+	}
+	if questionID != t.questionID {
+		return
+	}
+	t.answered[userID] = true
+	t.checkLocked()
+}
+
+// CloseEarly отмечает вопрос закрытым досрочно в atMs
+func (t *AnswerTracker) CloseEarly(questionID uint, atMs int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.closedAtMs[questionID] = atMs
+	if t.questionID == questionID {
+		t.questionID = 0
+	}
+}
+
+// ClosedBefore сообщает, был ли вопрос закрыт досрочно раньше receivedAtMs.
+// Такой ответ пришел после раскрытия правильного ответа и считается опоздавшим.
+func (t *AnswerTracker) ClosedBefore(questionID uint, receivedAtMs int64) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	closedAt, ok := t.closedAtMs[questionID]
+	return ok && receivedAtMs > closedAt
+}
+
+// checkLocked закрывает канал текущего вопроса, если ответили все активные игроки
+func (t *AnswerTracker) checkLocked() {
+	if t.questionID == 0 || t.signalled || len(t.players) == 0 {
+		return
+	}
+	for userID := range t.players {
+		if !t.answered[userID] {
+			return
+		}
+	}
+	t.signalled = true
+	close(t.allAnswered)
+}
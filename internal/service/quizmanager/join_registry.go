@@ -0,0 +1,164 @@
+package quizmanager
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/yourusername/trivia-api/internal/domain/entity"
+	"github.com/yourusername/trivia-api/internal/domain/repository"
+)
+
+// ErrQuizFull возвращается, если все места викторины (Quiz.MaxPlayers) заняты
+var ErrQuizFull = errors.New("quiz has reached the maximum number of players")
+
+// Значения ключа присоединения: когда игрок занял место
+const (
+	joinedBeforeStart = "before_start"
+	joinedInProgress  = "in_progress"
+)
+
+// joinMinTTL - наименьший срок хранения места игрока
+const joinMinTTL = 24 * time.Hour
+
+// JoinKey - ключ кэша с местом игрока в викторине
+func JoinKey(quizID, userID uint) string {
+	return fmt.Sprintf("quiz:%d:joins:user:%d", quizID, userID)
+}
+
+// joinsReservedKey и joinsReleasedKey - счетчики занятых и освобожденных мест
+// викторины на всех экземплярах. Число занятых мест - их разность.
+func joinsReservedKey(quizID uint) string {
+	return fmt.Sprintf("quiz:%d:joins:reserved", quizID)
+}
+
+func joinsReleasedKey(quizID uint) string {
+	return fmt.Sprintf("quiz:%d:joins:released", quizID)
+}
+
+// JoinedBeforeStart сообщает, занял ли игрок место в викторине до ее старта.
+// Такой игрок присоединяется к идущей викторине как отметившийся до старта.
+func JoinedBeforeStart(cache repository.CacheRepository, quizID, userID uint) bool {
+	value, err := cache.Get(JoinKey(quizID, userID))
+	return err == nil && value == joinedBeforeStart
+}
+
+// HasJoined сообщает, занимает ли игрок место в викторине
+func HasJoined(cache repository.CacheRepository, quizID, userID uint) bool {
+	joined, _ := cache.Exists(JoinKey(quizID, userID))
+	return joined
+}
+
+// JoinRegistry учитывает места игроков в викторинах: присоединение занимает
+// место, выход освобождает. Места хранятся в кэше и общие для всех экземпляров;
+// лимит Quiz.MaxPlayers проверяется по счетчикам занятых и освобожденных мест.
+type JoinRegistry struct {
+	deps *Dependencies
+}
+
+// NewJoinRegistry создает учет мест в викторинах
+func NewJoinRegistry(deps *Dependencies) *JoinRegistry {
+	return &JoinRegistry{deps: deps}
+}
+
+// Reserve занимает место игрока в викторине. Повторный вызов для игрока,
+// уже занявшего место, ничего не меняет и возвращает reserved = false. Если
+// свободных мест нет, место не занимается и возвращается ErrQuizFull.
+func (r *JoinRegistry) Reserve(quiz *entity.Quiz, userID uint, inProgress bool) (reserved bool, err error) {
+	key := JoinKey(quiz.ID, userID)
+	value := joinedBeforeStart
+	if inProgress {
+		value = joinedInProgress
+	}
+
+	// Место хранится до суток после запланированного начала
+	ttl := time.Until(quiz.ScheduledTime) + joinMinTTL
+	if ttl < joinMinTTL {
+		ttl = joinMinTTL
+	}
+	first, err := r.deps.CacheRepo.SetNX(key, value, ttl)
+	if err != nil {
+		return false, fmt.Errorf("failed to reserve a spot: %w", err)
+	}
+	if !first {
+		return false, nil
+	}
+
+	if _, err := r.deps.CacheRepo.Increment(joinsReservedKey(quiz.ID)); err != nil {
+		r.deleteKey(quiz.ID, key)
+		return false, fmt.Errorf("failed to reserve a spot: %w", err)
+	}
+	// Мест могло не хватить из-за одновременных присоединений: лишнее место
+	// сразу освобождается
+	if quiz.MaxPlayers > 0 && r.Count(quiz.ID) > int64(quiz.MaxPlayers) {
+		r.releaseKey(quiz.ID, key)
+		return false, fmt.Errorf("%w: %d players", ErrQuizFull, quiz.MaxPlayers)
+	}
+	return true, nil
+}
+
+// Release освобождает место игрока. Возвращает false, если игрок места не занимал.
+func (r *JoinRegistry) Release(quizID, userID uint) (bool, error) {
+	// Освобожденное место учитывается, только если ключ удален этим вызовом:
+	// одновременные выходы одного игрока освобождают место один раз
+	released, err := r.deps.CacheRepo.DeleteIfExists(JoinKey(quizID, userID))
+	if err != nil {
+		return false, fmt.Errorf("failed to release the spot: %w", err)
+	}
+	if released {
+		r.incrementReleased(quizID)
+	}
+	return released, nil
+}
+
+// IsJoined сообщает, занимает ли игрок место в викторине
+func (r *JoinRegistry) IsJoined(quizID, userID uint) bool {
+	return HasJoined(r.deps.CacheRepo, quizID, userID)
+}
+
+// Count возвращает число занятых мест викторины на всех экземплярах
+func (r *JoinRegistry) Count(quizID uint) int64 {
+	reserved := r.counterValue(joinsReservedKey(quizID))
+	released := r.counterValue(joinsReleasedKey(quizID))
+	if reserved < released {
+		return 0
+	}
+	return reserved - released
+}
+
+func (r *JoinRegistry) deleteKey(quizID uint, key string) {
+	if err := r.deps.CacheRepo.Delete(key); err != nil {
+		log.Printf("[JoinRegistry] WARNING: Не удалось удалить место в викторине #%d: %v", quizID, err)
+	}
+}
+
+// releaseKey удаляет место и учитывает его как освобожденное, если его не
+// освободил одновременный Release
+func (r *JoinRegistry) releaseKey(quizID uint, key string) {
+	released, err := r.deps.CacheRepo.DeleteIfExists(key)
+	if err != nil {
+		log.Printf("[JoinRegistry] WARNING: Не удалось удалить место в викторине #%d: %v", quizID, err)
+		return
+	}
+	if released {
+		r.incrementReleased(quizID)
+	}
+}
+
+func (r *JoinRegistry) incrementReleased(quizID uint) {
+	if _, err := r.deps.CacheRepo.Increment(joinsReleasedKey(quizID)); err != nil {
+		log.Printf("[JoinRegistry] WARNING: Не удалось учесть освобожденное место в викторине #%d: %v", quizID, err)
+	}
+}
+
+// counterValue возвращает значение счетчика в кэше (0, если его нет)
+func (r *JoinRegistry) counterValue(key string) int64 {
+	raw, err := r.deps.CacheRepo.Get(key)
+	if err != nil || raw == "" {
+		return 0
+	}
+	value, _ := strconv.ParseInt(raw, 10, 64)
+	return value
+}
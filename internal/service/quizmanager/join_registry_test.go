@@ -0,0 +1,106 @@
+package quizmanager
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yourusername/trivia-api/internal/domain/entity"
+)
+
+func newTestJoinRegistry() (*JoinRegistry, *memoryCache) {
+	cache := &memoryCache{data: make(map[string]string)}
+	return NewJoinRegistry(&Dependencies{CacheRepo: cache}), cache
+}
+
+// TestJoinRegistry_FullQuiz: сверх Quiz.MaxPlayers место не занимается, а
+// освобожденное место достается следующему игроку
+func TestJoinRegistry_FullQuiz(t *testing.T) {
+	registry, cache := newTestJoinRegistry()
+	quiz := &entity.Quiz{ID: 7, MaxPlayers: 2, ScheduledTime: time.Now().Add(time.Hour)}
+
+	for _, userID := range []uint{1, 2} {
+		reserved, err := registry.Reserve(quiz, userID, false)
+		require.NoError(t, err)
+		assert.True(t, reserved)
+	}
+
+	reserved, err := registry.Reserve(quiz, 3, false)
+	assert.True(t, errors.Is(err, ErrQuizFull), "третий игрок не помещается: %v", err)
+	assert.False(t, reserved)
+	assert.False(t, registry.IsJoined(quiz.ID, 3))
+	assert.Equal(t, int64(2), registry.Count(quiz.ID))
+
+	// Повторное присоединение не занимает второе место
+	reserved, err = registry.Reserve(quiz, 1, false)
+	require.NoError(t, err)
+	assert.False(t, reserved)
+	assert.Equal(t, int64(2), registry.Count(quiz.ID))
+	assert.True(t, JoinedBeforeStart(cache, quiz.ID, 1))
+
+	released, err := registry.Release(quiz.ID, 2)
+	require.NoError(t, err)
+	assert.True(t, released)
+	released, err = registry.Release(quiz.ID, 2)
+	require.NoError(t, err)
+	assert.False(t, released, "место уже освобождено")
+
+	reserved, err = registry.Reserve(quiz, 3, true)
+	require.NoError(t, err)
+	assert.True(t, reserved)
+	assert.Equal(t, int64(2), registry.Count(quiz.ID))
+	assert.False(t, JoinedBeforeStart(cache, quiz.ID, 3), "место занято во время проведения")
+}
+
+// TestJoinRegistry_Unlimited: при MaxPlayers = 0 места не ограничены
+func TestJoinRegistry_Unlimited(t *testing.T) {
+	registry, _ := newTestJoinRegistry()
+	quiz := &entity.Quiz{ID: 8, ScheduledTime: time.Now()}
+
+	for userID := uint(1); userID <= 50; userID++ {
+		_, err := registry.Reserve(quiz, userID, false)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, int64(50), registry.Count(quiz.ID))
+}
+
+// TestJoinRegistry_ConcurrentRelease: одновременные выходы одного игрока
+// освобождают место один раз и не занижают число занятых мест
+func TestJoinRegistry_ConcurrentRelease(t *testing.T) {
+	registry, _ := newTestJoinRegistry()
+	quiz := &entity.Quiz{ID: 9, MaxPlayers: 2, ScheduledTime: time.Now().Add(time.Hour)}
+
+	for _, userID := range []uint{1, 2} {
+		_, err := registry.Reserve(quiz, userID, false)
+		require.NoError(t, err)
+	}
+
+	var wg sync.WaitGroup
+	var released atomic.Int32
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ok, err := registry.Release(quiz.ID, 1)
+			assert.NoError(t, err)
+			if ok {
+				released.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), released.Load(), "место освобождается одним вызовом")
+	assert.Equal(t, int64(1), registry.Count(quiz.ID))
+
+	// Освободилось ровно одно место: второй игрок сверх лимита не помещается
+	_, err := registry.Reserve(quiz, 3, false)
+	require.NoError(t, err)
+	_, err = registry.Reserve(quiz, 4, false)
+	assert.True(t, errors.Is(err, ErrQuizFull))
+}
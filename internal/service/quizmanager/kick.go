@@ -0,0 +1,116 @@
+package quizmanager
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+)
+
+var (
+	// ErrNotParticipant возвращается при исключении пользователя, который не
+	// отмечался как участник викторины, и на ответы игрока, не занявшего место
+	ErrNotParticipant = errors.New("user is not a participant of this quiz")
+	// ErrParticipantKicked возвращается на ответы и повторное присоединение
+	// игрока, исключенного из викторины модератором
+	ErrParticipantKicked = errors.New("user was removed from this quiz")
+)
+
+// KickedKey - ключ кэша с причиной исключения игрока из викторины.
+// Отсутствует у игроков, которых не исключали.
+func KickedKey(quizID, userID uint) string {
+	return fmt.Sprintf("quiz:%d:user:%d:kicked", quizID, userID)
+}
+
+// participantsJoinedKey и participantsKickedKey - счетчики отметившихся и
+// исключенных участников викторины. Число участников - их разность.
+func participantsJoinedKey(quizID uint) string {
+	return fmt.Sprintf("quiz:%d:participants:joined", quizID)
+}
+
+func participantsKickedKey(quizID uint) string {
+	return fmt.Sprintf("quiz:%d:participants:kicked", quizID)
+}
+
+// IsKicked сообщает, исключен ли игрок из викторины
+func (ap *AnswerProcessor) IsKicked(quizID, userID uint) bool {
+	kicked, _ := ap.deps.CacheRepo.Exists(KickedKey(quizID, userID))
+	return kicked
+}
+
+// ParticipantCount возвращает число участников викторины без исключенных
+func (ap *AnswerProcessor) ParticipantCount(quizID uint) int64 {
+	joined := ap.counterValue(participantsJoinedKey(quizID))
+	kicked := ap.counterValue(participantsKickedKey(quizID))
+	if joined < kicked {
+		return 0
+	}
+	return joined - kicked
+}
+
+// KickParticipant исключает игрока из викторины: он выбывает, больше не может
+// отвечать и присоединиться заново, а его уже сохраненные ответы остаются и
+// попадают в итоговый результат. Игрок получает quiz:kicked, остальные
+// участники - обновленное число участников. Повторное исключение ничего не
+// меняет. Возвращает число участников после исключения.
+func (ap *AnswerProcessor) KickParticipant(quizID, userID uint, reason string) (int64, error) {
+	if participating, _ := ap.ParticipationStatus(quizID, userID); !participating {
+		return 0, ErrNotParticipant
+	}
+
+	wasSet, err := ap.deps.CacheRepo.SetNX(KickedKey(quizID, userID), reason, 24*time.Hour)
+	if err != nil {
+		return 0, fmt.Errorf("failed to save kick of user %d: %w", userID, err)
+	}
+	if !wasSet {
+		log.Printf("[AnswerProcessor] Пользователь #%d уже исключен из викторины #%d", userID, quizID)
+		return ap.ParticipantCount(quizID), nil
+	}
+
+	// Исключенный считается выбывшим: ответы отклоняются, результат помечается выбывшим
+	eliminationKey := fmt.Sprintf("quiz:%d:eliminated:%d", quizID, userID)
+	if err := ap.deps.CacheRepo.Set(eliminationKey, "1", 24*time.Hour); err != nil {
+		log.Printf("[AnswerProcessor] WARNING: Не удалось установить статус выбывшего пользователя #%d в Redis: %v", userID, err)
+	}
+	if _, err := ap.deps.CacheRepo.Increment(participantsKickedKey(quizID)); err != nil {
+		log.Printf("[AnswerProcessor] WARNING: Не удалось учесть исключение в числе участников викторины #%d: %v", quizID, err)
+	}
+
+	kickedEvent := map[string]interface{}{
+		"quiz_id": quizID,
+		"reason":  reason,
+		"message": "Вы исключены из викторины модератором",
+	}
+	if err := ap.deps.WSManager.SendEventToUser(strconv.FormatUint(uint64(userID), 10), "quiz:kicked", kickedEvent); err != nil {
+		log.Printf("[AnswerProcessor] Ошибка при отправке quiz:kicked пользователю #%d: %v", userID, err)
+	}
+
+	participants := ap.ParticipantCount(quizID)
+	ap.broadcastParticipantCount(quizID, participants)
+	return participants, nil
+}
+
+// broadcastParticipantCount рассылает участникам викторины число участников
+func (ap *AnswerProcessor) broadcastParticipantCount(quizID uint, participants int64) {
+	fullEvent := map[string]interface{}{
+		"type": "quiz:participant_count",
+		"data": map[string]interface{}{
+			"quiz_id":      quizID,
+			"participants": participants,
+		},
+	}
+	if err := ap.deps.WSManager.BroadcastEventToQuiz(quizID, fullEvent); err != nil {
+		log.Printf("[AnswerProcessor] Ошибка при рассылке числа участников викторины #%d: %v", quizID, err)
+	}
+}
+
+// counterValue возвращает значение счетчика в кэше (0, если его нет)
+func (ap *AnswerProcessor) counterValue(key string) int64 {
+	raw, err := ap.deps.CacheRepo.Get(key)
+	if err != nil || raw == "" {
+		return 0
+	}
+	value, _ := strconv.ParseInt(raw, 10, 64)
+	return value
+}
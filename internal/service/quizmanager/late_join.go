@@ -0,0 +1,128 @@
+package quizmanager
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/yourusername/trivia-api/internal/domain/repository"
+)
+
+// ErrLateJoinNotAllowed возвращается при попытке присоединиться к уже идущей
+// викторине, правило присоединения которой - before_start_only
+var ErrLateJoinNotAllowed = errors.New("quiz is already in progress and does not allow late join")
+
+// LateJoinKey - ключ кэша с номером вопроса, на котором игрок присоединился
+// к идущей викторине. Отсутствует у игроков, присоединившихся до старта.
+func LateJoinKey(quizID, userID uint) string {
+	return fmt.Sprintf("quiz:%d:user:%d:joined_at_question", quizID, userID)
+}
+
+// JoinedAtQuestion возвращает номер вопроса, с которого игрок участвует в викторине
+// (0 - с самого начала)
+func JoinedAtQuestion(cache repository.CacheRepository, quizID, userID uint) int {
+	value, err := cache.Get(LateJoinKey(quizID, userID))
+	if err != nil || value == "" {
+		return 0
+	}
+	joinedAt, _ := strconv.Atoi(value)
+	return joinedAt
+}
+
+// JoinInProgress обрабатывает присоединение игрока к уже идущей викторине.
+// Игрок, отметившийся до старта (переподключение), и присоединение до первого
+// вопроса обрабатываются как обычно и возвращают 0. Иначе запоминается номер
+// вопроса, с которого игрок участвует, и, если текущий вопрос еще открыт,
+// игроку сразу отправляется его снимок с оставшимся временем.
+func (qm *QuestionManager) JoinInProgress(userID uint, quizState *ActiveQuizState) (int, error) {
+	if quizState.SelfPaced != nil {
+		return 0, qm.joinSelfPaced(userID, quizState)
+	}
+
+	question, number, startMs := quizState.CurrentQuestionSnapshot()
+	if question == nil || quizState.Replay {
+		return 0, nil
+	}
+
+	quizID := quizState.Quiz.ID
+	readyKey := fmt.Sprintf("quiz:%d:ready_users:%d", quizID, userID)
+	if ready, _ := qm.deps.CacheRepo.Exists(readyKey); ready {
+		return 0, nil
+	}
+	// Игрок, занявший место до старта, участвует с начала, как отметившийся
+	if JoinedBeforeStart(qm.deps.CacheRepo, quizID, userID) {
+		return 0, nil
+	}
+
+	if !quizState.Quiz.AllowsLateJoin() {
+		log.Printf("[QuestionManager] Пользователь #%d не может присоединиться к идущей викторине #%d", userID, quizID)
+		return 0, ErrLateJoinNotAllowed
+	}
+
+	// Если время текущего вопроса истекло, игрок участвует со следующего
+	nowMs := time.Now().UnixMilli()
+	remainingMs := int64(0)
+	if startMs > 0 {
+		remainingMs = startMs + int64(question.TimeLimitSec)*1000 - nowMs
+	}
+	joinedAt := number
+	if startMs > 0 && remainingMs <= 0 {
+		joinedAt = number + 1
+	}
+
+	// Повторное присоединение не сдвигает точку входа
+	if _, err := qm.deps.CacheRepo.SetNX(LateJoinKey(quizID, userID), strconv.Itoa(joinedAt), 24*time.Hour); err != nil {
+		log.Printf("[QuestionManager] WARNING: Не удалось сохранить точку присоединения пользователя #%d к викторине #%d: %v",
+			userID, quizID, err)
+	}
+	if recorded := JoinedAtQuestion(qm.deps.CacheRepo, quizID, userID); recorded > 0 {
+		joinedAt = recorded
+	}
+
+	log.Printf("[QuestionManager] Пользователь #%d присоединился к идущей викторине #%d с вопроса %d из %d",
+		userID, quizID, joinedAt, len(quizState.Quiz.Questions))
+
+	// Снимок отправляется только для открытого вопроса. Если вопрос еще не открыт,
+	// игрок получит его вместе со всеми.
+	if joinedAt == number && startMs > 0 {
+		snapshot := questionEventData(quizState.Quiz, question, number, startMs)
+		snapshot["server_timestamp"] = nowMs
+		snapshot["remaining_ms"] = remainingMs
+		snapshot["late_join"] = true
+		if err := qm.deps.WSManager.SendEventToUser(strconv.FormatUint(uint64(userID), 10), "quiz:question", snapshot); err != nil {
+			log.Printf("[QuestionManager] Ошибка при отправке текущего вопроса пользователю #%d: %v", userID, err)
+		}
+	}
+	// Во время чтения вопрос уже показан остальным: игрок получает его сразу,
+	// а открытие приема ответов - вместе со всеми
+	if readUntilMs := quizState.CurrentQuestionReadUntil(); joinedAt == number && startMs == 0 && readUntilMs > 0 {
+		shownMs := readUntilMs - int64(quizState.Quiz.QuestionReadTimeSec)*1000
+		snapshot := questionEventData(quizState.Quiz, question, number, shownMs)
+		markReadPhase(snapshot, quizState.Quiz, question, readUntilMs)
+		snapshot["server_timestamp"] = nowMs
+		snapshot["late_join"] = true
+		if err := qm.deps.WSManager.SendEventToUser(strconv.FormatUint(uint64(userID), 10), "quiz:question", snapshot); err != nil {
+			log.Printf("[QuestionManager] Ошибка при отправке текущего вопроса пользователю #%d: %v", userID, err)
+		}
+	}
+
+	return joinedAt, nil
+}
+
+// joinSelfPaced проверяет начало самостоятельного прохождения после старта
+// викторины: при before_start_only начать его могут только отметившиеся до
+// старта игроки, вернуться к прохождению - все, кто его уже начал.
+func (qm *QuestionManager) joinSelfPaced(userID uint, quizState *ActiveQuizState) error {
+	if _, started := quizState.SelfPaced.Progress(userID); started || quizState.Quiz.AllowsLateJoin() {
+		return nil
+	}
+	quizID := quizState.Quiz.ID
+	readyKey := fmt.Sprintf("quiz:%d:ready_users:%d", quizID, userID)
+	if ready, _ := qm.deps.CacheRepo.Exists(readyKey); ready {
+		return nil
+	}
+	log.Printf("[QuestionManager] Пользователь #%d не может начать прохождение идущей викторины #%d", userID, quizID)
+	return ErrLateJoinNotAllowed
+}
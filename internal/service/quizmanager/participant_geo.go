@@ -0,0 +1,35 @@
+package quizmanager
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/yourusername/trivia-api/internal/domain/repository"
+)
+
+// ParticipantCountryKey - ключ кэша со страной, из которой игрок подключился
+// к викторине. Отсутствует, если местоположение не определено.
+func ParticipantCountryKey(quizID, userID uint) string {
+	return fmt.Sprintf("quiz:%d:user:%d:country", quizID, userID)
+}
+
+// ParticipantCountry возвращает страну игрока в викторине или пустую строку
+func ParticipantCountry(cache repository.CacheRepository, quizID, userID uint) string {
+	country, err := cache.Get(ParticipantCountryKey(quizID, userID))
+	if err != nil {
+		return ""
+	}
+	return country
+}
+
+// RecordParticipantCountry запоминает страну игрока, отметившегося в викторине.
+// Страна попадает в итоговый результат и аналитику участия.
+func (ap *AnswerProcessor) RecordParticipantCountry(quizID, userID uint, country string) {
+	if country == "" {
+		return
+	}
+	if err := ap.deps.CacheRepo.Set(ParticipantCountryKey(quizID, userID), country, 24*time.Hour); err != nil {
+		log.Printf("[AnswerProcessor] WARNING: Не удалось сохранить страну пользователя #%d в викторине #%d: %v", userID, quizID, err)
+	}
+}
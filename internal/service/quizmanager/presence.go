@@ -0,0 +1,255 @@
+package quizmanager
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Причины выхода игрока из комнаты викторины (quiz:player_left)
+const (
+	LeaveReasonDisconnected = "disconnected"
+	LeaveReasonLeft         = "left"
+	LeaveReasonKicked       = "kicked"
+)
+
+// presenceJoinedKey и presenceLeftKey - счетчики входов и выходов игроков
+// комнаты викторины на всех экземплярах. Число игроков в комнате - их разность.
+func presenceJoinedKey(quizID uint) string {
+	return fmt.Sprintf("quiz:%d:presence:joined", quizID)
+}
+
+func presenceLeftKey(quizID uint) string {
+	return fmt.Sprintf("quiz:%d:presence:left", quizID)
+}
+
+// presenceCounterTTL - сколько счетчики комнаты хранятся после последнего
+// изменения: комната живет, пока идет викторина, и счетчики истекают после ее
+// окончания
+const presenceCounterTTL = 24 * time.Hour
+
+// PresenceFeed рассылает в комнату викторины события входа и выхода игроков
+// (quiz:player_joined, quiz:player_left) с числом игроков в комнате. Соединения
+// учитываются в памяти экземпляра: игрок входит с первым соединением и выходит
+// с последним, поэтому повторный user:ready и несколько вкладок не дают лишних
+// событий. Если за окно PresenceWindow событий комнаты больше
+// PresenceBurstThreshold, отдельные события до конца окна не отправляются, а
+// по его окончании рассылается одно сводное quiz:presence_update.
+type PresenceFeed struct {
+	config *Config
+	deps   *Dependencies
+
+	mu    sync.Mutex
+	rooms map[uint]*presenceRoom
+
+	// emit рассылает событие в комнату викторины
+	emit func(quizID uint, eventType string, data map[string]interface{})
+}
+
+// presenceRoom - игроки комнаты на этом экземпляре и учет событий текущего окна
+type presenceRoom struct {
+	connections map[string]uint // connectionID -> userID
+	perUser     map[uint]int    // userID -> число соединений в комнате
+
+	windowStart  time.Time
+	windowEvents int
+	// Свернутые события текущего окна, ожидающие сводного обновления
+	pendingJoined int
+	pendingLeft   int
+	flushing      bool
+}
+
+// NewPresenceFeed создает ленту входа и выхода игроков
+func NewPresenceFeed(config *Config, deps *Dependencies) *PresenceFeed {
+	f := &PresenceFeed{
+		config: config,
+		deps:   deps,
+		rooms:  make(map[uint]*presenceRoom),
+	}
+	f.emit = f.broadcast
+	return f
+}
+
+// Join учитывает вход соединения игрока в комнату викторины. displayName
+// попадает в событие, если игрок вошел первым соединением.
+func (f *PresenceFeed) Join(quizID, userID uint, connectionID, displayName string) {
+	f.mu.Lock()
+	room := f.rooms[quizID]
+	if room == nil {
+		room = &presenceRoom{connections: make(map[string]uint), perUser: make(map[uint]int)}
+		f.rooms[quizID] = room
+	}
+	if _, ok := room.connections[connectionID]; ok {
+		f.mu.Unlock()
+		return
+	}
+	room.connections[connectionID] = userID
+	room.perUser[userID]++
+	if room.perUser[userID] > 1 {
+		f.mu.Unlock()
+		return
+	}
+	individual := f.admitLocked(quizID, room, true)
+	f.mu.Unlock()
+
+	present := f.adjustCount(quizID, presenceJoinedKey(quizID))
+	if individual {
+		f.emit(quizID, "quiz:player_joined", map[string]interface{}{
+			"quiz_id":      quizID,
+			"user_id":      userID,
+			"display_name": displayName,
+			"count":        present,
+		})
+	}
+}
+
+// Leave учитывает выход соединения из комнаты викторины. Неизвестное
+// соединение (не входило или уже вышло) игнорируется.
+func (f *PresenceFeed) Leave(quizID uint, connectionID, reason string) {
+	f.mu.Lock()
+	room := f.rooms[quizID]
+	if room == nil {
+		f.mu.Unlock()
+		return
+	}
+	userID, ok := room.connections[connectionID]
+	if !ok {
+		f.mu.Unlock()
+		return
+	}
+	delete(room.connections, connectionID)
+	f.leaveLocked(quizID, room, userID, reason)
+}
+
+// LeaveUser учитывает выход всех соединений игрока из комнаты (например, при
+// исключении модератором)
+func (f *PresenceFeed) LeaveUser(quizID, userID uint, reason string) {
+	f.mu.Lock()
+	room := f.rooms[quizID]
+	if room == nil || room.perUser[userID] == 0 {
+		f.mu.Unlock()
+		return
+	}
+	for connectionID, connUserID := range room.connections {
+		if connUserID == userID {
+			delete(room.connections, connectionID)
+		}
+	}
+	room.perUser[userID] = 1
+	f.leaveLocked(quizID, room, userID, reason)
+}
+
+// leaveLocked завершает выход соединения игрока и снимает блокировку
+func (f *PresenceFeed) leaveLocked(quizID uint, room *presenceRoom, userID uint, reason string) {
+	room.perUser[userID]--
+	if room.perUser[userID] > 0 {
+		f.mu.Unlock()
+		return
+	}
+	delete(room.perUser, userID)
+	individual := f.admitLocked(quizID, room, false)
+	if len(room.connections) == 0 && !room.flushing {
+		delete(f.rooms, quizID)
+	}
+	f.mu.Unlock()
+
+	present := f.adjustCount(quizID, presenceLeftKey(quizID))
+	if individual {
+		f.emit(quizID, "quiz:player_left", map[string]interface{}{
+			"quiz_id": quizID,
+			"user_id": userID,
+			"reason":  reason,
+			"count":   present,
+		})
+	}
+}
+
+// admitLocked учитывает событие в окне комнаты и решает, отправлять ли его
+// отдельно. Сверх порога событие сворачивается в сводное обновление в конце окна.
+func (f *PresenceFeed) admitLocked(quizID uint, room *presenceRoom, joined bool) bool {
+	now := time.Now()
+	window := f.config.PresenceWindow
+	if now.Sub(room.windowStart) >= window {
+		room.windowStart = now
+		room.windowEvents = 0
+	}
+	room.windowEvents++
+	threshold := f.config.PresenceBurstThreshold
+	if threshold <= 0 || room.windowEvents <= threshold {
+		return true
+	}
+
+	if joined {
+		room.pendingJoined++
+	} else {
+		room.pendingLeft++
+	}
+	if !room.flushing {
+		room.flushing = true
+		time.AfterFunc(time.Until(room.windowStart.Add(window)), func() { f.flush(quizID) })
+	}
+	return false
+}
+
+// flush рассылает сводное обновление по свернутым событиям окна
+func (f *PresenceFeed) flush(quizID uint) {
+	f.mu.Lock()
+	room := f.rooms[quizID]
+	if room == nil {
+		f.mu.Unlock()
+		return
+	}
+	joined, left := room.pendingJoined, room.pendingLeft
+	room.pendingJoined, room.pendingLeft = 0, 0
+	room.flushing = false
+	if len(room.connections) == 0 {
+		delete(f.rooms, quizID)
+	}
+	f.mu.Unlock()
+
+	f.emit(quizID, "quiz:presence_update", map[string]interface{}{
+		"quiz_id": quizID,
+		"joined":  joined,
+		"left":    left,
+		"count":   f.Count(quizID),
+	})
+}
+
+// Count возвращает число игроков в комнате викторины на всех экземплярах
+func (f *PresenceFeed) Count(quizID uint) int64 {
+	joined := f.counterValue(presenceJoinedKey(quizID))
+	left := f.counterValue(presenceLeftKey(quizID))
+	if joined < left {
+		return 0
+	}
+	return joined - left
+}
+
+// adjustCount увеличивает счетчик входов или выходов и возвращает число игроков в комнате
+func (f *PresenceFeed) adjustCount(quizID uint, key string) int64 {
+	if _, err := f.deps.CacheRepo.Increment(key); err != nil {
+		log.Printf("[PresenceFeed] WARNING: Не удалось обновить число игроков в комнате викторины #%d: %v", quizID, err)
+	} else if err := f.deps.CacheRepo.ExpireAt(key, time.Now().Add(presenceCounterTTL)); err != nil {
+		log.Printf("[PresenceFeed] WARNING: Не удалось задать срок хранения счетчика игроков викторины #%d: %v", quizID, err)
+	}
+	return f.Count(quizID)
+}
+
+// counterValue возвращает значение счетчика в кэше (0, если его нет)
+func (f *PresenceFeed) counterValue(key string) int64 {
+	raw, err := f.deps.CacheRepo.Get(key)
+	if err != nil || raw == "" {
+		return 0
+	}
+	value, _ := strconv.ParseInt(raw, 10, 64)
+	return value
+}
+
+func (f *PresenceFeed) broadcast(quizID uint, eventType string, data map[string]interface{}) {
+	fullEvent := map[string]interface{}{"type": eventType, "data": data}
+	if err := f.deps.WSManager.BroadcastEventToQuiz(quizID, fullEvent); err != nil {
+		log.Printf("[PresenceFeed] Ошибка при рассылке %s для викторины #%d: %v", eventType, quizID, err)
+	}
+}
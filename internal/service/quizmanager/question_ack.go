@@ -0,0 +1,53 @@
+package quizmanager
+
+import "sync"
+
+// QuestionAcks хранит время подтверждения получения вопросов игроками
+// (quiz:question_received) для викторин с Quiz.AnswerWindowFromAck. Время на
+// ответ отсчитывается для каждого игрока от его подтверждения, чтобы медленно
+// отрисовывающий вопрос клиент не терял время. Подтверждение приходит по тому же
+// соединению, что и ответы, поэтому учет ведется в памяти экземпляра.
+type QuestionAcks struct {
+	mu   sync.Mutex
+	acks map[uint]map[uint]int64 // questionID -> userID -> время подтверждения (Unix ms)
+}
+
+// NewQuestionAcks создает пустой учет подтверждений
+func NewQuestionAcks() *QuestionAcks {
+	return &QuestionAcks{acks: make(map[uint]map[uint]int64)}
+}
+
+// Acknowledge запоминает подтверждение получения вопроса игроком в atMs.
+// Учитывается только первое подтверждение: повторное не сдвигает начало
+// времени на ответ. Возвращает false для повторного подтверждения.
+func (a *QuestionAcks) Acknowledge(questionID, userID uint, atMs int64) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	byUser, ok := a.acks[questionID]
+	if !ok {
+		byUser = make(map[uint]int64)
+		a.acks[questionID] = byUser
+	}
+	if _, acked := byUser[userID]; acked {
+		return false
+	}
+	byUser[userID] = atMs
+	return true
+}
+
+// WindowStart возвращает начало времени на ответ игрока: время подтверждения,
+// но не раньше рассылки вопроса (questionStartMs) и не позже рассылки плюс
+// maxOffsetMs, чтобы задержкой подтверждения нельзя было выиграть больше
+// времени. Без подтверждения время отсчитывается от рассылки.
+func (a *QuestionAcks) WindowStart(questionID, userID uint, questionStartMs, maxOffsetMs int64) int64 {
+	a.mu.Lock()
+	ackMs, ok := a.acks[questionID][userID]
+	a.mu.Unlock()
+	if !ok || ackMs < questionStartMs {
+		return questionStartMs
+	}
+	if ackMs > questionStartMs+maxOffsetMs {
+		return questionStartMs + maxOffsetMs
+	}
+	return ackMs
+}
@@ -19,8 +19,14 @@ type QuestionManager struct {
 	// Зависимости
 	deps *Dependencies
 
-	// Канал для сигнализации о завершении вопроса
-	questionDoneCh chan struct{}
+	// Канал, в который передается ID викторины, все вопросы которой отправлены
+	questionDoneCh chan uint
+
+	// Вызывается после раскрытия правильного ответа на вопрос
+	onAnswerReveal func(questionID uint)
+
+	// Вызывается перед раскрытием правильного ответа на вопрос
+	beforeAnswerReveal func(ctx context.Context, questionID uint)
 }
 
 // NewQuestionManager создает новый менеджер вопросов
@@ -28,12 +34,25 @@ func NewQuestionManager(config *Config, deps *Dependencies) *QuestionManager {
 	return &QuestionManager{
 		config:         config,
 		deps:           deps,
-		questionDoneCh: make(chan struct{}, 1),
+		questionDoneCh: make(chan uint, 16),
 	}
 }
 
-// QuestionDone возвращает канал для уведомления о завершении вопроса
-func (qm *QuestionManager) QuestionDone() <-chan struct{} {
+// SetAnswerRevealHook задает обработчик, вызываемый после отправки quiz:answer_reveal.
+// Используется для рассылки отложенных результатов ответов.
+func (qm *QuestionManager) SetAnswerRevealHook(hook func(questionID uint)) {
+	qm.onAnswerReveal = hook
+}
+
+// SetBeforeRevealHook задает обработчик, вызываемый перед отправкой
+// quiz:answer_reveal. Используется для записи накопленных ответов в БД.
+func (qm *QuestionManager) SetBeforeRevealHook(hook func(ctx context.Context, questionID uint)) {
+	qm.beforeAnswerReveal = hook
+}
+
+// QuestionDone возвращает канал с ID викторин, у которых закончились вопросы.
+// Викторины проводятся параллельно, поэтому сигнал несет ID викторины.
+func (qm *QuestionManager) QuestionDone() <-chan uint {
 	return qm.questionDoneCh
 }
 
@@ -107,15 +126,22 @@ func (qm *QuestionManager) AutoFillQuizQuestions(ctx context.Context, quizID uin
 		// Создаем новый вопрос с корректными полями
 		questionsToAdd[i] = entity.Question{
 			QuizID:        quizID,
+			Type:          q.Type,
 			Text:          q.Text,
 			Options:       make(entity.StringArray, len(q.Options)),
 			CorrectOption: q.CorrectOption,
+			ScoringMethod: q.ScoringMethod,
 			TimeLimitSec:  q.TimeLimitSec,
 			PointValue:    q.PointValue,
+			Difficulty:    q.Difficulty,
 		}
 
 		// Используем встроенную функцию copy вместо цикла для копирования данных слайса
 		copy(questionsToAdd[i].Options, q.Options)
+		if len(q.CorrectOrder) > 0 {
+			questionsToAdd[i].CorrectOrder = make(entity.IntArray, len(q.CorrectOrder))
+			copy(questionsToAdd[i].CorrectOrder, q.CorrectOrder)
+		}
 	}
 
 	// Добавляем вопросы к викторине
@@ -153,6 +179,7 @@ func (qm *QuestionManager) RunQuizQuestions(ctx context.Context, quizState *Acti
 		"title":          quizState.Quiz.Title,
 		"question_count": len(quizState.Quiz.Questions),
 	}
+	markReplay(quizState, startEvent)
 
 	// Используем новую сигнатуру
 	startFullEvent := map[string]interface{}{"type": "quiz:start", "data": startEvent}
@@ -167,28 +194,37 @@ func (qm *QuestionManager) RunQuizQuestions(ctx context.Context, quizState *Acti
 		// Устанавливаем текущий вопрос в состоянии
 		quizState.SetCurrentQuestion(&question, i+1)
 
+		// Учет ответов начинается до открытия вопроса, чтобы не пропустить первые ответы.
+		// Без досрочного закрытия канал nil и ожидание идет только по таймеру.
+		var allAnswered <-chan struct{}
+		if quizState.Quiz.AutoAdvance && !quizState.Replay {
+			allAnswered = quizState.Answers().StartQuestion(question.ID)
+		}
+
 		// Добавляем задержку перед отправкой вопроса для синхронизации с фронтендом
 		time.Sleep(time.Duration(qm.config.QuestionDelayMs) * time.Millisecond)
 
 		// Получить точное время отправки вопроса
 		sendTimeMs := time.Now().UnixNano() / int64(time.Millisecond)
 
+		// При времени на чтение вопрос показывается, но ответы не принимаются
+		// до quiz:answer_window_open
+		readTime := time.Duration(quizState.Quiz.QuestionReadTimeSec) * time.Second
+		answersOpenAtMs := sendTimeMs + readTime.Milliseconds()
 		// ===>>> ДОБАВИТЬ ВЫЗОВ <<<===
-		quizState.SetCurrentQuestionStartTime(sendTimeMs)
+		if readTime > 0 {
+			quizState.SetCurrentQuestionReadPhase(answersOpenAtMs)
+		} else {
+			quizState.SetCurrentQuestionStartTime(sendTimeMs)
+		}
 		// ===>>> КОНЕЦ ИЗМЕНЕНИЯ <<<===
 
 		// Отправляем вопрос всем участникам
-		questionEvent := map[string]interface{}{
-			"question_id":      question.ID,
-			"quiz_id":          quizState.Quiz.ID,
-			"number":           i + 1,
-			"text":             question.Text,
-			"options":          helper.ConvertOptionsToObjects(question.Options),
-			"time_limit":       question.TimeLimitSec,
-			"total_questions":  len(quizState.Quiz.Questions),
-			"start_time":       sendTimeMs,
-			"server_timestamp": sendTimeMs,
+		questionEvent := questionEventData(quizState.Quiz, &question, i+1, sendTimeMs)
+		if readTime > 0 {
+			markReadPhase(questionEvent, quizState.Quiz, &question, answersOpenAtMs)
 		}
+		markReplay(quizState, questionEvent)
 
 		// Отправка с повторными попытками при ошибке
 		if err := qm.sendEventWithRetry(quizCtx, quizState.Quiz.ID, "quiz:question", questionEvent); err != nil {
@@ -198,45 +234,116 @@ func (qm *QuestionManager) RunQuizQuestions(ctx context.Context, quizState *Acti
 			return err // Прерываем выполнение викторины
 		}
 
-		// Сохраняем время начала вопроса для подсчета времени ответа
-		questionStartKey := fmt.Sprintf("question:%d:start_time", question.ID)
-		// Логируем ошибку Redis, но не прерываем викторину
-		if err := qm.deps.CacheRepo.Set(questionStartKey, fmt.Sprintf("%d", sendTimeMs), time.Hour); err != nil {
-			log.Printf("[QuestionManager] WARNING: Не удалось сохранить время начала вопроса #%d в Redis: %v", question.ID, err)
+		if readTime > 0 {
+			// Прием ответов открывается точно в объявленное answers_open_at,
+			// чтобы ends_at_ms из quiz:question был настоящим сроком ответа
+			select {
+			case <-time.After(time.Until(time.UnixMilli(answersOpenAtMs))):
+			case <-quizCtx.Done():
+				log.Printf("[QuestionManager] Процесс викторины #%d был прерван во время чтения вопроса #%d",
+					quizState.Quiz.ID, i+1)
+				return nil
+			}
+
+			// Время на ответ отсчитывается от открытия приема ответов
+			sendTimeMs = answersOpenAtMs
+			quizState.SetCurrentQuestionStartTime(sendTimeMs)
+			openEvent := map[string]interface{}{
+				"quiz_id":          quizState.Quiz.ID,
+				"question_id":      question.ID,
+				"time_limit":       question.TimeLimitSec,
+				"start_time":       sendTimeMs,
+				"ends_at_ms":       answerDeadlineMs(&question, sendTimeMs),
+				"server_timestamp": time.Now().UnixMilli(),
+			}
+			markReplay(quizState, openEvent)
+			if err := qm.sendEventWithRetry(quizCtx, quizState.Quiz.ID, "quiz:answer_window_open", openEvent); err != nil {
+				log.Printf("[QuestionManager] ФАТАЛЬНАЯ ОШИБКА при открытии приема ответов на вопрос #%d викторины #%d: %v. Прерывание викторины.",
+					question.ID, quizState.Quiz.ID, err)
+				return err
+			}
+		}
+
+		// Сохраняем время начала вопроса для подсчета времени ответа.
+		// В повторе ответы не засчитываются, время не нужно.
+		if !quizState.Replay {
+			questionStartKey := fmt.Sprintf("quiz:%d:question:%d:start_time", quizState.Quiz.ID, question.ID)
+			// Логируем ошибку Redis, но не прерываем викторину
+			if err := qm.deps.CacheRepo.Set(questionStartKey, fmt.Sprintf("%d", sendTimeMs), time.Hour); err != nil {
+				log.Printf("[QuestionManager] WARNING: Не удалось сохранить время начала вопроса #%d в Redis: %v", question.ID, err)
+			}
 		}
 
-		// Запускаем таймер для вопроса
+		// Запускаем таймер для вопроса. При досрочном закрытии он останавливается,
+		// чтобы не рассылать quiz:timer по уже закрытому вопросу.
 		timeLimit := time.Duration(question.TimeLimitSec) * time.Second
 		endTime := time.Now().Add(timeLimit)
+		questionCtx, stopQuestionTimer := context.WithCancel(quizCtx)
 		timerWg.Add(1)
-		go qm.runQuestionTimer(quizCtx, quizState.Quiz, &question, i+1, endTime, &timerWg)
-
-		// Ждем завершения времени на вопрос
+		go qm.runQuestionTimer(questionCtx, quizState, &question, i+1, endTime, &timerWg)
+
+		// Ждем завершения времени на вопрос или ответов всех активных игроков.
+		// При отсчете времени от подтверждения получения вопроса ответы
+		// принимаются дольше на максимальную задержку подтверждения.
+		answerWindow := timeLimit
+		if quizState.Quiz.AnswerWindowFromAck {
+			answerWindow += time.Duration(qm.config.MaxQuestionAckOffsetMs) * time.Millisecond
+		}
+		questionTimer := time.NewTimer(answerWindow)
+		closedEarly := false
 		select {
-		case <-time.After(timeLimit):
+		case <-questionTimer.C:
 			// Продолжаем
 			log.Printf("[QuestionManager] Время на вопрос #%d (%d из %d) истекло",
 				question.ID, i+1, len(quizState.Quiz.Questions))
+		case <-allAnswered:
+			closedEarly = true
+			quizState.Answers().CloseEarly(question.ID, time.Now().UnixMilli())
+			log.Printf("[QuestionManager] Все активные игроки ответили на вопрос #%d (%d из %d), вопрос закрыт досрочно",
+				question.ID, i+1, len(quizState.Quiz.Questions))
 		case <-quizCtx.Done():
+			questionTimer.Stop()
+			stopQuestionTimer()
 			log.Printf("[QuestionManager] Процесс викторины #%d был прерван на вопросе #%d",
 				quizState.Quiz.ID, i+1)
 			return nil
 		}
+		questionTimer.Stop()
+		stopQuestionTimer()
 
 		// Добавляем задержку перед отправкой правильного ответа
 		time.Sleep(time.Duration(qm.config.AnswerRevealDelayMs) * time.Millisecond)
 
+		// Принятые ответы должны быть в БД до раскрытия: по ним строятся
+		// таблица лидеров и восстановление состояния после переподключения
+		if qm.beforeAnswerReveal != nil {
+			qm.beforeAnswerReveal(quizCtx, question.ID)
+		}
+
 		// Отправляем правильный ответ всем участникам
 		answerRevealEvent := map[string]interface{}{
 			"question_id":    question.ID,
 			"correct_option": question.CorrectOption,
 		}
+		if question.IsOrdering() {
+			answerRevealEvent["correct_order"] = []int(question.CorrectOrder)
+		}
+		if closedEarly {
+			answerRevealEvent["closed_early"] = true
+		}
+		markReplay(quizState, answerRevealEvent)
 
 		// Отправка с повторными попытками
 		// Логируем ошибку, но не прерываем викторину, т.к. ответ уже не критичен
 		if err := qm.sendEventWithRetry(quizCtx, quizState.Quiz.ID, "quiz:answer_reveal", answerRevealEvent); err != nil {
 			log.Printf("[QuestionManager] WARNING: Не удалось отправить ответ на вопрос #%d: %v", question.ID, err)
 		}
+		quizState.MarkCurrentQuestionRevealed()
+
+		// Вопрос закрыт - рассылаем отложенные результаты
+		if qm.onAnswerReveal != nil && !quizState.Replay {
+			qm.onAnswerReveal(question.ID)
+		}
 
 		// Увеличиваем паузу между вопросами
 		if i < len(quizState.Quiz.Questions)-1 {
@@ -259,12 +366,19 @@ func (qm *QuestionManager) RunQuizQuestions(ctx context.Context, quizState *Acti
 	// Очищаем текущий вопрос
 	quizState.ClearCurrentQuestion()
 
-	// Отправляем сигнал о завершении всех вопросов
+	// Повтор не завершает викторину: результаты не пересчитываются
+	if quizState.Replay {
+		log.Printf("[QuestionManager] Повтор викторины #%d завершен", quizState.Quiz.ID)
+		return nil
+	}
+
+	// Отправляем сигнал о завершении всех вопросов. Сигнал нельзя отбросить:
+	// без него викторина останется активной до срабатывания сторожевого таймера.
 	select {
-	case qm.questionDoneCh <- struct{}{}:
+	case qm.questionDoneCh <- quizState.Quiz.ID:
 		log.Printf("[QuestionManager] Сигнал о завершении вопросов для викторины #%d отправлен", quizState.Quiz.ID)
-	default:
-		log.Printf("[QuestionManager] Сигнал о завершении вопросов уже был отправлен для викторины #%d", quizState.Quiz.ID)
+	case <-quizCtx.Done():
+		log.Printf("[QuestionManager] Викторина #%d завершена до отправки сигнала о завершении вопросов", quizState.Quiz.ID)
 	}
 
 	return nil
@@ -273,13 +387,14 @@ func (qm *QuestionManager) RunQuizQuestions(ctx context.Context, quizState *Acti
 // runQuestionTimer запускает таймер для вопроса и отправляет обновления
 func (qm *QuestionManager) runQuestionTimer(
 	ctx context.Context,
-	quiz *entity.Quiz,
+	quizState *ActiveQuizState,
 	question *entity.Question,
 	questionNumber int,
 	endTime time.Time,
 	wg *sync.WaitGroup,
 ) {
 	defer wg.Done()
+	quiz := quizState.Quiz
 
 	// Создаем отдельный контекст для этого таймера
 	timerCtx, timerCancel := context.WithCancel(ctx)
@@ -306,6 +421,7 @@ func (qm *QuestionManager) runQuestionTimer(
 				"remaining_seconds": remaining,
 				"server_timestamp":  time.Now().UnixNano() / int64(time.Millisecond),
 			}
+			markReplay(quizState, timerData)
 			timerFullEvent := map[string]interface{}{
 				"type": "quiz:timer",
 				"data": timerData,
@@ -326,6 +442,55 @@ func (qm *QuestionManager) runQuestionTimer(
 	}
 }
 
+// questionEventData формирует данные события quiz:question
+func questionEventData(quiz *entity.Quiz, question *entity.Question, number int, startMs int64) map[string]interface{} {
+	data := map[string]interface{}{
+		"question_id":      question.ID,
+		"quiz_id":          quiz.ID,
+		"type":             question.Type,
+		"number":           number,
+		"text":             question.Text,
+		"options":          helper.ConvertOptionsToObjects(question.Options),
+		"time_limit":       question.TimeLimitSec,
+		"point_value":      quiz.EffectivePointValue(question),
+		"total_questions":  len(quiz.Questions),
+		"start_time":       startMs,
+		"ends_at_ms":       answerDeadlineMs(question, startMs),
+		"server_timestamp": startMs,
+	}
+	if quiz.AnswerWindowFromAck {
+		// Время на ответ отсчитывается от подтверждения получения (quiz:question_received)
+		data["ack_required"] = true
+	}
+	if quiz.DifficultyScaling {
+		// point_value уже умножена на множитель сложности
+		data["difficulty"] = question.Difficulty
+	}
+	return data
+}
+
+// answerDeadlineMs возвращает срок ответа на вопрос (Unix, мс), если прием
+// ответов открыт в startMs. Ответ после срока считается опоздавшим.
+func answerDeadlineMs(question *entity.Question, startMs int64) int64 {
+	return startMs + int64(question.TimeLimitSec)*1000
+}
+
+// markReadPhase дополняет quiz:question временем на чтение: до answersOpenAtMs
+// ответы не принимаются, таймер запускается событием quiz:answer_window_open,
+// а срок ответа отсчитывается от открытия приема ответов
+func markReadPhase(data map[string]interface{}, quiz *entity.Quiz, question *entity.Question, answersOpenAtMs int64) {
+	data["read_time_sec"] = quiz.QuestionReadTimeSec
+	data["answers_open_at"] = answersOpenAtMs
+	data["ends_at_ms"] = answerDeadlineMs(question, answersOpenAtMs)
+}
+
+// markReplay помечает событие повтора, чтобы клиент не спутал его с живой викториной
+func markReplay(quizState *ActiveQuizState, data map[string]interface{}) {
+	if quizState.Replay {
+		data["replay"] = true
+	}
+}
+
 // --- Вспомогательная функция для отправки событий с ретраями ---
 
 // sendEventWithRetry пытается отправить событие через WSManager с заданным количеством попыток.
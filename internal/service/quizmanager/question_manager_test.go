@@ -0,0 +1,532 @@
+package quizmanager
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourusername/trivia-api/internal/domain/entity"
+	"github.com/yourusername/trivia-api/internal/websocket"
+)
+
+// TestRunQuizQuestions_ConcurrentAnswers проводит викторину и одновременно
+// отправляет ответы из нескольких горутин. Запускать с -race: гонки на текущем
+// вопросе и времени его старта приводят к подсчету очков по чужому вопросу.
+func TestRunQuizQuestions_ConcurrentAnswers(t *testing.T) {
+	config := DefaultConfig()
+	config.QuestionDelayMs = 20
+	config.AnswerRevealDelayMs = 10
+	config.InterQuestionDelayMs = 20
+
+	deps := &Dependencies{
+		CacheRepo:  newJoinedCache(),
+		ResultRepo: &memoryResults{},
+		WSManager:  websocket.NewManager(&recordingHub{}),
+	}
+
+	quiz := &entity.Quiz{ID: 1, Title: "race"}
+	for id := uint(1); id <= 3; id++ {
+		quiz.Questions = append(quiz.Questions, entity.Question{
+			ID:            id,
+			QuizID:        1,
+			Options:       entity.StringArray{"a", "b"},
+			CorrectOption: 1,
+			TimeLimitSec:  1,
+			PointValue:    10,
+		})
+	}
+
+	state := NewActiveQuizState(quiz)
+	qm := NewQuestionManager(config, deps)
+	ap := NewAnswerProcessor(config, deps)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		assert.NoError(t, qm.RunQuizQuestions(ctx, state))
+	}()
+
+	var (
+		mu       sync.Mutex
+		starts   = make(map[uint]int64) // questionID -> время старта из принятых ответов
+		accepted atomic.Int64
+		wg       sync.WaitGroup
+	)
+
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func(worker uint) {
+			defer wg.Done()
+			userID := worker * 1000
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+
+				question, _ := state.GetCurrentQuestion()
+				if question == nil {
+					time.Sleep(time.Millisecond)
+					continue
+				}
+
+				userID++
+				sub, err := ap.PrepareSubmission(userID, question.ID, 1, time.Now().UnixMilli(), state)
+				if err != nil {
+					continue
+				}
+				assert.Equal(t, sub.QuestionID, sub.Question.ID, "снимок вопроса не совпадает с вопросом ответа")
+				assert.NotZero(t, sub.QuestionStartMs)
+
+				mu.Lock()
+				if start, ok := starts[sub.QuestionID]; ok {
+					assert.Equal(t, start, sub.QuestionStartMs, "ответ на вопрос #%d засчитан по времени старта другого вопроса", sub.QuestionID)
+				} else {
+					starts[sub.QuestionID] = sub.QuestionStartMs
+				}
+				mu.Unlock()
+
+				if err := ap.ProcessSubmission(ctx, sub); err == nil {
+					accepted.Add(1)
+				}
+			}
+		}(uint(w + 1))
+	}
+
+	<-done
+	wg.Wait()
+
+	assert.Positive(t, accepted.Load(), "ни один ответ не был принят")
+	assert.Len(t, starts, len(quiz.Questions))
+
+	question, number, start := state.CurrentQuestionSnapshot()
+	assert.Nil(t, question)
+	assert.Zero(t, number)
+	assert.Zero(t, start)
+}
+
+func TestActiveQuizState_SetCurrentQuestionResetsStartTime(t *testing.T) {
+	state := NewActiveQuizState(&entity.Quiz{ID: 1})
+
+	state.SetCurrentQuestion(&entity.Question{ID: 1}, 1)
+	state.SetCurrentQuestionStartTime(1000)
+	state.SetCurrentQuestion(&entity.Question{ID: 2}, 2)
+
+	question, number, start := state.CurrentQuestionSnapshot()
+	assert.Equal(t, uint(2), question.ID)
+	assert.Equal(t, 2, number)
+	assert.Zero(t, start, "время старта предыдущего вопроса не должно переноситься на новый")
+}
+
+// TestJoinInProgress_JoinAtQuestionThree: игрок присоединяется на 3-м вопросе из 5,
+// сразу получает открытый вопрос и отвечает на него, очки считаются с этого вопроса
+func TestJoinInProgress_JoinAtQuestionThree(t *testing.T) {
+	hub := &recordingHub{}
+	cache := newJoinedCache()
+	deps := &Dependencies{
+		CacheRepo:  cache,
+		ResultRepo: &memoryResults{},
+		WSManager:  websocket.NewManager(hub),
+	}
+	qm := NewQuestionManager(DefaultConfig(), deps)
+	ap := NewAnswerProcessor(DefaultConfig(), deps)
+
+	quiz := &entity.Quiz{ID: 1, JoinPolicy: entity.JoinPolicyAnytime}
+	for id := uint(1); id <= 5; id++ {
+		quiz.Questions = append(quiz.Questions, entity.Question{
+			ID:            id,
+			QuizID:        1,
+			Options:       entity.StringArray{"a", "b"},
+			CorrectOption: 1,
+			TimeLimitSec:  10,
+			PointValue:    10,
+		})
+	}
+	state := NewActiveQuizState(quiz)
+
+	// До первого вопроса присоединение обычное
+	joinedAt, err := qm.JoinInProgress(7, state)
+	assert.NoError(t, err)
+	assert.Zero(t, joinedAt)
+
+	state.SetCurrentQuestion(&quiz.Questions[2], 3)
+	state.SetCurrentQuestionStartTime(time.Now().Add(-2 * time.Second).UnixMilli())
+
+	joinedAt, err = qm.JoinInProgress(7, state)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, joinedAt)
+	assert.Equal(t, 3, JoinedAtQuestion(cache, 1, 7))
+	assert.Equal(t, []string{"7:quiz:question"}, hub.Events(), "снимок текущего вопроса")
+	assert.Equal(t, 3, quiz.QuestionsAvailableFrom(joinedAt))
+
+	// Повторное присоединение не сдвигает точку входа
+	state.SetCurrentQuestion(&quiz.Questions[3], 4)
+	state.SetCurrentQuestionStartTime(time.Now().UnixMilli())
+	joinedAt, err = qm.JoinInProgress(7, state)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, joinedAt)
+
+	// Ответ на текущий вопрос принимается как обычно
+	sub, err := ap.PrepareSubmission(7, 4, 1, time.Now().UnixMilli(), state)
+	if assert.NoError(t, err) {
+		assert.NoError(t, ap.ProcessSubmission(context.Background(), sub))
+	}
+}
+
+func TestJoinInProgress_Policies(t *testing.T) {
+	cache := &memoryCache{data: make(map[string]string)}
+	deps := &Dependencies{
+		CacheRepo: cache,
+		WSManager: websocket.NewManager(&recordingHub{}),
+	}
+	qm := NewQuestionManager(DefaultConfig(), deps)
+
+	question := entity.Question{ID: 1, TimeLimitSec: 10}
+	quiz := &entity.Quiz{ID: 1, JoinPolicy: entity.JoinPolicyBeforeStartOnly, Questions: []entity.Question{question, {ID: 2}}}
+	state := NewActiveQuizState(quiz)
+	state.SetCurrentQuestion(&quiz.Questions[0], 1)
+	state.SetCurrentQuestionStartTime(time.Now().Add(-11 * time.Second).UnixMilli())
+
+	_, err := qm.JoinInProgress(7, state)
+	assert.ErrorIs(t, err, ErrLateJoinNotAllowed)
+
+	// Отметившийся до старта игрок переподключается без ограничений
+	cache.data["quiz:1:ready_users:8"] = "1"
+	joinedAt, err := qm.JoinInProgress(8, state)
+	assert.NoError(t, err)
+	assert.Zero(t, joinedAt)
+
+	// Время текущего вопроса истекло - игрок участвует со следующего
+	quiz.JoinPolicy = entity.JoinPolicyAnytime
+	joinedAt, err = qm.JoinInProgress(9, state)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, joinedAt)
+}
+
+func TestAnswerTracker_AllActivePlayersAnswered(t *testing.T) {
+	tracker := NewAnswerTracker()
+
+	// Без активных игроков вопрос досрочно не закрывается
+	allAnswered := tracker.StartQuestion(1)
+	tracker.RecordAnswer(7, 1, false)
+	assert.False(t, isClosed(allAnswered))
+
+	tracker.AddPlayer(7)
+	tracker.AddPlayer(8)
+	allAnswered = tracker.StartQuestion(2)
+	tracker.RecordAnswer(7, 1, false) // Ответ на прошлый вопрос не учитывается
+	tracker.RecordAnswer(7, 2, false)
+	assert.False(t, isClosed(allAnswered))
+	tracker.RecordAnswer(8, 2, true)
+	assert.True(t, isClosed(allAnswered))
+	assert.Equal(t, 1, tracker.ActivePlayers(), "выбывший игрок больше не ожидается")
+
+	// Отключение последнего не ответившего игрока закрывает вопрос
+	tracker.AddPlayer(9)
+	allAnswered = tracker.StartQuestion(3)
+	tracker.RecordAnswer(7, 3, false)
+	assert.False(t, isClosed(allAnswered))
+	tracker.RemovePlayer(9)
+	assert.True(t, isClosed(allAnswered))
+
+	tracker.CloseEarly(3, 1000)
+	assert.False(t, tracker.ClosedBefore(3, 1000))
+	assert.True(t, tracker.ClosedBefore(3, 1001))
+	assert.False(t, tracker.ClosedBefore(2, 5000), "вопрос, закрытый по таймеру")
+}
+
+// TestAnswerTracker_DisconnectGrace: отключившийся игрок ожидается, пока не
+// истечет время на переподключение; вернувшись, он снова обычный активный игрок
+func TestAnswerTracker_DisconnectGrace(t *testing.T) {
+	tracker := NewAnswerTracker()
+	tracker.AddPlayer(7)
+	tracker.AddPlayer(8)
+
+	// Игрок #8 переподключается до истечения ожидания и отвечает по новому соединению
+	allAnswered := tracker.StartQuestion(1)
+	tracker.RemovePlayerAfter(8, 30*time.Millisecond)
+	tracker.RecordAnswer(7, 1, false)
+	assert.False(t, isClosed(allAnswered), "вопрос ждет переподключения")
+	tracker.AddPlayer(8)
+	time.Sleep(60 * time.Millisecond)
+	assert.False(t, isClosed(allAnswered), "вернувшийся игрок не исключается по старому отключению")
+	tracker.RecordAnswer(8, 1, false)
+	assert.True(t, isClosed(allAnswered))
+	assert.Equal(t, 2, tracker.ActivePlayers())
+
+	// Не вернувшийся игрок исключается по истечении ожидания
+	allAnswered = tracker.StartQuestion(2)
+	tracker.RemovePlayerAfter(8, 30*time.Millisecond)
+	tracker.RecordAnswer(7, 2, false)
+	assert.Eventually(t, func() bool { return isClosed(allAnswered) }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, 1, tracker.ActivePlayers())
+}
+
+// TestRunQuizQuestions_AutoAdvance: вопросы с лимитом 10 секунд закрываются, как
+// только ответили все активные игроки, в том числе после отключения игрока
+func TestRunQuizQuestions_AutoAdvance(t *testing.T) {
+	config := DefaultConfig()
+	config.QuestionDelayMs = 10
+	config.AnswerRevealDelayMs = 5
+	config.InterQuestionDelayMs = 10
+
+	deps := &Dependencies{
+		CacheRepo:  newJoinedCache(),
+		ResultRepo: &memoryResults{},
+		WSManager:  websocket.NewManager(&recordingHub{}),
+	}
+
+	quiz := &entity.Quiz{ID: 1, Title: "auto", AutoAdvance: true}
+	for id := uint(1); id <= 2; id++ {
+		quiz.Questions = append(quiz.Questions, entity.Question{
+			ID:            id,
+			QuizID:        1,
+			Options:       entity.StringArray{"a", "b"},
+			CorrectOption: 1,
+			TimeLimitSec:  10,
+			PointValue:    10,
+		})
+	}
+
+	state := NewActiveQuizState(quiz)
+	state.Answers().AddPlayer(7)
+	state.Answers().AddPlayer(8)
+	qm := NewQuestionManager(config, deps)
+	ap := NewAnswerProcessor(config, deps)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	started := time.Now()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		assert.NoError(t, qm.RunQuizQuestions(ctx, state))
+	}()
+
+	answer := func(userID, questionID uint) {
+		assert.Eventually(t, func() bool {
+			sub, err := ap.PrepareSubmission(userID, questionID, 1, time.Now().UnixMilli(), state)
+			return err == nil && ap.ProcessSubmission(ctx, sub) == nil
+		}, time.Second, 5*time.Millisecond, "ответ пользователя #%d на вопрос #%d не принят", userID, questionID)
+	}
+
+	answer(7, 1)
+	answer(8, 1)
+	// На втором вопросе один игрок отвечает, другой отключается
+	answer(7, 2)
+	state.Answers().RemovePlayer(8)
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("вопросы не закрылись досрочно")
+	}
+	assert.Less(t, time.Since(started), 5*time.Second)
+
+	// Ответ после досрочного закрытия считается опоздавшим
+	sub := testSubmission(9, 2, false)
+	sub.QuestionID, sub.Question.ID = 2, 2
+	sub.QuizID = 1
+	sub.ReceivedAtMs = time.Now().UnixMilli()
+	sub.Answers = state.Answers()
+	assert.NoError(t, ap.ProcessSubmission(ctx, sub))
+	results := deps.ResultRepo.(*memoryResults)
+	last := results.answers[len(results.answers)-1]
+	assert.Equal(t, uint(9), last.UserID)
+	assert.Equal(t, "time_exceeded", last.EliminationReason)
+}
+
+// TestRunQuizQuestions_ReadPhase: при времени на чтение вопрос показан, но ответы
+// отклоняются до открытия приема, а время на ответ отсчитывается от открытия
+func TestRunQuizQuestions_ReadPhase(t *testing.T) {
+	config := DefaultConfig()
+	config.QuestionDelayMs = 10
+	config.AnswerRevealDelayMs = 5
+	config.InterQuestionDelayMs = 10
+
+	deps := &Dependencies{
+		CacheRepo:  newJoinedCache(),
+		ResultRepo: &memoryResults{},
+		WSManager:  websocket.NewManager(&recordingHub{}),
+	}
+
+	quiz := &entity.Quiz{ID: 1, Title: "read", AutoAdvance: true, QuestionReadTimeSec: 1}
+	quiz.Questions = []entity.Question{{
+		ID:            1,
+		QuizID:        1,
+		Options:       entity.StringArray{"a", "b"},
+		CorrectOption: 1,
+		TimeLimitSec:  10,
+		PointValue:    10,
+	}}
+
+	state := NewActiveQuizState(quiz)
+	state.Answers().AddPlayer(7)
+	qm := NewQuestionManager(config, deps)
+	ap := NewAnswerProcessor(config, deps)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		assert.NoError(t, qm.RunQuizQuestions(ctx, state))
+	}()
+
+	var readUntilMs int64
+	require.Eventually(t, func() bool {
+		readUntilMs = state.CurrentQuestionReadUntil()
+		return readUntilMs > 0
+	}, time.Second, time.Millisecond, "вопрос не перешел в фазу чтения")
+
+	_, err := ap.PrepareSubmission(7, 1, 1, time.Now().UnixMilli(), state)
+	assert.ErrorIs(t, err, ErrQuestionReadPhase)
+
+	var sub *AnswerSubmission
+	require.Eventually(t, func() bool {
+		sub, err = ap.PrepareSubmission(7, 1, 1, time.Now().UnixMilli(), state)
+		return err == nil
+	}, 3*time.Second, 5*time.Millisecond, "прием ответов не открылся")
+	assert.Equal(t, readUntilMs, sub.QuestionStartMs, "время на ответ отсчитывается от объявленного открытия приема")
+	require.NoError(t, ap.ProcessSubmission(ctx, sub))
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("вопрос не закрылся после ответа")
+	}
+}
+
+// TestQuestionEventData_EndsAt: срок ответа в quiz:question отсчитывается от
+// открытия приема ответов, а при времени на чтение - от answers_open_at
+func TestQuestionEventData_EndsAt(t *testing.T) {
+	quiz := &entity.Quiz{ID: 1, QuestionReadTimeSec: 3}
+	question := &entity.Question{ID: 5, TimeLimitSec: 15}
+	quiz.Questions = []entity.Question{*question}
+
+	data := questionEventData(quiz, question, 1, 1_000_000)
+	assert.Equal(t, int64(1_015_000), data["ends_at_ms"])
+
+	markReadPhase(data, quiz, question, 1_003_000)
+	assert.Equal(t, int64(1_003_000), data["answers_open_at"])
+	assert.Equal(t, int64(1_018_000), data["ends_at_ms"], "время на чтение сдвигает срок ответа")
+}
+
+func isClosed(ch <-chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
+
+type presenceEvent struct {
+	eventType string
+	data      map[string]interface{}
+}
+
+func newTestPresenceFeed(threshold int, window time.Duration) (*PresenceFeed, func() []presenceEvent) {
+	config := DefaultConfig()
+	config.PresenceBurstThreshold = threshold
+	config.PresenceWindow = window
+	feed := NewPresenceFeed(config, &Dependencies{CacheRepo: &memoryCache{data: make(map[string]string)}})
+
+	var mu sync.Mutex
+	var events []presenceEvent
+	feed.emit = func(quizID uint, eventType string, data map[string]interface{}) {
+		mu.Lock()
+		events = append(events, presenceEvent{eventType, data})
+		mu.Unlock()
+	}
+	return feed, func() []presenceEvent {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]presenceEvent(nil), events...)
+	}
+}
+
+// TestPresenceFeed_JoinLeave проверяет, что игрок входит в комнату первым
+// соединением и выходит последним, а повторный вход соединения не дает события
+func TestPresenceFeed_JoinLeave(t *testing.T) {
+	feed, events := newTestPresenceFeed(20, time.Second)
+
+	feed.Join(1, 7, "conn-a", "alice")
+	feed.Join(1, 7, "conn-a", "alice")
+	feed.Join(1, 7, "conn-b", "alice")
+	feed.Join(1, 8, "conn-c", "bob")
+	assert.Equal(t, int64(2), feed.Count(1))
+
+	feed.Leave(1, "conn-a", LeaveReasonDisconnected)
+	feed.Leave(1, "conn-unknown", LeaveReasonDisconnected)
+	assert.Equal(t, int64(2), feed.Count(1), "у игрока осталось второе соединение")
+	feed.Leave(1, "conn-b", LeaveReasonLeft)
+	feed.LeaveUser(1, 8, LeaveReasonKicked)
+	assert.Equal(t, int64(0), feed.Count(1))
+
+	got := events()
+	if assert.Len(t, got, 4) {
+		assert.Equal(t, "quiz:player_joined", got[0].eventType)
+		assert.Equal(t, "alice", got[0].data["display_name"])
+		assert.Equal(t, int64(1), got[0].data["count"])
+		assert.Equal(t, "quiz:player_joined", got[1].eventType)
+		assert.Equal(t, int64(2), got[1].data["count"])
+		assert.Equal(t, "quiz:player_left", got[2].eventType)
+		assert.Equal(t, uint(7), got[2].data["user_id"])
+		assert.Equal(t, LeaveReasonLeft, got[2].data["reason"])
+		assert.Equal(t, int64(1), got[2].data["count"])
+		assert.Equal(t, LeaveReasonKicked, got[3].data["reason"])
+		assert.Equal(t, int64(0), got[3].data["count"])
+	}
+}
+
+// TestPresenceFeed_CountersExpire проверяет, что счетчики комнаты получают срок хранения
+func TestPresenceFeed_CountersExpire(t *testing.T) {
+	cache := &memoryCache{data: make(map[string]string)}
+	feed := NewPresenceFeed(DefaultConfig(), &Dependencies{CacheRepo: cache})
+	feed.emit = func(quizID uint, eventType string, data map[string]interface{}) {}
+
+	feed.Join(1, 7, "conn-a", "alice")
+	feed.Leave(1, "conn-a", LeaveReasonLeft)
+
+	for _, key := range []string{presenceJoinedKey(1), presenceLeftKey(1)} {
+		expireAt, ok := cache.expires[key]
+		require.True(t, ok, key)
+		assert.WithinDuration(t, time.Now().Add(presenceCounterTTL), expireAt, time.Minute)
+	}
+}
+
+// TestPresenceFeed_BurstCoalesced проверяет, что сверх порога события окна
+// сворачиваются в одно сводное quiz:presence_update
+func TestPresenceFeed_BurstCoalesced(t *testing.T) {
+	feed, events := newTestPresenceFeed(3, 100*time.Millisecond)
+
+	for userID := uint(1); userID <= 10; userID++ {
+		feed.Join(1, userID, fmt.Sprintf("conn-%d", userID), "player")
+	}
+	feed.Leave(1, "conn-1", LeaveReasonDisconnected)
+	assert.Len(t, events(), 3, "сверх порога отдельные события не отправляются")
+
+	assert.Eventually(t, func() bool { return len(events()) == 4 }, time.Second, 10*time.Millisecond)
+	update := events()[3]
+	assert.Equal(t, "quiz:presence_update", update.eventType)
+	assert.Equal(t, 7, update.data["joined"])
+	assert.Equal(t, 1, update.data["left"])
+	assert.Equal(t, int64(9), update.data["count"])
+
+	// В новом окне события снова отправляются по отдельности
+	feed.Join(1, 11, "conn-11", "late")
+	got := events()
+	assert.Equal(t, "quiz:player_joined", got[len(got)-1].eventType)
+}
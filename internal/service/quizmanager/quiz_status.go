@@ -0,0 +1,24 @@
+package quizmanager
+
+import (
+	"fmt"
+
+	"github.com/yourusername/trivia-api/internal/domain/entity"
+	"github.com/yourusername/trivia-api/internal/domain/repository"
+)
+
+// UpdateQuizStatus переводит викторину в статус to. Переход проверяется по
+// entity.ValidateStatusTransition, а в БД статус меняется, только если он
+// не изменился с момента чтения quiz (например, викторину не отменили). При
+// недопустимом переходе возвращается ошибка с entity.ErrInvalidStatusTransition.
+// Через эту функцию проходят все смены статуса запуска, отмены и завершения.
+func UpdateQuizStatus(repo repository.QuizRepository, quiz *entity.Quiz, to entity.QuizStatus) error {
+	if err := entity.ValidateStatusTransition(quiz.Status, to); err != nil {
+		return fmt.Errorf("quiz %d: %w", quiz.ID, err)
+	}
+	if err := repo.UpdateStatus(quiz.ID, quiz.Status, to); err != nil {
+		return err
+	}
+	quiz.Status = to
+	return nil
+}
@@ -0,0 +1,105 @@
+package quizmanager
+
+import (
+	"fmt"
+	"log"
+	"sync"
+)
+
+// DeferredEvent описывает персональное событие, отправка которого отложена
+type DeferredEvent struct {
+	UserID    uint
+	EventType string
+	Data      map[string]interface{}
+}
+
+// ResultBuffer накапливает результаты ответов для викторин с отложенными
+// результатами (Quiz.DelayedResults). Результаты по вопросу отправляются
+// одним пакетом при его закрытии; ответы, обработанные после закрытия,
+// отправляются сразу.
+type ResultBuffer struct {
+	mu      sync.Mutex
+	pending map[uint][]DeferredEvent // questionID -> события в порядке поступления
+	closed  map[uint]bool            // вопросы, результаты которых уже раскрыты
+
+	send func(userID string, eventType string, data interface{}) error
+}
+
+// NewResultBuffer создает буфер с функцией отправки события пользователю
+func NewResultBuffer(send func(userID string, eventType string, data interface{}) error) *ResultBuffer {
+	return &ResultBuffer{
+		pending: make(map[uint][]DeferredEvent),
+		closed:  make(map[uint]bool),
+		send:    send,
+	}
+}
+
+// Defer откладывает события до закрытия вопроса. Возвращает false, если вопрос
+// уже закрыт - в этом случае события нужно отправить сразу (см. SendNow).
+func (b *ResultBuffer) Defer(questionID uint, events ...DeferredEvent) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed[questionID] {
+		return false
+	}
+	b.pending[questionID] = append(b.pending[questionID], events...)
+	return true
+}
+
+// Flush закрывает вопрос и отправляет все накопленные по нему события.
+// Возвращает количество отправленных событий.
+func (b *ResultBuffer) Flush(questionID uint) int {
+	b.mu.Lock()
+	events := b.pending[questionID]
+	delete(b.pending, questionID)
+	b.closed[questionID] = true
+	b.mu.Unlock()
+
+	b.SendNow(events...)
+	return len(events)
+}
+
+// Release забывает перечисленные вопросы завершенной викторины, чтобы состояние
+// буфера не росло с каждой проведенной викториной. Не раскрытые к этому моменту
+// события отправляются. Возвращает количество отправленных событий.
+func (b *ResultBuffer) Release(questionIDs ...uint) int {
+	b.mu.Lock()
+	var events []DeferredEvent
+	for _, id := range questionIDs {
+		events = append(events, b.pending[id]...)
+		delete(b.pending, id)
+		delete(b.closed, id)
+	}
+	b.mu.Unlock()
+
+	b.SendNow(events...)
+	return len(events)
+}
+
+// SendNow отправляет события без задержки
+func (b *ResultBuffer) SendNow(events ...DeferredEvent) {
+	for _, e := range events {
+		if err := b.send(fmt.Sprintf("%d", e.UserID), e.EventType, e.Data); err != nil {
+			log.Printf("[ResultBuffer] Ошибка при отправке события %s пользователю #%d: %v", e.EventType, e.UserID, err)
+		}
+	}
+}
+
+// Discard очищает состояние перечисленных вопросов перед новым запуском викторины.
+// Буфер общий для всех активных викторин, поэтому очищаются только ее вопросы.
+// Неотправленные события этих вопросов отбрасываются.
+func (b *ResultBuffer) Discard(questionIDs ...uint) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	dropped := 0
+	for _, id := range questionIDs {
+		dropped += len(b.pending[id])
+		delete(b.pending, id)
+		delete(b.closed, id)
+	}
+	if dropped > 0 {
+		log.Printf("[ResultBuffer] Сброс буфера: отброшено %d неотправленных событий", dropped)
+	}
+}
@@ -2,6 +2,7 @@ package quizmanager
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"sync"
@@ -23,15 +24,29 @@ type Scheduler struct {
 
 	// Канал для сигнализации о запуске викторины
 	quizStartCh chan uint
+
+	// Число игроков в комнате викторины (задается QuizManager); nil - минимум
+	// игроков не проверяется
+	roomPlayers func(quizID uint) int64
+	// emit рассылает событие ожидания игроков в комнату викторины
+	emit func(quizID uint, eventType string, data map[string]interface{})
 }
 
 // NewScheduler создает новый планировщик викторин
 func NewScheduler(config *Config, deps *Dependencies) *Scheduler {
-	return &Scheduler{
+	s := &Scheduler{
 		config:      config,
 		deps:        deps,
 		quizStartCh: make(chan uint, 10), // Буферизованный канал для событий запуска
 	}
+	s.emit = s.broadcastToQuiz
+	return s
+}
+
+// SetRoomPlayerCounter задает источник числа игроков в комнате викторины для
+// проверки Quiz.MinPlayers при запуске
+func (s *Scheduler) SetRoomPlayerCounter(count func(quizID uint) int64) {
+	s.roomPlayers = count
 }
 
 // GetQuizStartChannel возвращает канал для уведомлений о запуске викторин
@@ -42,7 +57,7 @@ func (s *Scheduler) GetQuizStartChannel() <-chan uint {
 // ScheduleQuiz планирует запуск викторины в заданное время
 func (s *Scheduler) ScheduleQuiz(ctx context.Context, quizID uint, scheduledTime time.Time) error {
 	// Сразу проверяем, что время в будущем
-	if scheduledTime.Before(time.Now()) {
+	if scheduledTime.Before(time.Now().Add(-ScheduleClockSkew)) {
 		return fmt.Errorf("ошибка: scheduled time is in the past")
 	}
 
@@ -58,8 +73,10 @@ func (s *Scheduler) ScheduleQuiz(ctx context.Context, quizID uint, scheduledTime
 	}
 
 	// Устанавливаем время запуска
+	if err := quiz.TransitionTo(entity.QuizStatusScheduled); err != nil {
+		return err
+	}
 	quiz.ScheduledTime = scheduledTime
-	quiz.Status = "scheduled"
 
 	// Сохраняем изменения
 	if err := s.deps.QuizRepo.Update(quiz); err != nil {
@@ -87,9 +104,17 @@ func (s *Scheduler) CancelQuiz(quizID uint) error {
 		return err
 	}
 
-	// Проверяем, что викторина запланирована
+	// Проверяем, что викторина запланирована: переход in_progress -> cancelled
+	// допустим только для отклоненного запуска, а не для отмены
 	if !quiz.IsScheduled() {
-		return fmt.Errorf("quiz is not in scheduled state")
+		return fmt.Errorf("quiz %d: %w: only a scheduled quiz can be cancelled, status is %s",
+			quizID, entity.ErrInvalidStatusTransition, quiz.Status)
+	}
+
+	// Обновляем статус в БД до снятия таймеров: если викторина тем временем
+	// запустилась, отмена не выполняется
+	if err := UpdateQuizStatus(s.deps.QuizRepo, quiz, entity.QuizStatusCancelled); err != nil {
+		return err
 	}
 
 	// Получаем функцию отмены из map
@@ -105,11 +130,6 @@ func (s *Scheduler) CancelQuiz(quizID uint) error {
 		log.Printf("[Scheduler] Таймеры для викторины #%d отменены", quizID)
 	}
 
-	// Обновляем статус в БД
-	if err := s.deps.QuizRepo.UpdateStatus(quizID, "cancelled"); err != nil {
-		return err
-	}
-
 	// Отправляем уведомление пользователям
 	cancelEvent := map[string]interface{}{
 		"quiz_id": quizID,
@@ -332,12 +352,19 @@ func (s *Scheduler) triggerCountdown(ctx context.Context, quiz *entity.Quiz) {
 
 // triggerQuizStart запускает викторину
 func (s *Scheduler) triggerQuizStart(ctx context.Context, quiz *entity.Quiz) {
+	if !s.awaitMinPlayers(ctx, quiz) {
+		return
+	}
 	log.Printf("[Scheduler] Запуск викторины #%d", quiz.ID)
 
-	// Обновляем статус викторины в БД
-	if err := s.deps.QuizRepo.UpdateStatus(quiz.ID, "in_progress"); err != nil {
+	// Обновляем статус викторины в БД. Отмененная или уже запущенная викторина
+	// не запускается; при ошибке БД запуск продолжается, т.к. отмена уже невозможна
+	if err := UpdateQuizStatus(s.deps.QuizRepo, quiz, entity.QuizStatusInProgress); err != nil {
+		if errors.Is(err, entity.ErrInvalidStatusTransition) {
+			log.Printf("[Scheduler] Викторина #%d не запускается: %v", quiz.ID, err)
+			return
+		}
 		log.Printf("[Scheduler] Ошибка при обновлении статуса викторины #%d на in_progress: %v", quiz.ID, err)
-		// Продолжаем, т.к. отмена уже невозможна
 	}
 
 	// Отправляем событие запуска
@@ -364,3 +391,82 @@ func (s *Scheduler) triggerQuizStart(ctx context.Context, quiz *entity.Quiz) {
 		log.Printf("[Scheduler] Предупреждение: не удалось отправить сигнал о запуске викторины #%d в QuizManager (канал переполнен?)", quiz.ID)
 	}
 }
+
+// awaitMinPlayers проверяет, что в комнате викторины не меньше Quiz.MinPlayers
+// игроков. При политике wait запуск откладывается на время MinPlayersGrace с
+// повторной проверкой каждые MinPlayersCheckInterval; если игроков так и не
+// хватило (или политика cancel), викторина отменяется. Возвращает true, если
+// викторину можно запускать.
+func (s *Scheduler) awaitMinPlayers(ctx context.Context, quiz *entity.Quiz) bool {
+	if quiz.MinPlayers <= 0 || s.roomPlayers == nil {
+		return true
+	}
+	players := s.roomPlayers(quiz.ID)
+	if players >= int64(quiz.MinPlayers) {
+		return true
+	}
+	if !quiz.WaitsForMinPlayers() {
+		s.cancelForInsufficientPlayers(quiz, players)
+		return false
+	}
+
+	waitUntil := time.Now().Add(s.config.MinPlayersGrace)
+	log.Printf("[Scheduler] Викторина #%d: игроков %d из %d, запуск отложен до %v",
+		quiz.ID, players, quiz.MinPlayers, waitUntil.Format(time.RFC3339))
+	s.emit(quiz.ID, "quiz:start_delayed", map[string]interface{}{
+		"quiz_id":     quiz.ID,
+		"players":     players,
+		"min_players": quiz.MinPlayers,
+		"wait_until":  waitUntil.UnixMilli(),
+	})
+
+	ticker := time.NewTicker(s.config.MinPlayersCheckInterval)
+	defer ticker.Stop()
+	deadline := time.NewTimer(s.config.MinPlayersGrace)
+	defer deadline.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if players = s.roomPlayers(quiz.ID); players >= int64(quiz.MinPlayers) {
+				log.Printf("[Scheduler] Викторина #%d: набралось %d игроков, запуск", quiz.ID, players)
+				return true
+			}
+		case <-deadline.C:
+			// Последняя проверка: игроки могли войти после предыдущей
+			if players = s.roomPlayers(quiz.ID); players >= int64(quiz.MinPlayers) {
+				return true
+			}
+			s.cancelForInsufficientPlayers(quiz, players)
+			return false
+		case <-ctx.Done():
+			log.Printf("[Scheduler] Викторина #%d: ожидание игроков прервано", quiz.ID)
+			return false
+		}
+	}
+}
+
+// cancelForInsufficientPlayers отменяет викторину, к началу которой не набралось
+// Quiz.MinPlayers игроков, и сообщает об этом комнате викторины
+func (s *Scheduler) cancelForInsufficientPlayers(quiz *entity.Quiz, players int64) {
+	log.Printf("[Scheduler] Викторина #%d отменена: игроков %d из %d", quiz.ID, players, quiz.MinPlayers)
+	if err := UpdateQuizStatus(s.deps.QuizRepo, quiz, entity.QuizStatusCancelled); err != nil {
+		log.Printf("[Scheduler] Ошибка при отмене викторины #%d: %v", quiz.ID, err)
+	}
+	s.emit(quiz.ID, "quiz:insufficient_players", map[string]interface{}{
+		"quiz_id":     quiz.ID,
+		"players":     players,
+		"min_players": quiz.MinPlayers,
+		"message":     "Quiz has been cancelled: not enough players",
+	})
+	s.deps.WSManager.BroadcastEvent("quiz:cancelled", map[string]interface{}{
+		"quiz_id": quiz.ID,
+		"message": "Quiz has been cancelled: not enough players",
+	})
+}
+
+func (s *Scheduler) broadcastToQuiz(quizID uint, eventType string, data map[string]interface{}) {
+	fullEvent := map[string]interface{}{"type": eventType, "data": data}
+	if err := s.deps.WSManager.BroadcastEventToQuiz(quizID, fullEvent); err != nil {
+		log.Printf("[Scheduler] Ошибка при отправке %s для викторины #%d: %v", eventType, quizID, err)
+	}
+}
@@ -0,0 +1,151 @@
+package quizmanager
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourusername/trivia-api/internal/domain/entity"
+	"github.com/yourusername/trivia-api/internal/domain/repository"
+	"github.com/yourusername/trivia-api/internal/websocket"
+)
+
+// statusQuizRepo запоминает статусы, записанные планировщиком
+type statusQuizRepo struct {
+	repository.QuizRepository
+	mu       sync.Mutex
+	statuses []entity.QuizStatus
+}
+
+func (r *statusQuizRepo) UpdateStatus(quizID uint, from, to entity.QuizStatus) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.statuses = append(r.statuses, to)
+	return nil
+}
+
+func (r *statusQuizRepo) Statuses() []entity.QuizStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]entity.QuizStatus(nil), r.statuses...)
+}
+
+// newMinPlayersScheduler создает планировщик с числом игроков в комнате players
+// и возвращает записанные события комнаты
+func newMinPlayersScheduler(grace time.Duration, players *atomic.Int64) (*Scheduler, *statusQuizRepo, func() []string) {
+	config := DefaultConfig()
+	config.MinPlayersGrace = grace
+	config.MinPlayersCheckInterval = 10 * time.Millisecond
+
+	repo := &statusQuizRepo{}
+	s := NewScheduler(config, &Dependencies{
+		QuizRepo:  repo,
+		WSManager: websocket.NewManager(&recordingHub{}),
+	})
+	s.SetRoomPlayerCounter(func(uint) int64 { return players.Load() })
+
+	var mu sync.Mutex
+	var events []string
+	s.emit = func(quizID uint, eventType string, data map[string]interface{}) {
+		mu.Lock()
+		events = append(events, eventType)
+		mu.Unlock()
+	}
+	return s, repo, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), events...)
+	}
+}
+
+// TestScheduler_MinPlayersWaitThenStart: при нехватке игроков запуск откладывается
+// и происходит, как только игроков становится достаточно
+func TestScheduler_MinPlayersWaitThenStart(t *testing.T) {
+	var players atomic.Int64
+	players.Store(1)
+	s, repo, events := newMinPlayersScheduler(5*time.Second, &players)
+	quiz := &entity.Quiz{ID: 3, Status: entity.QuizStatusScheduled, MinPlayers: 2, MinPlayersPolicy: entity.MinPlayersPolicyWait}
+
+	go s.triggerQuizStart(context.Background(), quiz)
+
+	require.Eventually(t, func() bool { return len(events()) > 0 }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, []string{"quiz:start_delayed"}, events())
+	select {
+	case <-s.GetQuizStartChannel():
+		t.Fatal("викторина запущена без нужного числа игроков")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	players.Store(2)
+	select {
+	case quizID := <-s.GetQuizStartChannel():
+		assert.Equal(t, uint(3), quizID)
+	case <-time.After(time.Second):
+		t.Fatal("викторина не запущена после входа игроков")
+	}
+	assert.Equal(t, []entity.QuizStatus{entity.QuizStatusInProgress}, repo.Statuses())
+}
+
+// TestScheduler_MinPlayersWaitThenCancel: если за время ожидания игроков не
+// набралось, викторина отменяется с quiz:insufficient_players
+func TestScheduler_MinPlayersWaitThenCancel(t *testing.T) {
+	var players atomic.Int64
+	players.Store(1)
+	s, repo, events := newMinPlayersScheduler(50*time.Millisecond, &players)
+	quiz := &entity.Quiz{ID: 3, Status: entity.QuizStatusScheduled, MinPlayers: 2, MinPlayersPolicy: entity.MinPlayersPolicyWait}
+
+	s.triggerQuizStart(context.Background(), quiz)
+
+	assert.Equal(t, []string{"quiz:start_delayed", "quiz:insufficient_players"}, events())
+	assert.Equal(t, []entity.QuizStatus{entity.QuizStatusCancelled}, repo.Statuses())
+	assert.Empty(t, s.GetQuizStartChannel())
+}
+
+// TestScheduler_MinPlayersCancelPolicy: при политике cancel викторина
+// отменяется сразу, без ожидания
+func TestScheduler_MinPlayersCancelPolicy(t *testing.T) {
+	var players atomic.Int64
+	s, repo, events := newMinPlayersScheduler(time.Hour, &players)
+	quiz := &entity.Quiz{ID: 3, Status: entity.QuizStatusScheduled, MinPlayers: 2, MinPlayersPolicy: entity.MinPlayersPolicyCancel}
+
+	s.triggerQuizStart(context.Background(), quiz)
+
+	assert.Equal(t, []string{"quiz:insufficient_players"}, events())
+	assert.Equal(t, []entity.QuizStatus{entity.QuizStatusCancelled}, repo.Statuses())
+	assert.Empty(t, s.GetQuizStartChannel())
+
+	// Отмененная викторина не запускается, даже если игроки подошли
+	players.Store(2)
+	s.triggerQuizStart(context.Background(), quiz)
+	assert.Empty(t, s.GetQuizStartChannel())
+	assert.Equal(t, []entity.QuizStatus{entity.QuizStatusCancelled}, repo.Statuses())
+
+	quiz.Status = entity.QuizStatusScheduled // Запланирована заново
+	s.triggerQuizStart(context.Background(), quiz)
+	assert.Equal(t, uint(3), <-s.GetQuizStartChannel(), "игроков достаточно - викторина запускается")
+}
+
+// completedQuizRepo возвращает уже завершенную викторину
+type completedQuizRepo struct {
+	statusQuizRepo
+}
+
+func (r *completedQuizRepo) GetByID(id uint) (*entity.Quiz, error) {
+	return &entity.Quiz{ID: id, Status: entity.QuizStatusCompleted}, nil
+}
+
+func TestScheduler_CancelQuizInvalidTransition(t *testing.T) {
+	repo := &completedQuizRepo{}
+	s := NewScheduler(DefaultConfig(), &Dependencies{
+		QuizRepo:  repo,
+		WSManager: websocket.NewManager(&recordingHub{}),
+	})
+
+	err := s.CancelQuiz(5)
+	assert.ErrorIs(t, err, entity.ErrInvalidStatusTransition)
+	assert.Empty(t, repo.Statuses(), "статус завершенной викторины не меняется")
+}
@@ -0,0 +1,219 @@
+package quizmanager
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/yourusername/trivia-api/internal/domain/entity"
+)
+
+// Ошибки самостоятельного прохождения викторины
+var (
+	// ErrSelfPacedTimeOver - общий лимит времени викторины истек
+	ErrSelfPacedTimeOver = errors.New("self-paced quiz time is over")
+	// ErrSelfPacedCompleted - игрок уже ответил на все вопросы
+	ErrSelfPacedCompleted = errors.New("all questions have been answered")
+	// ErrSelfPacedNotStarted - игрок еще не получил ни одного вопроса (не отправил user:ready)
+	ErrSelfPacedNotStarted = errors.New("player has not started the quiz")
+	// ErrNotCurrentSelfPacedQuestion - вопрос не является текущим вопросом игрока
+	ErrNotCurrentSelfPacedQuestion = errors.New("question is not the player's current question")
+)
+
+// SelfPacedRun - самостоятельное прохождение викторины: каждый игрок получает
+// следующий вопрос сразу после ответа на предыдущий, независимо от остальных.
+// Таймеров вопросов нет, общий срок для всех - Deadline. Как и ActiveQuizState,
+// состояние хранится в памяти экземпляра.
+type SelfPacedRun struct {
+	Quiz     *entity.Quiz
+	Deadline time.Time
+
+	mu      sync.Mutex
+	players map[uint]*selfPacedProgress
+}
+
+// selfPacedProgress - продвижение игрока по вопросам
+type selfPacedProgress struct {
+	next       int   // Индекс текущего вопроса; len(Questions) - все вопросы пройдены
+	servedAtMs int64 // Когда игрок получил текущий вопрос (Unix ms), 0 - еще не отправлен
+}
+
+// NewSelfPacedRun создает прохождение викторины, начатое в startedAt
+func NewSelfPacedRun(quiz *entity.Quiz, startedAt time.Time) *SelfPacedRun {
+	return &SelfPacedRun{
+		Quiz:     quiz,
+		Deadline: startedAt.Add(quiz.SelfPacedTimeLimit()),
+		players:  make(map[uint]*selfPacedProgress),
+	}
+}
+
+// CurrentQuestion возвращает текущий вопрос игрока, его номер и время получения
+// вопроса игроком (Unix ms), при первом вызове начиная прохождение. Повторный
+// вызов (например, после переподключения) возвращает тот же вопрос, не сбрасывая
+// время его получения.
+func (r *SelfPacedRun) CurrentQuestion(userID uint, now time.Time) (*entity.Question, int, int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !now.Before(r.Deadline) {
+		return nil, 0, 0, ErrSelfPacedTimeOver
+	}
+	progress, ok := r.players[userID]
+	if !ok {
+		progress = &selfPacedProgress{}
+		r.players[userID] = progress
+	}
+	if progress.next >= len(r.Quiz.Questions) {
+		return nil, 0, 0, ErrSelfPacedCompleted
+	}
+	if progress.servedAtMs == 0 {
+		progress.servedAtMs = now.UnixMilli()
+	}
+	return &r.Quiz.Questions[progress.next], progress.next + 1, progress.servedAtMs, nil
+}
+
+// TakeAnswer принимает ответ игрока на его текущий вопрос и переводит игрока к
+// следующему. Возвращает вопрос и время его получения игроком. Ответ на другой
+// вопрос, в том числе повторный ответ на пройденный, отклоняется.
+func (r *SelfPacedRun) TakeAnswer(userID, questionID uint, now time.Time) (*entity.Question, int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !now.Before(r.Deadline) {
+		return nil, 0, ErrSelfPacedTimeOver
+	}
+	progress, ok := r.players[userID]
+	if !ok {
+		return nil, 0, ErrSelfPacedNotStarted
+	}
+	if progress.next >= len(r.Quiz.Questions) {
+		return nil, 0, ErrSelfPacedCompleted
+	}
+	question := &r.Quiz.Questions[progress.next]
+	if question.ID != questionID || progress.servedAtMs == 0 {
+		return nil, 0, fmt.Errorf("%w: question %d", ErrNotCurrentSelfPacedQuestion, questionID)
+	}
+
+	servedAtMs := progress.servedAtMs
+	progress.next++
+	progress.servedAtMs = 0
+	return question, servedAtMs, nil
+}
+
+// ReturnAnswer возвращает игрока к вопросу questionID, если принятый TakeAnswer
+// ответ затем не был обработан (очередь ответов переполнена, ответ отклонен):
+// игрок может ответить на вопрос снова, время получения вопроса сохраняется
+func (r *SelfPacedRun) ReturnAnswer(userID, questionID uint, servedAtMs int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	progress, ok := r.players[userID]
+	if !ok || progress.next == 0 || progress.servedAtMs != 0 {
+		return
+	}
+	if r.Quiz.Questions[progress.next-1].ID != questionID {
+		return
+	}
+	progress.next--
+	progress.servedAtMs = servedAtMs
+}
+
+// Progress возвращает число пройденных игроком вопросов и начал ли он прохождение
+func (r *SelfPacedRun) Progress(userID uint) (answered int, started bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	progress, ok := r.players[userID]
+	if !ok {
+		return 0, false
+	}
+	return progress.next, true
+}
+
+// Participants возвращает ID игроков, начавших прохождение, по возрастанию
+func (r *SelfPacedRun) Participants() []uint {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	userIDs := make([]uint, 0, len(r.players))
+	for userID := range r.players {
+		userIDs = append(userIDs, userID)
+	}
+	sort.Slice(userIDs, func(i, j int) bool { return userIDs[i] < userIDs[j] })
+	return userIDs
+}
+
+// ServeSelfPacedQuestion отправляет игроку его текущий вопрос (quiz:question с
+// self_paced=true и сроком викторины), а после последнего вопроса -
+// quiz:self_paced_complete
+func (ap *AnswerProcessor) ServeSelfPacedQuestion(run *SelfPacedRun, userID uint) error {
+	userKey := fmt.Sprintf("%d", userID)
+	question, number, servedAtMs, err := run.CurrentQuestion(userID, time.Now())
+	if errors.Is(err, ErrSelfPacedCompleted) {
+		answered, _ := run.Progress(userID)
+		return ap.deps.WSManager.SendEventToUser(userKey, "quiz:self_paced_complete", map[string]interface{}{
+			"quiz_id":         run.Quiz.ID,
+			"answered":        answered,
+			"total_questions": len(run.Quiz.Questions),
+			"ends_at":         run.Deadline.UnixMilli(),
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	data := questionEventData(run.Quiz, question, number, servedAtMs)
+	data["self_paced"] = true
+	data["ends_at"] = run.Deadline.UnixMilli()
+	// Отдельного срока у вопроса нет: ответ принимается до конца прохождения
+	data["ends_at_ms"] = run.Deadline.UnixMilli()
+	if err := ap.deps.WSManager.SendEventToUser(userKey, "quiz:question", data); err != nil {
+		log.Printf("[AnswerProcessor] Ошибка при отправке вопроса #%d пользователю #%d: %v", question.ID, userID, err)
+		return err
+	}
+	return nil
+}
+
+// ReturnSelfPacedQuestion возвращает игрока самостоятельного прохождения к
+// вопросу, ответ на который не был обработан (см. SelfPacedRun.ReturnAnswer)
+func (sub *AnswerSubmission) ReturnSelfPacedQuestion() {
+	if sub.SelfPaced != nil {
+		sub.SelfPaced.ReturnAnswer(sub.UserID, sub.QuestionID, sub.QuestionStartMs)
+	}
+}
+
+// PrepareSelfPacedSubmission принимает ответ игрока на его текущий вопрос
+// самостоятельного прохождения и переводит игрока к следующему вопросу. Время
+// ответа считается по часам сервера от отправки вопроса игроку: метка времени
+// клиента не используется, так как общего старта вопроса нет.
+func (ap *AnswerProcessor) PrepareSelfPacedSubmission(
+	userID uint,
+	questionID uint,
+	selectedOption int,
+	quizState *ActiveQuizState,
+) (*AnswerSubmission, error) {
+	run := quizState.SelfPaced
+	now := time.Now()
+	question, servedAtMs, err := run.TakeAnswer(userID, questionID, now)
+	if err != nil {
+		log.Printf("[AnswerProcessor] Ответ пользователя #%d на вопрос #%d викторины #%d отклонен: %v",
+			userID, questionID, run.Quiz.ID, err)
+		return nil, err
+	}
+
+	nowMs := now.UnixMilli()
+	return &AnswerSubmission{
+		UserID:             userID,
+		QuizID:             run.Quiz.ID,
+		QuestionID:         questionID,
+		SelectedOption:     selectedOption,
+		Timestamp:          nowMs,
+		Question:           question,
+		QuestionStartMs:    servedAtMs,
+		PointValue:         run.Quiz.EffectivePointValue(question),
+		WrongAnswerPenalty: run.Quiz.WrongAnswerPenalty,
+		ReceivedAtMs:       nowMs,
+		SelfPaced:          run,
+	}, nil
+}
@@ -10,6 +10,11 @@ import (
 	"github.com/yourusername/trivia-api/internal/websocket"
 )
 
+// ScheduleClockSkew - допустимое отставание времени проведения от текущего при
+// планировании. Время «через несколько секунд», отправленное клиентом с чуть
+// спешащими часами, не отклоняется, а викторина запускается сразу.
+const ScheduleClockSkew = 5 * time.Second
+
 // Config содержит настройки для всех компонентов QuizManager
 type Config struct {
 	// Таймауты и интервалы
@@ -31,6 +36,61 @@ type Config struct {
 
 	// Максимальное количество попыток отправки сообщений
 	MaxRetries int
+
+	// Прощение опоздания из-за переподключения: если игрок отключился во время вопроса
+	// и ответил не позже ReconnectGraceWindow после отключения, опоздавший ответ
+	// засчитывается как пропущенный, без выбывания. 0 - выключено.
+	ReconnectGraceWindow      time.Duration
+	ReconnectReprievesPerQuiz int // Сколько раз за викторину игрока можно простить
+
+	// Сколько после отключения игрок еще считается активным: ответ, отправленный
+	// по новому соединению, успевает прийти до досрочного закрытия вопроса
+	// (Quiz.AutoAdvance). 0 - игрок исключается сразу.
+	DisconnectGrace time.Duration
+
+	// Множитель запаса для максимальной длительности викторины.
+	// По истечении расчетной длительности, умноженной на этот множитель,
+	// викторина принудительно завершается.
+	MaxDurationSlackFactor float64
+
+	// Максимальное число одновременно проводимых викторин. Запуск сверх лимита
+	// отклоняется. 0 - без ограничения.
+	MaxConcurrentQuizzes int
+
+	// Настройки очереди приема ответов
+	AnswerWorkers           int           // Количество воркеров, обрабатывающих ответы
+	AnswerQueueSize         int           // Емкость очереди ответов
+	AnswerShedMaxPending    int           // Максимум отложенных ответов в режиме сброса нагрузки
+	AnswerShedBatchSize     int           // Размер пакета при обработке отложенных ответов
+	AnswerShedFlushInterval time.Duration // Интервал обработки отложенных ответов
+	AnswerAlertCooldown     time.Duration // Минимальный интервал между алертами о насыщении очереди
+
+	// Пакетная запись ответов в БД (AnswerWriter). AnswerWriteBatchSize 1 и
+	// меньше - каждый ответ записывается сразу.
+	AnswerWriteBatchSize     int           // Максимальный размер пакета вставки
+	AnswerWriteFlushInterval time.Duration // Интервал записи накопленных ответов
+	AnswerWriteMaxPending    int           // Максимум ответов, ожидающих записи
+
+	// События входа и выхода игроков: сверх PresenceBurstThreshold событий за
+	// PresenceWindow они сворачиваются в сводное quiz:presence_update.
+	// 0 - без ограничения.
+	PresenceBurstThreshold int
+	PresenceWindow         time.Duration
+
+	// Насколько позже рассылки вопроса может начаться время на ответ игрока
+	// при Quiz.AnswerWindowFromAck. Более позднее подтверждение получения
+	// вопроса не дает дополнительного времени; на столько же продлевается прием
+	// ответов на вопрос.
+	MaxQuestionAckOffsetMs int64
+
+	// Ожидание игроков при Quiz.MinPlayers с политикой wait: сколько ждать
+	// после назначенного времени и как часто проверять число игроков в комнате
+	MinPlayersGrace         time.Duration
+	MinPlayersCheckInterval time.Duration
+
+	// Требовать присоединения через POST /api/quizzes/:id/join перед user:ready.
+	// Если выключено, user:ready занимает место в викторине сам.
+	RequireJoin bool
 }
 
 // DefaultConfig возвращает конфигурацию по умолчанию
@@ -48,7 +108,59 @@ func DefaultConfig() *Config {
 		MaxResponseTimeMs:    30000, // 30 секунд
 		EliminationTimeMs:    10000, // 10 секунд
 		MaxRetries:           3,
+
+		ReconnectReprievesPerQuiz: 1,
+		DisconnectGrace:           5 * time.Second,
+
+		MaxDurationSlackFactor: 2.0,
+
+		AnswerWorkers:           32,
+		AnswerQueueSize:         4096,
+		AnswerShedMaxPending:    50000,
+		AnswerShedBatchSize:     500,
+		AnswerShedFlushInterval: 200 * time.Millisecond,
+		AnswerAlertCooldown:     10 * time.Second,
+
+		AnswerWriteBatchSize:     500,
+		AnswerWriteFlushInterval: 250 * time.Millisecond,
+		AnswerWriteMaxPending:    100000,
+
+		PresenceBurstThreshold: 20,
+		PresenceWindow:         time.Second,
+
+		MaxQuestionAckOffsetMs: 2000,
+
+		MinPlayersGrace:         2 * time.Minute,
+		MinPlayersCheckInterval: 5 * time.Second,
+	}
+}
+
+// ExpectedQuizDuration возвращает расчетную длительность цикла вопросов:
+// сумму лимитов времени и служебных задержек всех вопросов
+func (c *Config) ExpectedQuizDuration(questions []entity.Question) time.Duration {
+	perQuestionDelay := time.Duration(c.QuestionDelayMs+c.AnswerRevealDelayMs+c.InterQuestionDelayMs) * time.Millisecond
+
+	var expected time.Duration
+	for _, q := range questions {
+		expected += time.Duration(q.TimeLimitSec)*time.Second + perQuestionDelay
+	}
+	return expected
+}
+
+// MaxQuizDuration возвращает предельную длительность проведения викторины:
+// расчетную длительность с учетом запаса
+func (c *Config) MaxQuizDuration(quiz *entity.Quiz) time.Duration {
+	expected := c.ExpectedQuizDuration(quiz.Questions)
+	if quiz.AnswerWindowFromAck {
+		expected += time.Duration(int64(len(quiz.Questions))*c.MaxQuestionAckOffsetMs) * time.Millisecond
+	}
+	expected += time.Duration(len(quiz.Questions)*quiz.QuestionReadTimeSec) * time.Second
+
+	factor := c.MaxDurationSlackFactor
+	if factor < 1 {
+		factor = 1
 	}
+	return time.Duration(float64(expected) * factor)
 }
 
 // ResultService определяет интерфейс для методов сервиса результатов,
@@ -68,56 +180,354 @@ type Dependencies struct {
 	WSManager     *websocket.Manager
 }
 
-// ActiveQuizState хранит состояние активной викторины
+// ActiveQuizState хранит состояние активной викторины.
+// Цикл вопросов меняет текущий вопрос, пока обработчики ответов читают его
+// из других горутин, поэтому поля текущего вопроса закрыты и доступны только
+// через методы, безопасные для конкурентного использования. Quiz не меняется
+// после создания состояния и читается без блокировки.
 type ActiveQuizState struct {
-	Quiz                       *entity.Quiz
-	CurrentQuestion            *entity.Question
-	CurrentQuestionNumber      int
-	CurrentQuestionStartTimeMs int64 // Добавляем время старта текущего вопроса (Unix ms)
-	Mu                         sync.RWMutex
+	Quiz *entity.Quiz
+	// Replay: повтор завершенной викторины. События помечаются replay=true,
+	// время вопросов не сохраняется и сигнал о завершении вопросов не отправляется.
+	Replay bool
+	// SelfPaced: самостоятельное прохождение (Quiz.IsSelfPaced). nil - вопросы
+	// отправляются всем одновременно циклом RunQuizQuestions.
+	SelfPaced *SelfPacedRun
+
+	mu                     sync.RWMutex
+	currentQuestion        *entity.Question
+	currentQuestionNumber  int
+	currentQuestionStartMs int64 // Время отправки текущего вопроса (Unix ms), 0 - вопрос еще не открыт
+	currentRevealed        bool  // Правильный ответ на текущий вопрос уже разослан (quiz:answer_reveal)
+	currentReadUntilMs     int64 // Конец времени на чтение текущего вопроса (Unix ms), 0 - вопрос не в фазе чтения
+
+	fastestFinger *FastestFingerTracker
+	answers       *AnswerTracker
+	acks          *QuestionAcks
+	lives         *LivesTracker
 }
 
 // NewActiveQuizState создает новое состояние активной викторины
 func NewActiveQuizState(quiz *entity.Quiz) *ActiveQuizState {
 	return &ActiveQuizState{
-		Quiz: quiz,
+		Quiz:          quiz,
+		fastestFinger: NewFastestFingerTracker(),
+		answers:       NewAnswerTracker(),
+		acks:          NewQuestionAcks(),
+		lives:         NewLivesTracker(quiz.EliminationLives()),
+	}
+}
+
+// FastestFinger возвращает учет первых правильных ответов викторины
+func (s *ActiveQuizState) FastestFinger() *FastestFingerTracker {
+	return s.fastestFinger
+}
+
+// Answers возвращает учет ответов активных игроков на текущий вопрос
+func (s *ActiveQuizState) Answers() *AnswerTracker {
+	return s.answers
+}
+
+// Lives возвращает учет оставшихся жизней игроков
+func (s *ActiveQuizState) Lives() *LivesTracker {
+	return s.lives
+}
+
+// Acks возвращает подтверждения получения вопросов игроками
+func (s *ActiveQuizState) Acks() *QuestionAcks {
+	return s.acks
+}
+
+// AnswerWindowStart возвращает начало времени на ответ игрока для вопроса,
+// разосланного в questionStartMs: при Quiz.AnswerWindowFromAck - от подтверждения
+// получения вопроса игроком, иначе - от рассылки
+func (s *ActiveQuizState) AnswerWindowStart(questionID, userID uint, questionStartMs, maxAckOffsetMs int64) int64 {
+	if !s.Quiz.AnswerWindowFromAck {
+		return questionStartMs
 	}
+	return s.acks.WindowStart(questionID, userID, questionStartMs, maxAckOffsetMs)
 }
 
-// SetCurrentQuestion устанавливает текущий вопрос
+// SetCurrentQuestion устанавливает текущий вопрос. Время старта сбрасывается:
+// до вызова SetCurrentQuestionStartTime ответы на новый вопрос не принимаются,
+// иначе они были бы засчитаны по времени старта предыдущего вопроса.
 func (s *ActiveQuizState) SetCurrentQuestion(question *entity.Question, number int) {
-	s.Mu.Lock()
-	defer s.Mu.Unlock()
-	s.CurrentQuestion = question
-	s.CurrentQuestionNumber = number
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.currentQuestion = question
+	s.currentQuestionNumber = number
+	s.currentQuestionStartMs = 0
+	s.currentRevealed = false
+	s.currentReadUntilMs = 0
 }
 
-// GetCurrentQuestion возвращает текущий вопрос
+// GetCurrentQuestion возвращает текущий вопрос и его номер
 func (s *ActiveQuizState) GetCurrentQuestion() (*entity.Question, int) {
-	s.Mu.RLock()
-	defer s.Mu.RUnlock()
-	return s.CurrentQuestion, s.CurrentQuestionNumber
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.currentQuestion, s.currentQuestionNumber
 }
 
 // SetCurrentQuestionStartTime устанавливает время начала текущего вопроса
+// (открытия приема ответов). Фаза чтения при этом заканчивается.
 func (s *ActiveQuizState) SetCurrentQuestionStartTime(startTimeMs int64) {
-	s.Mu.Lock()
-	defer s.Mu.Unlock()
-	s.CurrentQuestionStartTimeMs = startTimeMs
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.currentQuestionStartMs = startTimeMs
+	s.currentReadUntilMs = 0
+}
+
+// SetCurrentQuestionReadPhase отмечает, что текущий вопрос показан игрокам, но
+// ответы на него принимаются только с readUntilMs (Quiz.QuestionReadTimeSec)
+func (s *ActiveQuizState) SetCurrentQuestionReadPhase(readUntilMs int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.currentReadUntilMs = readUntilMs
+}
+
+// CurrentQuestionReadUntil возвращает конец времени на чтение текущего вопроса
+// или 0, если вопрос не в фазе чтения
+func (s *ActiveQuizState) CurrentQuestionReadUntil() int64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.currentReadUntilMs
 }
 
 // GetCurrentQuestionStartTime возвращает время начала текущего вопроса
 func (s *ActiveQuizState) GetCurrentQuestionStartTime() int64 {
-	s.Mu.RLock()
-	defer s.Mu.RUnlock()
-	return s.CurrentQuestionStartTimeMs
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.currentQuestionStartMs
+}
+
+// CurrentQuestionSnapshot атомарно возвращает текущий вопрос, его номер и время старта.
+// Обработчикам ответов следует использовать его вместо раздельных геттеров,
+// чтобы вопрос и время старта гарантированно относились друг к другу.
+func (s *ActiveQuizState) CurrentQuestionSnapshot() (*entity.Question, int, int64) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.currentQuestion, s.currentQuestionNumber, s.currentQuestionStartMs
+}
+
+// MarkCurrentQuestionRevealed отмечает, что правильный ответ на текущий вопрос разослан
+func (s *ActiveQuizState) MarkCurrentQuestionRevealed() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.currentRevealed = true
+}
+
+// QuestionRevealed сообщает, раскрыт ли правильный ответ на вопрос. Для вопроса,
+// который не является текущим, возвращает false: о прошедших вопросах судит вызывающий.
+func (s *ActiveQuizState) QuestionRevealed(questionID uint) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.currentQuestion != nil && s.currentQuestion.ID == questionID && s.currentRevealed
 }
 
 // ClearCurrentQuestion очищает текущий вопрос и время его старта
 func (s *ActiveQuizState) ClearCurrentQuestion() {
-	s.Mu.Lock()
-	defer s.Mu.Unlock()
-	s.CurrentQuestion = nil
-	s.CurrentQuestionNumber = 0
-	s.CurrentQuestionStartTimeMs = 0
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.currentQuestion = nil
+	s.currentQuestionNumber = 0
+	s.currentQuestionStartMs = 0
+	s.currentRevealed = false
+	s.currentReadUntilMs = 0
+}
+
+// FastestFingerSettleTimeout - сколько обработанный правильный ответ ждет
+// обработки кандидатов, принятых раньше него, прежде чем отказаться от первенства
+const FastestFingerSettleTimeout = 2 * time.Second
+
+// FastestFingerTracker фиксирует по каждому вопросу первого игрока, ответившего
+// правильно, по времени приема ответа сервером. Ответы обрабатываются воркерами
+// параллельно и не по порядку, поэтому правильный ответ регистрируется
+// кандидатом при приеме (Offer), а первенство решается при обработке (Settle):
+// ответ не получает его, пока не обработан кандидат, принятый раньше.
+type FastestFingerTracker struct {
+	mu         sync.Mutex
+	nextSeq    uint64
+	candidates map[uint][]fastestFingerCandidate // questionID -> необработанные кандидаты
+	winners    map[uint]uint                     // questionID -> userID
+	settled    chan struct{}                     // закрывается, когда снимается любой кандидат
+}
+
+// fastestFingerCandidate - правильный ответ, ожидающий обработки
+type fastestFingerCandidate struct {
+	seq          uint64
+	userID       uint
+	receivedAtMs int64
+	accepted     bool // ответ обработан и засчитан, ждет решения по более ранним
+}
+
+// before сообщает, принят ли кандидат раньше other; при равном времени
+// раньше тот, кто раньше зарегистрирован
+func (c fastestFingerCandidate) before(other fastestFingerCandidate) bool {
+	if c.receivedAtMs != other.receivedAtMs {
+		return c.receivedAtMs < other.receivedAtMs
+	}
+	return c.seq < other.seq
+}
+
+// NewFastestFingerTracker создает пустой учет первых правильных ответов
+func NewFastestFingerTracker() *FastestFingerTracker {
+	return &FastestFingerTracker{
+		candidates: make(map[uint][]fastestFingerCandidate),
+		winners:    make(map[uint]uint),
+		settled:    make(chan struct{}),
+	}
+}
+
+// Offer регистрирует правильный ответ, принятый в срок, кандидатом на первенство.
+// Возвращает номер кандидата для Settle.
+func (t *FastestFingerTracker) Offer(questionID, userID uint, receivedAtMs int64) uint64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.nextSeq++
+	t.candidates[questionID] = append(t.candidates[questionID], fastestFingerCandidate{
+		seq:          t.nextSeq,
+		userID:       userID,
+		receivedAtMs: receivedAtMs,
+	})
+	return t.nextSeq
+}
+
+// Settle снимает кандидата после обработки ответа. accepted - ответ принят и
+// засчитан правильным в срок. Возвращает true, если кандидат получает
+// первенство. Пока есть необработанные кандидаты, принятые раньше, засчитанный
+// ответ ждет их (не дольше FastestFingerSettleTimeout): отклоненный кандидат
+// (исключенный игрок, повторный ответ) первенства не забирает, и оно переходит
+// к следующему по времени приема засчитанному ответу.
+func (t *FastestFingerTracker) Settle(questionID uint, seq uint64, accepted bool) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	index := t.candidateIndex(questionID, seq)
+	if index < 0 {
+		return false
+	}
+	if !accepted {
+		t.removeCandidate(questionID, index)
+		return false
+	}
+	t.candidates[questionID][index].accepted = true
+	settled := t.candidates[questionID][index]
+
+	deadline := time.NewTimer(FastestFingerSettleTimeout)
+	defer deadline.Stop()
+	won := false
+	for waiting := true; waiting; {
+		if _, taken := t.winners[questionID]; taken {
+			break
+		}
+		if !t.hasCandidateBefore(questionID, settled) {
+			t.winners[questionID] = settled.userID
+			won = true
+			break
+		}
+		wake := t.settled
+		t.mu.Unlock()
+		select {
+		case <-wake:
+		case <-deadline.C:
+			waiting = false
+		}
+		t.mu.Lock()
+	}
+	t.removeCandidate(questionID, t.candidateIndex(questionID, seq))
+	return won
+}
+
+// candidateIndex возвращает позицию кандидата seq в списке вопроса или -1
+func (t *FastestFingerTracker) candidateIndex(questionID uint, seq uint64) int {
+	for i, candidate := range t.candidates[questionID] {
+		if candidate.seq == seq {
+			return i
+		}
+	}
+	return -1
+}
+
+// hasCandidateBefore сообщает, есть ли по вопросу кандидат, принятый раньше c
+func (t *FastestFingerTracker) hasCandidateBefore(questionID uint, c fastestFingerCandidate) bool {
+	for _, pending := range t.candidates[questionID] {
+		if pending.before(c) {
+			return true
+		}
+	}
+	return false
+}
+
+// removeCandidate снимает кандидата и будит ожидающих в Settle
+func (t *FastestFingerTracker) removeCandidate(questionID uint, index int) {
+	candidates := t.candidates[questionID]
+	if index < 0 || index >= len(candidates) {
+		return
+	}
+	candidates = append(candidates[:index], candidates[index+1:]...)
+	if len(candidates) == 0 {
+		delete(t.candidates, questionID)
+	} else {
+		t.candidates[questionID] = candidates
+	}
+	close(t.settled)
+	t.settled = make(chan struct{})
+}
+
+// Winner возвращает пользователя, первым правильно ответившего на вопрос
+func (t *FastestFingerTracker) Winner(questionID uint) (uint, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	userID, ok := t.winners[questionID]
+	return userID, ok
+}
+
+// LivesTracker учитывает оставшиеся жизни игроков в формате на выбывание
+// (Quiz.Lives): каждый неверный или опоздавший ответ отнимает жизнь, игрок
+// выбывает, когда жизней не остается. Общий для экземпляров счет потерянных
+// жизней хранится в кэше (см. AnswerProcessor.loseLife), трекер - его копия на
+// этом экземпляре и запасной вариант при недоступности кэша.
+type LivesTracker struct {
+	mu    sync.Mutex
+	lives int
+	lost  map[uint]int // userID -> потерянные жизни
+}
+
+// NewLivesTracker создает учет жизней с lives жизнями у каждого игрока
+func NewLivesTracker(lives int) *LivesTracker {
+	if lives < 1 {
+		lives = 1
+	}
+	return &LivesTracker{lives: lives, lost: make(map[uint]int)}
+}
+
+// LoseLife отнимает у игрока жизнь и возвращает число оставшихся
+func (t *LivesTracker) LoseLife(userID uint) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.lost[userID] < t.lives {
+		t.lost[userID]++
+	}
+	return t.lives - t.lost[userID]
+}
+
+// Observe учитывает число потерянных игроком жизней из общего счета (часть
+// жизней могла быть потеряна на других экземплярах) и возвращает число оставшихся
+func (t *LivesTracker) Observe(userID uint, lost int) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if lost > t.lives {
+		lost = t.lives
+	}
+	if lost > t.lost[userID] {
+		t.lost[userID] = lost
+	}
+	return t.lives - t.lost[userID]
+}
+
+// Remaining возвращает число оставшихся жизней игрока
+func (t *LivesTracker) Remaining(userID uint) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.lives - t.lost[userID]
 }
@@ -0,0 +1,33 @@
+package quizmanager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yourusername/trivia-api/internal/domain/entity"
+)
+
+func TestConfig_MaxQuizDuration(t *testing.T) {
+	config := &Config{
+		QuestionDelayMs:        1000,
+		AnswerRevealDelayMs:    500,
+		InterQuestionDelayMs:   500,
+		MaxQuestionAckOffsetMs: 2000,
+		MaxDurationSlackFactor: 2,
+	}
+	quiz := &entity.Quiz{Questions: []entity.Question{{TimeLimitSec: 10}, {TimeLimitSec: 20}}}
+
+	// (10с + 2с) + (20с + 2с) с двукратным запасом
+	assert.Equal(t, 34*time.Second, config.ExpectedQuizDuration(quiz.Questions))
+	assert.Equal(t, 68*time.Second, config.MaxQuizDuration(quiz))
+
+	// Время чтения и окно подтверждения получения добавляются к каждому вопросу
+	quiz.QuestionReadTimeSec = 3
+	quiz.AnswerWindowFromAck = true
+	assert.Equal(t, 2*(34+6+4)*time.Second, config.MaxQuizDuration(quiz))
+
+	// Множитель меньше 1 не сокращает расчетную длительность
+	config.MaxDurationSlackFactor = 0.5
+	assert.Equal(t, 44*time.Second, config.MaxQuizDuration(quiz))
+}
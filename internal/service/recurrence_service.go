@@ -0,0 +1,155 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/yourusername/trivia-api/internal/domain/repository"
+)
+
+// QuizScheduler планирует запуск викторины (реализуется QuizManager)
+type QuizScheduler interface {
+	ScheduleQuiz(quizID uint, scheduledTime time.Time) error
+}
+
+// RecurrenceService создает следующие викторины повторяющихся серий.
+// Правило повторения и связь викторин серии хранятся в БД, поэтому после
+// перезапуска сервер продолжает серии с того места, где остановился: викторина
+// считается обработанной, только если уже существует ее копия.
+type RecurrenceService struct {
+	quizRepo    repository.QuizRepository
+	quizService *QuizService
+	scheduler   QuizScheduler
+	cacheRepo   repository.CacheRepository
+	interval    time.Duration
+	batchSize   int
+	// В режиме обслуживания новые викторины серий не создаются (см. SetMaintenance)
+	maintenance *MaintenanceService
+
+	// running не дает проходам выполняться одновременно
+	running sync.Mutex
+}
+
+// NewRecurrenceService создает сервис повторяющихся викторин. cacheRepo может
+// быть nil; если он задан, копия одной викторины создается только одним
+// экземпляром сервера.
+func NewRecurrenceService(
+	quizRepo repository.QuizRepository,
+	quizService *QuizService,
+	scheduler QuizScheduler,
+	cacheRepo repository.CacheRepository,
+	interval time.Duration,
+) *RecurrenceService {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+
+	return &RecurrenceService{
+		quizRepo:    quizRepo,
+		quizService: quizService,
+		scheduler:   scheduler,
+		cacheRepo:   cacheRepo,
+		interval:    interval,
+		batchSize:   100,
+	}
+}
+
+// SetMaintenance приостанавливает создание викторин серий, пока включен режим
+// обслуживания; пропущенные серии продолжаются после его выключения
+func (s *RecurrenceService) SetMaintenance(maintenance *MaintenanceService) {
+	s.maintenance = maintenance
+}
+
+// Start сразу выполняет проход (чтобы наверстать серии, завершенные во время
+// простоя сервера) и затем повторяет его с заданным интервалом до отмены ctx
+func (s *RecurrenceService) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		log.Printf("[RecurrenceService] Запуск планировщика повторяющихся викторин (каждые %v)", s.interval)
+
+		for {
+			if _, err := s.Run(ctx); err != nil {
+				log.Printf("[RecurrenceService] Ошибка при создании следующих викторин серий: %v", err)
+			}
+
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				log.Println("[RecurrenceService] Завершение работы горутины повторяющихся викторин")
+				return
+			}
+		}
+	}()
+}
+
+// Run создает и планирует следующие викторины для завершенных повторяющихся
+// викторин. Возвращает число созданных викторин.
+func (s *RecurrenceService) Run(ctx context.Context) (int, error) {
+	if !s.running.TryLock() {
+		return 0, nil
+	}
+	defer s.running.Unlock()
+
+	if s.maintenance != nil && s.maintenance.IsEnabled() {
+		return 0, nil
+	}
+
+	quizzes, err := s.quizRepo.ListRecurrenceDue(s.batchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list recurring quizzes: %w", err)
+	}
+
+	created := 0
+	now := time.Now()
+	for _, quiz := range quizzes {
+		if err := ctx.Err(); err != nil {
+			return created, err
+		}
+		// Пауза могла быть выставлена после выборки
+		if !quiz.IsRecurring() || quiz.RecurrencePaused {
+			continue
+		}
+		if !s.acquire(quiz.ID) {
+			continue
+		}
+
+		next := quiz.NextOccurrence(now)
+		clone, err := s.quizService.CloneQuiz(quiz.ID, CloneQuizOptions{
+			ScheduledTime:      &next,
+			ContinueRecurrence: true,
+		})
+		if err != nil {
+			log.Printf("[RecurrenceService] Не удалось создать следующую викторину серии для #%d: %v", quiz.ID, err)
+			continue
+		}
+		created++
+
+		if err := s.scheduler.ScheduleQuiz(clone.ID, clone.ScheduledTime); err != nil {
+			log.Printf("[RecurrenceService] Не удалось запланировать викторину #%d: %v", clone.ID, err)
+			continue
+		}
+		log.Printf("[RecurrenceService] Викторина #%d серии #%d запланирована на %s",
+			clone.ID, quiz.ID, clone.ScheduledTime.Format(time.RFC3339))
+	}
+
+	return created, nil
+}
+
+// acquire захватывает викторину для создания копии, чтобы несколько экземпляров
+// сервера не создали одну и ту же следующую викторину серии
+func (s *RecurrenceService) acquire(quizID uint) bool {
+	if s.cacheRepo == nil {
+		return true
+	}
+	ok, err := s.cacheRepo.SetNX(fmt.Sprintf("quiz:%d:recurrence_lock", quizID), "1", 5*time.Minute)
+	if err != nil {
+		log.Printf("[RecurrenceService] WARNING: Не удалось захватить викторину #%d: %v", quizID, err)
+		return false
+	}
+	return ok
+}
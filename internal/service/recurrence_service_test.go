@@ -0,0 +1,191 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourusername/trivia-api/internal/domain/entity"
+	"github.com/yourusername/trivia-api/internal/domain/repository"
+)
+
+// recurrenceQuizRepo хранит викторины в памяти и выбирает серии так же, как QuizRepo
+type recurrenceQuizRepo struct {
+	repository.QuizRepository
+	quizzes map[uint]*entity.Quiz
+	nextID  uint
+}
+
+func (r *recurrenceQuizRepo) GetByID(id uint) (*entity.Quiz, error) {
+	quiz, ok := r.quizzes[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	copied := *quiz
+	return &copied, nil
+}
+
+func (r *recurrenceQuizRepo) Update(quiz *entity.Quiz) error {
+	copied := *quiz
+	r.quizzes[quiz.ID] = &copied
+	return nil
+}
+
+func (r *recurrenceQuizRepo) UpdateStatus(id uint, from, to entity.QuizStatus) error {
+	quiz, ok := r.quizzes[id]
+	if !ok || quiz.Status != from {
+		return entity.ErrInvalidStatusTransition
+	}
+	quiz.Status = to
+	return nil
+}
+
+func (r *recurrenceQuizRepo) UpdateScheduledTime(id uint, status entity.QuizStatus, scheduledTime time.Time) error {
+	quiz, ok := r.quizzes[id]
+	if !ok || quiz.Status != status {
+		return entity.ErrInvalidStatusTransition
+	}
+	quiz.ScheduledTime = scheduledTime
+	return nil
+}
+
+func (r *recurrenceQuizRepo) CreateWithQuestions(quiz *entity.Quiz, questions []entity.Question) error {
+	r.nextID++
+	quiz.ID = r.nextID
+	quiz.QuestionCount = len(questions)
+	return r.Update(quiz)
+}
+
+func (r *recurrenceQuizRepo) ListRecurrenceDue(limit int) ([]entity.Quiz, error) {
+	hasNext := make(map[uint]bool)
+	for _, q := range r.quizzes {
+		if q.RecurrenceSourceID != nil {
+			hasNext[*q.RecurrenceSourceID] = true
+		}
+	}
+	var found []entity.Quiz
+	for id := uint(1); id <= r.nextID; id++ {
+		q, ok := r.quizzes[id]
+		if ok && q.IsCompleted() && q.IsRecurring() && !q.RecurrencePaused && !hasNext[id] {
+			found = append(found, *q)
+		}
+	}
+	if len(found) > limit {
+		found = found[:limit]
+	}
+	return found, nil
+}
+
+// recordingScheduler запоминает запланированные викторины
+type recordingScheduler struct {
+	scheduled map[uint]time.Time
+}
+
+func (s *recordingScheduler) ScheduleQuiz(quizID uint, scheduledTime time.Time) error {
+	s.scheduled[quizID] = scheduledTime
+	return nil
+}
+
+func newRecurrenceFixture(source entity.Quiz) (*RecurrenceService, *QuizService, *recurrenceQuizRepo, *recordingScheduler) {
+	quizRepo := &recurrenceQuizRepo{quizzes: map[uint]*entity.Quiz{source.ID: &source}, nextID: source.ID}
+	questionRepo := &cloneQuestionRepo{questions: []entity.Question{
+		{ID: 11, QuizID: source.ID, Type: entity.QuestionTypeSingleChoice, Text: "q1", Options: entity.StringArray{"a", "b"}, CorrectOption: 1, TimeLimitSec: 10, PointValue: 10},
+	}}
+	quizService := NewQuizService(quizRepo, questionRepo, nil)
+	scheduler := &recordingScheduler{scheduled: make(map[uint]time.Time)}
+	return NewRecurrenceService(quizRepo, quizService, scheduler, nil, time.Minute), quizService, quizRepo, scheduler
+}
+
+func TestRecurrenceService_SchedulesNextOccurrence(t *testing.T) {
+	scheduled := time.Now().Add(-2 * time.Hour)
+	s, _, quizRepo, scheduler := newRecurrenceFixture(entity.Quiz{
+		ID: 1, Title: "Ежедневная викторина", ScheduledTime: scheduled, Status: "completed",
+		RecurrenceIntervalMin: 24 * 60, JoinPolicy: entity.JoinPolicyAnytime,
+	})
+
+	created, err := s.Run(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1, created)
+
+	next := quizRepo.quizzes[2]
+	require.NotNil(t, next)
+	assert.Equal(t, entity.QuizStatusScheduled, next.Status)
+	assert.Equal(t, scheduled.Add(24*time.Hour), next.ScheduledTime)
+	assert.Equal(t, 24*60, next.RecurrenceIntervalMin, "копия продолжает серию")
+	require.NotNil(t, next.RecurrenceSourceID)
+	assert.Equal(t, uint(1), *next.RecurrenceSourceID)
+	assert.Equal(t, entity.JoinPolicyAnytime, next.JoinPolicy)
+	assert.Equal(t, 1, next.QuestionCount, "вопросы переносятся в копию")
+	assert.Equal(t, next.ScheduledTime, scheduler.scheduled[2])
+
+	// Повторный проход (например, после перезапуска) не создает вторую копию
+	created, err = s.Run(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, created)
+	assert.Len(t, quizRepo.quizzes, 2)
+}
+
+func TestRecurrenceService_SkipsUnfinishedQuizzes(t *testing.T) {
+	s, _, quizRepo, _ := newRecurrenceFixture(entity.Quiz{
+		ID: 1, ScheduledTime: time.Now().Add(time.Hour), Status: "scheduled", RecurrenceIntervalMin: 60,
+	})
+
+	created, err := s.Run(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, created)
+	assert.Len(t, quizRepo.quizzes, 1)
+}
+
+func TestRecurrenceService_PauseAndResume(t *testing.T) {
+	s, quizService, quizRepo, scheduler := newRecurrenceFixture(entity.Quiz{
+		ID: 1, ScheduledTime: time.Now().Add(-time.Hour), Status: "completed", RecurrenceIntervalMin: 7 * 24 * 60,
+	})
+
+	paused := true
+	quiz, err := quizService.SetRecurrence(1, RecurrenceOptions{Paused: &paused})
+	require.NoError(t, err)
+	assert.True(t, quiz.RecurrencePaused)
+
+	created, err := s.Run(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, created, "приостановленная серия не продолжается")
+	assert.Empty(t, scheduler.scheduled)
+
+	paused = false
+	_, err = quizService.SetRecurrence(1, RecurrenceOptions{Paused: &paused})
+	require.NoError(t, err)
+
+	created, err = s.Run(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, created, "после возобновления серия продолжается")
+	assert.False(t, quizRepo.quizzes[2].RecurrencePaused)
+}
+
+func TestQuizService_SetRecurrence(t *testing.T) {
+	_, quizService, quizRepo, _ := newRecurrenceFixture(entity.Quiz{ID: 1, Status: "scheduled", ScheduledTime: time.Now()})
+
+	tooShort := MinRecurrenceIntervalMin - 1
+	_, err := quizService.SetRecurrence(1, RecurrenceOptions{IntervalMin: &tooShort})
+	assert.ErrorIs(t, err, ErrValidation)
+
+	_, err = quizService.SetRecurrence(42, RecurrenceOptions{IntervalMin: &tooShort})
+	assert.ErrorIs(t, err, ErrValidation, "интервал проверяется до поиска викторины")
+
+	weekly, paused := 7*24*60, true
+	quiz, err := quizService.SetRecurrence(1, RecurrenceOptions{IntervalMin: &weekly, Paused: &paused})
+	require.NoError(t, err)
+	assert.True(t, quiz.IsRecurring())
+	assert.True(t, quizRepo.quizzes[1].RecurrencePaused)
+
+	// Отмена повторения снимает и паузу
+	cancelled := 0
+	quiz, err = quizService.SetRecurrence(1, RecurrenceOptions{IntervalMin: &cancelled})
+	require.NoError(t, err)
+	assert.False(t, quiz.IsRecurring())
+	assert.False(t, quiz.RecurrencePaused)
+
+	_, err = quizService.SetRecurrence(42, RecurrenceOptions{IntervalMin: &weekly})
+	assert.ErrorIs(t, err, ErrQuizNotFound)
+}
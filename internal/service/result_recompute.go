@@ -0,0 +1,219 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"gorm.io/gorm"
+
+	"github.com/yourusername/trivia-api/internal/domain/entity"
+)
+
+var (
+	// ErrQuizNotRecomputable возвращается, если викторина еще не завершена
+	ErrQuizNotRecomputable = errors.New("only completed quizzes can have their results recomputed")
+	// ErrRecomputeInProgress возвращается, если пересчет результатов уже выполняется
+	ErrRecomputeInProgress = errors.New("results recomputation is already running")
+)
+
+// ResultRecomputeDiff - изменение результата одного участника после пересчета
+type ResultRecomputeDiff struct {
+	UserID            uint   `json:"user_id"`
+	Username          string `json:"username"`
+	OldScore          int    `json:"old_score"`
+	NewScore          int    `json:"new_score"`
+	OldCorrectAnswers int    `json:"old_correct_answers"`
+	NewCorrectAnswers int    `json:"new_correct_answers"`
+	// Количество ответов, у которых изменились очки, правильность или штраф
+	ChangedAnswers int `json:"changed_answers"`
+}
+
+// ResultRecomputeReport - итог пересчета результатов викторины
+type ResultRecomputeReport struct {
+	QuizID         uint                  `json:"quiz_id"`
+	DryRun         bool                  `json:"dry_run"`
+	ResultsChecked int                   `json:"results_checked"`
+	AnswersChecked int                   `json:"answers_checked"`
+	Changes        []ResultRecomputeDiff `json:"changes"`
+}
+
+// resultRecompute - изменение результата вместе с ответами, которые нужно обновить
+type resultRecompute struct {
+	resultID uint
+	diff     ResultRecomputeDiff
+	answers  []entity.UserAnswer
+}
+
+// RecomputeQuizResults заново подсчитывает результаты завершенной викторины по
+// сохраненным ответам с текущими правилами подсчета очков. В режиме dryRun
+// только возвращает различия. Иначе в одной транзакции обновляет ответы,
+// результаты и общий счет игроков, затем пересчитывает ранги и призы.
+// Выбывание игроков не пересматривается: оно уже повлияло на ход викторины.
+func (s *ResultService) RecomputeQuizResults(quizID, adminID uint, dryRun bool) (*ResultRecomputeReport, error) {
+	if !s.recomputeMu.TryLock() {
+		return nil, ErrRecomputeInProgress
+	}
+	defer s.recomputeMu.Unlock()
+
+	quiz, err := s.quizRepo.GetWithQuestions(quizID)
+	if err != nil {
+		return nil, quizLookupError(quizID, err)
+	}
+	if !quiz.IsCompleted() {
+		return nil, ErrQuizNotRecomputable
+	}
+
+	results, err := s.resultRepo.GetQuizResults(quizID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get quiz results: %w", err)
+	}
+	answers, err := s.resultRepo.GetQuizUserAnswers(quizID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get quiz answers: %w", err)
+	}
+
+	changes := planResultRecompute(quiz, results, answers)
+	report := &ResultRecomputeReport{
+		QuizID:         quizID,
+		DryRun:         dryRun,
+		ResultsChecked: len(results),
+		AnswersChecked: len(answers),
+		Changes:        make([]ResultRecomputeDiff, 0, len(changes)),
+	}
+	for _, change := range changes {
+		report.Changes = append(report.Changes, change.diff)
+	}
+
+	if !dryRun && len(changes) > 0 {
+		if err := s.applyResultRecompute(changes); err != nil {
+			return nil, err
+		}
+		if err := s.resultRepo.CalculateRanks(quizID); err != nil {
+			return nil, fmt.Errorf("failed to recalculate ranks: %w", err)
+		}
+	}
+
+	log.Printf("[ResultService] AUDIT: администратор ID=%d пересчитал результаты викторины #%d: проверено %d, изменено %d (dry_run=%v)",
+		adminID, quizID, len(results), len(changes), dryRun)
+	return report, nil
+}
+
+// applyResultRecompute сохраняет пересчитанные ответы и результаты в одной транзакции.
+// Общий счет игрока меняется на разницу, высший счет берется заново по всем результатам.
+func (s *ResultService) applyResultRecompute(changes []resultRecompute) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		for _, change := range changes {
+			for _, answer := range change.answers {
+				if err := tx.Model(&entity.UserAnswer{}).Where("id = ?", answer.ID).Updates(map[string]interface{}{
+					"is_correct": answer.IsCorrect,
+					"score":      answer.Score,
+					"penalty":    answer.Penalty,
+				}).Error; err != nil {
+					return fmt.Errorf("failed to update answer #%d: %w", answer.ID, err)
+				}
+			}
+
+			diff := change.diff
+			if err := tx.Model(&entity.Result{}).Where("id = ?", change.resultID).Updates(map[string]interface{}{
+				"score":           diff.NewScore,
+				"correct_answers": diff.NewCorrectAnswers,
+			}).Error; err != nil {
+				return fmt.Errorf("failed to update result #%d: %w", change.resultID, err)
+			}
+
+			if delta := diff.NewScore - diff.OldScore; delta != 0 {
+				if err := tx.Model(&entity.User{}).Where("id = ?", diff.UserID).
+					Update("total_score", gorm.Expr("total_score + ?", delta)).Error; err != nil {
+					return fmt.Errorf("failed to update total score of user #%d: %w", diff.UserID, err)
+				}
+			}
+			if err := tx.Model(&entity.User{}).Where("id = ?", diff.UserID).
+				Update("highest_score", gorm.Expr("(SELECT COALESCE(MAX(score), 0) FROM results WHERE user_id = ?)", diff.UserID)).Error; err != nil {
+				return fmt.Errorf("failed to update highest score of user #%d: %w", diff.UserID, err)
+			}
+		}
+		return nil
+	})
+}
+
+// planResultRecompute пересчитывает ответы каждого участника и возвращает
+// только результаты, которые изменились
+func planResultRecompute(quiz *entity.Quiz, results []entity.Result, answers []entity.UserAnswer) []resultRecompute {
+	questions := make(map[uint]*entity.Question, len(quiz.Questions))
+	for i := range quiz.Questions {
+		questions[quiz.Questions[i].ID] = &quiz.Questions[i]
+	}
+	answersByUser := make(map[uint][]entity.UserAnswer)
+	for _, answer := range answers {
+		answersByUser[answer.UserID] = append(answersByUser[answer.UserID], answer)
+	}
+
+	var changes []resultRecompute
+	for _, result := range results {
+		userAnswers := answersByUser[result.UserID]
+		rescored := make([]entity.UserAnswer, len(userAnswers))
+		var changed []entity.UserAnswer
+		for i, answer := range userAnswers {
+			rescored[i] = rescoreAnswer(quiz, questions[answer.QuestionID], answer)
+			if rescored[i].Score != answer.Score || rescored[i].IsCorrect != answer.IsCorrect || rescored[i].Penalty != answer.Penalty {
+				changed = append(changed, rescored[i])
+			}
+		}
+
+		score, correct := scoreAnswers(quiz, rescored)
+		if len(changed) == 0 && score == result.Score && correct == result.CorrectAnswers {
+			continue
+		}
+		changes = append(changes, resultRecompute{
+			resultID: result.ID,
+			answers:  changed,
+			diff: ResultRecomputeDiff{
+				UserID:            result.UserID,
+				Username:          result.Username,
+				OldScore:          result.Score,
+				NewScore:          score,
+				OldCorrectAnswers: result.CorrectAnswers,
+				NewCorrectAnswers: correct,
+				ChangedAnswers:    len(changed),
+			},
+		})
+	}
+	return changes
+}
+
+// rescoreAnswer подсчитывает очки и штраф за сохраненный ответ так же, как
+// AnswerProcessor при приеме ответа. Ответ на удаленный из викторины вопрос и
+// опоздание, прощенное после переподключения, остаются без изменений. Прощенное
+// опоздание сохраняется без причины выбывания; опоздание, стоившее жизни, имеет
+// причину time_exceeded, хотя игрок и не выбыл, и пересчитывается как обычно.
+func rescoreAnswer(quiz *entity.Quiz, question *entity.Question, answer entity.UserAnswer) entity.UserAnswer {
+	if question == nil {
+		return answer
+	}
+	if !answer.IsEliminated && answer.EliminationReason == "" && answer.ResponseTimeMs > int64(question.TimeLimitSec*1000) {
+		return answer
+	}
+
+	scored := *question
+	scored.PointValue = quiz.EffectivePointValue(question)
+	if question.IsOrdering() {
+		credit := question.OrderingCredit(answer.SelectedOrder)
+		answer.IsCorrect = credit == 1
+		answer.Score = scored.CalculateOrderingPoints(credit, answer.ResponseTimeMs)
+	} else {
+		answer.IsCorrect = question.IsCorrect(answer.SelectedOption)
+		answer.Score = scored.CalculatePoints(answer.IsCorrect, answer.ResponseTimeMs)
+	}
+
+	// Бонус за первый правильный ответ сохраняется, пока ответ остается правильным
+	if answer.FastestFinger && answer.IsCorrect {
+		answer.Score += quiz.FastestFingerBonus
+	}
+
+	answer.Penalty = 0
+	if !answer.IsCorrect {
+		answer.Penalty = quiz.WrongAnswerPenalty
+	}
+	return answer
+}
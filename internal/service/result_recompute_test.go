@@ -0,0 +1,137 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourusername/trivia-api/internal/domain/entity"
+	"github.com/yourusername/trivia-api/internal/domain/repository"
+)
+
+type recomputeQuizRepo struct {
+	repository.QuizRepository
+	quiz *entity.Quiz
+}
+
+func (r *recomputeQuizRepo) GetWithQuestions(id uint) (*entity.Quiz, error) {
+	if r.quiz == nil || r.quiz.ID != id {
+		return nil, repository.ErrNotFound
+	}
+	return r.quiz, nil
+}
+
+type recomputeResultRepo struct {
+	repository.ResultRepository
+	results       []entity.Result
+	answers       []entity.UserAnswer
+	rankedQuizzes []uint
+}
+
+func (r *recomputeResultRepo) GetQuizResults(quizID uint) ([]entity.Result, error) {
+	return r.results, nil
+}
+
+func (r *recomputeResultRepo) GetQuizUserAnswers(quizID uint) ([]entity.UserAnswer, error) {
+	return r.answers, nil
+}
+
+func (r *recomputeResultRepo) CalculateRanks(quizID uint) error {
+	r.rankedQuizzes = append(r.rankedQuizzes, quizID)
+	return nil
+}
+
+// newRecomputeFixture - викторина, в которой при проведении неверно был указан
+// правильный ответ на второй вопрос
+func newRecomputeFixture() (*recomputeQuizRepo, *recomputeResultRepo) {
+	quiz := &entity.Quiz{
+		ID:                 7,
+		Status:             "completed",
+		WrongAnswerPenalty: 3,
+		Questions: []entity.Question{
+			{ID: 1, CorrectOption: 2, PointValue: 10, TimeLimitSec: 10},
+			{ID: 2, CorrectOption: 1, PointValue: 10, TimeLimitSec: 10},
+		},
+	}
+	results := &recomputeResultRepo{
+		results: []entity.Result{
+			{ID: 11, UserID: 1, Username: "alice", Score: 10, CorrectAnswers: 1, IsEliminated: true},
+			{ID: 12, UserID: 2, Username: "bob", Score: 7, CorrectAnswers: 1, IsEliminated: true},
+			{ID: 13, UserID: 3, Username: "carol", Score: 0, CorrectAnswers: 0},
+		},
+		answers: []entity.UserAnswer{
+			{ID: 1, UserID: 1, QuestionID: 1, SelectedOption: 2, ResponseTimeMs: 1000, IsCorrect: true, Score: 10},
+			{ID: 2, UserID: 1, QuestionID: 2, SelectedOption: 1, ResponseTimeMs: 1000, Penalty: 3, IsEliminated: true},
+			{ID: 3, UserID: 2, QuestionID: 1, SelectedOption: 2, ResponseTimeMs: 1000, IsCorrect: true, Score: 10},
+			{ID: 4, UserID: 2, QuestionID: 2, SelectedOption: 0, ResponseTimeMs: 1000, Penalty: 3, IsEliminated: true},
+			// Опоздание, прощенное после переподключения
+			{ID: 5, UserID: 3, QuestionID: 1, SelectedOption: 2, ResponseTimeMs: 12000},
+		},
+	}
+	return &recomputeQuizRepo{quiz: quiz}, results
+}
+
+func TestRecomputeQuizResults_DryRunReportsDiffs(t *testing.T) {
+	quizRepo, resultRepo := newRecomputeFixture()
+	svc := &ResultService{quizRepo: quizRepo, resultRepo: resultRepo}
+
+	report, err := svc.RecomputeQuizResults(7, 1, true)
+	require.NoError(t, err)
+	assert.True(t, report.DryRun)
+	assert.Equal(t, 3, report.ResultsChecked)
+	assert.Equal(t, 5, report.AnswersChecked)
+	assert.Equal(t, []ResultRecomputeDiff{{
+		UserID: 1, Username: "alice",
+		OldScore: 10, NewScore: 20,
+		OldCorrectAnswers: 1, NewCorrectAnswers: 2,
+		ChangedAnswers: 1,
+	}}, report.Changes)
+	assert.Empty(t, resultRepo.rankedQuizzes, "dry-run ничего не сохраняет")
+}
+
+func TestRecomputeQuizResults_Guards(t *testing.T) {
+	quizRepo, resultRepo := newRecomputeFixture()
+	svc := &ResultService{quizRepo: quizRepo, resultRepo: resultRepo}
+
+	_, err := svc.RecomputeQuizResults(8, 1, true)
+	assert.ErrorIs(t, err, ErrQuizNotFound)
+
+	quizRepo.quiz.Status = "in_progress"
+	_, err = svc.RecomputeQuizResults(7, 1, true)
+	assert.ErrorIs(t, err, ErrQuizNotRecomputable)
+
+	quizRepo.quiz.Status = "completed"
+	svc.recomputeMu.Lock()
+	_, err = svc.RecomputeQuizResults(7, 1, true)
+	svc.recomputeMu.Unlock()
+	assert.ErrorIs(t, err, ErrRecomputeInProgress)
+}
+
+func TestRescoreAnswer_UsesCurrentQuizScoring(t *testing.T) {
+	quiz := &entity.Quiz{UniformPointValue: 50, WrongAnswerPenalty: 5}
+	question := &entity.Question{ID: 1, CorrectOption: 0, PointValue: 10, TimeLimitSec: 10}
+
+	correct := rescoreAnswer(quiz, question, entity.UserAnswer{QuestionID: 1, SelectedOption: 0, ResponseTimeMs: 500, Score: 10})
+	assert.True(t, correct.IsCorrect)
+	assert.Equal(t, 50, correct.Score)
+	assert.Zero(t, correct.Penalty)
+
+	wrong := rescoreAnswer(quiz, question, entity.UserAnswer{QuestionID: 1, SelectedOption: 3, ResponseTimeMs: 500, IsEliminated: true})
+	assert.False(t, wrong.IsCorrect)
+	assert.Equal(t, 5, wrong.Penalty)
+
+	// Стоимость, уменьшенная множителем, не заменяется базовой стоимостью вопроса
+	scaled := &entity.Quiz{PointsMultiplier: 0.04}
+	small := rescoreAnswer(scaled, question, entity.UserAnswer{QuestionID: 1, SelectedOption: 0, ResponseTimeMs: 500, Score: 10})
+	assert.Equal(t, 1, small.Score)
+
+	// Прощенное опоздание не меняется, а опоздание, стоившее жизни, пересчитывается
+	reprieved := entity.UserAnswer{QuestionID: 1, SelectedOption: 3, ResponseTimeMs: 12000}
+	assert.Equal(t, reprieved, rescoreAnswer(quiz, question, reprieved))
+	lifeLost := rescoreAnswer(quiz, question, entity.UserAnswer{QuestionID: 1, SelectedOption: 3, ResponseTimeMs: 12000, EliminationReason: "time_exceeded"})
+	assert.Equal(t, 5, lifeLost.Penalty)
+
+	// Ответ на удаленный вопрос не меняется
+	orphan := entity.UserAnswer{QuestionID: 9, Score: 10, IsCorrect: true}
+	assert.Equal(t, orphan, rescoreAnswer(quiz, nil, orphan))
+}
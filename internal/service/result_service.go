@@ -4,12 +4,14 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"sync"
 	"time"
 
 	"gorm.io/gorm"
 
 	"github.com/yourusername/trivia-api/internal/domain/entity"
 	"github.com/yourusername/trivia-api/internal/domain/repository"
+	"github.com/yourusername/trivia-api/internal/service/quizmanager"
 	"github.com/yourusername/trivia-api/internal/websocket"
 )
 
@@ -22,6 +24,15 @@ type ResultService struct {
 	cacheRepo    repository.CacheRepository
 	db           *gorm.DB
 	wsManager    *websocket.Manager
+
+	// finalResultsWebhook получает итоговую таблицу после финализации (nil - выключено)
+	finalResultsWebhook *FinalResultsWebhook
+
+	// tournamentRepo нужен для общего зачета турниров (nil - турниры не настроены)
+	tournamentRepo repository.TournamentRepository
+
+	// recomputeMu не дает запустить два пересчета результатов одновременно
+	recomputeMu sync.Mutex
 }
 
 // NewResultService создает новый сервис результатов
@@ -45,6 +56,11 @@ func NewResultService(
 	}
 }
 
+// SetFinalResultsWebhook включает отправку итоговых результатов во внешние системы
+func (s *ResultService) SetFinalResultsWebhook(webhook *FinalResultsWebhook) {
+	s.finalResultsWebhook = webhook
+}
+
 /*
 // ProcessUserAnswer обрабатывает ответ пользователя на вопрос
 // !!! ЭТА ФУНКЦИЯ НЕ ИСПОЛЬЗУЕТСЯ И ЛОГИКА ДУБЛИРУЕТСЯ/РЕАЛИЗОВАНА В quizmanager.AnswerProcessor !!!
@@ -90,6 +106,21 @@ func (s *ResultService) ProcessUserAnswer(userID, quizID, questionID uint, selec
 }
 */
 
+// scoreAnswers возвращает итоговый счет и число правильных ответов.
+// Штрафы за неверные ответы вычитаются из набранных очков, но не опускают
+// счет ниже Quiz.ScoreFloor.
+func scoreAnswers(quiz *entity.Quiz, answers []entity.UserAnswer) (totalScore, correctAnswers int) {
+	earned, penalty := 0, 0
+	for _, answer := range answers {
+		earned += answer.Score
+		penalty += answer.Penalty
+		if answer.IsCorrect {
+			correctAnswers++
+		}
+	}
+	return quiz.ApplyPenalties(earned, penalty), correctAnswers
+}
+
 // CalculateQuizResult подсчитывает итоговый результат пользователя в викторине
 func (s *ResultService) CalculateQuizResult(userID, quizID uint) (*entity.Result, error) {
 	// Получаем информацию о пользователе
@@ -115,14 +146,7 @@ func (s *ResultService) CalculateQuizResult(userID, quizID uint) (*entity.Result
 	isEliminated, _ := s.cacheRepo.Exists(eliminationKey)
 
 	// Подсчитываем общий счет и количество правильных ответов
-	totalScore := 0
-	correctAnswers := 0
-	for _, answer := range userAnswers {
-		totalScore += answer.Score
-		if answer.IsCorrect {
-			correctAnswers++
-		}
-	}
+	totalScore, correctAnswers := scoreAnswers(quiz, userAnswers)
 
 	// Создаем запись о результате
 	result := &entity.Result{
@@ -132,8 +156,10 @@ func (s *ResultService) CalculateQuizResult(userID, quizID uint) (*entity.Result
 		ProfilePicture: user.ProfilePicture,
 		Score:          totalScore,
 		CorrectAnswers: correctAnswers,
-		TotalQuestions: len(quiz.Questions),
+		// Присоединившийся во время проведения отвечал только с вопроса присоединения
+		TotalQuestions: quiz.QuestionsAvailableFrom(quizmanager.JoinedAtQuestion(s.cacheRepo, quizID, userID)),
 		IsEliminated:   isEliminated,
+		Country:        quizmanager.ParticipantCountry(s.cacheRepo, quizID, userID),
 		CompletedAt:    time.Now(),
 	}
 
@@ -188,6 +214,24 @@ func (s *ResultService) CalculateQuizResult(userID, quizID uint) (*entity.Result
 	return result, nil
 }
 
+// SaveSelfPacedResults сохраняет итоговые результаты игроков самостоятельного
+// прохождения викторины. Уже сохраненные результаты не пересчитываются,
+// ошибка по одному игроку не прерывает подсчет остальных.
+func (s *ResultService) SaveSelfPacedResults(quizID uint, userIDs []uint) {
+	saved := 0
+	for _, userID := range userIDs {
+		if _, err := s.resultRepo.GetUserResult(userID, quizID); err == nil {
+			continue
+		}
+		if _, err := s.CalculateQuizResult(userID, quizID); err != nil {
+			log.Printf("[ResultService] Ошибка при подсчете результата пользователя #%d в викторине #%d: %v", userID, quizID, err)
+			continue
+		}
+		saved++
+	}
+	log.Printf("[ResultService] Сохранено %d результатов самостоятельного прохождения викторины #%d из %d участников", saved, quizID, len(userIDs))
+}
+
 // GetQuizResults возвращает все результаты для викторины
 func (s *ResultService) GetQuizResults(quizID uint) ([]entity.Result, error) {
 	// Пересчитываем ранги перед получением результатов
@@ -203,6 +247,22 @@ func (s *ResultService) GetUserResult(userID, quizID uint) (*entity.Result, erro
 	return s.resultRepo.GetUserResult(userID, quizID)
 }
 
+// GetUserAnswerHistory возвращает викторину с вопросами и сохраненные ответы пользователя на них.
+// Решение о раскрытии правильных ответов принимается на уровне представления по статусу викторины.
+func (s *ResultService) GetUserAnswerHistory(userID, quizID uint) (*entity.Quiz, []entity.UserAnswer, error) {
+	quiz, err := s.quizRepo.GetWithQuestions(quizID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrQuizNotFound, err)
+	}
+
+	answers, err := s.resultRepo.GetUserAnswers(userID, quizID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get user answers: %w", err)
+	}
+
+	return quiz, answers, nil
+}
+
 // GetUserResults возвращает все результаты пользователя с пагинацией
 func (s *ResultService) GetUserResults(userID uint, page, pageSize int) ([]entity.Result, error) {
 	offset := (page - 1) * pageSize
@@ -253,10 +313,46 @@ func (s *ResultService) DetermineWinnersAndAllocatePrizes(ctx context.Context, q
 	}
 	// ===>>> КОНЕЦ ИЗМЕНЕНИЯ <<<===
 
+	s.publishFinalResults(quizID)
+
 	log.Printf("[ResultService] Финализация результатов для викторины #%d успешно завершена.", quizID)
 	return nil
 }
 
+// publishFinalResults отправляет окончательную таблицу событием quiz:final_results
+// и, если настроен, вебхуком. Ошибки не прерывают финализацию: результаты уже сохранены.
+func (s *ResultService) publishFinalResults(quizID uint) {
+	if s.wsManager == nil && s.finalResultsWebhook == nil {
+		return
+	}
+
+	results, err := s.resultRepo.GetQuizResults(quizID)
+	if err != nil {
+		log.Printf("[ResultService] Ошибка при получении итоговых результатов викторины #%d: %v", quizID, err)
+		return
+	}
+	final := NewFinalResults(quizID, results, time.Now().UTC())
+
+	if s.wsManager != nil {
+		fullEvent := map[string]interface{}{
+			"type": "quiz:final_results",
+			"data": final,
+		}
+		if err := s.wsManager.BroadcastEventToQuiz(quizID, fullEvent); err != nil {
+			log.Printf("[ResultService] Ошибка при отправке события quiz:final_results для викторины #%d: %v", quizID, err)
+		}
+	}
+
+	if s.finalResultsWebhook != nil {
+		// Доставка с ретраями не должна задерживать завершение викторины
+		go func() {
+			if err := s.finalResultsWebhook.Deliver(context.Background(), final); err != nil {
+				log.Printf("[ResultService] %v", err)
+			}
+		}()
+	}
+}
+
 // GetQuizWinners возвращает список победителей викторины
 func (s *ResultService) GetQuizWinners(quizID uint) ([]entity.Result, error) {
 	return s.resultRepo.GetQuizWinners(quizID)
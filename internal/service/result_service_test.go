@@ -0,0 +1,25 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yourusername/trivia-api/internal/domain/entity"
+)
+
+func TestScoreAnswers_PenaltiesStopAtFloor(t *testing.T) {
+	answers := []entity.UserAnswer{{IsCorrect: true, Score: 30}}
+	for i := 0; i < 10; i++ {
+		answers = append(answers, entity.UserAnswer{Penalty: 5})
+	}
+
+	total, correct := scoreAnswers(&entity.Quiz{WrongAnswerPenalty: 5}, answers)
+	assert.Equal(t, 0, total, "по умолчанию счет не уходит ниже нуля")
+	assert.Equal(t, 1, correct)
+
+	total, _ = scoreAnswers(&entity.Quiz{WrongAnswerPenalty: 5, ScoreFloor: 10}, answers)
+	assert.Equal(t, 10, total)
+
+	total, _ = scoreAnswers(&entity.Quiz{WrongAnswerPenalty: 5}, answers[:3])
+	assert.Equal(t, 20, total, "штрафы вычитаются, пока счет выше границы")
+}
@@ -0,0 +1,183 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/yourusername/trivia-api/internal/domain/repository"
+)
+
+// ErrRetentionInProgress возвращается, если очистка уже выполняется
+var ErrRetentionInProgress = errors.New("retention cleanup already in progress")
+
+// RetentionOptions содержит настройки очистки старых викторин
+type RetentionOptions struct {
+	RetentionDays int           // Возраст викторины (по времени проведения), после которого она удаляется
+	Interval      time.Duration // Интервал фонового запуска
+	DryRun        bool          // Только логировать, что было бы удалено
+	BatchSize     int           // Размер пакета при удалении ответов и результатов
+	MaxQuizzes    int           // Максимум викторин за один запуск
+}
+
+// RetentionQuizReport описывает одну викторину, попавшую под очистку
+type RetentionQuizReport struct {
+	QuizID        uint      `json:"quiz_id"`
+	Title         string    `json:"title"`
+	ScheduledTime time.Time `json:"scheduled_time"`
+	Answers       int64     `json:"answers"`
+	Results       int64     `json:"results"`
+}
+
+// RetentionReport содержит итог запуска очистки
+type RetentionReport struct {
+	DryRun   bool                  `json:"dry_run"`
+	Cutoff   time.Time             `json:"cutoff"`
+	Quizzes  []RetentionQuizReport `json:"quizzes"`
+	Answers  int64                 `json:"answers_deleted"`
+	Results  int64                 `json:"results_deleted"`
+	Duration string                `json:"duration"`
+}
+
+// RetentionService удаляет старые завершенные викторины вместе с ответами и результатами.
+// Итоговые очки пользователей (users.total_score, highest_score) хранятся отдельно
+// и при удалении результатов не меняются.
+type RetentionService struct {
+	quizRepo   repository.QuizRepository
+	resultRepo repository.ResultRepository
+	options    RetentionOptions
+
+	// running не дает фоновому и ручному запуску выполняться одновременно
+	running sync.Mutex
+}
+
+// NewRetentionService создает сервис очистки
+func NewRetentionService(
+	quizRepo repository.QuizRepository,
+	resultRepo repository.ResultRepository,
+	options RetentionOptions,
+) *RetentionService {
+	if options.BatchSize <= 0 {
+		options.BatchSize = 1000
+	}
+	if options.MaxQuizzes <= 0 {
+		options.MaxQuizzes = 100
+	}
+	if options.Interval <= 0 {
+		options.Interval = 24 * time.Hour
+	}
+
+	return &RetentionService{
+		quizRepo:   quizRepo,
+		resultRepo: resultRepo,
+		options:    options,
+	}
+}
+
+// Start запускает периодическую очистку до отмены ctx
+func (s *RetentionService) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(s.options.Interval)
+		defer ticker.Stop()
+
+		log.Printf("[RetentionService] Запуск периодической очистки викторин старше %d дней (каждые %v, dry-run: %v)",
+			s.options.RetentionDays, s.options.Interval, s.options.DryRun)
+
+		for {
+			select {
+			case <-ticker.C:
+				if _, err := s.Run(ctx, s.options.DryRun); err != nil {
+					log.Printf("[RetentionService] Ошибка при очистке: %v", err)
+				}
+			case <-ctx.Done():
+				log.Println("[RetentionService] Завершение работы горутины очистки викторин")
+				return
+			}
+		}
+	}()
+}
+
+// Run выполняет один проход очистки. В режиме dryRun только собирает и логирует
+// викторины, которые были бы удалены.
+func (s *RetentionService) Run(ctx context.Context, dryRun bool) (*RetentionReport, error) {
+	if s.options.RetentionDays <= 0 {
+		return nil, fmt.Errorf("%w: retention days must be positive", ErrValidation)
+	}
+	if !s.running.TryLock() {
+		return nil, ErrRetentionInProgress
+	}
+	defer s.running.Unlock()
+
+	start := time.Now()
+	report := &RetentionReport{
+		DryRun:  dryRun,
+		Cutoff:  start.AddDate(0, 0, -s.options.RetentionDays),
+		Quizzes: []RetentionQuizReport{},
+	}
+
+	quizzes, err := s.quizRepo.ListFinishedBefore(report.Cutoff, s.options.MaxQuizzes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list quizzes for cleanup: %w", err)
+	}
+
+	for _, quiz := range quizzes {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+
+		item := RetentionQuizReport{QuizID: quiz.ID, Title: quiz.Title, ScheduledTime: quiz.ScheduledTime}
+
+		if dryRun {
+			item.Answers, item.Results, err = s.resultRepo.CountQuizData(quiz.ID)
+			if err != nil {
+				return report, fmt.Errorf("failed to count data of quiz %d: %w", quiz.ID, err)
+			}
+			log.Printf("[RetentionService] [dry-run] Викторина #%d (%q, %s) была бы удалена: %d ответов, %d результатов",
+				quiz.ID, quiz.Title, quiz.ScheduledTime.Format(time.RFC3339), item.Answers, item.Results)
+			report.Quizzes = append(report.Quizzes, item)
+			continue
+		}
+
+		if item.Answers, err = s.deleteInBatches(ctx, quiz.ID, s.resultRepo.DeleteQuizUserAnswersBatch); err != nil {
+			return report, fmt.Errorf("failed to delete answers of quiz %d: %w", quiz.ID, err)
+		}
+		if item.Results, err = s.deleteInBatches(ctx, quiz.ID, s.resultRepo.DeleteQuizResultsBatch); err != nil {
+			return report, fmt.Errorf("failed to delete results of quiz %d: %w", quiz.ID, err)
+		}
+		// Вопросы удаляются каскадно вместе с викториной
+		if err := s.quizRepo.Delete(quiz.ID); err != nil {
+			return report, fmt.Errorf("failed to delete quiz %d: %w", quiz.ID, err)
+		}
+
+		log.Printf("[RetentionService] Удалена викторина #%d (%q): %d ответов, %d результатов",
+			quiz.ID, quiz.Title, item.Answers, item.Results)
+		report.Quizzes = append(report.Quizzes, item)
+		report.Answers += item.Answers
+		report.Results += item.Results
+	}
+
+	report.Duration = time.Since(start).String()
+	log.Printf("[RetentionService] Очистка завершена за %s: викторин %d, dry-run: %v", report.Duration, len(report.Quizzes), dryRun)
+	return report, nil
+}
+
+// deleteInBatches повторяет пакетное удаление, пока оно не вернет 0 строк
+func (s *RetentionService) deleteInBatches(ctx context.Context, quizID uint, deleteBatch func(quizID uint, batchSize int) (int64, error)) (int64, error) {
+	var total int64
+	for {
+		if err := ctx.Err(); err != nil {
+			return total, err
+		}
+		deleted, err := deleteBatch(quizID, s.options.BatchSize)
+		if err != nil {
+			return total, err
+		}
+		total += deleted
+		if deleted < int64(s.options.BatchSize) {
+			return total, nil
+		}
+	}
+}
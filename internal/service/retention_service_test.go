@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourusername/trivia-api/internal/domain/entity"
+	"github.com/yourusername/trivia-api/internal/domain/repository"
+)
+
+// retentionQuizRepo хранит викторины в памяти
+type retentionQuizRepo struct {
+	repository.QuizRepository
+	quizzes []entity.Quiz
+	deleted []uint
+}
+
+func (r *retentionQuizRepo) ListFinishedBefore(cutoff time.Time, limit int) ([]entity.Quiz, error) {
+	var found []entity.Quiz
+	for _, q := range r.quizzes {
+		if (q.Status == "completed" || q.Status == "cancelled") && q.ScheduledTime.Before(cutoff) {
+			found = append(found, q)
+		}
+	}
+	if len(found) > limit {
+		found = found[:limit]
+	}
+	return found, nil
+}
+
+func (r *retentionQuizRepo) Delete(id uint) error {
+	r.deleted = append(r.deleted, id)
+	return nil
+}
+
+// retentionResultRepo хранит количество ответов и результатов по викторинам
+type retentionResultRepo struct {
+	repository.ResultRepository
+	answers    map[uint]int64
+	results    map[uint]int64
+	batchCalls int
+}
+
+func (r *retentionResultRepo) CountQuizData(quizID uint) (int64, int64, error) {
+	return r.answers[quizID], r.results[quizID], nil
+}
+
+func (r *retentionResultRepo) deleteBatch(store map[uint]int64, quizID uint, batchSize int) (int64, error) {
+	r.batchCalls++
+	deleted := store[quizID]
+	if deleted > int64(batchSize) {
+		deleted = int64(batchSize)
+	}
+	store[quizID] -= deleted
+	return deleted, nil
+}
+
+func (r *retentionResultRepo) DeleteQuizUserAnswersBatch(quizID uint, batchSize int) (int64, error) {
+	return r.deleteBatch(r.answers, quizID, batchSize)
+}
+
+func (r *retentionResultRepo) DeleteQuizResultsBatch(quizID uint, batchSize int) (int64, error) {
+	return r.deleteBatch(r.results, quizID, batchSize)
+}
+
+func newRetentionFixture() (*retentionQuizRepo, *retentionResultRepo) {
+	old := time.Now().AddDate(0, 0, -400)
+	quizRepo := &retentionQuizRepo{quizzes: []entity.Quiz{
+		{ID: 1, Title: "old", Status: "completed", ScheduledTime: old},
+		{ID: 2, Title: "old cancelled", Status: "cancelled", ScheduledTime: old},
+		{ID: 3, Title: "recent", Status: "completed", ScheduledTime: time.Now().AddDate(0, 0, -10)},
+		{ID: 4, Title: "old scheduled", Status: "scheduled", ScheduledTime: old},
+	}}
+	resultRepo := &retentionResultRepo{
+		answers: map[uint]int64{1: 25, 3: 5},
+		results: map[uint]int64{1: 7, 3: 2},
+	}
+	return quizRepo, resultRepo
+}
+
+func TestRetentionService_DryRunDeletesNothing(t *testing.T) {
+	quizRepo, resultRepo := newRetentionFixture()
+	s := NewRetentionService(quizRepo, resultRepo, RetentionOptions{RetentionDays: 365, BatchSize: 10})
+
+	report, err := s.Run(context.Background(), true)
+	require.NoError(t, err)
+
+	assert.True(t, report.DryRun)
+	require.Len(t, report.Quizzes, 2)
+	assert.Equal(t, uint(1), report.Quizzes[0].QuizID)
+	assert.Equal(t, int64(25), report.Quizzes[0].Answers)
+	assert.Empty(t, quizRepo.deleted)
+	assert.Zero(t, resultRepo.batchCalls)
+}
+
+func TestRetentionService_DeletesInBatches(t *testing.T) {
+	quizRepo, resultRepo := newRetentionFixture()
+	s := NewRetentionService(quizRepo, resultRepo, RetentionOptions{RetentionDays: 365, BatchSize: 10})
+
+	report, err := s.Run(context.Background(), false)
+	require.NoError(t, err)
+
+	assert.Equal(t, []uint{1, 2}, quizRepo.deleted, "недавние и незавершенные викторины не удаляются")
+	assert.Equal(t, int64(25), report.Answers)
+	assert.Equal(t, int64(7), report.Results)
+	assert.Zero(t, resultRepo.answers[1])
+	assert.Equal(t, int64(5), resultRepo.answers[3])
+	// Викторина 1: ответы 10+10+5, результаты 7; викторина 2: по одному пустому пакету
+	assert.Equal(t, 6, resultRepo.batchCalls)
+}
+
+func TestRetentionService_RequiresPositiveRetention(t *testing.T) {
+	quizRepo, resultRepo := newRetentionFixture()
+	s := NewRetentionService(quizRepo, resultRepo, RetentionOptions{})
+
+	_, err := s.Run(context.Background(), true)
+	assert.ErrorIs(t, err, ErrValidation)
+}
@@ -0,0 +1,148 @@
+package service
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/yourusername/trivia-api/internal/domain/entity"
+	"github.com/yourusername/trivia-api/internal/domain/repository"
+)
+
+// DefaultStatsCacheTTL - как долго переиспользуется подсчитанная статистика.
+// Страница со статистикой открывается часто, а точность до секунд не нужна.
+const DefaultStatsCacheTTL = 30 * time.Second
+
+// OnlineCounter - источник числа подключенных пользователей (WebSocket-хаб)
+type OnlineCounter interface {
+	ClientCount() int
+}
+
+// PlatformStats - общая статистика платформы, доступная без аутентификации
+type PlatformStats struct {
+	RegisteredUsers  int64     `json:"registered_users"`
+	QuizzesCompleted int64     `json:"quizzes_completed"`
+	TotalAnswers     int64     `json:"total_answers"`
+	ActiveQuizzes    int64     `json:"active_quizzes"`
+	OnlineUsers      int       `json:"online_users"` // Подключенные к этому экземпляру сервера
+	GeneratedAt      time.Time `json:"generated_at"`
+}
+
+// AdminPlatformStats - статистика для администраторов: дополнительно учитывает
+// еще не проведенные и отмененные викторины, в том числе неопубликованные
+type AdminPlatformStats struct {
+	PlatformStats
+	QuizzesCreated  int64            `json:"quizzes_created"`
+	QuizzesByStatus map[string]int64 `json:"quizzes_by_status"`
+}
+
+// StatsService подсчитывает статистику платформы по нескольким репозиториям и
+// состоянию WebSocket-хаба. Результат кэшируется в памяти экземпляра на ttl,
+// чтобы частые запросы не нагружали БД.
+type StatsService struct {
+	userRepo   repository.UserRepository
+	quizRepo   repository.QuizRepository
+	resultRepo repository.ResultRepository
+	online     OnlineCounter
+	ttl        time.Duration
+
+	mu     sync.Mutex
+	cached *AdminPlatformStats
+}
+
+// NewStatsService создает сервис статистики. online может быть nil - тогда
+// число подключенных пользователей равно 0.
+func NewStatsService(
+	userRepo repository.UserRepository,
+	quizRepo repository.QuizRepository,
+	resultRepo repository.ResultRepository,
+	online OnlineCounter,
+) *StatsService {
+	return &StatsService{
+		userRepo:   userRepo,
+		quizRepo:   quizRepo,
+		resultRepo: resultRepo,
+		online:     online,
+		ttl:        DefaultStatsCacheTTL,
+	}
+}
+
+// CacheTTL возвращает время жизни кэша статистики
+func (s *StatsService) CacheTTL() time.Duration {
+	return s.ttl
+}
+
+// PublicStats возвращает статистику, доступную без аутентификации
+func (s *StatsService) PublicStats() (*PlatformStats, error) {
+	stats, err := s.AdminStats()
+	if err != nil {
+		return nil, err
+	}
+	public := stats.PlatformStats
+	return &public, nil
+}
+
+// AdminStats возвращает полную статистику платформы
+func (s *StatsService) AdminStats() (*AdminPlatformStats, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.cached != nil && time.Since(s.cached.GeneratedAt) < s.ttl {
+		return s.copyCached(), nil
+	}
+
+	stats, err := s.collect()
+	if err != nil {
+		return nil, err
+	}
+	s.cached = stats
+	return s.copyCached(), nil
+}
+
+// collect выполняет запросы подсчета
+func (s *StatsService) collect() (*AdminPlatformStats, error) {
+	users, err := s.userRepo.Count()
+	if err != nil {
+		return nil, fmt.Errorf("failed to count users: %w", err)
+	}
+	byStatus, err := s.quizRepo.CountByStatus()
+	if err != nil {
+		return nil, fmt.Errorf("failed to count quizzes: %w", err)
+	}
+	answers, err := s.resultRepo.CountAnswers()
+	if err != nil {
+		return nil, fmt.Errorf("failed to count answers: %w", err)
+	}
+
+	var created int64
+	for _, count := range byStatus {
+		created += count
+	}
+	online := 0
+	if s.online != nil {
+		online = s.online.ClientCount()
+	}
+
+	return &AdminPlatformStats{
+		PlatformStats: PlatformStats{
+			RegisteredUsers:  users,
+			QuizzesCompleted: byStatus[string(entity.QuizStatusCompleted)],
+			TotalAnswers:     answers,
+			ActiveQuizzes:    byStatus[string(entity.QuizStatusInProgress)],
+			OnlineUsers:      online,
+			GeneratedAt:      time.Now(),
+		},
+		QuizzesCreated:  created,
+		QuizzesByStatus: byStatus,
+	}, nil
+}
+
+// copyCached возвращает копию кэша, чтобы вызывающий код не изменил его
+func (s *StatsService) copyCached() *AdminPlatformStats {
+	stats := *s.cached
+	stats.QuizzesByStatus = make(map[string]int64, len(s.cached.QuizzesByStatus))
+	for status, count := range s.cached.QuizzesByStatus {
+		stats.QuizzesByStatus[status] = count
+	}
+	return &stats
+}
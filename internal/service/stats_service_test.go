@@ -0,0 +1,107 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourusername/trivia-api/internal/domain/repository"
+)
+
+// statsUserRepo считает пользователей и обращения к БД
+type statsUserRepo struct {
+	repository.UserRepository
+	users   int64
+	queries int
+}
+
+func (r *statsUserRepo) Count() (int64, error) {
+	r.queries++
+	return r.users, nil
+}
+
+type statsQuizRepo struct {
+	repository.QuizRepository
+	byStatus map[string]int64
+	err      error
+}
+
+func (r *statsQuizRepo) CountByStatus() (map[string]int64, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	counts := make(map[string]int64, len(r.byStatus))
+	for status, count := range r.byStatus {
+		counts[status] = count
+	}
+	return counts, nil
+}
+
+type statsResultRepo struct {
+	repository.ResultRepository
+	answers int64
+}
+
+func (r *statsResultRepo) CountAnswers() (int64, error) {
+	return r.answers, nil
+}
+
+type staticOnline int
+
+func (o staticOnline) ClientCount() int { return int(o) }
+
+func TestStatsService_AggregatesAndCaches(t *testing.T) {
+	users := &statsUserRepo{users: 120}
+	quizzes := &statsQuizRepo{byStatus: map[string]int64{
+		"scheduled": 3, "in_progress": 2, "completed": 10, "cancelled": 1,
+	}}
+	s := NewStatsService(users, quizzes, &statsResultRepo{answers: 4500}, staticOnline(42))
+
+	admin, err := s.AdminStats()
+	require.NoError(t, err)
+	assert.Equal(t, int64(120), admin.RegisteredUsers)
+	assert.Equal(t, int64(16), admin.QuizzesCreated)
+	assert.Equal(t, int64(10), admin.QuizzesCompleted)
+	assert.Equal(t, int64(2), admin.ActiveQuizzes)
+	assert.Equal(t, int64(4500), admin.TotalAnswers)
+	assert.Equal(t, 42, admin.OnlineUsers)
+	assert.Equal(t, int64(3), admin.QuizzesByStatus["scheduled"])
+
+	// Повторные запросы в пределах TTL не обращаются к БД
+	users.users = 121
+	admin.QuizzesByStatus["scheduled"] = 100
+	public, err := s.PublicStats()
+	require.NoError(t, err)
+	assert.Equal(t, int64(120), public.RegisteredUsers)
+	again, err := s.AdminStats()
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), again.QuizzesByStatus["scheduled"], "кэш не должен меняться через возвращенную копию")
+	assert.Equal(t, 1, users.queries)
+
+	// По истечении TTL статистика подсчитывается заново
+	s.mu.Lock()
+	s.cached.GeneratedAt = time.Now().Add(-DefaultStatsCacheTTL)
+	s.mu.Unlock()
+	public, err = s.PublicStats()
+	require.NoError(t, err)
+	assert.Equal(t, int64(121), public.RegisteredUsers)
+	assert.Equal(t, 2, users.queries)
+}
+
+func TestStatsService_ErrorNotCached(t *testing.T) {
+	users := &statsUserRepo{users: 5}
+	quizzes := &statsQuizRepo{err: errors.New("db is down")}
+	s := NewStatsService(users, quizzes, &statsResultRepo{}, nil)
+
+	_, err := s.PublicStats()
+	assert.Error(t, err)
+
+	quizzes.err = nil
+	stats, err := s.PublicStats()
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), stats.RegisteredUsers)
+	assert.Zero(t, stats.OnlineUsers)
+	assert.Equal(t, 2, users.queries)
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/yourusername/trivia-api/internal/domain/entity"
+	"github.com/yourusername/trivia-api/internal/domain/repository"
+)
+
+// ErrTournamentNotFound возвращается, когда турнир не найден
+var ErrTournamentNotFound = errors.New("tournament not found")
+
+// MaxTournamentBestN - максимальное число учитываемых лучших результатов при best_n
+const MaxTournamentBestN = 100
+
+// TournamentOptions задает новый турнир
+type TournamentOptions struct {
+	Title       string
+	Description string
+	// ScoringMode: entity.TournamentScoringSum (по умолчанию) или entity.TournamentScoringBestN
+	ScoringMode string
+	// BestN: сколько лучших результатов игрока учитывать при best_n
+	BestN int
+}
+
+// validate проверяет настройки и подставляет правило подсчета по умолчанию
+func (o *TournamentOptions) validate() error {
+	switch o.ScoringMode {
+	case "":
+		o.ScoringMode = entity.TournamentScoringSum
+	case entity.TournamentScoringSum, entity.TournamentScoringBestN:
+	default:
+		return fmt.Errorf("%w: scoring_mode must be %s or %s", ErrValidation, entity.TournamentScoringSum, entity.TournamentScoringBestN)
+	}
+	if o.ScoringMode == entity.TournamentScoringBestN && (o.BestN < 1 || o.BestN > MaxTournamentBestN) {
+		return fmt.Errorf("%w: best_n must be between 1 and %d for %s scoring", ErrValidation, MaxTournamentBestN, entity.TournamentScoringBestN)
+	}
+	if o.ScoringMode == entity.TournamentScoringSum && o.BestN != 0 {
+		return fmt.Errorf("%w: best_n is only allowed for %s scoring", ErrValidation, entity.TournamentScoringBestN)
+	}
+	return nil
+}
+
+// TournamentInfo - турнир и его викторины
+type TournamentInfo struct {
+	*entity.Tournament
+	QuizIDs []uint `json:"quiz_ids"`
+}
+
+// TournamentService управляет турнирами: созданием и составом викторин.
+// Общий зачет считает ResultService.TournamentStandings.
+type TournamentService struct {
+	tournamentRepo repository.TournamentRepository
+	quizRepo       repository.QuizRepository
+}
+
+// NewTournamentService создает новый сервис турниров
+func NewTournamentService(tournamentRepo repository.TournamentRepository, quizRepo repository.QuizRepository) *TournamentService {
+	return &TournamentService{
+		tournamentRepo: tournamentRepo,
+		quizRepo:       quizRepo,
+	}
+}
+
+// CreateTournament создает турнир без викторин
+func (s *TournamentService) CreateTournament(opts TournamentOptions) (*entity.Tournament, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+
+	tournament := &entity.Tournament{
+		Title:       opts.Title,
+		Description: opts.Description,
+		ScoringMode: opts.ScoringMode,
+		BestN:       opts.BestN,
+	}
+	if err := s.tournamentRepo.Create(tournament); err != nil {
+		return nil, fmt.Errorf("failed to create tournament: %w", err)
+	}
+	return tournament, nil
+}
+
+// GetTournament возвращает турнир и ID его викторин
+func (s *TournamentService) GetTournament(tournamentID uint) (*TournamentInfo, error) {
+	tournament, err := s.tournamentRepo.GetByID(tournamentID)
+	if err != nil {
+		return nil, tournamentLookupError(tournamentID, err)
+	}
+	quizIDs, err := s.tournamentRepo.GetQuizIDs(tournamentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get quizzes of tournament %d: %w", tournamentID, err)
+	}
+	return &TournamentInfo{Tournament: tournament, QuizIDs: quizIDs}, nil
+}
+
+// AddQuiz добавляет викторину в турнир. Добавить можно викторину в любом
+// состоянии, в том числе уже завершенную: ее результаты сразу попадают в зачет.
+func (s *TournamentService) AddQuiz(tournamentID, quizID uint) (*TournamentInfo, error) {
+	if _, err := s.tournamentRepo.GetByID(tournamentID); err != nil {
+		return nil, tournamentLookupError(tournamentID, err)
+	}
+	if _, err := s.quizRepo.GetByID(quizID); err != nil {
+		return nil, quizLookupError(quizID, err)
+	}
+	if err := s.tournamentRepo.AddQuiz(tournamentID, quizID); err != nil {
+		return nil, fmt.Errorf("failed to add quiz %d to tournament %d: %w", quizID, tournamentID, err)
+	}
+	return s.GetTournament(tournamentID)
+}
+
+// tournamentLookupError преобразует ошибку поиска турнира в ошибку сервиса
+func tournamentLookupError(tournamentID uint, err error) error {
+	if errors.Is(err, repository.ErrNotFound) {
+		return fmt.Errorf("%w: id %d", ErrTournamentNotFound, tournamentID)
+	}
+	return fmt.Errorf("failed to get tournament %d: %w", tournamentID, err)
+}
@@ -0,0 +1,157 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourusername/trivia-api/internal/domain/entity"
+	"github.com/yourusername/trivia-api/internal/domain/repository"
+)
+
+// memoryTournamentRepo хранит турниры в памяти; результаты берутся из results
+// по викторинам турнира, как в SQL-запросе репозитория
+type memoryTournamentRepo struct {
+	tournaments map[uint]*entity.Tournament
+	quizzes     map[uint][]uint
+	results     []entity.Result
+}
+
+func newMemoryTournamentRepo() *memoryTournamentRepo {
+	return &memoryTournamentRepo{tournaments: make(map[uint]*entity.Tournament), quizzes: make(map[uint][]uint)}
+}
+
+func (r *memoryTournamentRepo) Create(tournament *entity.Tournament) error {
+	tournament.ID = uint(len(r.tournaments) + 1)
+	r.tournaments[tournament.ID] = tournament
+	return nil
+}
+
+func (r *memoryTournamentRepo) GetByID(id uint) (*entity.Tournament, error) {
+	if tournament, ok := r.tournaments[id]; ok {
+		return tournament, nil
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *memoryTournamentRepo) AddQuiz(tournamentID, quizID uint) error {
+	for _, id := range r.quizzes[tournamentID] {
+		if id == quizID {
+			return nil
+		}
+	}
+	r.quizzes[tournamentID] = append(r.quizzes[tournamentID], quizID)
+	return nil
+}
+
+func (r *memoryTournamentRepo) GetQuizIDs(tournamentID uint) ([]uint, error) {
+	return r.quizzes[tournamentID], nil
+}
+
+func (r *memoryTournamentRepo) GetResults(tournamentID uint) ([]entity.Result, error) {
+	var results []entity.Result
+	for _, result := range r.results {
+		for _, quizID := range r.quizzes[tournamentID] {
+			if result.QuizID == quizID {
+				results = append(results, result)
+			}
+		}
+	}
+	return results, nil
+}
+
+// tournamentFixture - турнир из трех викторин и результаты двух игроков и
+// одной викторины вне турнира
+func tournamentFixture(t *testing.T, opts TournamentOptions) (*ResultService, uint) {
+	t.Helper()
+	repo := newMemoryTournamentRepo()
+	quizRepo := &parallelQuizRepo{quizzes: map[uint]*entity.Quiz{1: parallelQuiz(1), 2: parallelQuiz(2), 3: parallelQuiz(3), 4: parallelQuiz(4)}}
+	tournaments := NewTournamentService(repo, quizRepo)
+
+	opts.Title = "Кубок недели"
+	tournament, err := tournaments.CreateTournament(opts)
+	require.NoError(t, err)
+	for _, quizID := range []uint{1, 2, 3} {
+		_, err := tournaments.AddQuiz(tournament.ID, quizID)
+		require.NoError(t, err)
+	}
+
+	now := time.Now()
+	repo.results = []entity.Result{
+		{UserID: 1, QuizID: 1, Username: "alice", Score: 50, CorrectAnswers: 5, CompletedAt: now},
+		{UserID: 1, QuizID: 2, Username: "alice", Score: 10, CorrectAnswers: 1, CompletedAt: now},
+		{UserID: 1, QuizID: 3, Username: "alice", Score: 30, CorrectAnswers: 3, CompletedAt: now},
+		{UserID: 2, QuizID: 1, Username: "bob", Score: 45, CorrectAnswers: 4, CompletedAt: now},
+		{UserID: 2, QuizID: 3, Username: "bobby", Score: 40, CorrectAnswers: 4, CompletedAt: now.Add(time.Hour)},
+		// Викторина не входит в турнир
+		{UserID: 2, QuizID: 4, Username: "bob", Score: 100, CorrectAnswers: 10, CompletedAt: now},
+	}
+
+	resultService := NewResultService(nil, nil, quizRepo, nil, nil, nil, nil)
+	resultService.SetTournamentRepo(repo)
+	return resultService, tournament.ID
+}
+
+func TestTournamentStandings_Sum(t *testing.T) {
+	resultService, tournamentID := tournamentFixture(t, TournamentOptions{})
+
+	standings, err := resultService.TournamentStandings(tournamentID)
+	require.NoError(t, err)
+	assert.Equal(t, entity.TournamentScoringSum, standings.ScoringMode)
+	assert.Equal(t, []uint{1, 2, 3}, standings.QuizIDs)
+	require.Len(t, standings.Standings, 2)
+
+	assert.Equal(t, TournamentStanding{Rank: 1, UserID: 1, Username: "alice", Score: 90, CorrectAnswers: 9, QuizzesPlayed: 3, QuizzesCounted: 3}, standings.Standings[0])
+	assert.Equal(t, TournamentStanding{Rank: 2, UserID: 2, Username: "bobby", Score: 85, CorrectAnswers: 8, QuizzesPlayed: 2, QuizzesCounted: 2}, standings.Standings[1])
+}
+
+func TestTournamentStandings_BestN(t *testing.T) {
+	resultService, tournamentID := tournamentFixture(t, TournamentOptions{ScoringMode: entity.TournamentScoringBestN, BestN: 2})
+
+	standings, err := resultService.TournamentStandings(tournamentID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, standings.BestN)
+	require.Len(t, standings.Standings, 2)
+
+	// У alice худший результат (10) не учитывается, у bob учтены оба
+	assert.Equal(t, TournamentStanding{Rank: 1, UserID: 2, Username: "bobby", Score: 85, CorrectAnswers: 8, QuizzesPlayed: 2, QuizzesCounted: 2}, standings.Standings[0])
+	assert.Equal(t, TournamentStanding{Rank: 2, UserID: 1, Username: "alice", Score: 80, CorrectAnswers: 8, QuizzesPlayed: 3, QuizzesCounted: 2}, standings.Standings[1])
+}
+
+func TestRankTournamentResults_TiesShareRank(t *testing.T) {
+	standings := rankTournamentResults([]entity.Result{
+		{UserID: 1, QuizID: 1, Score: 20, CorrectAnswers: 2},
+		{UserID: 2, QuizID: 1, Score: 20, CorrectAnswers: 2},
+		{UserID: 3, QuizID: 1, Score: 10, CorrectAnswers: 1},
+	}, 0)
+	require.Len(t, standings, 3)
+	assert.Equal(t, []int{1, 1, 3}, []int{standings[0].Rank, standings[1].Rank, standings[2].Rank})
+}
+
+func TestTournamentService_Validation(t *testing.T) {
+	repo := newMemoryTournamentRepo()
+	quizRepo := &parallelQuizRepo{quizzes: map[uint]*entity.Quiz{1: parallelQuiz(1)}}
+	tournaments := NewTournamentService(repo, quizRepo)
+
+	_, err := tournaments.CreateTournament(TournamentOptions{Title: "Кубок", ScoringMode: entity.TournamentScoringBestN})
+	assert.ErrorIs(t, err, ErrValidation, "best_n обязателен для best_n")
+	_, err = tournaments.CreateTournament(TournamentOptions{Title: "Кубок", BestN: 3})
+	assert.ErrorIs(t, err, ErrValidation, "best_n не задается для sum")
+	_, err = tournaments.CreateTournament(TournamentOptions{Title: "Кубок", ScoringMode: "max"})
+	assert.ErrorIs(t, err, ErrValidation)
+
+	tournament, err := tournaments.CreateTournament(TournamentOptions{Title: "Кубок"})
+	require.NoError(t, err)
+	_, err = tournaments.AddQuiz(tournament.ID, 42)
+	assert.ErrorIs(t, err, ErrQuizNotFound)
+	_, err = tournaments.AddQuiz(99, 1)
+	assert.ErrorIs(t, err, ErrTournamentNotFound)
+
+	// Повторное добавление не дублирует викторину
+	_, err = tournaments.AddQuiz(tournament.ID, 1)
+	require.NoError(t, err)
+	info, err := tournaments.AddQuiz(tournament.ID, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []uint{1}, info.QuizIDs)
+}
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/yourusername/trivia-api/internal/domain/entity"
+	"github.com/yourusername/trivia-api/internal/domain/repository"
+)
+
+// TournamentStanding - строка общего зачета турнира
+type TournamentStanding struct {
+	Rank           int    `json:"rank"`
+	UserID         uint   `json:"user_id"`
+	Username       string `json:"username"`
+	Score          int    `json:"score"`
+	CorrectAnswers int    `json:"correct_answers"` // По учтенным викторинам
+	QuizzesPlayed  int    `json:"quizzes_played"`
+	QuizzesCounted int    `json:"quizzes_counted"` // Сколько результатов учтено (при best_n - не больше best_n)
+}
+
+// TournamentStandings - общий зачет турнира
+type TournamentStandings struct {
+	TournamentID uint                 `json:"tournament_id"`
+	Title        string               `json:"title"`
+	ScoringMode  string               `json:"scoring_mode"`
+	BestN        int                  `json:"best_n,omitempty"`
+	QuizIDs      []uint               `json:"quiz_ids"`
+	Standings    []TournamentStanding `json:"standings"`
+}
+
+// SetTournamentRepo задает репозиторий турниров для подсчета общего зачета
+func (s *ResultService) SetTournamentRepo(repo repository.TournamentRepository) {
+	s.tournamentRepo = repo
+}
+
+// TournamentStandings считает общий зачет турнира по итоговым результатам его
+// викторин. Учитываются только викторины с подсчитанными результатами.
+func (s *ResultService) TournamentStandings(tournamentID uint) (*TournamentStandings, error) {
+	if s.tournamentRepo == nil {
+		return nil, fmt.Errorf("tournament repository is not configured")
+	}
+	tournament, err := s.tournamentRepo.GetByID(tournamentID)
+	if err != nil {
+		return nil, tournamentLookupError(tournamentID, err)
+	}
+	quizIDs, err := s.tournamentRepo.GetQuizIDs(tournamentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get quizzes of tournament %d: %w", tournamentID, err)
+	}
+	results, err := s.tournamentRepo.GetResults(tournamentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get results of tournament %d: %w", tournamentID, err)
+	}
+
+	return &TournamentStandings{
+		TournamentID: tournament.ID,
+		Title:        tournament.Title,
+		ScoringMode:  tournament.ScoringMode,
+		BestN:        tournament.CountedResults(),
+		QuizIDs:      quizIDs,
+		Standings:    rankTournamentResults(results, tournament.CountedResults()),
+	}, nil
+}
+
+// rankTournamentResults складывает очки каждого игрока по его лучшим bestN
+// результатам (0 - по всем) и упорядочивает игроков по убыванию очков, затем
+// правильных ответов. Игроки с одинаковыми очками и правильными ответами делят место.
+func rankTournamentResults(results []entity.Result, bestN int) []TournamentStanding {
+	byUser := make(map[uint][]entity.Result)
+	for _, result := range results {
+		byUser[result.UserID] = append(byUser[result.UserID], result)
+	}
+
+	standings := make([]TournamentStanding, 0, len(byUser))
+	for userID, userResults := range byUser {
+		sort.SliceStable(userResults, func(i, j int) bool { return userResults[i].Score > userResults[j].Score })
+		counted := userResults
+		if bestN > 0 && len(counted) > bestN {
+			counted = counted[:bestN]
+		}
+
+		standing := TournamentStanding{
+			UserID:         userID,
+			QuizzesPlayed:  len(userResults),
+			QuizzesCounted: len(counted),
+		}
+		for _, result := range counted {
+			standing.Score += result.Score
+			standing.CorrectAnswers += result.CorrectAnswers
+		}
+		// Имя берется из самого нового результата: игрок мог его сменить
+		latest := userResults[0]
+		for _, result := range userResults[1:] {
+			if result.CompletedAt.After(latest.CompletedAt) {
+				latest = result
+			}
+		}
+		standing.Username = latest.Username
+		standings = append(standings, standing)
+	}
+
+	sort.Slice(standings, func(i, j int) bool {
+		a, b := standings[i], standings[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if a.CorrectAnswers != b.CorrectAnswers {
+			return a.CorrectAnswers > b.CorrectAnswers
+		}
+		return a.UserID < b.UserID
+	})
+	for i := range standings {
+		standings[i].Rank = i + 1
+		if i > 0 && standings[i].Score == standings[i-1].Score && standings[i].CorrectAnswers == standings[i-1].CorrectAnswers {
+			standings[i].Rank = standings[i-1].Rank
+		}
+	}
+	return standings
+}
@@ -0,0 +1,204 @@
+package service
+
+import (
+	"crypto/rand"
+	"fmt"
+	"log"
+	"net/mail"
+	"strings"
+
+	"github.com/yourusername/trivia-api/internal/domain/entity"
+)
+
+// MaxUserImportBatch - максимальное число строк в одном импорте пользователей
+const MaxUserImportBatch = 500
+
+// Ограничения полей совпадают с регистрацией (RegisterRequest)
+const (
+	importMinUsername = 3
+	importMaxUsername = 50
+	importMinPassword = 6
+	importMaxPassword = 50
+)
+
+// Временные пароли: без похожих символов, чтобы их можно было продиктовать
+const (
+	tempPasswordLength   = 12
+	tempPasswordAlphabet = "ABCDEFGHJKMNPQRSTUVWXYZabcdefghjkmnpqrstuvwxyz23456789"
+)
+
+// Статусы строк импорта пользователей
+const (
+	UserImportCreated = "created"
+	UserImportFailed  = "failed"
+	// Строка корректна, но не создана, потому что в атомарном импорте есть ошибки
+	UserImportSkipped = "skipped"
+)
+
+// UserImportRow - строка импорта. Пустой Password - будет сгенерирован временный пароль.
+type UserImportRow struct {
+	Username string
+	Email    string
+	Password string
+}
+
+// UserImportRowResult - результат обработки строки импорта
+type UserImportRowResult struct {
+	Row      int    `json:"row"` // Номер строки, начиная с 1
+	Username string `json:"username"`
+	Email    string `json:"email"`
+	Status   string `json:"status"`
+	UserID   uint   `json:"user_id,omitempty"`
+	// Сгенерированный временный пароль; возвращается только в ответе на импорт
+	TempPassword string `json:"temp_password,omitempty"`
+	Error        string `json:"error,omitempty"`
+}
+
+// UserImportResult - итог импорта пользователей
+type UserImportResult struct {
+	Atomic  bool                  `json:"atomic"`
+	Created int                   `json:"created"`
+	Failed  int                   `json:"failed"`
+	Rows    []UserImportRowResult `json:"rows"`
+}
+
+// ImportUsers создает пользователей из списка с теми же проверками, что и регистрация.
+// Без atomic корректные строки создаются, даже если другие строки содержат ошибки;
+// с atomic при любой ошибке не создается ни один пользователь.
+func (s *AuthService) ImportUsers(adminID uint, rows []UserImportRow, atomic bool) (*UserImportResult, error) {
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("%w: no users to import", ErrValidation)
+	}
+	if len(rows) > MaxUserImportBatch {
+		return nil, fmt.Errorf("%w: at most %d users per import, got %d", ErrValidation, MaxUserImportBatch, len(rows))
+	}
+
+	result := &UserImportResult{Atomic: atomic, Rows: make([]UserImportRowResult, len(rows))}
+	users := make([]*entity.User, len(rows))
+	seenEmails := make(map[string]int, len(rows))
+	seenNames := make(map[string]int, len(rows))
+
+	for i, row := range rows {
+		row.Username, row.Email = strings.TrimSpace(row.Username), strings.TrimSpace(row.Email)
+		res := &result.Rows[i]
+		*res = UserImportRowResult{Row: i + 1, Username: row.Username, Email: row.Email}
+
+		err := validateImportRow(row)
+		if err == nil {
+			// Повторы внутри файла не видны проверке уникальности в БД
+			if first, ok := seenEmails[strings.ToLower(row.Email)]; ok {
+				err = fmt.Errorf("email duplicates row %d", first)
+			} else if first, ok := seenNames[strings.ToLower(row.Username)]; ok {
+				err = fmt.Errorf("username duplicates row %d", first)
+			}
+		}
+		if err == nil {
+			err = s.checkNewUser(row.Username, row.Email)
+		}
+		if err != nil {
+			res.Status, res.Error = UserImportFailed, err.Error()
+			continue
+		}
+		seenEmails[strings.ToLower(row.Email)] = i + 1
+		seenNames[strings.ToLower(row.Username)] = i + 1
+
+		password := row.Password
+		if password == "" {
+			if password, err = generateTempPassword(); err != nil {
+				return nil, err
+			}
+			res.TempPassword = password
+		}
+		// Пароль хешируется хуком BeforeSave в entity.User
+		users[i] = &entity.User{Username: row.Username, Email: row.Email, Password: password}
+	}
+
+	if atomic {
+		s.createImportedUsersAtomically(result, users)
+	} else {
+		s.createImportedUsers(result, users)
+	}
+
+	for _, res := range result.Rows {
+		switch res.Status {
+		case UserImportCreated:
+			result.Created++
+		case UserImportFailed:
+			result.Failed++
+		}
+	}
+	log.Printf("[AuthService] AUDIT: администратор ID=%d импортировал пользователей: создано %d, ошибок %d (atomic=%v)",
+		adminID, result.Created, result.Failed, atomic)
+	return result, nil
+}
+
+// createImportedUsers создает пользователей по одному; ошибка записи отмечается в строке
+func (s *AuthService) createImportedUsers(result *UserImportResult, users []*entity.User) {
+	for i, user := range users {
+		if user == nil {
+			continue
+		}
+		res := &result.Rows[i]
+		if err := s.userRepo.Create(user); err != nil {
+			res.Status, res.Error, res.TempPassword = UserImportFailed, fmt.Sprintf("failed to create user: %v", err), ""
+			continue
+		}
+		res.Status, res.UserID = UserImportCreated, user.ID
+	}
+}
+
+// createImportedUsersAtomically создает всех пользователей в одной транзакции
+// или, если хотя бы одна строка не прошла проверку или запись, ни одного
+func (s *AuthService) createImportedUsersAtomically(result *UserImportResult, users []*entity.User) {
+	valid := make([]*entity.User, 0, len(users))
+	for _, user := range users {
+		if user != nil {
+			valid = append(valid, user)
+		}
+	}
+
+	var batchErr error
+	if len(valid) == len(users) {
+		batchErr = s.userRepo.CreateBatch(valid)
+	}
+
+	for i, user := range users {
+		res := &result.Rows[i]
+		switch {
+		case user == nil:
+			// Ошибка проверки уже записана в строку
+		case len(valid) != len(users):
+			res.Status, res.TempPassword = UserImportSkipped, ""
+		case batchErr != nil:
+			res.Status, res.Error, res.TempPassword = UserImportFailed, fmt.Sprintf("failed to create users: %v", batchErr), ""
+		default:
+			res.Status, res.UserID = UserImportCreated, user.ID
+		}
+	}
+}
+
+// validateImportRow проверяет формат полей так же, как запрос регистрации
+func validateImportRow(row UserImportRow) error {
+	if n := len([]rune(row.Username)); n < importMinUsername || n > importMaxUsername {
+		return fmt.Errorf("username must be between %d and %d characters", importMinUsername, importMaxUsername)
+	}
+	if addr, err := mail.ParseAddress(row.Email); err != nil || addr.Address != row.Email {
+		return fmt.Errorf("email is not a valid address")
+	}
+	if n := len([]rune(row.Password)); row.Password != "" && (n < importMinPassword || n > importMaxPassword) {
+		return fmt.Errorf("password must be between %d and %d characters", importMinPassword, importMaxPassword)
+	}
+	return nil
+}
+
+// generateTempPassword создает случайный временный пароль
+func generateTempPassword() (string, error) {
+	buf := make([]byte, tempPasswordLength)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate temporary password: %w", err)
+	}
+	for i, b := range buf {
+		buf[i] = tempPasswordAlphabet[int(b)%len(tempPasswordAlphabet)]
+	}
+	return string(buf), nil
+}
@@ -0,0 +1,140 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourusername/trivia-api/internal/domain/entity"
+	"github.com/yourusername/trivia-api/internal/domain/repository"
+	"github.com/yourusername/trivia-api/pkg/moderation"
+)
+
+// importUserRepo хранит пользователей в памяти; failEmail имитирует ошибку записи
+type importUserRepo struct {
+	repository.UserRepository
+	users     []*entity.User
+	failEmail string
+}
+
+func (r *importUserRepo) GetByEmail(email string) (*entity.User, error) {
+	for _, u := range r.users {
+		if u.Email == email {
+			return u, nil
+		}
+	}
+	return nil, fmt.Errorf("user not found: %w", repository.ErrNotFound)
+}
+
+func (r *importUserRepo) GetByUsername(username string) (*entity.User, error) {
+	for _, u := range r.users {
+		if u.Username == username {
+			return u, nil
+		}
+	}
+	return nil, fmt.Errorf("user not found: %w", repository.ErrNotFound)
+}
+
+func (r *importUserRepo) Create(user *entity.User) error {
+	if user.Email == r.failEmail {
+		return errors.New("duplicate key value violates unique constraint")
+	}
+	user.ID = uint(len(r.users) + 1)
+	r.users = append(r.users, user)
+	return nil
+}
+
+func (r *importUserRepo) CreateBatch(users []*entity.User) error {
+	for _, u := range users {
+		if u.Email == r.failEmail {
+			return errors.New("duplicate key value violates unique constraint")
+		}
+	}
+	for _, u := range users {
+		_ = r.Create(u)
+	}
+	return nil
+}
+
+func newImportFixture() (*AuthService, *importUserRepo) {
+	repo := &importUserRepo{users: []*entity.User{{ID: 1, Username: "existing", Email: "existing@example.com"}}}
+	s := &AuthService{userRepo: repo}
+	s.SetNameFilter(moderation.NewNameFilter([]string{"badword"}, nil))
+	return s, repo
+}
+
+func importRows() []UserImportRow {
+	return []UserImportRow{
+		{Username: "alice", Email: "alice@example.com", Password: "secret123"},
+		{Username: "bob", Email: "bob@example.com"},
+		{Username: "carol", Email: "existing@example.com"},
+		{Username: "badword_1", Email: "dave@example.com"},
+		{Username: "eve", Email: "not-an-email"},
+		{Username: "Alice", Email: "ALICE@example.com"},
+	}
+}
+
+func TestImportUsers_PartialFailuresKeepValidRows(t *testing.T) {
+	s, repo := newImportFixture()
+
+	result, err := s.ImportUsers(100, importRows(), false)
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Created)
+	assert.Equal(t, 4, result.Failed)
+
+	statuses := make([]string, len(result.Rows))
+	for i, row := range result.Rows {
+		statuses[i] = row.Status
+	}
+	assert.Equal(t, []string{UserImportCreated, UserImportCreated, UserImportFailed, UserImportFailed, UserImportFailed, UserImportFailed}, statuses)
+
+	assert.Empty(t, result.Rows[0].TempPassword, "пароль из файла не возвращается")
+	assert.Len(t, result.Rows[1].TempPassword, tempPasswordLength)
+	assert.Equal(t, result.Rows[1].TempPassword, repo.users[2].Password, "временный пароль передается на хеширование как обычный")
+	assert.Contains(t, result.Rows[2].Error, "email already exists")
+	assert.Contains(t, result.Rows[5].Error, "duplicates row 1")
+	assert.Len(t, repo.users, 3)
+}
+
+func TestImportUsers_AtomicCreatesNothingOnError(t *testing.T) {
+	s, repo := newImportFixture()
+
+	result, err := s.ImportUsers(100, importRows(), true)
+	require.NoError(t, err)
+	assert.Zero(t, result.Created)
+	assert.Equal(t, UserImportSkipped, result.Rows[0].Status)
+	assert.Empty(t, result.Rows[1].TempPassword, "пароль несозданного пользователя не возвращается")
+	assert.Len(t, repo.users, 1)
+
+	// Ошибка записи откатывает весь пакет
+	repo.failEmail = "bob@example.com"
+	result, err = s.ImportUsers(100, importRows()[:2], true)
+	require.NoError(t, err)
+	assert.Zero(t, result.Created)
+	assert.Equal(t, 2, result.Failed)
+	assert.Len(t, repo.users, 1)
+
+	repo.failEmail = ""
+	result, err = s.ImportUsers(100, importRows()[:2], true)
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Created)
+	assert.NotZero(t, result.Rows[1].UserID)
+}
+
+func TestImportUsers_BatchLimits(t *testing.T) {
+	s, _ := newImportFixture()
+
+	_, err := s.ImportUsers(100, nil, false)
+	assert.ErrorIs(t, err, ErrValidation)
+
+	rows := make([]UserImportRow, MaxUserImportBatch+1)
+	for i := range rows {
+		rows[i] = UserImportRow{Username: fmt.Sprintf("user%d", i), Email: fmt.Sprintf("user%d@example.com", i)}
+	}
+	_, err = s.ImportUsers(100, rows, false)
+	assert.ErrorIs(t, err, ErrValidation)
+	assert.True(t, strings.Contains(err.Error(), "at most"))
+}
@@ -0,0 +1,172 @@
+package websocket
+
+import (
+	"sync"
+	"time"
+)
+
+// AckMessageType - тип сообщения клиента, подтверждающего получение критического
+// события: {"type": "ack", "ack_id": "..."}
+const AckMessageType = "ack"
+
+const (
+	// defaultAckMaxRedeliveries - сколько раз неподтвержденное событие повторяется
+	// при переподключениях, если лимит не задан
+	defaultAckMaxRedeliveries = 3
+	// ackPendingTTL - сколько неподтвержденное событие ждет повторной доставки
+	ackPendingTTL = 10 * time.Minute
+	// maxPendingAcksPerUser ограничивает число неподтвержденных событий пользователя;
+	// при превышении отбрасываются самые старые
+	maxPendingAcksPerUser = 32
+)
+
+// criticalEventTypes - события, получение которых клиент должен подтвердить
+var criticalEventTypes = map[string]bool{
+	"quiz:elimination": true,
+	"quiz:kicked":      true,
+	"quiz:finish":      true,
+	"quiz:end":         true,
+}
+
+// IsCriticalEvent сообщает, требует ли событие подтверждения получения
+func IsCriticalEvent(eventType string) bool {
+	return criticalEventTypes[eventType]
+}
+
+// pendingAck - отправленное критическое событие, ожидающее подтверждения
+type pendingAck struct {
+	ackID      string
+	eventType  string
+	event      interface{}
+	sentAt     time.Time
+	deliveries int
+}
+
+// ackTracker хранит неподтвержденные критические события по пользователям
+// и считает метрики подтверждений. Состояние локально для экземпляра сервера.
+type ackTracker struct {
+	mu              sync.Mutex
+	pending         map[string][]*pendingAck
+	maxRedeliveries int
+
+	tracked      int64
+	confirmed    int64
+	redelivered  int64
+	dropped      int64 // Отброшены без подтверждения: лимит повторов, срок или переполнение
+	unknownAcks  int64
+	latencyTotal time.Duration
+	latencyMax   time.Duration
+}
+
+func newAckTracker(maxRedeliveries int) *ackTracker {
+	if maxRedeliveries <= 0 {
+		maxRedeliveries = defaultAckMaxRedeliveries
+	}
+	return &ackTracker{
+		pending:         make(map[string][]*pendingAck),
+		maxRedeliveries: maxRedeliveries,
+	}
+}
+
+// track запоминает отправленное пользователю событие до подтверждения
+func (t *ackTracker) track(userID, ackID, eventType string, event interface{}, now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	queue := append(t.pending[userID], &pendingAck{
+		ackID:      ackID,
+		eventType:  eventType,
+		event:      event,
+		sentAt:     now,
+		deliveries: 1,
+	})
+	if overflow := len(queue) - maxPendingAcksPerUser; overflow > 0 {
+		t.dropped += int64(overflow)
+		queue = queue[overflow:]
+	}
+	t.pending[userID] = queue
+	t.tracked++
+}
+
+// confirm отмечает событие подтвержденным. Возвращает false для неизвестного
+// ack_id (уже подтвержден, отброшен или принадлежит другому пользователю).
+func (t *ackTracker) confirm(userID, ackID string, now time.Time) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	queue := t.pending[userID]
+	for i, p := range queue {
+		if p.ackID != ackID {
+			continue
+		}
+		latency := now.Sub(p.sentAt)
+		t.latencyTotal += latency
+		if latency > t.latencyMax {
+			t.latencyMax = latency
+		}
+		t.confirmed++
+		t.setQueue(userID, append(queue[:i:i], queue[i+1:]...))
+		return true
+	}
+	t.unknownAcks++
+	return false
+}
+
+// takeRedeliveries возвращает события пользователя для повторной отправки после
+// переподключения. События, исчерпавшие лимит повторов или срок ожидания,
+// отбрасываются; остальные остаются ждать подтверждения.
+func (t *ackTracker) takeRedeliveries(userID string, now time.Time) []interface{} {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var events []interface{}
+	kept := t.pending[userID][:0]
+	for _, p := range t.pending[userID] {
+		if p.deliveries > t.maxRedeliveries || now.Sub(p.sentAt) > ackPendingTTL {
+			t.dropped++
+			continue
+		}
+		p.deliveries++
+		t.redelivered++
+		events = append(events, p.event)
+		kept = append(kept, p)
+	}
+	t.setQueue(userID, kept)
+	return events
+}
+
+// setQueue сохраняет очередь пользователя, удаляя пустую
+func (t *ackTracker) setQueue(userID string, queue []*pendingAck) {
+	if len(queue) == 0 {
+		delete(t.pending, userID)
+		return
+	}
+	t.pending[userID] = queue
+}
+
+// metrics возвращает метрики подтверждений
+func (t *ackTracker) metrics() map[string]interface{} {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	unacked := 0
+	for _, queue := range t.pending {
+		unacked += len(queue)
+	}
+	var avgLatencyMs float64
+	if t.confirmed > 0 {
+		avgLatencyMs = float64(t.latencyTotal.Milliseconds()) / float64(t.confirmed)
+	}
+	return map[string]interface{}{
+		"tracked":          t.tracked,
+		"confirmed":        t.confirmed,
+		"unacked":          unacked,
+		"unacked_users":    len(t.pending),
+		"redelivered":      t.redelivered,
+		"dropped":          t.dropped,
+		"unknown_acks":     t.unknownAcks,
+		"avg_latency_ms":   avgLatencyMs,
+		"max_latency_ms":   t.latencyMax.Milliseconds(),
+		"max_redeliveries": t.maxRedeliveries,
+	}
+}
@@ -0,0 +1,271 @@
+package websocket
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/yourusername/trivia-api/internal/domain/entity"
+	"github.com/yourusername/trivia-api/internal/domain/repository"
+)
+
+// Ограничения истории алертов по умолчанию
+const (
+	DefaultAlertHistorySize   = 1000
+	DefaultAlertHistoryMaxAge = 24 * time.Hour
+)
+
+// ErrAlertHistoryUnavailable возвращается, если хаб не хранит историю алертов
+var ErrAlertHistoryUnavailable = errors.New("alert history requires a sharded hub")
+
+// AlertFilter - условия выборки из истории алертов. Пустые поля выборку не
+// ограничивают.
+type AlertFilter struct {
+	Severity AlertSeverity
+	Type     AlertType
+	Since    time.Time
+	Limit    int // Наибольшее число алертов (0 - все)
+}
+
+// Matches проверяет, подходит ли алерт под условия фильтра
+func (f AlertFilter) Matches(alert AlertMessage) bool {
+	if f.Severity != "" && alert.Severity != f.Severity {
+		return false
+	}
+	if f.Type != "" && alert.Type != f.Type {
+		return false
+	}
+	return f.Since.IsZero() || !alert.Timestamp.Before(f.Since)
+}
+
+// AlertStore хранит историю алертов хаба для разбора инцидентов
+type AlertStore interface {
+	// Append сохраняет алерт
+	Append(alert AlertMessage) error
+
+	// List возвращает подходящие под фильтр алерты, от новых к старым
+	List(filter AlertFilter) ([]AlertMessage, error)
+}
+
+// MemoryAlertStore хранит последние алерты экземпляра в кольцевом буфере.
+// Сверх maxCount вытесняются самые старые алерты, а алерты старше maxAge
+// не возвращаются и удаляются при следующей записи.
+type MemoryAlertStore struct {
+	mu     sync.Mutex
+	alerts []AlertMessage
+	start  int // Индекс самого старого алерта
+	count  int
+	maxAge time.Duration
+}
+
+// NewMemoryAlertStore создает историю алертов в памяти. maxCount <= 0 и
+// maxAge <= 0 заменяются значениями по умолчанию.
+func NewMemoryAlertStore(maxCount int, maxAge time.Duration) *MemoryAlertStore {
+	if maxCount <= 0 {
+		maxCount = DefaultAlertHistorySize
+	}
+	if maxAge <= 0 {
+		maxAge = DefaultAlertHistoryMaxAge
+	}
+	return &MemoryAlertStore{
+		alerts: make([]AlertMessage, maxCount),
+		maxAge: maxAge,
+	}
+}
+
+// Append сохраняет алерт, вытесняя самый старый при заполненном буфере
+func (s *MemoryAlertStore) Append(alert AlertMessage) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.expireLocked(time.Now())
+	if s.count == len(s.alerts) {
+		s.alerts[s.start] = AlertMessage{}
+		s.start = (s.start + 1) % len(s.alerts)
+		s.count--
+	}
+	s.alerts[(s.start+s.count)%len(s.alerts)] = alert
+	s.count++
+	return nil
+}
+
+// List возвращает подходящие под фильтр алерты, от новых к старым
+func (s *MemoryAlertStore) List(filter AlertFilter) ([]AlertMessage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cutoff := time.Now().Add(-s.maxAge)
+	result := make([]AlertMessage, 0)
+	for i := s.count - 1; i >= 0; i-- {
+		alert := s.alerts[(s.start+i)%len(s.alerts)]
+		if alert.Timestamp.Before(cutoff) {
+			break
+		}
+		if !filter.Matches(alert) {
+			continue
+		}
+		result = append(result, alert)
+		if filter.Limit > 0 && len(result) >= filter.Limit {
+			break
+		}
+	}
+	return result, nil
+}
+
+// Len возвращает число хранимых алертов
+func (s *MemoryAlertStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.count
+}
+
+// expireLocked удаляет алерты старше maxAge
+func (s *MemoryAlertStore) expireLocked(now time.Time) {
+	cutoff := now.Add(-s.maxAge)
+	for s.count > 0 && s.alerts[s.start].Timestamp.Before(cutoff) {
+		s.alerts[s.start] = AlertMessage{}
+		s.start = (s.start + 1) % len(s.alerts)
+		s.count--
+	}
+}
+
+// PersistentAlertStore сохраняет алерты в БД (таблица ws_alerts), чтобы история
+// переживала перезапуск и была общей для экземпляров кластера. Последние алерты
+// дублируются в памяти: если БД недоступна, выборка возвращается из памяти.
+type PersistentAlertStore struct {
+	memory     *MemoryAlertStore
+	repo       repository.WSAlertRepository
+	instanceID string
+	retention  time.Duration
+
+	purgeMu   sync.Mutex
+	lastPurge time.Time
+}
+
+// NewPersistentAlertStore создает историю алертов в БД. Записи старше retention
+// удаляются не чаще раза в час.
+func NewPersistentAlertStore(repo repository.WSAlertRepository, instanceID string, maxCount int, retention time.Duration) *PersistentAlertStore {
+	if retention <= 0 {
+		retention = DefaultAlertHistoryMaxAge
+	}
+	return &PersistentAlertStore{
+		memory:     NewMemoryAlertStore(maxCount, retention),
+		repo:       repo,
+		instanceID: instanceID,
+		retention:  retention,
+	}
+}
+
+// Append сохраняет алерт в памяти и в БД
+func (s *PersistentAlertStore) Append(alert AlertMessage) error {
+	if alert.InstanceID == "" {
+		alert.InstanceID = s.instanceID
+	}
+	_ = s.memory.Append(alert)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	record := &entity.WSAlert{
+		InstanceID: alert.InstanceID,
+		Type:       string(alert.Type),
+		Severity:   string(alert.Severity),
+		Message:    alert.Message,
+		Metadata:   entity.JSONMap(alert.Metadata),
+		CreatedAt:  alert.Timestamp,
+	}
+	if err := s.repo.SaveAlert(ctx, record); err != nil {
+		return err
+	}
+	s.purge(ctx)
+	return nil
+}
+
+// List возвращает подходящие под фильтр алерты всех экземпляров из БД, а при
+// ее недоступности - алерты этого экземпляра из памяти
+func (s *PersistentAlertStore) List(filter AlertFilter) ([]AlertMessage, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	since := filter.Since
+	if cutoff := time.Now().Add(-s.retention); since.Before(cutoff) {
+		since = cutoff
+	}
+	records, err := s.repo.GetAlerts(ctx, string(filter.Severity), string(filter.Type), since, filter.Limit)
+	if err != nil {
+		log.Printf("ShardedHub: история алертов из БД недоступна, используется память: %v", err)
+		return s.memory.List(filter)
+	}
+
+	result := make([]AlertMessage, 0, len(records))
+	for _, record := range records {
+		result = append(result, AlertMessage{
+			Type:       AlertType(record.Type),
+			Severity:   AlertSeverity(record.Severity),
+			Message:    record.Message,
+			Metadata:   record.Metadata,
+			Timestamp:  record.CreatedAt,
+			InstanceID: record.InstanceID,
+		})
+	}
+	return result, nil
+}
+
+// purge удаляет из БД алерты старше retention не чаще раза в час
+func (s *PersistentAlertStore) purge(ctx context.Context) {
+	s.purgeMu.Lock()
+	if time.Since(s.lastPurge) < time.Hour {
+		s.purgeMu.Unlock()
+		return
+	}
+	s.lastPurge = time.Now()
+	s.purgeMu.Unlock()
+
+	if deleted, err := s.repo.DeleteOlderThan(ctx, time.Now().Add(-s.retention)); err != nil {
+		log.Printf("ShardedHub: ошибка очистки истории алертов: %v", err)
+	} else if deleted > 0 {
+		log.Printf("ShardedHub: удалено %d устаревших алертов", deleted)
+	}
+}
+
+// SetAlertStore задает хранилище истории алертов. nil отключает историю.
+func (h *ShardedHub) SetAlertStore(store AlertStore) {
+	h.alertMu.Lock()
+	defer h.alertMu.Unlock()
+	h.alertStore = store
+}
+
+// AlertHistory возвращает подходящие под фильтр алерты из истории хаба
+func (h *ShardedHub) AlertHistory(filter AlertFilter) ([]AlertMessage, error) {
+	h.alertMu.RLock()
+	store := h.alertStore
+	h.alertMu.RUnlock()
+	if store == nil {
+		return nil, ErrAlertHistoryUnavailable
+	}
+	return store.List(filter)
+}
+
+// recordAlert сохраняет алерт в истории хаба
+func (h *ShardedHub) recordAlert(alert AlertMessage) {
+	h.alertMu.RLock()
+	store := h.alertStore
+	h.alertMu.RUnlock()
+	if store == nil {
+		return
+	}
+	if err := store.Append(alert); err != nil {
+		log.Printf("ShardedHub: не удалось сохранить алерт %s в истории: %v", alert.Type, err)
+	}
+}
+
+// AlertHistory возвращает подходящие под фильтр алерты из истории хаба
+func (m *Manager) AlertHistory(filter AlertFilter) ([]AlertMessage, error) {
+	shardedHub, ok := m.hub.(*ShardedHub)
+	if !ok {
+		return nil, ErrAlertHistoryUnavailable
+	}
+	return shardedHub.AlertHistory(filter)
+}
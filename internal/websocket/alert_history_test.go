@@ -0,0 +1,92 @@
+package websocket
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func alertMessages(alerts []AlertMessage) []string {
+	messages := make([]string, 0, len(alerts))
+	for _, alert := range alerts {
+		messages = append(messages, alert.Message)
+	}
+	return messages
+}
+
+func TestMemoryAlertStore_BoundedAndFiltered(t *testing.T) {
+	store := NewMemoryAlertStore(3, time.Hour)
+	now := time.Now()
+
+	for i := 1; i <= 4; i++ {
+		severity := AlertWarning
+		if i%2 == 0 {
+			severity = AlertCritical
+		}
+		require.NoError(t, store.Append(AlertMessage{
+			Type:      AlertHotShard,
+			Severity:  severity,
+			Message:   fmt.Sprint(i),
+			Timestamp: now.Add(time.Duration(i) * time.Second),
+		}))
+	}
+	assert.Equal(t, 3, store.Len(), "сверх лимита вытесняется самый старый алерт")
+
+	all, err := store.List(AlertFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"4", "3", "2"}, alertMessages(all), "от новых к старым")
+
+	critical, _ := store.List(AlertFilter{Severity: AlertCritical})
+	assert.Equal(t, []string{"4", "2"}, alertMessages(critical))
+
+	recent, _ := store.List(AlertFilter{Since: now.Add(3 * time.Second)})
+	assert.Equal(t, []string{"4", "3"}, alertMessages(recent))
+
+	limited, _ := store.List(AlertFilter{Limit: 1})
+	assert.Equal(t, []string{"4"}, alertMessages(limited))
+
+	other, _ := store.List(AlertFilter{Type: AlertClusterDegraded})
+	assert.Empty(t, other)
+}
+
+func TestMemoryAlertStore_ExpiresOldAlerts(t *testing.T) {
+	store := NewMemoryAlertStore(10, time.Minute)
+	require.NoError(t, store.Append(AlertMessage{Message: "old", Timestamp: time.Now().Add(-2 * time.Minute)}))
+	require.NoError(t, store.Append(AlertMessage{Message: "new", Timestamp: time.Now()}))
+
+	alerts, _ := store.List(AlertFilter{})
+	assert.Equal(t, []string{"new"}, alertMessages(alerts))
+	assert.Equal(t, 1, store.Len(), "устаревший алерт удален при записи")
+}
+
+// TestShardedHub_AlertHistory: отправленные алерты после обработки доступны в истории
+func TestShardedHub_AlertHistory(t *testing.T) {
+	hub := newAlertTestHub(AlertOverflowDropNew)
+	hub.alertChan = make(chan AlertMessage, 10)
+	hub.alertHandler = hub.defaultAlertHandler
+	hub.alertStore = NewMemoryAlertStore(0, 0)
+	go hub.handleAlerts()
+	defer close(hub.done)
+
+	hub.SendAlert(AlertHotShard, AlertWarning, "shard 1 is hot", map[string]interface{}{"shard_id": 1})
+	hub.SendAlert(AlertClusterDegraded, AlertCritical, "pubsub is down", nil)
+
+	var alerts []AlertMessage
+	require.Eventually(t, func() bool {
+		alerts, _ = hub.AlertHistory(AlertFilter{})
+		return len(alerts) == 2
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"pubsub is down", "shard 1 is hot"}, alertMessages(alerts))
+	assert.Equal(t, 1, alerts[1].Metadata["shard_id"])
+
+	critical, err := hub.AlertHistory(AlertFilter{Severity: AlertCritical})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"pubsub is down"}, alertMessages(critical))
+
+	hub.SetAlertStore(nil)
+	_, err = hub.AlertHistory(AlertFilter{})
+	assert.ErrorIs(t, err, ErrAlertHistoryUnavailable)
+}
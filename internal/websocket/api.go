@@ -101,10 +101,11 @@ func WebSocketSystemAlertsHandler(hub interface{}) http.HandlerFunc {
 
 				// Добавляем алерт о горячих шардах
 				alerts = append(alerts, map[string]interface{}{
-					"type":     "hot_shards",
-					"severity": "warning",
-					"message":  fmt.Sprintf("Обнаружено %d горячих шардов", len(hotShards)),
-					"details":  hotShards,
+					"type":                    "hot_shards",
+					"severity":                "warning",
+					"message":                 fmt.Sprintf("Обнаружено %d горячих шардов", len(hotShards)),
+					"details":                 hotShards,
+					"recommended_shard_count": hubMetrics["recommended_shard_count"],
 				})
 			}
 
@@ -321,13 +322,21 @@ func renderPrometheusMetrics(w http.ResponseWriter, metrics map[string]interface
 		help string
 		typ  string
 	}{
-		"total_connections":        {"Total number of connections since server start", "counter"},
-		"active_connections":       {"Current number of active connections", "gauge"},
-		"messages_sent":            {"Total number of messages sent", "counter"},
-		"messages_received":        {"Total number of messages received", "counter"},
-		"connection_errors":        {"Total number of connection errors", "counter"},
-		"inactive_clients_removed": {"Total number of inactive clients removed", "counter"},
-		"uptime_seconds":           {"Server uptime in seconds", "gauge"},
+		"total_connections":           {"Total number of connections since server start", "counter"},
+		"active_connections":          {"Current number of active connections", "gauge"},
+		"messages_sent":               {"Total number of messages sent", "counter"},
+		"messages_received":           {"Total number of messages received", "counter"},
+		"connection_errors":           {"Total number of connection errors", "counter"},
+		"inactive_clients_removed":    {"Total number of inactive clients removed", "counter"},
+		"uptime_seconds":              {"Server uptime in seconds", "gauge"},
+		"peak_connections":            {"Peak number of concurrent connections", "gauge"},
+		"alerts_total":                {"Total number of alerts raised", "counter"},
+		"alerts_dropped":              {"Total number of alerts dropped due to alert buffer overflow", "counter"},
+		"configured_shard_count":      {"Configured number of shards", "gauge"},
+		"recommended_shard_count":     {"Recommended number of shards for peak load", "gauge"},
+		"broadcasts_in_flight":        {"Current number of broadcasts being fanned out to shards", "gauge"},
+		"broadcasts_queued":           {"Current number of broadcasts waiting for a free broadcast slot", "gauge"},
+		"broadcast_concurrency_limit": {"Maximum number of concurrent broadcasts", "gauge"},
 	}
 
 	// Выводим основные метрики
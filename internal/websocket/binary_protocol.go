@@ -0,0 +1,322 @@
+package websocket
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// BinaryProtocolName - имя подпротокола WebSocket (Sec-WebSocket-Protocol), которым
+// клиент запрашивает компактный бинарный формат для частых событий викторины.
+// Без него соединение работает в JSON, который остается форматом по умолчанию.
+const BinaryProtocolName = "trivia.binary.v1"
+
+// Коды бинарных кадров. Первый байт кадра - код; коды меньше 0x20, поэтому кадр
+// нельзя спутать с JSON-сообщением, которое всегда начинается с '{'.
+const (
+	binaryFrameAnswer   byte = 0x01 // Клиент -> сервер: user:answer
+	binaryFrameQuestion byte = 0x02 // Сервер -> клиент: quiz:question
+	binaryFrameTimer    byte = 0x03 // Сервер -> клиент: quiz:timer
+)
+
+// Поля кадров кодируются подряд: целые числа - varint (encoding/binary),
+// строки - длина в varint и байты UTF-8, списки - длина и элементы.
+//
+//	answer:   question_id, selected_option, timestamp, len(order), order...
+//	question: question_id, quiz_id, number, total_questions, time_limit, point_value,
+//	          start_time, server_timestamp, type, text, len(options), options...
+//	timer:    question_id, remaining_seconds, server_timestamp
+
+// ErrInvalidBinaryFrame возвращается для поврежденного или неизвестного кадра
+var ErrInvalidBinaryFrame = errors.New("invalid binary frame")
+
+// AnswerFrame - ответ пользователя (поля совпадают с JSON-событием user:answer)
+type AnswerFrame struct {
+	QuestionID     uint  `json:"question_id"`
+	SelectedOption int   `json:"selected_option"`
+	Order          []int `json:"order,omitempty"`
+	Timestamp      int64 `json:"timestamp"`
+}
+
+// QuestionOptionFrame - вариант ответа в событии quiz:question.
+// В бинарном кадре передается только текст: ID равен позиции варианта (с 1).
+type QuestionOptionFrame struct {
+	ID   int    `json:"id"`
+	Text string `json:"text"`
+}
+
+// QuestionFrame - событие quiz:question
+type QuestionFrame struct {
+	QuestionID      uint                  `json:"question_id"`
+	QuizID          uint                  `json:"quiz_id"`
+	Type            string                `json:"type"`
+	Number          int                   `json:"number"`
+	Text            string                `json:"text"`
+	Options         []QuestionOptionFrame `json:"options"`
+	TimeLimit       int                   `json:"time_limit"`
+	PointValue      int                   `json:"point_value"`
+	TotalQuestions  int                   `json:"total_questions"`
+	StartTime       int64                 `json:"start_time"`
+	ServerTimestamp int64                 `json:"server_timestamp"`
+}
+
+// TimerFrame - событие quiz:timer
+type TimerFrame struct {
+	QuestionID       uint  `json:"question_id"`
+	RemainingSeconds int   `json:"remaining_seconds"`
+	ServerTimestamp  int64 `json:"server_timestamp"`
+}
+
+// isBinaryFrame проверяет, что сообщение является бинарным кадром, а не JSON
+func isBinaryFrame(message []byte) bool {
+	return len(message) > 0 && message[0] < 0x20
+}
+
+// binaryFrameTypeName возвращает тип события, соответствующий кадру (для логов)
+func binaryFrameTypeName(message []byte) string {
+	switch message[0] {
+	case binaryFrameAnswer:
+		return "binary:user:answer"
+	case binaryFrameQuestion:
+		return "binary:quiz:question"
+	case binaryFrameTimer:
+		return "binary:quiz:timer"
+	}
+	return "binary:unknown"
+}
+
+// EncodeAnswerFrame кодирует ответ пользователя
+func EncodeAnswerFrame(f AnswerFrame) []byte {
+	buf := make([]byte, 0, 16+len(f.Order)*2)
+	buf = append(buf, binaryFrameAnswer)
+	buf = binary.AppendUvarint(buf, uint64(f.QuestionID))
+	buf = binary.AppendVarint(buf, int64(f.SelectedOption))
+	buf = binary.AppendVarint(buf, f.Timestamp)
+	buf = binary.AppendUvarint(buf, uint64(len(f.Order)))
+	for _, o := range f.Order {
+		buf = binary.AppendVarint(buf, int64(o))
+	}
+	return buf
+}
+
+// DecodeAnswerFrame декодирует ответ пользователя
+func DecodeAnswerFrame(message []byte) (AnswerFrame, error) {
+	var f AnswerFrame
+	r, err := newFrameReader(message, binaryFrameAnswer)
+	if err != nil {
+		return f, err
+	}
+	f.QuestionID = uint(r.uvarint())
+	f.SelectedOption = int(r.varint())
+	f.Timestamp = r.varint()
+	if n := r.length(); n > 0 {
+		f.Order = make([]int, n)
+		for i := range f.Order {
+			f.Order[i] = int(r.varint())
+		}
+	}
+	return f, r.finish()
+}
+
+// EncodeQuestionFrame кодирует событие quiz:question
+func EncodeQuestionFrame(f QuestionFrame) []byte {
+	size := 48 + len(f.Type) + len(f.Text)
+	for _, o := range f.Options {
+		size += len(o.Text) + 2
+	}
+	buf := make([]byte, 0, size)
+	buf = append(buf, binaryFrameQuestion)
+	buf = binary.AppendUvarint(buf, uint64(f.QuestionID))
+	buf = binary.AppendUvarint(buf, uint64(f.QuizID))
+	buf = binary.AppendVarint(buf, int64(f.Number))
+	buf = binary.AppendVarint(buf, int64(f.TotalQuestions))
+	buf = binary.AppendVarint(buf, int64(f.TimeLimit))
+	buf = binary.AppendVarint(buf, int64(f.PointValue))
+	buf = binary.AppendVarint(buf, f.StartTime)
+	buf = binary.AppendVarint(buf, f.ServerTimestamp)
+	buf = appendString(buf, f.Type)
+	buf = appendString(buf, f.Text)
+	buf = binary.AppendUvarint(buf, uint64(len(f.Options)))
+	for _, o := range f.Options {
+		buf = appendString(buf, o.Text)
+	}
+	return buf
+}
+
+// DecodeQuestionFrame декодирует событие quiz:question
+func DecodeQuestionFrame(message []byte) (QuestionFrame, error) {
+	var f QuestionFrame
+	r, err := newFrameReader(message, binaryFrameQuestion)
+	if err != nil {
+		return f, err
+	}
+	f.QuestionID = uint(r.uvarint())
+	f.QuizID = uint(r.uvarint())
+	f.Number = int(r.varint())
+	f.TotalQuestions = int(r.varint())
+	f.TimeLimit = int(r.varint())
+	f.PointValue = int(r.varint())
+	f.StartTime = r.varint()
+	f.ServerTimestamp = r.varint()
+	f.Type = r.string()
+	f.Text = r.string()
+	f.Options = make([]QuestionOptionFrame, r.length())
+	for i := range f.Options {
+		f.Options[i] = QuestionOptionFrame{ID: i + 1, Text: r.string()}
+	}
+	return f, r.finish()
+}
+
+// EncodeTimerFrame кодирует событие quiz:timer
+func EncodeTimerFrame(f TimerFrame) []byte {
+	buf := make([]byte, 0, 24)
+	buf = append(buf, binaryFrameTimer)
+	buf = binary.AppendUvarint(buf, uint64(f.QuestionID))
+	buf = binary.AppendVarint(buf, int64(f.RemainingSeconds))
+	buf = binary.AppendVarint(buf, f.ServerTimestamp)
+	return buf
+}
+
+// DecodeTimerFrame декодирует событие quiz:timer
+func DecodeTimerFrame(message []byte) (TimerFrame, error) {
+	var f TimerFrame
+	r, err := newFrameReader(message, binaryFrameTimer)
+	if err != nil {
+		return f, err
+	}
+	f.QuestionID = uint(r.uvarint())
+	f.RemainingSeconds = int(r.varint())
+	f.ServerTimestamp = r.varint()
+	return f, r.finish()
+}
+
+// hasBinaryFormat сообщает, есть ли у события бинарное представление.
+// Тип извлекается без сериализации; для событий неизвестной структуры возвращается true,
+// и решение принимает encodeBinaryEvent.
+func hasBinaryFormat(event interface{}) bool {
+	var eventType string
+	switch e := event.(type) {
+	case map[string]interface{}:
+		eventType, _ = e["type"].(string)
+	case Event:
+		eventType = e.Type
+	case *Event:
+		eventType = e.Type
+	default:
+		return true
+	}
+	return eventType == "quiz:question" || eventType == "quiz:timer"
+}
+
+// encodeBinaryEvent строит бинарный кадр для JSON-события, если для его типа есть
+// бинарный формат. Возвращает nil для остальных событий.
+func encodeBinaryEvent(jsonEvent []byte) ([]byte, error) {
+	var envelope struct {
+		Type string          `json:"type"`
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(jsonEvent, &envelope); err != nil {
+		return nil, err
+	}
+
+	switch envelope.Type {
+	case "quiz:question":
+		var f QuestionFrame
+		if err := json.Unmarshal(envelope.Data, &f); err != nil {
+			return nil, fmt.Errorf("failed to decode quiz:question for binary protocol: %w", err)
+		}
+		return EncodeQuestionFrame(f), nil
+	case "quiz:timer":
+		var f TimerFrame
+		if err := json.Unmarshal(envelope.Data, &f); err != nil {
+			return nil, fmt.Errorf("failed to decode quiz:timer for binary protocol: %w", err)
+		}
+		return EncodeTimerFrame(f), nil
+	}
+	return nil, nil
+}
+
+// decodeBinaryInbound преобразует входящий бинарный кадр в событие JSON-протокола,
+// чтобы его обработали те же обработчики, что и JSON-сообщения
+func decodeBinaryInbound(message []byte) (Event, error) {
+	if message[0] != binaryFrameAnswer {
+		return Event{}, fmt.Errorf("%w: unexpected inbound frame 0x%02x", ErrInvalidBinaryFrame, message[0])
+	}
+	answer, err := DecodeAnswerFrame(message)
+	if err != nil {
+		return Event{}, err
+	}
+	return Event{Type: "user:answer", Data: answer}, nil
+}
+
+func appendString(buf []byte, s string) []byte {
+	buf = binary.AppendUvarint(buf, uint64(len(s)))
+	return append(buf, s...)
+}
+
+// frameReader последовательно читает поля кадра и запоминает первую ошибку
+type frameReader struct {
+	buf []byte
+	err error
+}
+
+func newFrameReader(message []byte, code byte) (*frameReader, error) {
+	if len(message) == 0 || message[0] != code {
+		return nil, fmt.Errorf("%w: expected frame 0x%02x", ErrInvalidBinaryFrame, code)
+	}
+	return &frameReader{buf: message[1:]}, nil
+}
+
+func (r *frameReader) uvarint() uint64 {
+	if r.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(r.buf)
+	if n <= 0 {
+		r.err = fmt.Errorf("%w: truncated varint", ErrInvalidBinaryFrame)
+		return 0
+	}
+	r.buf = r.buf[n:]
+	return v
+}
+
+func (r *frameReader) varint() int64 {
+	if r.err != nil {
+		return 0
+	}
+	v, n := binary.Varint(r.buf)
+	if n <= 0 {
+		r.err = fmt.Errorf("%w: truncated varint", ErrInvalidBinaryFrame)
+		return 0
+	}
+	r.buf = r.buf[n:]
+	return v
+}
+
+// length читает длину списка или строки, не превышающую остаток кадра
+func (r *frameReader) length() int {
+	n := r.uvarint()
+	if r.err == nil && n > uint64(len(r.buf)) {
+		r.err = fmt.Errorf("%w: length %d exceeds frame size", ErrInvalidBinaryFrame, n)
+		return 0
+	}
+	return int(n)
+}
+
+func (r *frameReader) string() string {
+	n := r.length()
+	if r.err != nil {
+		return ""
+	}
+	s := string(r.buf[:n])
+	r.buf = r.buf[n:]
+	return s
+}
+
+func (r *frameReader) finish() error {
+	if r.err == nil && len(r.buf) > 0 {
+		r.err = fmt.Errorf("%w: %d trailing bytes", ErrInvalidBinaryFrame, len(r.buf))
+	}
+	return r.err
+}
@@ -0,0 +1,246 @@
+package websocket
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// typicalQuestionEvent повторяет событие quiz:question, которое рассылает QuestionManager
+func typicalQuestionEvent() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "quiz:question",
+		"data": map[string]interface{}{
+			"question_id": 1234,
+			"quiz_id":     56,
+			"type":        "single_choice",
+			"number":      3,
+			"text":        "Какая планета Солнечной системы самая большая?",
+			"options": []QuestionOptionFrame{
+				{ID: 1, Text: "Марс"}, {ID: 2, Text: "Юпитер"}, {ID: 3, Text: "Сатурн"}, {ID: 4, Text: "Нептун"},
+			},
+			"time_limit":       15,
+			"point_value":      10,
+			"total_questions":  10,
+			"start_time":       int64(1760600000123),
+			"server_timestamp": int64(1760600000123),
+		},
+	}
+}
+
+func typicalTimerEvent() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "quiz:timer",
+		"data": map[string]interface{}{
+			"question_id":       1234,
+			"remaining_seconds": 12,
+			"server_timestamp":  int64(1760600003125),
+		},
+	}
+}
+
+func typicalAnswer() AnswerFrame {
+	return AnswerFrame{QuestionID: 1234, SelectedOption: 2, Timestamp: 1760600004567}
+}
+
+// stubHub запоминает отправленные пользователям события
+type stubHub struct {
+	sent []Event
+	raw  [][]byte
+}
+
+func (h *stubHub) BroadcastJSON(v interface{}) error { return nil }
+func (h *stubHub) SendJSONToUser(userID string, v interface{}) error {
+	h.sent = append(h.sent, v.(Event))
+	return nil
+}
+func (h *stubHub) SendToUser(userID string, message []byte) bool {
+	h.raw = append(h.raw, message)
+	return true
+}
+func (h *stubHub) GetMetrics() map[string]interface{} { return nil }
+func (h *stubHub) ClientCount() int                   { return 0 }
+
+func TestBinaryProtocol_EncodeFromJSONEvent(t *testing.T) {
+	jsonEvent, err := json.Marshal(typicalQuestionEvent())
+	require.NoError(t, err)
+
+	frame, err := encodeBinaryEvent(jsonEvent)
+	require.NoError(t, err)
+	require.True(t, isBinaryFrame(frame))
+	assert.Less(t, len(frame), len(jsonEvent)/2)
+
+	question, err := DecodeQuestionFrame(frame)
+	require.NoError(t, err)
+	assert.Equal(t, uint(1234), question.QuestionID)
+	assert.Equal(t, uint(56), question.QuizID)
+	assert.Equal(t, "single_choice", question.Type)
+	assert.Equal(t, 3, question.Number)
+	assert.Equal(t, 10, question.PointValue)
+	assert.Equal(t, int64(1760600000123), question.StartTime)
+	assert.Equal(t, []QuestionOptionFrame{
+		{ID: 1, Text: "Марс"}, {ID: 2, Text: "Юпитер"}, {ID: 3, Text: "Сатурн"}, {ID: 4, Text: "Нептун"},
+	}, question.Options)
+
+	jsonTimer, _ := json.Marshal(typicalTimerEvent())
+	timerFrame, err := encodeBinaryEvent(jsonTimer)
+	require.NoError(t, err)
+	timer, err := DecodeTimerFrame(timerFrame)
+	require.NoError(t, err)
+	assert.Equal(t, TimerFrame{QuestionID: 1234, RemainingSeconds: 12, ServerTimestamp: 1760600003125}, timer)
+
+	// Для остальных событий бинарного формата нет
+	assert.True(t, hasBinaryFormat(typicalTimerEvent()))
+	assert.False(t, hasBinaryFormat(map[string]interface{}{"type": "quiz:leaderboard"}))
+	other, err := encodeBinaryEvent([]byte(`{"type":"quiz:finish","data":{}}`))
+	require.NoError(t, err)
+	assert.Nil(t, other)
+}
+
+func TestBinaryProtocol_AnswerRoundTrip(t *testing.T) {
+	ordering := AnswerFrame{QuestionID: 7, Order: []int{3, 1, 2}, Timestamp: -1}
+	for _, answer := range []AnswerFrame{typicalAnswer(), ordering} {
+		decoded, err := DecodeAnswerFrame(EncodeAnswerFrame(answer))
+		require.NoError(t, err)
+		assert.Equal(t, answer, decoded)
+	}
+}
+
+func TestBinaryProtocol_RejectsMalformedFrames(t *testing.T) {
+	frame := EncodeQuestionFrame(QuestionFrame{QuestionID: 1, Text: "text", Options: []QuestionOptionFrame{{Text: "a"}}})
+
+	_, err := DecodeQuestionFrame(frame[:len(frame)-1])
+	assert.ErrorIs(t, err, ErrInvalidBinaryFrame)
+
+	_, err = DecodeQuestionFrame(append(frame, 0))
+	assert.ErrorIs(t, err, ErrInvalidBinaryFrame)
+
+	_, err = DecodeTimerFrame(frame)
+	assert.ErrorIs(t, err, ErrInvalidBinaryFrame, "кадр другого типа")
+
+	// Длина списка больше остатка кадра не должна приводить к большим аллокациям
+	_, err = DecodeAnswerFrame([]byte{binaryFrameAnswer, 1, 2, 2, 0xff, 0xff, 0x03})
+	assert.ErrorIs(t, err, ErrInvalidBinaryFrame)
+}
+
+func TestManager_HandleBinaryAnswer(t *testing.T) {
+	hub := &stubHub{}
+	m := NewManager(hub)
+	m.EnableBinaryProtocol()
+
+	var received AnswerFrame
+	m.RegisterHandler("user:answer", func(data json.RawMessage, client *Client) error {
+		return json.Unmarshal(data, &received)
+	})
+
+	client := NewClient(nil, nil, "42")
+
+	// Без согласованного подпротокола бинарные кадры не принимаются
+	assert.Error(t, m.HandleMessage(EncodeAnswerFrame(typicalAnswer()), client))
+	require.Len(t, hub.sent, 1)
+	assert.Equal(t, "server:error", hub.sent[0].Type)
+
+	client.SetBinaryProtocol(true)
+	require.NoError(t, m.HandleMessage(EncodeAnswerFrame(typicalAnswer()), client))
+	assert.Equal(t, typicalAnswer(), received)
+}
+
+func TestClient_PayloadFor(t *testing.T) {
+	jsonMessage, binaryMessage := []byte(`{"type":"quiz:timer"}`), []byte{binaryFrameTimer}
+	client := NewClient(nil, nil, "1")
+
+	assert.Equal(t, jsonMessage, client.payloadFor(jsonMessage, binaryMessage))
+	client.SetBinaryProtocol(true)
+	assert.Equal(t, binaryMessage, client.payloadFor(jsonMessage, binaryMessage))
+	assert.Equal(t, jsonMessage, client.payloadFor(jsonMessage, nil), "события без бинарного формата идут в JSON")
+}
+
+// Бенчмарки сравнивают размер и скорость кодирования типичного вопроса.
+// Размер сообщения выводится метрикой bytes/msg.
+
+func BenchmarkQuestionEncode_JSON(b *testing.B) {
+	event := typicalQuestionEvent()
+	var size int
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		data, _ := json.Marshal(event)
+		size = len(data)
+	}
+	b.ReportMetric(float64(size), "bytes/msg")
+}
+
+func BenchmarkQuestionEncode_Binary(b *testing.B) {
+	jsonEvent, _ := json.Marshal(typicalQuestionEvent())
+	question, _ := encodeBinaryEvent(jsonEvent)
+	frame, _ := DecodeQuestionFrame(question)
+	var size int
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		size = len(EncodeQuestionFrame(frame))
+	}
+	b.ReportMetric(float64(size), "bytes/msg")
+}
+
+func BenchmarkQuestionDecode_JSON(b *testing.B) {
+	data, _ := json.Marshal(typicalQuestionEvent())
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var event struct {
+			Type string        `json:"type"`
+			Data QuestionFrame `json:"data"`
+		}
+		_ = json.Unmarshal(data, &event)
+	}
+}
+
+func BenchmarkQuestionDecode_Binary(b *testing.B) {
+	jsonEvent, _ := json.Marshal(typicalQuestionEvent())
+	frame, _ := encodeBinaryEvent(jsonEvent)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, _ = DecodeQuestionFrame(frame)
+	}
+}
+
+func BenchmarkTimerEncode_JSON(b *testing.B) {
+	event := typicalTimerEvent()
+	var size int
+	for i := 0; i < b.N; i++ {
+		data, _ := json.Marshal(event)
+		size = len(data)
+	}
+	b.ReportMetric(float64(size), "bytes/msg")
+}
+
+func BenchmarkTimerEncode_Binary(b *testing.B) {
+	frame := TimerFrame{QuestionID: 1234, RemainingSeconds: 12, ServerTimestamp: 1760600003125}
+	var size int
+	for i := 0; i < b.N; i++ {
+		size = len(EncodeTimerFrame(frame))
+	}
+	b.ReportMetric(float64(size), "bytes/msg")
+}
+
+func BenchmarkAnswerDecode_JSON(b *testing.B) {
+	data, _ := json.Marshal(Event{Type: "user:answer", Data: typicalAnswer()})
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var event struct {
+			Type string      `json:"type"`
+			Data AnswerFrame `json:"data"`
+		}
+		_ = json.Unmarshal(data, &event)
+	}
+	b.ReportMetric(float64(len(data)), "bytes/msg")
+}
+
+func BenchmarkAnswerDecode_Binary(b *testing.B) {
+	frame := EncodeAnswerFrame(typicalAnswer())
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, _ = DecodeAnswerFrame(frame)
+	}
+	b.ReportMetric(float64(len(frame)), "bytes/msg")
+}
@@ -3,15 +3,21 @@ package websocket
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"net"
 	"runtime/debug"
+	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
+
+	"github.com/yourusername/trivia-api/internal/i18n"
+	"github.com/yourusername/trivia-api/pkg/geoip"
 )
 
 const (
@@ -30,6 +36,10 @@ const (
 
 	// Размер буфера по умолчанию для каналов отправки сообщений клиенту
 	defaultClientBufferSize = 64
+
+	// Число медленных записей подряд, после которого клиент отключается,
+	// если MaxSlowWrites не задан
+	defaultMaxSlowWrites = 3
 )
 
 var (
@@ -48,9 +58,17 @@ type ClientConfig struct {
 	// PongWait определяет время ожидания pong-ответа
 	PongWait time.Duration
 
-	// WriteWait определяет тайм-аут для записи сообщений
+	// WriteWait определяет тайм-аут для записи сообщений. Запись, не завершившаяся
+	// за это время, обрывает соединение.
 	WriteWait time.Duration
 
+	// SlowWriteThreshold: запись дольше этого времени считается медленной. 0 - не отслеживать.
+	SlowWriteThreshold time.Duration
+
+	// MaxSlowWrites: после стольких медленных записей подряд клиент отключается
+	// как медленный потребитель
+	MaxSlowWrites int
+
 	// MaxMessageSize определяет максимальный размер сообщения
 	MaxMessageSize int64
 }
@@ -84,12 +102,36 @@ type Client struct {
 	// Уменьшен размер буфера с 256 до 64 для экономии памяти
 	send chan []byte
 
+	// Максимальная занятость буфера send за время соединения:
+	// показывает, насколько клиент приближался к переполнению
+	sendHighWater atomic.Int32
+
+	// Счетчики соединения для диагностики (см. ConnectionStats): обновляются
+	// атомарно и читаются только по запросу администратора
+	connectedAt      time.Time
+	messagesQueued   atomic.Int64
+	messagesDropped  atomic.Int64
+	messagesReceived atomic.Int64
+
 	// Время последней активности клиента
 	lastActivity time.Time
+	// Время отправки IdleWarningEvent (UnixNano); активность после него отменяет предупреждение
+	idleWarnedAt atomic.Int64
 
 	// Канал для ожидания завершения регистрации
 	registrationComplete chan struct{}
 
+	// Результат регистрации в шарде: принят, отклонен или ждет решения
+	// текущего соединения пользователя (см. DuplicateConnectionPolicy)
+	registration atomic.Int32
+	// Решение текущего соединения при политике DuplicatePrompt
+	takeoverDecision chan bool
+	// Зарегистрировать, заменив текущее соединение, без учета политики
+	forceReplace bool
+	// Сообщение (или ошибка чтения), прочитанное во время ожидания передачи
+	// соединения; readPump возвращает его первым
+	pendingRead chan pendingMessage
+
 	// Карта подписок на типы сообщений
 	subscriptions sync.Map
 
@@ -102,6 +144,29 @@ type Client struct {
 	// ID викторины, к которой подключен клиент (0 если не подключен)
 	// Используем атомарный тип для потокобезопасности
 	currentQuizID atomic.Uint32
+
+	// Клиент согласовал бинарный подпротокол (BinaryProtocolName)
+	binaryProtocol atomic.Bool
+
+	// Время отправки последнего ping (UnixNano) и измеренное по pong время кругового пути
+	lastPingSentAt atomic.Int64
+	rttNanos       atomic.Int64
+
+	// Вызывается при закрытии соединения (задается до StartPumps)
+	onDisconnect func(client *Client)
+
+	// Местоположение по IP-адресу подключения (задается до StartPumps)
+	location geoip.Location
+
+	// Язык сообщений server:error по Accept-Language подключения (задается до StartPumps)
+	lang i18n.Lang
+
+	// Политика записи: тайм-аут и отключение медленного потребителя
+	writeWait          time.Duration
+	slowWriteThreshold time.Duration
+	maxSlowWrites      int
+	// Медленные записи подряд (используется только в writePump)
+	consecutiveSlowWrites int
 }
 
 // NewClient создает нового клиента
@@ -110,12 +175,14 @@ func NewClient(hub interface{}, conn *websocket.Conn, userID string) *Client {
 	return &Client{
 		hub:                  hub,
 		conn:                 conn,
-		send:                 make(chan []byte, 64), // Уменьшено с 256 до 64
+		send:                 make(chan []byte, defaultClientBufferSize),
 		UserID:               userID,
 		ConnectionID:         connectionID,
 		lastActivity:         time.Now(),
+		connectedAt:          time.Now(),
 		registrationComplete: make(chan struct{}, 1),
 		roles:                make(map[string]bool),
+		writeWait:            writeWait,
 	}
 }
 
@@ -127,6 +194,12 @@ func NewClientWithConfig(hub interface{}, conn *websocket.Conn, userID string, c
 	if config.BufferSize <= 0 {
 		config.BufferSize = defaultClientBufferSize
 	}
+	if config.WriteWait <= 0 {
+		config.WriteWait = writeWait
+	}
+	if config.MaxSlowWrites <= 0 {
+		config.MaxSlowWrites = defaultMaxSlowWrites
+	}
 
 	return &Client{
 		hub:                  hub,
@@ -135,9 +208,63 @@ func NewClientWithConfig(hub interface{}, conn *websocket.Conn, userID string, c
 		UserID:               userID,
 		ConnectionID:         connectionID,
 		lastActivity:         time.Now(),
+		connectedAt:          time.Now(),
 		registrationComplete: make(chan struct{}, 1),
 		roles:                make(map[string]bool),
+		writeWait:            config.WriteWait,
+		slowWriteThreshold:   config.SlowWriteThreshold,
+		maxSlowWrites:        config.MaxSlowWrites,
+	}
+}
+
+// SetBinaryProtocol включает бинарный формат частых событий для клиента.
+// Вызывается до StartPumps по результату согласования подпротокола.
+func (c *Client) SetBinaryProtocol(enabled bool) {
+	c.binaryProtocol.Store(enabled)
+}
+
+// SetDisconnectHandler задает обработчик закрытия соединения.
+// Вызывается до StartPumps; обработчик выполняется в горутине чтения.
+func (c *Client) SetDisconnectHandler(handler func(client *Client)) {
+	c.onDisconnect = handler
+}
+
+// SetLocation задает местоположение клиента, определенное по IP-адресу.
+// Вызывается до StartPumps.
+func (c *Client) SetLocation(location geoip.Location) {
+	c.location = location
+}
+
+// Location возвращает местоположение клиента (пустое, если не определено)
+func (c *Client) Location() geoip.Location {
+	return c.location
+}
+
+// SetLang задает язык сообщений об ошибках для клиента. Вызывается до StartPumps.
+func (c *Client) SetLang(lang i18n.Lang) {
+	c.lang = lang
+}
+
+// Lang возвращает язык сообщений об ошибках (DefaultLang, если не задан)
+func (c *Client) Lang() i18n.Lang {
+	if c.lang == "" {
+		return i18n.DefaultLang
+	}
+	return c.lang
+}
+
+// UsesBinaryProtocol сообщает, согласован ли с клиентом бинарный формат
+func (c *Client) UsesBinaryProtocol() bool {
+	return c.binaryProtocol.Load()
+}
+
+// payloadFor выбирает представление события для клиента: бинарный кадр,
+// если он есть и клиент его поддерживает, иначе JSON
+func (c *Client) payloadFor(jsonMessage, binaryMessage []byte) []byte {
+	if binaryMessage != nil && c.UsesBinaryProtocol() {
+		return binaryMessage
 	}
+	return jsonMessage
 }
 
 // SetQuizID устанавливает ID текущей викторины для клиента
@@ -161,6 +288,9 @@ func (c *Client) ClearQuizID() {
 func (c *Client) readPump(messageHandler func(message []byte, client *Client) error) {
 	defer func() {
 		log.Printf("WebSocket Client Read Pump STOPPED for UserID: %s, ConnID: %s", c.UserID, c.ConnectionID)
+		if c.onDisconnect != nil {
+			c.onDisconnect(c)
+		}
 		// Сообщаем хабу об отписке клиента
 		// Проверяем, к какому типу хаба подключен клиент
 		switch hub := c.hub.(type) {
@@ -177,19 +307,15 @@ func (c *Client) readPump(messageHandler func(message []byte, client *Client) er
 		c.conn.Close()
 	}()
 
-	// Настройка чтения сообщений
-	c.conn.SetReadLimit(maxMessageSize) // Используем значение из defaultConfig
-	c.conn.SetReadDeadline(time.Now().Add(pongWait))
-	c.conn.SetPongHandler(func(string) error {
-		c.conn.SetReadDeadline(time.Now().Add(pongWait))
-		c.lastActivity = time.Now() // Обновляем время активности при получении pong
-		return nil
-	})
+	// Во время ожидания передачи соединения чтение уже настроено (awaitTakeover)
+	if c.pendingRead == nil {
+		c.configureRead()
+	}
 
 	log.Printf("WebSocket Client Read Pump STARTED for UserID: %s, ConnID: %s", c.UserID, c.ConnectionID)
 
 	for {
-		_, message, err := c.conn.ReadMessage()
+		messageType, message, err := c.nextMessage()
 		if err != nil {
 			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure, websocket.CloseNormalClosure) {
 				log.Printf("WebSocket Client Read Error (UserID: %s, ConnID: %s): %v", c.UserID, c.ConnectionID, err)
@@ -205,6 +331,12 @@ func (c *Client) readPump(messageHandler func(message []byte, client *Client) er
 
 		// Обновляем время активности при получении сообщения
 		c.lastActivity = time.Now()
+		c.messagesReceived.Add(1)
+
+		// Переводы строк заменяются только в текстовых (JSON) сообщениях, бинарные кадры передаются как есть
+		if messageType == websocket.TextMessage {
+			message = bytes.TrimSpace(bytes.Replace(message, newline, space, -1))
+		}
 
 		// Безопасный вызов обработчика с recover
 		if handlerErr := safeHandleMessage(message, c, messageHandler); handlerErr != nil {
@@ -215,6 +347,31 @@ func (c *Client) readPump(messageHandler func(message []byte, client *Client) er
 	}
 }
 
+// configureRead задает лимит размера сообщения, срок чтения и обработчик pong
+func (c *Client) configureRead() {
+	c.conn.SetReadLimit(maxMessageSize) // Используем значение из defaultConfig
+	c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	c.conn.SetPongHandler(func(string) error {
+		c.conn.SetReadDeadline(time.Now().Add(pongWait))
+		if sentAt := c.lastPingSentAt.Load(); sentAt > 0 {
+			c.rttNanos.Store(time.Now().UnixNano() - sentAt)
+		}
+		c.lastActivity = time.Now() // Обновляем время активности при получении pong
+		return nil
+	})
+}
+
+// nextMessage читает следующее сообщение. Сообщение, прочитанное во время
+// ожидания передачи соединения, возвращается первым.
+func (c *Client) nextMessage() (int, []byte, error) {
+	if c.pendingRead != nil {
+		pending := <-c.pendingRead
+		c.pendingRead = nil
+		return pending.messageType, pending.data, pending.err
+	}
+	return c.conn.ReadMessage()
+}
+
 // safeHandleMessage - обертка для вызова обработчика с recover
 // Возвращает ошибку, если обработчик вернул ошибку.
 func safeHandleMessage(message []byte, client *Client, messageHandler func(message []byte, client *Client) error) (err error) {
@@ -229,7 +386,6 @@ func safeHandleMessage(message []byte, client *Client, messageHandler func(messa
 		}
 	}()
 	// Вызов оригинального обработчика
-	message = bytes.TrimSpace(bytes.Replace(message, newline, space, -1))
 	if messageHandler != nil {
 		err = messageHandler(message, client) // Сохраняем возвращенную ошибку
 	} else {
@@ -257,7 +413,8 @@ func (c *Client) writePump() {
 			log.Printf("[Client %s][Conn %s] Dequeued message for writing. Type: %s. Current send buffer len: %d", c.UserID, c.ConnectionID, messageTypeFromBytes(message), len(c.send))
 
 			// Устанавливаем таймаут для записи
-			if err := c.conn.SetWriteDeadline(time.Now().Add(writeWait)); err != nil {
+			writeStart := time.Now()
+			if err := c.conn.SetWriteDeadline(writeStart.Add(c.writeWait)); err != nil {
 				log.Printf("WebSocket Client SetWriteDeadline Error (UserID: %s, ConnID: %s): %v", c.UserID, c.ConnectionID, err)
 				// Ошибку установки дедлайна можно считать фатальной для записи
 				return // Завершаем горутину записи
@@ -271,10 +428,25 @@ func (c *Client) writePump() {
 				return // Завершаем горутину записи
 			}
 
+			// Бинарные кадры отправляются отдельными сообщениями и не объединяются с JSON
+			if isBinaryFrame(message) {
+				if err := c.conn.WriteMessage(websocket.BinaryMessage, message); err != nil {
+					log.Printf("WebSocket Client Binary Write Error (UserID: %s, ConnID: %s): %v", c.UserID, c.ConnectionID, err)
+					c.recordWriteError(err)
+					return // Завершаем горутину записи
+				}
+				if c.noteWriteDuration(time.Since(writeStart)) {
+					c.closeSlowConsumer()
+					return
+				}
+				continue
+			}
+
 			// Получаем writer для отправки сообщения
 			w, err := c.conn.NextWriter(websocket.TextMessage)
 			if err != nil {
 				log.Printf("WebSocket Client NextWriter Error (UserID: %s, ConnID: %s): %v", c.UserID, c.ConnectionID, err)
+				c.recordWriteError(err)
 				return // Завершаем горутину записи
 			}
 
@@ -285,29 +457,50 @@ func (c *Client) writePump() {
 				// Не выходим сразу, пытаемся закрыть writer
 			}
 
-			// Добавляем оставшиеся сообщения в очереди в текущее сообщение WebSocket (оптимизация)
+			// Добавляем оставшиеся сообщения в очереди в текущее сообщение WebSocket (оптимизация).
+			// Бинарный кадр в очереди завершает объединение: он будет отправлен на следующей итерации.
 			n := len(c.send)
+			var pendingBinary []byte
 			for i := 0; i < n; i++ {
+				queued := <-c.send
+				if isBinaryFrame(queued) {
+					pendingBinary = queued
+					break
+				}
 				w.Write(newline)
-				w.Write(<-c.send)
+				w.Write(queued)
 			}
 
 			// Закрываем writer, чтобы отправить сообщение
 			if err := w.Close(); err != nil {
 				log.Printf("WebSocket Client Writer Close Error (UserID: %s, ConnID: %s): %v", c.UserID, c.ConnectionID, err)
+				c.recordWriteError(err)
 				return // Завершаем горутину записи
 			}
+			if pendingBinary != nil {
+				if err := c.conn.WriteMessage(websocket.BinaryMessage, pendingBinary); err != nil {
+					log.Printf("WebSocket Client Binary Write Error (UserID: %s, ConnID: %s): %v", c.UserID, c.ConnectionID, err)
+					c.recordWriteError(err)
+					return
+				}
+			}
 			// Лог после успешной записи
 			log.Printf("[Client %s][Conn %s] Successfully wrote message. Type: %s", c.UserID, c.ConnectionID, messageTypeFromBytes(message))
+			if c.noteWriteDuration(time.Since(writeStart)) {
+				c.closeSlowConsumer()
+				return
+			}
 
 		case <-ticker.C:
 			// Отправляем ping клиенту
-			if err := c.conn.SetWriteDeadline(time.Now().Add(writeWait)); err != nil {
+			if err := c.conn.SetWriteDeadline(time.Now().Add(c.writeWait)); err != nil {
 				log.Printf("WebSocket Client SetWriteDeadline (Ping) Error (UserID: %s, ConnID: %s): %v", c.UserID, c.ConnectionID, err)
 				return // Завершаем горутину записи
 			}
+			c.lastPingSentAt.Store(time.Now().UnixNano())
 			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
 				log.Printf("WebSocket Client Ping Error (UserID: %s, ConnID: %s): %v", c.UserID, c.ConnectionID, err)
+				c.recordWriteError(err)
 				return // Завершаем горутину записи при ошибке пинга
 			}
 			// Логируем отправку пинга (можно сделать реже или убрать)
@@ -316,12 +509,84 @@ func (c *Client) writePump() {
 	}
 }
 
-// StartPumps запускает горутины для чтения и записи сообщений
-func (c *Client) StartPumps(messageHandler func(message []byte, client *Client) error) {
+// noteWriteDuration учитывает длительность записи и сообщает, пора ли отключить
+// клиента как медленного потребителя (MaxSlowWrites медленных записей подряд)
+func (c *Client) noteWriteDuration(elapsed time.Duration) bool {
+	if c.slowWriteThreshold <= 0 {
+		return false
+	}
+	if elapsed < c.slowWriteThreshold {
+		c.consecutiveSlowWrites = 0
+		return false
+	}
+
+	c.consecutiveSlowWrites++
+	log.Printf("WebSocket Client Slow Write (UserID: %s, ConnID: %s): %v, %d подряд", c.UserID, c.ConnectionID, elapsed, c.consecutiveSlowWrites)
+
+	disconnect := c.consecutiveSlowWrites >= c.maxSlowWrites
+	if metrics := c.shardMetrics(); metrics != nil {
+		metrics.mu.Lock()
+		metrics.slowWrites++
+		if disconnect {
+			metrics.slowConsumerDisconnects++
+		}
+		metrics.mu.Unlock()
+	}
+	return disconnect
+}
+
+// recordWriteError учитывает тайм-аут записи в метриках шарда.
+// После тайм-аута соединение непригодно для записи, поэтому writePump завершается.
+func (c *Client) recordWriteError(err error) {
+	var netErr net.Error
+	if !errors.As(err, &netErr) || !netErr.Timeout() {
+		return
+	}
+	log.Printf("WebSocket Client Write Timeout (UserID: %s, ConnID: %s): запись не завершилась за %v", c.UserID, c.ConnectionID, c.writeWait)
+	if metrics := c.shardMetrics(); metrics != nil {
+		metrics.mu.Lock()
+		metrics.writeTimeouts++
+		metrics.mu.Unlock()
+	}
+}
+
+// closeSlowConsumer сообщает клиенту причину отключения кодом 1013 (Try Again Later)
+func (c *Client) closeSlowConsumer() {
+	log.Printf("WebSocket Client Slow Consumer (UserID: %s, ConnID: %s): отключение после %d медленных записей подряд",
+		c.UserID, c.ConnectionID, c.consecutiveSlowWrites)
+	closeMessage := websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "slow consumer")
+	c.conn.WriteControl(websocket.CloseMessage, closeMessage, time.Now().Add(time.Second))
+}
+
+// closeWithReason отправляет клиенту кадр закрытия с кодом и причиной и закрывает
+// соединение. readPump получает ошибку чтения и снимает клиента с регистрации обычным путем.
+func (c *Client) closeWithReason(code int, reason string) {
+	closeMessage := websocket.FormatCloseMessage(code, reason)
+	c.conn.WriteControl(websocket.CloseMessage, closeMessage, time.Now().Add(time.Second))
+	c.conn.Close()
+}
+
+// shardMetrics возвращает метрики шарда, обслуживающего клиента
+func (c *Client) shardMetrics() *ShardMetrics {
+	switch hub := c.hub.(type) {
+	case *Shard:
+		return hub.metrics
+	case *ShardedHub:
+		if len(hub.shards) > 0 {
+			return hub.getShard(c.UserID).metrics
+		}
+	}
+	return nil
+}
+
+// StartPumps регистрирует клиента и запускает горутины для чтения и записи
+// сообщений. Возвращает false, если соединение не зарегистрировано (например,
+// отклонено как повторное соединение пользователя).
+func (c *Client) StartPumps(messageHandler func(message []byte, client *Client) error) bool {
 	if c.UserID == "" {
 		log.Printf("WebSocket: client has no UserID, skipping registration")
 		c.conn.Close()
-		return
+		return false
 	}
 
 	// Регистрируем клиента в хабе в зависимости от его типа
@@ -334,7 +599,7 @@ func (c *Client) StartPumps(messageHandler func(message []byte, client *Client)
 	} else {
 		log.Printf("WebSocket: unknown hub type for client %s, skipping registration", c.UserID)
 		c.conn.Close()
-		return
+		return false
 	}
 
 	// Ожидаем завершения регистрации
@@ -344,7 +609,21 @@ func (c *Client) StartPumps(messageHandler func(message []byte, client *Client)
 	case <-time.After(5 * time.Second):
 		log.Printf("WebSocket: timeout waiting for client %s registration", c.UserID)
 		c.conn.Close()
-		return
+		return false
+	}
+
+	// Повторное соединение пользователя могло быть отклонено или ждать решения
+	if sh, ok := c.hub.(*ShardedHub); ok {
+		switch c.registration.Load() {
+		case registrationRejected:
+			c.closeDuplicate()
+			return false
+		case registrationPending:
+			if !c.awaitTakeover(sh) {
+				c.closeDuplicate()
+				return false
+			}
+		}
 	}
 
 	// Проверяем, что клиент все еще зарегистрирован
@@ -361,11 +640,12 @@ func (c *Client) StartPumps(messageHandler func(message []byte, client *Client)
 
 	if !clientExists {
 		log.Printf("WebSocket: client %s was replaced before pumps started, skipping pumps", c.UserID)
-		return
+		return false
 	}
 
 	go c.writePump()
 	go c.readPump(messageHandler)
+	return true
 }
 
 // IsSubscribed проверяет, подписан ли клиент на указанный тип сообщений
@@ -422,9 +702,28 @@ func (c *Client) GetSubscriptions() []string {
 		return true
 	})
 
+	sort.Strings(subscriptions)
 	return subscriptions
 }
 
+// SetSubscriptions заменяет набор подписок клиента. Замена выполняется под
+// блокировкой, поэтому IsSubscribed не видит промежуточного состояния.
+func (c *Client) SetSubscriptions(messageTypes []string) {
+	c.subMutex.Lock()
+	defer c.subMutex.Unlock()
+
+	c.subscriptions.Range(func(key, value interface{}) bool {
+		c.subscriptions.Delete(key)
+		return true
+	})
+	for _, messageType := range messageTypes {
+		if messageType != "" {
+			c.subscriptions.Store(messageType, true)
+		}
+	}
+	log.Printf("WebSocket: клиент %s заменил подписки: %v", c.UserID, messageTypes)
+}
+
 // SubscribeToQuiz подписывает клиента на все типы сообщений викторины
 func (c *Client) SubscribeToQuiz() {
 	c.Subscribe(QUIZ_START)
@@ -435,6 +734,51 @@ func (c *Client) SubscribeToQuiz() {
 	log.Printf("WebSocket: клиент %s подписался на все сообщения викторины", c.UserID)
 }
 
+// RTT возвращает время кругового пути, измеренное по последнему ping/pong (0 - еще не измерено)
+func (c *Client) RTT() time.Duration {
+	return time.Duration(c.rttNanos.Load())
+}
+
+// SendBufferUsage возвращает занятость и размер буфера исходящих сообщений
+func (c *Client) SendBufferUsage() (used, capacity int) {
+	return len(c.send), cap(c.send)
+}
+
+// SendBufferHighWater возвращает максимальную занятость буфера исходящих сообщений
+func (c *Client) SendBufferHighWater() int {
+	return int(c.sendHighWater.Load())
+}
+
+// noteQueued обновляет максимум занятости буфера после постановки сообщения в очередь
+func (c *Client) noteQueued() {
+	c.messagesQueued.Add(1)
+	used := int32(len(c.send))
+	for {
+		current := c.sendHighWater.Load()
+		if used <= current || c.sendHighWater.CompareAndSwap(current, used) {
+			return
+		}
+	}
+}
+
+// noteDropped учитывает сообщение, не поставленное в очередь из-за переполнения буфера
+func (c *Client) noteDropped() {
+	c.messagesDropped.Add(1)
+}
+
+// GetRoles возвращает отсортированный список ролей клиента
+func (c *Client) GetRoles() []string {
+	c.subMutex.RLock()
+	defer c.subMutex.RUnlock()
+
+	roles := make([]string, 0, len(c.roles))
+	for role := range c.roles {
+		roles = append(roles, role)
+	}
+	sort.Strings(roles)
+	return roles
+}
+
 // HasRole проверяет, есть ли у клиента указанная роль
 func (c *Client) HasRole(role string) bool {
 	c.subMutex.RLock()
@@ -462,6 +806,9 @@ func (c *Client) RemoveRole(role string) {
 
 // messageTypeFromBytes пытается извлечь тип сообщения из JSON байтов
 func messageTypeFromBytes(message []byte) string {
+	if isBinaryFrame(message) {
+		return binaryFrameTypeName(message)
+	}
 	// Добавляем импорт encoding/json локально, если его нет вверху файла
 	// import "encoding/json"
 	var event struct {
@@ -0,0 +1,61 @@
+package websocket
+
+import (
+	"errors"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient_SetSubscriptionsReplacesSet(t *testing.T) {
+	client := &Client{UserID: "1"}
+	client.Subscribe(QUIZ_START)
+	client.Subscribe(QUIZ_END)
+
+	client.SetSubscriptions([]string{RESULT_UPDATE, QUESTION_START})
+
+	assert.Equal(t, []string{QUESTION_START, RESULT_UPDATE}, client.GetSubscriptions())
+	assert.False(t, client.IsSubscribed(QUIZ_START))
+
+	client.SetSubscriptions(nil)
+	assert.Empty(t, client.GetSubscriptions())
+}
+
+func TestIsSubscribableMessageType(t *testing.T) {
+	for _, messageType := range SubscribableMessageTypes() {
+		assert.True(t, IsSubscribableMessageType(messageType), messageType)
+	}
+	assert.False(t, IsSubscribableMessageType("quiz:unknown"))
+	assert.False(t, IsSubscribableMessageType(""))
+}
+
+func TestClient_SlowWritePolicy(t *testing.T) {
+	shard := NewShard(0, nil, 10, 0, 0) // Без фоновой очистки
+	hub := &ShardedHub{shards: []*Shard{shard}, shardCount: 1}
+	client := NewClientWithConfig(hub, nil, "7", ClientConfig{SlowWriteThreshold: 100 * time.Millisecond, MaxSlowWrites: 2})
+
+	assert.False(t, client.noteWriteDuration(200*time.Millisecond))
+	assert.False(t, client.noteWriteDuration(10*time.Millisecond), "быстрая запись сбрасывает счетчик")
+	assert.False(t, client.noteWriteDuration(200*time.Millisecond))
+	assert.True(t, client.noteWriteDuration(200*time.Millisecond), "вторая медленная запись подряд отключает клиента")
+
+	client.recordWriteError(&net.OpError{Op: "write", Err: os.ErrDeadlineExceeded})
+	client.recordWriteError(errors.New("connection reset"))
+
+	metrics := shard.GetMetrics()
+	assert.Equal(t, int64(3), metrics["slow_writes"])
+	assert.Equal(t, int64(1), metrics["slow_consumer_disconnects"])
+	assert.Equal(t, int64(1), metrics["write_timeouts"], "учитываются только тайм-ауты")
+}
+
+func TestClient_SlowWritePolicyDisabledByDefault(t *testing.T) {
+	client := NewClientWithConfig(nil, nil, "7", DefaultClientConfig())
+
+	for i := 0; i < 10; i++ {
+		assert.False(t, client.noteWriteDuration(time.Minute))
+	}
+	assert.Equal(t, writeWait, client.writeWait)
+}
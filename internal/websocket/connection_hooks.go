@@ -0,0 +1,132 @@
+package websocket
+
+import (
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// connectionHookQueueSize - размер очереди событий подключения для хука
+const connectionHookQueueSize = 1024
+
+// ConnectionEvent описывает подключение или отключение WebSocket-клиента
+type ConnectionEvent struct {
+	UserID       string
+	ConnectionID string
+	// Шард, обслуживающий соединение (-1 для устаревшего Hub без шардов)
+	ShardID   int
+	Timestamp time.Time
+}
+
+// ConnectionHook получает уведомления о подключении и отключении клиентов,
+// например для внешней системы присутствия или аналитики. Методы вызываются
+// в отдельной горутине в порядке событий и не блокируют регистрацию клиентов.
+// Если хук не успевает обрабатывать события, лишние события отбрасываются.
+type ConnectionHook interface {
+	OnConnect(event ConnectionEvent)
+	OnDisconnect(event ConnectionEvent)
+}
+
+// NoopConnectionHook - хук по умолчанию, ничего не делает
+type NoopConnectionHook struct{}
+
+// OnConnect ничего не делает
+func (NoopConnectionHook) OnConnect(ConnectionEvent) {}
+
+// OnDisconnect ничего не делает
+func (NoopConnectionHook) OnDisconnect(ConnectionEvent) {}
+
+// connectionHookEvent - событие в очереди хука
+type connectionHookEvent struct {
+	connected bool
+	event     ConnectionEvent
+}
+
+// connectionHooks доставляет события подключения зарегистрированному хуку
+type connectionHooks struct {
+	mu      sync.RWMutex
+	hook    ConnectionHook
+	events  chan connectionHookEvent
+	start   sync.Once
+	done    <-chan struct{}
+	dropped atomic.Int64
+}
+
+func newConnectionHooks(done <-chan struct{}) *connectionHooks {
+	return &connectionHooks{
+		hook:   NoopConnectionHook{},
+		events: make(chan connectionHookEvent, connectionHookQueueSize),
+		done:   done,
+	}
+}
+
+// set заменяет хук; nil возвращает хук по умолчанию
+func (c *connectionHooks) set(hook ConnectionHook) {
+	if hook == nil {
+		hook = NoopConnectionHook{}
+	}
+	c.mu.Lock()
+	c.hook = hook
+	c.mu.Unlock()
+
+	if _, noop := hook.(NoopConnectionHook); !noop {
+		c.start.Do(func() { go c.run() })
+	}
+}
+
+func (c *connectionHooks) current() ConnectionHook {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.hook
+}
+
+// notify ставит событие в очередь, не блокируя вызывающего
+func (c *connectionHooks) notify(connected bool, client *Client, shardID int) {
+	if _, noop := c.current().(NoopConnectionHook); noop {
+		return
+	}
+	item := connectionHookEvent{
+		connected: connected,
+		event: ConnectionEvent{
+			UserID:       client.UserID,
+			ConnectionID: client.ConnectionID,
+			ShardID:      shardID,
+			Timestamp:    time.Now(),
+		},
+	}
+	select {
+	case c.events <- item:
+	default:
+		if c.dropped.Add(1)%100 == 1 {
+			log.Printf("[ConnectionHook] WARNING: Очередь событий переполнена, событие пользователя %s отброшено (всего отброшено: %d)",
+				client.UserID, c.dropped.Load())
+		}
+	}
+}
+
+// run вызывает хук для событий из очереди до остановки хаба
+func (c *connectionHooks) run() {
+	for {
+		select {
+		case item := <-c.events:
+			c.deliver(item)
+		case <-c.done:
+			return
+		}
+	}
+}
+
+func (c *connectionHooks) deliver(item connectionHookEvent) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("[ConnectionHook] Восстановление после паники в хуке: %v", r)
+		}
+	}()
+	hook := c.current()
+	if item.connected {
+		hook.OnConnect(item.event)
+	} else {
+		hook.OnDisconnect(item.event)
+	}
+}
@@ -0,0 +1,143 @@
+package websocket
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Ключи сортировки соединений в ShardedHub.TopConnections
+const (
+	ConnectionSortSent        = "sent"         // Больше всего сообщений поставлено в очередь
+	ConnectionSortDropped     = "dropped"      // Больше всего сообщений потеряно из-за переполнения
+	ConnectionSortBuffer      = "buffer"       // Наибольшая занятость буфера за время соединения
+	ConnectionSortInboundRate = "inbound_rate" // Больше всего входящих сообщений в секунду
+	ConnectionSortRTT         = "rtt"          // Наибольшее время кругового пути
+	ConnectionSortAge         = "age"          // Самые старые соединения
+)
+
+// MaxTopConnections - наибольшее число соединений в одной выборке
+const MaxTopConnections = 100
+
+var (
+	// ErrUnknownConnectionSort возвращается для неизвестного ключа сортировки
+	ErrUnknownConnectionSort = errors.New("unknown connection sort key")
+	// ErrShardNotFound возвращается для несуществующего номера шарда
+	ErrShardNotFound = errors.New("shard not found")
+	// ErrConnectionStatsUnavailable возвращается, если хаб не поддерживает выборку соединений
+	ErrConnectionStatsUnavailable = errors.New("connection stats require a sharded hub")
+)
+
+// ConnectionStats - снимок счетчиков одного соединения для разбора инцидентов:
+// помогает отличить одного проблемного клиента от общей нагрузки на шард
+type ConnectionStats struct {
+	UserID              string  `json:"user_id"`
+	ConnectionID        string  `json:"connection_id"`
+	ShardID             int     `json:"shard_id"`
+	QuizID              uint    `json:"quiz_id"`
+	AgeSec              float64 `json:"age_sec"`
+	MessagesSent        int64   `json:"messages_sent"` // Поставлены в очередь отправки
+	MessagesDropped     int64   `json:"messages_dropped"`
+	MessagesReceived    int64   `json:"messages_received"`
+	InboundRate         float64 `json:"inbound_rate"` // Входящих сообщений в секунду за время соединения
+	SendBufferUsed      int     `json:"send_buffer_used"`
+	SendBufferSize      int     `json:"send_buffer_size"`
+	SendBufferHighWater int     `json:"send_buffer_high_water"`
+	RTTMs               float64 `json:"rtt_ms"`
+}
+
+// connectionStats снимает счетчики соединения на момент now
+func (c *Client) connectionStats(now time.Time) ConnectionStats {
+	used, size := c.SendBufferUsage()
+	stats := ConnectionStats{
+		UserID:              c.UserID,
+		ConnectionID:        c.ConnectionID,
+		QuizID:              c.GetQuizID(),
+		MessagesSent:        c.messagesQueued.Load(),
+		MessagesDropped:     c.messagesDropped.Load(),
+		MessagesReceived:    c.messagesReceived.Load(),
+		SendBufferUsed:      used,
+		SendBufferSize:      size,
+		SendBufferHighWater: c.SendBufferHighWater(),
+		RTTMs:               float64(c.RTT().Microseconds()) / 1000,
+	}
+	if !c.connectedAt.IsZero() {
+		stats.AgeSec = now.Sub(c.connectedAt).Seconds()
+	}
+	if stats.AgeSec > 0 {
+		stats.InboundRate = float64(stats.MessagesReceived) / stats.AgeSec
+	}
+	return stats
+}
+
+// connectionStats снимает счетчики всех соединений шарда. sync.Map обходится
+// без блокировки, поэтому регистрация клиентов во время выборки не ждет.
+func (s *Shard) connectionStats(now time.Time) []ConnectionStats {
+	var stats []ConnectionStats
+	s.clients.Range(func(key, _ interface{}) bool {
+		if client, ok := key.(*Client); ok {
+			snapshot := client.connectionStats(now)
+			snapshot.ShardID = s.id
+			stats = append(stats, snapshot)
+		}
+		return true
+	})
+	return stats
+}
+
+// connectionSortValues - значение, по которому сортируются соединения, для каждого ключа
+var connectionSortValues = map[string]func(ConnectionStats) float64{
+	ConnectionSortSent:        func(s ConnectionStats) float64 { return float64(s.MessagesSent) },
+	ConnectionSortDropped:     func(s ConnectionStats) float64 { return float64(s.MessagesDropped) },
+	ConnectionSortBuffer:      func(s ConnectionStats) float64 { return float64(s.SendBufferHighWater) },
+	ConnectionSortInboundRate: func(s ConnectionStats) float64 { return s.InboundRate },
+	ConnectionSortRTT:         func(s ConnectionStats) float64 { return s.RTTMs },
+	ConnectionSortAge:         func(s ConnectionStats) float64 { return s.AgeSec },
+}
+
+// TopConnections возвращает до limit соединений шарда shardID с наибольшим
+// значением sortBy (shardID < 0 - по всем шардам экземпляра). Счетчики
+// соединений обновляются атомарно, а снимок и сортировка выполняются только
+// при запросе, поэтому без запросов выборка ничего не стоит.
+func (h *ShardedHub) TopConnections(shardID int, sortBy string, limit int) ([]ConnectionStats, error) {
+	value, ok := connectionSortValues[sortBy]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownConnectionSort, sortBy)
+	}
+	if shardID >= len(h.shards) {
+		return nil, fmt.Errorf("%w: %d", ErrShardNotFound, shardID)
+	}
+	if limit <= 0 || limit > MaxTopConnections {
+		limit = MaxTopConnections
+	}
+
+	now := time.Now()
+	var stats []ConnectionStats
+	for _, shard := range h.shards {
+		if shardID < 0 || shard.id == shardID {
+			stats = append(stats, shard.connectionStats(now)...)
+		}
+	}
+
+	sort.SliceStable(stats, func(i, j int) bool {
+		return value(stats[i]) > value(stats[j])
+	})
+	if len(stats) > limit {
+		stats = stats[:limit]
+	}
+	if stats == nil {
+		stats = []ConnectionStats{}
+	}
+	return stats, nil
+}
+
+// TopConnections возвращает самые нагруженные или медленные соединения
+// экземпляра (см. ShardedHub.TopConnections)
+func (m *Manager) TopConnections(shardID int, sortBy string, limit int) ([]ConnectionStats, error) {
+	shardedHub, ok := m.hub.(*ShardedHub)
+	if !ok {
+		return nil, ErrConnectionStatsUnavailable
+	}
+	return shardedHub.TopConnections(shardID, sortBy, limit)
+}
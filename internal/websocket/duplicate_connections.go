@@ -0,0 +1,248 @@
+package websocket
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// DuplicateConnectionPolicy определяет, что делать со вторым соединением
+// пользователя с тем же экземпляром (например, из второй вкладки)
+type DuplicateConnectionPolicy string
+
+const (
+	// DuplicateReplace - новое соединение заменяет старое, старое закрывается
+	DuplicateReplace DuplicateConnectionPolicy = "replace"
+
+	// DuplicateRejectNew - старое соединение остается, новое закрывается с
+	// причиной AlreadyConnectedCloseReason
+	DuplicateRejectNew DuplicateConnectionPolicy = "reject_new"
+
+	// DuplicatePrompt - старое соединение получает TakeoverRequestedEvent и
+	// отвечает TakeoverResponseEvent; без ответа новое соединение заменяет старое
+	DuplicatePrompt DuplicateConnectionPolicy = "prompt"
+)
+
+// События и причина закрытия при повторном соединении пользователя
+const (
+	// TakeoverRequestedEvent отправляется текущему соединению при политике prompt
+	TakeoverRequestedEvent = "connection:takeover_requested"
+	// TakeoverPendingEvent отправляется новому соединению, ожидающему решения
+	TakeoverPendingEvent = "connection:takeover_pending"
+	// TakeoverResponseEvent - ответ текущего соединения: {"accept": bool}
+	TakeoverResponseEvent = "connection:takeover_response"
+	// AlreadyConnectedCloseReason - причина закрытия отклоненного нового соединения
+	AlreadyConnectedCloseReason = "already_connected"
+)
+
+// DefaultTakeoverTimeout - сколько по умолчанию ждать ответа на запрос передачи соединения
+const DefaultTakeoverTimeout = 15 * time.Second
+
+// ErrNoPendingTakeover возвращается на ответ, если передачу соединения никто не запрашивал
+var ErrNoPendingTakeover = errors.New("no pending connection takeover")
+
+// Результат регистрации клиента в шарде (Client.registration)
+const (
+	registrationAccepted int32 = iota
+	registrationRejected
+	registrationPending
+)
+
+// parseDuplicatePolicy возвращает политику из конфигурации; пустая и неизвестная
+// заменяются на DuplicateReplace
+func parseDuplicatePolicy(value string) DuplicateConnectionPolicy {
+	policy := DuplicateConnectionPolicy(value)
+	switch policy {
+	case DuplicateReplace, DuplicateRejectNew, DuplicatePrompt:
+		return policy
+	}
+	if policy != "" {
+		log.Printf("[ShardedHub] Неизвестная политика повторного соединения %q, используется %s", policy, DuplicateReplace)
+	}
+	return DuplicateReplace
+}
+
+// duplicatePolicy возвращает политику повторного соединения родительского хаба
+func (s *Shard) duplicatePolicy() DuplicateConnectionPolicy {
+	if hub, ok := s.parent.(*ShardedHub); ok && hub.duplicatePolicy != "" {
+		return hub.duplicatePolicy
+	}
+	return DuplicateReplace
+}
+
+// takeoverTimeout возвращает время ожидания ответа на запрос передачи соединения
+func (s *Shard) takeoverTimeout() time.Duration {
+	if hub, ok := s.parent.(*ShardedHub); ok && hub.takeoverTimeout > 0 {
+		return hub.takeoverTimeout
+	}
+	return DefaultTakeoverTimeout
+}
+
+// handleDuplicate применяет политику повторного соединения к новому клиенту,
+// если у пользователя уже есть соединение в шарде. Возвращает true, если клиент
+// не регистрируется сейчас (отклонен или ждет решения).
+func (s *Shard) handleDuplicate(client *Client) bool {
+	existing := s.clientForUser(client.UserID)
+	if existing == nil || existing == client || client.forceReplace {
+		return false
+	}
+
+	switch s.duplicatePolicy() {
+	case DuplicateRejectNew:
+		log.Printf("Shard %d: client %s already connected, rejecting new connection %s", s.id, client.UserID, client.ConnectionID)
+		s.finishRegistration(client, registrationRejected)
+		return true
+	case DuplicatePrompt:
+		s.requestTakeover(existing, client)
+		return true
+	}
+	return false
+}
+
+// requestTakeover спрашивает текущее соединение пользователя, отдать ли место
+// новому. Более раннее ожидающее соединение того же пользователя отклоняется.
+func (s *Shard) requestTakeover(existing, client *Client) {
+	client.takeoverDecision = make(chan bool, 1)
+	if previous, loaded := s.pendingTakeovers.Swap(client.UserID, client); loaded {
+		previous.(*Client).takeoverDecision <- false
+	}
+
+	timeout := s.takeoverTimeout()
+	event, _ := json.Marshal(Event{
+		Type: TakeoverRequestedEvent,
+		Data: map[string]interface{}{
+			"connection_id": client.ConnectionID,
+			"timeout_ms":    timeout.Milliseconds(),
+		},
+	})
+	s.SendToUser(existing.UserID, event)
+
+	log.Printf("Shard %d: client %s opened another connection %s, takeover requested", s.id, client.UserID, client.ConnectionID)
+	s.finishRegistration(client, registrationPending)
+}
+
+// resolveTakeover передает решение текущего соединения ожидающему клиенту
+func (s *Shard) resolveTakeover(userID string, accept bool) error {
+	pending, ok := s.pendingTakeovers.LoadAndDelete(userID)
+	if !ok {
+		return ErrNoPendingTakeover
+	}
+	pending.(*Client).takeoverDecision <- accept
+	return nil
+}
+
+// finishRegistration сохраняет результат регистрации и сообщает о ее завершении
+func (s *Shard) finishRegistration(client *Client, outcome int32) {
+	client.registration.Store(outcome)
+	if client.registrationComplete != nil {
+		select {
+		case client.registrationComplete <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// ResolveTakeover передает ответ текущего соединения пользователя на запрос
+// передачи соединения: accept = true отдает место новому соединению
+func (h *ShardedHub) ResolveTakeover(client *Client, accept bool) error {
+	shard := h.getShard(client.UserID)
+	if shard.clientForUser(client.UserID) != client {
+		return ErrNoPendingTakeover
+	}
+	return shard.resolveTakeover(client.UserID, accept)
+}
+
+// ResolveTakeover передает ответ клиента на запрос передачи соединения
+func (m *Manager) ResolveTakeover(client *Client, accept bool) error {
+	shardedHub, ok := m.hub.(*ShardedHub)
+	if !ok {
+		return ErrNoPendingTakeover
+	}
+	return shardedHub.ResolveTakeover(client, accept)
+}
+
+// pendingMessage - результат чтения из соединения, ожидающего передачи
+type pendingMessage struct {
+	messageType int
+	data        []byte
+	err         error
+}
+
+// awaitTakeover ждет решения текущего соединения пользователя и при согласии
+// или истечении времени регистрирует клиента вместо него. Возвращает false,
+// если клиент отклонен или закрыл соединение, не дождавшись решения.
+func (c *Client) awaitTakeover(hub *ShardedHub) bool {
+	shard := hub.getShard(c.UserID)
+	timeout := shard.takeoverTimeout()
+	c.writeDirect(Event{Type: TakeoverPendingEvent, Data: map[string]interface{}{"timeout_ms": timeout.Milliseconds()}})
+
+	// Читаем соединение, чтобы заметить его закрытие (например, вторую вкладку
+	// закрыли). Прочитанное сообщение достанется readPump после передачи.
+	c.configureRead()
+	c.pendingRead = make(chan pendingMessage, 1)
+	closed := make(chan struct{})
+	go func() {
+		messageType, data, err := c.conn.ReadMessage()
+		c.pendingRead <- pendingMessage{messageType: messageType, data: data, err: err}
+		if err != nil {
+			close(closed)
+		}
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	accept := true
+	select {
+	case accept = <-c.takeoverDecision:
+	case <-timer.C:
+		// Решение могло прийти одновременно с истечением времени
+		if !shard.pendingTakeovers.CompareAndDelete(c.UserID, c) {
+			accept = <-c.takeoverDecision
+		}
+	case <-closed:
+		log.Printf("WebSocket: client %s closed connection %s while waiting for takeover", c.UserID, c.ConnectionID)
+		if !shard.pendingTakeovers.CompareAndDelete(c.UserID, c) {
+			<-c.takeoverDecision
+		}
+		accept = false
+	}
+	if !accept {
+		return false
+	}
+
+	c.forceReplace = true
+	hub.RegisterSync(c, c.registrationComplete)
+	select {
+	case <-c.registrationComplete:
+		return true
+	case <-time.After(5 * time.Second):
+		log.Printf("WebSocket: timeout waiting for client %s takeover registration", c.UserID)
+		return false
+	}
+}
+
+// closeDuplicate закрывает отклоненное повторное соединение и выполняет
+// обработчик отключения (pumps этого соединения не запускались)
+func (c *Client) closeDuplicate() {
+	log.Printf("WebSocket: client %s already connected, closing connection %s", c.UserID, c.ConnectionID)
+	c.closeWithReason(websocket.ClosePolicyViolation, AlreadyConnectedCloseReason)
+	if c.onDisconnect != nil {
+		c.onDisconnect(c)
+	}
+}
+
+// writeDirect отправляет событие в соединение, пока writePump еще не запущен
+func (c *Client) writeDirect(event Event) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	c.conn.SetWriteDeadline(time.Now().Add(c.writeWait))
+	if err := c.conn.WriteMessage(websocket.TextMessage, data); err != nil {
+		log.Printf("WebSocket: failed to write %s to client %s: %v", event.Type, c.UserID, err)
+	}
+}
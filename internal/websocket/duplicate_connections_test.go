@@ -0,0 +1,140 @@
+package websocket
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newDuplicateTestHub(policy DuplicateConnectionPolicy) (*ShardedHub, *Shard) {
+	hub := &ShardedHub{shardCount: 1, duplicatePolicy: policy}
+	shard := NewShard(0, hub, 10, 0, 0) // Без фоновой очистки
+	hub.shards = []*Shard{shard}
+	return hub, shard
+}
+
+func TestShard_DuplicateConnection_Replace(t *testing.T) {
+	hub, shard := newDuplicateTestHub(DuplicateReplace)
+
+	oldClient := NewClient(hub, nil, "7")
+	shard.handleRegister(oldClient)
+	newClient := NewClient(hub, nil, "7")
+	shard.handleRegister(newClient)
+
+	assert.Equal(t, registrationAccepted, newClient.registration.Load())
+	assert.Same(t, newClient, shard.clientForUser("7"))
+}
+
+func TestShard_DuplicateConnection_RejectNew(t *testing.T) {
+	hub, shard := newDuplicateTestHub(DuplicateRejectNew)
+
+	oldClient := NewClient(hub, nil, "7")
+	shard.handleRegister(oldClient)
+	newClient := NewClient(hub, nil, "7")
+	shard.handleRegister(newClient)
+
+	assert.Equal(t, registrationRejected, newClient.registration.Load())
+	assert.Same(t, oldClient, shard.clientForUser("7"), "старое соединение остается текущим")
+	_, stored := shard.clients.Load(newClient)
+	assert.False(t, stored)
+}
+
+// TestShard_DuplicateConnection_Prompt: текущее соединение получает запрос и
+// решает, отдать ли место; отказ оставляет его текущим
+func TestShard_DuplicateConnection_Prompt(t *testing.T) {
+	hub, shard := newDuplicateTestHub(DuplicatePrompt)
+
+	oldClient := NewClient(hub, nil, "7")
+	shard.handleRegister(oldClient)
+	newClient := NewClient(hub, nil, "7")
+	shard.handleRegister(newClient)
+
+	assert.Equal(t, registrationPending, newClient.registration.Load())
+	assert.Same(t, oldClient, shard.clientForUser("7"))
+	require.Len(t, oldClient.send, 1)
+	var request struct {
+		Type string                 `json:"type"`
+		Data map[string]interface{} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(<-oldClient.send, &request))
+	assert.Equal(t, TakeoverRequestedEvent, request.Type)
+	assert.Equal(t, newClient.ConnectionID, request.Data["connection_id"])
+
+	// Отвечать может только текущее соединение
+	assert.ErrorIs(t, hub.ResolveTakeover(newClient, true), ErrNoPendingTakeover)
+
+	require.NoError(t, hub.ResolveTakeover(oldClient, false))
+	assert.False(t, <-newClient.takeoverDecision)
+	assert.ErrorIs(t, hub.ResolveTakeover(oldClient, true), ErrNoPendingTakeover, "решение уже принято")
+}
+
+// TestShard_DuplicateConnection_PromptAccepted: после ухода текущего соединения
+// ожидающее регистрируется вместо него
+func TestShard_DuplicateConnection_PromptAccepted(t *testing.T) {
+	hub, shard := newDuplicateTestHub(DuplicatePrompt)
+
+	oldClient := NewClient(hub, nil, "7")
+	shard.handleRegister(oldClient)
+	newClient := NewClient(hub, nil, "7")
+	shard.handleRegister(newClient)
+	require.Equal(t, registrationPending, newClient.registration.Load())
+
+	shard.handleUnregister(oldClient)
+	assert.True(t, <-newClient.takeoverDecision, "закрытое соединение отдает место")
+
+	newClient.forceReplace = true
+	shard.handleRegister(newClient)
+	assert.Equal(t, registrationAccepted, newClient.registration.Load())
+	assert.Same(t, newClient, shard.clientForUser("7"))
+}
+
+// TestClient_AwaitTakeover_PendingConnectionClosed: ожидающее соединение
+// закрыли до решения - передача отменяется сразу, а не по истечении времени,
+// и текущее соединение не заменяется
+func TestClient_AwaitTakeover_PendingConnectionClosed(t *testing.T) {
+	hub, shard := newDuplicateTestHub(DuplicatePrompt)
+	hub.takeoverTimeout = 5 * time.Second
+
+	oldClient := NewClient(hub, nil, "7")
+	shard.handleRegister(oldClient)
+	go shard.Run()
+	defer shard.Close()
+
+	started := make(chan bool, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+		require.NoError(t, err)
+		started <- NewClient(hub, conn, "7").StartPumps(func([]byte, *Client) error { return nil })
+	}))
+	defer server.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	require.NoError(t, err)
+	var pending Event
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	require.NoError(t, conn.ReadJSON(&pending))
+	assert.Equal(t, TakeoverPendingEvent, pending.Type)
+
+	// Вторую вкладку закрыли, не дождавшись решения
+	closedAt := time.Now()
+	require.NoError(t, conn.Close())
+
+	select {
+	case ok := <-started:
+		assert.False(t, ok, "закрытое соединение не регистрируется")
+		assert.Less(t, time.Since(closedAt), hub.takeoverTimeout)
+	case <-time.After(2 * hub.takeoverTimeout):
+		t.Fatal("ожидание передачи соединения не завершилось")
+	}
+	_, waiting := shard.pendingTakeovers.Load("7")
+	assert.False(t, waiting, "запрос передачи снят")
+	assert.ErrorIs(t, hub.ResolveTakeover(oldClient, true), ErrNoPendingTakeover)
+	assert.Same(t, oldClient, shard.clientForUser("7"), "текущее соединение остается")
+}
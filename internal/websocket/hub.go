@@ -40,6 +40,9 @@ type Hub struct {
 	// Канал для завершения работы фоновых горутин
 	done chan struct{}
 
+	// Хук событий подключения и отключения клиентов
+	connectionHooks *connectionHooks
+
 	// Метрики для мониторинга
 	metrics struct {
 		totalConnections       int64
@@ -76,6 +79,7 @@ func NewHub() *Hub {
 
 	// Инициализация метрик
 	hub.metrics.startTime = time.Now()
+	hub.connectionHooks = newConnectionHooks(hub.done)
 
 	return hub
 }
@@ -110,6 +114,7 @@ func (h *Hub) Run() {
 						oldClient.conn.Close()
 					}
 					close(oldClient.send)
+					h.connectionHooks.notify(false, oldClient, -1)
 
 					// Обновляем метрики
 					h.metrics.mu.Lock()
@@ -125,6 +130,7 @@ func (h *Hub) Run() {
 					h.clients[client] = true
 					h.userMap[client.UserID] = client
 					client.lastActivity = time.Now()
+					h.connectionHooks.notify(true, client, -1)
 
 					// 2. Создаем отложенное закрытие старого соединения
 					oldClientCopy := oldClient // создаем копию, чтобы избежать проблем с гонками данных
@@ -152,6 +158,7 @@ func (h *Hub) Run() {
 								oldClient.conn.Close()
 							}
 							close(oldClient.send)
+							h.connectionHooks.notify(false, oldClient, -1)
 							log.Printf("Hub: delayed close of old client %s completed", oldClient.UserID)
 						}
 						h.mu.Unlock()
@@ -181,6 +188,7 @@ func (h *Hub) Run() {
 			h.userMap[client.UserID] = client
 			client.lastActivity = time.Now()
 			log.Printf("Hub: client %s registered, total clients: %d", client.UserID, len(h.clients))
+			h.connectionHooks.notify(true, client, -1)
 			h.mu.Unlock()
 
 			// Отправляем сигнал о завершении регистрации
@@ -201,6 +209,7 @@ func (h *Hub) Run() {
 				delete(h.clients, client)
 				delete(h.userMap, client.UserID)
 				log.Printf("Hub: client %s unregistered, total clients: %d", client.UserID, len(h.clients))
+				h.connectionHooks.notify(false, client, -1)
 
 				// Обновляем метрики
 				h.metrics.mu.Lock()
@@ -236,14 +245,17 @@ func (h *Hub) Run() {
 				select {
 				case client.send <- message:
 					// Сообщение успешно отправлено (или поставлено в очередь)
+					client.noteQueued()
 				default:
 					// Канал клиента переполнен или закрыт
+					client.noteDropped()
 					log.Printf("Hub: канал клиента %s переполнен, удаляем клиента", client.ConnectionID)
 					// Блокировка для записи нужна только здесь, если мы удаляем клиента
 					h.mu.RUnlock() // Отпускаем RLock перед захватом Lock
 					h.mu.Lock()
 					close(client.send)
 					delete(h.clients, client)
+					h.connectionHooks.notify(false, client, -1)
 					// Удаляем и из userMap
 					if _, ok := h.userMap[client.UserID]; ok {
 						// Проверяем, тот ли это клиент, если UserID используется несколькими соединениями
@@ -375,6 +387,12 @@ func (h *Hub) Close() {
 	log.Printf("Hub: closed")
 }
 
+// SetConnectionHook задает хук событий подключения и отключения клиентов.
+// nil возвращает хук по умолчанию, который ничего не делает.
+func (h *Hub) SetConnectionHook(hook ConnectionHook) {
+	h.connectionHooks.set(hook)
+}
+
 // RegisterSync регистрирует клиента и ожидает завершения регистрации
 func (h *Hub) RegisterSync(client *Client, done chan struct{}) {
 	// Создаем канал для прямого уведомления от хаба
@@ -456,9 +474,11 @@ func (h *Hub) SendToUser(userID string, message []byte) bool {
 		log.Printf("Hub: sending message to user %s: %s", userID, string(message))
 		select {
 		case client.send <- message:
+			client.noteQueued()
 			return true
 		default:
 			log.Printf("Hub: failed to send message to user %s, buffer full", userID)
+			client.noteDropped()
 			h.mu.Lock()
 			delete(h.clients, client)
 			delete(h.userMap, userID)
@@ -485,6 +505,48 @@ func (h *Hub) SendJSONToUser(userID string, v interface{}) error {
 	return nil
 }
 
+// SendJSONToUserExcept отправляет JSON структуру пользователю, если его соединение
+// не совпадает с excludeConnectionID
+func (h *Hub) SendJSONToUserExcept(userID string, v interface{}, excludeConnectionID string) error {
+	if excludeConnectionID != "" {
+		h.mu.RLock()
+		client, exists := h.userMap[userID]
+		h.mu.RUnlock()
+		if exists && client.ConnectionID == excludeConnectionID {
+			return nil
+		}
+	}
+	return h.SendJSONToUser(userID, v)
+}
+
+// DisconnectUser закрывает соединение пользователя с кодом ClosePolicyViolation
+func (h *Hub) DisconnectUser(userID string, reason string) int {
+	h.mu.RLock()
+	client, exists := h.userMap[userID]
+	h.mu.RUnlock()
+	if !exists || client.conn == nil {
+		return 0
+	}
+	log.Printf("Hub: closing connection of user %s, reason: %s", userID, reason)
+	client.closeWithReason(websocket.ClosePolicyViolation, reason)
+	return 1
+}
+
+// UserConnectionCount возвращает число соединений пользователя
+func (h *Hub) UserConnectionCount(userID string) int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if _, exists := h.userMap[userID]; exists {
+		return 1
+	}
+	return 0
+}
+
+// ClusterEnabled всегда false: старый Hub не поддерживает кластерный режим
+func (h *Hub) ClusterEnabled() bool {
+	return false
+}
+
 // ClientCount возвращает количество подключенных клиентов
 func (h *Hub) ClientCount() int {
 	h.mu.RLock()
@@ -0,0 +1,78 @@
+package websocket
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// IdleWarningEvent отправляется клиенту за IdleWarningGrace до отключения по
+// неактивности: любое сообщение или pong в ответ сохраняет соединение
+const IdleWarningEvent = "connection:idle_warning"
+
+// idleWarningGrace возвращает, за сколько до отключения по неактивности
+// предупреждать клиента (0 - без предупреждения)
+func (s *Shard) idleWarningGrace() time.Duration {
+	if hub, ok := s.parent.(*ShardedHub); ok && hub.idleWarningGrace > 0 && hub.idleWarningGrace < s.inactivityTimeout {
+		return hub.idleWarningGrace
+	}
+	return 0
+}
+
+// cleanupTickInterval возвращает период проверки неактивности. С предупреждением
+// проверка идет не реже чем раз в половину IdleWarningGrace: иначе предупреждение
+// и отключение запаздывали бы на интервал очистки (по умолчанию 5 минут).
+func (s *Shard) cleanupTickInterval() time.Duration {
+	interval := s.cleanupInterval
+	if grace := s.idleWarningGrace(); grace > 0 && grace/2 < interval {
+		interval = grace / 2
+	}
+	return interval
+}
+
+// idleWarned сообщает, предупрежден ли клиент после своей последней активности
+func (c *Client) idleWarned() bool {
+	return c.idleWarnedAt.Load() > c.lastActivity.UnixNano()
+}
+
+// checkIdle решает судьбу клиента при проверке неактивности: true - отключить.
+// С предупреждением клиент отключается, только если он бездействует дольше
+// timeout и не ответил за grace после предупреждения.
+func (s *Shard) checkIdle(client *Client, timeout, grace time.Duration) bool {
+	idle := time.Since(client.lastActivity)
+	if grace <= 0 {
+		return idle > timeout
+	}
+
+	if !client.idleWarned() {
+		if idle > timeout-grace {
+			s.sendIdleWarning(client, grace)
+		}
+		return false
+	}
+	warnedAt := time.Unix(0, client.idleWarnedAt.Load())
+	return idle > timeout && time.Since(warnedAt) >= grace
+}
+
+// sendIdleWarning ставит клиенту в очередь предупреждение о скором отключении.
+// Если буфер клиента переполнен, предупреждение повторяется при следующей проверке.
+func (s *Shard) sendIdleWarning(client *Client, grace time.Duration) {
+	data, err := json.Marshal(Event{
+		Type: IdleWarningEvent,
+		Data: map[string]interface{}{
+			"disconnect_in_ms": grace.Milliseconds(),
+		},
+	})
+	if err != nil {
+		return
+	}
+
+	select {
+	case client.send <- data:
+		client.noteQueued()
+		client.idleWarnedAt.Store(time.Now().UnixNano())
+		s.metrics.mu.Lock()
+		s.metrics.messagesSent++
+		s.metrics.mu.Unlock()
+	default:
+	}
+}
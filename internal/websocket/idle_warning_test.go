@@ -0,0 +1,99 @@
+package websocket
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestShard_IdleWarning_ActivitySurvives: клиент получает предупреждение до
+// отключения и, ответив на него, остается подключенным
+func TestShard_IdleWarning_ActivitySurvives(t *testing.T) {
+	hub := &ShardedHub{shardCount: 1, idleWarningGrace: 5 * time.Second}
+	shard := NewShard(0, hub, 10, 0, 30*time.Second) // Без фоновой очистки
+	hub.shards = []*Shard{shard}
+
+	client := NewClient(hub, nil, "7")
+	shard.handleRegister(client)
+	client.lastActivity = time.Now().Add(-26 * time.Second)
+
+	shard.cleanupInactiveClients(shard.inactivityTimeout)
+	assert.Empty(t, shard.unregister, "предупрежденный клиент не отключается")
+	require.Len(t, client.send, 1)
+	var warning struct {
+		Type string                 `json:"type"`
+		Data map[string]interface{} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(<-client.send, &warning))
+	assert.Equal(t, IdleWarningEvent, warning.Type)
+	assert.Equal(t, float64(5000), warning.Data["disconnect_in_ms"])
+
+	// Повторная проверка не дублирует предупреждение
+	shard.cleanupInactiveClients(shard.inactivityTimeout)
+	assert.Empty(t, client.send)
+
+	// Клиент отвечает ping, и к истечению таймаута соединение сохраняется
+	client.lastActivity = time.Now()
+	client.idleWarnedAt.Store(time.Now().Add(-time.Second).UnixNano())
+	shard.cleanupInactiveClients(shard.inactivityTimeout)
+	assert.Empty(t, shard.unregister)
+	assert.Empty(t, client.send)
+	assert.Same(t, client, shard.clientForUser("7"))
+}
+
+// TestShard_IdleWarning_SilentDisconnected: клиент, не ответивший на
+// предупреждение, отключается по истечении таймаута
+func TestShard_IdleWarning_SilentDisconnected(t *testing.T) {
+	hub := &ShardedHub{shardCount: 1, idleWarningGrace: 5 * time.Second}
+	shard := NewShard(0, hub, 10, 0, 30*time.Second)
+	hub.shards = []*Shard{shard}
+
+	client := NewClient(hub, nil, "7")
+	shard.handleRegister(client)
+	client.lastActivity = time.Now().Add(-time.Minute)
+
+	// Без предупреждения клиент не отключается, даже если таймаут уже истек
+	shard.cleanupInactiveClients(shard.inactivityTimeout)
+	assert.Empty(t, shard.unregister)
+	require.Len(t, client.send, 1)
+
+	client.idleWarnedAt.Store(time.Now().Add(-6 * time.Second).UnixNano())
+	shard.cleanupInactiveClients(shard.inactivityTimeout)
+	require.Len(t, shard.unregister, 1)
+	assert.Same(t, client, <-shard.unregister)
+}
+
+// TestShard_IdleWarning_TickerCadence: при интервале очистки намного больше
+// IdleWarningGrace фоновая проверка все равно предупреждает и отключает клиента
+// вовремя
+func TestShard_IdleWarning_TickerCadence(t *testing.T) {
+	hub := &ShardedHub{shardCount: 1, idleWarningGrace: 200 * time.Millisecond}
+	shard := NewShard(0, hub, 10, time.Hour, 600*time.Millisecond)
+	hub.shards = []*Shard{shard}
+	defer close(shard.done)
+
+	connectedAt := time.Now()
+	client := NewClient(hub, nil, "7")
+	shard.handleRegister(client)
+
+	select {
+	case raw := <-client.send:
+		var warning Event
+		require.NoError(t, json.Unmarshal(raw, &warning))
+		assert.Equal(t, IdleWarningEvent, warning.Type)
+		assert.Less(t, time.Since(connectedAt), 600*time.Millisecond, "предупреждение приходит до таймаута")
+	case <-time.After(time.Second):
+		t.Fatal("предупреждение не отправлено")
+	}
+
+	select {
+	case removed := <-shard.unregister:
+		assert.Same(t, client, removed)
+		assert.Less(t, time.Since(connectedAt), 600*time.Millisecond+2*200*time.Millisecond)
+	case <-time.After(2 * time.Second):
+		t.Fatal("клиент не отключен")
+	}
+}
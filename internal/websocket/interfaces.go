@@ -40,6 +40,35 @@ type HubInterface interface {
 	// UnregisterClient(client *Client) // Пример
 }
 
+// ExcludingSender - хаб, который умеет отправить событие пользователю, пропустив
+// одно из соединений (например, вкладку, инициировавшую действие).
+// Реализуется ShardedHub и Hub; вызывающий код проверяет его приведением типа.
+type ExcludingSender interface {
+	// SendJSONToUserExcept работает как SendJSONToUser, но не доставляет событие
+	// соединению excludeConnectionID. Пустой excludeConnectionID - обычная отправка.
+	SendJSONToUserExcept(userID string, v interface{}, excludeConnectionID string) error
+}
+
+// UserDisconnector - хаб, который умеет принудительно закрыть соединение пользователя
+// (например, после отзыва его сессий администратором).
+// Реализуется ShardedHub и Hub; вызывающий код проверяет его приведением типа.
+type UserDisconnector interface {
+	// DisconnectUser закрывает соединение пользователя с кодом ClosePolicyViolation
+	// и причиной reason. ShardedHub в кластерном режиме передает команду остальным
+	// экземплярам. Возвращает число соединений, закрытых на этом экземпляре.
+	DisconnectUser(userID string, reason string) int
+}
+
+// DeliveryInspector - хаб, который сообщает, где обслуживается соединение пользователя.
+// Используется для диагностики доставки; реализуется ShardedHub и Hub.
+type DeliveryInspector interface {
+	// UserConnectionCount возвращает число соединений пользователя на этом экземпляре
+	UserConnectionCount(userID string) int
+	// ClusterEnabled сообщает, пересылаются ли сообщения пользователям, не подключенным
+	// к этому экземпляру, остальным экземплярам
+	ClusterEnabled() bool
+}
+
 // HttpHandlerProvider определяет метод для предоставления HTTP обработчиков.
 type HttpHandlerProvider interface {
 	GetHttpHandlers() map[string]http.HandlerFunc
@@ -0,0 +1,143 @@
+package websocket
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// IPConnectionCounter считает открытые WebSocket-соединения по IP-адресу источника
+type IPConnectionCounter interface {
+	// Acquire учитывает новое соединение с ip, если их меньше limit, и возвращает
+	// число соединений с ip. false - лимит достигнут, соединение не учтено.
+	Acquire(ip string, limit int) (int64, bool, error)
+	// Release снимает учет закрытого соединения
+	Release(ip string) error
+	// Counts возвращает число открытых соединений по IP-адресам
+	Counts() (map[string]int64, error)
+}
+
+// LocalIPConnectionCounter считает соединения одного экземпляра сервера
+type LocalIPConnectionCounter struct {
+	mu     sync.Mutex
+	counts map[string]int64
+}
+
+// NewLocalIPConnectionCounter создает счетчик соединений в памяти
+func NewLocalIPConnectionCounter() *LocalIPConnectionCounter {
+	return &LocalIPConnectionCounter{counts: make(map[string]int64)}
+}
+
+// Acquire учитывает новое соединение с ip, если их меньше limit
+func (c *LocalIPConnectionCounter) Acquire(ip string, limit int) (int64, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.counts[ip] >= int64(limit) {
+		return c.counts[ip], false, nil
+	}
+	c.counts[ip]++
+	return c.counts[ip], true, nil
+}
+
+// Release снимает учет закрытого соединения
+func (c *LocalIPConnectionCounter) Release(ip string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.counts[ip] <= 1 {
+		delete(c.counts, ip)
+		return nil
+	}
+	c.counts[ip]--
+	return nil
+}
+
+// Counts возвращает число открытых соединений по IP-адресам
+func (c *LocalIPConnectionCounter) Counts() (map[string]int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	counts := make(map[string]int64, len(c.counts))
+	for ip, n := range c.counts {
+		counts[ip] = n
+	}
+	return counts, nil
+}
+
+// Ключи счетчиков соединений в Redis. Срок ключа продлевается при каждом
+// изменении, чтобы счетчики упавшего экземпляра не блокировали IP навсегда.
+const (
+	ipConnectionKeyPrefix = "ws:ip_connections:"
+	ipConnectionKeyTTL    = 6 * time.Hour
+)
+
+// acquireIPConnectionScript увеличивает счетчик и сразу откатывает увеличение
+// сверх лимита; возвращает число соединений после увеличения
+var acquireIPConnectionScript = redis.NewScript(`
+local n = redis.call('INCR', KEYS[1])
+redis.call('EXPIRE', KEYS[1], ARGV[2])
+if n > tonumber(ARGV[1]) then
+	redis.call('DECR', KEYS[1])
+end
+return n
+`)
+
+// releaseIPConnectionScript уменьшает счетчик и удаляет его на нуле
+var releaseIPConnectionScript = redis.NewScript(`
+local n = redis.call('DECR', KEYS[1])
+if n <= 0 then
+	redis.call('DEL', KEYS[1])
+else
+	redis.call('EXPIRE', KEYS[1], ARGV[1])
+end
+return n
+`)
+
+// RedisIPConnectionCounter считает соединения всех экземпляров кластера в Redis
+type RedisIPConnectionCounter struct {
+	client redis.UniversalClient
+	ctx    context.Context
+}
+
+// NewRedisIPConnectionCounter создает общий для кластера счетчик соединений
+func NewRedisIPConnectionCounter(client redis.UniversalClient) *RedisIPConnectionCounter {
+	return &RedisIPConnectionCounter{client: client, ctx: context.Background()}
+}
+
+// Acquire учитывает новое соединение с ip, если их меньше limit
+func (c *RedisIPConnectionCounter) Acquire(ip string, limit int) (int64, bool, error) {
+	n, err := acquireIPConnectionScript.Run(c.ctx, c.client, []string{ipConnectionKeyPrefix + ip},
+		limit, int(ipConnectionKeyTTL.Seconds())).Int64()
+	if err != nil {
+		return 0, false, err
+	}
+	if n > int64(limit) {
+		return n - 1, false, nil
+	}
+	return n, true, nil
+}
+
+// Release снимает учет закрытого соединения
+func (c *RedisIPConnectionCounter) Release(ip string) error {
+	return releaseIPConnectionScript.Run(c.ctx, c.client, []string{ipConnectionKeyPrefix + ip},
+		int(ipConnectionKeyTTL.Seconds())).Err()
+}
+
+// Counts возвращает число открытых соединений по IP-адресам во всем кластере
+func (c *RedisIPConnectionCounter) Counts() (map[string]int64, error) {
+	counts := make(map[string]int64)
+	iter := c.client.Scan(c.ctx, 0, ipConnectionKeyPrefix+"*", 1000).Iterator()
+	for iter.Next(c.ctx) {
+		key := iter.Val()
+		n, err := c.client.Get(c.ctx, key).Int64()
+		if err == redis.Nil {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		counts[strings.TrimPrefix(key, ipConnectionKeyPrefix)] = n
+	}
+	return counts, iter.Err()
+}
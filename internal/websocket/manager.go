@@ -5,12 +5,19 @@ import (
 	"fmt"
 	"log"
 	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/yourusername/trivia-api/internal/i18n"
 )
 
 // Event представляет структуру WebSocket-сообщения
 type Event struct {
 	Type string      `json:"type"`
 	Data interface{} `json:"data"`
+	// Идентификатор подтверждения критического события (см. IsCriticalEvent);
+	// в сообщении ack от клиента - подтверждаемое событие
+	AckID string `json:"ack_id,omitempty"`
 }
 
 // Manager обрабатывает WebSocket сообщения
@@ -20,6 +27,21 @@ type Manager struct {
 
 	// WaitGroup для ожидания завершения обработки
 	wg sync.WaitGroup
+
+	// Кодировать частые события викторины также в бинарный формат
+	binaryProtocol bool
+
+	// Настройки уведомлений пользователей (nil - доставлять все события)
+	notificationFilter NotificationFilter
+
+	// Подтверждения критических событий (nil - выключены)
+	acks *ackTracker
+}
+
+// NotificationFilter решает, доставлять ли пользователю событие, от которого
+// можно отказаться (см. IsSuppressibleEvent)
+type NotificationFilter interface {
+	AllowsNotification(userID string, eventType string) bool
 }
 
 // NewManager создает новый менеджер WebSocket
@@ -31,26 +53,121 @@ func NewManager(hub HubInterface) *Manager {
 	return m
 }
 
+// SetNotificationFilter включает проверку настроек уведомлений при адресной отправке
+func (m *Manager) SetNotificationFilter(filter NotificationFilter) {
+	m.notificationFilter = filter
+}
+
+// SetConnectionHook регистрирует хук подключений в хабе, если хаб их поддерживает
+func (m *Manager) SetConnectionHook(hook ConnectionHook) {
+	if hub, ok := m.hub.(interface{ SetConnectionHook(ConnectionHook) }); ok {
+		hub.SetConnectionHook(hook)
+		return
+	}
+	log.Printf("[WebSocketManager] WARNING: Хаб %T не поддерживает хуки подключений", m.hub)
+}
+
+// notificationAllowed проверяет, хочет ли пользователь получать событие eventType
+func (m *Manager) notificationAllowed(userID string, eventType string) bool {
+	if m.notificationFilter == nil || !IsSuppressibleEvent(eventType) {
+		return true
+	}
+	if m.notificationFilter.AllowsNotification(userID, eventType) {
+		return true
+	}
+	log.Printf("[WebSocketManager] Событие %s не отправлено пользователю ID=%s: отключено в настройках уведомлений", eventType, userID)
+	return false
+}
+
 // RegisterHandler регистрирует обработчик для определенного типа сообщений
 func (m *Manager) RegisterHandler(eventType string, handler func(data json.RawMessage, client *Client) error) {
 	m.messageHandler[eventType] = handler
 	log.Printf("[WebSocketManager] Зарегистрирован обработчик для сообщений типа: %s", eventType)
 }
 
+// EnableBinaryProtocol включает поддержку бинарного формата (BinaryProtocolName):
+// рассылки quiz:question и quiz:timer дополнительно кодируются в бинарные кадры,
+// а бинарные ответы клиентов принимаются наравне с JSON.
+// Вызывается при инициализации, до подключения клиентов.
+func (m *Manager) EnableBinaryProtocol() {
+	m.binaryProtocol = true
+	log.Printf("[WebSocketManager] Включен бинарный протокол %s", BinaryProtocolName)
+}
+
+// EnableAcks включает подтверждение критических событий: они отправляются с
+// ack_id, а неподтвержденные повторяются при переподключении пользователя не
+// более maxRedeliveries раз (0 - по умолчанию). Вызывается при инициализации.
+func (m *Manager) EnableAcks(maxRedeliveries int) {
+	m.acks = newAckTracker(maxRedeliveries)
+	log.Printf("[WebSocketManager] Включены подтверждения критических событий, повторов не более %d", m.acks.maxRedeliveries)
+}
+
+// trackCritical присваивает критическому событию ack_id и запоминает его для
+// пользователей userIDs до подтверждения. Остальные события не меняются.
+func (m *Manager) trackCritical(event Event, userIDs ...string) Event {
+	if m.acks == nil || !IsCriticalEvent(event.Type) {
+		return event
+	}
+	event.AckID = uuid.New().String()
+	now := time.Now()
+	for _, userID := range userIDs {
+		m.acks.track(userID, event.AckID, event.Type, event, now)
+	}
+	return event
+}
+
+// handleAck подтверждает получение критического события клиентом
+func (m *Manager) handleAck(client *Client, ackID string) {
+	if m.acks == nil || ackID == "" {
+		return
+	}
+	if !m.acks.confirm(client.UserID, ackID, time.Now()) {
+		log.Printf("[WebSocketManager] Неизвестный ack_id %s от пользователя %s", ackID, client.UserID)
+	}
+}
+
+// RedeliverUnacked повторно отправляет пользователю неподтвержденные критические
+// события. Вызывается после регистрации нового соединения. Возвращает число
+// отправленных событий.
+func (m *Manager) RedeliverUnacked(userID string) int {
+	if m.acks == nil {
+		return 0
+	}
+	events := m.acks.takeRedeliveries(userID, time.Now())
+	for _, event := range events {
+		if err := m.hub.SendJSONToUser(userID, event); err != nil {
+			log.Printf("[WebSocketManager] Ошибка повторной отправки критического события пользователю %s: %v", userID, err)
+		}
+	}
+	if len(events) > 0 {
+		log.Printf("[WebSocketManager] Пользователю %s повторно отправлено неподтвержденных событий: %d", userID, len(events))
+	}
+	return len(events)
+}
+
 // HandleMessage обрабатывает входящее сообщение от клиента.
 // Возвращает error, если обработка не удалась и соединение нужно закрыть.
 func (m *Manager) HandleMessage(message []byte, client *Client) error {
+	if isBinaryFrame(message) {
+		return m.handleBinaryMessage(message, client)
+	}
+
 	var event Event
 	if err := json.Unmarshal(message, &event); err != nil {
 		log.Printf("Failed to unmarshal message from %s: %v, Message: %s", client.UserID, err, string(message))
-		m.SendErrorToClient(client, "invalid_message_format", "Invalid JSON format")
+		m.SendLocalizedError(client, "invalid_message_format", i18n.WSInvalidJSON)
 		return err // Ошибка парсинга - закрываем соединение
 	}
 
+	if event.Type == AckMessageType {
+		m.handleAck(client, event.AckID)
+		return nil
+	}
+
 	handler, ok := m.messageHandler[event.Type]
 	if !ok {
 		log.Printf("No handler registered for message type '%s' from client %s", event.Type, client.UserID)
-		m.SendErrorToClient(client, "unknown_message_type", fmt.Sprintf("Unknown message type: %s", event.Type))
+		m.SendLocalizedError(client, "unknown_message_type", i18n.WSUnknownMessageType, event.Type)
 		return nil // Неизвестный тип - не закрываем соединение
 	}
 
@@ -65,6 +182,36 @@ func (m *Manager) HandleMessage(message []byte, client *Client) error {
 	return nil // Обработка успешна или ошибка не фатальна
 }
 
+// handleBinaryMessage декодирует бинарный кадр и передает его обработчику
+// соответствующего JSON-события
+func (m *Manager) handleBinaryMessage(message []byte, client *Client) error {
+	if !m.binaryProtocol || !client.UsesBinaryProtocol() {
+		m.SendLocalizedError(client, "invalid_message_format", i18n.WSBinaryNotNegotiated)
+		return fmt.Errorf("binary frame from client %s without negotiated protocol", client.UserID)
+	}
+
+	event, err := decodeBinaryInbound(message)
+	if err != nil {
+		log.Printf("[WebSocketManager] Некорректный бинарный кадр от %s: %v", client.UserID, err)
+		m.SendLocalizedError(client, "invalid_message_format", i18n.WSInvalidBinaryFrame)
+		return err
+	}
+
+	handler, ok := m.messageHandler[event.Type]
+	if !ok {
+		m.SendLocalizedError(client, "unknown_message_type", i18n.WSUnknownMessageType, event.Type)
+		return nil
+	}
+
+	// Обработчики принимают JSON, поэтому данные кадра передаются в том же виде, что и из JSON-сообщения
+	rawMessage, _ := json.Marshal(event.Data)
+	if err := handler(rawMessage, client); err != nil {
+		log.Printf("Handler for type '%s' returned error for client %s: %v", event.Type, client.UserID, err)
+		return err
+	}
+	return nil
+}
+
 // SendErrorToClient отправляет стандартизированное сообщение об ошибке клиенту.
 // Этот метод НЕ закрывает соединение.
 func (m *Manager) SendErrorToClient(client *Client, code string, message string) {
@@ -80,6 +227,12 @@ func (m *Manager) SendErrorToClient(client *Client, code string, message string)
 	}
 }
 
+// SendLocalizedError отправляет клиенту server:error с сообщением key из
+// каталога i18n на языке клиента. Соединение не закрывается.
+func (m *Manager) SendLocalizedError(client *Client, code string, key i18n.Key, args ...interface{}) {
+	m.SendErrorToClient(client, code, i18n.T(client.Lang(), key, args...))
+}
+
 // BroadcastEvent отправляет событие всем клиентам
 func (m *Manager) BroadcastEvent(eventType string, data interface{}) error {
 	event := Event{
@@ -92,16 +245,24 @@ func (m *Manager) BroadcastEvent(eventType string, data interface{}) error {
 
 // SendEventToUser отправляет событие конкретному пользователю
 func (m *Manager) SendEventToUser(userID string, eventType string, data interface{}) error {
-	event := Event{
+	if !m.notificationAllowed(userID, eventType) {
+		return nil
+	}
+
+	event := m.trackCritical(Event{
 		Type: eventType,
 		Data: data,
-	}
+	}, userID)
 
 	return m.hub.SendJSONToUser(userID, event)
 }
 
 // SendTokenExpirationWarning отправляет пользователю предупреждение о скором истечении срока действия токена
 func (m *Manager) SendTokenExpirationWarning(userID string, expiresIn int) {
+	if !m.notificationAllowed(userID, TOKEN_EXPIRE_SOON) {
+		return
+	}
+
 	// Создаем сообщение
 	message := map[string]interface{}{
 		"type": TOKEN_EXPIRE_SOON,
@@ -126,6 +287,41 @@ func (m *Manager) SendTokenExpirationWarning(userID string, expiresIn int) {
 	}
 }
 
+// SendRefreshTokenExpirationWarning предупреждает пользователя, что refresh-токен
+// скоро истечет и после этого потребуется повторный вход. sessionLimited - срок
+// определяется абсолютным ограничением сессии, а не отсутствием активности.
+func (m *Manager) SendRefreshTokenExpirationWarning(userID string, expiresAt time.Time, sessionLimited bool) {
+	if !m.notificationAllowed(userID, REFRESH_TOKEN_EXPIRE_SOON) {
+		return
+	}
+
+	reason := "inactivity"
+	if sessionLimited {
+		reason = "session_limit"
+	}
+	message := map[string]interface{}{
+		"type": REFRESH_TOKEN_EXPIRE_SOON,
+		"data": map[string]interface{}{
+			"expires_at": expiresAt.UTC().Format(time.RFC3339),
+			"expires_in": int(time.Until(expiresAt).Seconds()),
+			"unit":       "seconds",
+			"reason":     reason,
+		},
+	}
+
+	jsonMessage, err := json.Marshal(message)
+	if err != nil {
+		log.Printf("[WebSocketManager] Ошибка при сериализации предупреждения о refresh-токене: %v", err)
+		return
+	}
+
+	if m.hub.SendToUser(userID, jsonMessage) {
+		log.Printf("[WebSocketManager] Отправлено предупреждение об истечении refresh-токена пользователю ID=%s", userID)
+	} else {
+		log.Printf("[WebSocketManager] Не удалось отправить предупреждение об истечении refresh-токена пользователю ID=%s", userID)
+	}
+}
+
 // SendTokenExpiredNotification отправляет пользователю уведомление о истечении срока действия токена
 func (m *Manager) SendTokenExpiredNotification(userID string) {
 	// Создаем сообщение
@@ -151,6 +347,60 @@ func (m *Manager) SendTokenExpiredNotification(userID string) {
 	}
 }
 
+// ConnectionDiagnostics описывает состояние одного соединения для поддержки игроков.
+// Поля с omitempty заполняются только в подробном режиме (для администраторов):
+// они раскрывают внутреннее устройство сервера.
+type ConnectionDiagnostics struct {
+	ConnectionID  string   `json:"connection_id"`
+	ServerTime    int64    `json:"server_time"` // Unix, мс
+	RTTMs         float64  `json:"rtt_ms"`      // 0, если ping/pong еще не было
+	Protocol      string   `json:"protocol"`
+	Subscriptions []string `json:"subscriptions"`
+	QuizID        uint     `json:"quiz_id"`
+
+	ShardID        *int     `json:"shard_id,omitempty"`
+	Roles          []string `json:"roles,omitempty"`
+	SendBufferUsed *int     `json:"send_buffer_used,omitempty"`
+	SendBufferSize *int     `json:"send_buffer_size,omitempty"`
+	// Максимальная занятость буфера за время соединения
+	SendBufferHighWater *int `json:"send_buffer_high_water,omitempty"`
+}
+
+// ConnectionDiagnostics собирает диагностику соединения клиента.
+// При detailed=false возвращаются только сведения о самом соединении.
+func (m *Manager) ConnectionDiagnostics(client *Client, detailed bool) ConnectionDiagnostics {
+	protocol := "json"
+	if client.UsesBinaryProtocol() {
+		protocol = BinaryProtocolName
+	}
+	subscriptions := client.GetSubscriptions()
+	if subscriptions == nil {
+		subscriptions = []string{}
+	}
+
+	diag := ConnectionDiagnostics{
+		ConnectionID:  client.ConnectionID,
+		ServerTime:    time.Now().UnixMilli(),
+		RTTMs:         float64(client.RTT().Microseconds()) / 1000,
+		Protocol:      protocol,
+		Subscriptions: subscriptions,
+		QuizID:        client.GetQuizID(),
+	}
+	if !detailed {
+		return diag
+	}
+
+	if shardedHub, ok := m.hub.(*ShardedHub); ok {
+		shardID := shardedHub.ShardIDForUser(client.UserID)
+		diag.ShardID = &shardID
+	}
+	used, size := client.SendBufferUsage()
+	highWater := client.SendBufferHighWater()
+	diag.SendBufferUsed, diag.SendBufferSize, diag.SendBufferHighWater = &used, &size, &highWater
+	diag.Roles = client.GetRoles()
+	return diag
+}
+
 // GetMetrics возвращает текущие метрики WebSocket-системы
 func (m *Manager) GetMetrics() map[string]interface{} {
 	return map[string]interface{}{
@@ -158,8 +408,28 @@ func (m *Manager) GetMetrics() map[string]interface{} {
 	}
 }
 
+// AckStats возвращает метрики подтверждений критических событий
+// (nil, если подтверждения выключены)
+func (m *Manager) AckStats() map[string]interface{} {
+	if m.acks == nil {
+		return nil
+	}
+	return m.acks.metrics()
+}
+
+// SendAlert передает алерт в хаб, если он поддерживает систему алертов.
+// Для остальных хабов алерт просто логируется.
+func (m *Manager) SendAlert(alertType AlertType, severity AlertSeverity, message string, metadata map[string]interface{}) {
+	if shardedHub, ok := m.hub.(*ShardedHub); ok {
+		shardedHub.SendAlert(alertType, severity, message, metadata)
+		return
+	}
+	log.Printf("[WebSocketManager] ALERT [%s] %s: %s %v", severity, alertType, message, metadata)
+}
+
 // BroadcastEventToQuiz отправляет событие всем клиентам, подключенным к указанной викторине
 func (m *Manager) BroadcastEventToQuiz(quizID uint, event interface{}) error {
+	event = m.trackCriticalBroadcast(quizID, event)
 	jsonBytes, err := json.Marshal(event)
 	if err != nil {
 		return fmt.Errorf("failed to marshal event for quiz %d: %w", quizID, err)
@@ -167,8 +437,17 @@ func (m *Manager) BroadcastEventToQuiz(quizID uint, event interface{}) error {
 
 	// Проверяем, является ли хаб шардированным
 	if shardedHub, ok := m.hub.(*ShardedHub); ok {
+		// Частые события кодируются в бинарный кадр один раз на рассылку.
+		// Если кодирование не удалось, все клиенты получают JSON.
+		var binaryBytes []byte
+		if m.binaryProtocol && hasBinaryFormat(event) {
+			if binaryBytes, err = encodeBinaryEvent(jsonBytes); err != nil {
+				log.Printf("[WebSocketManager] Не удалось закодировать событие викторины %d в бинарный формат: %v", quizID, err)
+				binaryBytes = nil
+			}
+		}
 		// Если да, используем его метод для отправки в конкретный квиз
-		shardedHub.BroadcastToQuiz(quizID, jsonBytes)
+		shardedHub.BroadcastToQuizEncoded(quizID, jsonBytes, binaryBytes)
 		return nil
 	} else {
 		// Если это не ShardedHub, то специфичная для квиза рассылка не поддерживается.
@@ -178,6 +457,40 @@ func (m *Manager) BroadcastEventToQuiz(quizID uint, event interface{}) error {
 	}
 }
 
+// trackCriticalBroadcast присваивает критическому событию викторины ack_id и
+// ждет подтверждения от подписчиков викторины на этом экземпляре. Событие
+// хранится для повтора целиком, со всеми полями исходного payload.
+//
+// Подтверждения не передаются между экземплярами: подписчики других экземпляров
+// получают событие через кластер без ожидания подтверждения, а ack, пришедший
+// на другой экземпляр (после переподключения), считается там неизвестным.
+func (m *Manager) trackCriticalBroadcast(quizID uint, event interface{}) interface{} {
+	if m.acks == nil {
+		return event
+	}
+	fullEvent, ok := event.(map[string]interface{})
+	if !ok {
+		return event
+	}
+	eventType, _ := fullEvent["type"].(string)
+	lister, ok := m.hub.(interface{ QuizSubscriberIDs(quizID uint) []string })
+	if !IsCriticalEvent(eventType) || !ok {
+		return event
+	}
+
+	tracked := make(map[string]interface{}, len(fullEvent)+1)
+	for key, value := range fullEvent {
+		tracked[key] = value
+	}
+	ackID := uuid.New().String()
+	tracked["ack_id"] = ackID
+	now := time.Now()
+	for _, userID := range lister.QuizSubscriberIDs(quizID) {
+		m.acks.track(userID, ackID, eventType, tracked, now)
+	}
+	return tracked
+}
+
 // SubscribeClientToTypes подписывает клиента на указанные типы сообщений
 func (m *Manager) SubscribeClientToTypes(client *Client, messageTypes []string) {
 	for _, msgType := range messageTypes {
@@ -239,6 +552,30 @@ func (m *Manager) UnsubscribeClientFromQuiz(client *Client) error {
 	return nil
 }
 
+// RemoveUserFromQuiz отписывает соединение пользователя от событий викторины
+// (например, после исключения модератором). В кластерном режиме отписка
+// передается остальным экземплярам. Возвращает true, если соединение было
+// отписано на этом экземпляре.
+func (m *Manager) RemoveUserFromQuiz(userID string, quizID uint) bool {
+	shardedHub, ok := m.hub.(*ShardedHub)
+	if !ok {
+		log.Printf("[WebSocketManager] RemoveUserFromQuiz не поддерживается хабом типа %T", m.hub)
+		return false
+	}
+	return shardedHub.UnsubscribeUserFromQuiz(userID, quizID)
+}
+
+// HasNewerConnection сообщает, что пользователь соединения client уже
+// переподключился к этому экземпляру: закрытие старого соединения не означает
+// ухода игрока. Для несегментированного хаба всегда false.
+func (m *Manager) HasNewerConnection(client *Client) bool {
+	shardedHub, ok := m.hub.(*ShardedHub)
+	if !ok {
+		return false
+	}
+	return shardedHub.HasNewerConnection(client)
+}
+
 // BroadcastEventToSubscribers отправляет событие только подписанным клиентам
 func (m *Manager) BroadcastEventToSubscribers(eventType string, data interface{}) error {
 	event := Event{
@@ -0,0 +1,161 @@
+package websocket
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManager_ConnectionDiagnosticsHidesInternalsFromPlayers(t *testing.T) {
+	manager := NewManager(&stubHub{})
+	client := NewClient(nil, nil, "1")
+	client.AddRole("player")
+	client.SetQuizID(7)
+	client.Subscribe(QUIZ_START)
+	client.SetBinaryProtocol(true)
+
+	diag := manager.ConnectionDiagnostics(client, false)
+	assert.Equal(t, client.ConnectionID, diag.ConnectionID)
+	assert.Equal(t, BinaryProtocolName, diag.Protocol)
+	assert.Equal(t, uint(7), diag.QuizID)
+	assert.Equal(t, []string{QUIZ_START}, diag.Subscriptions)
+	assert.NotZero(t, diag.ServerTime)
+
+	raw, err := json.Marshal(diag)
+	require.NoError(t, err)
+	for _, field := range []string{"shard_id", "roles", "send_buffer_used", "send_buffer_size", "send_buffer_high_water"} {
+		assert.NotContains(t, string(raw), field)
+	}
+}
+
+func TestManager_ConnectionDiagnosticsDetailed(t *testing.T) {
+	manager := NewManager(&stubHub{})
+	client := NewClient(nil, nil, "1")
+	client.AddRole("player")
+	client.AddRole("admin")
+	client.send <- []byte("{}")
+
+	diag := manager.ConnectionDiagnostics(client, true)
+	assert.Equal(t, "json", diag.Protocol)
+	assert.Equal(t, []string{"admin", "player"}, diag.Roles)
+	require.NotNil(t, diag.SendBufferUsed)
+	require.NotNil(t, diag.SendBufferSize)
+	assert.Equal(t, 1, *diag.SendBufferUsed)
+	assert.Equal(t, cap(client.send), *diag.SendBufferSize)
+	require.NotNil(t, diag.SendBufferHighWater)
+	assert.Nil(t, diag.ShardID, "shard_id is reported only for ShardedHub")
+}
+
+// muteFilter отключает все отключаемые события и запоминает проверки
+type muteFilter struct {
+	checked []string
+}
+
+func (f *muteFilter) AllowsNotification(userID string, eventType string) bool {
+	f.checked = append(f.checked, eventType)
+	return false
+}
+
+func TestManager_NotificationFilter(t *testing.T) {
+	hub := &stubHub{}
+	manager := NewManager(hub)
+	filter := &muteFilter{}
+	manager.SetNotificationFilter(filter)
+
+	require.NoError(t, manager.SendEventToUser("1", "system:announcement", map[string]string{}))
+	require.NoError(t, manager.SendEventToUser("1", TOKEN_EXPIRE_SOON, map[string]string{}))
+	manager.SendTokenExpirationWarning("1", 60)
+	manager.SendRefreshTokenExpirationWarning("1", time.Now().Add(time.Hour), false)
+	assert.Empty(t, hub.sent)
+	assert.Empty(t, hub.raw)
+
+	// События викторины и TOKEN_EXPIRED не отключаются и фильтр для них не вызывается
+	require.NoError(t, manager.SendEventToUser("1", "quiz:answer_result", map[string]string{}))
+	require.NoError(t, manager.SendEventToUser("1", TOKEN_EXPIRED, map[string]string{}))
+	manager.SendTokenExpiredNotification("1")
+	require.Len(t, hub.sent, 2)
+	assert.Len(t, hub.raw, 1)
+	assert.Equal(t, []string{"system:announcement", TOKEN_EXPIRE_SOON, TOKEN_EXPIRE_SOON, REFRESH_TOKEN_EXPIRE_SOON}, filter.checked)
+}
+
+func TestManager_AcksRedeliverUnconfirmedCriticalEvents(t *testing.T) {
+	hub := &stubHub{}
+	manager := NewManager(hub)
+	manager.EnableAcks(2)
+
+	require.NoError(t, manager.SendEventToUser("1", "quiz:answer_result", map[string]string{}))
+	require.NoError(t, manager.SendEventToUser("1", "quiz:elimination", map[string]string{"reason": "incorrect_answer"}))
+	require.Len(t, hub.sent, 2)
+	assert.Empty(t, hub.sent[0].AckID, "некритические события не требуют подтверждения")
+	ackID := hub.sent[1].AckID
+	require.NotEmpty(t, ackID)
+
+	// Неподтвержденное событие повторяется при переподключениях, но не больше лимита
+	assert.Equal(t, 1, manager.RedeliverUnacked("1"))
+	assert.Equal(t, ackID, hub.sent[2].AckID)
+	assert.Equal(t, 1, manager.RedeliverUnacked("1"))
+	assert.Equal(t, 0, manager.RedeliverUnacked("1"))
+	assert.Equal(t, 0, manager.RedeliverUnacked("2"))
+
+	metrics := manager.AckStats()
+	assert.Equal(t, int64(2), metrics["redelivered"])
+	assert.Equal(t, int64(1), metrics["dropped"])
+	assert.Equal(t, 0, metrics["unacked"])
+}
+
+func TestManager_AckConfirmsDelivery(t *testing.T) {
+	hub := &stubHub{}
+	manager := NewManager(hub)
+	manager.EnableAcks(0)
+
+	require.NoError(t, manager.SendEventToUser("1", "quiz:kicked", map[string]string{}))
+	ackID := hub.sent[0].AckID
+	ack := []byte(`{"type":"ack","ack_id":"` + ackID + `"}`)
+
+	// Подтверждение чужого события не засчитывается
+	require.NoError(t, manager.HandleMessage(ack, NewClient(nil, nil, "2")))
+	require.NoError(t, manager.HandleMessage(ack, NewClient(nil, nil, "1")))
+	assert.Equal(t, 0, manager.RedeliverUnacked("1"))
+
+	metrics := manager.AckStats()
+	assert.Equal(t, int64(1), metrics["confirmed"])
+	assert.Equal(t, int64(1), metrics["unknown_acks"])
+	assert.Equal(t, 0, metrics["unacked"])
+}
+
+// quizAckHub - хаб с подписчиками викторины, запоминающий отправленные события
+type quizAckHub struct {
+	stubHub
+	subscribers []string
+	events      []interface{}
+}
+
+func (h *quizAckHub) SendJSONToUser(userID string, v interface{}) error {
+	h.events = append(h.events, v)
+	return nil
+}
+
+func (h *quizAckHub) QuizSubscriberIDs(quizID uint) []string { return h.subscribers }
+
+func TestManager_AcksRedeliverFullQuizBroadcast(t *testing.T) {
+	hub := &quizAckHub{subscribers: []string{"1"}}
+	manager := NewManager(hub)
+	manager.EnableAcks(0)
+
+	require.NoError(t, manager.BroadcastEventToQuiz(7, map[string]interface{}{
+		"type":      "quiz:finish",
+		"data":      map[string]interface{}{"quiz_id": 7},
+		"timestamp": int64(1760600000123),
+	}))
+
+	// Повтор содержит все поля исходного события, а не только type и data
+	require.Equal(t, 1, manager.RedeliverUnacked("1"))
+	event, ok := hub.events[0].(map[string]interface{})
+	require.True(t, ok)
+	assert.Equal(t, "quiz:finish", event["type"])
+	assert.Equal(t, int64(1760600000123), event["timestamp"])
+	assert.NotEmpty(t, event["ack_id"])
+}
@@ -1,5 +1,7 @@
 package websocket
 
+import "strings"
+
 // Типы сообщений для викторины
 const (
 	// QUIZ_START сообщает о начале викторины
@@ -28,4 +30,49 @@ const (
 
 	// TOKEN_EXPIRED уведомляет об истечении срока действия токена
 	TOKEN_EXPIRED = "TOKEN_EXPIRED"
+
+	// REFRESH_TOKEN_EXPIRE_SOON уведомляет, что скоро потребуется повторный вход
+	REFRESH_TOKEN_EXPIRE_SOON = "REFRESH_TOKEN_EXPIRE_SOON"
 )
+
+// SystemEventPrefix - префикс служебных событий (объявления и т.п.), которые
+// пользователь может отключить в настройках уведомлений
+const SystemEventPrefix = "system:"
+
+// IsSuppressibleEvent сообщает, может ли пользователь отказаться от события.
+// TOKEN_EXPIRED и принудительное отключение сессии (кадр закрытия) не отключаются.
+func IsSuppressibleEvent(eventType string) bool {
+	switch eventType {
+	case TOKEN_EXPIRE_SOON, REFRESH_TOKEN_EXPIRE_SOON:
+		return true
+	}
+	return strings.HasPrefix(eventType, SystemEventPrefix)
+}
+
+// subscribableMessageTypes - типы сообщений, на которые клиент может подписаться
+var subscribableMessageTypes = []string{
+	QUIZ_START,
+	QUIZ_END,
+	QUESTION_START,
+	QUESTION_END,
+	USER_ANSWER,
+	RESULT_UPDATE,
+	TOKEN_EXPIRE_SOON,
+	TOKEN_EXPIRED,
+	REFRESH_TOKEN_EXPIRE_SOON,
+}
+
+// SubscribableMessageTypes возвращает список типов сообщений, доступных для подписки
+func SubscribableMessageTypes() []string {
+	return append([]string(nil), subscribableMessageTypes...)
+}
+
+// IsSubscribableMessageType проверяет, можно ли подписаться на указанный тип сообщений
+func IsSubscribableMessageType(messageType string) bool {
+	for _, t := range subscribableMessageTypes {
+		if t == messageType {
+			return true
+		}
+	}
+	return false
+}
@@ -10,6 +10,7 @@ type HubMetrics struct {
 	// Основные метрики
 	totalConnections       int64     // Общее количество подключений за все время
 	activeConnections      int64     // Текущее количество активных подключений
+	peakConnections        int64     // Максимальное количество одновременных подключений
 	messagesSent           int64     // Общее количество отправленных сообщений
 	messagesReceived       int64     // Общее количество полученных сообщений
 	connectionErrors       int64     // Общее количество ошибок соединений
@@ -46,6 +47,30 @@ func (m *HubMetrics) IncrementTotalConnections() {
 	defer m.mu.Unlock()
 	m.totalConnections++
 	m.activeConnections++
+	m.updatePeakLocked()
+}
+
+// SetActiveConnections устанавливает текущее количество активных подключений
+// и обновляет пиковое значение
+func (m *HubMetrics) SetActiveConnections(count int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.activeConnections = count
+	m.updatePeakLocked()
+}
+
+// PeakConnections возвращает максимальное количество одновременных подключений
+func (m *HubMetrics) PeakConnections() int64 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.peakConnections
+}
+
+// updatePeakLocked обновляет пиковое значение. Вызывается под m.mu.
+func (m *HubMetrics) updatePeakLocked() {
+	if m.activeConnections > m.peakConnections {
+		m.peakConnections = m.activeConnections
+	}
 }
 
 // DecrementActiveConnections уменьшает счетчик активных подключений
@@ -153,6 +178,7 @@ func (m *HubMetrics) GetAllMetrics() map[string]interface{} {
 		// Основные метрики
 		"total_connections":        m.totalConnections,
 		"active_connections":       m.activeConnections,
+		"peak_connections":         m.peakConnections,
 		"messages_sent":            m.messagesSent,
 		"messages_received":        m.messagesReceived,
 		"connection_errors":        m.connectionErrors,
@@ -0,0 +1,101 @@
+package websocket
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/yourusername/trivia-api/internal/domain/entity"
+	"github.com/yourusername/trivia-api/internal/domain/repository"
+)
+
+// StartMetricsHistory запускает периодическое сохранение снимков метрик хаба.
+// Снимок строится на основе данных gatherMetrics; алерты о горячих шардах
+// отправляет сбор метрик в Run, а не этот цикл. Записи старше retention
+// удаляются не чаще раза в час (retention <= 0 отключает очистку).
+func (h *ShardedHub) StartMetricsHistory(repo repository.WSMetricsRepository, interval, retention time.Duration) {
+	if repo == nil {
+		log.Println("ShardedHub: репозиторий истории метрик не задан, сохранение отключено")
+		return
+	}
+	if interval <= 0 {
+		interval = time.Minute
+	}
+
+	log.Printf("ShardedHub: сохранение истории метрик каждые %v (хранение: %v)", interval, retention)
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		var lastPurge time.Time
+
+		for {
+			select {
+			case <-h.done:
+				log.Println("ShardedHub: остановка сохранения истории метрик")
+				return
+			case <-ticker.C:
+				snapshot := h.buildMetricsSnapshot(h.gatherMetrics())
+
+				ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				if err := repo.SaveSnapshot(ctx, snapshot); err != nil {
+					log.Printf("ShardedHub: ошибка сохранения снимка метрик: %v", err)
+				}
+
+				if retention > 0 && time.Since(lastPurge) >= time.Hour {
+					if deleted, err := repo.DeleteOlderThan(ctx, time.Now().Add(-retention)); err != nil {
+						log.Printf("ShardedHub: ошибка очистки истории метрик: %v", err)
+					} else if deleted > 0 {
+						log.Printf("ShardedHub: удалено %d устаревших снимков метрик", deleted)
+					}
+					lastPurge = time.Now()
+				}
+				cancel()
+			}
+		}
+	}()
+}
+
+// buildMetricsSnapshot формирует снимок метрик для сохранения
+func (h *ShardedHub) buildMetricsSnapshot(collection *metricsCollection) *entity.WSMetricsSnapshot {
+	basic := h.metrics.GetBasicMetrics()
+
+	snapshot := &entity.WSMetricsSnapshot{
+		InstanceID:        h.GetInstanceID(),
+		CollectedAt:       time.Now(),
+		ActiveConnections: collection.totalConnections,
+		AlertsTotal:       h.alertsTotal.Load(),
+		HotShards:         len(collection.hotShards),
+		MaxShardLoad:      collection.maxLoad,
+		ShardLoads:        make(entity.JSONMap, len(collection.shardMetrics)),
+	}
+
+	if v, ok := basic["total_connections"].(int64); ok {
+		snapshot.TotalConnections = v
+	}
+
+	for i, m := range collection.shardMetrics {
+		if v, ok := m["messages_sent"].(int64); ok {
+			snapshot.MessagesSent += v
+		}
+		if v, ok := m["messages_received"].(int64); ok {
+			snapshot.MessagesReceived += v
+		}
+		if v, ok := m["messages_dropped"].(int64); ok {
+			snapshot.MessagesDropped += v
+		}
+		if v, ok := m["connection_errors"].(int64); ok {
+			snapshot.ConnectionErrors += v
+		}
+
+		snapshot.ShardLoads[fmt.Sprintf("%d", i)] = map[string]interface{}{
+			"active_connections": m["active_connections"],
+			"load_percentage":    m["load_percentage"],
+			"messages_dropped":   m["messages_dropped"],
+		}
+	}
+
+	return snapshot
+}
@@ -0,0 +1,78 @@
+package websocket
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourusername/trivia-api/internal/domain/entity"
+)
+
+// memoryMetricsRepo хранит снимки метрик в памяти
+type memoryMetricsRepo struct {
+	mu        sync.Mutex
+	snapshots []*entity.WSMetricsSnapshot
+	purges    []time.Time
+}
+
+func (r *memoryMetricsRepo) SaveSnapshot(_ context.Context, snapshot *entity.WSMetricsSnapshot) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.snapshots = append(r.snapshots, snapshot)
+	return nil
+}
+
+func (r *memoryMetricsRepo) GetSnapshots(context.Context, time.Time, time.Time, string, int) ([]entity.WSMetricsSnapshot, error) {
+	return nil, nil
+}
+
+func (r *memoryMetricsRepo) DeleteOlderThan(_ context.Context, cutoffTime time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.purges = append(r.purges, cutoffTime)
+	return 0, nil
+}
+
+func (r *memoryMetricsRepo) counts() (int, int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.snapshots), len(r.purges)
+}
+
+func TestShardedHub_BuildMetricsSnapshot(t *testing.T) {
+	hub := newLoadedTestHub()
+	hub.shards[0].metrics.messagesSent = 3
+	hub.shards[1].metrics.messagesSent = 4
+	hub.shards[1].metrics.messagesDropped = 1
+	hub.alertsTotal.Add(2)
+
+	snapshot := hub.buildMetricsSnapshot(hub.gatherMetrics())
+	assert.Equal(t, "standalone_instance", snapshot.InstanceID)
+	assert.Equal(t, int64(2), snapshot.ActiveConnections)
+	assert.Equal(t, int64(7), snapshot.MessagesSent)
+	assert.Equal(t, int64(1), snapshot.MessagesDropped)
+	assert.Equal(t, int64(2), snapshot.AlertsTotal)
+	assert.Equal(t, 1, snapshot.HotShards)
+	assert.Equal(t, 100.0, snapshot.MaxShardLoad)
+	require.Contains(t, snapshot.ShardLoads, "1")
+	assert.Equal(t, 0.0, snapshot.ShardLoads["1"].(map[string]interface{})["load_percentage"])
+}
+
+func TestShardedHub_StartMetricsHistory(t *testing.T) {
+	hub := newLoadedTestHub()
+	repo := &memoryMetricsRepo{}
+
+	hub.StartMetricsHistory(repo, 10*time.Millisecond, time.Hour)
+	require.Eventually(t, func() bool {
+		saved, _ := repo.counts()
+		return saved >= 2
+	}, time.Second, 5*time.Millisecond)
+	close(hub.done)
+
+	_, purged := repo.counts()
+	assert.Equal(t, 1, purged, "очистка выполняется не чаще раза в час")
+	assert.Empty(t, drainAlerts(hub), "алерты отправляет сбор метрик, а не история")
+}
@@ -29,6 +29,13 @@ type ClusterAwareHub interface {
 	// Возвращает true, если клиент найден локально и сообщение отправлено (или поставлено в очередь), иначе false.
 	SendToUser(userID string, message []byte) bool
 
+	// DisconnectUserLocal закрывает соединение пользователя только на этом экземпляре.
+	DisconnectUserLocal(userID string, reason string) int
+
+	// UnsubscribeUserFromQuizLocal отписывает соединение пользователя на этом
+	// экземпляре от викторины, не закрывая его.
+	UnsubscribeUserFromQuizLocal(userID string, quizID uint) bool
+
 	// GetInstanceID возвращает уникальный ID этого экземпляра хаба.
 	GetInstanceID() string
 
@@ -59,6 +66,8 @@ type ClusterMessage struct {
 	// MessageType определяет тип сообщения кластера
 	// broadcast - широковещательное сообщение для всех клиентов
 	// direct - сообщение для конкретного пользователя
+	// disconnect - закрыть соединение пользователя (Payload - причина в виде JSON-строки)
+	// unsubscribe - отписать соединение пользователя от викторины (Payload - ID викторины)
 	// metrics - обновление метрик кластера
 	MessageType string `json:"type"`
 
@@ -228,6 +237,61 @@ func (ch *ClusterHub) SendToUserInCluster(userID string, payload []byte) error {
 	return ch.Provider.Publish(ch.config.DirectChannel, data)
 }
 
+// DisconnectUserInCluster просит остальные экземпляры закрыть соединение пользователя
+func (ch *ClusterHub) DisconnectUserInCluster(userID string, reason string) error {
+	if !ch.config.Enabled {
+		return nil
+	}
+
+	payload, err := json.Marshal(reason)
+	if err != nil {
+		return err
+	}
+
+	msg := ClusterMessage{
+		MessageType: "disconnect",
+		RecipientID: userID,
+		InstanceID:  ch.config.InstanceID,
+		Payload:     payload,
+		Timestamp:   time.Now(),
+	}
+
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	return ch.Provider.Publish(ch.config.DirectChannel, data)
+}
+
+// UnsubscribeUserFromQuizInCluster просит остальные экземпляры отписать соединение
+// пользователя от викторины
+func (ch *ClusterHub) UnsubscribeUserFromQuizInCluster(userID string, quizID uint) error {
+	if !ch.config.Enabled {
+		return nil
+	}
+
+	payload, err := json.Marshal(quizID)
+	if err != nil {
+		return err
+	}
+
+	msg := ClusterMessage{
+		MessageType: "unsubscribe",
+		RecipientID: userID,
+		InstanceID:  ch.config.InstanceID,
+		Payload:     payload,
+		Timestamp:   time.Now(),
+	}
+
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	return ch.Provider.Publish(ch.config.DirectChannel, data)
+}
+
 // handleBroadcastMessages обрабатывает входящие широковещательные сообщения
 func (ch *ClusterHub) handleBroadcastMessages() {
 	broadcastCh, err := ch.Provider.Subscribe(ch.ctx, ch.config.BroadcastChannel)
@@ -319,6 +383,19 @@ func (ch *ClusterHub) handleDirectMessages() {
 				// Отправляем сообщение локальному пользователю, если он есть
 				// Ошибку не обрабатываем, т.к. SendToUser сам логирует, если получатель не найден локально
 				_ = ch.parent.SendToUser(msg.RecipientID, msg.Payload)
+			} else if msg.MessageType == "disconnect" && msg.RecipientID != "" {
+				var reason string
+				if err := json.Unmarshal(msg.Payload, &reason); err != nil {
+					log.Printf("[ClusterHub:Direct] Некорректная причина отключения пользователя %s от %s: %v", msg.RecipientID, msg.InstanceID, err)
+				}
+				ch.parent.DisconnectUserLocal(msg.RecipientID, reason)
+			} else if msg.MessageType == "unsubscribe" && msg.RecipientID != "" {
+				var quizID uint
+				if err := json.Unmarshal(msg.Payload, &quizID); err != nil {
+					log.Printf("[ClusterHub:Direct] Некорректный ID викторины для отписки пользователя %s от %s: %v", msg.RecipientID, msg.InstanceID, err)
+					continue
+				}
+				ch.parent.UnsubscribeUserFromQuizLocal(msg.RecipientID, quizID)
 			} else {
 				log.Printf("[ClusterHub:Direct] Получено сообщение неверного типа или без получателя в канале %s: %+v", ch.config.DirectChannel, msg)
 			}
@@ -474,6 +551,8 @@ type RedisPubSub struct {
 	cancel        context.CancelFunc
 	subscriptions sync.Map   // Хранит активные подписки (channel -> *redis.PubSub)
 	mu            sync.Mutex // Защищает доступ к subscriptions
+	// Повторы публикации и размыкание цепи при недоступном Redis
+	publishGuard *publishGuard
 }
 
 // NewRedisPubSub создает новый Redis Pub/Sub провайдер, используя существующий UniversalClient.
@@ -496,21 +575,36 @@ func NewRedisPubSub(client redis.UniversalClient) (*RedisPubSub, error) {
 		ctx:           ctxPubSub,
 		cancel:        cancelPubSub,
 		subscriptions: sync.Map{},
+		publishGuard:  newPublishGuard(DefaultPublishRetryPolicy(), isRedisConnError),
 	}
 
 	log.Println("RedisPubSub provider created using existing client.")
 	return rp, nil
 }
 
-// Publish публикует сообщение в указанный канал
+// SetPublishRetryPolicy задает повторы публикации и размыкание цепи
+// (по умолчанию DefaultPublishRetryPolicy). Вызывается до начала работы.
+func (p *RedisPubSub) SetPublishRetryPolicy(policy PublishRetryPolicy) {
+	p.publishGuard = newPublishGuard(policy, isRedisConnError)
+}
+
+// Publish публикует сообщение в указанный канал. Ошибки соединения повторяются
+// с паузой; пока цепь разомкнута, возвращается ErrPubSubCircuitOpen без обращения к Redis.
 func (p *RedisPubSub) Publish(channel string, message []byte) error {
-	// Используем retry логику, вынесенную в config
-	cmd := p.client.Publish(p.ctx, channel, message)
-	if err := cmd.Err(); err != nil {
+	var subscribers int64
+	err := p.publishGuard.do(func() error {
+		cmd := p.client.Publish(p.ctx, channel, message)
+		subscribers = cmd.Val()
+		return cmd.Err()
+	})
+	if errors.Is(err, ErrPubSubCircuitOpen) {
+		return err
+	}
+	if err != nil {
 		log.Printf("RedisPubSub: Error publishing to channel '%s': %v", channel, err)
 		return fmt.Errorf("failed to publish to Redis channel %s: %w", channel, err)
 	}
-	log.Printf("RedisPubSub: Published message to channel '%s' (Subscribers: %d)", channel, cmd.Val())
+	log.Printf("RedisPubSub: Published message to channel '%s' (Subscribers: %d)", channel, subscribers)
 	return nil
 }
 
@@ -666,13 +760,6 @@ func isRedisConnError(err error) bool {
 		strings.Contains(strErr, "broken pipe")
 }
 
-// Вспомогательная функция для выполнения операции с ретраями (заменена на встроенную логику клиента)
-/*
-func (p *RedisPubSub) executeWithRetry(operation func() error) error {
-	// ... (старая логика ретраев)
-}
-*/
-
 // generateInstanceID создает уникальный ID для экземпляра Hub
 func generateInstanceID() string {
 	return "instance_" + time.Now().Format("20060102150405") + "_" + randomString(8)
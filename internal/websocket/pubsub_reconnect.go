@@ -0,0 +1,367 @@
+package websocket
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+)
+
+// ErrPubSubUnavailable возвращается при публикации, пока соединение с брокером не установлено
+var ErrPubSubUnavailable = errors.New("pub/sub provider is not connected")
+
+// Интервалы повторного подключения ReconnectingPubSub
+const (
+	pubSubReconnectMinInterval = 2 * time.Second
+	pubSubReconnectMaxInterval = time.Minute
+)
+
+// PubSubConnectFunc создает рабочий провайдер Pub/Sub (например, RedisPubSub)
+type PubSubConnectFunc func() (PubSubProvider, error)
+
+// PubSubAlertFunc отправляет алерт о состоянии кластерного режима (см. Manager.SendAlert)
+type PubSubAlertFunc func(alertType AlertType, severity AlertSeverity, message string, metadata map[string]interface{})
+
+// reconnectingSubscription - подписка, переживающая переподключение провайдера.
+// Подписчик читает из out, а сообщения провайдера пересылаются туда, пока он доступен.
+type reconnectingSubscription struct {
+	ctx      context.Context
+	channel  string
+	out      chan []byte
+	attached bool // Есть ли активная подписка у текущего провайдера (под ReconnectingPubSub.mu)
+
+	outMu  sync.Mutex // Не дает закрыть out во время отправки
+	closed bool
+}
+
+// deliver передает сообщение подписчику; при переполненном буфере сообщение отбрасывается
+func (s *reconnectingSubscription) deliver(msg []byte) {
+	s.outMu.Lock()
+	defer s.outMu.Unlock()
+	if s.closed {
+		return
+	}
+	select {
+	case s.out <- msg:
+	default:
+		log.Printf("[ReconnectingPubSub] Буфер подписки на канал '%s' переполнен, сообщение отброшено", s.channel)
+	}
+}
+
+func (s *reconnectingSubscription) close() {
+	s.outMu.Lock()
+	defer s.outMu.Unlock()
+	if !s.closed {
+		s.closed = true
+		close(s.out)
+	}
+}
+
+// ReconnectingPubSub реализует PubSubProvider поверх провайдера, который может быть
+// недоступен при запуске или отвалиться позже. Пока провайдера нет, публикация
+// возвращает ErrPubSubUnavailable, подписки ожидают, а в фоне выполняются попытки
+// подключения с экспоненциальной паузой. Алерт AlertClusterDegraded отправляется
+// один раз при потере связи и один раз при ее восстановлении, а не на каждую попытку.
+// После подключения подписки восстанавливаются без перезапуска сервиса.
+type ReconnectingPubSub struct {
+	connect PubSubConnectFunc
+
+	mu            sync.Mutex
+	provider      PubSubProvider // nil, пока соединения нет
+	subs          []*reconnectingSubscription
+	alert         PubSubAlertFunc
+	degradedSince time.Time
+	attempts      int
+	// Отправлен ли алерт о текущей серии неудачных попыток
+	degradedAlerted bool
+
+	minInterval time.Duration
+	maxInterval time.Duration
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wake   chan struct{}
+}
+
+// Проверка компилятором, что ReconnectingPubSub реализует PubSubProvider
+var _ PubSubProvider = (*ReconnectingPubSub)(nil)
+
+// NewReconnectingPubSub создает обертку и сразу делает первую попытку подключения,
+// чтобы при доступном брокере кластерный режим работал с момента запуска.
+// Фоновые попытки начинаются после вызова Start.
+func NewReconnectingPubSub(connect PubSubConnectFunc) *ReconnectingPubSub {
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &ReconnectingPubSub{
+		connect:     connect,
+		minInterval: pubSubReconnectMinInterval,
+		maxInterval: pubSubReconnectMaxInterval,
+		ctx:         ctx,
+		cancel:      cancel,
+		wake:        make(chan struct{}, 1),
+	}
+	p.tryConnect()
+	return p
+}
+
+// SetAlertFunc задает получателя алертов о деградации кластерного режима
+func (p *ReconnectingPubSub) SetAlertFunc(alert PubSubAlertFunc) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.alert = alert
+}
+
+// Connected сообщает, установлено ли соединение с брокером
+func (p *ReconnectingPubSub) Connected() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.provider != nil
+}
+
+// Start запускает фоновое переподключение и восстановление подписок до вызова Close
+func (p *ReconnectingPubSub) Start() {
+	go p.run()
+}
+
+func (p *ReconnectingPubSub) run() {
+	interval := p.minInterval
+	for {
+		timer := time.NewTimer(interval)
+		select {
+		case <-p.ctx.Done():
+			timer.Stop()
+			return
+		case <-p.wake:
+			timer.Stop()
+		case <-timer.C:
+		}
+
+		if p.tryConnect() && p.attachPending() {
+			interval = p.minInterval
+			continue
+		}
+		// Пока брокер недоступен, увеличиваем паузу, чтобы не засыпать его попытками
+		interval *= 2
+		if interval > p.maxInterval {
+			interval = p.maxInterval
+		}
+	}
+}
+
+// tryConnect подключается, если соединения нет. Возвращает true, если провайдер доступен.
+func (p *ReconnectingPubSub) tryConnect() bool {
+	p.mu.Lock()
+	if p.provider != nil {
+		p.mu.Unlock()
+		return true
+	}
+	p.mu.Unlock()
+
+	provider, err := p.connect()
+
+	p.mu.Lock()
+	if p.ctx.Err() != nil {
+		p.mu.Unlock()
+		if provider != nil {
+			provider.Close()
+		}
+		return false
+	}
+	alert := p.alert
+
+	if err != nil {
+		p.attempts++
+		if p.degradedSince.IsZero() {
+			p.degradedSince = time.Now()
+		}
+		attempts, downtime := p.attempts, time.Since(p.degradedSince).Round(time.Second)
+		// Повторные неудачи той же серии видны в логе, алерт - только о ее начале.
+		// Первая попытка выполняется до SetAlertFunc, поэтому ориентируемся не на номер попытки.
+		sendAlert := alert != nil && !p.degradedAlerted
+		if sendAlert {
+			p.degradedAlerted = true
+		}
+		p.mu.Unlock()
+
+		log.Printf("[ReconnectingPubSub] ВНИМАНИЕ: брокер Pub/Sub недоступен (попытка %d, %v без связи): %v. "+
+			"Сообщения между экземплярами не доставляются до восстановления соединения.", attempts, downtime, err)
+		if sendAlert {
+			alert(AlertClusterDegraded, AlertCritical,
+				"Кластерный режим WebSocket деградировал: нет соединения с брокером Pub/Sub",
+				map[string]interface{}{
+					"attempts":         attempts,
+					"downtime_seconds": int(downtime.Seconds()),
+					"error":            err.Error(),
+				})
+		}
+		return false
+	}
+
+	recovered, attempts, downtime := !p.degradedSince.IsZero(), p.attempts, time.Since(p.degradedSince).Round(time.Second)
+	p.provider = provider
+	p.degradedSince = time.Time{}
+	p.attempts = 0
+	p.degradedAlerted = false
+	p.mu.Unlock()
+
+	if recovered {
+		log.Printf("[ReconnectingPubSub] Соединение с брокером Pub/Sub восстановлено после %d попыток (%v без связи)", attempts, downtime)
+		if alert != nil {
+			alert(AlertClusterDegraded, AlertInfo, "Кластерный режим WebSocket восстановлен",
+				map[string]interface{}{"attempts": attempts})
+		}
+	}
+	return true
+}
+
+// attachPending подписывает у текущего провайдера все подписки, которые еще не подключены.
+// Возвращает false, если подписаться не удалось: провайдер считается потерянным.
+func (p *ReconnectingPubSub) attachPending() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	active := p.subs[:0]
+	for _, sub := range p.subs {
+		if sub.ctx.Err() != nil {
+			continue // Подписчик ушел, его канал уже закрыт
+		}
+		active = append(active, sub)
+	}
+	p.subs = active
+
+	for _, sub := range p.subs {
+		if sub.attached || p.provider == nil {
+			continue
+		}
+		if err := p.attachLocked(sub); err != nil {
+			log.Printf("[ReconnectingPubSub] Ошибка подписки на канал '%s': %v. Провайдер будет переподключен.", sub.channel, err)
+			p.dropProviderLocked()
+			return false
+		}
+	}
+	return true
+}
+
+// attachLocked подписывается у провайдера и запускает пересылку сообщений в канал подписчика
+func (p *ReconnectingPubSub) attachLocked(sub *reconnectingSubscription) error {
+	in, err := p.provider.Subscribe(sub.ctx, sub.channel)
+	if err != nil {
+		return err
+	}
+	sub.attached = true
+	go p.forward(sub, in, p.provider)
+	return nil
+}
+
+// forward пересылает сообщения провайдера подписчику. Если канал провайдера закрылся,
+// а подписчик и обертка еще активны, соединение считается потерянным.
+func (p *ReconnectingPubSub) forward(sub *reconnectingSubscription, in <-chan []byte, provider PubSubProvider) {
+	for {
+		select {
+		case msg, ok := <-in:
+			if ok {
+				sub.deliver(msg)
+				continue
+			}
+			if sub.ctx.Err() != nil || p.ctx.Err() != nil {
+				return
+			}
+			p.mu.Lock()
+			// Канал старого провайдера закрывается и при его замене - это не потеря нового соединения
+			lost := p.provider == provider
+			if lost {
+				sub.attached = false
+				p.dropProviderLocked()
+			}
+			p.mu.Unlock()
+			if lost {
+				log.Printf("[ReconnectingPubSub] Подписка на канал '%s' потеряна, ожидание переподключения", sub.channel)
+				p.signal()
+			}
+			return
+		case <-sub.ctx.Done():
+			return
+		case <-p.ctx.Done():
+			return
+		}
+	}
+}
+
+// dropProviderLocked закрывает текущего провайдера и переводит обертку в режим деградации
+func (p *ReconnectingPubSub) dropProviderLocked() {
+	if p.provider == nil {
+		return
+	}
+	if err := p.provider.Close(); err != nil {
+		log.Printf("[ReconnectingPubSub] Ошибка при закрытии провайдера: %v", err)
+	}
+	p.provider = nil
+	for _, sub := range p.subs {
+		sub.attached = false
+	}
+}
+
+// signal будит фоновую горутину для немедленной попытки переподключения
+func (p *ReconnectingPubSub) signal() {
+	select {
+	case p.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Publish публикует сообщение, если соединение установлено
+func (p *ReconnectingPubSub) Publish(channel string, message []byte) error {
+	p.mu.Lock()
+	provider := p.provider
+	p.mu.Unlock()
+
+	if provider == nil {
+		return ErrPubSubUnavailable
+	}
+	return provider.Publish(channel, message)
+}
+
+// Subscribe возвращает канал, который продолжает получать сообщения после переподключений.
+// Канал закрывается при отмене ctx или закрытии обертки.
+func (p *ReconnectingPubSub) Subscribe(ctx context.Context, channel string) (<-chan []byte, error) {
+	sub := &reconnectingSubscription{
+		ctx:     ctx,
+		channel: channel,
+		out:     make(chan []byte, 100),
+	}
+
+	p.mu.Lock()
+	p.subs = append(p.subs, sub)
+	if p.provider != nil {
+		if err := p.attachLocked(sub); err != nil {
+			log.Printf("[ReconnectingPubSub] Ошибка подписки на канал '%s': %v. Подписка восстановится после переподключения.", channel, err)
+			p.dropProviderLocked()
+			defer p.signal()
+		}
+	}
+	p.mu.Unlock()
+
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-p.ctx.Done():
+		}
+		sub.close()
+	}()
+
+	return sub.out, nil
+}
+
+// Close останавливает переподключение и закрывает текущего провайдера
+func (p *ReconnectingPubSub) Close() error {
+	p.cancel()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.provider == nil {
+		return nil
+	}
+	err := p.provider.Close()
+	p.provider = nil
+	return err
+}
@@ -0,0 +1,232 @@
+package websocket
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryPubSub - провайдер в памяти; Close имитирует обрыв соединения
+type memoryPubSub struct {
+	mu   sync.Mutex
+	subs map[string][]chan []byte
+}
+
+func newMemoryPubSub() *memoryPubSub {
+	return &memoryPubSub{subs: make(map[string][]chan []byte)}
+}
+
+func (m *memoryPubSub) Publish(channel string, message []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, ch := range m.subs[channel] {
+		ch <- message
+	}
+	return nil
+}
+
+func (m *memoryPubSub) Subscribe(ctx context.Context, channel string) (<-chan []byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ch := make(chan []byte, 10)
+	m.subs[channel] = append(m.subs[channel], ch)
+	return ch, nil
+}
+
+func (m *memoryPubSub) subscribers(channel string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.subs[channel])
+}
+
+func (m *memoryPubSub) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, chs := range m.subs {
+		for _, ch := range chs {
+			close(ch)
+		}
+	}
+	m.subs = make(map[string][]chan []byte)
+	return nil
+}
+
+// flakyBroker выдает новые провайдеры, пока доступен
+type flakyBroker struct {
+	mu        sync.Mutex
+	available bool
+	providers []*memoryPubSub
+}
+
+func (b *flakyBroker) connect() (PubSubProvider, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.available {
+		return nil, errors.New("connection refused")
+	}
+	provider := newMemoryPubSub()
+	b.providers = append(b.providers, provider)
+	return provider, nil
+}
+
+func (b *flakyBroker) setAvailable(available bool) {
+	b.mu.Lock()
+	b.available = available
+	b.mu.Unlock()
+}
+
+func (b *flakyBroker) last() *memoryPubSub {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.providers[len(b.providers)-1]
+}
+
+func (b *flakyBroker) connections() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.providers)
+}
+
+// alertRecorder запоминает уровни полученных алертов
+type alertRecorder struct {
+	mu         sync.Mutex
+	severities []AlertSeverity
+}
+
+func (r *alertRecorder) record(alertType AlertType, severity AlertSeverity, message string, metadata map[string]interface{}) {
+	r.mu.Lock()
+	r.severities = append(r.severities, severity)
+	r.mu.Unlock()
+}
+
+func (r *alertRecorder) has(severity AlertSeverity) bool {
+	return r.count(severity) > 0
+}
+
+func (r *alertRecorder) count(severity AlertSeverity) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := 0
+	for _, s := range r.severities {
+		if s == severity {
+			n++
+		}
+	}
+	return n
+}
+
+func newTestReconnectingPubSub(broker *flakyBroker) (*ReconnectingPubSub, *alertRecorder) {
+	alerts := &alertRecorder{}
+	p := NewReconnectingPubSub(broker.connect)
+	p.minInterval = 5 * time.Millisecond
+	p.maxInterval = 20 * time.Millisecond
+	p.SetAlertFunc(alerts.record)
+	return p, alerts
+}
+
+func receive(t *testing.T, ch <-chan []byte) string {
+	t.Helper()
+	select {
+	case msg := <-ch:
+		return string(msg)
+	case <-time.After(time.Second):
+		t.Fatal("сообщение не получено")
+		return ""
+	}
+}
+
+func TestReconnectingPubSub_UpgradesWhenBrokerBecomesAvailable(t *testing.T) {
+	broker := &flakyBroker{}
+	p, alerts := newTestReconnectingPubSub(broker)
+	defer p.Close()
+
+	assert.False(t, p.Connected())
+	assert.ErrorIs(t, p.Publish("broadcast", []byte("lost")), ErrPubSubUnavailable)
+
+	// Подписка принимается и в режиме деградации
+	msgs, err := p.Subscribe(context.Background(), "broadcast")
+	require.NoError(t, err)
+
+	p.Start()
+	require.Eventually(t, func() bool { return alerts.has(AlertCritical) }, time.Second, time.Millisecond)
+
+	broker.setAvailable(true)
+	require.Eventually(t, p.Connected, time.Second, time.Millisecond)
+	require.Eventually(t, func() bool { return alerts.has(AlertInfo) }, time.Second, time.Millisecond)
+
+	require.Eventually(t, func() bool {
+		return p.Publish("broadcast", []byte("hello")) == nil
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, "hello", receive(t, msgs))
+}
+
+func TestReconnectingPubSub_AlertsOncePerFailureStreak(t *testing.T) {
+	broker := &flakyBroker{}
+	p, alerts := newTestReconnectingPubSub(broker)
+	defer p.Close()
+	p.Start()
+
+	attempts := func() int {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		return p.attempts
+	}
+	require.Eventually(t, func() bool { return attempts() >= 3 }, time.Second, time.Millisecond)
+	assert.Equal(t, 1, alerts.count(AlertCritical), "повторные неудачные попытки не дублируют алерт")
+
+	broker.setAvailable(true)
+	require.Eventually(t, func() bool { return alerts.has(AlertInfo) }, time.Second, time.Millisecond)
+
+	// Новая потеря связи (обнаруживается по закрытию подписки) начинает новую серию
+	broker.setAvailable(false)
+	_, err := p.Subscribe(context.Background(), "direct")
+	require.NoError(t, err)
+	broker.last().Close()
+	require.Eventually(t, func() bool { return attempts() >= 3 }, time.Second, time.Millisecond)
+	assert.Equal(t, 2, alerts.count(AlertCritical))
+}
+
+func TestReconnectingPubSub_ResubscribesAfterConnectionLoss(t *testing.T) {
+	broker := &flakyBroker{available: true}
+	p, _ := newTestReconnectingPubSub(broker)
+	defer p.Close()
+	require.True(t, p.Connected())
+
+	msgs, err := p.Subscribe(context.Background(), "direct")
+	require.NoError(t, err)
+	p.Start()
+
+	require.NoError(t, p.Publish("direct", []byte("first")))
+	assert.Equal(t, "first", receive(t, msgs))
+
+	// Обрыв соединения: подписка переезжает на новый провайдер, канал подписчика не закрывается
+	broker.last().Close()
+	require.Eventually(t, func() bool { return broker.connections() == 2 && p.Connected() }, time.Second, time.Millisecond)
+
+	require.Eventually(t, func() bool { return broker.last().subscribers("direct") == 1 }, time.Second, time.Millisecond)
+	require.NoError(t, p.Publish("direct", []byte("second")))
+	assert.Equal(t, "second", receive(t, msgs))
+}
+
+func TestReconnectingPubSub_ClosesSubscriberChannelOnCancel(t *testing.T) {
+	broker := &flakyBroker{available: true}
+	p, _ := newTestReconnectingPubSub(broker)
+	defer p.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	msgs, err := p.Subscribe(ctx, "metrics")
+	require.NoError(t, err)
+
+	cancel()
+	select {
+	case _, ok := <-msgs:
+		assert.False(t, ok)
+	case <-time.After(time.Second):
+		t.Fatal("канал подписчика не закрыт")
+	}
+}
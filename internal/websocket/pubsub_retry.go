@@ -0,0 +1,148 @@
+package websocket
+
+import (
+	"errors"
+	"log"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// ErrPubSubCircuitOpen возвращается при публикации, пока брокер считается недоступным
+var ErrPubSubCircuitOpen = errors.New("pub/sub publish circuit is open")
+
+// PublishRetryPolicy задает повторы публикации и размыкание цепи при недоступном брокере
+type PublishRetryPolicy struct {
+	// Число попыток одной публикации (включая первую)
+	MaxAttempts int
+	// Пауза перед второй попыткой; далее удваивается до MaxDelay
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// Число публикаций подряд, не прошедших из-за ошибок соединения, после которого цепь размыкается
+	FailureThreshold int
+	// Сколько цепь остается разомкнутой до пробной публикации
+	OpenTimeout time.Duration
+}
+
+// DefaultPublishRetryPolicy возвращает политику по умолчанию: до 3 попыток
+// с паузами около 50 и 100 мс, размыкание после 5 неудачных публикаций на 5 секунд
+func DefaultPublishRetryPolicy() PublishRetryPolicy {
+	return PublishRetryPolicy{
+		MaxAttempts:      3,
+		BaseDelay:        50 * time.Millisecond,
+		MaxDelay:         500 * time.Millisecond,
+		FailureThreshold: 5,
+		OpenTimeout:      5 * time.Second,
+	}
+}
+
+// publishGuard выполняет публикацию с повторами и размыканием цепи.
+// Паузы между попытками вычисляются для каждого вызова заново и не влияют
+// на другие публикации; общим состоянием является только счетчик неудач цепи.
+type publishGuard struct {
+	policy    PublishRetryPolicy
+	retryable func(error) bool
+	sleep     func(time.Duration)
+	now       func() time.Time
+
+	mu       sync.Mutex
+	failures int       // Публикации подряд, завершившиеся ошибкой соединения
+	openTill time.Time // Цепь разомкнута до этого момента (нулевое - замкнута)
+	probing  bool      // После OpenTimeout выполняется пробная публикация
+}
+
+func newPublishGuard(policy PublishRetryPolicy, retryable func(error) bool) *publishGuard {
+	if policy.MaxAttempts < 1 {
+		policy.MaxAttempts = 1
+	}
+	return &publishGuard{
+		policy:    policy,
+		retryable: retryable,
+		sleep:     time.Sleep,
+		now:       time.Now,
+	}
+}
+
+// do выполняет publish. Повторяются только ошибки соединения; пробная публикация
+// после размыкания выполняется одной попыткой.
+func (g *publishGuard) do(publish func() error) error {
+	probe, err := g.acquire()
+	if err != nil {
+		return err
+	}
+
+	attempts := g.policy.MaxAttempts
+	if probe {
+		attempts = 1
+	}
+
+	for attempt := 0; ; attempt++ {
+		err = publish()
+		if err == nil || !g.retryable(err) {
+			// Брокер ответил (возможно, ошибкой команды) - соединение есть
+			g.recordSuccess()
+			return err
+		}
+		if attempt+1 >= attempts {
+			break
+		}
+		g.sleep(g.backoff(attempt))
+	}
+
+	g.recordFailure(probe)
+	return err
+}
+
+// acquire проверяет состояние цепи. Возвращает probe=true, если вызов - пробная
+// публикация после OpenTimeout.
+func (g *publishGuard) acquire() (probe bool, err error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.openTill.IsZero() {
+		return false, nil
+	}
+	if g.probing || g.now().Before(g.openTill) {
+		return false, ErrPubSubCircuitOpen
+	}
+	g.probing = true
+	return true, nil
+}
+
+func (g *publishGuard) recordSuccess() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if !g.openTill.IsZero() {
+		log.Println("[RedisPubSub] Публикация восстановлена, цепь замкнута")
+	}
+	g.failures = 0
+	g.openTill = time.Time{}
+	g.probing = false
+}
+
+func (g *publishGuard) recordFailure(probe bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.failures++
+	g.probing = false
+	if probe || g.failures >= g.policy.FailureThreshold {
+		g.openTill = g.now().Add(g.policy.OpenTimeout)
+		log.Printf("[RedisPubSub] ВНИМАНИЕ: %d публикаций подряд не прошли, цепь разомкнута на %v", g.failures, g.policy.OpenTimeout)
+	}
+}
+
+// backoff возвращает паузу перед попыткой attempt+1: экспоненциальная пауза со
+// случайной составляющей, чтобы экземпляры не повторяли публикации одновременно
+func (g *publishGuard) backoff(attempt int) time.Duration {
+	delay := g.policy.BaseDelay << uint(attempt)
+	if delay <= 0 || delay > g.policy.MaxDelay {
+		delay = g.policy.MaxDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(delay-half)+1))
+}
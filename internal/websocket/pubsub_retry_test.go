@@ -0,0 +1,145 @@
+package websocket
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errConnRefused = errors.New("dial tcp 127.0.0.1:6379: connect: connection refused")
+
+// testGuard создает publishGuard с записью пауз и ручными часами
+func testGuard(policy PublishRetryPolicy) (*publishGuard, *[]time.Duration, *time.Time) {
+	g := newPublishGuard(policy, isRedisConnError)
+	var sleeps []time.Duration
+	now := time.Now()
+	g.sleep = func(d time.Duration) { sleeps = append(sleeps, d) }
+	g.now = func() time.Time { return now }
+	return g, &sleeps, &now
+}
+
+// failingTimes возвращает публикацию, которая n раз завершается ошибкой соединения
+func failingTimes(n int) func() error {
+	return func() error {
+		if n > 0 {
+			n--
+			return errConnRefused
+		}
+		return nil
+	}
+}
+
+func TestPublishGuard_BackoffIsPerCall(t *testing.T) {
+	g, sleeps, _ := testGuard(DefaultPublishRetryPolicy())
+
+	// Медленный период: две неудачные попытки, затем успех
+	require.NoError(t, g.do(failingTimes(2)))
+	require.Len(t, *sleeps, 2)
+	assert.True(t, (*sleeps)[0] >= 25*time.Millisecond && (*sleeps)[0] <= 50*time.Millisecond, "пауза %v", (*sleeps)[0])
+	assert.True(t, (*sleeps)[1] >= 50*time.Millisecond && (*sleeps)[1] <= 100*time.Millisecond, "пауза %v", (*sleeps)[1])
+
+	// Следующая публикация снова начинает с базовой паузы
+	*sleeps = nil
+	require.NoError(t, g.do(failingTimes(1)))
+	require.Len(t, *sleeps, 1)
+	assert.True(t, (*sleeps)[0] <= 50*time.Millisecond, "пауза не должна накапливаться между вызовами: %v", (*sleeps)[0])
+}
+
+func TestPublishGuard_DoesNotRetryCommandErrors(t *testing.T) {
+	g, sleeps, _ := testGuard(DefaultPublishRetryPolicy())
+
+	calls := 0
+	err := g.do(func() error {
+		calls++
+		return errors.New("WRONGTYPE Operation against a key holding the wrong kind of value")
+	})
+	assert.Error(t, err)
+	assert.Equal(t, 1, calls)
+	assert.Empty(t, *sleeps)
+}
+
+func TestPublishGuard_CircuitOpensAndProbes(t *testing.T) {
+	policy := DefaultPublishRetryPolicy()
+	policy.FailureThreshold = 2
+	g, _, now := testGuard(policy)
+
+	calls := 0
+	down := func() error { calls++; return errConnRefused }
+
+	assert.ErrorIs(t, g.do(down), errConnRefused)
+	assert.ErrorIs(t, g.do(down), errConnRefused)
+	assert.Equal(t, 2*policy.MaxAttempts, calls)
+
+	// Цепь разомкнута: публикации отклоняются без обращения к Redis
+	calls = 0
+	assert.ErrorIs(t, g.do(down), ErrPubSubCircuitOpen)
+	assert.Zero(t, calls)
+
+	// После OpenTimeout выполняется одна пробная попытка; неудача снова размыкает цепь
+	*now = now.Add(policy.OpenTimeout)
+	assert.ErrorIs(t, g.do(down), errConnRefused)
+	assert.Equal(t, 1, calls)
+	assert.ErrorIs(t, g.do(down), ErrPubSubCircuitOpen)
+
+	// Успешная проба замыкает цепь
+	*now = now.Add(policy.OpenTimeout)
+	require.NoError(t, g.do(failingTimes(0)))
+	calls = 0
+	assert.ErrorIs(t, g.do(down), errConnRefused)
+	assert.Equal(t, policy.MaxAttempts, calls, "после восстановления повторы снова работают")
+}
+
+func TestPublishGuard_ConcurrentPublishes(t *testing.T) {
+	policy := DefaultPublishRetryPolicy()
+	policy.FailureThreshold = 1
+	g := newPublishGuard(policy, isRedisConnError)
+	g.sleep = func(time.Duration) {}
+
+	var calls int64
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, g.do(func() error { atomic.AddInt64(&calls, 1); return nil }))
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int64(50), calls)
+
+	// Размыкаем цепь и ждем окончания OpenTimeout
+	var now atomic.Value
+	now.Store(time.Now())
+	g.now = func() time.Time { return now.Load().(time.Time) }
+	assert.Error(t, g.do(func() error { return errConnRefused }))
+	now.Store(time.Now().Add(policy.OpenTimeout))
+
+	// Одновременные публикации: пробу выполняет только одна, остальные отклоняются сразу
+	release := make(chan struct{})
+	var probes, rejected int64
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := g.do(func() error {
+				atomic.AddInt64(&probes, 1)
+				<-release
+				return nil
+			})
+			if errors.Is(err, ErrPubSubCircuitOpen) {
+				atomic.AddInt64(&rejected, 1)
+			}
+		}()
+	}
+	require.Eventually(t, func() bool { return atomic.LoadInt64(&rejected) == 19 }, time.Second, time.Millisecond)
+	close(release)
+	wg.Wait()
+	assert.Equal(t, int64(1), probes)
+
+	require.NoError(t, g.do(func() error { return nil }), "после успешной пробы цепь замкнута")
+}
@@ -0,0 +1,48 @@
+package websocket
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourusername/trivia-api/internal/config"
+)
+
+// unsubscribeRecorder - экземпляр кластера, который запоминает отписки от викторин
+type unsubscribeRecorder struct {
+	ClusterAwareHub
+	unsubscribed chan string
+}
+
+func (r *unsubscribeRecorder) UnsubscribeUserFromQuizLocal(userID string, quizID uint) bool {
+	r.unsubscribed <- fmt.Sprintf("%s:%d", userID, quizID)
+	return true
+}
+
+func TestClusterHub_RelaysQuizUnsubscribe(t *testing.T) {
+	provider := newMemoryPubSub()
+	cfg := config.ClusterConfig{Enabled: true, DirectChannel: "direct"}
+
+	recorder := &unsubscribeRecorder{unsubscribed: make(chan string, 1)}
+	receiverCfg := cfg
+	receiverCfg.InstanceID = "receiver"
+	receiver := NewClusterHub(recorder, receiverCfg, provider)
+	receiver.wg.Add(1)
+	go receiver.handleDirectMessages()
+	defer receiver.Stop()
+	require.Eventually(t, func() bool { return provider.subscribers("direct") == 1 }, time.Second, time.Millisecond)
+
+	senderCfg := cfg
+	senderCfg.InstanceID = "sender"
+	sender := NewClusterHub(nil, senderCfg, provider)
+	require.NoError(t, sender.UnsubscribeUserFromQuizInCluster("7", 5))
+
+	select {
+	case got := <-recorder.unsubscribed:
+		assert.Equal(t, "7:5", got)
+	case <-time.After(time.Second):
+		t.Fatal("отписка не передана другому экземпляру")
+	}
+}
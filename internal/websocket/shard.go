@@ -5,6 +5,8 @@ import (
 	"log"
 	"sync"
 	"time"
+
+	"github.com/gorilla/websocket"
 )
 
 // ClientStore - определяем интерфейс здесь, если файл interfaces.go не создался
@@ -37,18 +39,27 @@ type Shard struct {
 	// Добавляем индекс для быстрой рассылки по викторинам
 	// Ключ: quizID (uint), Значение: map[*Client]struct{}
 	quizSubscriptions sync.Map
+
+	// Новые соединения, ожидающие решения текущего соединения пользователя
+	// (политика DuplicatePrompt). Ключ: UserID, Значение: *Client
+	pendingTakeovers sync.Map
 }
 
 // ShardMetrics содержит метрики для отдельного шарда
 type ShardMetrics struct {
-	id                     int
-	activeConnections      int64
-	messagesSent           int64
-	messagesReceived       int64
-	connectionErrors       int64
-	inactiveClientsRemoved int64
-	lastCleanupTime        time.Time
-	mu                     sync.RWMutex
+	id                      int
+	activeConnections       int64
+	messagesSent            int64
+	messagesReceived        int64
+	connectionErrors        int64
+	inactiveClientsRemoved  int64
+	messagesDropped         int64 // Сообщения, не доставленные из-за переполнения буферов
+	overflowDisconnects     int64 // Клиенты, отключенные из-за переполнения буфера отправки
+	writeTimeouts           int64 // Записи, не завершившиеся за WriteWait (соединение обрывается)
+	slowWrites              int64 // Записи дольше SlowWriteThreshold
+	slowConsumerDisconnects int64 // Клиенты, отключенные после MaxSlowWrites медленных записей подряд
+	lastCleanupTime         time.Time
+	mu                      sync.RWMutex
 }
 
 // NewShard создает новый шард
@@ -101,8 +112,15 @@ func (s *Shard) Run() {
 
 // handleRegister регистрирует клиента в шарде
 func (s *Shard) handleRegister(client *Client) {
-	// Проверяем существующего клиента с тем же UserID
-	if existingClient, loaded := s.userMap.LoadOrStore(client.UserID, client); loaded {
+	// Второе соединение пользователя обрабатывается по политике хаба
+	if s.handleDuplicate(client) {
+		return
+	}
+
+	// Новое соединение сразу становится текущим соединением пользователя:
+	// адресные события (результаты ответов и т.п.) уходят в него, а не в
+	// закрываемое старое
+	if existingClient, loaded := s.userMap.Swap(client.UserID, client); loaded {
 		oldClient, ok := existingClient.(*Client)
 		if ok && oldClient != client {
 			log.Printf("Shard %d: replacing client %s with new connection", s.id, client.UserID)
@@ -121,6 +139,7 @@ func (s *Shard) handleRegister(client *Client) {
 				s.metrics.mu.Lock()
 				s.metrics.activeConnections--
 				s.metrics.mu.Unlock()
+				s.notifyConnectionHook(false, oldClient)
 			}()
 		}
 	}
@@ -135,14 +154,10 @@ func (s *Shard) handleRegister(client *Client) {
 	s.metrics.mu.Unlock()
 
 	log.Printf("Shard %d: client %s registered", s.id, client.UserID)
+	s.notifyConnectionHook(true, client)
 
 	// Сигнал о завершении регистрации
-	if client.registrationComplete != nil {
-		select {
-		case client.registrationComplete <- struct{}{}:
-		default:
-		}
-	}
+	s.finishRegistration(client, registrationAccepted)
 }
 
 // handleUnregister удаляет клиента из шарда
@@ -158,6 +173,8 @@ func (s *Shard) handleUnregister(client *Client) {
 		if existingClient, loaded := s.userMap.Load(client.UserID); loaded {
 			if existingClient == client {
 				s.userMap.Delete(client.UserID)
+				// Закрытое соединение уже не ответит: ожидающее занимает его место
+				s.resolveTakeover(client.UserID, true)
 			}
 		}
 
@@ -175,6 +192,14 @@ func (s *Shard) handleUnregister(client *Client) {
 		s.metrics.mu.Unlock()
 
 		log.Printf("Shard %d: client %s unregistered", s.id, client.UserID)
+		s.notifyConnectionHook(false, client)
+	}
+}
+
+// notifyConnectionHook передает событие подключения хуку родительского хаба
+func (s *Shard) notifyConnectionHook(connected bool, client *Client) {
+	if hub, ok := s.parent.(*ShardedHub); ok && hub.connectionHooks != nil {
+		hub.connectionHooks.notify(connected, client, s.id)
 	}
 }
 
@@ -215,10 +240,14 @@ func (s *Shard) handleBroadcast(message []byte) {
 		select {
 		case client.send <- message:
 			// Сообщение успешно отправлено в буфер клиента
+			client.noteQueued()
 		default:
 			// Буфер клиента переполнен, отключаем клиента
 			log.Printf("Shard %d: client %s buffer full, unregistering", s.id, client.UserID)
+			client.noteDropped()
+			s.recordDroppedMessage()
 			s.clients.Delete(client)
+			s.notifyConnectionHook(false, client)
 
 			if existingClient, loaded := s.userMap.Load(client.UserID); loaded && existingClient == client {
 				s.userMap.Delete(client.UserID)
@@ -233,6 +262,7 @@ func (s *Shard) handleBroadcast(message []byte) {
 			s.metrics.mu.Lock()
 			s.metrics.activeConnections--
 			s.metrics.connectionErrors++
+			s.metrics.overflowDisconnects++
 			s.metrics.mu.Unlock()
 		}
 		return true
@@ -352,9 +382,36 @@ func (s *Shard) unsubscribeInternal(client *Client, quizID uint) {
 	}
 }
 
+// quizSubscriberIDs возвращает ID пользователей, подписанных на викторину в этом шарде
+func (s *Shard) quizSubscriberIDs(quizID uint) []string {
+	quizMapUntyped, ok := s.quizSubscriptions.Load(quizID)
+	if !ok {
+		return nil
+	}
+	quizMap, ok := quizMapUntyped.(*sync.Map)
+	if !ok {
+		return nil
+	}
+	var userIDs []string
+	quizMap.Range(func(key, value interface{}) bool {
+		if client, ok := key.(*Client); ok {
+			userIDs = append(userIDs, client.UserID)
+		}
+		return true
+	})
+	return userIDs
+}
+
 // BroadcastToQuiz отправляет сообщение только тем клиентам шарда,
 // которые подписаны на указанную викторину.
 func (s *Shard) BroadcastToQuiz(quizID uint, message []byte) {
+	s.broadcastToQuiz(quizID, message, nil)
+}
+
+// broadcastToQuiz рассылает событие викторины; клиентам с бинарным протоколом
+// отправляется binaryMessage, если он задан
+func (s *Shard) broadcastToQuiz(quizID uint, jsonMessage, binaryMessage []byte) {
+	message := jsonMessage
 	// НОВЫЙ ЛОГ
 	log.Printf("[Shard %d][Quiz %d] BroadcastToQuiz called. Message type: %s", s.id, quizID, messageTypeFromBytes(message))
 	clientCount := 0
@@ -378,16 +435,21 @@ func (s *Shard) BroadcastToQuiz(quizID uint, message []byte) {
 			log.Printf("[Shard %d][Quiz %d][User %s][Conn %s] Attempting to queue message type: %s", s.id, quizID, client.UserID, client.ConnectionID, messageTypeFromBytes(message))
 
 			select {
-			case client.send <- message:
+			case client.send <- client.payloadFor(message, binaryMessage):
 				clientCount++
+				client.noteQueued()
 				log.Printf("[Shard %d][Quiz %d][User %s][Conn %s] Successfully queued message type: %s. Buffer len: %d", s.id, quizID, client.UserID, client.ConnectionID, messageTypeFromBytes(message), len(client.send))
 			default:
 				// Буфер клиента переполнен, отключаем клиента (копипаста из handleBroadcast)
 				// Добавляем лог перед существующим логом об ошибке
 				log.Printf("[Shard %d][Quiz %d][User %s][Conn %s] FAILED to queue message type: %s (BUFFER FULL/CLOSED). Buffer len: %d. Initiating unregister.", s.id, quizID, client.UserID, client.ConnectionID, messageTypeFromBytes(message), len(client.send))
 				log.Printf("Shard %d: client %s buffer full during quiz broadcast, unregistering", s.id, client.UserID)
+				client.noteDropped()
+				s.recordDroppedMessage()
 				s.clients.Delete(client)
 				quizMap.Delete(client) // Удаляем из карты викторины
+				// handleUnregister не найдет клиента в clients, поэтому уведомляем здесь
+				s.notifyConnectionHook(false, client)
 
 				if existingClient, loaded := s.userMap.Load(client.UserID); loaded && existingClient == client {
 					s.userMap.Delete(client.UserID)
@@ -407,6 +469,7 @@ func (s *Shard) BroadcastToQuiz(quizID uint, message []byte) {
 					s.metrics.activeConnections--
 				}
 				s.metrics.connectionErrors++
+				s.metrics.overflowDisconnects++
 				s.metrics.mu.Unlock()
 			}
 			return true
@@ -433,14 +496,14 @@ func (s *Shard) runCleanupTicker() {
 		return
 	}
 
-	log.Printf("[Shard %d] Запуск рутины очистки каждые %v с таймаутом %v", s.id, s.cleanupInterval, s.inactivityTimeout)
-	ticker := time.NewTicker(s.cleanupInterval)
+	interval := s.cleanupTickInterval()
+	log.Printf("[Shard %d] Запуск рутины очистки каждые %v с таймаутом %v", s.id, interval, s.inactivityTimeout)
+	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
 	for {
 		select {
 		case <-ticker.C:
-			log.Printf("[Shard %d] Запуск проверки неактивных клиентов...", s.id)
 			s.cleanupInactiveClients(s.inactivityTimeout)
 		case <-s.done:
 			log.Printf("[Shard %d] Остановка рутины очистки", s.id)
@@ -449,9 +512,11 @@ func (s *Shard) runCleanupTicker() {
 	}
 }
 
-// cleanupInactiveClients проверяет и инициирует удаление неактивных клиентов
+// cleanupInactiveClients проверяет и инициирует удаление неактивных клиентов.
+// Если задан IdleWarningGrace, клиент сначала получает IdleWarningEvent.
 func (s *Shard) cleanupInactiveClients(timeout time.Duration) {
 	inactiveCount := 0
+	grace := s.idleWarningGrace()
 	s.clients.Range(func(key, value interface{}) bool {
 		client, ok := key.(*Client)
 		if !ok {
@@ -459,7 +524,7 @@ func (s *Shard) cleanupInactiveClients(timeout time.Duration) {
 		}
 
 		// Проверяем время последней активности
-		if time.Since(client.lastActivity) > timeout {
+		if s.checkIdle(client, timeout, grace) {
 			inactiveCount++
 			log.Printf("[Shard %d Cleanup] Найден неактивный клиент %s (ConnID: %s). Последняя активность: %v. Инициируем удаление.",
 				s.id, client.UserID, client.ConnectionID, client.lastActivity)
@@ -469,6 +534,9 @@ func (s *Shard) cleanupInactiveClients(timeout time.Duration) {
 			select {
 			case s.unregister <- client:
 				// Успешно отправлен на удаление
+				s.metrics.mu.Lock()
+				s.metrics.inactiveClientsRemoved++
+				s.metrics.mu.Unlock()
 			default:
 				// Если канал переполнен, логируем и пропускаем на этой итерации
 				log.Printf("[Shard %d Cleanup] WARN: Канал unregister переполнен, не удалось инициировать удаление клиента %s (ConnID: %s)",
@@ -504,20 +572,45 @@ func (s *Shard) cleanupAllClients() {
 	log.Printf("Shard %d: all clients cleanup completed", s.id)
 }
 
-// SendToUser отправляет сообщение конкретному пользователю в шарде
-func (s *Shard) SendToUser(userID string, message []byte) bool {
+// hasNewerConnection сообщает, заменено ли соединение client более новым
+// соединением того же пользователя в шарде
+func (s *Shard) hasNewerConnection(client *Client) bool {
+	current := s.clientForUser(client.UserID)
+	return current != nil && current != client
+}
+
+// clientForUser возвращает текущее соединение пользователя в шарде или nil
+func (s *Shard) clientForUser(userID string) *Client {
 	clientInterface, exists := s.userMap.Load(userID)
 	if !exists {
-		return false
+		return nil
 	}
+	client, _ := clientInterface.(*Client)
+	return client
+}
 
-	client, ok := clientInterface.(*Client)
-	if !ok {
+// disconnectUser закрывает соединение пользователя в шарде. Клиент снимается
+// с регистрации в handleUnregister после завершения readPump.
+func (s *Shard) disconnectUser(userID string, reason string) int {
+	client := s.clientForUser(userID)
+	if client == nil || client.conn == nil {
+		return 0
+	}
+	log.Printf("Shard %d: closing connection of client %s (ConnID: %s), reason: %s", s.id, userID, client.ConnectionID, reason)
+	client.closeWithReason(websocket.ClosePolicyViolation, reason)
+	return 1
+}
+
+// SendToUser отправляет сообщение конкретному пользователю в шарде
+func (s *Shard) SendToUser(userID string, message []byte) bool {
+	client := s.clientForUser(userID)
+	if client == nil {
 		return false
 	}
 
 	select {
 	case client.send <- message:
+		client.noteQueued()
 		// Обновляем метрики
 		s.metrics.mu.Lock()
 		s.metrics.messagesSent++
@@ -526,7 +619,10 @@ func (s *Shard) SendToUser(userID string, message []byte) bool {
 	default:
 		// Буфер клиента переполнен, отключаем клиента
 		log.Printf("Shard %d: client %s buffer full on direct message, unregistering", s.id, userID)
+		client.noteDropped()
+		s.recordDroppedMessage()
 		s.clients.Delete(client)
+		s.notifyConnectionHook(false, client)
 
 		if existingClient, loaded := s.userMap.Load(client.UserID); loaded && existingClient == client {
 			s.userMap.Delete(client.UserID)
@@ -541,6 +637,7 @@ func (s *Shard) SendToUser(userID string, message []byte) bool {
 		s.metrics.mu.Lock()
 		s.metrics.activeConnections--
 		s.metrics.connectionErrors++
+		s.metrics.overflowDisconnects++
 		s.metrics.mu.Unlock()
 		return false
 	}
@@ -553,9 +650,17 @@ func (s *Shard) BroadcastBytes(message []byte) {
 		// Сообщение успешно отправлено в канал рассылки
 	default:
 		log.Printf("Shard %d: broadcast channel full, message dropped", s.id)
+		s.recordDroppedMessage()
 	}
 }
 
+// recordDroppedMessage учитывает недоставленное сообщение в метриках шарда
+func (s *Shard) recordDroppedMessage() {
+	s.metrics.mu.Lock()
+	s.metrics.messagesDropped++
+	s.metrics.mu.Unlock()
+}
+
 // BroadcastJSON рассылает JSON-сообщение всем клиентам в шарде
 func (s *Shard) BroadcastJSON(v interface{}) error {
 	data, err := json.Marshal(v)
@@ -575,6 +680,18 @@ func (s *Shard) GetMetrics() map[string]interface{} {
 	clientCount := s.GetClientCount()
 	loadPercentage := float64(clientCount) / float64(s.maxClients) * 100
 
+	// Максимальная занятость буфера отправки среди подключенных клиентов
+	highWater, highWaterPct := 0, 0.0
+	s.clients.Range(func(key, value interface{}) bool {
+		if client, ok := key.(*Client); ok {
+			if hw := client.SendBufferHighWater(); hw > highWater {
+				highWater = hw
+				highWaterPct = float64(hw) / float64(cap(client.send)) * 100
+			}
+		}
+		return true
+	})
+
 	return map[string]interface{}{
 		"shard_id":           s.id,
 		"active_connections": clientCount,
@@ -585,6 +702,14 @@ func (s *Shard) GetMetrics() map[string]interface{} {
 		"load_percentage":    loadPercentage,
 		"last_cleanup":       s.metrics.lastCleanupTime.Format(time.RFC3339),
 		"inactive_removed":   s.metrics.inactiveClientsRemoved,
+		"messages_dropped":   s.metrics.messagesDropped,
+		// Отключения из-за переполнения буфера считаются отдельно от удаления неактивных
+		"buffer_overflow_disconnects": s.metrics.overflowDisconnects,
+		"send_buffer_high_water":      highWater,
+		"send_buffer_high_water_pct":  highWaterPct,
+		"write_timeouts":              s.metrics.writeTimeouts,
+		"slow_writes":                 s.metrics.slowWrites,
+		"slow_consumer_disconnects":   s.metrics.slowConsumerDisconnects,
 	}
 }
 
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"hash/fnv"
 	"log"
+	"math"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -132,15 +133,50 @@ type ShardedHub struct {
 	// Пул воркеров для обработки задач
 	workerPool *WorkerPool
 
+	// Хук событий подключения и отключения клиентов
+	connectionHooks *connectionHooks
+
+	// Семафор одновременных рассылок по шардам (nil - без ограничения)
+	broadcastSlots chan struct{}
+
+	// Количество выполняемых рассылок и рассылок, ожидающих свободного слота
+	broadcastsInFlight atomic.Int64
+	broadcastsQueued   atomic.Int64
+
 	// Каналы для алертинга
 	alertChan chan AlertMessage
 
+	// Общее количество отправленных алертов
+	alertsTotal atomic.Int64
+
+	// Количество алертов, потерянных из-за переполнения буфера
+	alertsDropped atomic.Int64
+
+	// Поведение при переполнении буфера алертов
+	alertOverflowPolicy AlertOverflowPolicy
+
+	// Время ожидания места в буфере для политики AlertOverflowBlock
+	alertBlockTimeout time.Duration
+
 	// Функция для обработки алертов (может быть заменена пользователем)
 	alertHandler func(AlertMessage)
 
-	// Мьютекс для безопасной работы с alertHandler
+	// История алертов для разбора инцидентов (дополняется обработчиком по умолчанию)
+	alertStore AlertStore
+
+	// Мьютекс для безопасной работы с alertHandler и alertStore
 	alertMu sync.RWMutex
 
+	// Политика второго соединения пользователя и ожидание ответа при DuplicatePrompt
+	duplicatePolicy DuplicateConnectionPolicy
+	takeoverTimeout time.Duration
+
+	// За сколько до отключения по неактивности предупреждать клиента (0 - без предупреждения)
+	idleWarningGrace time.Duration
+
+	// Интервал сбора метрик шардов и проверки горячих шардов (0 - по умолчанию)
+	metricsInterval time.Duration
+
 	// Добавляем хранилище для информации о других узлах кластера
 	clusterPeers sync.Map // Ключ: InstanceID, Значение: map[string]interface{} (распарсенные метрики)
 }
@@ -160,6 +196,12 @@ const (
 
 	// AlertHighLatency сигнализирует о высокой задержке обработки сообщений
 	AlertHighLatency AlertType = "high_latency"
+
+	// AlertAnswerQueueSaturated сигнализирует о переполнении очереди обработки ответов
+	AlertAnswerQueueSaturated AlertType = "answer_queue_saturated"
+
+	// AlertClusterDegraded сигнализирует о потере соединения с брокером Pub/Sub кластера
+	AlertClusterDegraded AlertType = "cluster_degraded"
 )
 
 // AlertSeverity определяет уровень серьезности алерта
@@ -176,6 +218,20 @@ const (
 	AlertCritical AlertSeverity = "critical"
 )
 
+// AlertOverflowPolicy определяет поведение при переполнении буфера алертов
+type AlertOverflowPolicy string
+
+const (
+	// AlertOverflowDropNew отбрасывает новый алерт
+	AlertOverflowDropNew AlertOverflowPolicy = "drop_new"
+
+	// AlertOverflowDropOldest вытесняет самый старый алерт из буфера
+	AlertOverflowDropOldest AlertOverflowPolicy = "drop_oldest"
+
+	// AlertOverflowBlock ждет освобождения места ограниченное время, затем отбрасывает новый алерт
+	AlertOverflowBlock AlertOverflowPolicy = "block"
+)
+
 // AlertMessage представляет сообщение алерта
 type AlertMessage struct {
 	// Тип алерта
@@ -192,6 +248,9 @@ type AlertMessage struct {
 
 	// Время создания
 	Timestamp time.Time `json:"timestamp"`
+
+	// Экземпляр, отправивший алерт (заполняется в истории алертов в БД)
+	InstanceID string `json:"instance_id,omitempty"`
 }
 
 // Проверка компилятором, что ShardedHub реализует интерфейс HubInterface
@@ -213,6 +272,30 @@ func NewShardedHub(wsConfig config.WebSocketConfig, provider PubSubProvider) *Sh
 		log.Printf("[ShardedHub] Используется макс. клиентов на шард по умолчанию: %d", maxClientsPerShard)
 	}
 
+	alertBuffer := wsConfig.Alerts.Buffer
+	if alertBuffer <= 0 {
+		alertBuffer = 100 // Значение по умолчанию
+	}
+	overflowPolicy := AlertOverflowPolicy(wsConfig.Alerts.OverflowPolicy)
+	switch overflowPolicy {
+	case AlertOverflowDropNew, AlertOverflowDropOldest, AlertOverflowBlock:
+	default:
+		if overflowPolicy != "" {
+			log.Printf("[ShardedHub] Неизвестная политика переполнения алертов %q, используется %s", overflowPolicy, AlertOverflowDropNew)
+		}
+		overflowPolicy = AlertOverflowDropNew
+	}
+	blockTimeout := time.Duration(wsConfig.Alerts.BlockTimeoutMs) * time.Millisecond
+	if blockTimeout <= 0 {
+		blockTimeout = 50 * time.Millisecond
+	}
+
+	maxBroadcasts := wsConfig.Limits.MaxConcurrentBroadcasts
+	if maxBroadcasts <= 0 {
+		maxBroadcasts = defaultMaxConcurrentBroadcasts
+		log.Printf("[ShardedHub] Используется лимит одновременных рассылок по умолчанию: %d", maxBroadcasts)
+	}
+
 	metrics := NewHubMetrics()
 
 	// Создаем пул воркеров
@@ -220,16 +303,25 @@ func NewShardedHub(wsConfig config.WebSocketConfig, provider PubSubProvider) *Sh
 	workerPool.Start()
 
 	hub := &ShardedHub{
-		shardCount:         shardCount,
-		maxClientsPerShard: maxClientsPerShard,
-		metrics:            metrics,
-		done:               make(chan struct{}),
-		workerPool:         workerPool,
-		alertChan:          make(chan AlertMessage, 100),
+		shardCount:          shardCount,
+		maxClientsPerShard:  maxClientsPerShard,
+		metrics:             metrics,
+		done:                make(chan struct{}),
+		workerPool:          workerPool,
+		broadcastSlots:      make(chan struct{}, maxBroadcasts),
+		alertChan:           make(chan AlertMessage, alertBuffer),
+		alertOverflowPolicy: overflowPolicy,
+		alertBlockTimeout:   blockTimeout,
+		duplicatePolicy:     parseDuplicatePolicy(wsConfig.DuplicateConnections.Policy),
+		takeoverTimeout:     time.Duration(wsConfig.DuplicateConnections.TakeoverTimeoutMs) * time.Millisecond,
+		alertStore: NewMemoryAlertStore(wsConfig.Alerts.History.Size,
+			time.Duration(wsConfig.Alerts.History.MaxAgeHours)*time.Hour),
+		idleWarningGrace: time.Duration(wsConfig.Limits.IdleWarningGrace) * time.Second,
 	}
 
 	// Инициализируем обработчик алертов по умолчанию
 	hub.alertHandler = hub.defaultAlertHandler
+	hub.connectionHooks = newConnectionHooks(hub.done)
 
 	// Создаем шарды
 	hub.shards = make([]*Shard, shardCount)
@@ -241,9 +333,12 @@ func NewShardedHub(wsConfig config.WebSocketConfig, provider PubSubProvider) *Sh
 			cleanupInterval = 5 * time.Minute
 			log.Printf("[ShardedHub] Используется интервал очистки по умолчанию: %v", cleanupInterval)
 		}
-		// Считаем, что PongWait уже включает в себя необходимый запас времени
-		// и является подходящим таймаутом неактивности.
-		inactivityTimeout := time.Duration(wsConfig.Limits.PongWait) * time.Second
+		// Таймаут неактивности задается IdleTimeout, а без него - PongWait,
+		// который уже включает в себя необходимый запас времени
+		inactivityTimeout := time.Duration(wsConfig.Limits.IdleTimeout) * time.Second
+		if inactivityTimeout <= 0 {
+			inactivityTimeout = time.Duration(wsConfig.Limits.PongWait) * time.Second
+		}
 		if inactivityTimeout <= 0 {
 			// Устанавливаем значение по умолчанию, если не задано или некорректно
 			inactivityTimeout = 60 * time.Second
@@ -262,8 +357,11 @@ func NewShardedHub(wsConfig config.WebSocketConfig, provider PubSubProvider) *Sh
 	return hub
 }
 
-// defaultAlertHandler обрабатывает алерты по умолчанию - просто логирует их
+// defaultAlertHandler обрабатывает алерты по умолчанию: сохраняет их в истории
+// алертов и логирует
 func (h *ShardedHub) defaultAlertHandler(alert AlertMessage) {
+	h.recordAlert(alert)
+
 	switch alert.Severity {
 	case AlertCritical:
 		log.Printf("[КРИТИЧЕСКИЙ АЛЕРТ] %s: %s", alert.Type, alert.Message)
@@ -278,15 +376,25 @@ func (h *ShardedHub) defaultAlertHandler(alert AlertMessage) {
 	log.Printf("[АЛЕРТ ДЕТАЛИ] %s", string(metadataJson))
 }
 
-// SetAlertHandler устанавливает пользовательский обработчик алертов
+// SetAlertHandler устанавливает пользовательский обработчик алертов. Он
+// заменяет обработчик по умолчанию, поэтому алерты не попадают в историю.
 func (h *ShardedHub) SetAlertHandler(handler func(AlertMessage)) {
 	h.alertMu.Lock()
 	defer h.alertMu.Unlock()
 	h.alertHandler = handler
 }
 
-// SendAlert отправляет алерт
+// SetConnectionHook задает хук событий подключения и отключения клиентов.
+// nil возвращает хук по умолчанию, который ничего не делает.
+func (h *ShardedHub) SetConnectionHook(hook ConnectionHook) {
+	h.connectionHooks.set(hook)
+}
+
+// SendAlert отправляет алерт. При переполнении буфера поведение определяется
+// политикой alertOverflowPolicy; потерянные алерты учитываются в alertsDropped.
 func (h *ShardedHub) SendAlert(alertType AlertType, severity AlertSeverity, message string, metadata map[string]interface{}) {
+	h.alertsTotal.Add(1)
+
 	alert := AlertMessage{
 		Type:      alertType,
 		Severity:  severity,
@@ -298,10 +406,64 @@ func (h *ShardedHub) SendAlert(alertType AlertType, severity AlertSeverity, mess
 	// Отправляем неблокирующим способом
 	select {
 	case h.alertChan <- alert:
-		// Успешно отправлено
+		return
 	default:
-		// Буфер алертов переполнен, логируем это напрямую
-		log.Printf("[ПЕРЕПОЛНЕНИЕ БУФЕРА АЛЕРТОВ] %s: %s", alertType, message)
+	}
+
+	switch h.alertOverflowPolicy {
+	case AlertOverflowDropOldest:
+		// Освобождаем место, вытесняя самый старый алерт
+		select {
+		case oldest := <-h.alertChan:
+			h.dropAlert(oldest)
+		default:
+		}
+		select {
+		case h.alertChan <- alert:
+			return
+		default:
+		}
+
+	case AlertOverflowBlock:
+		timer := time.NewTimer(h.alertBlockTimeout)
+		defer timer.Stop()
+		select {
+		case h.alertChan <- alert:
+			return
+		case <-timer.C:
+		case <-h.done:
+		}
+	}
+
+	h.dropAlert(alert)
+}
+
+// dropAlert учитывает потерянный алерт. Критические алерты логируются
+// синхронно со всеми метаданными, чтобы они не терялись при перегрузке.
+func (h *ShardedHub) dropAlert(alert AlertMessage) {
+	h.alertsDropped.Add(1)
+
+	if alert.Severity != AlertCritical {
+		log.Printf("[ПЕРЕПОЛНЕНИЕ БУФЕРА АЛЕРТОВ] %s: %s", alert.Type, alert.Message)
+		return
+	}
+
+	data, err := json.Marshal(alert)
+	if err != nil {
+		log.Printf("[ПЕРЕПОЛНЕНИЕ БУФЕРА АЛЕРТОВ] [КРИТИЧЕСКИЙ АЛЕРТ] %s: %s (ошибка сериализации: %v)", alert.Type, alert.Message, err)
+		return
+	}
+	log.Printf("[ПЕРЕПОЛНЕНИЕ БУФЕРА АЛЕРТОВ] [КРИТИЧЕСКИЙ АЛЕРТ] %s", data)
+}
+
+// AlertStats возвращает статистику очереди алертов
+func (h *ShardedHub) AlertStats() map[string]interface{} {
+	return map[string]interface{}{
+		"alerts_total":          h.alertsTotal.Load(),
+		"alerts_dropped":        h.alertsDropped.Load(),
+		"alert_buffer_size":     cap(h.alertChan),
+		"alert_buffer_len":      len(h.alertChan),
+		"alert_overflow_policy": string(h.alertOverflowPolicy),
 	}
 }
 
@@ -315,8 +477,8 @@ func (h *ShardedHub) Run() {
 		go shard.Run()
 	}
 
-	// Запускаем сбор метрик
-	go h.collectMetrics()
+	// Запускаем периодический сбор метрик и проверку горячих шардов
+	go h.runMetricsCollector()
 
 	// Запускаем кластерный компонент
 	if err := h.cluster.Start(); err != nil {
@@ -346,6 +508,11 @@ func (h *ShardedHub) getShardID(userID string) int {
 	return int(hasher.Sum32() % uint32(h.shardCount))
 }
 
+// ShardIDForUser возвращает ID шарда, к которому относится пользователь
+func (h *ShardedHub) ShardIDForUser(userID string) int {
+	return h.getShardID(userID)
+}
+
 // getShard возвращает шард для указанного userID
 func (h *ShardedHub) getShard(userID string) *Shard {
 	shardID := h.getShardID(userID)
@@ -402,19 +569,80 @@ func (h *ShardedHub) BroadcastBytes(message []byte) {
 // BroadcastBytesLocal отправляет байтовое сообщение всем локальным шардам через worker pool.
 // Этот метод используется для внутренней локальной рассылки.
 func (h *ShardedHub) BroadcastBytesLocal(message []byte) {
+	if !h.acquireBroadcastSlot() {
+		return
+	}
+	if len(h.shards) == 0 {
+		h.releaseBroadcastSlot()
+		return
+	}
+
+	// Слот освобождает задача, последней передавшая сообщение своему шарду
+	var pending atomic.Int32
+	pending.Store(int32(len(h.shards)))
+	shardDone := func() {
+		if pending.Add(-1) == 0 {
+			h.releaseBroadcastSlot()
+		}
+	}
+
 	// Используем пул воркеров для асинхронной отправки сообщения каждому шарду
 	for _, shard := range h.shards {
 		// Захватываем переменную shard для замыкания
 		currentShard := shard
 		success := h.workerPool.Submit(func() {
+			defer shardDone()
 			// Отправляем сообщение в канал broadcast конкретного шарда
 			// Shard.Run() обработает это сообщение и разошлет клиентам
 			currentShard.broadcast <- message
 		})
 		if !success {
 			log.Printf("[ShardedHub] Пул воркеров переполнен, broadcast сообщение для шарда %d может быть потеряно.", currentShard.id)
+			shardDone()
+		}
+	}
+}
+
+// defaultMaxConcurrentBroadcasts - лимит одновременных рассылок, если он не задан в конфигурации
+const defaultMaxConcurrentBroadcasts = 16
+
+// acquireBroadcastSlot занимает слот рассылки. Если все слоты заняты, вызывающий
+// ждет освобождения: при всплеске событий рассылки выстраиваются в очередь,
+// а не порождают все новые горутины. Возвращает false, если хаб остановлен.
+func (h *ShardedHub) acquireBroadcastSlot() bool {
+	if h.broadcastSlots != nil {
+		select {
+		case h.broadcastSlots <- struct{}{}:
+		default:
+			h.broadcastsQueued.Add(1)
+			select {
+			case h.broadcastSlots <- struct{}{}:
+				h.broadcastsQueued.Add(-1)
+			case <-h.done:
+				h.broadcastsQueued.Add(-1)
+				return false
+			}
 		}
 	}
+	h.broadcastsInFlight.Add(1)
+	return true
+}
+
+// releaseBroadcastSlot освобождает слот, занятый acquireBroadcastSlot
+func (h *ShardedHub) releaseBroadcastSlot() {
+	h.broadcastsInFlight.Add(-1)
+	if h.broadcastSlots != nil {
+		<-h.broadcastSlots
+	}
+}
+
+// BroadcastStats возвращает состояние ограничения одновременных рассылок
+func (h *ShardedHub) BroadcastStats() map[string]interface{} {
+	return map[string]interface{}{
+		"broadcasts_in_flight":        h.broadcastsInFlight.Load(),
+		"broadcasts_queued":           h.broadcastsQueued.Load(),
+		"broadcast_concurrency_limit": cap(h.broadcastSlots),
+	}
 }
 
 // BroadcastJSON сериализует объект в JSON и отправляет его всем клиентам.
@@ -460,9 +688,116 @@ func (h *ShardedHub) SendJSONToUser(userID string, v interface{}) error {
 	return nil
 }
 
+// SendJSONToUserExcept отправляет JSON структуру пользователю, пропуская соединение
+// excludeConnectionID. У пользователя одно соединение на экземпляр, поэтому если
+// исключенное соединение локальное, событие уходит только на другие экземпляры кластера.
+func (h *ShardedHub) SendJSONToUserExcept(userID string, v interface{}, excludeConnectionID string) error {
+	if excludeConnectionID == "" {
+		return h.SendJSONToUser(userID, v)
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	if client := h.getShard(userID).clientForUser(userID); client != nil && client.ConnectionID == excludeConnectionID {
+		if h.cluster != nil {
+			go func() {
+				if err := h.cluster.SendToUserInCluster(userID, data); err != nil {
+					log.Printf("ShardedHub: ошибка отправки сообщения пользователю %s через кластер: %v", userID, err)
+				}
+			}()
+		}
+		return nil
+	}
+
+	h.SendToUser(userID, data)
+	return nil
+}
+
+// DisconnectUser закрывает соединение пользователя на этом экземпляре и, в кластерном
+// режиме, на остальных экземплярах
+func (h *ShardedHub) DisconnectUser(userID string, reason string) int {
+	closed := h.DisconnectUserLocal(userID, reason)
+	if h.cluster != nil {
+		go func() {
+			if err := h.cluster.DisconnectUserInCluster(userID, reason); err != nil {
+				log.Printf("ShardedHub: ошибка передачи отключения пользователя %s в кластер: %v", userID, err)
+			}
+		}()
+	}
+	return closed
+}
+
+// DisconnectUserLocal закрывает соединение пользователя только на этом экземпляре
+func (h *ShardedHub) DisconnectUserLocal(userID string, reason string) int {
+	return h.getShard(userID).disconnectUser(userID, reason)
+}
+
+// HasNewerConnection сообщает, заменено ли соединение client более новым
+// соединением того же пользователя на этом экземпляре (переподключение)
+func (h *ShardedHub) HasNewerConnection(client *Client) bool {
+	return h.getShard(client.UserID).hasNewerConnection(client)
+}
+
+// UnsubscribeUserFromQuiz отписывает соединение пользователя от указанной
+// викторины на этом экземпляре и, в кластерном режиме, на остальных экземплярах.
+// Соединение остается открытым. Возвращает true, если соединение было отписано
+// на этом экземпляре.
+func (h *ShardedHub) UnsubscribeUserFromQuiz(userID string, quizID uint) bool {
+	unsubscribed := h.UnsubscribeUserFromQuizLocal(userID, quizID)
+	if h.cluster != nil {
+		go func() {
+			if err := h.cluster.UnsubscribeUserFromQuizInCluster(userID, quizID); err != nil {
+				log.Printf("ShardedHub: ошибка передачи отписки пользователя %s от викторины %d в кластер: %v", userID, quizID, err)
+			}
+		}()
+	}
+	return unsubscribed
+}
+
+// UnsubscribeUserFromQuizLocal отписывает соединение пользователя от викторины
+// только на этом экземпляре. Возвращает false, если пользователь не подключен
+// или подписан на другую викторину.
+func (h *ShardedHub) UnsubscribeUserFromQuizLocal(userID string, quizID uint) bool {
+	shard := h.getShard(userID)
+	client := shard.clientForUser(userID)
+	if client == nil || client.GetQuizID() != quizID {
+		return false
+	}
+	shard.UnsubscribeFromQuiz(client)
+	return true
+}
+
+// UserConnectionCount возвращает число соединений пользователя на этом экземпляре
+func (h *ShardedHub) UserConnectionCount(userID string) int {
+	if h.getShard(userID).clientForUser(userID) != nil {
+		return 1
+	}
+	return 0
+}
+
+// ClusterEnabled сообщает, работает ли хаб в кластерном режиме
+func (h *ShardedHub) ClusterEnabled() bool {
+	return h.cluster != nil && h.cluster.config.Enabled
+}
+
 // BroadcastToQuiz отправляет сообщение всем клиентам указанной викторины во всех шардах.
 func (h *ShardedHub) BroadcastToQuiz(quizID uint, message []byte) {
+	h.BroadcastToQuizEncoded(quizID, message, nil)
+}
+
+// BroadcastToQuizEncoded рассылает событие викторины в двух представлениях:
+// JSON для обычных клиентов и бинарный кадр (если не nil) для клиентов,
+// согласовавших BinaryProtocolName. Событие кодируется один раз на рассылку.
+func (h *ShardedHub) BroadcastToQuizEncoded(quizID uint, message, binaryMessage []byte) {
 	log.Printf("ShardedHub: Broadcasting message to Quiz %d across all shards", quizID)
+	if !h.acquireBroadcastSlot() {
+		return
+	}
+	defer h.releaseBroadcastSlot()
+
 	// Используем пул воркеров для параллельной рассылки по шардам
 	var wg sync.WaitGroup
 	wg.Add(h.shardCount)
@@ -472,13 +807,13 @@ func (h *ShardedHub) BroadcastToQuiz(quizID uint, message []byte) {
 		currentShard := shard // Захватываем переменную для горутины
 		success := h.workerPool.Submit(func() {
 			defer wg.Done()
-			currentShard.BroadcastToQuiz(quizID, message)
+			currentShard.broadcastToQuiz(quizID, message, binaryMessage)
 		})
 		if !success {
 			// Если пул переполнен, выполняем синхронно и логируем
 			log.Printf("ShardedHub: Worker pool full, broadcasting to quiz %d in shard %d synchronously", quizID, currentShard.id)
 			wg.Done() // Уменьшаем счетчик, так как горутина не будет запущена
-			currentShard.BroadcastToQuiz(quizID, message)
+			currentShard.broadcastToQuiz(quizID, message, binaryMessage)
 		}
 	}
 
@@ -486,6 +821,15 @@ func (h *ShardedHub) BroadcastToQuiz(quizID uint, message []byte) {
 	log.Printf("ShardedHub: Finished broadcasting to Quiz %d", quizID)
 }
 
+// QuizSubscriberIDs возвращает ID пользователей, подписанных на викторину на этом экземпляре
+func (h *ShardedHub) QuizSubscriberIDs(quizID uint) []string {
+	var userIDs []string
+	for _, shard := range h.shards {
+		userIDs = append(userIDs, shard.quizSubscriberIDs(quizID)...)
+	}
+	return userIDs
+}
+
 // ClientCount возвращает общее количество подключенных клиентов
 // Совместимость с интерфейсом старого Hub
 func (h *ShardedHub) ClientCount() int {
@@ -498,7 +842,9 @@ func (h *ShardedHub) ClientCount() int {
 
 // GetMetrics возвращает основные метрики хаба
 func (h *ShardedHub) GetMetrics() map[string]interface{} {
-	return h.metrics.GetBasicMetrics()
+	metrics := h.metrics.GetBasicMetrics()
+	metrics["broadcasts_in_flight"] = h.broadcastsInFlight.Load()
+	return metrics
 }
 
 // GetDetailedMetrics возвращает расширенные метрики хаба, включая шарды и пиры кластера
@@ -512,6 +858,32 @@ func (h *ShardedHub) GetDetailedMetrics() map[string]interface{} {
 	}
 	allMetrics["shards"] = shardMetrics
 
+	// Итоги по шардам: отключения из-за переполнения буфера отдельно от удаления неактивных
+	var overflowDisconnects, inactiveRemoved, writeTimeouts, slowConsumerDisconnects int64
+	highWater := 0
+	for _, m := range shardMetrics {
+		if v, ok := m["buffer_overflow_disconnects"].(int64); ok {
+			overflowDisconnects += v
+		}
+		if v, ok := m["write_timeouts"].(int64); ok {
+			writeTimeouts += v
+		}
+		if v, ok := m["slow_consumer_disconnects"].(int64); ok {
+			slowConsumerDisconnects += v
+		}
+		if v, ok := m["inactive_removed"].(int64); ok {
+			inactiveRemoved += v
+		}
+		if v, ok := m["send_buffer_high_water"].(int); ok && v > highWater {
+			highWater = v
+		}
+	}
+	allMetrics["buffer_overflow_disconnects"] = overflowDisconnects
+	allMetrics["shard_inactive_removed"] = inactiveRemoved
+	allMetrics["send_buffer_high_water"] = highWater
+	allMetrics["write_timeouts"] = writeTimeouts
+	allMetrics["slow_consumer_disconnects"] = slowConsumerDisconnects
+
 	// Добавляем информацию о пирах кластера
 	peerMetrics := make(map[string]interface{})
 	h.clusterPeers.Range(func(key, value interface{}) bool {
@@ -521,103 +893,174 @@ func (h *ShardedHub) GetDetailedMetrics() map[string]interface{} {
 	})
 	allMetrics["cluster_peers"] = peerMetrics
 
+	for key, value := range h.AlertStats() {
+		allMetrics[key] = value
+	}
+	for key, value := range h.BroadcastStats() {
+		allMetrics[key] = value
+	}
+	allMetrics["connection_hook_events_dropped"] = h.connectionHooks.dropped.Load()
+
+	allMetrics["configured_shard_count"] = h.shardCount
+	allMetrics["recommended_shard_count"] = h.RecommendedShardCount()
+
 	return allMetrics
 }
 
-// collectMetrics периодически собирает метрики со всех шардов
-func (h *ShardedHub) collectMetrics() {
-	log.Println("ShardedHub: сбор метрик")
+// shardTargetLoad - целевая загрузка шарда при расчете рекомендуемого количества шардов.
+// Совпадает с порогом, после которого шард считается "горячим".
+const shardTargetLoad = 0.75
+
+// RecommendedShardCount рассчитывает количество шардов, при котором текущее или
+// пиковое (большее из них) число подключений укладывается в целевую загрузку
+// шардов с учетом MaxClientsPerShard. Хаб не меняет количество шардов сам -
+// значение используется только в метриках и алертах, чтобы подсказать,
+// насколько нужно увеличить ShardCount в конфигурации.
+func (h *ShardedHub) RecommendedShardCount() int {
+	connections := int64(h.ClientCount())
+	if peak := h.metrics.PeakConnections(); peak > connections {
+		connections = peak
+	}
 
-	// Создаем метрики для всех шардов
-	shardMetrics := make([]map[string]interface{}, h.shardCount)
-	hotShards := make([]int, 0)
-	totalConnections := int64(0)
-	maxLoad := float64(0)
-	maxLoadShardID := -1
+	capacity := float64(h.maxClientsPerShard) * shardTargetLoad
+	if connections == 0 || capacity <= 0 {
+		return 1
+	}
+
+	return int(math.Ceil(float64(connections) / capacity))
+}
+
+// metricsCollection содержит результат одного сбора метрик шардов
+type metricsCollection struct {
+	shardMetrics     []map[string]interface{}
+	hotShards        []int
+	totalConnections int64
+	maxLoad          float64
+	maxLoadShardID   int
+}
+
+// hotShardLoad - загрузка шарда в процентах, начиная с которой он считается горячим
+const hotShardLoad = 75
+
+// defaultMetricsCollectInterval - интервал проверки нагрузки шардов
+const defaultMetricsCollectInterval = 30 * time.Second
+
+// runMetricsCollector периодически собирает метрики шардов и отправляет алерты
+// о перегрузке независимо от сохранения истории метрик
+func (h *ShardedHub) runMetricsCollector() {
+	interval := h.metricsInterval
+	if interval <= 0 {
+		interval = defaultMetricsCollectInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	h.collectMetrics()
+	for {
+		select {
+		case <-h.done:
+			return
+		case <-ticker.C:
+			h.collectMetrics()
+		}
+	}
+}
+
+// gatherMetrics собирает метрики со всех шардов и обновляет метрики хаба
+func (h *ShardedHub) gatherMetrics() *metricsCollection {
+	collection := &metricsCollection{
+		shardMetrics:   make([]map[string]interface{}, h.shardCount),
+		hotShards:      make([]int, 0),
+		maxLoadShardID: -1,
+	}
 
-	// Собираем метрики со всех шардов
 	for i, shard := range h.shards {
 		metrics := shard.GetMetrics()
-		shardMetrics[i] = metrics
+		collection.shardMetrics[i] = metrics
 
-		// Обновляем общее количество соединений
 		if connections, ok := metrics["active_connections"].(int); ok {
-			totalConnections += int64(connections)
+			collection.totalConnections += int64(connections)
 		}
-
-		// Проверяем нагрузку шарда
 		if loadPercentage, ok := metrics["load_percentage"].(float64); ok {
-			if loadPercentage > maxLoad {
-				maxLoad = loadPercentage
-				maxLoadShardID = i
+			if loadPercentage > collection.maxLoad {
+				collection.maxLoad = loadPercentage
+				collection.maxLoadShardID = i
+			}
+			if loadPercentage > hotShardLoad {
+				collection.hotShards = append(collection.hotShards, i)
 			}
+		}
+	}
 
-			// Определяем "горячие" шарды
-			if loadPercentage > 75 {
-				hotShards = append(hotShards, i)
+	h.metrics.SetActiveConnections(collection.totalConnections)
+	h.metrics.UpdateShardMetrics(collection.shardMetrics)
+	return collection
+}
 
-				// Отправляем алерт для "горячего" шарда
-				severity := AlertWarning
-				if loadPercentage > 90 {
-					severity = AlertCritical
-				}
+// collectMetrics собирает метрики со всех шардов, обновляет метрики хаба
+// и отправляет алерты о перегруженных шардах
+func (h *ShardedHub) collectMetrics() *metricsCollection {
+	log.Println("ShardedHub: сбор метрик")
 
-				h.SendAlert(AlertHotShard, severity,
-					fmt.Sprintf("Обнаружен горячий шард %d с загрузкой %.2f%%", i, loadPercentage),
-					map[string]interface{}{
-						"shard_id":           i,
-						"load_percentage":    loadPercentage,
-						"active_connections": metrics["active_connections"],
-						"max_clients":        metrics["max_clients"],
-					})
+	collection := h.gatherMetrics()
+	for i, metrics := range collection.shardMetrics {
+		// Отправляем алерт для "горячего" шарда
+		if loadPercentage, ok := metrics["load_percentage"].(float64); ok && loadPercentage > hotShardLoad {
+			severity := AlertWarning
+			if loadPercentage > 90 {
+				severity = AlertCritical
 			}
 
-			// Проверяем статистику отключений, если доступна
-			if disconnectionStats, ok := metrics["disconnection_stats"].(map[string]interface{}); ok {
-				if bufferAlert, ok := disconnectionStats["buffer_alert_triggered"].(bool); ok && bufferAlert {
-					h.SendAlert(AlertBufferOverflow, AlertCritical,
-						fmt.Sprintf("Переполнение буфера отключений в шарде %d", i),
-						map[string]interface{}{
-							"shard_id":            i,
-							"disconnection_stats": disconnectionStats,
-						})
-				}
+			h.SendAlert(AlertHotShard, severity,
+				fmt.Sprintf("Обнаружен горячий шард %d с загрузкой %.2f%%", i, loadPercentage),
+				map[string]interface{}{
+					"shard_id":           i,
+					"load_percentage":    loadPercentage,
+					"active_connections": metrics["active_connections"],
+					"max_clients":        metrics["max_clients"],
+					"shard_count":        h.shardCount,
+				})
+		}
+
+		// Проверяем статистику отключений, если доступна
+		if disconnectionStats, ok := metrics["disconnection_stats"].(map[string]interface{}); ok {
+			if bufferAlert, ok := disconnectionStats["buffer_alert_triggered"].(bool); ok && bufferAlert {
+				h.SendAlert(AlertBufferOverflow, AlertCritical,
+					fmt.Sprintf("Переполнение буфера отключений в шарде %d", i),
+					map[string]interface{}{
+						"shard_id":            i,
+						"disconnection_stats": disconnectionStats,
+					})
 			}
 		}
 	}
 
-	// Обновляем метрики хаба
-	h.metrics.mu.Lock()
-	h.metrics.activeConnections = totalConnections
-	h.metrics.UpdateShardMetrics(shardMetrics)
-	h.metrics.mu.Unlock()
-
-	// Проверяем, нужна ли балансировка
+	hotShards := collection.hotShards
 	if len(hotShards) > 0 {
 		log.Printf("ShardedHub: обнаружены горячие шарды: %v", hotShards)
 
+		recommendedShards := h.RecommendedShardCount()
+		message := fmt.Sprintf("Обнаружено %d горячих шардов, максимальная нагрузка %.2f%% (шард %d)",
+			len(hotShards), collection.maxLoad, collection.maxLoadShardID)
+		if recommendedShards > h.shardCount {
+			message += fmt.Sprintf("; настроенного количества шардов недостаточно, рекомендуется увеличить ShardCount с %d до %d",
+				h.shardCount, recommendedShards)
+		}
+
 		// Отправляем общий алерт о "горячих" шардах
-		h.SendAlert(AlertHotShard, AlertWarning,
-			fmt.Sprintf("Обнаружено %d горячих шардов, максимальная нагрузка %.2f%% (шард %d)",
-				len(hotShards), maxLoad, maxLoadShardID),
+		h.SendAlert(AlertHotShard, AlertWarning, message,
 			map[string]interface{}{
-				"hot_shards":        hotShards,
-				"max_load":          maxLoad,
-				"max_load_shard":    maxLoadShardID,
-				"total_connections": totalConnections,
+				"hot_shards":              hotShards,
+				"max_load":                collection.maxLoad,
+				"max_load_shard":          collection.maxLoadShardID,
+				"total_connections":       collection.totalConnections,
+				"peak_connections":        h.metrics.PeakConnections(),
+				"shard_count":             h.shardCount,
+				"recommended_shard_count": recommendedShards,
 			})
-
-		// Запуск балансировки удален
-		/*
-			if maxLoad > 95 && len(hotShards) > h.shardCount/4 {
-				log.Printf("ShardedHub: инициирована экстренная балансировка шардов")
-				h.workerPool.Submit(func() {
-					migratedCount := h.BalanceShards()
-					log.Printf("ShardedHub: экстренная балансировка завершена, мигрировано %d клиентов", migratedCount)
-				})
-			}
-		*/
 	}
+
+	return collection
 }
 
 // Close закрывает все шарды и освобождает ресурсы
@@ -649,6 +1092,10 @@ func (h *ShardedHub) Close() {
 // с дополнительными гарантиями доставки
 func (h *ShardedHub) BroadcastPrioritized(message []byte) error {
 	log.Printf("ShardedHub: рассылка высокоприоритетного сообщения")
+	if !h.acquireBroadcastSlot() {
+		return fmt.Errorf("hub is stopped")
+	}
+	defer h.releaseBroadcastSlot()
 
 	// Создаем WaitGroup для ожидания завершения отправки во все шарды
 	var wg sync.WaitGroup
@@ -685,6 +1132,7 @@ func (h *ShardedHub) BroadcastPrioritized(message []byte) error {
 					select {
 					case client.send <- message:
 						clientCount++
+						client.noteQueued()
 					case <-time.After(500 * time.Millisecond):
 						// Если буфер клиента переполнен и не освобождается, обрабатываем ошибку
 						log.Printf("Shard %d: не удалось отправить приоритетное сообщение клиенту %s",
@@ -705,12 +1153,11 @@ func (h *ShardedHub) BroadcastPrioritized(message []byte) error {
 				}
 			}
 		}) {
-			// Если пул воркеров переполнен, выполняем задачу напрямую
+			// Если пул воркеров переполнен, выполняем задачу синхронно,
+			// не запуская дополнительных горутин
 			log.Printf("ShardedHub: пул воркеров переполнен, выполняем задачу напрямую для шарда %d", shard.id)
-			go func(s *Shard) {
-				defer wg.Done()
-				s.BroadcastBytes(message)
-			}(shard)
+			shard.BroadcastBytes(message)
+			wg.Done()
 		}
 	}
 
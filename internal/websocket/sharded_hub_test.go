@@ -0,0 +1,393 @@
+package websocket
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourusername/trivia-api/internal/config"
+)
+
+func TestShardedHub_RecommendedShardCount(t *testing.T) {
+	hub := &ShardedHub{
+		shardCount:         4,
+		maxClientsPerShard: 1000,
+		metrics:            NewHubMetrics(),
+	}
+
+	// Без подключений достаточно одного шарда
+	assert.Equal(t, 1, hub.RecommendedShardCount())
+
+	// 3000 подключений при целевой загрузке 75% от 1000 - ровно 4 шарда
+	hub.metrics.SetActiveConnections(3000)
+	assert.Equal(t, 4, hub.RecommendedShardCount())
+
+	// Пик выше возможностей конфигурации
+	hub.metrics.SetActiveConnections(6100)
+	assert.Equal(t, 9, hub.RecommendedShardCount())
+
+	// После спада рекомендация опирается на пиковое значение
+	hub.metrics.SetActiveConnections(100)
+	assert.Equal(t, int64(6100), hub.metrics.PeakConnections())
+	assert.Equal(t, 9, hub.RecommendedShardCount())
+}
+
+// newAlertTestHub создает хаб без обработчика алертов, чтобы буфер заполнялся
+func newAlertTestHub(policy AlertOverflowPolicy) *ShardedHub {
+	return &ShardedHub{
+		alertChan:           make(chan AlertMessage, 2),
+		alertOverflowPolicy: policy,
+		alertBlockTimeout:   10 * time.Millisecond,
+		done:                make(chan struct{}),
+	}
+}
+
+// drainAlerts возвращает сообщения алертов, оставшихся в буфере
+func drainAlerts(hub *ShardedHub) []string {
+	var messages []string
+	for len(hub.alertChan) > 0 {
+		messages = append(messages, (<-hub.alertChan).Message)
+	}
+	return messages
+}
+
+func TestShardedHub_SendAlertOverflowPolicies(t *testing.T) {
+	tests := []struct {
+		policy   AlertOverflowPolicy
+		expected []string
+	}{
+		{AlertOverflowDropNew, []string{"1", "2"}},
+		{AlertOverflowDropOldest, []string{"2", "3"}},
+		{AlertOverflowBlock, []string{"1", "2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			hub := newAlertTestHub(tt.policy)
+
+			for _, message := range []string{"1", "2", "3"} {
+				hub.SendAlert(AlertHotShard, AlertCritical, message, nil)
+			}
+
+			stats := hub.AlertStats()
+			assert.Equal(t, int64(3), stats["alerts_total"])
+			assert.Equal(t, int64(1), stats["alerts_dropped"])
+			assert.Equal(t, tt.expected, drainAlerts(hub))
+		})
+	}
+}
+
+func TestShardedHub_SendAlertBlockWaitsForSpace(t *testing.T) {
+	hub := newAlertTestHub(AlertOverflowBlock)
+	hub.alertBlockTimeout = time.Second
+
+	hub.SendAlert(AlertHotShard, AlertWarning, "1", nil)
+	hub.SendAlert(AlertHotShard, AlertWarning, "2", nil)
+
+	// Обработчик освобождает место, пока SendAlert ожидает
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		<-hub.alertChan
+	}()
+	hub.SendAlert(AlertHotShard, AlertWarning, "3", nil)
+
+	assert.Zero(t, hub.alertsDropped.Load())
+	assert.Equal(t, []string{"2", "3"}, drainAlerts(hub))
+}
+
+func TestShardedHub_SendJSONToUserExceptSkipsInitiator(t *testing.T) {
+	shard := NewShard(0, nil, 10, 0, 0) // Без фоновой очистки
+	hub := &ShardedHub{shards: []*Shard{shard}, shardCount: 1}
+
+	client := NewClient(hub, nil, "7")
+	shard.userMap.Store("7", client)
+
+	event := map[string]interface{}{"event": "logout_all_devices"}
+
+	assert.NoError(t, hub.SendJSONToUserExcept("7", event, client.ConnectionID))
+	assert.Empty(t, client.send, "соединение-инициатор не должно получить событие")
+
+	assert.NoError(t, hub.SendJSONToUserExcept("7", event, "other-connection"))
+	assert.Len(t, client.send, 1)
+
+	assert.NoError(t, hub.SendJSONToUserExcept("7", event, ""))
+	assert.Len(t, client.send, 2, "без исключения событие отправляется как обычно")
+}
+
+// TestShard_ReconnectReplacesUserConnection: после переподключения адресные
+// события идут в новое соединение, а старое считается замененным
+func TestShard_ReconnectReplacesUserConnection(t *testing.T) {
+	shard := NewShard(0, nil, 10, 0, 0) // Без фоновой очистки
+	hub := &ShardedHub{shards: []*Shard{shard}, shardCount: 1}
+
+	oldClient := NewClient(hub, nil, "7")
+	shard.handleRegister(oldClient)
+	assert.False(t, hub.HasNewerConnection(oldClient))
+
+	newClient := NewClient(hub, nil, "7")
+	shard.handleRegister(newClient)
+	assert.True(t, hub.HasNewerConnection(oldClient), "закрытие старого соединения - не уход игрока")
+	assert.False(t, hub.HasNewerConnection(newClient))
+
+	assert.True(t, shard.SendToUser("7", []byte("answer_result")))
+	assert.Len(t, newClient.send, 1)
+
+	// Старое соединение закрывается с задержкой и не уносит с собой новое
+	assert.Eventually(t, func() bool {
+		_, stored := shard.clients.Load(oldClient)
+		return !stored
+	}, 2*time.Second, 20*time.Millisecond)
+	assert.Same(t, newClient, shard.clientForUser("7"))
+}
+
+func TestShard_SendBufferHighWaterAndOverflowMetrics(t *testing.T) {
+	shard := NewShard(0, nil, 10, 0, 0) // Без фоновой очистки
+	client := NewClientWithConfig(nil, nil, "7", ClientConfig{BufferSize: 3})
+	shard.clients.Store(client, true)
+	shard.userMap.Store("7", client)
+
+	assert.True(t, shard.SendToUser("7", []byte("1")))
+	assert.True(t, shard.SendToUser("7", []byte("2")))
+	<-client.send
+	assert.True(t, shard.SendToUser("7", []byte("3")))
+	assert.Equal(t, 2, client.SendBufferHighWater(), "максимум сохраняется после разгрузки буфера")
+
+	metrics := shard.GetMetrics()
+	assert.Equal(t, 2, metrics["send_buffer_high_water"])
+	assert.InDelta(t, 66.6, metrics["send_buffer_high_water_pct"], 0.1)
+
+	assert.True(t, shard.SendToUser("7", []byte("4")))
+	assert.False(t, shard.SendToUser("7", []byte("5")), "переполненный буфер отключает клиента")
+
+	metrics = shard.GetMetrics()
+	assert.Equal(t, int64(1), metrics["buffer_overflow_disconnects"])
+	assert.Equal(t, int64(0), metrics["inactive_removed"])
+}
+
+func TestShardedHub_DisconnectUserClosesWithReason(t *testing.T) {
+	shard := NewShard(0, nil, 10, 0, 0) // Без фоновой очистки
+	hub := &ShardedHub{shards: []*Shard{shard}, shardCount: 1}
+
+	registered := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+		require.NoError(t, err)
+		shard.userMap.Store("7", NewClient(hub, conn, "7"))
+		close(registered)
+	}))
+	defer server.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	require.NoError(t, err)
+	defer conn.Close()
+	<-registered
+
+	assert.Zero(t, hub.DisconnectUser("8", "session_revoked"), "у другого пользователя нет соединения")
+	assert.Equal(t, 1, hub.DisconnectUser("7", "session_revoked"))
+
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	_, _, err = conn.ReadMessage()
+	var closeErr *websocket.CloseError
+	require.ErrorAs(t, err, &closeErr)
+	assert.Equal(t, websocket.ClosePolicyViolation, closeErr.Code)
+	assert.Equal(t, "session_revoked", closeErr.Text)
+}
+
+func TestShardedHub_BroadcastConcurrencyLimit(t *testing.T) {
+	hub := &ShardedHub{
+		broadcastSlots: make(chan struct{}, 1),
+		done:           make(chan struct{}),
+	}
+
+	// Единственный слот занят - следующая рассылка ждет в очереди
+	require.True(t, hub.acquireBroadcastSlot())
+	finished := make(chan struct{})
+	go func() {
+		hub.BroadcastToQuiz(1, []byte(`{}`))
+		close(finished)
+	}()
+
+	require.Eventually(t, func() bool { return hub.broadcastsQueued.Load() == 1 }, time.Second, 5*time.Millisecond)
+	stats := hub.BroadcastStats()
+	assert.Equal(t, int64(1), stats["broadcasts_in_flight"])
+	assert.Equal(t, int64(1), stats["broadcasts_queued"])
+	assert.Equal(t, 1, stats["broadcast_concurrency_limit"])
+
+	hub.releaseBroadcastSlot()
+	select {
+	case <-finished:
+	case <-time.After(time.Second):
+		t.Fatal("рассылка не получила освободившийся слот")
+	}
+	assert.Equal(t, int64(0), hub.broadcastsInFlight.Load())
+	assert.Equal(t, int64(0), hub.broadcastsQueued.Load())
+
+	// Остановка хаба снимает ожидающие рассылки
+	require.True(t, hub.acquireBroadcastSlot())
+	close(hub.done)
+	assert.Error(t, hub.BroadcastPrioritized([]byte(`{}`)))
+	assert.Equal(t, int64(0), hub.broadcastsQueued.Load())
+}
+
+func TestShardedHub_BroadcastBytesLocalReleasesSlot(t *testing.T) {
+	hub := NewShardedHub(config.WebSocketConfig{
+		Sharding: config.ShardingConfig{ShardCount: 2},
+		Limits:   config.LimitsConfig{MaxConcurrentBroadcasts: 1},
+	}, nil)
+	defer hub.Close()
+	assert.Equal(t, 1, hub.BroadcastStats()["broadcast_concurrency_limit"])
+
+	// Слот освобождается после передачи сообщения всем шардам
+	hub.BroadcastBytesLocal([]byte(`{"type":"ping"}`))
+	hub.BroadcastBytesLocal([]byte(`{"type":"ping"}`))
+	require.Eventually(t, func() bool { return hub.broadcastsInFlight.Load() == 0 }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, int64(0), hub.GetMetrics()["broadcasts_in_flight"])
+}
+
+// recordingConnectionHook запоминает события подключения
+type recordingConnectionHook struct {
+	events chan string
+	last   chan ConnectionEvent
+}
+
+func (h *recordingConnectionHook) OnConnect(event ConnectionEvent) {
+	h.events <- "connect:" + event.UserID
+	h.last <- event
+}
+
+func (h *recordingConnectionHook) OnDisconnect(event ConnectionEvent) {
+	h.events <- "disconnect:" + event.UserID
+	h.last <- event
+}
+
+func TestShardedHub_ConnectionHook(t *testing.T) {
+	hub := NewShardedHub(config.WebSocketConfig{Sharding: config.ShardingConfig{ShardCount: 2}}, nil)
+	defer hub.Close()
+	hook := &recordingConnectionHook{events: make(chan string, 4), last: make(chan ConnectionEvent, 4)}
+	NewManager(hub).SetConnectionHook(hook)
+
+	client := NewClient(hub, nil, "7")
+	registered := make(chan struct{}, 1)
+	hub.RegisterSync(client, registered)
+	<-registered
+	hub.UnregisterClient(client)
+
+	for _, want := range []string{"connect:7", "disconnect:7"} {
+		select {
+		case got := <-hook.events:
+			assert.Equal(t, want, got)
+		case <-time.After(time.Second):
+			t.Fatalf("хук не получил событие %s", want)
+		}
+		event := <-hook.last
+		assert.Equal(t, client.ConnectionID, event.ConnectionID)
+		assert.Equal(t, hub.ShardIDForUser("7"), event.ShardID)
+		assert.False(t, event.Timestamp.IsZero())
+	}
+}
+
+func TestShardedHub_TopConnections(t *testing.T) {
+	shards := []*Shard{NewShard(0, nil, 10, 0, 0), NewShard(1, nil, 10, 0, 0)} // Без фоновой очистки
+	hub := &ShardedHub{shards: shards, shardCount: 2}
+
+	busy := NewClientWithConfig(nil, nil, "1", ClientConfig{BufferSize: 2})
+	quiet := NewClient(nil, nil, "2")
+	other := NewClient(nil, nil, "3")
+	shards[0].clients.Store(busy, true)
+	shards[0].userMap.Store("1", busy)
+	shards[0].clients.Store(quiet, true)
+	shards[0].userMap.Store("2", quiet)
+	shards[1].clients.Store(other, true)
+	shards[1].userMap.Store("3", other)
+
+	assert.True(t, shards[0].SendToUser("1", []byte("a")))
+	assert.True(t, shards[0].SendToUser("1", []byte("b")))
+	assert.True(t, shards[0].SendToUser("2", []byte("c")))
+	assert.False(t, shards[0].SendToUser("1", []byte("d")), "переполненный буфер")
+	busy.messagesReceived.Add(5)
+
+	top, err := hub.TopConnections(0, ConnectionSortSent, 10)
+	require.NoError(t, err)
+	require.Len(t, top, 1, "отключенный клиент не учитывается")
+	assert.Equal(t, "2", top[0].UserID)
+	assert.Equal(t, int64(1), top[0].MessagesSent)
+
+	busyStats := busy.connectionStats(time.Now())
+	assert.Equal(t, int64(2), busyStats.MessagesSent)
+	assert.Equal(t, int64(1), busyStats.MessagesDropped)
+	assert.Equal(t, 2, busyStats.SendBufferHighWater)
+	assert.Greater(t, busyStats.InboundRate, 0.0)
+
+	shards[0].clients.Store(busy, true)
+	top, err = hub.TopConnections(-1, ConnectionSortDropped, 1)
+	require.NoError(t, err)
+	require.Len(t, top, 1)
+	assert.Equal(t, "1", top[0].UserID)
+	assert.Equal(t, 0, top[0].ShardID)
+
+	all, err := hub.TopConnections(-1, ConnectionSortAge, 0)
+	require.NoError(t, err)
+	assert.Len(t, all, 3)
+
+	_, err = hub.TopConnections(0, "bytes", 10)
+	assert.ErrorIs(t, err, ErrUnknownConnectionSort)
+	_, err = hub.TopConnections(2, ConnectionSortSent, 10)
+	assert.ErrorIs(t, err, ErrShardNotFound)
+}
+
+// newLoadedTestHub создает хаб из двух шардов по два клиента, первый из которых заполнен
+func newLoadedTestHub() *ShardedHub {
+	shards := []*Shard{NewShard(0, nil, 2, 0, 0), NewShard(1, nil, 2, 0, 0)} // Без фоновой очистки
+	for _, userID := range []string{"1", "2"} {
+		shards[0].clients.Store(NewClient(nil, nil, userID), true)
+	}
+	return &ShardedHub{
+		shards:             shards,
+		shardCount:         2,
+		maxClientsPerShard: 2,
+		metrics:            NewHubMetrics(),
+		alertChan:          make(chan AlertMessage, 10),
+		done:               make(chan struct{}),
+	}
+}
+
+func TestShardedHub_GatherMetricsSendsNoAlerts(t *testing.T) {
+	hub := newLoadedTestHub()
+
+	collection := hub.gatherMetrics()
+	assert.Equal(t, []int{0}, collection.hotShards)
+	assert.Equal(t, int64(2), collection.totalConnections)
+	assert.Equal(t, 100.0, collection.maxLoad)
+	assert.Equal(t, 0, collection.maxLoadShardID)
+	assert.Empty(t, drainAlerts(hub))
+}
+
+func TestShardedHub_MetricsCollectorAlertsHotShards(t *testing.T) {
+	hub := newLoadedTestHub()
+	hub.metricsInterval = 10 * time.Millisecond
+
+	// Алерты приходят без включенной истории метрик
+	go hub.runMetricsCollector()
+	defer close(hub.done)
+
+	var alerts []AlertMessage
+	require.Eventually(t, func() bool {
+		for len(hub.alertChan) > 0 {
+			alerts = append(alerts, <-hub.alertChan)
+		}
+		return len(alerts) >= 4
+	}, time.Second, 5*time.Millisecond, "сбор метрик повторяется по интервалу")
+
+	shardAlert, summary := alerts[0], alerts[1]
+	assert.Equal(t, AlertHotShard, shardAlert.Type)
+	assert.Equal(t, AlertCritical, shardAlert.Severity)
+	assert.Equal(t, 0, shardAlert.Metadata["shard_id"])
+	assert.Equal(t, AlertWarning, summary.Severity)
+	assert.Equal(t, []int{0}, summary.Metadata["hot_shards"])
+	assert.Equal(t, 0, summary.Metadata["max_load_shard"])
+}
@@ -0,0 +1,19 @@
+-- Таблица для периодических снимков метрик WebSocket
+CREATE TABLE IF NOT EXISTS ws_metrics (
+    id SERIAL PRIMARY KEY,
+    instance_id VARCHAR(64),
+    collected_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    active_connections BIGINT NOT NULL DEFAULT 0,
+    total_connections BIGINT NOT NULL DEFAULT 0,
+    messages_sent BIGINT NOT NULL DEFAULT 0,
+    messages_received BIGINT NOT NULL DEFAULT 0,
+    messages_dropped BIGINT NOT NULL DEFAULT 0,
+    connection_errors BIGINT NOT NULL DEFAULT 0,
+    alerts_total BIGINT NOT NULL DEFAULT 0,
+    hot_shards INTEGER NOT NULL DEFAULT 0,
+    max_shard_load DOUBLE PRECISION NOT NULL DEFAULT 0,
+    shard_loads JSONB
+);
+
+CREATE INDEX IF NOT EXISTS idx_ws_metrics_collected_at ON ws_metrics (collected_at);
+CREATE INDEX IF NOT EXISTS idx_ws_metrics_instance_id ON ws_metrics (instance_id);
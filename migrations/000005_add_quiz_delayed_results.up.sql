@@ -0,0 +1,2 @@
+-- Режим отложенных результатов: игроки узнают результат ответа только после закрытия вопроса
+ALTER TABLE quizzes ADD COLUMN IF NOT EXISTS delayed_results BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,5 @@
+ALTER TABLE user_answers DROP COLUMN IF EXISTS selected_order;
+
+ALTER TABLE questions DROP COLUMN IF EXISTS scoring_method;
+ALTER TABLE questions DROP COLUMN IF EXISTS correct_order;
+ALTER TABLE questions DROP COLUMN IF EXISTS type;
@@ -0,0 +1,6 @@
+-- Вопросы типа ordering: игрок расставляет варианты в правильном порядке
+ALTER TABLE questions ADD COLUMN IF NOT EXISTS type VARCHAR(20) NOT NULL DEFAULT 'single_choice';
+ALTER TABLE questions ADD COLUMN IF NOT EXISTS correct_order JSONB;
+ALTER TABLE questions ADD COLUMN IF NOT EXISTS scoring_method VARCHAR(20);
+
+ALTER TABLE user_answers ADD COLUMN IF NOT EXISTS selected_order JSONB;
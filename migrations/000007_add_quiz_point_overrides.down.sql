@@ -0,0 +1,2 @@
+ALTER TABLE quizzes DROP COLUMN IF EXISTS points_multiplier;
+ALTER TABLE quizzes DROP COLUMN IF EXISTS uniform_point_value;
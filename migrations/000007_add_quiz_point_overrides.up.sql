@@ -0,0 +1,3 @@
+-- Настройки стоимости вопросов на уровне викторины
+ALTER TABLE quizzes ADD COLUMN IF NOT EXISTS uniform_point_value INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE quizzes ADD COLUMN IF NOT EXISTS points_multiplier DOUBLE PRECISION NOT NULL DEFAULT 1;
@@ -0,0 +1 @@
+ALTER TABLE quizzes DROP COLUMN IF EXISTS suppress_answer_feedback;
@@ -0,0 +1,2 @@
+-- Формат на выбывание: до раскрытия ответа игрок получает только подтверждение приема
+ALTER TABLE quizzes ADD COLUMN IF NOT EXISTS suppress_answer_feedback BOOLEAN NOT NULL DEFAULT FALSE;
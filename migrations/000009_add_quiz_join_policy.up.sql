@@ -0,0 +1,2 @@
+-- Правило присоединения: before_start_only или anytime (присоединение во время проведения)
+ALTER TABLE quizzes ADD COLUMN IF NOT EXISTS join_policy VARCHAR(20) NOT NULL DEFAULT 'before_start_only';
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_quizzes_recurrence_source_id;
+ALTER TABLE quizzes DROP COLUMN IF EXISTS recurrence_source_id;
+ALTER TABLE quizzes DROP COLUMN IF EXISTS recurrence_paused;
+ALTER TABLE quizzes DROP COLUMN IF EXISTS recurrence_interval_min;
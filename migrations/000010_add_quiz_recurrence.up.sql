@@ -0,0 +1,6 @@
+-- Повторяющиеся викторины: интервал в минутах (0 - не повторяется), пауза серии
+-- и ссылка на предыдущую викторину серии, из которой создана копия
+ALTER TABLE quizzes ADD COLUMN IF NOT EXISTS recurrence_interval_min INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE quizzes ADD COLUMN IF NOT EXISTS recurrence_paused BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE quizzes ADD COLUMN IF NOT EXISTS recurrence_source_id INTEGER;
+CREATE INDEX IF NOT EXISTS idx_quizzes_recurrence_source_id ON quizzes(recurrence_source_id);
@@ -0,0 +1,2 @@
+-- Абсолютный срок сессии: токены, выданные при обновлении, наследуют его от токена входа
+ALTER TABLE refresh_tokens ADD COLUMN IF NOT EXISTS session_expires_at TIMESTAMP WITH TIME ZONE;
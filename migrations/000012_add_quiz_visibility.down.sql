@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_quizzes_visibility;
+ALTER TABLE quizzes DROP COLUMN IF EXISTS invite_code;
+ALTER TABLE quizzes DROP COLUMN IF EXISTS visibility;
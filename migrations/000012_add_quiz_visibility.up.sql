@@ -0,0 +1,5 @@
+-- Видимость викторины: public (в списках), unlisted (только по ссылке),
+-- private (по коду приглашения)
+ALTER TABLE quizzes ADD COLUMN IF NOT EXISTS visibility VARCHAR(20) NOT NULL DEFAULT 'public';
+ALTER TABLE quizzes ADD COLUMN IF NOT EXISTS invite_code VARCHAR(32);
+CREATE INDEX IF NOT EXISTS idx_quizzes_visibility ON quizzes(visibility);
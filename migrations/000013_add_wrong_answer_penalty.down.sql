@@ -0,0 +1,3 @@
+ALTER TABLE user_answers DROP COLUMN IF EXISTS penalty;
+ALTER TABLE quizzes DROP COLUMN IF EXISTS score_floor;
+ALTER TABLE quizzes DROP COLUMN IF EXISTS wrong_answer_penalty;
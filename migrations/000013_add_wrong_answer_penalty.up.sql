@@ -0,0 +1,5 @@
+-- Штраф за неверный ответ и нижняя граница итогового счета викторины
+ALTER TABLE quizzes ADD COLUMN IF NOT EXISTS wrong_answer_penalty INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE quizzes ADD COLUMN IF NOT EXISTS score_floor INTEGER NOT NULL DEFAULT 0;
+-- Штраф, начисленный за конкретный ответ
+ALTER TABLE user_answers ADD COLUMN IF NOT EXISTS penalty INTEGER NOT NULL DEFAULT 0;
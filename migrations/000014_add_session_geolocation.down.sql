@@ -0,0 +1,3 @@
+ALTER TABLE results DROP COLUMN IF EXISTS country;
+ALTER TABLE refresh_tokens DROP COLUMN IF EXISTS city;
+ALTER TABLE refresh_tokens DROP COLUMN IF EXISTS country;
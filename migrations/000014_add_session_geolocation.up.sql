@@ -0,0 +1,4 @@
+-- Местоположение по IP-адресу: сессии и участие в викторинах
+ALTER TABLE refresh_tokens ADD COLUMN IF NOT EXISTS country VARCHAR(2);
+ALTER TABLE refresh_tokens ADD COLUMN IF NOT EXISTS city VARCHAR(100);
+ALTER TABLE results ADD COLUMN IF NOT EXISTS country VARCHAR(2);
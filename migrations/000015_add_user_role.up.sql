@@ -0,0 +1,2 @@
+-- Роли пользователей: player, moderator, admin
+ALTER TABLE users ADD COLUMN IF NOT EXISTS role VARCHAR(20) NOT NULL DEFAULT 'player';
@@ -0,0 +1,2 @@
+-- Немедленный результат ответа без правильного ответа до quiz:answer_reveal
+ALTER TABLE quizzes ADD COLUMN IF NOT EXISTS hide_correct_answer BOOLEAN NOT NULL DEFAULT FALSE;
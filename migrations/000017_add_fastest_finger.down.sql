@@ -0,0 +1,2 @@
+ALTER TABLE user_answers DROP COLUMN IF EXISTS fastest_finger;
+ALTER TABLE quizzes DROP COLUMN IF EXISTS fastest_finger_bonus;
@@ -0,0 +1,4 @@
+-- Бонус первому правильно ответившему на вопрос
+ALTER TABLE quizzes ADD COLUMN IF NOT EXISTS fastest_finger_bonus INTEGER NOT NULL DEFAULT 0;
+-- Отметка ответа, получившего бонус: пересчет результатов сохраняет бонус
+ALTER TABLE user_answers ADD COLUMN IF NOT EXISTS fastest_finger BOOLEAN NOT NULL DEFAULT FALSE;
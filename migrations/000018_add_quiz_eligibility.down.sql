@@ -0,0 +1,4 @@
+ALTER TABLE users DROP COLUMN IF EXISTS email_verified;
+ALTER TABLE quizzes DROP COLUMN IF EXISTS external_eligibility_check;
+ALTER TABLE quizzes DROP COLUMN IF EXISTS min_games_played;
+ALTER TABLE quizzes DROP COLUMN IF EXISTS require_verified_email;
@@ -0,0 +1,6 @@
+-- Условия участия в викторине
+ALTER TABLE quizzes ADD COLUMN IF NOT EXISTS require_verified_email BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE quizzes ADD COLUMN IF NOT EXISTS min_games_played INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE quizzes ADD COLUMN IF NOT EXISTS external_eligibility_check BOOLEAN NOT NULL DEFAULT FALSE;
+-- Подтверждение email пользователя
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_verified BOOLEAN NOT NULL DEFAULT FALSE;
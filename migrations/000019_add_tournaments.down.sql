@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS tournament_quizzes;
+DROP TABLE IF EXISTS tournaments;
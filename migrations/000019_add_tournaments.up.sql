@@ -0,0 +1,20 @@
+-- Турниры: несколько викторин с общим зачетом
+CREATE TABLE IF NOT EXISTS tournaments (
+    id SERIAL PRIMARY KEY,
+    title VARCHAR(100) NOT NULL,
+    description VARCHAR(500),
+    scoring_mode VARCHAR(20) NOT NULL DEFAULT 'sum',
+    best_n INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Викторины турнира. Викторина может входить в несколько турниров
+CREATE TABLE IF NOT EXISTS tournament_quizzes (
+    tournament_id INTEGER NOT NULL REFERENCES tournaments(id) ON DELETE CASCADE,
+    quiz_id INTEGER NOT NULL REFERENCES quizzes(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (tournament_id, quiz_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_tournament_quizzes_quiz_id ON tournament_quizzes (quiz_id);
@@ -0,0 +1,2 @@
+ALTER TABLE quizzes DROP COLUMN IF EXISTS self_paced_time_limit_sec;
+ALTER TABLE quizzes DROP COLUMN IF EXISTS pacing_mode;
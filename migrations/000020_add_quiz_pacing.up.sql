@@ -0,0 +1,3 @@
+-- Темп прохождения викторины и общий лимит времени самостоятельного прохождения
+ALTER TABLE quizzes ADD COLUMN IF NOT EXISTS pacing_mode VARCHAR(20) NOT NULL DEFAULT 'synchronized';
+ALTER TABLE quizzes ADD COLUMN IF NOT EXISTS self_paced_time_limit_sec INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,2 @@
+-- Досрочное закрытие вопроса, когда ответили все активные игроки
+ALTER TABLE quizzes ADD COLUMN IF NOT EXISTS auto_advance BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1 @@
+ALTER TABLE quizzes DROP COLUMN IF EXISTS answer_window_from_ack;
@@ -0,0 +1,2 @@
+-- Отсчет времени на ответ от подтверждения получения вопроса игроком
+ALTER TABLE quizzes ADD COLUMN IF NOT EXISTS answer_window_from_ack BOOLEAN NOT NULL DEFAULT FALSE;
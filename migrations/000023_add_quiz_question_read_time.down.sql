@@ -0,0 +1 @@
+ALTER TABLE quizzes DROP COLUMN IF EXISTS question_read_time_sec;
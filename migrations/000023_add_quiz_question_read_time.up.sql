@@ -0,0 +1,2 @@
+-- Время на чтение вопроса до открытия приема ответов
+ALTER TABLE quizzes ADD COLUMN IF NOT EXISTS question_read_time_sec INTEGER NOT NULL DEFAULT 0;
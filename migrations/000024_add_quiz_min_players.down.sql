@@ -0,0 +1,2 @@
+ALTER TABLE quizzes DROP COLUMN IF EXISTS min_players_policy;
+ALTER TABLE quizzes DROP COLUMN IF EXISTS min_players;
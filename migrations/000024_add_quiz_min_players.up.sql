@@ -0,0 +1,3 @@
+-- Минимум игроков в комнате к началу викторины и поведение при нехватке (wait или cancel)
+ALTER TABLE quizzes ADD COLUMN IF NOT EXISTS min_players INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE quizzes ADD COLUMN IF NOT EXISTS min_players_policy VARCHAR(20) NOT NULL DEFAULT 'wait';
@@ -0,0 +1,2 @@
+-- Максимум игроков, занявших место в викторине (0 - без ограничения)
+ALTER TABLE quizzes ADD COLUMN IF NOT EXISTS max_players INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,14 @@
+-- История алертов WebSocket-хаба для разбора инцидентов
+CREATE TABLE IF NOT EXISTS ws_alerts (
+    id SERIAL PRIMARY KEY,
+    instance_id VARCHAR(64),
+    type VARCHAR(64) NOT NULL,
+    severity VARCHAR(16) NOT NULL,
+    message TEXT,
+    metadata JSONB,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_ws_alerts_created_at ON ws_alerts (created_at);
+CREATE INDEX IF NOT EXISTS idx_ws_alerts_type ON ws_alerts (type);
+CREATE INDEX IF NOT EXISTS idx_ws_alerts_instance_id ON ws_alerts (instance_id);
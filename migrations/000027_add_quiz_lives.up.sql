@@ -0,0 +1,2 @@
+-- Число жизней игрока в формате на выбывание (1 - выбывание с первой ошибки)
+ALTER TABLE quizzes ADD COLUMN IF NOT EXISTS lives INTEGER NOT NULL DEFAULT 1;
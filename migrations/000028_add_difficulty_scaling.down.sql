@@ -0,0 +1,5 @@
+ALTER TABLE quizzes DROP COLUMN IF EXISTS difficulty_hard;
+ALTER TABLE quizzes DROP COLUMN IF EXISTS difficulty_medium;
+ALTER TABLE quizzes DROP COLUMN IF EXISTS difficulty_easy;
+ALTER TABLE quizzes DROP COLUMN IF EXISTS difficulty_scaling;
+ALTER TABLE questions DROP COLUMN IF EXISTS difficulty;
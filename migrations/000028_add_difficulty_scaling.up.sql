@@ -0,0 +1,6 @@
+-- Сложность вопроса и масштабирование стоимости вопросов по сложности
+ALTER TABLE questions ADD COLUMN IF NOT EXISTS difficulty VARCHAR(10) NOT NULL DEFAULT 'medium';
+ALTER TABLE quizzes ADD COLUMN IF NOT EXISTS difficulty_scaling BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE quizzes ADD COLUMN IF NOT EXISTS difficulty_easy DOUBLE PRECISION NOT NULL DEFAULT 0.5;
+ALTER TABLE quizzes ADD COLUMN IF NOT EXISTS difficulty_medium DOUBLE PRECISION NOT NULL DEFAULT 1;
+ALTER TABLE quizzes ADD COLUMN IF NOT EXISTS difficulty_hard DOUBLE PRECISION NOT NULL DEFAULT 2;
@@ -0,0 +1,183 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Режимы работы при недоступности хранилища инвалидированных токенов
+const (
+	// InvalidationFailOpen - токен принимается по проверке в памяти экземпляра
+	// (по умолчанию). Каждый сбой учитывается в метриках, о сбоях пишется алерт.
+	InvalidationFailOpen = "fail_open"
+	// InvalidationFailClosed - токен отклоняется, пока хранилище недоступно
+	InvalidationFailClosed = "fail_closed"
+)
+
+// ErrInvalidationCheckUnavailable возвращается в режиме fail_closed, если
+// хранилище инвалидированных токенов недоступно
+var ErrInvalidationCheckUnavailable = errors.New("token invalidation check is unavailable")
+
+// invalidationAlertCooldown - минимальный интервал между алертами о сбоях проверки
+const invalidationAlertCooldown = time.Minute
+
+// invalidationVerdictTTL - сколько экземпляр доверяет ответу хранилища, что токены
+// пользователя не инвалидированы. Инвалидация на другом экземпляре вступает в
+// силу здесь с такой задержкой; инвалидация на этом экземпляре - сразу.
+const invalidationVerdictTTL = 5 * time.Second
+
+// validVerdict - ответ хранилища, что токены пользователя, выданные не раньше
+// issuedAt, не инвалидированы. Действует до until.
+type validVerdict struct {
+	issuedAt time.Time
+	until    time.Time
+}
+
+// invalidationCheck - режим и метрики проверки токена по хранилищу инвалидаций
+type invalidationCheck struct {
+	failClosed atomic.Bool
+
+	checks        atomic.Int64 // Обращения к хранилищу
+	failures      atomic.Int64 // Ошибки хранилища
+	allowedOpen   atomic.Int64 // Токены, принятые без проверки хранилищем (fail_open)
+	rejected      atomic.Int64 // Токены, отклоненные из-за сбоя (fail_closed)
+	lastFailureMs atomic.Int64
+	lastAlertMs   atomic.Int64
+	cacheHits     atomic.Int64 // Проверки, решенные по недавнему ответу хранилища
+
+	verdictsMu sync.Mutex
+	verdicts   map[uint]validVerdict
+}
+
+// cachedValid сообщает, подтверждало ли хранилище недавно, что токен, выданный
+// в issuedAt, не инвалидирован. Ответ для более раннего токена подходит и для
+// более позднего: инвалидация действует на токены, выданные до нее.
+func (c *invalidationCheck) cachedValid(userID uint, issuedAt, now time.Time) bool {
+	c.verdictsMu.Lock()
+	defer c.verdictsMu.Unlock()
+	verdict, ok := c.verdicts[userID]
+	return ok && now.Before(verdict.until) && !issuedAt.Before(verdict.issuedAt)
+}
+
+// rememberValid запоминает ответ хранилища, что токен не инвалидирован
+func (c *invalidationCheck) rememberValid(userID uint, issuedAt, now time.Time) {
+	c.verdictsMu.Lock()
+	defer c.verdictsMu.Unlock()
+	if c.verdicts == nil {
+		c.verdicts = make(map[uint]validVerdict)
+	}
+	c.verdicts[userID] = validVerdict{issuedAt: issuedAt, until: now.Add(invalidationVerdictTTL)}
+}
+
+// forget удаляет запомненный ответ для пользователя (после его инвалидации)
+func (c *invalidationCheck) forget(userID uint) {
+	c.verdictsMu.Lock()
+	delete(c.verdicts, userID)
+	c.verdictsMu.Unlock()
+}
+
+// pruneVerdicts удаляет истекшие ответы хранилища
+func (c *invalidationCheck) pruneVerdicts(now time.Time) {
+	c.verdictsMu.Lock()
+	defer c.verdictsMu.Unlock()
+	for userID, verdict := range c.verdicts {
+		if !now.Before(verdict.until) {
+			delete(c.verdicts, userID)
+		}
+	}
+}
+
+// SetInvalidationFailMode задает поведение при недоступности хранилища
+// инвалидированных токенов: InvalidationFailOpen или InvalidationFailClosed
+func (s *JWTService) SetInvalidationFailMode(mode string) error {
+	switch mode {
+	case "", InvalidationFailOpen:
+		s.invalidation.failClosed.Store(false)
+	case InvalidationFailClosed:
+		s.invalidation.failClosed.Store(true)
+	default:
+		return fmt.Errorf("unknown invalidation fail mode %q", mode)
+	}
+	log.Printf("[JWT] Режим при недоступности хранилища инвалидаций: %s", s.InvalidationFailMode())
+	return nil
+}
+
+// InvalidationFailMode возвращает текущий режим при недоступности хранилища
+func (s *JWTService) InvalidationFailMode() string {
+	if s.invalidation.failClosed.Load() {
+		return InvalidationFailClosed
+	}
+	return InvalidationFailOpen
+}
+
+// InvalidationCheckStats возвращает метрики проверки токенов по хранилищу инвалидаций
+func (s *JWTService) InvalidationCheckStats() map[string]interface{} {
+	c := &s.invalidation
+	stats := map[string]interface{}{
+		"fail_mode":        s.InvalidationFailMode(),
+		"checks":           c.checks.Load(),
+		"failures":         c.failures.Load(),
+		"allowed_degraded": c.allowedOpen.Load(),
+		"rejected":         c.rejected.Load(),
+		"cache_hits":       c.cacheHits.Load(),
+	}
+	if lastFailure := c.lastFailureMs.Load(); lastFailure > 0 {
+		stats["last_failure_at"] = time.UnixMilli(lastFailure).UTC()
+	}
+	return stats
+}
+
+// checkInvalidatedInStore проверяет токен по хранилищу инвалидаций, общему для
+// всех экземпляров. Проверка в памяти уже пройдена: при сбое хранилища в режиме
+// fail_open она остается единственной защитой. Ответ хранилища, что токен не
+// инвалидирован, запоминается на invalidationVerdictTTL, чтобы не обращаться
+// к БД на каждый запрос.
+func (s *JWTService) checkInvalidatedInStore(ctx context.Context, claims *JWTCustomClaims) error {
+	if s.invalidTokenRepo == nil || claims.UserID == 0 {
+		return nil
+	}
+
+	c := &s.invalidation
+	now := time.Now()
+	if c.cachedValid(claims.UserID, claims.IssuedAt.Time, now) {
+		c.cacheHits.Add(1)
+		return nil
+	}
+
+	c.checks.Add(1)
+	invalid, err := s.invalidTokenRepo.IsTokenInvalid(ctx, claims.UserID, claims.IssuedAt.Time)
+	if err == nil {
+		if invalid {
+			log.Printf("[JWT] Токен инвалидирован (проверка в БД) для пользователя ID=%d, выдан в %v",
+				claims.UserID, claims.IssuedAt.Time)
+			return errors.New("token has been invalidated")
+		}
+		c.rememberValid(claims.UserID, claims.IssuedAt.Time, now)
+		return nil
+	}
+
+	c.failures.Add(1)
+	nowMs := now.UnixMilli()
+	c.lastFailureMs.Store(nowMs)
+	failClosed := c.failClosed.Load()
+	if failClosed {
+		c.rejected.Add(1)
+	} else {
+		c.allowedOpen.Add(1)
+	}
+
+	if last := c.lastAlertMs.Load(); nowMs-last >= invalidationAlertCooldown.Milliseconds() && c.lastAlertMs.CompareAndSwap(last, nowMs) {
+		log.Printf("[JWT] ALERT: хранилище инвалидированных токенов недоступно (режим %s, сбоев: %d): %v",
+			s.InvalidationFailMode(), c.failures.Load(), err)
+	}
+
+	if failClosed {
+		return fmt.Errorf("%w: %v", ErrInvalidationCheckUnavailable, err)
+	}
+	return nil
+}
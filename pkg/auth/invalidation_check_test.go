@@ -0,0 +1,117 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourusername/trivia-api/internal/domain/entity"
+	"github.com/yourusername/trivia-api/internal/domain/repository"
+)
+
+// stubInvalidTokenRepo - хранилище инвалидаций, которое может быть недоступно
+type stubInvalidTokenRepo struct {
+	repository.InvalidTokenRepository
+	invalid bool
+	err     error
+}
+
+func (r *stubInvalidTokenRepo) IsTokenInvalid(_ context.Context, _ uint, _ time.Time) (bool, error) {
+	return r.invalid, r.err
+}
+
+func newInvalidationTestService(repo repository.InvalidTokenRepository) *JWTService {
+	return &JWTService{
+		secretKey:        "test-secret",
+		expirationHrs:    1,
+		invalidatedUsers: make(map[uint]time.Time),
+		invalidTokenRepo: repo,
+	}
+}
+
+func issueTestToken(t *testing.T, s *JWTService) string {
+	t.Helper()
+	token, err := s.GenerateToken(&entity.User{ID: 7, Email: "user@example.com", Role: "user"})
+	require.NoError(t, err)
+	return token
+}
+
+func TestParseToken_StoreInvalidationApplied(t *testing.T) {
+	s := newInvalidationTestService(&stubInvalidTokenRepo{invalid: true})
+
+	_, err := s.ParseToken(context.Background(), issueTestToken(t, s))
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, ErrInvalidationCheckUnavailable)
+}
+
+func TestParseToken_StoreUnavailableFailOpen(t *testing.T) {
+	s := newInvalidationTestService(&stubInvalidTokenRepo{err: errors.New("connection refused")})
+	require.NoError(t, s.SetInvalidationFailMode(InvalidationFailOpen))
+
+	claims, err := s.ParseToken(context.Background(), issueTestToken(t, s))
+	require.NoError(t, err)
+	assert.Equal(t, uint(7), claims.UserID)
+
+	// Инвалидация, известная экземпляру, действует и без хранилища
+	s.invalidatedUsers[7] = time.Now().Add(time.Minute)
+	_, err = s.ParseToken(context.Background(), issueTestToken(t, s))
+	require.Error(t, err)
+
+	stats := s.InvalidationCheckStats()
+	assert.Equal(t, InvalidationFailOpen, stats["fail_mode"])
+	assert.Equal(t, int64(1), stats["failures"])
+	assert.Equal(t, int64(1), stats["allowed_degraded"])
+	assert.Equal(t, int64(0), stats["rejected"])
+	assert.Contains(t, stats, "last_failure_at")
+}
+
+func TestParseToken_StoreUnavailableFailClosed(t *testing.T) {
+	repo := &stubInvalidTokenRepo{err: errors.New("connection refused")}
+	s := newInvalidationTestService(repo)
+	require.NoError(t, s.SetInvalidationFailMode(InvalidationFailClosed))
+	token := issueTestToken(t, s)
+
+	_, err := s.ParseToken(context.Background(), token)
+	assert.ErrorIs(t, err, ErrInvalidationCheckUnavailable)
+
+	stats := s.InvalidationCheckStats()
+	assert.Equal(t, int64(1), stats["failures"])
+	assert.Equal(t, int64(1), stats["rejected"])
+	assert.Equal(t, int64(0), stats["allowed_degraded"])
+
+	// После восстановления хранилища токен снова принимается
+	repo.err = nil
+	_, err = s.ParseToken(context.Background(), token)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), s.InvalidationCheckStats()["checks"])
+}
+
+func TestSetInvalidationFailMode_Unknown(t *testing.T) {
+	s := newInvalidationTestService(nil)
+	assert.Error(t, s.SetInvalidationFailMode("ignore"))
+	assert.Equal(t, InvalidationFailOpen, s.InvalidationFailMode())
+}
+
+func TestParseToken_StoreVerdictCached(t *testing.T) {
+	repo := &stubInvalidTokenRepo{}
+	s := newInvalidationTestService(repo)
+	token := issueTestToken(t, s)
+
+	for i := 0; i < 3; i++ {
+		_, err := s.ParseToken(context.Background(), token)
+		require.NoError(t, err)
+	}
+	stats := s.InvalidationCheckStats()
+	assert.Equal(t, int64(1), stats["checks"], "хранилище опрашивается один раз за срок ответа")
+	assert.Equal(t, int64(2), stats["cache_hits"])
+
+	// Инвалидация на другом экземпляре видна после истечения ответа
+	repo.invalid = true
+	s.invalidation.verdicts[7] = validVerdict{issuedAt: s.invalidation.verdicts[7].issuedAt, until: time.Now()}
+	_, err := s.ParseToken(context.Background(), token)
+	require.Error(t, err)
+	assert.Equal(t, int64(2), s.InvalidationCheckStats()["checks"])
+}
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
 	"github.com/yourusername/trivia-api/internal/domain/entity"
 	"github.com/yourusername/trivia-api/internal/domain/repository"
 )
@@ -23,6 +24,20 @@ type JWTCustomClaims struct {
 	Usage string `json:"usage,omitempty"`
 }
 
+// EffectiveRole возвращает роль пользователя: пользователь с ID=1 остается
+// администратором независимо от роли в токене
+func (c *JWTCustomClaims) EffectiveRole() string {
+	if c.UserID == 1 {
+		return entity.RoleAdmin
+	}
+	return c.Role
+}
+
+// IsAdmin проверяет, является ли владелец токена администратором
+func (c *JWTCustomClaims) IsAdmin() bool {
+	return c.EffectiveRole() == entity.RoleAdmin
+}
+
 // JWTService предоставляет методы для работы с JWT
 type JWTService struct {
 	secretKey     string
@@ -37,6 +52,15 @@ type JWTService struct {
 	wsTicketExpiry time.Duration
 	// Интервал для очистки кеша
 	cleanupInterval time.Duration
+	// Асимметричная подпись (см. EnableAsymmetricSigning); nil - HS256 с secretKey
+	signingMethod jwt.SigningMethod
+	signKey       interface{}
+	verifyKey     interface{}
+	keyID         string
+	// Использованные WS-тикеты (тикет одноразовый)
+	wsTickets wsTicketGuard
+	// Режим и метрики проверки по хранилищу инвалидаций
+	invalidation invalidationCheck
 }
 
 // NewJWTService создает новый сервис JWT
@@ -111,15 +135,14 @@ func (s *JWTService) GenerateToken(user *entity.User) (string, error) {
 	claims := &JWTCustomClaims{
 		UserID: user.ID,
 		Email:  user.Email,
-		// Role:   user.Role, // TODO: Uncomment and ensure user.Role exists when roles are implemented
+		Role:   user.Role,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour * time.Duration(s.expirationHrs))),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString([]byte(s.secretKey))
+	tokenString, err := s.sign(claims)
 	if err != nil {
 		log.Printf("[JWT] Ошибка генерации токена для пользователя ID=%d: %v", user.ID, err)
 		return "", err
@@ -135,11 +158,11 @@ func (s *JWTService) GenerateToken(user *entity.User) (string, error) {
 func (s *JWTService) ParseToken(ctx context.Context, tokenString string) (*JWTCustomClaims, error) {
 	claims := &JWTCustomClaims{}
 	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		key, err := s.verificationKey(token)
+		if err != nil {
 			log.Printf("[JWT] Неожиданный метод подписи: %v", token.Header["alg"])
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
-		return []byte(s.secretKey), nil
+		return key, err
 	})
 
 	if err != nil {
@@ -203,6 +226,11 @@ func (s *JWTService) ParseToken(ctx context.Context, tokenString string) (*JWTCu
 		return nil, errors.New("token has been invalidated")
 	}
 
+	// Инвалидация на другом экземпляре видна только в общем хранилище
+	if err := s.checkInvalidatedInStore(ctx, claims); err != nil {
+		return nil, err
+	}
+
 	log.Printf("[JWT] Токен успешно проверен для пользователя ID=%d, Email=%s, выдан: %v",
 		claims.UserID, claims.Email, claims.IssuedAt.Time)
 	return claims, nil
@@ -217,6 +245,7 @@ func (s *JWTService) InvalidateTokensForUser(ctx context.Context, userID uint) e
 	s.mu.Lock()
 	s.invalidatedUsers[userID] = now
 	s.mu.Unlock()
+	s.invalidation.forget(userID)
 
 	// Инвалидация в БД
 	if s.invalidTokenRepo != nil {
@@ -278,6 +307,8 @@ func (s *JWTService) CleanupInvalidatedUsers(ctx context.Context) error {
 		}
 	}
 
+	s.invalidation.pruneVerdicts(time.Now())
+
 	// Очистка кеша в памяти
 	s.mu.Lock() // Блокируем карту для записи
 	defer s.mu.Unlock()
@@ -359,15 +390,11 @@ func (s *JWTService) DebugToken(tokenString string) map[string]interface{} {
 	return result
 }
 
-// ParseWSTicket проверяет JWT, используемый как WS тикет
+// ParseWSTicket проверяет JWT, используемый как WS тикет, и отмечает его
+// использованным: второй вызов с тем же тикетом возвращает ErrWSTicketReused
 func (s *JWTService) ParseWSTicket(ticketString string) (*JWTCustomClaims, error) {
 	claims := &JWTCustomClaims{}
-	token, err := jwt.ParseWithClaims(ticketString, claims, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(s.secretKey), nil
-	})
+	token, err := jwt.ParseWithClaims(ticketString, claims, s.verificationKey)
 
 	if err != nil {
 		// Обработка ошибок валидации
@@ -390,23 +417,33 @@ func (s *JWTService) ParseWSTicket(ticketString string) (*JWTCustomClaims, error
 
 	// Проверка инвалидации НЕ НУЖНА для WS тикетов
 
+	// Тикет одноразовый: повторное подключение с тем же тикетом отклоняется
+	if claims.ID == "" || claims.ExpiresAt == nil {
+		return nil, errors.New("invalid ticket: missing id")
+	}
+	if err := s.wsTickets.consume(claims.ID, claims.ExpiresAt.Time, time.Now()); err != nil {
+		return nil, err
+	}
+
 	return claims, nil
 }
 
-// GenerateWSTicket создает короткоживущий JWT для аутентификации WebSocket
-func (s *JWTService) GenerateWSTicket(userID uint, email string) (string, error) {
+// GenerateWSTicket создает короткоживущий JWT для аутентификации WebSocket.
+// Роль переносится в тикет, чтобы WS-соединение получило те же права, что и API
+func (s *JWTService) GenerateWSTicket(userID uint, email, role string) (string, error) {
 	claims := &JWTCustomClaims{
 		UserID: userID,
 		Email:  email,
+		Role:   role,
 		Usage:  "websocket_auth", // Указываем назначение токена
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(s.wsTicketExpiry)), // Используем настраиваемое время жизни
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ID:        uuid.New().String(), // jti для проверки однократного использования
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString([]byte(s.secretKey))
+	tokenString, err := s.sign(claims)
 	if err != nil {
 		log.Printf("[JWT] Ошибка генерации WS-тикета для пользователя ID=%d: %v", userID, err)
 		return "", err
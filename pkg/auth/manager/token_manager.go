@@ -15,6 +15,7 @@ import (
 	"github.com/yourusername/trivia-api/internal/domain/entity"
 	"github.com/yourusername/trivia-api/internal/domain/repository"
 	"github.com/yourusername/trivia-api/pkg/auth"
+	"github.com/yourusername/trivia-api/pkg/geoip"
 )
 
 // Константы для настройки токенов
@@ -34,11 +35,21 @@ const (
 
 	// Время жизни CSRF токена в памяти
 	csrfTokenLifetime = 15 * time.Minute
+	// Максимальное количество CSRF токенов пользователя в памяти (по умолчанию)
+	DefaultMaxCSRFTokensPerUser = 20
 
 	// Время жизни ключа JWT по умолчанию
 	DefaultJWTKeyLifetime = 90 * 24 * time.Hour // 90 дней
 )
 
+// Политики при входе сверх лимита сессий
+const (
+	// SessionLimitPolicyEvictOldest - новая сессия создается, самые старые завершаются (по умолчанию)
+	SessionLimitPolicyEvictOldest = "evict_oldest"
+	// SessionLimitPolicyRejectNew - вход отклоняется, пока пользователь не завершит другую сессию
+	SessionLimitPolicyRejectNew = "reject_new"
+)
+
 // TokenErrorType определяет тип ошибки токена
 type TokenErrorType string
 
@@ -89,12 +100,30 @@ func NewTokenError(tokenType TokenErrorType, message string, err error) *TokenEr
 	}
 }
 
+// SessionLimitError - причина ошибки TooManySessions: сколько сессий уже активно
+type SessionLimitError struct {
+	ActiveSessions int
+	Limit          int
+}
+
+// Error возвращает строковое представление ошибки
+func (e *SessionLimitError) Error() string {
+	return fmt.Sprintf("active sessions %d of %d", e.ActiveSessions, e.Limit)
+}
+
 // TokenInfo содержит информацию о сроке действия токенов
 type TokenInfo struct {
 	AccessTokenExpires   time.Time `json:"access_token_expires"`
 	RefreshTokenExpires  time.Time `json:"refresh_token_expires"`
 	AccessTokenValidFor  float64   `json:"access_token_valid_for"`
 	RefreshTokenValidFor float64   `json:"refresh_token_valid_for"`
+	// Абсолютный срок сессии, после которого потребуется повторный вход
+	// даже при регулярном обновлении токенов (нет - сессия не ограничена)
+	SessionExpiresAt *time.Time `json:"session_expires_at,omitempty"`
+	// Время жизни refresh-токена от последнего обновления, в секундах
+	RefreshTokenLifetime float64 `json:"refresh_token_lifetime"`
+	// Максимальная длительность сессии в секундах (0 - без ограничения)
+	MaxSessionLifetime float64 `json:"max_session_lifetime"`
 }
 
 // CSRFToken содержит данные CSRF токена
@@ -127,15 +156,21 @@ type TokenManager struct {
 	refreshTokenRepo        repository.RefreshTokenRepository
 	userRepo                repository.UserRepository
 	csrfTokens              map[string]CSRFToken
+	csrfByUser              map[uint][]string // CSRF токены пользователя в порядке выдачи
+	csrfEvicted             int64             // CSRF токены, вытесненные сверх лимита
+	maxCSRFTokensPerUser    int
 	csrfMutex               sync.RWMutex
 	jwtKeys                 []JWTKeyRotation
 	jwtKeysMutex            sync.RWMutex
 	currentJWTKeyID         string
 	accessTokenExpiry       time.Duration
 	refreshTokenExpiry      time.Duration
-	maxRefreshTokensPerUser int       // Добавлено: настраиваемый лимит сессий
-	lastKeyRotation         time.Time // Добавлено: время последней ротации ключей
-	isProductionMode        bool      // Определяет, устанавливать ли Secure флаг для cookies (true в production, false в development)
+	maxSessionLifetime      time.Duration  // Абсолютный срок сессии от входа (0 - без ограничения)
+	maxRefreshTokensPerUser int            // Добавлено: настраиваемый лимит сессий
+	sessionLimitPolicy      string         // Поведение при входе сверх лимита сессий
+	lastKeyRotation         time.Time      // Добавлено: время последней ротации ключей
+	isProductionMode        bool           // Определяет, устанавливать ли Secure флаг для cookies (true в production, false в development)
+	geoProvider             geoip.Provider // Местоположение сессий по IP (nil - не определяется)
 }
 
 // NewTokenManager создает новый менеджер токенов
@@ -164,10 +199,13 @@ func NewTokenManager(
 		refreshTokenRepo:        refreshTokenRepo,
 		userRepo:                userRepo,
 		csrfTokens:              make(map[string]CSRFToken),
+		csrfByUser:              make(map[uint][]string),
+		maxCSRFTokensPerUser:    DefaultMaxCSRFTokensPerUser,
 		jwtKeys:                 make([]JWTKeyRotation, 0),
 		accessTokenExpiry:       accessTokenExpiry,
 		refreshTokenExpiry:      refreshTokenExpiry,
 		maxRefreshTokensPerUser: maxRefreshTokens,
+		sessionLimitPolicy:      SessionLimitPolicyEvictOldest,
 		isProductionMode:        true, // По умолчанию считаем production
 	}
 
@@ -205,12 +243,29 @@ func (m *TokenManager) SetRefreshTokenExpiry(duration time.Duration) {
 	}
 }
 
+// SetMaxSessionLifetime ограничивает срок сессии от входа: обновление refresh-токена
+// не продлевает ее дальше этого срока, после него нужен повторный вход (0 - без ограничения)
+func (m *TokenManager) SetMaxSessionLifetime(duration time.Duration) {
+	if duration < 0 {
+		log.Printf("[TokenManager] Warning: Invalid max session lifetime provided: %v. Using: %v", duration, m.maxSessionLifetime)
+		return
+	}
+	m.maxSessionLifetime = duration
+	log.Printf("[TokenManager] Max session lifetime set to: %v", duration)
+}
+
 // SetProductionMode устанавливает флаг режима production для Secure cookies
 func (m *TokenManager) SetProductionMode(isProduction bool) {
 	m.isProductionMode = isProduction
 	log.Printf("[TokenManager] Production mode set to: %v", isProduction)
 }
 
+// SetGeoProvider включает определение местоположения сессий по IP-адресу.
+// Страна и город сохраняются вместе с refresh-токеном.
+func (m *TokenManager) SetGeoProvider(provider geoip.Provider) {
+	m.geoProvider = provider
+}
+
 // GenerateTokenPair создает новую пару токенов (access и refresh)
 // Эта функция теперь использует jwtService напрямую, а не через tokenService
 func (m *TokenManager) GenerateTokenPair(userID uint, deviceID, ipAddress, userAgent string) (*TokenResponse, error) {
@@ -220,6 +275,11 @@ func (m *TokenManager) GenerateTokenPair(userID uint, deviceID, ipAddress, userA
 		return nil, NewTokenError(UserNotFound, "пользователь не найден", err)
 	}
 
+	// При политике reject_new вход сверх лимита отклоняется до выдачи токенов
+	if err := m.checkSessionCapacity(userID); err != nil {
+		return nil, err
+	}
+
 	// Генерируем access-токен с использованием текущего активного ключа
 	currentKeyID, _, keyErr := m.GetCurrentJWTKey()
 	if keyErr != nil {
@@ -234,8 +294,8 @@ func (m *TokenManager) GenerateTokenPair(userID uint, deviceID, ipAddress, userA
 		return nil, NewTokenError(TokenGenerationFailed, "ошибка генерации access токена", err)
 	}
 
-	// Генерируем refresh-токен
-	_, err = m.generateRefreshToken(userID, deviceID, ipAddress, userAgent)
+	// Генерируем refresh-токен; вход начинает новую сессию
+	_, err = m.generateRefreshToken(userID, deviceID, ipAddress, userAgent, m.newSessionExpiry())
 	if err != nil {
 		log.Printf("[TokenManager] Ошибка генерации refresh-токена для пользователя ID=%d: %v", userID, err)
 		return nil, NewTokenError(TokenGenerationFailed, "ошибка генерации refresh токена", err)
@@ -308,8 +368,13 @@ func (m *TokenManager) RefreshTokens(refreshToken, csrfToken, deviceID, ipAddres
 		return nil, NewTokenError(TokenGenerationFailed, "ошибка генерации нового access токена", err)
 	}
 
-	// Генерируем новый refresh токен
-	_, err = m.generateRefreshToken(user.ID, deviceID, ipAddress, userAgent)
+	// Генерируем новый refresh токен в рамках той же сессии. Токены, выданные
+	// до ограничения срока сессии, получают срок от текущего обновления.
+	sessionExpiresAt := tokenEntity.SessionExpiresAt
+	if sessionExpiresAt == nil {
+		sessionExpiresAt = m.newSessionExpiry()
+	}
+	_, err = m.generateRefreshToken(user.ID, deviceID, ipAddress, userAgent, sessionExpiresAt)
 	if err != nil {
 		log.Printf("[TokenManager] Ошибка генерации нового refresh-токена для пользователя ID=%d: %v", user.ID, err)
 		return nil, NewTokenError(TokenGenerationFailed, "ошибка генерации нового refresh токена", err)
@@ -343,6 +408,10 @@ func (m *TokenManager) GetTokenInfo(refreshToken string) (*TokenInfo, error) {
 	if err != nil {
 		return nil, NewTokenError(InvalidRefreshToken, "Недействительный refresh-токен", err)
 	}
+	// Отозванный или замененный при обновлении токен больше не описывает сессию
+	if token.IsExpired {
+		return nil, NewTokenError(InvalidRefreshToken, "Недействительный refresh-токен", nil)
+	}
 
 	// Вычисляем время истечения access-токена (примерно)
 	accessTokenExpires := time.Now().Add(m.accessTokenExpiry)
@@ -353,6 +422,9 @@ func (m *TokenManager) GetTokenInfo(refreshToken string) (*TokenInfo, error) {
 		RefreshTokenExpires:  token.ExpiresAt,
 		AccessTokenValidFor:  accessTokenExpires.Sub(now).Seconds(),
 		RefreshTokenValidFor: token.ExpiresAt.Sub(now).Seconds(),
+		SessionExpiresAt:     token.SessionExpiresAt,
+		RefreshTokenLifetime: m.refreshTokenExpiry.Seconds(),
+		MaxSessionLifetime:   m.maxSessionLifetime.Seconds(),
 	}, nil
 }
 
@@ -400,6 +472,7 @@ func (m *TokenManager) RevokeAllUserTokens(userID uint) error {
 			delete(m.csrfTokens, k)
 		}
 	}
+	delete(m.csrfByUser, userID)
 
 	log.Printf("[TokenManager] Отозваны все токены пользователя ID=%d", userID)
 	return nil
@@ -577,8 +650,27 @@ func (m *TokenManager) RotateJWTKeys() (string, error) {
 
 // Служебные функции
 
+// newSessionExpiry возвращает абсолютный срок новой сессии (nil - без ограничения)
+func (m *TokenManager) newSessionExpiry() *time.Time {
+	if m.maxSessionLifetime <= 0 {
+		return nil
+	}
+	expiresAt := time.Now().Add(m.maxSessionLifetime)
+	return &expiresAt
+}
+
+// refreshTokenExpiresAt возвращает срок нового refresh-токена: скользящее окно
+// от now, но не дальше абсолютного срока сессии
+func refreshTokenExpiresAt(now time.Time, lifetime time.Duration, sessionExpiresAt *time.Time) time.Time {
+	expiresAt := now.Add(lifetime)
+	if sessionExpiresAt != nil && sessionExpiresAt.Before(expiresAt) {
+		return *sessionExpiresAt
+	}
+	return expiresAt
+}
+
 // generateRefreshToken генерирует новый refresh-токен и сохраняет его в БД
-func (m *TokenManager) generateRefreshToken(userID uint, deviceID, ipAddress, userAgent string) (string, error) {
+func (m *TokenManager) generateRefreshToken(userID uint, deviceID, ipAddress, userAgent string, sessionExpiresAt *time.Time) (string, error) {
 	// Генерируем случайный токен
 	randomBytes := make([]byte, 32)
 	if _, err := rand.Read(randomBytes); err != nil {
@@ -586,11 +678,15 @@ func (m *TokenManager) generateRefreshToken(userID uint, deviceID, ipAddress, us
 	}
 	tokenString := hex.EncodeToString(randomBytes)
 
-	// Время истечения - "скользящее окно" 30 дней от текущего момента
-	expiresAt := time.Now().Add(m.refreshTokenExpiry)
+	// Время истечения - "скользящее окно" 30 дней от текущего момента,
+	// ограниченное абсолютным сроком сессии
+	expiresAt := refreshTokenExpiresAt(time.Now(), m.refreshTokenExpiry, sessionExpiresAt)
 
 	// Создаем запись в БД
 	token := entity.NewRefreshToken(userID, tokenString, deviceID, ipAddress, userAgent, expiresAt)
+	token.SessionExpiresAt = sessionExpiresAt
+	location := geoip.Locate(m.geoProvider, ipAddress)
+	token.Country, token.City = location.Country, location.City
 
 	// Сохраняем в БД
 	_, err := m.refreshTokenRepo.CreateToken(token)
@@ -615,11 +711,34 @@ func (m *TokenManager) generateCSRFToken(userID uint) string {
 	m.csrfMutex.Lock()
 	defer m.csrfMutex.Unlock()
 
+	now := time.Now()
 	key := fmt.Sprintf("%d:%s", userID, csrfToken)
 	m.csrfTokens[key] = CSRFToken{
 		Token:     csrfToken,
-		ExpiresAt: time.Now().Add(m.accessTokenExpiry),
+		ExpiresAt: now.Add(m.accessTokenExpiry),
+	}
+
+	// Частые обновления токенов не должны раздувать карту до часовой очистки:
+	// истекшие токены пользователя удаляются сразу, а сверх лимита вытесняются
+	// самые старые
+	tokens := m.csrfByUser[userID][:0]
+	for _, token := range m.csrfByUser[userID] {
+		tokenKey := fmt.Sprintf("%d:%s", userID, token)
+		if stored, ok := m.csrfTokens[tokenKey]; ok && !stored.ExpiresAt.Before(now) {
+			tokens = append(tokens, token)
+		} else {
+			delete(m.csrfTokens, tokenKey)
+		}
+	}
+	tokens = append(tokens, csrfToken)
+	if excess := len(tokens) - m.maxCSRFTokensPerUser; excess > 0 {
+		for _, token := range tokens[:excess] {
+			delete(m.csrfTokens, fmt.Sprintf("%d:%s", userID, token))
+		}
+		tokens = append([]string(nil), tokens[excess:]...)
+		m.csrfEvicted += int64(excess)
 	}
+	m.csrfByUser[userID] = tokens
 
 	return csrfToken
 }
@@ -658,6 +777,33 @@ func (m *TokenManager) cleanupExpiredCSRFTokens() {
 			delete(m.csrfTokens, k)
 		}
 	}
+	for userID, tokens := range m.csrfByUser {
+		alive := tokens[:0]
+		for _, token := range tokens {
+			if _, ok := m.csrfTokens[fmt.Sprintf("%d:%s", userID, token)]; ok {
+				alive = append(alive, token)
+			}
+		}
+		if len(alive) == 0 {
+			delete(m.csrfByUser, userID)
+		} else {
+			m.csrfByUser[userID] = alive
+		}
+	}
+}
+
+// CSRFTokenStats возвращает метрики CSRF токенов в памяти: число токенов,
+// пользователей с токенами, лимит на пользователя и число вытесненных токенов
+func (m *TokenManager) CSRFTokenStats() map[string]interface{} {
+	m.csrfMutex.RLock()
+	defer m.csrfMutex.RUnlock()
+
+	return map[string]interface{}{
+		"tokens":         len(m.csrfTokens),
+		"users":          len(m.csrfByUser),
+		"limit_per_user": m.maxCSRFTokensPerUser,
+		"evicted":        m.csrfEvicted,
+	}
 }
 
 // generateNewJWTKey генерирует новый ключ подписи JWT
@@ -761,6 +907,28 @@ func (m *TokenManager) SetMaxRefreshTokensPerUser(limit int) {
 	log.Printf("[TokenManager] Установлен лимит активных сессий: %d", limit)
 }
 
+// SetMaxCSRFTokensPerUser устанавливает максимальное количество CSRF токенов
+// пользователя в памяти; при выдаче токена сверх лимита вытесняются самые старые
+func (m *TokenManager) SetMaxCSRFTokensPerUser(limit int) {
+	if limit <= 0 {
+		limit = DefaultMaxCSRFTokensPerUser
+	}
+	m.csrfMutex.Lock()
+	m.maxCSRFTokensPerUser = limit
+	m.csrfMutex.Unlock()
+	log.Printf("[TokenManager] Установлен лимит CSRF токенов на пользователя: %d", limit)
+}
+
+// SetSessionLimitPolicy задает поведение при входе сверх лимита сессий:
+// SessionLimitPolicyEvictOldest (по умолчанию) или SessionLimitPolicyRejectNew
+func (m *TokenManager) SetSessionLimitPolicy(policy string) {
+	if policy != SessionLimitPolicyRejectNew {
+		policy = SessionLimitPolicyEvictOldest
+	}
+	m.sessionLimitPolicy = policy
+	log.Printf("[TokenManager] Политика лимита сессий: %s", policy)
+}
+
 // GetMaxRefreshTokensPerUser возвращает текущий лимит активных сессий
 func (m *TokenManager) GetMaxRefreshTokensPerUser() int {
 	return m.maxRefreshTokensPerUser
@@ -898,6 +1066,25 @@ func (m *TokenManager) limitUserSessions(userID uint) error {
 	return nil
 }
 
+// checkSessionCapacity при политике reject_new возвращает ошибку TooManySessions,
+// если у пользователя уже открыто максимальное количество сессий
+func (m *TokenManager) checkSessionCapacity(userID uint) error {
+	if m.sessionLimitPolicy != SessionLimitPolicyRejectNew {
+		return nil
+	}
+	count, err := m.refreshTokenRepo.CountTokensForUser(userID)
+	if err != nil {
+		log.Printf("[TokenManager] Ошибка подсчета сессий пользователя ID=%d: %v", userID, err)
+		return NewTokenError(DatabaseError, "ошибка проверки лимита сессий", err)
+	}
+	if count >= m.maxRefreshTokensPerUser {
+		log.Printf("[TokenManager] Вход пользователя ID=%d отклонен: активных сессий %d при лимите %d", userID, count, m.maxRefreshTokensPerUser)
+		return NewTokenError(TooManySessions, "превышен лимит активных сессий, завершите одну из них",
+			&SessionLimitError{ActiveSessions: count, Limit: m.maxRefreshTokensPerUser})
+	}
+	return nil
+}
+
 // cleanupExpiredCSRFTokensLoop запускает периодическую очистку CSRF токенов
 func (m *TokenManager) cleanupExpiredCSRFTokensLoop() {
 	ticker := time.NewTicker(1 * time.Hour) // Запускаем очистку каждый час
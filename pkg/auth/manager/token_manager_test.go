@@ -0,0 +1,64 @@
+package manager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRefreshTokenExpiresAt_CappedBySession(t *testing.T) {
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	lifetime := 30 * 24 * time.Hour
+
+	assert.Equal(t, now.Add(lifetime), refreshTokenExpiresAt(now, lifetime, nil), "без ограничения сессии - скользящее окно")
+
+	far := now.Add(90 * 24 * time.Hour)
+	assert.Equal(t, now.Add(lifetime), refreshTokenExpiresAt(now, lifetime, &far))
+
+	// Обновление за 2 дня до конца сессии не продлевает ее
+	near := now.Add(48 * time.Hour)
+	assert.Equal(t, near, refreshTokenExpiresAt(now, lifetime, &near))
+}
+
+// TestGenerateCSRFToken_BoundedPerUser: частые обновления токенов не раздувают
+// карту CSRF токенов - сверх лимита самые старые вытесняются сразу при выдаче
+func TestGenerateCSRFToken_BoundedPerUser(t *testing.T) {
+	m := &TokenManager{
+		csrfTokens:        make(map[string]CSRFToken),
+		csrfByUser:        make(map[uint][]string),
+		accessTokenExpiry: 30 * time.Minute,
+	}
+	m.SetMaxCSRFTokensPerUser(5)
+
+	var issued []string
+	for i := 0; i < 1000; i++ {
+		issued = append(issued, m.generateCSRFToken(7))
+	}
+	other := m.generateCSRFToken(8)
+
+	assert.Len(t, m.csrfTokens, 6, "5 токенов пользователя 7 и 1 токен пользователя 8")
+	assert.Equal(t, issued[len(issued)-5:], m.csrfByUser[7])
+	for _, token := range issued[len(issued)-5:] {
+		assert.True(t, m.validateCSRFToken(7, token), "последние токены действуют")
+	}
+	assert.False(t, m.validateCSRFToken(7, issued[0]), "самый старый токен вытеснен")
+	assert.True(t, m.validateCSRFToken(8, other), "лимит считается для каждого пользователя")
+
+	stats := m.CSRFTokenStats()
+	assert.Equal(t, 6, stats["tokens"])
+	assert.Equal(t, 2, stats["users"])
+	assert.Equal(t, int64(995), stats["evicted"])
+
+	// Истекшие токены удаляются при следующей выдаче, не дожидаясь очистки
+	for key, token := range m.csrfTokens {
+		token.ExpiresAt = time.Now().Add(-time.Second)
+		m.csrfTokens[key] = token
+	}
+	fresh := m.generateCSRFToken(7)
+	require.Equal(t, []string{fresh}, m.csrfByUser[7])
+	m.cleanupExpiredCSRFTokens()
+	assert.Len(t, m.csrfTokens, 1)
+	assert.NotContains(t, m.csrfByUser, uint(8))
+}
@@ -0,0 +1,181 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// Поддерживаемые алгоритмы подписи токенов
+const (
+	AlgorithmHS256 = "HS256"
+	AlgorithmRS256 = "RS256"
+	AlgorithmES256 = "ES256"
+)
+
+// JWK - открытый ключ в формате JSON Web Key (RFC 7517)
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// EC
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// JWKSet - набор открытых ключей, отдаваемый по /.well-known/jwks.json
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// EnableAsymmetricSigning переключает подпись токенов на RS256 или ES256.
+// privateKeyPEM - закрытый ключ RSA (PKCS#1/PKCS#8) или EC P-256 (SEC 1/PKCS#8).
+// Токены, подписанные прежним секретом HS256, после переключения не принимаются.
+func (s *JWTService) EnableAsymmetricSigning(algorithm string, privateKeyPEM []byte) error {
+	key, err := parsePrivateKey(privateKeyPEM)
+	if err != nil {
+		return err
+	}
+
+	var (
+		method    jwt.SigningMethod
+		publicKey interface{}
+	)
+	switch algorithm {
+	case AlgorithmRS256:
+		rsaKey, ok := key.(*rsa.PrivateKey)
+		if !ok {
+			return errors.New("RS256 requires an RSA private key")
+		}
+		method, publicKey = jwt.SigningMethodRS256, &rsaKey.PublicKey
+	case AlgorithmES256:
+		ecKey, ok := key.(*ecdsa.PrivateKey)
+		if !ok || ecKey.Curve != elliptic.P256() {
+			return errors.New("ES256 requires an EC P-256 private key")
+		}
+		method, publicKey = jwt.SigningMethodES256, &ecKey.PublicKey
+	default:
+		return fmt.Errorf("unsupported asymmetric algorithm: %s", algorithm)
+	}
+
+	kid, err := keyID(publicKey)
+	if err != nil {
+		return err
+	}
+
+	s.signingMethod = method
+	s.signKey = key
+	s.verifyKey = publicKey
+	s.keyID = kid
+
+	log.Printf("[JWT] Подпись токенов переключена на %s (kid=%s)", algorithm, kid)
+	return nil
+}
+
+// Algorithm возвращает алгоритм подписи токенов
+func (s *JWTService) Algorithm() string {
+	return s.method().Alg()
+}
+
+// JWKS возвращает открытые ключи для проверки токенов. При HS256 набор пуст:
+// общий секрет не публикуется.
+func (s *JWTService) JWKS() JWKSet {
+	set := JWKSet{Keys: []JWK{}}
+	switch key := s.verifyKey.(type) {
+	case *rsa.PublicKey:
+		set.Keys = append(set.Keys, JWK{
+			Kty: "RSA",
+			Use: "sig",
+			Alg: AlgorithmRS256,
+			Kid: s.keyID,
+			N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		})
+	case *ecdsa.PublicKey:
+		size := (key.Curve.Params().BitSize + 7) / 8
+		set.Keys = append(set.Keys, JWK{
+			Kty: "EC",
+			Use: "sig",
+			Alg: AlgorithmES256,
+			Kid: s.keyID,
+			Crv: key.Curve.Params().Name,
+			X:   base64.RawURLEncoding.EncodeToString(key.X.FillBytes(make([]byte, size))),
+			Y:   base64.RawURLEncoding.EncodeToString(key.Y.FillBytes(make([]byte, size))),
+		})
+	}
+	return set
+}
+
+// method возвращает текущий метод подписи (по умолчанию HS256)
+func (s *JWTService) method() jwt.SigningMethod {
+	if s.signingMethod == nil {
+		return jwt.SigningMethodHS256
+	}
+	return s.signingMethod
+}
+
+// sign подписывает claims текущим ключом
+func (s *JWTService) sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(s.method(), claims)
+	if s.signKey == nil {
+		return token.SignedString([]byte(s.secretKey))
+	}
+	token.Header["kid"] = s.keyID
+	return token.SignedString(s.signKey)
+}
+
+// verificationKey выбирает ключ проверки по настроенному алгоритму.
+// Токены с другим алгоритмом отклоняются, в том числе HS256 при асимметричной подписи:
+// иначе открытый ключ можно было бы использовать как секрет HMAC.
+func (s *JWTService) verificationKey(token *jwt.Token) (interface{}, error) {
+	if token.Method.Alg() != s.method().Alg() {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+	if s.verifyKey == nil {
+		return []byte(s.secretKey), nil
+	}
+	return s.verifyKey, nil
+}
+
+// parsePrivateKey разбирает закрытый ключ RSA или EC в формате PEM
+func parsePrivateKey(data []byte) (interface{}, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("private key is not PEM encoded")
+	}
+	if key, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParseECPrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	return nil, errors.New("unsupported private key format")
+}
+
+// keyID вычисляется из открытого ключа, поэтому не меняется между перезапусками
+func keyID(publicKey interface{}) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal public key: %w", err)
+	}
+	sum := sha256.Sum256(der)
+	return base64.RawURLEncoding.EncodeToString(sum[:8]), nil
+}
@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"errors"
+	"log"
+	"sync"
+	"time"
+)
+
+// ErrWSTicketReused возвращается при повторном использовании WS-тикета
+var ErrWSTicketReused = errors.New("ticket has already been used")
+
+// usedWSTicketKeyPrefix - префикс ключей использованных тикетов в общем хранилище
+const usedWSTicketKeyPrefix = "ws_ticket:used:"
+
+// WSTicketStore - общее для экземпляров хранилище использованных тикетов
+// (repository.CacheRepository подходит без адаптера)
+type WSTicketStore interface {
+	SetNX(key string, value interface{}, expiration time.Duration) (bool, error)
+}
+
+// wsTicketGuard делает WS-тикеты одноразовыми: запоминает ID (jti) использованных
+// тикетов до истечения их срока. Без общего хранилища или при его ошибке
+// используется память экземпляра.
+type wsTicketGuard struct {
+	mu    sync.Mutex
+	used  map[string]time.Time // jti -> время истечения тикета
+	store WSTicketStore
+}
+
+// SetWSTicketStore задает общее хранилище использованных тикетов, чтобы тикет
+// нельзя было повторно использовать на другом экземпляре
+func (s *JWTService) SetWSTicketStore(store WSTicketStore) {
+	s.wsTickets.mu.Lock()
+	s.wsTickets.store = store
+	s.wsTickets.mu.Unlock()
+}
+
+// consume отмечает тикет использованным. Возвращает ErrWSTicketReused, если он
+// уже был использован.
+func (g *wsTicketGuard) consume(jti string, expiresAt, now time.Time) error {
+	ttl := expiresAt.Sub(now)
+	if ttl <= 0 {
+		return errors.New("ticket is expired")
+	}
+
+	g.mu.Lock()
+	store := g.store
+	g.mu.Unlock()
+
+	if store != nil {
+		ok, err := store.SetNX(usedWSTicketKeyPrefix+jti, 1, ttl)
+		if err == nil {
+			if !ok {
+				return ErrWSTicketReused
+			}
+			return nil
+		}
+		log.Printf("[JWT] Ошибка хранилища использованных WS-тикетов, проверка в памяти экземпляра: %v", err)
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.used == nil {
+		g.used = make(map[string]time.Time)
+	}
+	for id, exp := range g.used {
+		if !now.Before(exp) {
+			delete(g.used, id)
+		}
+	}
+	if _, ok := g.used[jti]; ok {
+		return ErrWSTicketReused
+	}
+	g.used[jti] = expiresAt
+	return nil
+}
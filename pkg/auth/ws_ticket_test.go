@@ -0,0 +1,92 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourusername/trivia-api/internal/domain/entity"
+)
+
+// memoryTicketStore - хранилище использованных тикетов, общее для нескольких сервисов
+type memoryTicketStore struct {
+	keys map[string]bool
+	err  error
+}
+
+func (m *memoryTicketStore) SetNX(key string, _ interface{}, _ time.Duration) (bool, error) {
+	if m.err != nil {
+		return false, m.err
+	}
+	if m.keys[key] {
+		return false, nil
+	}
+	m.keys[key] = true
+	return true, nil
+}
+
+func newTicketTestService() *JWTService {
+	return &JWTService{secretKey: "test-secret", wsTicketExpiry: 30 * time.Second}
+}
+
+func TestParseWSTicket_SecondUseRejected(t *testing.T) {
+	s := newTicketTestService()
+	ticket, err := s.GenerateWSTicket(7, "user@example.com", entity.RolePlayer)
+	require.NoError(t, err)
+
+	claims, err := s.ParseWSTicket(ticket)
+	require.NoError(t, err)
+	assert.Equal(t, uint(7), claims.UserID)
+	assert.NotEmpty(t, claims.ID, "тикет содержит jti")
+
+	_, err = s.ParseWSTicket(ticket)
+	assert.ErrorIs(t, err, ErrWSTicketReused)
+
+	// Новый тикет того же пользователя принимается
+	other, err := s.GenerateWSTicket(7, "user@example.com", entity.RolePlayer)
+	require.NoError(t, err)
+	_, err = s.ParseWSTicket(other)
+	require.NoError(t, err)
+}
+
+func TestParseWSTicket_SharedStoreRejectsReuseOnOtherInstance(t *testing.T) {
+	store := &memoryTicketStore{keys: make(map[string]bool)}
+	first, second := newTicketTestService(), newTicketTestService()
+	first.SetWSTicketStore(store)
+	second.SetWSTicketStore(store)
+
+	ticket, err := first.GenerateWSTicket(7, "user@example.com", entity.RolePlayer)
+	require.NoError(t, err)
+	_, err = first.ParseWSTicket(ticket)
+	require.NoError(t, err)
+	_, err = second.ParseWSTicket(ticket)
+	assert.ErrorIs(t, err, ErrWSTicketReused)
+}
+
+func TestParseWSTicket_StoreErrorFallsBackToMemory(t *testing.T) {
+	s := newTicketTestService()
+	s.SetWSTicketStore(&memoryTicketStore{err: errors.New("redis unavailable")})
+
+	ticket, err := s.GenerateWSTicket(7, "user@example.com", entity.RolePlayer)
+	require.NoError(t, err)
+	_, err = s.ParseWSTicket(ticket)
+	require.NoError(t, err)
+	_, err = s.ParseWSTicket(ticket)
+	assert.ErrorIs(t, err, ErrWSTicketReused)
+}
+
+func TestParseWSTicket_CarriesAdminRole(t *testing.T) {
+	s := newTicketTestService()
+	ticket, err := s.GenerateWSTicket(7, "admin@example.com", entity.RoleAdmin)
+	require.NoError(t, err)
+
+	claims, err := s.ParseWSTicket(ticket)
+	require.NoError(t, err)
+	assert.True(t, claims.IsAdmin(), "администратор определяется по роли, как в RequireAuth")
+
+	// Пользователь с ID=1 остается администратором и без роли в тикете
+	assert.True(t, (&JWTCustomClaims{UserID: 1, Role: entity.RolePlayer}).IsAdmin())
+	assert.False(t, (&JWTCustomClaims{UserID: 7, Role: entity.RoleModerator}).IsAdmin())
+}
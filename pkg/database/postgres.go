@@ -3,18 +3,28 @@ package database
 import (
 	"database/sql"
 	"fmt"
+	"log"
 	"time"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 
+	"github.com/yourusername/trivia-api/internal/config"
 	"github.com/yourusername/trivia-api/internal/domain/entity"
 )
 
-// NewPostgresDB создает новое подключение к PostgreSQL
-func NewPostgresDB(dsn string) (*gorm.DB, error) {
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+// Параметры пула соединений по умолчанию
+const (
+	DefaultMaxOpenConns    = 25
+	DefaultMaxIdleConns    = 10
+	DefaultConnMaxLifetime = time.Hour
+)
+
+// NewPostgresDB создает новое подключение к PostgreSQL и настраивает пул соединений.
+// Нулевые параметры пула заменяются значениями по умолчанию.
+func NewPostgresDB(cfg config.DatabaseConfig) (*gorm.DB, error) {
+	db, err := gorm.Open(postgres.Open(cfg.PostgresConnectionString()), &gorm.Config{
 		Logger: logger.Default.LogMode(logger.Info),
 	})
 	if err != nil {
@@ -27,15 +37,32 @@ func NewPostgresDB(dsn string) (*gorm.DB, error) {
 		return nil, fmt.Errorf("failed to get sql.DB: %w", err)
 	}
 
+	maxOpen := cfg.MaxOpenConns
+	if maxOpen <= 0 {
+		maxOpen = DefaultMaxOpenConns
+	}
+	maxIdle := cfg.MaxIdleConns
+	if maxIdle <= 0 {
+		maxIdle = DefaultMaxIdleConns
+	}
+	if maxIdle > maxOpen {
+		maxIdle = maxOpen
+	}
+	lifetime := time.Duration(cfg.ConnMaxLifetimeMin) * time.Minute
+	if lifetime <= 0 {
+		lifetime = DefaultConnMaxLifetime
+	}
+
 	// Максимальное число открытых соединений
-	sqlDB.SetMaxOpenConns(25)
+	sqlDB.SetMaxOpenConns(maxOpen)
 
 	// Максимальное число простаивающих соединений
-	sqlDB.SetMaxIdleConns(10)
+	sqlDB.SetMaxIdleConns(maxIdle)
 
 	// Максимальное время жизни соединения
-	sqlDB.SetConnMaxLifetime(time.Hour)
+	sqlDB.SetConnMaxLifetime(lifetime)
 
+	log.Printf("[Database] Пул соединений: maxOpen=%d, maxIdle=%d, maxLifetime=%v", maxOpen, maxIdle, lifetime)
 	return db, nil
 }
 
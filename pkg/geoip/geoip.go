@@ -0,0 +1,56 @@
+package geoip
+
+import (
+	"log"
+	"net"
+	"strings"
+)
+
+// Location - местоположение, определенное по IP-адресу
+type Location struct {
+	// Код страны ISO 3166-1 alpha-2 в верхнем регистре (например, "DE")
+	Country string `json:"country,omitempty"`
+	City    string `json:"city,omitempty"`
+}
+
+// IsZero сообщает, что местоположение не определено
+func (l Location) IsZero() bool {
+	return l.Country == "" && l.City == ""
+}
+
+// Provider определяет местоположение по IP-адресу. Реализация вызывается
+// при входе и подключении по WebSocket, поэтому должна отвечать быстро -
+// например, читать локальную базу MaxMind GeoLite2, а не ходить в сеть.
+type Provider interface {
+	Lookup(ip net.IP) (Location, error)
+}
+
+// NoopProvider - провайдер по умолчанию: местоположение не определяется
+type NoopProvider struct{}
+
+// Lookup всегда возвращает пустое местоположение
+func (NoopProvider) Lookup(net.IP) (Location, error) {
+	return Location{}, nil
+}
+
+// Locate определяет местоположение адреса через provider. Пустой или
+// некорректный адрес, адреса локальных сетей и ошибки провайдера дают пустое
+// местоположение: обогащение данных не должно мешать входу и подключению.
+func Locate(provider Provider, ip string) Location {
+	if provider == nil {
+		return Location{}
+	}
+	addr := net.ParseIP(strings.TrimSpace(ip))
+	if addr == nil || addr.IsLoopback() || addr.IsPrivate() || addr.IsUnspecified() || addr.IsLinkLocalUnicast() {
+		return Location{}
+	}
+
+	location, err := provider.Lookup(addr)
+	if err != nil {
+		log.Printf("[GeoIP] Не удалось определить местоположение %s: %v", ip, err)
+		return Location{}
+	}
+	location.Country = strings.ToUpper(strings.TrimSpace(location.Country))
+	location.City = strings.TrimSpace(location.City)
+	return location
+}
@@ -0,0 +1,37 @@
+package geoip
+
+import (
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// staticProvider возвращает заданное местоположение и считает вызовы
+type staticProvider struct {
+	location Location
+	err      error
+	calls    int
+}
+
+func (p *staticProvider) Lookup(net.IP) (Location, error) {
+	p.calls++
+	return p.location, p.err
+}
+
+func TestLocate(t *testing.T) {
+	provider := &staticProvider{location: Location{Country: " de ", City: "Berlin "}}
+
+	assert.Equal(t, Location{Country: "DE", City: "Berlin"}, Locate(provider, "203.0.113.7"))
+	assert.Equal(t, Location{Country: "DE", City: "Berlin"}, Locate(provider, "2001:db8::1"))
+
+	for _, ip := range []string{"", "not-an-ip", "127.0.0.1", "10.1.2.3", "192.168.0.5", "::1", "fe80::1"} {
+		assert.True(t, Locate(provider, ip).IsZero(), ip)
+	}
+	assert.Equal(t, 2, provider.calls, "локальные и некорректные адреса не передаются провайдеру")
+
+	assert.True(t, Locate(nil, "203.0.113.7").IsZero())
+	assert.True(t, Locate(NoopProvider{}, "203.0.113.7").IsZero())
+	assert.True(t, Locate(&staticProvider{err: errors.New("database closed")}, "203.0.113.7").IsZero())
+}
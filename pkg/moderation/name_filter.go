@@ -0,0 +1,117 @@
+package moderation
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// ErrNameNotAllowed возвращается, если имя содержит запрещенное слово
+var ErrNameNotAllowed = errors.New("name is not allowed")
+
+// BlockedNameError описывает отклоненное имя. Запрещенное слово в текст
+// ошибки не попадает, чтобы не подсказывать, как обойти фильтр.
+type BlockedNameError struct {
+	Name string
+}
+
+func (e *BlockedNameError) Error() string {
+	return fmt.Sprintf("name %q is not allowed: it contains inappropriate language", e.Name)
+}
+
+// Unwrap позволяет проверять ошибку через errors.Is(err, ErrNameNotAllowed)
+func (e *BlockedNameError) Unwrap() error {
+	return ErrNameNotAllowed
+}
+
+// leetReplacements сопоставляет символы, которыми обычно маскируют буквы,
+// а также похожие кириллические буквы - с латинскими
+var leetReplacements = map[rune]rune{
+	'0': 'o', '1': 'i', '3': 'e', '4': 'a', '5': 's', '7': 't', '8': 'b', '9': 'g',
+	'@': 'a', '$': 's', '!': 'i', '|': 'l', '+': 't', '€': 'e',
+	'а': 'a', 'в': 'b', 'е': 'e', 'ё': 'e', 'к': 'k', 'м': 'm', 'н': 'h',
+	'о': 'o', 'р': 'p', 'с': 'c', 'т': 't', 'у': 'y', 'х': 'x',
+}
+
+// Normalize приводит строку к виду для сравнения: нижний регистр, замена
+// leetspeak и похожих букв, удаление разделителей (пробелов, точек, "_" и т.п.)
+func Normalize(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	for _, r := range strings.ToLower(s) {
+		if replacement, ok := leetReplacements[r]; ok {
+			r = replacement
+		}
+		if unicode.IsLetter(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// collapseRepeats схлопывает повторяющиеся подряд буквы ("baaad" -> "bad")
+func collapseRepeats(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	var prev rune
+	for i, r := range s {
+		if i > 0 && r == prev {
+			continue
+		}
+		b.WriteRune(r)
+		prev = r
+	}
+	return b.String()
+}
+
+// NameFilter проверяет отображаемые имена по списку запрещенных слов.
+// Слова из allowlist вырезаются из имени перед проверкой, что позволяет
+// разрешать безобидные слова, содержащие запрещенные подстроки.
+type NameFilter struct {
+	blocklist []string
+	allowlist []string
+}
+
+// NewNameFilter создает фильтр. Пустые и дублирующиеся после нормализации слова пропускаются.
+func NewNameFilter(blocklist, allowlist []string) *NameFilter {
+	return &NameFilter{
+		blocklist: normalizeTerms(blocklist),
+		allowlist: normalizeTerms(allowlist),
+	}
+}
+
+func normalizeTerms(terms []string) []string {
+	seen := make(map[string]bool, len(terms))
+	normalized := make([]string, 0, len(terms))
+	for _, term := range terms {
+		n := Normalize(term)
+		if n == "" || seen[n] {
+			continue
+		}
+		seen[n] = true
+		normalized = append(normalized, n)
+	}
+	return normalized
+}
+
+// Check возвращает *BlockedNameError, если имя содержит запрещенное слово.
+// Nil-фильтр ничего не проверяет, что соответствует выключенной модерации.
+func (f *NameFilter) Check(name string) error {
+	if f == nil || len(f.blocklist) == 0 {
+		return nil
+	}
+
+	normalized := Normalize(name)
+	for _, allowed := range f.allowlist {
+		normalized = strings.ReplaceAll(normalized, allowed, " ")
+	}
+	collapsed := collapseRepeats(normalized)
+
+	for _, term := range f.blocklist {
+		if strings.Contains(normalized, term) || strings.Contains(collapsed, collapseRepeats(term)) {
+			return &BlockedNameError{Name: name}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package moderation
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalize(t *testing.T) {
+	assert.Equal(t, "badword", Normalize("B@dW0rd"))
+	assert.Equal(t, "badword", Normalize("b.a.d_w-o r d"))
+	assert.Equal(t, "leet", Normalize("l33t"))
+	// Кириллические буквы, похожие на латинские
+	assert.Equal(t, "copo", Normalize("сОрО"))
+}
+
+func TestNameFilter_BlocksEvasionVariants(t *testing.T) {
+	filter := NewNameFilter([]string{"badword"}, nil)
+
+	for _, name := range []string{
+		"badword",
+		"BadWord",
+		"b@dw0rd",
+		"b4dw0rd",
+		"b.a.d.w.o.r.d",
+		"b_a_d w-o-r-d",
+		"baaaadwooord",
+		"xXbadwordXx",
+		"bаdwоrd", // кириллические "а" и "о"
+	} {
+		err := filter.Check(name)
+		assert.ErrorIs(t, err, ErrNameNotAllowed, name)
+
+		var blocked *BlockedNameError
+		if assert.True(t, errors.As(err, &blocked), name) {
+			assert.Equal(t, name, blocked.Name)
+		}
+	}
+
+	for _, name := range []string{"goodplayer", "bad_player", "wordsmith"} {
+		assert.NoError(t, filter.Check(name), name)
+	}
+}
+
+func TestNameFilter_Allowlist(t *testing.T) {
+	filter := NewNameFilter([]string{"ass"}, []string{"class", "assassin"})
+
+	assert.NoError(t, filter.Check("ClassicPlayer"))
+	assert.NoError(t, filter.Check("the_assassin"))
+	assert.Error(t, filter.Check("a$$hat"))
+	// Разрешенное слово не прикрывает запрещенное в другой части имени
+	assert.Error(t, filter.Check("class_ass"))
+}
+
+func TestNameFilter_Disabled(t *testing.T) {
+	var filter *NameFilter
+	assert.NoError(t, filter.Check("badword"))
+	assert.NoError(t, NewNameFilter(nil, nil).Check("badword"))
+}
@@ -8,10 +8,20 @@ const API_BASE_URL = process.env.NEXT_PUBLIC_API_URL || 'http://localhost:8080';
 // Типы HTTP методов
 export type HttpMethod = 'GET' | 'POST' | 'PUT' | 'DELETE' | 'PATCH';
 
+// Тело ответа сервера об ошибке
+interface ApiErrorBody {
+  code: string;
+  message: string;
+  details?: unknown;
+  request_id?: string;
+}
+
 // Интерфейс для ошибок API
 export interface ApiError {
-  error: string;
-  error_type?: string;
+  error: string;       // Сообщение для пользователя (message из ответа сервера)
+  error_type?: string; // Машиночитаемый код (code из ответа сервера)
+  details?: unknown;
+  request_id?: string;
   status: number;
 }
 
@@ -76,12 +86,15 @@ export async function request<T>(
     
     // Если ответ не OK (статус не 2xx), выбрасываем ошибку
     if (!response.ok) {
-      const errorData = await response.json().catch(() => ({ 
-        error: 'Ошибка при обработке ответа сервера' 
+      const errorData: Partial<ApiErrorBody> = await response.json().catch(() => ({
+        message: 'Ошибка при обработке ответа сервера'
       }));
-      
+
       throw {
-        ...errorData,
+        error: errorData.message || 'Ошибка при обработке ответа сервера',
+        error_type: errorData.code,
+        details: errorData.details,
+        request_id: errorData.request_id,
         status: response.status,
       } as ApiError;
     }